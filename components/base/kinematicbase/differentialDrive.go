@@ -273,6 +273,17 @@ func CollisionGeometry(cfg *referenceframe.LinkConfig) ([]spatialmath.Geometry,
 		} else {
 			r += geoCfg.R
 		}
+	case spatialmath.MeshType:
+		// the mesh is posed at the offset, and its furthest point from the origin is one of its vertices. With so low a density, the
+		// points of the mesh are only its vertices
+		mesh, err := geoCfg.ParseConfig()
+		if err != nil {
+			return nil, err
+		}
+		r = 0
+		for _, pt := range mesh.ToPoints(math.SmallestNonzeroFloat64) {
+			r = math.Max(r, pt.Norm())
+		}
 	case spatialmath.PointType:
 	default:
 		return nil, spatialmath.ErrGeometryTypeUnsupported
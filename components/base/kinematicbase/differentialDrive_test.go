@@ -191,3 +191,22 @@ func TestNewValidRegionCapsule(t *testing.T) {
 	test.That(t, err, test.ShouldBeNil)
 	test.That(t, col, test.ShouldBeFalse)
 }
+
+func TestMeshCollisionGeometry(t *testing.T) {
+	cfg := &referenceframe.LinkConfig{
+		ID: "base",
+		Geometry: &spatialmath.GeometryConfig{
+			Type:              spatialmath.MeshType,
+			MeshFile:          utils.ResolveFile("spatialmath/data/cube.stl"),
+			MeshScale:         100,
+			TranslationOffset: r3.Vector{X: 100},
+		},
+	}
+	geometries, err := CollisionGeometry(cfg)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, len(geometries), test.ShouldEqual, 1)
+	// the corners of the cube furthest from the origin are at x = 150
+	sphere, err := spatialmath.NewSphere(spatialmath.NewZeroPose(), r3.Vector{150, 50, 50}.Norm(), "")
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, geometries[0].AlmostEqual(sphere), test.ShouldBeTrue)
+}
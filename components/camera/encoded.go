@@ -0,0 +1,576 @@
+package camera
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/aler9/gortsplib/v2/pkg/codecs/h264"
+	"github.com/aler9/gortsplib/v2/pkg/codecs/h265"
+	"github.com/pion/webrtc/v3"
+	"github.com/pkg/errors"
+	"go.uber.org/multierr"
+	viamutils "go.viam.com/utils"
+)
+
+// maxGOPFrames is the most frames since the last keyframe that are kept around for decoding.
+const maxGOPFrames = 600
+
+// frameDecodeTimeout is how long a decoder can take to decode a frame before it is restarted.
+const frameDecodeTimeout = 5 * time.Second
+
+// encodedStreamBufferSize is how many frames an encoded video stream can fall behind before frames
+// are dropped until the next keyframe.
+const encodedStreamBufferSize = 64
+
+var errEncodedVideoClosed = errors.New("encoded video closed")
+
+// An EncodedFrame is one frame (access unit) of H.264 or H.265 video: its NAL units, without start
+// codes, and when it should be presented relative to the start of the video.
+type EncodedFrame struct {
+	NALUs [][]byte
+	PTS   time.Duration
+}
+
+// An EncodedVideoSource is a source of already compressed video that can be streamed without being
+// decoded and encoded again.
+type EncodedVideoSource interface {
+	// EncodedVideoCodec returns the MIME type of the video, either webrtc.MimeTypeH264 or
+	// webrtc.MimeTypeH265.
+	EncodedVideoCodec() string
+	// StreamEncoded returns a stream of the video starting from the next keyframe.
+	StreamEncoded(ctx context.Context) (EncodedVideoStream, error)
+}
+
+// An EncodedVideoStream streams frames from an EncodedVideoSource.
+type EncodedVideoStream interface {
+	// Next returns the next frame. If the stream falls too far behind, frames are dropped until the
+	// next keyframe.
+	Next(ctx context.Context) (EncodedFrame, error)
+	Close(ctx context.Context) error
+}
+
+// EncodedVideoSourceFrom returns the encoded video source behind a video source, if it has one.
+func EncodedVideoSourceFrom(src VideoSource) (EncodedVideoSource, bool) {
+	switch s := src.(type) {
+	case EncodedVideoSource:
+		return s, true
+	case *sourceBasedCamera:
+		return EncodedVideoSourceFrom(s.VideoSource)
+	case *videoSource:
+		enc, ok := s.actualSource.(EncodedVideoSource)
+		return enc, ok
+	default:
+		return nil, false
+	}
+}
+
+// A videoDecoder decodes video one frame at a time, keeping what it needs to decode the frames
+// that follow.
+type videoDecoder interface {
+	// Decode decodes the next frame, given as NAL units in Annex B format, and returns its image.
+	Decode(ctx context.Context, frame []byte) (image.Image, error)
+	Close() error
+}
+
+// An EncodedVideoRelay passes H.264 or H.265 video from a camera on to any number of encoded video
+// streams. It is also a gostream.VideoReader that only decodes frames, using ffmpeg, once an image
+// is read. The decoder is then kept running, so that each frame is decoded at most once.
+type EncodedVideoRelay struct {
+	codec      string
+	newDecoder func(codec string, width, height int) (videoDecoder, error)
+
+	mu          sync.Mutex
+	closed      bool
+	paramSets   map[uint8][]byte
+	gop         []EncodedFrame
+	gopSeq      int
+	gotKeyframe chan struct{}
+	streams     map[*encodedVideoStream]struct{}
+	decoder     videoDecoder
+
+	// decodeMu serializes decoding. It guards the fields below, and is taken before mu.
+	decodeMu sync.Mutex
+	// decoded is the last decoded image, which was the frame at decodedLen of GOP decodedSeq.
+	decoded       image.Image
+	decodedSeq    int
+	decodedLen    int
+	width, height int
+}
+
+// NewEncodedVideoRelay returns a relay for video with the given MIME type, either
+// webrtc.MimeTypeH264 or webrtc.MimeTypeH265.
+func NewEncodedVideoRelay(codec string) (*EncodedVideoRelay, error) {
+	if codec != webrtc.MimeTypeH264 && codec != webrtc.MimeTypeH265 {
+		return nil, errors.Errorf("unsupported encoded video codec %q", codec)
+	}
+	return &EncodedVideoRelay{
+		codec:       codec,
+		newDecoder:  newFFMPEGDecoder,
+		paramSets:   map[uint8][]byte{},
+		gotKeyframe: make(chan struct{}),
+		streams:     map[*encodedVideoStream]struct{}{},
+	}, nil
+}
+
+// EncodedVideoCodec returns the MIME type of the video.
+func (r *EncodedVideoRelay) EncodedVideoCodec() string {
+	return r.codec
+}
+
+// naluType returns the type of a NAL unit.
+func (r *EncodedVideoRelay) naluType(nalu []byte) uint8 {
+	if r.codec == webrtc.MimeTypeH265 {
+		return (nalu[0] >> 1) & 0x3f
+	}
+	return nalu[0] & 0x1f
+}
+
+// paramSetTypes returns the types of the parameter set NAL units needed to decode the video, in the
+// order they must come in.
+func (r *EncodedVideoRelay) paramSetTypes() []uint8 {
+	if r.codec == webrtc.MimeTypeH265 {
+		return []uint8{uint8(h265.NALUType_VPS_NUT), uint8(h265.NALUType_SPS_NUT), uint8(h265.NALUType_PPS_NUT)}
+	}
+	return []uint8{uint8(h264.NALUTypeSPS), uint8(h264.NALUTypePPS)}
+}
+
+// isParamSet returns whether a NAL unit type is a parameter set.
+func (r *EncodedVideoRelay) isParamSet(typ uint8) bool {
+	for _, paramSetType := range r.paramSetTypes() {
+		if typ == paramSetType {
+			return true
+		}
+	}
+	return false
+}
+
+// isSPS returns whether a NAL unit type is a sequence parameter set.
+func (r *EncodedVideoRelay) isSPS(typ uint8) bool {
+	if r.codec == webrtc.MimeTypeH265 {
+		return typ == uint8(h265.NALUType_SPS_NUT)
+	}
+	return typ == uint8(h264.NALUTypeSPS)
+}
+
+// isAUD returns whether a NAL unit type is an access unit delimiter, which carries no picture data.
+func (r *EncodedVideoRelay) isAUD(typ uint8) bool {
+	if r.codec == webrtc.MimeTypeH265 {
+		return typ == uint8(h265.NALUType_AUD_NUT)
+	}
+	return typ == uint8(h264.NALUTypeAccessUnitDelimiter)
+}
+
+// isKeyframe returns whether a NAL unit type starts a frame that can be decoded on its own.
+func (r *EncodedVideoRelay) isKeyframe(typ uint8) bool {
+	if r.codec == webrtc.MimeTypeH265 {
+		return typ >= uint8(h265.NALUType_BLA_W_LP) && typ <= uint8(h265.NALUType_RSV_IRAP_VCL23)
+	}
+	return typ == uint8(h264.NALUTypeIDR)
+}
+
+// SetParameterSets stores parameter sets that were sent out of band, such as in an SDP, so that
+// keyframes can carry them.
+func (r *EncodedVideoRelay) SetParameterSets(nalus ...[]byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, nalu := range nalus {
+		if len(nalu) > 0 {
+			r.paramSets[r.naluType(nalu)] = nalu
+		}
+	}
+}
+
+// Publish passes a frame on to every stream. Keyframes are sent with the latest parameter sets so
+// that decoding can start from any of them.
+func (r *EncodedVideoRelay) Publish(frame EncodedFrame) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return
+	}
+
+	keyframe := false
+	present := map[uint8]bool{}
+	for _, nalu := range frame.NALUs {
+		if len(nalu) == 0 {
+			continue
+		}
+		typ := r.naluType(nalu)
+		present[typ] = true
+		if r.isParamSet(typ) {
+			r.paramSets[typ] = nalu
+		}
+		if r.isKeyframe(typ) {
+			keyframe = true
+		}
+	}
+
+	if keyframe {
+		var missing [][]byte
+		for _, typ := range r.paramSetTypes() {
+			if nalu, ok := r.paramSets[typ]; ok && !present[typ] {
+				missing = append(missing, nalu)
+			}
+		}
+		if len(missing) > 0 {
+			frame.NALUs = append(missing, frame.NALUs...)
+		}
+		r.gop = nil
+		r.gopSeq++
+		select {
+		case <-r.gotKeyframe:
+		default:
+			close(r.gotKeyframe)
+		}
+	}
+	if (keyframe || len(r.gop) > 0) && len(r.gop) < maxGOPFrames {
+		r.gop = append(r.gop, frame)
+	}
+
+	for stream := range r.streams {
+		stream.push(frame, keyframe)
+	}
+}
+
+// StreamEncoded returns a stream of the video starting from the next keyframe.
+func (r *EncodedVideoRelay) StreamEncoded(ctx context.Context) (EncodedVideoStream, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return nil, errEncodedVideoClosed
+	}
+	stream := &encodedVideoStream{
+		relay:        r,
+		frames:       make(chan EncodedFrame, encodedStreamBufferSize),
+		done:         make(chan struct{}),
+		waitKeyframe: true,
+	}
+	r.streams[stream] = struct{}{}
+	return stream, nil
+}
+
+// Read decodes and returns the latest frame, waiting for the first keyframe if needed. Only the
+// frames published since the last read are decoded, unless there has been a keyframe since.
+func (r *EncodedVideoRelay) Read(ctx context.Context) (image.Image, func(), error) {
+	r.mu.Lock()
+	gotKeyframe := r.gotKeyframe
+	r.mu.Unlock()
+	select {
+	case <-ctx.Done():
+		return nil, nil, ctx.Err()
+	case <-gotKeyframe:
+	}
+
+	r.decodeMu.Lock()
+	defer r.decodeMu.Unlock()
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return nil, nil, errEncodedVideoClosed
+	}
+	if r.decoded != nil && r.decodedSeq == r.gopSeq && r.decodedLen == len(r.gop) {
+		img := r.decoded
+		r.mu.Unlock()
+		return img, func() {}, nil
+	}
+	gop := r.gop
+	gopSeq := r.gopSeq
+	decoder := r.decoder
+	r.mu.Unlock()
+	if len(gop) == 0 {
+		return nil, nil, errors.New("no decodable frame yet")
+	}
+
+	// carry on from the last decoded frame if it was in the same GOP
+	frames := gop
+	if decoder != nil && r.decodedSeq == gopSeq {
+		frames = gop[r.decodedLen:]
+	}
+	var img image.Image
+	for i, frame := range frames {
+		annexB, width, height, err := r.annexB(frame)
+		if err != nil {
+			return nil, nil, err
+		}
+		if i == 0 && len(frames) == len(gop) {
+			if width == 0 || height == 0 {
+				return nil, nil, errors.New("no sequence parameter set found in video")
+			}
+			if decoder != nil && (width != r.width || height != r.height) {
+				r.stopDecoder(decoder)
+				decoder = nil
+			}
+			r.width, r.height = width, height
+		}
+		if decoder == nil {
+			if decoder, err = r.startDecoder(); err != nil {
+				return nil, nil, err
+			}
+		}
+		decodeCtx, cancel := context.WithTimeout(ctx, frameDecodeTimeout)
+		img, err = decoder.Decode(decodeCtx, annexB)
+		cancel()
+		if err != nil {
+			// the decoder may have missed the frame, so later frames are decoded from the next keyframe
+			r.stopDecoder(decoder)
+			r.decoded = nil
+			return nil, nil, err
+		}
+		r.decoded, r.decodedSeq, r.decodedLen = img, gopSeq, len(gop)-len(frames)+i+1
+	}
+	return img, func() {}, nil
+}
+
+// annexB returns the NAL units of a frame in Annex B format, without access unit delimiters, and
+// the size of the video if the frame has a sequence parameter set.
+func (r *EncodedVideoRelay) annexB(frame EncodedFrame) ([]byte, int, int, error) {
+	var annexB []byte
+	var width, height int
+	for _, nalu := range frame.NALUs {
+		if len(nalu) == 0 || r.isAUD(r.naluType(nalu)) {
+			continue
+		}
+		if width == 0 && r.isSPS(r.naluType(nalu)) {
+			var err error
+			width, height, err = r.dimensions(nalu)
+			if err != nil {
+				return nil, 0, 0, errors.Wrap(err, "could not read video dimensions")
+			}
+		}
+		annexB = append(annexB, 0, 0, 0, 1)
+		annexB = append(annexB, nalu...)
+	}
+	return annexB, width, height, nil
+}
+
+// startDecoder starts a decoder for video of the current size. It assumes decodeMu is being held.
+func (r *EncodedVideoRelay) startDecoder() (videoDecoder, error) {
+	decoder, err := r.newDecoder(r.codec, r.width, r.height)
+	if err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return nil, multierr.Combine(errEncodedVideoClosed, decoder.Close())
+	}
+	r.decoder = decoder
+	return decoder, nil
+}
+
+// stopDecoder closes a decoder, and forgets it if it is the current one.
+func (r *EncodedVideoRelay) stopDecoder(decoder videoDecoder) {
+	r.mu.Lock()
+	if r.decoder == decoder {
+		r.decoder = nil
+	}
+	r.mu.Unlock()
+	viamutils.UncheckedError(decoder.Close())
+}
+
+// dimensions returns the width and height of the video from its sequence parameter set.
+func (r *EncodedVideoRelay) dimensions(sps []byte) (int, int, error) {
+	if r.codec == webrtc.MimeTypeH265 {
+		var parsed h265.SPS
+		if err := parsed.Unmarshal(sps); err != nil {
+			return 0, 0, err
+		}
+		return parsed.Width(), parsed.Height(), nil
+	}
+	var parsed h264.SPS
+	if err := parsed.Unmarshal(sps); err != nil {
+		return 0, 0, err
+	}
+	return parsed.Width(), parsed.Height(), nil
+}
+
+// Close ends every stream of the relay and stops its decoder.
+func (r *EncodedVideoRelay) Close(ctx context.Context) error {
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return nil
+	}
+	r.closed = true
+	for stream := range r.streams {
+		close(stream.done)
+	}
+	r.streams = nil
+	select {
+	case <-r.gotKeyframe:
+	default:
+		close(r.gotKeyframe)
+	}
+	decoder := r.decoder
+	r.decoder = nil
+	r.mu.Unlock()
+	if decoder == nil {
+		return nil
+	}
+	return decoder.Close()
+}
+
+// An ffmpegDecoder decodes H.264 or H.265 video with a long-running ffmpeg process, which is fed
+// one frame at a time and keeps its decoding state between frames.
+type ffmpegDecoder struct {
+	width, height int
+	cmd           *exec.Cmd
+	stdin         io.WriteCloser
+	stderr        bytes.Buffer
+	frames        chan []byte
+	done          chan struct{}
+	// aud is an access unit delimiter, written after each frame so that ffmpeg does not wait for
+	// the start of the next frame before decoding it.
+	aud       []byte
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// newFFMPEGDecoder starts an ffmpeg process that decodes video of the given codec and size.
+func newFFMPEGDecoder(codec string, width, height int) (videoDecoder, error) {
+	if _, err := exec.LookPath("ffmpeg"); err != nil {
+		return nil, errors.Wrap(err, "ffmpeg is needed to decode images from encoded video")
+	}
+	format := "h264"
+	aud := []byte{0, 0, 0, 1, 0x09, 0xf0}
+	if codec == webrtc.MimeTypeH265 {
+		format = "hevc"
+		aud = []byte{0, 0, 0, 1, 0x46, 0x01, 0x50}
+	}
+	//nolint:gosec
+	cmd := exec.Command("ffmpeg",
+		"-hide_banner", "-loglevel", "error",
+		"-probesize", "32", "-analyzeduration", "0",
+		"-fflags", "nobuffer", "-flags", "low_delay", "-threads", "1",
+		"-f", format, "-i", "pipe:0",
+		"-vsync", "passthrough", "-f", "rawvideo", "-pix_fmt", "rgba", "pipe:1")
+	d := &ffmpegDecoder{
+		width:  width,
+		height: height,
+		cmd:    cmd,
+		frames: make(chan []byte),
+		done:   make(chan struct{}),
+		aud:    aud,
+	}
+	cmd.Stderr = &d.stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	d.stdin = stdin
+	if err := cmd.Start(); err != nil {
+		return nil, errors.Wrap(err, "could not start ffmpeg")
+	}
+	viamutils.PanicCapturingGo(func() {
+		defer close(d.frames)
+		for {
+			frame := make([]byte, width*height*4)
+			if _, err := io.ReadFull(stdout, frame); err != nil {
+				return
+			}
+			select {
+			case d.frames <- frame:
+			case <-d.done:
+				return
+			}
+		}
+	})
+	return d, nil
+}
+
+// Decode writes a frame to ffmpeg and waits for its image.
+func (d *ffmpegDecoder) Decode(ctx context.Context, frame []byte) (image.Image, error) {
+	if _, err := d.stdin.Write(append(frame, d.aud...)); err != nil {
+		return nil, d.decodeError(err)
+	}
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-d.done:
+		return nil, errEncodedVideoClosed
+	case pix, ok := <-d.frames:
+		if !ok {
+			return nil, d.decodeError(errors.New("ffmpeg exited"))
+		}
+		img := image.NewNRGBA(image.Rect(0, 0, d.width, d.height))
+		img.Pix = pix
+		return img, nil
+	}
+}
+
+// decodeError stops ffmpeg and adds what it reported to an error.
+func (d *ffmpegDecoder) decodeError(err error) error {
+	viamutils.UncheckedError(d.Close())
+	return errors.Wrapf(err, "could not decode video: %s", d.stderr.String())
+}
+
+// Close stops ffmpeg.
+func (d *ffmpegDecoder) Close() error {
+	d.closeOnce.Do(func() {
+		close(d.done)
+		d.closeErr = d.stdin.Close()
+		if err := d.cmd.Process.Kill(); err != nil && !errors.Is(err, os.ErrProcessDone) {
+			d.closeErr = multierr.Combine(d.closeErr, err)
+		}
+		// ffmpeg was killed, so it exiting with an error is expected
+		viamutils.UncheckedError(d.cmd.Wait())
+	})
+	return d.closeErr
+}
+
+// encodedVideoStream is a stream of frames from an EncodedVideoRelay.
+type encodedVideoStream struct {
+	relay  *EncodedVideoRelay
+	frames chan EncodedFrame
+	done   chan struct{}
+	// waitKeyframe is whether the stream is dropping frames until the next keyframe. It is guarded by
+	// the relay's lock.
+	waitKeyframe bool
+}
+
+// push queues a frame, dropping frames until the next keyframe if the stream is too far behind. It
+// assumes the relay's lock is being held.
+func (s *encodedVideoStream) push(frame EncodedFrame, keyframe bool) {
+	if s.waitKeyframe {
+		if !keyframe {
+			return
+		}
+		s.waitKeyframe = false
+	}
+	select {
+	case s.frames <- frame:
+	default:
+		s.waitKeyframe = true
+	}
+}
+
+func (s *encodedVideoStream) Next(ctx context.Context) (EncodedFrame, error) {
+	select {
+	case <-ctx.Done():
+		return EncodedFrame{}, ctx.Err()
+	case <-s.done:
+		return EncodedFrame{}, errEncodedVideoClosed
+	case frame := <-s.frames:
+		return frame, nil
+	}
+}
+
+func (s *encodedVideoStream) Close(ctx context.Context) error {
+	s.relay.mu.Lock()
+	defer s.relay.mu.Unlock()
+	if _, ok := s.relay.streams[s]; ok {
+		delete(s.relay.streams, s)
+		close(s.done)
+	}
+	return nil
+}
@@ -0,0 +1,197 @@
+package camera
+
+import (
+	"context"
+	"image"
+	"testing"
+	"time"
+
+	"github.com/pion/webrtc/v3"
+	"github.com/pkg/errors"
+	"github.com/viamrobotics/gostream"
+	"go.viam.com/test"
+
+	"go.viam.com/rdk/resource"
+)
+
+var (
+	// testSPS is the sequence parameter set of 352x288 H.264 video.
+	testSPS = []byte{
+		0x67, 0x64, 0x00, 0x0c, 0xac, 0x3b, 0x50, 0xb0,
+		0x4b, 0x42, 0x00, 0x00, 0x03, 0x00, 0x02, 0x00,
+		0x00, 0x03, 0x00, 0x3d, 0x08,
+	}
+	testPPS   = []byte{0x68, 0xee, 0x3c, 0x80}
+	testIDR   = []byte{0x65, 0x88, 0x84}
+	testSlice = []byte{0x41, 0x9a, 0x21}
+)
+
+func TestEncodedVideoRelay(t *testing.T) {
+	_, err := NewEncodedVideoRelay("video/VP8")
+	test.That(t, err, test.ShouldNotBeNil)
+
+	t.Run("streams start at a keyframe with its parameter sets", func(t *testing.T) {
+		relay, err := NewEncodedVideoRelay(webrtc.MimeTypeH264)
+		test.That(t, err, test.ShouldBeNil)
+		relay.SetParameterSets(testSPS, testPPS)
+
+		stream, err := relay.StreamEncoded(context.Background())
+		test.That(t, err, test.ShouldBeNil)
+		relay.Publish(EncodedFrame{NALUs: [][]byte{testSlice}, PTS: time.Millisecond})
+		relay.Publish(EncodedFrame{NALUs: [][]byte{testIDR}, PTS: 2 * time.Millisecond})
+		relay.Publish(EncodedFrame{NALUs: [][]byte{testSlice}, PTS: 3 * time.Millisecond})
+
+		frame, err := stream.Next(context.Background())
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, frame.NALUs, test.ShouldResemble, [][]byte{testSPS, testPPS, testIDR})
+		test.That(t, frame.PTS, test.ShouldEqual, 2*time.Millisecond)
+		frame, err = stream.Next(context.Background())
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, frame.NALUs, test.ShouldResemble, [][]byte{testSlice})
+
+		test.That(t, stream.Close(context.Background()), test.ShouldBeNil)
+		_, err = stream.Next(context.Background())
+		test.That(t, err, test.ShouldBeError, errEncodedVideoClosed)
+		test.That(t, relay.Close(context.Background()), test.ShouldBeNil)
+	})
+
+	t.Run("streams that fall behind wait for the next keyframe", func(t *testing.T) {
+		relay, err := NewEncodedVideoRelay(webrtc.MimeTypeH264)
+		test.That(t, err, test.ShouldBeNil)
+		stream, err := relay.StreamEncoded(context.Background())
+		test.That(t, err, test.ShouldBeNil)
+
+		relay.Publish(EncodedFrame{NALUs: [][]byte{testSPS, testPPS, testIDR}})
+		for i := 0; i < encodedStreamBufferSize+1; i++ {
+			relay.Publish(EncodedFrame{NALUs: [][]byte{testSlice}})
+		}
+		for i := 0; i < encodedStreamBufferSize; i++ {
+			_, err := stream.Next(context.Background())
+			test.That(t, err, test.ShouldBeNil)
+		}
+
+		relay.Publish(EncodedFrame{NALUs: [][]byte{testSlice}, PTS: time.Second})
+		relay.Publish(EncodedFrame{NALUs: [][]byte{testIDR}, PTS: 2 * time.Second})
+		frame, err := stream.Next(context.Background())
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, frame.PTS, test.ShouldEqual, 2*time.Second)
+
+		test.That(t, relay.Close(context.Background()), test.ShouldBeNil)
+		_, err = stream.Next(context.Background())
+		test.That(t, err, test.ShouldBeError, errEncodedVideoClosed)
+		_, err = relay.StreamEncoded(context.Background())
+		test.That(t, err, test.ShouldBeError, errEncodedVideoClosed)
+	})
+
+	t.Run("read decodes each frame once with one decoder", func(t *testing.T) {
+		relay, err := NewEncodedVideoRelay(webrtc.MimeTypeH264)
+		test.That(t, err, test.ShouldBeNil)
+		var decoders []*fakeVideoDecoder
+		relay.newDecoder = func(codec string, width, height int) (videoDecoder, error) {
+			decoder := &fakeVideoDecoder{width: width, height: height}
+			decoders = append(decoders, decoder)
+			return decoder, nil
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		_, _, err = relay.Read(ctx)
+		test.That(t, err, test.ShouldBeError, context.DeadlineExceeded)
+
+		relay.Publish(EncodedFrame{NALUs: [][]byte{testSPS, testPPS, testIDR}})
+		relay.Publish(EncodedFrame{NALUs: [][]byte{testSlice}})
+		img, _, err := relay.Read(context.Background())
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, img.Bounds(), test.ShouldResemble, image.Rect(0, 0, 352, 288))
+		test.That(t, decoders, test.ShouldHaveLength, 1)
+		test.That(t, decoders[0].frames, test.ShouldResemble, [][]byte{
+			annexBOf(testSPS, testPPS, testIDR),
+			annexBOf(testSlice),
+		})
+
+		_, _, err = relay.Read(context.Background())
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, decoders[0].frames, test.ShouldHaveLength, 2)
+
+		relay.Publish(EncodedFrame{NALUs: [][]byte{testSlice}})
+		_, _, err = relay.Read(context.Background())
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, decoders, test.ShouldHaveLength, 1)
+		test.That(t, decoders[0].frames, test.ShouldHaveLength, 3)
+
+		// a new GOP of the same size goes to the same decoder
+		relay.Publish(EncodedFrame{NALUs: [][]byte{testIDR}})
+		_, _, err = relay.Read(context.Background())
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, decoders, test.ShouldHaveLength, 1)
+		test.That(t, decoders[0].frames[3], test.ShouldResemble, annexBOf(testSPS, testPPS, testIDR))
+
+		// after a failed decode, the decoder is restarted from the start of the GOP
+		decoders[0].err = errors.New("decode failed")
+		relay.Publish(EncodedFrame{NALUs: [][]byte{testSlice}})
+		_, _, err = relay.Read(context.Background())
+		test.That(t, err, test.ShouldBeError, decoders[0].err)
+		test.That(t, decoders[0].closed, test.ShouldBeTrue)
+		_, _, err = relay.Read(context.Background())
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, decoders, test.ShouldHaveLength, 2)
+		test.That(t, decoders[1].frames, test.ShouldResemble, [][]byte{
+			annexBOf(testSPS, testPPS, testIDR),
+			annexBOf(testSlice),
+		})
+
+		test.That(t, relay.Close(context.Background()), test.ShouldBeNil)
+		test.That(t, decoders[1].closed, test.ShouldBeTrue)
+	})
+}
+
+// annexBOf returns NAL units in Annex B format.
+func annexBOf(nalus ...[]byte) []byte {
+	var annexB []byte
+	for _, nalu := range nalus {
+		annexB = append(annexB, 0, 0, 0, 1)
+		annexB = append(annexB, nalu...)
+	}
+	return annexB
+}
+
+// fakeVideoDecoder records the frames it is given and returns blank images.
+type fakeVideoDecoder struct {
+	width, height int
+	frames        [][]byte
+	err           error
+	closed        bool
+}
+
+func (d *fakeVideoDecoder) Decode(ctx context.Context, frame []byte) (image.Image, error) {
+	if d.err != nil {
+		return nil, d.err
+	}
+	d.frames = append(d.frames, frame)
+	return image.NewNRGBA(image.Rect(0, 0, d.width, d.height)), nil
+}
+
+func (d *fakeVideoDecoder) Close() error {
+	d.closed = true
+	return nil
+}
+
+func TestEncodedVideoSourceFrom(t *testing.T) {
+	relay, err := NewEncodedVideoRelay(webrtc.MimeTypeH264)
+	test.That(t, err, test.ShouldBeNil)
+	src, err := NewVideoSourceFromReader(context.Background(), relay, nil, ColorStream)
+	test.That(t, err, test.ShouldBeNil)
+	cam := FromVideoSource(resource.NewName(API, "cam"), src)
+
+	enc, ok := EncodedVideoSourceFrom(cam)
+	test.That(t, ok, test.ShouldBeTrue)
+	test.That(t, enc.EncodedVideoCodec(), test.ShouldEqual, webrtc.MimeTypeH264)
+
+	plain, err := NewVideoSourceFromReader(context.Background(), gostream.VideoReaderFunc(
+		func(ctx context.Context) (image.Image, func(), error) {
+			return image.NewNRGBA(image.Rect(0, 0, 1, 1)), func() {}, nil
+		}), nil, ColorStream)
+	test.That(t, err, test.ShouldBeNil)
+	_, ok = EncodedVideoSourceFrom(FromVideoSource(resource.NewName(API, "plain"), plain))
+	test.That(t, ok, test.ShouldBeFalse)
+}
@@ -0,0 +1,83 @@
+package ffmpeg
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"time"
+
+	"github.com/aler9/gortsplib/v2/pkg/codecs/h264"
+	"github.com/aler9/gortsplib/v2/pkg/codecs/h265"
+	"github.com/pion/webrtc/v3"
+
+	"go.viam.com/rdk/components/camera"
+)
+
+// maxNALUSize is the largest NAL unit that can be read from ffmpeg.
+const maxNALUSize = 8 << 20
+
+var startCode = []byte{0, 0, 1}
+
+// splitAnnexB is a bufio.SplitFunc that splits an H.264 or H.265 byte stream in Annex B format into
+// NAL units.
+func splitAnnexB(data []byte, atEOF bool) (int, []byte, error) {
+	start := bytes.Index(data, startCode)
+	if start < 0 {
+		if atEOF {
+			return len(data), nil, nil
+		}
+		return 0, nil, nil
+	}
+	start += len(startCode)
+	end := bytes.Index(data[start:], startCode)
+	if end < 0 {
+		if atEOF {
+			return len(data), data[start:], nil
+		}
+		return 0, nil, nil
+	}
+	// NAL units never end in a zero byte, so any are the start of a four byte start code
+	return start + end, bytes.TrimRight(data[start:start+end], "\x00"), nil
+}
+
+// isAccessUnitDelimiter returns whether a NAL unit marks the start of a new frame.
+func isAccessUnitDelimiter(codec string, nalu []byte) bool {
+	if codec == webrtc.MimeTypeH265 {
+		return h265.NALUType((nalu[0]>>1)&0x3f) == h265.NALUType_AUD_NUT
+	}
+	return h264.NALUType(nalu[0]&0x1f) == h264.NALUTypeAccessUnitDelimiter
+}
+
+// relayAnnexB publishes each frame of the H.264 or H.265 byte stream read from r to the relay until
+// r is closed. Frames are told apart by the access unit delimiters ffmpeg inserts.
+func relayAnnexB(r io.Reader, relay *camera.EncodedVideoRelay) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxNALUSize)
+	scanner.Split(splitAnnexB)
+
+	var start time.Time
+	var nalus [][]byte
+	publish := func() {
+		if len(nalus) == 0 {
+			return
+		}
+		if start.IsZero() {
+			start = time.Now()
+		}
+		relay.Publish(camera.EncodedFrame{NALUs: nalus, PTS: time.Since(start)})
+		nalus = nil
+	}
+	for scanner.Scan() {
+		nalu := scanner.Bytes()
+		if len(nalu) == 0 {
+			continue
+		}
+		if isAccessUnitDelimiter(relay.EncodedVideoCodec(), nalu) {
+			publish()
+			continue
+		}
+		// the scanner reuses its buffer
+		nalus = append(nalus, append([]byte(nil), nalu...))
+	}
+	publish()
+}
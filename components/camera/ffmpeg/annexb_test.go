@@ -0,0 +1,44 @@
+package ffmpeg
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/pion/webrtc/v3"
+	"go.viam.com/test"
+
+	"go.viam.com/rdk/components/camera"
+)
+
+func TestRelayAnnexB(t *testing.T) {
+	aud := []byte{0x09, 0xf0}
+	sps := []byte{0x67, 0x42, 0x00, 0x0a}
+	pps := []byte{0x68, 0xce, 0x38, 0x80}
+	idr := []byte{0x65, 0x88, 0x00, 0x10}
+	slice := []byte{0x41, 0x9a, 0x00, 0x01}
+
+	// start codes can be three or four bytes long
+	var stream bytes.Buffer
+	for _, nalu := range [][]byte{aud, sps, pps, idr, aud, slice, aud, slice} {
+		if nalu[0] == aud[0] {
+			stream.Write([]byte{0, 0, 0, 1})
+		} else {
+			stream.Write([]byte{0, 0, 1})
+		}
+		stream.Write(nalu)
+	}
+
+	relay, err := camera.NewEncodedVideoRelay(webrtc.MimeTypeH264)
+	test.That(t, err, test.ShouldBeNil)
+	defer relay.Close(context.Background())
+	frames, err := relay.StreamEncoded(context.Background())
+	test.That(t, err, test.ShouldBeNil)
+
+	relayAnnexB(&stream, relay)
+	for _, expected := range [][][]byte{{sps, pps, idr}, {slice}, {slice}} {
+		frame, err := frames.Next(context.Background())
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, frame.NALUs, test.ShouldResemble, expected)
+	}
+}
@@ -3,7 +3,6 @@ package ffmpeg
 
 import (
 	"context"
-	"errors"
 	"image"
 	"image/jpeg"
 	"io"
@@ -12,6 +11,8 @@ import (
 	"sync/atomic"
 
 	"github.com/edaniels/golog"
+	"github.com/pion/webrtc/v3"
+	"github.com/pkg/errors"
 	ffmpeg "github.com/u2takey/ffmpeg-go"
 	"github.com/viamrobotics/gostream"
 	"go.uber.org/zap"
@@ -25,7 +26,6 @@ import (
 
 // Config is the attribute struct for ffmpeg cameras.
 type Config struct {
-	resource.TriviallyValidateConfig
 	CameraParameters     *transform.PinholeCameraIntrinsics `json:"intrinsic_parameters,omitempty"`
 	DistortionParameters *transform.BrownConrady            `json:"distortion_parameters,omitempty"`
 	Debug                bool                               `json:"debug,omitempty"`
@@ -33,6 +33,32 @@ type Config struct {
 	InputKWArgs          map[string]interface{}             `json:"input_kw_args,omitempty"`
 	Filters              []FilterConfig                     `json:"filters,omitempty"`
 	OutputKWArgs         map[string]interface{}             `json:"output_kw_args,omitempty"`
+	// Passthrough is the codec, h264 or h265, of video to pass on without decoding it, so that it
+	// can be streamed as is. Images are only decoded when they are read.
+	Passthrough string `json:"passthrough,omitempty"`
+}
+
+// Validate ensures all parts of the config are valid.
+func (conf *Config) Validate(path string) ([]string, error) {
+	if _, ok := passthroughCodecs[conf.Passthrough]; !ok && conf.Passthrough != "" {
+		return nil, viamutils.NewConfigValidationError(path,
+			errors.Errorf("passthrough must be h264 or h265, not %q", conf.Passthrough))
+	}
+	return nil, nil
+}
+
+// passthroughCodec describes how to pass on video of a codec.
+type passthroughCodec struct {
+	mimeType string
+	// format is the ffmpeg muxer of the codec.
+	format string
+	// bitstreamFilter has ffmpeg insert access unit delimiters, so that frames can be told apart.
+	bitstreamFilter string
+}
+
+var passthroughCodecs = map[string]passthroughCodec{
+	"h264": {webrtc.MimeTypeH264, "h264", "h264_metadata=aud=insert"},
+	"h265": {webrtc.MimeTypeH265, "hevc", "hevc_metadata=aud=insert"},
 }
 
 // FilterConfig is a struct to used to configure ffmpeg filters.
@@ -66,6 +92,23 @@ type ffmpegCamera struct {
 	activeBackgroundWorkers sync.WaitGroup
 	inClose                 func() error
 	outClose                func() error
+	relay                   *camera.EncodedVideoRelay
+}
+
+// passthroughCamera is an ffmpeg camera that passes on H.264 or H.265 video, which can be streamed
+// without being decoded.
+type passthroughCamera struct {
+	*ffmpegCamera
+}
+
+// EncodedVideoCodec returns the MIME type of the video.
+func (fc *passthroughCamera) EncodedVideoCodec() string {
+	return fc.relay.EncodedVideoCodec()
+}
+
+// StreamEncoded returns a stream of the video starting from the next keyframe.
+func (fc *passthroughCamera) StreamEncoded(ctx context.Context) (camera.EncodedVideoStream, error) {
+	return fc.relay.StreamEncoded(ctx)
 }
 
 // NewFFMPEGCamera instantiates a new camera which leverages ffmpeg to handle a variety of potential video types.
@@ -79,8 +122,17 @@ func NewFFMPEGCamera(ctx context.Context, conf *Config, logger golog.Logger) (ca
 	for key, value := range conf.OutputKWArgs {
 		outArgs[key] = value
 	}
-	outArgs["update"] = 1        // always interpret the filename as just a filename, not a pattern
-	outArgs["format"] = "image2" // select image file muxer, used to write video frames to image files
+	passthrough, isPassthrough := passthroughCodecs[conf.Passthrough]
+	if isPassthrough {
+		if _, ok := outArgs["c:v"]; !ok {
+			outArgs["c:v"] = "copy" // pass the video on without decoding it
+		}
+		outArgs["format"] = passthrough.format
+		outArgs["bsf:v"] = passthrough.bitstreamFilter
+	} else {
+		outArgs["update"] = 1        // always interpret the filename as just a filename, not a pattern
+		outArgs["format"] = "image2" // select image file muxer, used to write video frames to image files
+	}
 
 	// instantiate camera with cancellable context that will be applied to all spawned processes
 	cancelableCtx, cancel := context.WithCancel(context.Background())
@@ -120,6 +172,27 @@ func NewFFMPEGCamera(ctx context.Context, conf *Config, logger golog.Logger) (ca
 		ffCam.activeBackgroundWorkers.Done()
 	})
 
+	if isPassthrough {
+		relay, err := camera.NewEncodedVideoRelay(passthrough.mimeType)
+		if err != nil {
+			return nil, err
+		}
+		ffCam.relay = relay
+		ffCam.VideoReader = relay
+
+		// launch thread to pass on frames from the pipe
+		ffCam.activeBackgroundWorkers.Add(1)
+		viamutils.ManagedGo(func() {
+			relayAnnexB(in, relay)
+		}, ffCam.activeBackgroundWorkers.Done)
+
+		return camera.NewVideoSourceFromReader(
+			ctx,
+			&passthroughCamera{ffCam},
+			&transform.PinholeCameraModel{PinholeCameraIntrinsics: conf.CameraParameters},
+			camera.ColorStream)
+	}
+
 	// launch thread to consume images from the pipe and store the latest in shared memory
 	gotFirstFrame := make(chan struct{})
 	var latestFrame atomic.Pointer[image.Image]
@@ -171,5 +244,8 @@ func (fc *ffmpegCamera) Close(ctx context.Context) error {
 	viamutils.UncheckedError(fc.inClose())
 	viamutils.UncheckedError(fc.outClose())
 	fc.activeBackgroundWorkers.Wait()
+	if fc.relay != nil {
+		viamutils.UncheckedError(fc.relay.Close(ctx))
+	}
 	return nil
 }
@@ -35,3 +35,13 @@ func TestFFMPEGNotFound(t *testing.T) {
 	_, err := NewFFMPEGCamera(context.Background(), nil, nil)
 	test.That(t, err.Error(), test.ShouldContainSubstring, "not found")
 }
+
+func TestFFMPEGConfig(t *testing.T) {
+	conf := &Config{VideoPath: "rtsp://example.com", Passthrough: "vp8"}
+	_, err := conf.Validate("path")
+	test.That(t, err.Error(), test.ShouldContainSubstring, "passthrough must be h264 or h265")
+
+	conf.Passthrough = "h265"
+	_, err = conf.Validate("path")
+	test.That(t, err, test.ShouldBeNil)
+}
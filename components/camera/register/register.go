@@ -6,8 +6,10 @@ import (
 	_ "go.viam.com/rdk/components/camera/align"
 	_ "go.viam.com/rdk/components/camera/fake"
 	_ "go.viam.com/rdk/components/camera/ffmpeg"
+	_ "go.viam.com/rdk/components/camera/replaylocal"
 	_ "go.viam.com/rdk/components/camera/replaypcd"
 	_ "go.viam.com/rdk/components/camera/rtsp"
+	_ "go.viam.com/rdk/components/camera/stereo"
 	_ "go.viam.com/rdk/components/camera/transformpipeline"
 	_ "go.viam.com/rdk/components/camera/ultrasonic"
 	_ "go.viam.com/rdk/components/camera/velodyne"
@@ -0,0 +1,117 @@
+// Package replaylocal implements a replay camera that plays back images and point clouds from local
+// data capture files.
+package replaylocal
+
+import (
+	"bytes"
+	"context"
+	"image"
+
+	"github.com/edaniels/golog"
+	"github.com/pkg/errors"
+	"github.com/viamrobotics/gostream"
+
+	"go.viam.com/rdk/components/camera"
+	"go.viam.com/rdk/pointcloud"
+	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/rimage"
+	"go.viam.com/rdk/services/datamanager/datacapture"
+	"go.viam.com/rdk/utils"
+)
+
+// model is the model of a local replay camera.
+var model = resource.DefaultModelFamily.WithModel("replay_local")
+
+// Config describes how to configure the local replay camera.
+type Config = datacapture.ReplayConfig
+
+func init() {
+	resource.RegisterComponent(camera.API, model, resource.Registration[camera.Camera, *Config]{
+		Constructor: newReplayCamera,
+	})
+}
+
+// replaySource plays back the captured ReadImage and NextPointCloud data of a camera.
+type replaySource struct {
+	images      *datacapture.Player
+	pointClouds *datacapture.Player
+}
+
+// pcdReplaySource is a replaySource that also plays back point clouds, so that the camera only
+// reports point cloud support when there is point cloud data.
+type pcdReplaySource struct {
+	*replaySource
+}
+
+func newReplayCamera(ctx context.Context, deps resource.Dependencies, conf resource.Config, logger golog.Logger) (camera.Camera, error) {
+	newConf, err := resource.NativeConfig[*Config](conf)
+	if err != nil {
+		return nil, err
+	}
+
+	src := &replaySource{}
+	src.images, err = datacapture.NewPlayer(deps, newConf, camera.API, "ReadImage")
+	if err != nil {
+		return nil, err
+	}
+	src.pointClouds, err = datacapture.NewPlayer(deps, newConf, camera.API, "NextPointCloud")
+	if err != nil {
+		return nil, err
+	}
+	if src.images.Len() == 0 && src.pointClouds.Len() == 0 {
+		logger.Warnf("no images or point clouds captured from %q found in %s", newConf.Source, newConf.Directory)
+	}
+
+	var reader gostream.VideoReader = src
+	if src.pointClouds.Len() > 0 {
+		reader = &pcdReplaySource{src}
+	}
+	vs, err := camera.NewVideoSourceFromReader(ctx, reader, nil, camera.ColorStream)
+	if err != nil {
+		return nil, err
+	}
+	return camera.FromVideoSource(conf.ResourceName(), vs), nil
+}
+
+// Read returns the next captured image.
+func (rs *replaySource) Read(ctx context.Context) (image.Image, func(), error) {
+	data, md, err := rs.images.Next(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := datacapture.AddReplayMetadata(ctx, data); err != nil {
+		return nil, nil, err
+	}
+
+	// images are captured as raw RGBA unless another mime type was requested
+	mimeType := datacapture.MethodParameter(md, "mime_type")
+	if mimeType == "" {
+		mimeType = utils.MimeTypeRawRGBA
+	}
+	img, err := rimage.DecodeImage(ctx, data.GetBinary(), mimeType)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "could not decode captured image")
+	}
+	return img, func() {}, nil
+}
+
+// NextPointCloud returns the next captured point cloud.
+func (rs *pcdReplaySource) NextPointCloud(ctx context.Context) (pointcloud.PointCloud, error) {
+	data, _, err := rs.pointClouds.Next(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := datacapture.AddReplayMetadata(ctx, data); err != nil {
+		return nil, err
+	}
+	pc, err := pointcloud.ReadPCD(bytes.NewReader(data.GetBinary()))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not decode captured point cloud")
+	}
+	return pc, nil
+}
+
+// Close does nothing since all of the data is read when the camera is built.
+func (rs *replaySource) Close(ctx context.Context) error {
+	return nil
+}
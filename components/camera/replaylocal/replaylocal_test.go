@@ -0,0 +1,84 @@
+package replaylocal
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"testing"
+
+	"github.com/edaniels/golog"
+	"github.com/golang/geo/r3"
+	v1 "go.viam.com/api/app/datasync/v1"
+	"go.viam.com/test"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"go.viam.com/rdk/components/camera"
+	"go.viam.com/rdk/pointcloud"
+	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/rimage"
+	"go.viam.com/rdk/services/datamanager/datacapture"
+	"go.viam.com/rdk/utils"
+)
+
+// binaryReading returns a reading of binary data captured now.
+func binaryReading(data []byte) *v1.SensorData {
+	now := timestamppb.Now()
+	return &v1.SensorData{
+		Metadata: &v1.SensorMetadata{TimeRequested: now, TimeReceived: now},
+		Data:     &v1.SensorData_Binary{Binary: data},
+	}
+}
+
+func TestReplayCamera(t *testing.T) {
+	ctx := context.Background()
+	logger := golog.NewTestLogger(t)
+	dir := t.TempDir()
+
+	img := image.NewNRGBA(image.Rect(0, 0, 4, 2))
+	imgBytes, err := rimage.EncodeImage(ctx, img, utils.MimeTypePNG)
+	test.That(t, err, test.ShouldBeNil)
+	md, err := datacapture.BuildCaptureMetadata(camera.API, "cam1", "ReadImage", map[string]string{"mime_type": utils.MimeTypePNG}, nil)
+	test.That(t, err, test.ShouldBeNil)
+	datacapture.WriteTestFile(t, dir, "image", md, binaryReading(imgBytes))
+
+	conf := resource.Config{
+		Name:                "replay",
+		ConvertedAttributes: &Config{Directory: dir, Source: "cam1"},
+	}
+	cam, err := newReplayCamera(ctx, nil, conf, logger)
+	test.That(t, err, test.ShouldBeNil)
+	props, err := cam.Properties(ctx)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, props.SupportsPCD, test.ShouldBeFalse)
+
+	replayed, release, err := camera.ReadImage(ctx, cam)
+	test.That(t, err, test.ShouldBeNil)
+	release()
+	test.That(t, replayed.Bounds(), test.ShouldResemble, img.Bounds())
+	_, _, err = camera.ReadImage(ctx, cam)
+	test.That(t, err, test.ShouldBeError, datacapture.ErrEndOfDataset)
+	test.That(t, cam.Close(ctx), test.ShouldBeNil)
+
+	pc := pointcloud.New()
+	test.That(t, pc.Set(r3.Vector{X: 1, Y: 2, Z: 3}, nil), test.ShouldBeNil)
+	var buf bytes.Buffer
+	test.That(t, pointcloud.ToPCD(pc, &buf, pointcloud.PCDBinary), test.ShouldBeNil)
+	md, err = datacapture.BuildCaptureMetadata(camera.API, "cam1", "NextPointCloud", nil, nil)
+	test.That(t, err, test.ShouldBeNil)
+	datacapture.WriteTestFile(t, dir, "pointcloud", md, binaryReading(buf.Bytes()))
+
+	cam, err = newReplayCamera(ctx, nil, conf, logger)
+	test.That(t, err, test.ShouldBeNil)
+	defer func() {
+		test.That(t, cam.Close(ctx), test.ShouldBeNil)
+	}()
+	props, err = cam.Properties(ctx)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, props.SupportsPCD, test.ShouldBeTrue)
+
+	replayedPC, err := cam.NextPointCloud(ctx)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, replayedPC.Size(), test.ShouldEqual, 1)
+	_, ok := replayedPC.At(1, 2, 3)
+	test.That(t, ok, test.ShouldBeTrue)
+}
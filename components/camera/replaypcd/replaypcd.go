@@ -23,6 +23,7 @@ import (
 	"go.viam.com/rdk/pointcloud"
 	"go.viam.com/rdk/resource"
 	"go.viam.com/rdk/rimage/transform"
+	"go.viam.com/rdk/services/datamanager/datacapture"
 	"go.viam.com/rdk/utils/contextutils"
 )
 
@@ -55,6 +56,7 @@ type Config struct {
 	OrganizationID string       `json:"organization_id,omitempty"`
 	Interval       TimeInterval `json:"time_interval,omitempty"`
 	BatchSize      *uint64      `json:"batch_size,omitempty"`
+	Clock          string       `json:"clock,omitempty"`
 }
 
 // TimeInterval holds the start and end time used to filter data.
@@ -122,7 +124,11 @@ func (cfg *Config) Validate(path string) ([]string, error) {
 		return nil, errors.Errorf("batch_size must be between 1 and %d", maxCacheSize)
 	}
 
-	return []string{cloud.InternalServiceName.String()}, nil
+	deps := []string{cloud.InternalServiceName.String()}
+	if cfg.Clock != "" {
+		deps = append(deps, cfg.Clock)
+	}
+	return deps, nil
 }
 
 // pcdCamera is a camera model that plays back pre-captured point cloud data.
@@ -139,6 +145,7 @@ type pcdCamera struct {
 	filter   *datapb.Filter
 
 	cache []*cacheEntry
+	clock datacapture.ReplayClock
 
 	mu     sync.RWMutex
 	closed bool
@@ -160,6 +167,27 @@ func newPCDCamera(ctx context.Context, deps resource.Dependencies, conf resource
 
 // NextPointCloud returns the next point cloud retrieved from cloud storage based on the applied filter.
 func (replay *pcdCamera) NextPointCloud(ctx context.Context) (pointcloud.PointCloud, error) {
+	data, clock, err := replay.nextData(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// The lock is not held while waiting for the data to be due, so that a paused or slow replay
+	// clock does not block Close and Reconfigure.
+	if err := waitForClock(ctx, clock, data.timeRequested); err != nil {
+		return nil, err
+	}
+
+	if err := addGRPCMetadata(ctx, data.timeRequested, data.timeReceived); err != nil {
+		return nil, err
+	}
+
+	return data.pc, nil
+}
+
+// nextData retrieves the next point cloud from the cache, or from cloud storage if the cache is
+// empty, along with the replay clock it is played back on.
+func (replay *pcdCamera) nextData(ctx context.Context) (*cacheEntry, datacapture.ReplayClock, error) {
 	// First acquire the lock, so that it's safe to populate the cache and/or retrieve and
 	// remove the next data point from the cache. Note that if multiple threads call
 	// NextPointCloud concurrently, they may get data out-of-order, since there's no guarantee
@@ -167,13 +195,14 @@ func (replay *pcdCamera) NextPointCloud(ctx context.Context) (pointcloud.PointCl
 	replay.mu.Lock()
 	defer replay.mu.Unlock()
 	if replay.closed {
-		return nil, errors.New("session closed")
+		return nil, nil, errors.New("session closed")
 	}
 
 	// Retrieve next cached data and remove from cache, if no data remains in the cache, download a
 	// new batch
 	if len(replay.cache) != 0 {
-		return replay.getDataFromCache(ctx)
+		data, err := replay.getDataFromCache()
+		return data, replay.clock, err
 	}
 
 	// Retrieve data from the cloud. If the batch size is > 1, only metadata is returned here, otherwise
@@ -189,11 +218,11 @@ func (replay *pcdCamera) NextPointCloud(ctx context.Context) (pointcloud.PointCl
 		IncludeBinary: replay.limit == 1,
 	})
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	if len(resp.GetData()) == 0 {
-		return nil, ErrEndOfDataset
+		return nil, nil, ErrEndOfDataset
 	}
 	replay.lastData = resp.GetLast()
 
@@ -202,14 +231,10 @@ func (replay *pcdCamera) NextPointCloud(ctx context.Context) (pointcloud.PointCl
 	if replay.limit == 1 {
 		pc, err := decodeResponseData(resp.GetData(), replay.logger)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
-		if err := addGRPCMetadata(ctx,
-			resp.GetData()[0].GetMetadata().GetTimeRequested(),
-			resp.GetData()[0].GetMetadata().GetTimeReceived()); err != nil {
-			return nil, err
-		}
-		return pc, nil
+		md := resp.GetData()[0].GetMetadata()
+		return &cacheEntry{pc: pc, timeRequested: md.GetTimeRequested(), timeReceived: md.GetTimeReceived()}, replay.clock, nil
 	}
 
 	// Otherwise if using a batch size > 1, use the metadata from BinaryDataByFilter to download
@@ -228,10 +253,11 @@ func (replay *pcdCamera) NextPointCloud(ctx context.Context) (pointcloud.PointCl
 	defer cancelTimeout()
 	replay.downloadBatch(ctxTimeout)
 	if ctxTimeout.Err() != nil {
-		return nil, errors.Wrap(ctxTimeout.Err(), "failed to download batch")
+		return nil, nil, errors.Wrap(ctxTimeout.Err(), "failed to download batch")
 	}
 
-	return replay.getDataFromCache(ctx)
+	data, err := replay.getDataFromCache()
+	return data, replay.clock, err
 }
 
 // downloadBatch iterates through the current cache, performing the download of the respective data in
@@ -268,7 +294,7 @@ func (replay *pcdCamera) downloadBatch(ctx context.Context) {
 
 // getDataFromCache retrieves the next cached data and removes it from the cache. It assumes the
 // write lock is being held.
-func (replay *pcdCamera) getDataFromCache(ctx context.Context) (pointcloud.PointCloud, error) {
+func (replay *pcdCamera) getDataFromCache() (*cacheEntry, error) {
 	// Grab the next cached data and update the cache immediately, even if there's an error,
 	// so we don't get stuck in a loop checking for and returning the same error.
 	data := replay.cache[0]
@@ -276,12 +302,17 @@ func (replay *pcdCamera) getDataFromCache(ctx context.Context) (pointcloud.Point
 	if data.err != nil {
 		return nil, errors.Wrap(data.err, "cache data contained an error")
 	}
+	return data, nil
+}
 
-	if err := addGRPCMetadata(ctx, data.timeRequested, data.timeReceived); err != nil {
-		return nil, err
+// waitForClock blocks until the replay clock, if there is one, reaches the time the data was
+// requested.
+func waitForClock(ctx context.Context, clock datacapture.ReplayClock, timeRequested *timestamppb.Timestamp) error {
+	if clock == nil || timeRequested == nil {
+		return nil
 	}
-
-	return data.pc, nil
+	clock.Register(timeRequested.AsTime())
+	return clock.WaitUntil(ctx, timeRequested.AsTime())
 }
 
 // addGRPCMetadata adds timestamps from the data response to the gRPC response header if one is
@@ -369,6 +400,14 @@ func (replay *pcdCamera) Reconfigure(ctx context.Context, deps resource.Dependen
 		}
 	}
 
+	replay.clock = nil
+	if replayCamConfig.Clock != "" {
+		replay.clock, err = datacapture.ReplayClockFromDependencies(deps, replayCamConfig.Clock)
+		if err != nil {
+			return err
+		}
+	}
+
 	if replayCamConfig.BatchSize == nil {
 		replay.limit = 1
 	} else {
@@ -1,17 +1,27 @@
 package replaypcd
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
 	"fmt"
 	"math/rand"
 	"testing"
+	"time"
 
+	"github.com/edaniels/golog"
 	"github.com/pkg/errors"
+	datapb "go.viam.com/api/app/data/v1"
 	"go.viam.com/test"
 	"go.viam.com/utils"
 	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
+	"go.viam.com/rdk/components/camera"
+	"go.viam.com/rdk/components/generic"
+	"go.viam.com/rdk/components/generic/replayclock"
 	"go.viam.com/rdk/internal/cloud"
+	"go.viam.com/rdk/pointcloud"
 	"go.viam.com/rdk/resource"
 	"go.viam.com/rdk/testutils"
 	"go.viam.com/rdk/utils/contextutils"
@@ -782,3 +792,68 @@ func TestReplayPCDReconfigure(t *testing.T) {
 
 	test.That(t, serverClose(), test.ShouldBeNil)
 }
+
+// clockDataClient is a data client holding a single point cloud, which was requested at the given time.
+type clockDataClient struct {
+	datapb.DataServiceClient
+	data          []byte
+	timeRequested *timestamppb.Timestamp
+}
+
+func (c *clockDataClient) BinaryDataByFilter(ctx context.Context, req *datapb.BinaryDataByFilterRequest, opts ...grpc.CallOption,
+) (*datapb.BinaryDataByFilterResponse, error) {
+	return &datapb.BinaryDataByFilterResponse{
+		Data: []*datapb.BinaryData{{Binary: c.data, Metadata: &datapb.BinaryMetadata{TimeRequested: c.timeRequested}}},
+		Last: "0",
+	}, nil
+}
+
+func TestReplayPCDClock(t *testing.T) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	pc := pointcloud.New()
+	test.That(t, pc.Set(pointcloud.NewVector(1, 2, 3), nil), test.ShouldBeNil)
+	test.That(t, pointcloud.ToPCD(pc, gz, pointcloud.PCDBinary), test.ShouldBeNil)
+	test.That(t, gz.Close(), test.ShouldBeNil)
+
+	start := time.Date(2000, 1, 1, 12, 0, 0, 0, time.UTC)
+	clock, err := replayclock.NewClock(generic.Named("clock"), &replayclock.Config{Start: start.Format(time.RFC3339), Paused: true})
+	test.That(t, err, test.ShouldBeNil)
+	replay := &pcdCamera{
+		Named:      camera.Named("replay").AsNamed(),
+		logger:     golog.NewTestLogger(t),
+		dataClient: &clockDataClient{data: buf.Bytes(), timeRequested: timestamppb.New(start.Add(time.Second))},
+		limit:      1,
+		filter:     &datapb.Filter{},
+		clock:      clock,
+	}
+
+	// a point cloud which is not yet due waits for the clock
+	ctx := context.Background()
+	waitCtx, cancel := context.WithCancel(ctx)
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := replay.NextPointCloud(waitCtx)
+		errCh <- err
+	}()
+	select {
+	case err := <-errCh:
+		t.Fatalf("point cloud returned before it was due: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// without blocking the camera from closing
+	closed := make(chan error, 1)
+	go func() {
+		closed <- replay.Close(ctx)
+	}()
+	select {
+	case err := <-closed:
+		test.That(t, err, test.ShouldBeNil)
+	case <-time.After(5 * time.Second):
+		t.Fatal("close blocked on a point cloud waiting for the clock")
+	}
+
+	cancel()
+	test.That(t, errors.Is(<-errCh, context.Canceled), test.ShouldBeTrue)
+}
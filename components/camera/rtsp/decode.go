@@ -12,8 +12,7 @@ import (
 
 type decoder func(pkt *rtp.Packet) (image.Image, error)
 
-func mjpegDecoding() (*format.MJPEG, decoder) {
-	var mjpeg format.MJPEG
+func mjpegDecoding(mjpeg *format.MJPEG) decoder {
 	// get the RTP->MJPEG decoder
 	rtpDec := mjpeg.CreateDecoder()
 	mjpegDecoder := func(pkt *rtp.Packet) (image.Image, error) {
@@ -23,5 +22,5 @@ func mjpegDecoding() (*format.MJPEG, decoder) {
 		}
 		return jpeg.Decode(bytes.NewReader(encoded))
 	}
-	return &mjpeg, mjpegDecoder
+	return mjpegDecoder
 }
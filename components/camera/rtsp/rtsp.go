@@ -12,10 +12,13 @@ import (
 
 	"github.com/aler9/gortsplib/v2"
 	"github.com/aler9/gortsplib/v2/pkg/base"
+	"github.com/aler9/gortsplib/v2/pkg/format"
 	"github.com/aler9/gortsplib/v2/pkg/liberrors"
+	"github.com/aler9/gortsplib/v2/pkg/media"
 	"github.com/aler9/gortsplib/v2/pkg/url"
 	"github.com/edaniels/golog"
 	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v3"
 	"github.com/pkg/errors"
 	"github.com/viamrobotics/gostream"
 	"go.uber.org/multierr"
@@ -28,6 +31,9 @@ import (
 
 var model = resource.DefaultModelFamily.WithModel("rtsp")
 
+// mimeTypeMJPEG is the MIME type of MJPEG video, which has to be decoded as it arrives.
+const mimeTypeMJPEG = "video/MJPEG"
+
 func init() {
 	resource.RegisterComponent(camera.API, model, resource.Registration[camera.Camera, *Config]{
 		Constructor: func(ctx context.Context, _ resource.Dependencies, conf resource.Config, logger golog.Logger) (camera.Camera, error) {
@@ -78,12 +84,36 @@ type rtspCamera struct {
 	gotFirstFrame           chan struct{}
 	latestFrame             atomic.Pointer[image.Image]
 	logger                  golog.Logger
+
+	// codec is the MIME type of the video being played, which is kept across reconnects.
+	codec string
+	// relay passes H.264 and H.265 video on as is, only decoding it when an image is read.
+	relay *camera.EncodedVideoRelay
+}
+
+// h26xRTSPCamera is an RTSP camera of H.264 or H.265 video, which can be streamed without being
+// decoded.
+type h26xRTSPCamera struct {
+	*rtspCamera
+}
+
+// EncodedVideoCodec returns the MIME type of the video.
+func (rc *h26xRTSPCamera) EncodedVideoCodec() string {
+	return rc.relay.EncodedVideoCodec()
+}
+
+// StreamEncoded returns a stream of the video starting from the next keyframe.
+func (rc *h26xRTSPCamera) StreamEncoded(ctx context.Context) (camera.EncodedVideoStream, error) {
+	return rc.relay.StreamEncoded(ctx)
 }
 
 // Close closes the camera. It always returns nil, but because of Close() interface, it needs to return an error.
 func (rc *rtspCamera) Close(ctx context.Context) error {
 	rc.cancelFunc()
 	rc.activeBackgroundWorkers.Wait()
+	if rc.relay != nil {
+		goutils.UncheckedError(rc.relay.Close(ctx))
+	}
 	if err := rc.client.Close(); err != nil && !errors.Is(err, liberrors.ErrClientTerminated{}) {
 		rc.logger.Infow("error while closing rtsp client:", "error", err)
 	}
@@ -149,34 +179,51 @@ func (rc *rtspCamera) reconnectClient() (err error) {
 	if err != nil {
 		return err
 	}
-	mjpegFormat, mjpegDecoder := mjpegDecoding()
 	tracks, baseURL, _, err := rc.client.Describe(rc.u)
 	if err != nil {
 		return err
 	}
-	track := tracks.FindFormat(&mjpegFormat)
-	if track == nil {
-		return errors.New("MJPEG track not found")
+	track, trackFormat, codec, err := findVideoTrack(tracks, rc.codec)
+	if err != nil {
+		return err
 	}
 	_, err = rc.client.Setup(track, baseURL, 0, 0)
 	if err != nil {
 		return err
 	}
-	// On packet retreival, turn it into an image, and store it in shared memory
-	rc.client.OnPacketRTP(track, mjpegFormat, func(pkt *rtp.Packet) {
-		img, err := mjpegDecoder(pkt)
-		if err != nil {
-			return
-		}
-		if img == nil {
-			return
+	rc.codec = codec
+	switch f := trackFormat.(type) {
+	case *format.MJPEG:
+		mjpegDecoder := mjpegDecoding(f)
+		// On packet retreival, turn it into an image, and store it in shared memory
+		rc.client.OnPacketRTP(track, f, func(pkt *rtp.Packet) {
+			img, err := mjpegDecoder(pkt)
+			if err != nil {
+				return
+			}
+			if img == nil {
+				return
+			}
+			rc.latestFrame.Store(&img)
+			if !rc.gotFirstFrameOnce {
+				rc.gotFirstFrameOnce = true
+				close(rc.gotFirstFrame)
+			}
+		})
+	case *format.H264:
+		if err := rc.setupRelay(); err != nil {
+			return err
 		}
-		rc.latestFrame.Store(&img)
-		if !rc.gotFirstFrameOnce {
-			rc.gotFirstFrameOnce = true
-			close(rc.gotFirstFrame)
+		rc.relay.SetParameterSets(f.SafeSPS(), f.SafePPS())
+		// On packet retreival, pass on whole frames without decoding them
+		rc.client.OnPacketRTP(track, f, rc.relayFrames(f.CreateDecoder().DecodeUntilMarker))
+	case *format.H265:
+		if err := rc.setupRelay(); err != nil {
+			return err
 		}
-	})
+		rc.relay.SetParameterSets(f.SafeVPS(), f.SafeSPS(), f.SafePPS())
+		rc.client.OnPacketRTP(track, f, rc.relayFrames(f.CreateDecoder().DecodeUntilMarker))
+	}
 	_, err = rc.client.Play(nil)
 	if err != nil {
 		return err
@@ -185,8 +232,61 @@ func (rc *rtspCamera) reconnectClient() (err error) {
 	return nil
 }
 
+// setupRelay makes the relay for H.264 and H.265 video the first time the client connects.
+func (rc *rtspCamera) setupRelay() error {
+	if rc.relay != nil {
+		return nil
+	}
+	relay, err := camera.NewEncodedVideoRelay(rc.codec)
+	if err != nil {
+		return err
+	}
+	rc.relay = relay
+	return nil
+}
+
+// relayFrames returns a handler of RTP packets that publishes each whole frame to the relay.
+func (rc *rtspCamera) relayFrames(decode func(pkt *rtp.Packet) ([][]byte, time.Duration, error)) func(pkt *rtp.Packet) {
+	return func(pkt *rtp.Packet) {
+		nalus, pts, err := decode(pkt)
+		if err != nil {
+			return
+		}
+		rc.relay.Publish(camera.EncodedFrame{NALUs: nalus, PTS: pts})
+	}
+}
+
+// findVideoTrack returns the video track to play. H.264 and H.265 video, which can be streamed
+// without being decoded, is preferred over MJPEG. Once a codec has been played, only video with that
+// codec is played.
+func findVideoTrack(tracks media.Medias, codec string) (*media.Media, format.Format, string, error) {
+	if codec == "" || codec == webrtc.MimeTypeH264 {
+		var h264Format *format.H264
+		if track := tracks.FindFormat(&h264Format); track != nil {
+			return track, h264Format, webrtc.MimeTypeH264, nil
+		}
+	}
+	if codec == "" || codec == webrtc.MimeTypeH265 {
+		var h265Format *format.H265
+		if track := tracks.FindFormat(&h265Format); track != nil {
+			return track, h265Format, webrtc.MimeTypeH265, nil
+		}
+	}
+	if codec == "" || codec == mimeTypeMJPEG {
+		var mjpegFormat *format.MJPEG
+		if track := tracks.FindFormat(&mjpegFormat); track != nil {
+			return track, mjpegFormat, mimeTypeMJPEG, nil
+		}
+	}
+	if codec == "" {
+		return nil, nil, "", errors.New("no H264, H265 or MJPEG track found")
+	}
+	return nil, nil, "", errors.Errorf("%s track not found", codec)
+}
+
 // NewRTSPCamera creates a camera client using RTSP given the server URL.
-// Right now, only supports servers that have MJPEG video tracks.
+// It supports servers that have H264, H265 or MJPEG video tracks. H264 and H265 video is streamed
+// as is and only decoded, with ffmpeg, when an image is read.
 func NewRTSPCamera(ctx context.Context, name resource.Name, conf *Config, logger golog.Logger) (camera.Camera, error) {
 	u, err := url.Parse(conf.Address)
 	if err != nil {
@@ -229,7 +329,12 @@ func NewRTSPCamera(ctx context.Context, name resource.Name, conf *Config, logger
 	rtspCam.cancelFunc = cancel
 	cameraModel := camera.NewPinholeModelWithBrownConradyDistortion(conf.IntrinsicParams, conf.DistortionParams)
 	rtspCam.clientReconnectBackgroundWorker()
-	src, err := camera.NewVideoSourceFromReader(ctx, rtspCam, &cameraModel, camera.ColorStream)
+	var camReader gostream.VideoReader = rtspCam
+	if rtspCam.relay != nil {
+		rtspCam.VideoReader = rtspCam.relay
+		camReader = &h26xRTSPCamera{rtspCam}
+	}
+	src, err := camera.NewVideoSourceFromReader(ctx, camReader, &cameraModel, camera.ColorStream)
 	if err != nil {
 		return nil, err
 	}
@@ -14,6 +14,7 @@ import (
 	"github.com/aler9/gortsplib/v2/pkg/headers"
 	"github.com/aler9/gortsplib/v2/pkg/media"
 	"github.com/edaniels/golog"
+	"github.com/pion/webrtc/v3"
 	"go.viam.com/test"
 	viamutils "go.viam.com/utils"
 
@@ -22,14 +23,32 @@ import (
 )
 
 func TestRTSPCamera(t *testing.T) {
+	t.Run("mjpeg", func(t *testing.T) {
+		rtspCam := testRTSPCamera(t, "32512", &format.MJPEG{})
+		_, ok := camera.EncodedVideoSourceFrom(rtspCam)
+		test.That(t, ok, test.ShouldBeFalse)
+		test.That(t, rtspCam.Close(context.Background()), test.ShouldBeNil)
+	})
+
+	t.Run("h264", func(t *testing.T) {
+		rtspCam := testRTSPCamera(t, "32513", &format.H264{PayloadTyp: 96, PacketizationMode: 1})
+		encoded, ok := camera.EncodedVideoSourceFrom(rtspCam)
+		test.That(t, ok, test.ShouldBeTrue)
+		test.That(t, encoded.EncodedVideoCodec(), test.ShouldEqual, webrtc.MimeTypeH264)
+		test.That(t, rtspCam.Close(context.Background()), test.ShouldBeNil)
+	})
+}
+
+// testRTSPCamera returns a camera connected to a simple server of video in the given format, which
+// expects specific requests in a certain order.
+func testRTSPCamera(t *testing.T, port string, videoFormat format.Format) camera.Camera {
+	t.Helper()
 	logger := golog.NewTestLogger(t)
 	host := "127.0.0.1"
-	port := "32512"
 	outputURL := fmt.Sprintf("rtsp://%s:%s/mystream", host, port)
-	// set up a simple server, which expects specific requests in a certain order
 	l, err := net.Listen("tcp", fmt.Sprintf("%s:%s", host, port))
 	test.That(t, err, test.ShouldBeNil)
-	defer l.Close()
+	t.Cleanup(func() { l.Close() })
 	viamutils.PanicCapturingGo(func() {
 		nconn, err := l.Accept()
 		test.That(t, err, test.ShouldBeNil)
@@ -58,12 +77,12 @@ func TestRTSPCamera(t *testing.T) {
 		test.That(t, err, test.ShouldBeNil)
 		test.That(t, req.Method, test.ShouldEqual, base.Describe)
 		logger.Debug("in describe")
-		testMJPEG := &media.Media{
+		testVideo := &media.Media{
 			Type:      media.TypeVideo,
 			Direction: media.DirectionRecvonly,
-			Formats:   []format.Format{&format.MJPEG{}},
+			Formats:   []format.Format{videoFormat},
 		}
-		medias := media.Medias{testMJPEG}
+		medias := media.Medias{testVideo}
 		medias.SetControls()
 		mediaBytes, err := medias.Marshal(false).Marshal()
 		test.That(t, err, test.ShouldBeNil)
@@ -123,9 +142,7 @@ func TestRTSPCamera(t *testing.T) {
 	defer timeoutCancel()
 	rtspCam, err := NewRTSPCamera(timeoutCtx, camera.Named("cam1"), rtspConf, logger)
 	test.That(t, err, test.ShouldBeNil)
-	// close everything
-	err = rtspCam.Close(context.Background())
-	test.That(t, err, test.ShouldBeNil)
+	return rtspCam
 }
 
 func TestRTSPConfig(t *testing.T) {
@@ -0,0 +1,134 @@
+package stereo
+
+import (
+	"image"
+	"image/draw"
+	"math"
+)
+
+// blockMatcher computes the disparity of a rectified stereo pair by comparing each block of pixels
+// of the left image to the blocks along the same row of the right image, with the sum of absolute
+// differences of their gray levels as the cost.
+type blockMatcher struct {
+	blockSize    int
+	maxDisparity int
+	// uniquenessPct is how much, in percent, the best match has to be better than any other match
+	// that is not next to it for the match to be kept.
+	uniquenessPct int
+}
+
+// noMatch is the disparity of pixels that could not be matched.
+const noMatch = -1
+
+// toGray converts an image to gray levels.
+func toGray(img image.Image) *image.Gray {
+	if gray, ok := img.(*image.Gray); ok && gray.Rect.Min == (image.Point{}) && gray.Stride == gray.Rect.Dx() {
+		return gray
+	}
+	bounds := img.Bounds()
+	gray := image.NewGray(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(gray, gray.Bounds(), img, bounds.Min, draw.Src)
+	return gray
+}
+
+// disparity returns the disparity in pixels of every pixel of the left image, with sub-pixel
+// precision, or noMatch for pixels without a match that is good enough. Both images must be the
+// same size.
+func (bm *blockMatcher) disparity(left, right *image.Gray) []float64 {
+	width, height := left.Rect.Dx(), left.Rect.Dy()
+	costs := make([]int, width*height)
+	integral := make([]int, (width+1)*(height+1))
+	best := make([]int, width*height)
+	bestCost := make([]int, width*height)
+	for i := range best {
+		best[i] = noMatch
+		bestCost[i] = math.MaxInt
+	}
+
+	// first find the disparity with the lowest cost
+	for d := 0; d <= bm.maxDisparity; d++ {
+		bm.costs(left, right, d, integral, costs)
+		for i, c := range costs {
+			if c >= 0 && c < bestCost[i] {
+				best[i], bestCost[i] = d, c
+			}
+		}
+	}
+
+	// then go through the costs again to reject ambiguous matches and to fit a parabola through
+	// the costs next to the best one
+	before := make([]int, width*height)
+	after := make([]int, width*height)
+	for i := range before {
+		before[i], after[i] = -1, -1
+	}
+	for d := 0; d <= bm.maxDisparity; d++ {
+		bm.costs(left, right, d, integral, costs)
+		for i, c := range costs {
+			if c < 0 || best[i] == noMatch {
+				continue
+			}
+			switch {
+			case d == best[i]-1:
+				before[i] = c
+			case d == best[i]+1:
+				after[i] = c
+			case d != best[i] && 100*c <= (100+bm.uniquenessPct)*bestCost[i]:
+				best[i] = noMatch
+			}
+		}
+	}
+
+	// pixels closer to the left edge than the largest disparity cannot be searched fully
+	minX := bm.maxDisparity + bm.blockSize/2
+	disparities := make([]float64, width*height)
+	for i, d := range best {
+		if d == noMatch || i%width < minX {
+			disparities[i] = noMatch
+			continue
+		}
+		disparities[i] = float64(d)
+		if before[i] < 0 || after[i] < 0 {
+			continue
+		}
+		if denom := before[i] - 2*bestCost[i] + after[i]; denom > 0 {
+			disparities[i] += float64(before[i]-after[i]) / float64(2*denom)
+		}
+	}
+	return disparities
+}
+
+// costs fills in the cost of matching every pixel of the left image to the pixel d columns to its
+// left in the right image, or -1 where the blocks do not fit in both images. The integral buffer
+// holds one more row and column than the images.
+func (bm *blockMatcher) costs(left, right *image.Gray, d int, integral, costs []int) {
+	width, height := left.Rect.Dx(), left.Rect.Dy()
+	// sum the absolute differences in an integral image so each block is summed in constant time
+	stride := width + 1
+	for y := 0; y < height; y++ {
+		rowSum := 0
+		for x := 0; x < width; x++ {
+			if x >= d {
+				diff := int(left.Pix[y*left.Stride+x]) - int(right.Pix[y*right.Stride+x-d])
+				if diff < 0 {
+					diff = -diff
+				}
+				rowSum += diff
+			}
+			integral[(y+1)*stride+x+1] = integral[y*stride+x+1] + rowSum
+		}
+	}
+
+	radius := bm.blockSize / 2
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			i := y*width + x
+			if y < radius || y >= height-radius || x < d+radius || x >= width-radius {
+				costs[i] = -1
+				continue
+			}
+			x0, y0, x1, y1 := x-radius, y-radius, x+radius+1, y+radius+1
+			costs[i] = integral[y1*stride+x1] - integral[y0*stride+x1] - integral[y1*stride+x0] + integral[y0*stride+x0]
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package stereo
+
+import (
+	"image"
+	"math/rand"
+	"testing"
+
+	"go.viam.com/test"
+)
+
+// shiftedPair returns a randomly textured left image and a right image whose content is shifted
+// to the left by the given disparity.
+func shiftedPair(width, height, disparity int) (*image.Gray, *image.Gray) {
+	//nolint:gosec
+	r := rand.New(rand.NewSource(1))
+	scene := image.NewGray(image.Rect(0, 0, width+disparity, height))
+	r.Read(scene.Pix)
+	left := image.NewGray(image.Rect(0, 0, width, height))
+	right := image.NewGray(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		copy(left.Pix[y*width:(y+1)*width], scene.Pix[y*scene.Stride:])
+		copy(right.Pix[y*width:(y+1)*width], scene.Pix[y*scene.Stride+disparity:])
+	}
+	return left, right
+}
+
+func TestBlockMatcher(t *testing.T) {
+	bm := &blockMatcher{blockSize: 5, maxDisparity: 8, uniquenessPct: 10}
+	left, right := shiftedPair(40, 20, 3)
+	disparities := bm.disparity(left, right)
+	test.That(t, disparities, test.ShouldHaveLength, 40*20)
+	// inside the image the shift is found, give or take the sub-pixel fit
+	for y := 2; y < 18; y++ {
+		for x := 8 + 2; x < 38; x++ {
+			test.That(t, disparities[y*40+x], test.ShouldAlmostEqual, 3, 0.1)
+		}
+	}
+	// blocks that do not fit in both images, or cannot be searched fully, have no match
+	test.That(t, disparities[0], test.ShouldEqual, noMatch)
+	test.That(t, disparities[10*40+9], test.ShouldEqual, noMatch)
+	test.That(t, disparities[10*40+39], test.ShouldEqual, noMatch)
+
+	// a flat image matches everywhere equally well, which is no match at all
+	flat := image.NewGray(image.Rect(0, 0, 40, 20))
+	for _, d := range bm.disparity(flat, flat) {
+		test.That(t, d, test.ShouldEqual, noMatch)
+	}
+}
+
+func TestToGray(t *testing.T) {
+	gray := image.NewGray(image.Rect(0, 0, 4, 4))
+	test.That(t, toGray(gray), test.ShouldEqual, gray)
+	sub := gray.SubImage(image.Rect(1, 1, 3, 3))
+	converted := toGray(sub)
+	test.That(t, converted.Bounds(), test.ShouldResemble, image.Rect(0, 0, 2, 2))
+	test.That(t, toGray(image.NewNRGBA(image.Rect(0, 0, 3, 2))).Bounds(), test.ShouldResemble, image.Rect(0, 0, 3, 2))
+}
@@ -0,0 +1,270 @@
+// Package stereo defines a camera model that computes depth from a calibrated pair of 2D cameras.
+package stereo
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"math"
+	"sync"
+
+	"github.com/edaniels/golog"
+	"github.com/golang/geo/r3"
+	"github.com/pkg/errors"
+	"github.com/viamrobotics/gostream"
+	"go.opencensus.io/trace"
+	"go.uber.org/multierr"
+	"go.viam.com/utils"
+
+	"go.viam.com/rdk/components/camera"
+	"go.viam.com/rdk/pointcloud"
+	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/rimage"
+	"go.viam.com/rdk/rimage/transform"
+	"go.viam.com/rdk/spatialmath"
+)
+
+var model = resource.DefaultModelFamily.WithModel("stereo")
+
+const (
+	defaultBlockSize     = 9
+	defaultMaxDisparity  = 64
+	defaultUniquenessPct = 10
+)
+
+func init() {
+	resource.RegisterComponent(camera.API, model,
+		resource.Registration[camera.Camera, *Config]{
+			Constructor: func(ctx context.Context, deps resource.Dependencies,
+				conf resource.Config, logger golog.Logger,
+			) (camera.Camera, error) {
+				newConf, err := resource.NativeConfig[*Config](conf)
+				if err != nil {
+					return nil, err
+				}
+				left, err := camera.FromDependencies(deps, newConf.Left)
+				if err != nil {
+					return nil, fmt.Errorf("no left camera (%s): %w", newConf.Left, err)
+				}
+				right, err := camera.FromDependencies(deps, newConf.Right)
+				if err != nil {
+					return nil, fmt.Errorf("no right camera (%s): %w", newConf.Right, err)
+				}
+				src, err := newStereoCamera(ctx, left, right, newConf, logger)
+				if err != nil {
+					return nil, err
+				}
+				return camera.FromVideoSource(conf.ResourceName(), src), nil
+			},
+		})
+}
+
+// Config is the attribute struct for a stereo camera. The rotation and translation take points
+// from the left camera's frame to the right camera's frame.
+type Config struct {
+	ImageType       string                             `json:"output_image_type,omitempty"`
+	Left            string                             `json:"left_camera_name"`
+	Right           string                             `json:"right_camera_name"`
+	LeftIntrinsics  *transform.PinholeCameraIntrinsics `json:"left_intrinsic_parameters"`
+	RightIntrinsics *transform.PinholeCameraIntrinsics `json:"right_intrinsic_parameters"`
+	LeftDistortion  *transform.BrownConrady            `json:"left_distortion_parameters,omitempty"`
+	RightDistortion *transform.BrownConrady            `json:"right_distortion_parameters,omitempty"`
+	RotationMatrix  []float64                          `json:"rotation_matrix"`
+	TranslationMM   []float64                          `json:"translation_mm"`
+	BlockSize       int                                `json:"block_size_px,omitempty"`
+	MaxDisparity    int                                `json:"max_disparity_px,omitempty"`
+	UniquenessPct   *int                               `json:"uniqueness_pct,omitempty"`
+}
+
+// Validate ensures all parts of the config are valid.
+func (cfg *Config) Validate(path string) ([]string, error) {
+	if cfg.Left == "" {
+		return nil, utils.NewConfigValidationFieldRequiredError(path, "left_camera_name")
+	}
+	if cfg.Right == "" {
+		return nil, utils.NewConfigValidationFieldRequiredError(path, "right_camera_name")
+	}
+	if cfg.LeftIntrinsics == nil {
+		return nil, utils.NewConfigValidationFieldRequiredError(path, "left_intrinsic_parameters")
+	}
+	if cfg.RightIntrinsics == nil {
+		return nil, utils.NewConfigValidationFieldRequiredError(path, "right_intrinsic_parameters")
+	}
+	if len(cfg.RotationMatrix) != 9 {
+		return nil, utils.NewConfigValidationError(path,
+			errors.Errorf("length of rotation_matrix is %d, should be 9", len(cfg.RotationMatrix)))
+	}
+	if len(cfg.TranslationMM) != 3 {
+		return nil, utils.NewConfigValidationError(path,
+			errors.Errorf("length of translation_mm is %d, should be 3", len(cfg.TranslationMM)))
+	}
+	switch camera.ImageType(cfg.ImageType) {
+	case camera.UnspecifiedStream, camera.DepthStream, camera.ColorStream:
+	default:
+		return nil, utils.NewConfigValidationError(path, errors.Errorf("invalid output_image_type %q", cfg.ImageType))
+	}
+	if cfg.BlockSize != 0 && (cfg.BlockSize < 3 || cfg.BlockSize%2 == 0) {
+		return nil, utils.NewConfigValidationError(path, errors.New("block_size_px must be an odd number of at least 3"))
+	}
+	if cfg.MaxDisparity < 0 {
+		return nil, utils.NewConfigValidationError(path, errors.New("max_disparity_px cannot be negative"))
+	}
+	if cfg.UniquenessPct != nil && *cfg.UniquenessPct < 0 {
+		return nil, utils.NewConfigValidationError(path, errors.New("uniqueness_pct cannot be negative"))
+	}
+	return []string{cfg.Left, cfg.Right}, nil
+}
+
+// stereoCamera rectifies the images of a left and right camera, matches them to find the
+// disparity of every pixel, and turns the disparity into depth.
+type stereoCamera struct {
+	left, right         gostream.VideoStream
+	leftName, rightName string
+	rectification       *transform.StereoRectification
+	matcher             *blockMatcher
+	baselineMM, focalPx float64
+	imageType           camera.ImageType
+	logger              golog.Logger
+}
+
+// newStereoCamera creates a camera.VideoSource that computes depth from a stereo pair.
+func newStereoCamera(ctx context.Context, left, right camera.VideoSource, conf *Config, logger golog.Logger,
+) (camera.VideoSource, error) {
+	rotation, err := spatialmath.NewRotationMatrix(conf.RotationMatrix)
+	if err != nil {
+		return nil, err
+	}
+	if len(conf.TranslationMM) != 3 {
+		return nil, errors.Errorf("length of translation_mm is %d, should be 3", len(conf.TranslationMM))
+	}
+	translation := r3.Vector{X: conf.TranslationMM[0], Y: conf.TranslationMM[1], Z: conf.TranslationMM[2]}
+	rect, err := transform.NewStereoRectification(
+		conf.LeftIntrinsics, conf.RightIntrinsics, conf.LeftDistortion, conf.RightDistortion, rotation, translation)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot rectify stereo pair")
+	}
+	// matching is done along rows, so the right camera has to be to the right of the left one
+	if math.Abs(rect.Baseline.Y) > math.Abs(rect.Baseline.X) || rect.Baseline.X >= 0 {
+		return nil, errors.New("the right camera has to be beside and to the right of the left camera")
+	}
+
+	matcher := &blockMatcher{
+		blockSize:     conf.BlockSize,
+		maxDisparity:  conf.MaxDisparity,
+		uniquenessPct: defaultUniquenessPct,
+	}
+	if matcher.blockSize == 0 {
+		matcher.blockSize = defaultBlockSize
+	}
+	if matcher.maxDisparity == 0 {
+		matcher.maxDisparity = defaultMaxDisparity
+	}
+	if conf.UniquenessPct != nil {
+		matcher.uniquenessPct = *conf.UniquenessPct
+	}
+
+	imgType := camera.ImageType(conf.ImageType)
+	if imgType == camera.UnspecifiedStream {
+		imgType = camera.DepthStream
+	}
+	sc := &stereoCamera{
+		left:          gostream.NewEmbeddedVideoStream(left),
+		right:         gostream.NewEmbeddedVideoStream(right),
+		leftName:      conf.Left,
+		rightName:     conf.Right,
+		rectification: rect,
+		matcher:       matcher,
+		baselineMM:    rect.Baseline.Norm(),
+		focalPx:       rect.Intrinsics.Fx,
+		imageType:     imgType,
+		logger:        logger,
+	}
+	// the images are rectified, so they have no distortion
+	cameraModel := camera.NewPinholeModelWithBrownConradyDistortion(rect.Intrinsics, nil)
+	return camera.NewVideoSourceFromReader(ctx, sc, &cameraModel, imgType)
+}
+
+// Read returns either the depth map or the rectified left image, which the depth map lines up with.
+func (sc *stereoCamera) Read(ctx context.Context) (image.Image, func(), error) {
+	ctx, span := trace.StartSpan(ctx, "stereo::stereoCamera::Read")
+	defer span.End()
+	left, right, err := sc.nextRectifiedPair(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	switch sc.imageType {
+	case camera.ColorStream:
+		return left, func() {}, nil
+	case camera.DepthStream:
+		return sc.depth(left, right), func() {}, nil
+	default:
+		return nil, nil, camera.NewUnsupportedImageTypeError(sc.imageType)
+	}
+}
+
+// NextPointCloud returns a point cloud colored by the rectified left image.
+func (sc *stereoCamera) NextPointCloud(ctx context.Context) (pointcloud.PointCloud, error) {
+	ctx, span := trace.StartSpan(ctx, "stereo::stereoCamera::NextPointCloud")
+	defer span.End()
+	left, right, err := sc.nextRectifiedPair(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return sc.rectification.Intrinsics.RGBDToPointCloud(rimage.ConvertImage(left), sc.depth(left, right))
+}
+
+// nextRectifiedPair gets an image from both cameras, as simultaneously as possible, and rectifies them.
+func (sc *stereoCamera) nextRectifiedPair(ctx context.Context) (*image.NRGBA, *image.NRGBA, error) {
+	var wg sync.WaitGroup
+	var left, right image.Image
+	var releaseLeft, releaseRight func()
+	var leftErr, rightErr error
+	wg.Add(2)
+	utils.PanicCapturingGo(func() {
+		defer wg.Done()
+		left, releaseLeft, leftErr = sc.left.Next(ctx)
+	})
+	utils.PanicCapturingGo(func() {
+		defer wg.Done()
+		right, releaseRight, rightErr = sc.right.Next(ctx)
+	})
+	wg.Wait()
+	// rectification copies the images, so they can be released once it is done
+	defer func() {
+		if releaseLeft != nil {
+			releaseLeft()
+		}
+		if releaseRight != nil {
+			releaseRight()
+		}
+	}()
+	if leftErr != nil {
+		return nil, nil, errors.Wrapf(leftErr, "could not get image from left camera %q", sc.leftName)
+	}
+	if rightErr != nil {
+		return nil, nil, errors.Wrapf(rightErr, "could not get image from right camera %q", sc.rightName)
+	}
+	return sc.rectification.Rectify(left, right)
+}
+
+// depth computes the depth map of a rectified pair from the disparity of its pixels.
+func (sc *stereoCamera) depth(left, right *image.NRGBA) *rimage.DepthMap {
+	width, height := left.Rect.Dx(), left.Rect.Dy()
+	disparities := sc.matcher.disparity(toGray(left), toGray(right))
+	dm := rimage.NewEmptyDepthMap(width, height)
+	for i, d := range disparities {
+		if d <= 0 {
+			continue
+		}
+		depth := sc.focalPx * sc.baselineMM / d
+		if depth >= float64(rimage.MaxDepth) {
+			continue
+		}
+		dm.Set(i%width, i/width, rimage.Depth(math.Round(depth)))
+	}
+	return dm
+}
+
+func (sc *stereoCamera) Close(ctx context.Context) error {
+	return multierr.Combine(sc.left.Close(ctx), sc.right.Close(ctx))
+}
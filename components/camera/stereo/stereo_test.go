@@ -0,0 +1,166 @@
+package stereo
+
+import (
+	"context"
+	"image"
+	"sync/atomic"
+	"testing"
+
+	"github.com/edaniels/golog"
+	"github.com/viamrobotics/gostream"
+	"go.viam.com/test"
+
+	"go.viam.com/rdk/components/camera"
+	"go.viam.com/rdk/rimage"
+	"go.viam.com/rdk/rimage/transform"
+	"go.viam.com/rdk/testutils/inject"
+)
+
+var testIntrinsics = &transform.PinholeCameraIntrinsics{
+	Width:  40,
+	Height: 20,
+	Fx:     50,
+	Fy:     50,
+	Ppx:    20,
+	Ppy:    10,
+}
+
+func testConfig() *Config {
+	return &Config{
+		Left:            "left",
+		Right:           "right",
+		LeftIntrinsics:  testIntrinsics,
+		RightIntrinsics: testIntrinsics,
+		RotationMatrix:  []float64{1, 0, 0, 0, 1, 0, 0, 0, 1},
+		TranslationMM:   []float64{-60, 0, 0},
+		BlockSize:       5,
+		MaxDisparity:    8,
+	}
+}
+
+func newTestSource(t *testing.T, img image.Image) camera.VideoSource {
+	t.Helper()
+	src, err := camera.NewVideoSourceFromReader(context.Background(),
+		gostream.VideoReaderFunc(func(ctx context.Context) (image.Image, func(), error) {
+			return img, func() {}, nil
+		}), nil, camera.ColorStream)
+	test.That(t, err, test.ShouldBeNil)
+	return src
+}
+
+func TestValidate(t *testing.T) {
+	conf := testConfig()
+	deps, err := conf.Validate("path")
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, deps, test.ShouldResemble, []string{"left", "right"})
+
+	conf.Right = ""
+	_, err = conf.Validate("path")
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "right_camera_name")
+
+	conf = testConfig()
+	conf.RotationMatrix = conf.RotationMatrix[:4]
+	_, err = conf.Validate("path")
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "rotation_matrix")
+
+	conf = testConfig()
+	conf.BlockSize = 4
+	_, err = conf.Validate("path")
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "block_size_px")
+
+	conf = testConfig()
+	conf.ImageType = "pointcloud"
+	_, err = conf.Validate("path")
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "output_image_type")
+}
+
+func TestStereoCamera(t *testing.T) {
+	logger := golog.NewTestLogger(t)
+	leftImg, rightImg := shiftedPair(testIntrinsics.Width, testIntrinsics.Height, 3)
+	left := newTestSource(t, leftImg)
+	right := newTestSource(t, rightImg)
+	defer func() {
+		test.That(t, left.Close(context.Background()), test.ShouldBeNil)
+		test.That(t, right.Close(context.Background()), test.ShouldBeNil)
+	}()
+
+	conf := testConfig()
+	cam, err := newStereoCamera(context.Background(), left, right, conf, logger)
+	test.That(t, err, test.ShouldBeNil)
+	props, err := cam.Properties(context.Background())
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, props.SupportsPCD, test.ShouldBeTrue)
+	test.That(t, props.ImageType, test.ShouldEqual, camera.DepthStream)
+	test.That(t, props.IntrinsicParams, test.ShouldResemble, testIntrinsics)
+
+	img, _, err := camera.ReadImage(context.Background(), cam)
+	test.That(t, err, test.ShouldBeNil)
+	dm, ok := img.(*rimage.DepthMap)
+	test.That(t, ok, test.ShouldBeTrue)
+	// a disparity of 3 pixels with a focal length of 50 pixels and a baseline of 60mm
+	test.That(t, float64(dm.GetDepth(20, 10)), test.ShouldAlmostEqual, 1000, 30)
+	test.That(t, dm.GetDepth(0, 0), test.ShouldEqual, 0)
+
+	pc, err := cam.NextPointCloud(context.Background())
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, pc.Size(), test.ShouldBeGreaterThan, 0)
+	_, got := pc.At(0, 0, float64(dm.GetDepth(20, 10)))
+	test.That(t, got, test.ShouldBeTrue)
+	test.That(t, cam.Close(context.Background()), test.ShouldBeNil)
+
+	// the color stream is the rectified left image
+	conf.ImageType = string(camera.ColorStream)
+	cam, err = newStereoCamera(context.Background(), left, right, conf, logger)
+	test.That(t, err, test.ShouldBeNil)
+	img, _, err = camera.ReadImage(context.Background(), cam)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, toGray(img), test.ShouldResemble, leftImg)
+	test.That(t, cam.Close(context.Background()), test.ShouldBeNil)
+
+	// the cameras have to be side by side with the right camera on the right
+	conf.TranslationMM = []float64{60, 0, 0}
+	_, err = newStereoCamera(context.Background(), left, right, conf, logger)
+	test.That(t, err, test.ShouldNotBeNil)
+	conf.TranslationMM = []float64{0, -60, 0}
+	_, err = newStereoCamera(context.Background(), left, right, conf, logger)
+	test.That(t, err, test.ShouldNotBeNil)
+}
+
+// releaseCountingStream streams one image and counts how many times it has been released.
+type releaseCountingStream struct {
+	img      image.Image
+	released atomic.Int32
+}
+
+func (s *releaseCountingStream) Next(ctx context.Context) (image.Image, func(), error) {
+	return s.img, func() { s.released.Add(1) }, nil
+}
+
+func (s *releaseCountingStream) Close(ctx context.Context) error {
+	return nil
+}
+
+func TestReleaseImages(t *testing.T) {
+	leftImg, rightImg := shiftedPair(testIntrinsics.Width, testIntrinsics.Height, 3)
+	leftStream := &releaseCountingStream{img: leftImg}
+	rightStream := &releaseCountingStream{img: rightImg}
+	newSource := func(stream gostream.VideoStream) camera.VideoSource {
+		cam := inject.NewCamera("cam")
+		cam.StreamFunc = func(ctx context.Context, errHandlers ...gostream.ErrorHandler) (gostream.VideoStream, error) {
+			return stream, nil
+		}
+		return cam
+	}
+
+	cam, err := newStereoCamera(context.Background(), newSource(leftStream), newSource(rightStream), testConfig(), golog.NewTestLogger(t))
+	test.That(t, err, test.ShouldBeNil)
+	_, err = cam.NextPointCloud(context.Background())
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, leftStream.released.Load(), test.ShouldEqual, 1)
+	test.That(t, rightStream.released.Load(), test.ShouldEqual, 1)
+	test.That(t, cam.Close(context.Background()), test.ShouldBeNil)
+}
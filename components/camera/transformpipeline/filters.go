@@ -0,0 +1,327 @@
+package transformpipeline
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"image/draw"
+
+	"github.com/disintegration/imaging"
+	"github.com/pkg/errors"
+	"github.com/viamrobotics/gostream"
+	"go.opencensus.io/trace"
+
+	"go.viam.com/rdk/components/camera"
+	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/rimage"
+	"go.viam.com/rdk/utils"
+)
+
+// filterSource applies a filter to every image of the original stream. Filters that only make
+// sense for color images leave depth nil.
+type filterSource struct {
+	originalStream gostream.VideoStream
+	stream         camera.ImageType
+	spanName       string
+	color          func(img image.Image) (image.Image, error)
+	depth          func(dm *rimage.DepthMap) (image.Image, error)
+}
+
+// newFilterSource creates a source that applies the given filters to the images of the source.
+func newFilterSource(
+	ctx context.Context,
+	source gostream.VideoSource,
+	stream camera.ImageType,
+	name string,
+	colorFilter func(img image.Image) (image.Image, error),
+	depthFilter func(dm *rimage.DepthMap) (image.Image, error),
+) (gostream.VideoSource, camera.ImageType, error) {
+	if stream == camera.DepthStream && depthFilter == nil {
+		return nil, camera.UnspecifiedStream, errors.Errorf("%s transform only works on color images", name)
+	}
+	reader := &filterSource{
+		originalStream: gostream.NewEmbeddedVideoStream(source),
+		stream:         stream,
+		spanName:       "camera::transformpipeline::" + name + "::Read",
+		color:          colorFilter,
+		depth:          depthFilter,
+	}
+	src, err := camera.NewVideoSourceFromReader(ctx, reader, nil, stream)
+	if err != nil {
+		return nil, camera.UnspecifiedStream, err
+	}
+	return src, stream, err
+}
+
+// Read applies the filter to the 2D image depending on the stream type.
+func (fs *filterSource) Read(ctx context.Context) (image.Image, func(), error) {
+	ctx, span := trace.StartSpan(ctx, fs.spanName)
+	defer span.End()
+	orig, release, err := fs.originalStream.Next(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	switch fs.stream {
+	case camera.ColorStream, camera.UnspecifiedStream:
+		img, err := fs.color(orig)
+		if err != nil {
+			return nil, nil, err
+		}
+		return img, release, nil
+	case camera.DepthStream:
+		dm, err := rimage.ConvertImageToDepthMap(ctx, orig)
+		if err != nil {
+			return nil, nil, err
+		}
+		img, err := fs.depth(dm)
+		if err != nil {
+			return nil, nil, err
+		}
+		return img, release, nil
+	default:
+		return nil, nil, camera.NewUnsupportedImageTypeError(fs.stream)
+	}
+}
+
+// Close closes the original stream.
+func (fs *filterSource) Close(ctx context.Context) error {
+	return fs.originalStream.Close(ctx)
+}
+
+// the color spaces images can be converted to.
+const (
+	colorSpaceGray = "gray"
+	colorSpaceHSV  = "hsv"
+)
+
+// colorSpaceConfig are the attributes for a color space transform.
+type colorSpaceConfig struct {
+	ColorSpace string `json:"color_space"`
+}
+
+// newColorSpaceTransform creates a transform that converts images to grayscale, or to HSV with
+// hue, saturation and value stored in the red, green and blue channels.
+func newColorSpaceTransform(
+	ctx context.Context, source gostream.VideoSource, stream camera.ImageType, am utils.AttributeMap,
+) (gostream.VideoSource, camera.ImageType, error) {
+	conf, err := resource.TransformAttributeMap[*colorSpaceConfig](am)
+	if err != nil {
+		return nil, camera.UnspecifiedStream, err
+	}
+	var filter func(img image.Image) (image.Image, error)
+	switch conf.ColorSpace {
+	case colorSpaceGray:
+		filter = func(img image.Image) (image.Image, error) { return toGray(img), nil }
+	case colorSpaceHSV:
+		filter = toHSV
+	default:
+		return nil, camera.UnspecifiedStream,
+			errors.Errorf("color_space must be %q or %q, not %q", colorSpaceGray, colorSpaceHSV, conf.ColorSpace)
+	}
+	return newFilterSource(ctx, source, stream, "color_space", filter, nil)
+}
+
+// toGray converts an image to grayscale, with its bounds starting at the origin.
+func toGray(img image.Image) *image.Gray {
+	if gray, ok := img.(*image.Gray); ok && gray.Bounds().Min == (image.Point{}) {
+		return gray
+	}
+	bounds := img.Bounds()
+	gray := image.NewGray(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(gray, gray.Bounds(), img, bounds.Min, draw.Src)
+	return gray
+}
+
+func toHSV(img image.Image) (image.Image, error) {
+	bounds := img.Bounds()
+	hsv := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	for y := 0; y < bounds.Dy(); y++ {
+		for x := 0; x < bounds.Dx(); x++ {
+			h, s, v := rimage.NewColorFromColor(img.At(bounds.Min.X+x, bounds.Min.Y+y)).ScaleHSV()
+			hsv.SetNRGBA(x, y, color.NRGBA{uint8(h * 255), uint8(s * 255), uint8(v * 255), 255})
+		}
+	}
+	return hsv, nil
+}
+
+// blurConfig are the attributes for a blur transform.
+type blurConfig struct {
+	Sigma float64 `json:"sigma"`
+}
+
+// newBlurTransform creates a transform that applies a Gaussian blur with the given standard
+// deviation in pixels.
+func newBlurTransform(
+	ctx context.Context, source gostream.VideoSource, stream camera.ImageType, am utils.AttributeMap,
+) (gostream.VideoSource, camera.ImageType, error) {
+	conf, err := resource.TransformAttributeMap[*blurConfig](am)
+	if err != nil {
+		return nil, camera.UnspecifiedStream, err
+	}
+	if conf.Sigma <= 0 {
+		return nil, camera.UnspecifiedStream, errors.New("sigma for blur transform must be positive")
+	}
+	return newFilterSource(ctx, source, stream, "blur", func(img image.Image) (image.Image, error) {
+		return imaging.Blur(img, conf.Sigma), nil
+	}, nil)
+}
+
+const defaultThresholdBlockSize = 11
+
+// adaptiveThresholdConfig are the attributes for an adaptive threshold transform.
+type adaptiveThresholdConfig struct {
+	BlockSize int     `json:"block_size_px,omitempty"`
+	Offset    float64 `json:"offset,omitempty"`
+	Invert    bool    `json:"invert,omitempty"`
+}
+
+// newAdaptiveThresholdTransform creates a transform that turns images black and white by comparing
+// each pixel to the mean of the block around it less an offset.
+func newAdaptiveThresholdTransform(
+	ctx context.Context, source gostream.VideoSource, stream camera.ImageType, am utils.AttributeMap,
+) (gostream.VideoSource, camera.ImageType, error) {
+	conf, err := resource.TransformAttributeMap[*adaptiveThresholdConfig](am)
+	if err != nil {
+		return nil, camera.UnspecifiedStream, err
+	}
+	if conf.BlockSize == 0 {
+		conf.BlockSize = defaultThresholdBlockSize
+	}
+	if conf.BlockSize < 3 || conf.BlockSize%2 == 0 {
+		return nil, camera.UnspecifiedStream, errors.New("block_size_px for adaptive threshold transform must be odd and at least 3")
+	}
+	return newFilterSource(ctx, source, stream, "adaptive_threshold", func(img image.Image) (image.Image, error) {
+		return adaptiveThreshold(toGray(img), conf.BlockSize/2, conf.Offset, conf.Invert), nil
+	}, nil)
+}
+
+// adaptiveThreshold thresholds each pixel of a grayscale image against the mean of the pixels
+// within radius of it, using an integral image so the cost does not grow with the radius.
+func adaptiveThreshold(gray *image.Gray, radius int, offset float64, invert bool) *image.Gray {
+	width, height := gray.Bounds().Dx(), gray.Bounds().Dy()
+	integral := make([]int64, (width+1)*(height+1))
+	for y := 0; y < height; y++ {
+		var rowSum int64
+		for x := 0; x < width; x++ {
+			rowSum += int64(gray.GrayAt(x, y).Y)
+			integral[(y+1)*(width+1)+x+1] = integral[y*(width+1)+x+1] + rowSum
+		}
+	}
+
+	on, off := uint8(255), uint8(0)
+	if invert {
+		on, off = off, on
+	}
+	out := image.NewGray(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0, y1 := utils.MaxInt(y-radius, 0), utils.MinInt(y+radius+1, height)
+		for x := 0; x < width; x++ {
+			x0, x1 := utils.MaxInt(x-radius, 0), utils.MinInt(x+radius+1, width)
+			sum := integral[y1*(width+1)+x1] - integral[y0*(width+1)+x1] - integral[y1*(width+1)+x0] + integral[y0*(width+1)+x0]
+			mean := float64(sum) / float64((x1-x0)*(y1-y0))
+			if float64(gray.GrayAt(x, y).Y) > mean-offset {
+				out.SetGray(x, y, color.Gray{on})
+			} else {
+				out.SetGray(x, y, color.Gray{off})
+			}
+		}
+	}
+	return out
+}
+
+// adjustConfig are the attributes for an adjust transform. Brightness and contrast are percentages
+// from -100 to 100, and a gamma of zero leaves the image's gamma as it is.
+type adjustConfig struct {
+	Brightness float64 `json:"brightness_pct,omitempty"`
+	Contrast   float64 `json:"contrast_pct,omitempty"`
+	Gamma      float64 `json:"gamma,omitempty"`
+}
+
+// newAdjustTransform creates a transform that changes the brightness, contrast and gamma of images.
+func newAdjustTransform(
+	ctx context.Context, source gostream.VideoSource, stream camera.ImageType, am utils.AttributeMap,
+) (gostream.VideoSource, camera.ImageType, error) {
+	conf, err := resource.TransformAttributeMap[*adjustConfig](am)
+	if err != nil {
+		return nil, camera.UnspecifiedStream, err
+	}
+	if conf.Brightness < -100 || conf.Brightness > 100 {
+		return nil, camera.UnspecifiedStream, errors.New("brightness_pct must be between -100 and 100")
+	}
+	if conf.Contrast < -100 || conf.Contrast > 100 {
+		return nil, camera.UnspecifiedStream, errors.New("contrast_pct must be between -100 and 100")
+	}
+	if conf.Gamma < 0 {
+		return nil, camera.UnspecifiedStream, errors.New("gamma cannot be negative")
+	}
+	return newFilterSource(ctx, source, stream, "adjust", func(img image.Image) (image.Image, error) {
+		adjusted := imaging.AdjustBrightness(img, conf.Brightness)
+		adjusted = imaging.AdjustContrast(adjusted, conf.Contrast)
+		if conf.Gamma != 0 {
+			adjusted = imaging.AdjustGamma(adjusted, conf.Gamma)
+		}
+		return adjusted, nil
+	}, nil)
+}
+
+// the directions images can be flipped in.
+const (
+	flipHorizontal = "horizontal"
+	flipVertical   = "vertical"
+	flipBoth       = "both"
+)
+
+// flipConfig are the attributes for a flip transform.
+type flipConfig struct {
+	Direction string `json:"direction"`
+}
+
+// newFlipTransform creates a transform that mirrors images horizontally, flips them vertically, or
+// both.
+func newFlipTransform(
+	ctx context.Context, source gostream.VideoSource, stream camera.ImageType, am utils.AttributeMap,
+) (gostream.VideoSource, camera.ImageType, error) {
+	conf, err := resource.TransformAttributeMap[*flipConfig](am)
+	if err != nil {
+		return nil, camera.UnspecifiedStream, err
+	}
+	var horizontal, vertical bool
+	switch conf.Direction {
+	case flipHorizontal:
+		horizontal = true
+	case flipVertical:
+		vertical = true
+	case flipBoth:
+		horizontal, vertical = true, true
+	default:
+		return nil, camera.UnspecifiedStream, errors.Errorf("direction must be %q, %q or %q, not %q",
+			flipHorizontal, flipVertical, flipBoth, conf.Direction)
+	}
+	return newFilterSource(ctx, source, stream, "flip",
+		func(img image.Image) (image.Image, error) {
+			if horizontal && vertical {
+				return imaging.Rotate180(img), nil
+			}
+			if horizontal {
+				return imaging.FlipH(img), nil
+			}
+			return imaging.FlipV(img), nil
+		},
+		func(dm *rimage.DepthMap) (image.Image, error) {
+			width, height := dm.Width(), dm.Height()
+			flipped := rimage.NewEmptyDepthMap(width, height)
+			for y := 0; y < height; y++ {
+				for x := 0; x < width; x++ {
+					fx, fy := x, y
+					if horizontal {
+						fx = width - 1 - x
+					}
+					if vertical {
+						fy = height - 1 - y
+					}
+					flipped.Set(fx, fy, dm.GetDepth(x, y))
+				}
+			}
+			return flipped, nil
+		})
+}
@@ -0,0 +1,170 @@
+package transformpipeline
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/pion/mediadevices/pkg/prop"
+	"github.com/viamrobotics/gostream"
+	"go.viam.com/test"
+
+	"go.viam.com/rdk/components/camera"
+	"go.viam.com/rdk/rimage"
+	"go.viam.com/rdk/utils"
+)
+
+// newTestImageSource returns a source that always returns the given image.
+func newTestImageSource(img image.Image) gostream.VideoSource {
+	return gostream.NewVideoSource(gostream.VideoReaderFunc(func(ctx context.Context) (image.Image, func(), error) {
+		return img, func() {}, nil
+	}), prop.Video{})
+}
+
+// readTransformed builds a transform on a source of the given image and reads one image from it.
+func readTransformed(
+	t *testing.T,
+	newTransform func(context.Context, gostream.VideoSource, camera.ImageType, utils.AttributeMap,
+	) (gostream.VideoSource, camera.ImageType, error),
+	img image.Image,
+	stream camera.ImageType,
+	am utils.AttributeMap,
+) image.Image {
+	t.Helper()
+	source := newTestImageSource(img)
+	defer func() {
+		test.That(t, source.Close(context.Background()), test.ShouldBeNil)
+	}()
+	src, outStream, err := newTransform(context.Background(), source, stream, am)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, outStream, test.ShouldEqual, stream)
+	out, _, err := camera.ReadImage(context.Background(), src)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, src.Close(context.Background()), test.ShouldBeNil)
+	return out
+}
+
+// twoToneImage is black on the left half and the given color on the right half.
+func twoToneImage(width, height int, right color.NRGBA) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if x >= width/2 {
+				img.SetNRGBA(x, y, right)
+			} else {
+				img.SetNRGBA(x, y, color.NRGBA{0, 0, 0, 255})
+			}
+		}
+	}
+	return img
+}
+
+func TestColorSpace(t *testing.T) {
+	img := twoToneImage(4, 2, color.NRGBA{255, 0, 0, 255})
+
+	out := readTransformed(t, newColorSpaceTransform, img, camera.ColorStream, utils.AttributeMap{"color_space": "gray"})
+	gray, ok := out.(*image.Gray)
+	test.That(t, ok, test.ShouldBeTrue)
+	test.That(t, gray.GrayAt(0, 0).Y, test.ShouldEqual, 0)
+	test.That(t, gray.GrayAt(3, 0).Y, test.ShouldEqual, 76)
+
+	out = readTransformed(t, newColorSpaceTransform, img, camera.ColorStream, utils.AttributeMap{"color_space": "hsv"})
+	// pure red has a hue of zero and full saturation and value
+	test.That(t, color.NRGBAModel.Convert(out.At(3, 1)), test.ShouldResemble, color.NRGBA{0, 255, 255, 255})
+	test.That(t, color.NRGBAModel.Convert(out.At(0, 1)), test.ShouldResemble, color.NRGBA{0, 0, 0, 255})
+
+	source := newTestImageSource(img)
+	_, _, err := newColorSpaceTransform(context.Background(), source, camera.ColorStream, utils.AttributeMap{"color_space": "lab"})
+	test.That(t, err, test.ShouldNotBeNil)
+	_, _, err = newColorSpaceTransform(context.Background(), source, camera.DepthStream, utils.AttributeMap{"color_space": "gray"})
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, source.Close(context.Background()), test.ShouldBeNil)
+}
+
+func TestBlur(t *testing.T) {
+	img := twoToneImage(8, 4, color.NRGBA{255, 255, 255, 255})
+	out := readTransformed(t, newBlurTransform, img, camera.ColorStream, utils.AttributeMap{"sigma": 1.0})
+	test.That(t, out.Bounds(), test.ShouldResemble, img.Bounds())
+	// the edge between the halves is smoothed
+	r, _, _, _ := out.At(3, 2).RGBA()
+	test.That(t, r>>8, test.ShouldBeGreaterThan, 0)
+	test.That(t, r>>8, test.ShouldBeLessThan, 255)
+
+	source := newTestImageSource(img)
+	_, _, err := newBlurTransform(context.Background(), source, camera.ColorStream, utils.AttributeMap{})
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, source.Close(context.Background()), test.ShouldBeNil)
+}
+
+func TestAdaptiveThreshold(t *testing.T) {
+	// a gradient with a dark dot, which only stands out from its neighborhood
+	img := image.NewGray(image.Rect(0, 0, 9, 9))
+	for y := 0; y < 9; y++ {
+		for x := 0; x < 9; x++ {
+			img.SetGray(x, y, color.Gray{uint8(100 + 10*x)})
+		}
+	}
+	img.SetGray(6, 4, color.Gray{50})
+
+	out := readTransformed(t, newAdaptiveThresholdTransform, img, camera.ColorStream,
+		utils.AttributeMap{"block_size_px": 3, "offset": 5})
+	gray, ok := out.(*image.Gray)
+	test.That(t, ok, test.ShouldBeTrue)
+	test.That(t, gray.GrayAt(6, 4).Y, test.ShouldEqual, 0)
+	test.That(t, gray.GrayAt(2, 4).Y, test.ShouldEqual, 255)
+
+	out = readTransformed(t, newAdaptiveThresholdTransform, img, camera.ColorStream,
+		utils.AttributeMap{"block_size_px": 3, "offset": 5, "invert": true})
+	test.That(t, out.(*image.Gray).GrayAt(6, 4).Y, test.ShouldEqual, 255)
+
+	source := newTestImageSource(img)
+	_, _, err := newAdaptiveThresholdTransform(context.Background(), source, camera.ColorStream, utils.AttributeMap{"block_size_px": 4})
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, source.Close(context.Background()), test.ShouldBeNil)
+}
+
+func TestAdjust(t *testing.T) {
+	img := twoToneImage(4, 2, color.NRGBA{100, 100, 100, 255})
+	out := readTransformed(t, newAdjustTransform, img, camera.ColorStream, utils.AttributeMap{"brightness_pct": 50})
+	r, _, _, _ := out.At(3, 0).RGBA()
+	test.That(t, r>>8, test.ShouldBeGreaterThan, 100)
+
+	out = readTransformed(t, newAdjustTransform, img, camera.ColorStream, utils.AttributeMap{"gamma": 0.5})
+	r, _, _, _ = out.At(3, 0).RGBA()
+	test.That(t, r>>8, test.ShouldBeLessThan, 100)
+
+	source := newTestImageSource(img)
+	_, _, err := newAdjustTransform(context.Background(), source, camera.ColorStream, utils.AttributeMap{"contrast_pct": 150})
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, source.Close(context.Background()), test.ShouldBeNil)
+}
+
+func TestFlip(t *testing.T) {
+	white := color.NRGBA{255, 255, 255, 255}
+	img := twoToneImage(4, 2, white)
+	img.SetNRGBA(0, 0, color.NRGBA{255, 0, 0, 255})
+
+	out := readTransformed(t, newFlipTransform, img, camera.ColorStream, utils.AttributeMap{"direction": "horizontal"})
+	test.That(t, color.NRGBAModel.Convert(out.At(0, 1)), test.ShouldResemble, white)
+	test.That(t, color.NRGBAModel.Convert(out.At(3, 0)), test.ShouldResemble, color.NRGBA{255, 0, 0, 255})
+
+	out = readTransformed(t, newFlipTransform, img, camera.ColorStream, utils.AttributeMap{"direction": "vertical"})
+	test.That(t, color.NRGBAModel.Convert(out.At(0, 1)), test.ShouldResemble, color.NRGBA{255, 0, 0, 255})
+
+	out = readTransformed(t, newFlipTransform, img, camera.ColorStream, utils.AttributeMap{"direction": "both"})
+	test.That(t, color.NRGBAModel.Convert(out.At(3, 1)), test.ShouldResemble, color.NRGBA{255, 0, 0, 255})
+
+	dm := rimage.NewEmptyDepthMap(3, 2)
+	dm.Set(0, 0, 10)
+	out = readTransformed(t, newFlipTransform, dm, camera.DepthStream, utils.AttributeMap{"direction": "both"})
+	flipped, ok := out.(*rimage.DepthMap)
+	test.That(t, ok, test.ShouldBeTrue)
+	test.That(t, flipped.GetDepth(2, 1), test.ShouldEqual, 10)
+	test.That(t, flipped.GetDepth(0, 0), test.ShouldEqual, 0)
+
+	source := newTestImageSource(img)
+	_, _, err := newFlipTransform(context.Background(), source, camera.ColorStream, utils.AttributeMap{"direction": "sideways"})
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, source.Close(context.Background()), test.ShouldBeNil)
+}
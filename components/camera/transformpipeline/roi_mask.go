@@ -0,0 +1,113 @@
+package transformpipeline
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"image/draw"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/viamrobotics/gostream"
+
+	"go.viam.com/rdk/components/camera"
+	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/rimage"
+	"go.viam.com/rdk/utils"
+)
+
+// maskPoint is a vertex of a region of interest polygon in pixels.
+type maskPoint struct {
+	X float64 `json:"x_px"`
+	Y float64 `json:"y_px"`
+}
+
+// roiMaskConfig are the attributes for a region of interest mask transform.
+type roiMaskConfig struct {
+	Polygon []maskPoint `json:"polygon"`
+	Invert  bool        `json:"invert,omitempty"`
+}
+
+// roiMask blacks out, or zeroes the depth of, every pixel outside a polygon, or inside it if
+// inverted. The mask is computed once for each image size.
+type roiMask struct {
+	polygon []maskPoint
+	invert  bool
+
+	mu     sync.Mutex
+	size   image.Point
+	inside []bool
+}
+
+// newROIMaskTransform creates a transform that masks images to a polygonal region of interest.
+func newROIMaskTransform(
+	ctx context.Context, source gostream.VideoSource, stream camera.ImageType, am utils.AttributeMap,
+) (gostream.VideoSource, camera.ImageType, error) {
+	conf, err := resource.TransformAttributeMap[*roiMaskConfig](am)
+	if err != nil {
+		return nil, camera.UnspecifiedStream, err
+	}
+	if len(conf.Polygon) < 3 {
+		return nil, camera.UnspecifiedStream, errors.New("polygon for roi_mask transform needs at least 3 points")
+	}
+	mask := &roiMask{polygon: conf.Polygon, invert: conf.Invert}
+	return newFilterSource(ctx, source, stream, "roi_mask", mask.applyColor, mask.applyDepth)
+}
+
+// contains returns whether a point is inside the polygon using the even-odd rule.
+func (m *roiMask) contains(x, y float64) bool {
+	inside := false
+	for i, j := 0, len(m.polygon)-1; i < len(m.polygon); j, i = i, i+1 {
+		a, b := m.polygon[i], m.polygon[j]
+		if (a.Y > y) != (b.Y > y) && x < (b.X-a.X)*(y-a.Y)/(b.Y-a.Y)+a.X {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// keep returns which pixels of an image of the given size are kept, by the center of each pixel.
+func (m *roiMask) keep(size image.Point) []bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.inside != nil && m.size == size {
+		return m.inside
+	}
+	m.size = size
+	m.inside = make([]bool, size.X*size.Y)
+	for y := 0; y < size.Y; y++ {
+		for x := 0; x < size.X; x++ {
+			m.inside[y*size.X+x] = m.contains(float64(x)+0.5, float64(y)+0.5) != m.invert
+		}
+	}
+	return m.inside
+}
+
+func (m *roiMask) applyColor(img image.Image) (image.Image, error) {
+	bounds := img.Bounds()
+	masked := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(masked, masked.Bounds(), img, bounds.Min, draw.Src)
+	keep := m.keep(masked.Bounds().Size())
+	black := color.NRGBA{0, 0, 0, 255}
+	for y := 0; y < bounds.Dy(); y++ {
+		for x := 0; x < bounds.Dx(); x++ {
+			if !keep[y*bounds.Dx()+x] {
+				masked.SetNRGBA(x, y, black)
+			}
+		}
+	}
+	return masked, nil
+}
+
+func (m *roiMask) applyDepth(dm *rimage.DepthMap) (image.Image, error) {
+	masked := dm.Clone()
+	keep := m.keep(image.Pt(dm.Width(), dm.Height()))
+	for y := 0; y < dm.Height(); y++ {
+		for x := 0; x < dm.Width(); x++ {
+			if !keep[y*dm.Width()+x] {
+				masked.Set(x, y, 0)
+			}
+		}
+	}
+	return masked, nil
+}
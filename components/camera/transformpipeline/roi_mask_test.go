@@ -0,0 +1,56 @@
+package transformpipeline
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"testing"
+
+	"go.viam.com/test"
+
+	"go.viam.com/rdk/components/camera"
+	"go.viam.com/rdk/rimage"
+	"go.viam.com/rdk/utils"
+)
+
+func TestROIMask(t *testing.T) {
+	white := color.NRGBA{255, 255, 255, 255}
+	black := color.NRGBA{0, 0, 0, 255}
+	img := image.NewNRGBA(image.Rect(0, 0, 10, 10))
+	for i := range img.Pix {
+		img.Pix[i] = 255
+	}
+	// a triangle covering the top left half of the image
+	polygon := []interface{}{
+		map[string]interface{}{"x_px": 0, "y_px": 0},
+		map[string]interface{}{"x_px": 10, "y_px": 0},
+		map[string]interface{}{"x_px": 0, "y_px": 10},
+	}
+
+	out := readTransformed(t, newROIMaskTransform, img, camera.ColorStream, utils.AttributeMap{"polygon": polygon})
+	test.That(t, color.NRGBAModel.Convert(out.At(1, 1)), test.ShouldResemble, white)
+	test.That(t, color.NRGBAModel.Convert(out.At(8, 8)), test.ShouldResemble, black)
+
+	out = readTransformed(t, newROIMaskTransform, img, camera.ColorStream,
+		utils.AttributeMap{"polygon": polygon, "invert": true})
+	test.That(t, color.NRGBAModel.Convert(out.At(1, 1)), test.ShouldResemble, black)
+	test.That(t, color.NRGBAModel.Convert(out.At(8, 8)), test.ShouldResemble, white)
+
+	dm := rimage.NewEmptyDepthMap(10, 10)
+	for y := 0; y < 10; y++ {
+		for x := 0; x < 10; x++ {
+			dm.Set(x, y, 100)
+		}
+	}
+	out = readTransformed(t, newROIMaskTransform, dm, camera.DepthStream, utils.AttributeMap{"polygon": polygon})
+	masked, ok := out.(*rimage.DepthMap)
+	test.That(t, ok, test.ShouldBeTrue)
+	test.That(t, masked.GetDepth(1, 1), test.ShouldEqual, 100)
+	test.That(t, masked.GetDepth(8, 8), test.ShouldEqual, 0)
+
+	source := newTestImageSource(img)
+	_, _, err := newROIMaskTransform(context.Background(), source, camera.ColorStream,
+		utils.AttributeMap{"polygon": polygon[:2]})
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, source.Close(context.Background()), test.ShouldBeNil)
+}
@@ -0,0 +1,90 @@
+package transformpipeline
+
+import (
+	"context"
+	"image"
+	"image/draw"
+
+	"github.com/golang/geo/r3"
+	"github.com/pkg/errors"
+	"github.com/viamrobotics/gostream"
+
+	"go.viam.com/rdk/components/camera"
+	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/rimage/transform"
+	"go.viam.com/rdk/spatialmath"
+	"go.viam.com/rdk/utils"
+)
+
+// stereoRectifyConfig are the attributes for a stereo rectification transform. The rotation and
+// translation take points from the left camera's frame to the right camera's frame.
+type stereoRectifyConfig struct {
+	LeftIntrinsics  *transform.PinholeCameraIntrinsics `json:"left_intrinsic_parameters"`
+	RightIntrinsics *transform.PinholeCameraIntrinsics `json:"right_intrinsic_parameters"`
+	LeftDistortion  *transform.BrownConrady            `json:"left_distortion_parameters,omitempty"`
+	RightDistortion *transform.BrownConrady            `json:"right_distortion_parameters,omitempty"`
+	RotationMatrix  []float64                          `json:"rotation_matrix"`
+	TranslationMM   []float64                          `json:"translation_mm"`
+}
+
+// newStereoRectifyTransform creates a transform that rectifies side-by-side stereo pairs so that
+// matching points lie on the same row of both halves.
+func newStereoRectifyTransform(
+	ctx context.Context, source gostream.VideoSource, stream camera.ImageType, am utils.AttributeMap,
+) (gostream.VideoSource, camera.ImageType, error) {
+	conf, err := resource.TransformAttributeMap[*stereoRectifyConfig](am)
+	if err != nil {
+		return nil, camera.UnspecifiedStream, err
+	}
+	rect, err := newStereoRectification(conf)
+	if err != nil {
+		return nil, camera.UnspecifiedStream, errors.Wrap(err, "cannot create stereo_rectify transform")
+	}
+	return newFilterSource(ctx, source, stream, "stereo_rectify", rect.apply, nil)
+}
+
+// newStereoRectification checks the attributes and computes the rectification of the pair.
+func newStereoRectification(conf *stereoRectifyConfig) (*stereoRectification, error) {
+	rotation, err := spatialmath.NewRotationMatrix(conf.RotationMatrix)
+	if err != nil {
+		return nil, err
+	}
+	if len(conf.TranslationMM) != 3 {
+		return nil, errors.Errorf("length of translation_mm is %d, should be 3", len(conf.TranslationMM))
+	}
+	translation := r3.Vector{X: conf.TranslationMM[0], Y: conf.TranslationMM[1], Z: conf.TranslationMM[2]}
+	rect, err := transform.NewStereoRectification(
+		conf.LeftIntrinsics, conf.RightIntrinsics, conf.LeftDistortion, conf.RightDistortion, rotation, translation)
+	if err != nil {
+		return nil, err
+	}
+	return &stereoRectification{rect}, nil
+}
+
+// stereoRectification rectifies side-by-side stereo pairs.
+type stereoRectification struct {
+	*transform.StereoRectification
+}
+
+// apply rectifies a side-by-side stereo pair.
+func (sr *stereoRectification) apply(img image.Image) (image.Image, error) {
+	width, height := sr.Intrinsics.Width, sr.Intrinsics.Height
+	bounds := img.Bounds()
+	if bounds.Dx() != 2*width || bounds.Dy() != height {
+		return nil, errors.Errorf("stereo pair is %dx%d but the intrinsics expect %dx%d",
+			bounds.Dx(), bounds.Dy(), 2*width, height)
+	}
+	orig := image.NewNRGBA(image.Rect(0, 0, 2*width, height))
+	draw.Draw(orig, orig.Bounds(), img, bounds.Min, draw.Src)
+	left, right, err := sr.Rectify(
+		orig.SubImage(image.Rect(0, 0, width, height)),
+		orig.SubImage(image.Rect(width, 0, 2*width, height)),
+	)
+	if err != nil {
+		return nil, err
+	}
+	out := image.NewNRGBA(orig.Bounds())
+	draw.Draw(out, image.Rect(0, 0, width, height), left, image.Point{}, draw.Src)
+	draw.Draw(out, image.Rect(width, 0, 2*width, height), right, image.Point{}, draw.Src)
+	return out, nil
+}
@@ -0,0 +1,76 @@
+package transformpipeline
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"testing"
+
+	"go.viam.com/test"
+
+	"go.viam.com/rdk/components/camera"
+	"go.viam.com/rdk/rimage/transform"
+	"go.viam.com/rdk/utils"
+)
+
+var stereoTestIntrinsics = &transform.PinholeCameraIntrinsics{
+	Width:  20,
+	Height: 10,
+	Fx:     10,
+	Fy:     10,
+	Ppx:    10,
+	Ppy:    5,
+}
+
+// stereoTestPair is a side-by-side pair whose pixels are all different.
+func stereoTestPair() *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, 2*stereoTestIntrinsics.Width, stereoTestIntrinsics.Height))
+	for y := 0; y < img.Bounds().Dy(); y++ {
+		for x := 0; x < img.Bounds().Dx(); x++ {
+			img.SetNRGBA(x, y, color.NRGBA{uint8(x), uint8(y), 0, 255})
+		}
+	}
+	return img
+}
+
+func TestStereoRectifyAlignedPair(t *testing.T) {
+	// cameras that are already side by side and parallel are left as they are
+	am := utils.AttributeMap{
+		"left_intrinsic_parameters":  stereoTestIntrinsics,
+		"right_intrinsic_parameters": stereoTestIntrinsics,
+		"rotation_matrix":            []float64{1, 0, 0, 0, 1, 0, 0, 0, 1},
+		"translation_mm":             []float64{-60, 0, 0},
+	}
+	img := stereoTestPair()
+	out := readTransformed(t, newStereoRectifyTransform, img, camera.ColorStream, am)
+	test.That(t, out, test.ShouldResemble, img)
+
+	source := newTestImageSource(image.NewNRGBA(image.Rect(0, 0, 20, 10)))
+	src, _, err := newStereoRectifyTransform(context.Background(), source, camera.ColorStream, am)
+	test.That(t, err, test.ShouldBeNil)
+	_, _, err = camera.ReadImage(context.Background(), src)
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "intrinsics expect 40x10")
+	test.That(t, src.Close(context.Background()), test.ShouldBeNil)
+	test.That(t, source.Close(context.Background()), test.ShouldBeNil)
+}
+
+func TestStereoRectifyConfig(t *testing.T) {
+	_, err := newStereoRectification(&stereoRectifyConfig{
+		LeftIntrinsics:  stereoTestIntrinsics,
+		RightIntrinsics: stereoTestIntrinsics,
+		RotationMatrix:  []float64{1, 0, 0, 0, 1, 0, 0, 0, 1},
+		TranslationMM:   []float64{0, 0},
+	})
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "translation_mm")
+	_, err = newStereoRectification(&stereoRectifyConfig{
+		LeftIntrinsics:  stereoTestIntrinsics,
+		RightIntrinsics: stereoTestIntrinsics,
+		RotationMatrix:  []float64{1, 0, 0, 0, 1, 0, 0, 0, 1},
+		TranslationMM:   []float64{0, 0, 0},
+	})
+	test.That(t, err, test.ShouldNotBeNil)
+	_, err = newStereoRectification(&stereoRectifyConfig{LeftIntrinsics: stereoTestIntrinsics})
+	test.That(t, err, test.ShouldNotBeNil)
+}
@@ -30,6 +30,13 @@ const (
 	transformTypeSegmentations   = transformType("segmentations")
 	transformTypeDepthEdges      = transformType("depth_edges")
 	transformTypeDepthPreprocess = transformType("depth_preprocess")
+	transformTypeColorSpace      = transformType("color_space")
+	transformTypeBlur            = transformType("blur")
+	transformTypeThreshold       = transformType("adaptive_threshold")
+	transformTypeROIMask         = transformType("roi_mask")
+	transformTypeAdjust          = transformType("adjust")
+	transformTypeFlip            = transformType("flip")
+	transformTypeStereoRectify   = transformType("stereo_rectify")
 )
 
 // emptyConfig is for transforms that have no attribute fields.
@@ -104,6 +111,41 @@ var registeredTransformConfigs = map[transformType]*transformRegistration{
 		&emptyConfig{},
 		"Applies some basic hole-filling and edge smoothing to a depth map.",
 	},
+	transformTypeColorSpace: {
+		string(transformTypeColorSpace),
+		&colorSpaceConfig{},
+		"Converts the image to grayscale, or to HSV with the hue, saturation and value in the red, green and blue channels.",
+	},
+	transformTypeBlur: {
+		string(transformTypeBlur),
+		&blurConfig{},
+		"Applies a Gaussian blur with the specified standard deviation in pixels.",
+	},
+	transformTypeThreshold: {
+		string(transformTypeThreshold),
+		&adaptiveThresholdConfig{},
+		"Turns the image black and white by comparing each pixel to the mean of the block of pixels around it.",
+	},
+	transformTypeROIMask: {
+		string(transformTypeROIMask),
+		&roiMaskConfig{},
+		"Blacks out everything outside of a polygonal region of interest, or inside of it if inverted. Works on depth maps too.",
+	},
+	transformTypeAdjust: {
+		string(transformTypeAdjust),
+		&adjustConfig{},
+		"Adjusts the brightness, contrast and gamma of the image.",
+	},
+	transformTypeFlip: {
+		string(transformTypeFlip),
+		&flipConfig{},
+		"Mirrors the image horizontally, flips it vertically, or both. Works on depth maps too.",
+	},
+	transformTypeStereoRectify: {
+		string(transformTypeStereoRectify),
+		&stereoRectifyConfig{},
+		"Rectifies a side-by-side stereo pair using the intrinsics of both cameras and the extrinsics between them.",
+	},
 }
 
 // Transformation states the type of transformation and the attributes that are specific to the given type.
@@ -161,6 +203,20 @@ func buildTransform(
 		return newDepthEdgesTransform(ctx, source, tr.Attributes)
 	case transformTypeDepthPreprocess:
 		return newDepthPreprocessTransform(ctx, source)
+	case transformTypeColorSpace:
+		return newColorSpaceTransform(ctx, source, stream, tr.Attributes)
+	case transformTypeBlur:
+		return newBlurTransform(ctx, source, stream, tr.Attributes)
+	case transformTypeThreshold:
+		return newAdaptiveThresholdTransform(ctx, source, stream, tr.Attributes)
+	case transformTypeROIMask:
+		return newROIMaskTransform(ctx, source, stream, tr.Attributes)
+	case transformTypeAdjust:
+		return newAdjustTransform(ctx, source, stream, tr.Attributes)
+	case transformTypeFlip:
+		return newFlipTransform(ctx, source, stream, tr.Attributes)
+	case transformTypeStereoRectify:
+		return newStereoRectifyTransform(ctx, source, stream, tr.Attributes)
 	default:
 		return nil, camera.UnspecifiedStream, errors.Errorf("do not know camera transform of type %q", tr.Type)
 	}
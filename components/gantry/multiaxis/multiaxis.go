@@ -4,6 +4,7 @@ package multiaxis
 import (
 	"context"
 	"sync"
+	"sync/atomic"
 
 	"github.com/edaniels/golog"
 	"github.com/pkg/errors"
@@ -23,15 +24,20 @@ var model = resource.DefaultModelFamily.WithModel("multi-axis")
 type Config struct {
 	SubAxes            []string `json:"subaxes_list"`
 	MoveSimultaneously *bool    `json:"move_simultaneously,omitempty"`
+	// HomeOrder lists the stages in which subaxes are homed. The subaxes of a stage are homed
+	// concurrently. Subaxes that are not listed are homed one at a time after all stages.
+	HomeOrder [][]string `json:"home_order,omitempty"`
 }
 
 type multiAxis struct {
 	resource.Named
 	resource.AlwaysRebuild
 	subAxes            []gantry.Gantry
+	mu                 sync.Mutex // guards lengthsMm and model, which homing may change
 	lengthsMm          []float64
 	logger             golog.Logger
 	moveSimultaneously bool
+	homeStages         [][]gantry.Gantry // nil if no home order is configured
 	model              referenceframe.Model
 	opMgr              *operation.SingleOperationManager
 	workers            sync.WaitGroup
@@ -45,6 +51,23 @@ func (conf *Config) Validate(path string) ([]string, error) {
 		return nil, utils.NewConfigValidationError(path, errors.New("need at least one axis"))
 	}
 
+	subAxes := map[string]bool{}
+	for _, s := range conf.SubAxes {
+		subAxes[s] = true
+	}
+	homed := map[string]bool{}
+	for _, stage := range conf.HomeOrder {
+		for _, s := range stage {
+			if !subAxes[s] {
+				return nil, utils.NewConfigValidationError(path, errors.Errorf("home_order axis %q is not in subaxes_list", s))
+			}
+			if homed[s] {
+				return nil, utils.NewConfigValidationError(path, errors.Errorf("home_order lists axis %q more than once", s))
+			}
+			homed[s] = true
+		}
+	}
+
 	deps = append(deps, conf.SubAxes...)
 	return deps, nil
 }
@@ -73,12 +96,30 @@ func newMultiAxis(
 		opMgr:  operation.NewSingleOperationManager(),
 	}
 
+	subAxesByName := map[string]gantry.Gantry{}
 	for _, s := range newConf.SubAxes {
 		subAx, err := gantry.FromDependencies(deps, s)
 		if err != nil {
 			return nil, errors.Wrapf(err, "no axes named [%s]", s)
 		}
 		mAx.subAxes = append(mAx.subAxes, subAx)
+		subAxesByName[s] = subAx
+	}
+
+	if len(newConf.HomeOrder) > 0 {
+		for _, stage := range newConf.HomeOrder {
+			var homeStage []gantry.Gantry
+			for _, s := range stage {
+				homeStage = append(homeStage, subAxesByName[s])
+				delete(subAxesByName, s)
+			}
+			mAx.homeStages = append(mAx.homeStages, homeStage)
+		}
+		for _, s := range newConf.SubAxes {
+			if subAx, ok := subAxesByName[s]; ok {
+				mAx.homeStages = append(mAx.homeStages, []gantry.Gantry{subAx})
+			}
+		}
 	}
 
 	mAx.moveSimultaneously = false
@@ -94,17 +135,60 @@ func newMultiAxis(
 	return mAx, nil
 }
 
-// Home runs the homing sequence of the gantry and returns true once completed.
+// Home runs the homing sequence of the gantry one stage at a time and returns true once completed.
 func (g *multiAxis) Home(ctx context.Context, extra map[string]interface{}) (bool, error) {
-	for _, subAx := range g.subAxes {
-		homed, err := subAx.Home(ctx, nil)
-		if err != nil {
-			return false, err
+	ctx, done := g.opMgr.New(ctx)
+	defer done()
+
+	stages := g.homeStages
+	if stages == nil {
+		// without a home order, home the subaxes one at a time
+		for _, subAx := range g.subAxes {
+			stages = append(stages, []gantry.Gantry{subAx})
+		}
+	}
+
+	for _, stage := range stages {
+		if len(stage) == 1 {
+			homed, err := stage[0].Home(ctx, nil)
+			if err != nil {
+				return false, err
+			}
+			if !homed {
+				return false, nil
+			}
+			continue
+		}
+
+		var notHomed atomic.Bool
+		fs := []rdkutils.SimpleFunc{}
+		for _, subAx := range stage {
+			singleGantry := subAx
+			fs = append(fs, func(ctx context.Context) error {
+				homed, err := singleGantry.Home(ctx, nil)
+				if err == nil && !homed {
+					notHomed.Store(true)
+				}
+				return err
+			})
+		}
+		if _, err := rdkutils.RunInParallel(ctx, fs); err != nil {
+			return false, multierr.Combine(err, g.Stop(ctx, nil))
 		}
-		if !homed {
+		if notHomed.Load() {
 			return false, nil
 		}
 	}
+
+	// homing may have measured new lengths for the subaxes
+	lengths, err := g.Lengths(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	g.mu.Lock()
+	g.lengthsMm = lengths
+	g.model = nil
+	g.mu.Unlock()
 	return true, nil
 }
 
@@ -117,10 +201,13 @@ func (g *multiAxis) MoveToPosition(ctx context.Context, positions, speeds []floa
 		return errors.Errorf("need position inputs for %v-axis gantry, have %v positions", len(g.subAxes), len(positions))
 	}
 
-	if len(positions) != len(g.lengthsMm) {
+	g.mu.Lock()
+	numAxes := len(g.lengthsMm)
+	g.mu.Unlock()
+	if len(positions) != numAxes {
 		return errors.Errorf(
 			"number of input positions %v does not match total gantry axes count %v",
-			len(positions), len(g.lengthsMm),
+			len(positions), numAxes,
 		)
 	}
 
@@ -240,6 +327,8 @@ func (g *multiAxis) CurrentInputs(ctx context.Context) ([]referenceframe.Input,
 
 // ModelFrame returns the frame model of the Gantry.
 func (g *multiAxis) ModelFrame() referenceframe.Model {
+	g.mu.Lock()
+	defer g.mu.Unlock()
 	if g.model == nil {
 		model := referenceframe.NewSimpleModel("")
 		for _, subAx := range g.subAxes {
@@ -2,7 +2,9 @@ package multiaxis
 
 import (
 	"context"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/edaniels/golog"
 	"go.viam.com/test"
@@ -87,6 +89,14 @@ func TestValidate(t *testing.T) {
 	fakecfg = &Config{SubAxes: []string{"singleaxis"}}
 	_, err = fakecfg.Validate("path")
 	test.That(t, err, test.ShouldBeNil)
+
+	fakecfg = &Config{SubAxes: []string{"x", "y"}, HomeOrder: [][]string{{"x", "z"}}}
+	_, err = fakecfg.Validate("path")
+	test.That(t, err.Error(), test.ShouldContainSubstring, "not in subaxes_list")
+
+	fakecfg = &Config{SubAxes: []string{"x", "y"}, HomeOrder: [][]string{{"x"}, {"x", "y"}}}
+	_, err = fakecfg.Validate("path")
+	test.That(t, err.Error(), test.ShouldContainSubstring, "more than once")
 }
 
 func TestNewMultiAxis(t *testing.T) {
@@ -254,6 +264,66 @@ func TestHome(t *testing.T) {
 	test.That(t, homed, test.ShouldBeTrue)
 }
 
+func TestHomeOrder(t *testing.T) {
+	ctx := context.Background()
+	logger := golog.NewTestLogger(t)
+
+	deps := createFakeDeps()
+	var mu sync.Mutex
+	var homing, maxHoming int
+	var order []string
+	for _, name := range []string{"1", "2", "3"} {
+		g := deps[gantry.Named(name)].(*inject.Gantry)
+		name := name
+		g.HomeFunc = func(ctx context.Context, extra map[string]interface{}) (bool, error) {
+			mu.Lock()
+			homing++
+			if homing > maxHoming {
+				maxHoming = homing
+			}
+			order = append(order, name)
+			mu.Unlock()
+			time.Sleep(10 * time.Millisecond)
+			mu.Lock()
+			homing--
+			mu.Unlock()
+			return true, nil
+		}
+	}
+
+	fakeMultAxcfg := resource.Config{
+		Name: "gantry",
+		ConvertedAttributes: &Config{
+			SubAxes:   []string{"1", "2", "3"},
+			HomeOrder: [][]string{{"3"}, {"2"}},
+		},
+	}
+	g, err := newMultiAxis(ctx, deps, fakeMultAxcfg, logger)
+	test.That(t, err, test.ShouldBeNil)
+	homed, err := g.Home(ctx, nil)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, homed, test.ShouldBeTrue)
+	test.That(t, order, test.ShouldResemble, []string{"3", "2", "1"})
+	test.That(t, maxHoming, test.ShouldEqual, 1)
+
+	order = nil
+	fakeMultAxcfg = resource.Config{
+		Name: "gantry",
+		ConvertedAttributes: &Config{
+			SubAxes:   []string{"1", "2", "3"},
+			HomeOrder: [][]string{{"1", "2"}},
+		},
+	}
+	g, err = newMultiAxis(ctx, deps, fakeMultAxcfg, logger)
+	test.That(t, err, test.ShouldBeNil)
+	homed, err = g.Home(ctx, nil)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, homed, test.ShouldBeTrue)
+	test.That(t, order, test.ShouldHaveLength, 3)
+	test.That(t, order[2], test.ShouldEqual, "3")
+	test.That(t, maxHoming, test.ShouldEqual, 2)
+}
+
 func TestStop(t *testing.T) {
 	ctx := context.Background()
 	fakemultiaxis := &multiAxis{
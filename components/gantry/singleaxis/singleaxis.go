@@ -38,6 +38,14 @@ type Config struct {
 	LengthMm        float64  `json:"length_mm"`
 	MmPerRevolution float64  `json:"mm_per_rev"`
 	GantryMmPerSec  float64  `json:"gantry_mm_per_sec,omitempty"`
+
+	// HomeOffsetMm is the distance from the limit switch to the zero position of an axis with one limit switch.
+	HomeOffsetMm float64 `json:"home_offset_mm,omitempty"`
+	// HomeBackoffMm is how far to back off a limit switch after first hitting it while homing, before
+	// re-approaching it slowly. Zero disables the re-approach.
+	HomeBackoffMm float64 `json:"home_backoff_mm,omitempty"`
+	// HomeSlowMmPerSec is the speed of the re-approach. Defaults to a tenth of gantry_mm_per_sec.
+	HomeSlowMmPerSec float64 `json:"home_slow_mm_per_sec,omitempty"`
 }
 
 // Validate ensures all parts of the config are valid.
@@ -74,6 +82,14 @@ func (cfg *Config) Validate(path string) ([]string, error) {
 	if len(cfg.LimitSwitchPins) > 0 && cfg.LimitPinEnabled == nil {
 		return nil, errors.New("limit pin enabled must be set to true or false")
 	}
+
+	if cfg.HomeOffsetMm < 0 || cfg.HomeBackoffMm < 0 || cfg.HomeSlowMmPerSec < 0 {
+		return nil, errors.New("home_offset_mm, home_backoff_mm and home_slow_mm_per_sec cannot be negative")
+	}
+
+	if cfg.HomeOffsetMm != 0 && len(cfg.LimitSwitchPins) != 1 {
+		return nil, errors.New("home_offset_mm is only used by gantries with one limit switch")
+	}
 	return deps, nil
 }
 
@@ -99,6 +115,12 @@ type singleAxis struct {
 	mmPerRevolution float64
 	rpm             float64
 
+	homeOffsetMm  float64
+	homeBackoffMm float64
+	homeSlowRpm   float64
+	// measuredLengthMm is the travel between two limit switches found by the last homing.
+	measuredLengthMm float64
+
 	model referenceframe.Model
 	frame r3.Vector
 
@@ -163,6 +185,18 @@ func (g *singleAxis) Reconfigure(ctx context.Context, deps resource.Dependencies
 		g.rpm = 100
 	}
 
+	g.homeBackoffMm = newConf.HomeBackoffMm
+	g.homeSlowRpm = g.gantryToMotorSpeeds(newConf.HomeSlowMmPerSec)
+	if g.homeSlowRpm == 0 {
+		g.homeSlowRpm = g.rpm / 10
+	}
+
+	// Rerun homing if the zero position moves
+	if g.homeOffsetMm != newConf.HomeOffsetMm {
+		g.homeOffsetMm = newConf.HomeOffsetMm
+		needsToReHome = true
+	}
+
 	// Rerun homing if the board has changed
 	if newConf.Board != "" {
 		if g.board == nil || g.board.Name().ShortName() != newConf.Board {
@@ -216,6 +250,10 @@ func (g *singleAxis) Reconfigure(ctx context.Context, deps resource.Dependencies
 		g.logger.Infof("single-axis gantry '%v' needs to re-home", g.Named.Name().ShortName())
 		g.positionRange = 0
 		g.positionLimits = []float64{0, 0}
+		g.measuredLengthMm = 0
+	} else if g.measuredLengthMm > 0 {
+		// keep the travel measured by the last homing rather than the configured length
+		g.lengthMm = g.measuredLengthMm
 	}
 	ctx, cancelFunc := context.WithCancel(context.Background())
 	g.cancelFunc = cancelFunc
@@ -355,8 +393,16 @@ func (g *singleAxis) homeLimSwitch(ctx context.Context) error {
 		if err != nil {
 			return err
 		}
+		// The travel between the switches is measured, so it replaces the configured length
+		if measured := (positionB - positionA) * g.mmPerRevolution; measured > 0 {
+			g.logger.Debugf("measured length: %0.2f mm configured length: %0.2f mm", measured, g.lengthMm)
+			g.measuredLengthMm = measured
+			g.lengthMm = measured
+			g.model = nil
+		}
 	} else {
-		// Only one limit switch, calculate positionB
+		// Only one limit switch, offset positionA from the switch and calculate positionB
+		positionA += g.homeOffsetMm / g.mmPerRevolution
 		revPerLength := g.lengthMm / g.mmPerRevolution
 		positionB = positionA + revPerLength
 	}
@@ -410,61 +456,78 @@ func (g *singleAxis) testLimit(ctx context.Context, pin int) (float64, error) {
 	defer utils.UncheckedErrorFunc(func() error {
 		return g.motor.Stop(ctx, nil)
 	})
-	wrongPin := 1
 	d := -1.0
 	if pin != 0 {
 		d = 1
+	}
+
+	if err := g.approachLimit(ctx, pin, d*g.rpm); err != nil {
+		return 0, err
+	}
+
+	// Back off the switch and re-approach it slowly to more accurately find where it triggers
+	if g.homeBackoffMm > 0 {
+		if err := g.motor.GoFor(ctx, -d*g.rpm, g.homeBackoffMm/g.mmPerRevolution, nil); err != nil {
+			return 0, err
+		}
+		if err := g.approachLimit(ctx, pin, d*g.homeSlowRpm); err != nil {
+			return 0, err
+		}
+	}
+
+	// Short pause after stopping to increase the precision of the position of each limit switch
+	position, err := g.motor.Position(ctx, nil)
+	time.Sleep(250 * time.Millisecond)
+	return position, err
+}
+
+// approachLimit moves the motor at the given rpm until the limit switch is hit.
+func (g *singleAxis) approachLimit(ctx context.Context, pin int, rpm float64) error {
+	wrongPin := 1
+	if pin != 0 {
 		wrongPin = 0
 	}
 
-	err := g.motor.GoFor(ctx, d*g.rpm, 0, nil)
+	err := g.motor.GoFor(ctx, rpm, 0, nil)
 	if err != nil {
-		return 0, err
+		return err
 	}
 
 	start := time.Now()
 	for {
 		hit, err := g.limitHit(ctx, pin)
 		if err != nil {
-			return 0, err
+			return err
 		}
 		if hit {
-			err = g.motor.Stop(ctx, nil)
-			if err != nil {
-				return 0, err
-			}
-			break
+			return g.motor.Stop(ctx, nil)
 		}
 
 		// check if the wrong limit switch was hit
 		wrongHit, err := g.limitHit(ctx, wrongPin)
 		if err != nil {
-			return 0, err
+			return err
 		}
 		if wrongHit {
 			err = g.motor.Stop(ctx, nil)
 			if err != nil {
-				return 0, err
+				return err
 			}
-			return 0, errors.Errorf(
+			return errors.Errorf(
 				"expected limit switch %v but hit limit switch %v, try switching the order in the config",
 				pin,
 				wrongPin)
 		}
 
-		elapsed := start.Sub(start)
+		elapsed := time.Since(start)
 		if elapsed > (time.Second * 15) {
-			return 0, errors.New("gantry timed out testing limit")
+			return errors.New("gantry timed out testing limit")
 		}
 
 		if !utils.SelectContextOrWait(ctx, time.Millisecond*10) {
-			return 0, ctx.Err()
+			return ctx.Err()
 		}
 	}
-	// Short pause after stopping to increase the precision of the position of each limit switch
-	position, err := g.motor.Position(ctx, nil)
-	time.Sleep(250 * time.Millisecond)
-	return position, err
 }
 
 // this function may need to be run in the background upon initialisation of the ganty,
@@ -112,6 +112,19 @@ func TestValidate(t *testing.T) {
 	test.That(t, err, test.ShouldBeNil)
 	test.That(t, deps, test.ShouldResemble, []string{fakecfg.Motor, fakecfg.Board})
 	test.That(t, fakecfg.GantryMmPerSec, test.ShouldEqual, float64(0))
+
+	fakecfg.HomeBackoffMm = -1
+	_, err = fakecfg.Validate("path")
+	test.That(t, err.Error(), test.ShouldContainSubstring, "cannot be negative")
+
+	fakecfg.HomeBackoffMm = 2
+	fakecfg.HomeOffsetMm = 5
+	_, err = fakecfg.Validate("path")
+	test.That(t, err, test.ShouldBeNil)
+
+	fakecfg.LimitSwitchPins = []string{"1", "2"}
+	_, err = fakecfg.Validate("path")
+	test.That(t, err.Error(), test.ShouldContainSubstring, "one limit switch")
 }
 
 func TestNewSingleAxis(t *testing.T) {
@@ -446,6 +459,72 @@ func TestHomeLimitSwitch(t *testing.T) {
 	test.That(t, err, test.ShouldNotBeNil)
 }
 
+func TestHomeLimitSwitchMeasuredLength(t *testing.T) {
+	ctx := context.Background()
+	logger := golog.NewTestLogger(t)
+
+	positions := []float64{2, 12}
+	var goFors [][]float64
+	injectMotor := &inject.Motor{
+		PositionFunc: func(ctx context.Context, extra map[string]interface{}) (float64, error) {
+			pos := positions[0]
+			positions = positions[1:]
+			return pos, nil
+		},
+		GoForFunc: func(ctx context.Context, rpm, revolutions float64, extra map[string]interface{}) error {
+			goFors = append(goFors, []float64{rpm, revolutions})
+			return nil
+		},
+		GoToFunc: func(ctx context.Context, rpm, position float64, extra map[string]interface{}) error { return nil },
+		StopFunc: func(ctx context.Context, extra map[string]interface{}) error { return nil },
+	}
+	fakegantry := &singleAxis{
+		motor:           injectMotor,
+		board:           createLimitBoard(),
+		limitHigh:       true,
+		logger:          logger,
+		rpm:             float64(100),
+		lengthMm:        float64(15),
+		mmPerRevolution: float64(2),
+		homeBackoffMm:   float64(4),
+		homeSlowRpm:     float64(10),
+		limitSwitchPins: []string{"1", "2"},
+		opMgr:           operation.NewSingleOperationManager(),
+	}
+
+	err := fakegantry.homeLimSwitch(ctx)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, goFors, test.ShouldResemble, [][]float64{
+		{-100, 0}, {100, 2}, {-10, 0},
+		{100, 0}, {-100, 2}, {10, 0},
+	})
+	test.That(t, fakegantry.positionLimits, test.ShouldResemble, []float64{2, 12})
+	test.That(t, fakegantry.lengthMm, test.ShouldEqual, 20)
+	test.That(t, fakegantry.measuredLengthMm, test.ShouldEqual, 20)
+}
+
+func TestHomeLimitSwitchOffset(t *testing.T) {
+	ctx := context.Background()
+	logger := golog.NewTestLogger(t)
+	fakegantry := &singleAxis{
+		motor:           createFakeMotor(),
+		board:           createLimitBoard(),
+		limitHigh:       true,
+		logger:          logger,
+		rpm:             float64(300),
+		lengthMm:        float64(1),
+		mmPerRevolution: float64(0.1),
+		homeOffsetMm:    float64(0.5),
+		limitSwitchPins: []string{"1"},
+		opMgr:           operation.NewSingleOperationManager(),
+	}
+
+	err := fakegantry.homeLimSwitch(ctx)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, fakegantry.positionLimits, test.ShouldResemble, []float64{6, 16})
+	test.That(t, fakegantry.lengthMm, test.ShouldEqual, 1)
+}
+
 func TestHomeLimitSwitch2(t *testing.T) {
 	ctx := context.Background()
 	logger := golog.NewTestLogger(t)
@@ -5,4 +5,5 @@ import (
 	// register generic.
 	_ "go.viam.com/rdk/components/generic"
 	_ "go.viam.com/rdk/components/generic/fake"
+	_ "go.viam.com/rdk/components/generic/replayclock"
 )
@@ -0,0 +1,236 @@
+// Package replayclock implements a clock that keeps replay models on one shared timeline.
+package replayclock
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/edaniels/golog"
+	"github.com/pkg/errors"
+	goutils "go.viam.com/utils"
+
+	"go.viam.com/rdk/components/generic"
+	"go.viam.com/rdk/resource"
+)
+
+// model is the model of a replay clock.
+var model = resource.DefaultModelFamily.WithModel("replay_clock")
+
+// DoCommand commands and their arguments.
+const (
+	Command = "command"
+	Play    = "play"
+	Pause   = "pause"
+	Seek    = "seek"
+	SetRate = "set_rate"
+	Status  = "status"
+	TimeVal = "time"
+	RateVal = "rate"
+)
+
+const timeFormat = time.RFC3339Nano
+
+func init() {
+	resource.RegisterComponent(generic.API, model, resource.Registration[resource.Resource, *Config]{
+		Constructor: func(
+			ctx context.Context,
+			deps resource.Dependencies,
+			conf resource.Config,
+			logger golog.Logger,
+		) (resource.Resource, error) {
+			newConf, err := resource.NativeConfig[*Config](conf)
+			if err != nil {
+				return nil, err
+			}
+			return NewClock(conf.ResourceName(), newConf)
+		},
+	})
+}
+
+// Config describes how to configure a replay clock.
+type Config struct {
+	// Start is the capture time (UTC, RFC3339) to start replaying from. Defaults to the earliest
+	// reading of the replay models using the clock.
+	Start string `json:"start,omitempty"`
+	// Rate is how fast the timeline advances relative to real time. Defaults to 1.
+	Rate float64 `json:"rate,omitempty"`
+	// Paused starts the clock paused until it is told to play.
+	Paused bool `json:"paused,omitempty"`
+}
+
+// Validate ensures all parts of the config are valid.
+func (cfg *Config) Validate(path string) ([]string, error) {
+	if cfg.Start != "" {
+		if _, err := time.Parse(time.RFC3339, cfg.Start); err != nil {
+			return nil, goutils.NewConfigValidationError(path, errors.New("invalid time format for start time (UTC), use RFC3339"))
+		}
+	}
+	if cfg.Rate < 0 {
+		return nil, goutils.NewConfigValidationError(path, errors.New("rate cannot be negative"))
+	}
+	return nil, nil
+}
+
+// A Clock is a timeline of capture times shared by replay models so that they emit data in the
+// order and with the spacing it was captured with. The timeline starts the first time a replay
+// model asks for the time, and it can be paused, moved and sped up or slowed down with DoCommand.
+type Clock struct {
+	resource.Named
+	resource.AlwaysRebuild
+	resource.TriviallyCloseable
+
+	mu       sync.Mutex
+	started  bool
+	earliest time.Time
+	// position is the capture time the timeline was at when it was last changed, at anchor.
+	position time.Time
+	anchor   time.Time
+	rate     float64
+	paused   bool
+	// changed is closed and replaced whenever the timeline is changed, to wake up waiters.
+	changed chan struct{}
+}
+
+// NewClock returns a new replay clock.
+func NewClock(name resource.Name, conf *Config) (*Clock, error) {
+	c := &Clock{
+		Named:   name.AsNamed(),
+		rate:    conf.Rate,
+		paused:  conf.Paused,
+		changed: make(chan struct{}),
+	}
+	if c.rate == 0 {
+		c.rate = 1
+	}
+	if conf.Start != "" {
+		start, err := time.Parse(time.RFC3339, conf.Start)
+		if err != nil {
+			return nil, err
+		}
+		c.earliest = start
+		c.started = true
+		c.position = start
+		c.anchor = time.Now()
+	}
+	return c, nil
+}
+
+// Register tells the clock about the earliest capture time of a replay model, so that the
+// timeline starts there. It has no effect once the timeline has started.
+func (c *Clock) Register(first time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.started {
+		return
+	}
+	if c.earliest.IsZero() || first.Before(c.earliest) {
+		c.earliest = first
+	}
+}
+
+// Now returns the current capture time of the timeline, starting it if needed.
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now()
+}
+
+// now returns the current capture time. It assumes the lock is being held.
+func (c *Clock) now() time.Time {
+	if !c.started {
+		c.started = true
+		c.position = c.earliest
+		c.anchor = time.Now()
+	}
+	if c.paused {
+		return c.position
+	}
+	return c.position.Add(time.Duration(float64(time.Since(c.anchor)) * c.rate))
+}
+
+// WaitUntil blocks until the timeline reaches t, following any pauses, seeks and rate changes.
+func (c *Clock) WaitUntil(ctx context.Context, t time.Time) error {
+	for {
+		c.mu.Lock()
+		remaining := t.Sub(c.now())
+		paused, rate, changed := c.paused, c.rate, c.changed
+		c.mu.Unlock()
+		if remaining <= 0 {
+			return nil
+		}
+
+		// a paused clock only moves again once it is changed
+		var timeout <-chan time.Time
+		var timer *time.Timer
+		if !paused {
+			timer = time.NewTimer(time.Duration(float64(remaining) / rate))
+			timeout = timer.C
+		}
+		select {
+		case <-ctx.Done():
+		case <-changed:
+		case <-timeout:
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+}
+
+// update changes the timeline, keeping its current position. It assumes the lock is being held.
+func (c *Clock) update(f func()) {
+	c.position = c.now()
+	c.anchor = time.Now()
+	f()
+	close(c.changed)
+	c.changed = make(chan struct{})
+}
+
+// DoCommand controls the timeline. Every command returns the status of the clock.
+func (c *Clock) DoCommand(ctx context.Context, cmd map[string]interface{}) (map[string]interface{}, error) {
+	name, ok := cmd[Command]
+	if !ok {
+		return nil, errors.Errorf("missing %s value", Command)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch name {
+	case Play:
+		c.update(func() { c.paused = false })
+	case Pause:
+		c.update(func() { c.paused = true })
+	case Seek:
+		timeRaw, ok := cmd[TimeVal].(string)
+		if !ok {
+			return nil, errors.Errorf("need %s value for seek", TimeVal)
+		}
+		seekTime, err := time.Parse(timeFormat, timeRaw)
+		if err != nil {
+			return nil, errors.New("time value must be in RFC3339 format")
+		}
+		c.update(func() { c.position = seekTime })
+	case SetRate:
+		rate, ok := cmd[RateVal].(float64)
+		if !ok {
+			return nil, errors.Errorf("need %s value for set_rate", RateVal)
+		}
+		if rate <= 0 {
+			return nil, errors.New("rate value must be positive")
+		}
+		c.update(func() { c.rate = rate })
+	case Status:
+	default:
+		return nil, errors.Errorf("no such command: %s", name)
+	}
+
+	return map[string]interface{}{
+		TimeVal:  c.now().Format(timeFormat),
+		RateVal:  c.rate,
+		"paused": c.paused,
+	}, nil
+}
@@ -0,0 +1,107 @@
+package replayclock
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.viam.com/test"
+
+	"go.viam.com/rdk/components/generic"
+)
+
+func TestValidate(t *testing.T) {
+	cfg := &Config{Start: "yesterday"}
+	_, err := cfg.Validate("path")
+	test.That(t, err.Error(), test.ShouldContainSubstring, "invalid time format for start time")
+
+	cfg = &Config{Rate: -1}
+	_, err = cfg.Validate("path")
+	test.That(t, err.Error(), test.ShouldContainSubstring, "rate cannot be negative")
+
+	cfg = &Config{Start: "2023-07-01T12:00:00Z", Rate: 2}
+	deps, err := cfg.Validate("path")
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, deps, test.ShouldBeEmpty)
+}
+
+func TestClock(t *testing.T) {
+	base := time.Date(2023, 7, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("starts at the earliest registered time", func(t *testing.T) {
+		c, err := NewClock(generic.Named("clock"), &Config{Paused: true})
+		test.That(t, err, test.ShouldBeNil)
+		c.Register(base.Add(time.Second))
+		c.Register(base)
+		test.That(t, c.Now(), test.ShouldEqual, base)
+
+		// registering after the timeline has started has no effect
+		c.Register(base.Add(-time.Second))
+		test.That(t, c.Now(), test.ShouldEqual, base)
+	})
+
+	t.Run("commands", func(t *testing.T) {
+		c, err := NewClock(generic.Named("clock"), &Config{Start: "2023-07-01T12:00:00Z", Paused: true})
+		test.That(t, err, test.ShouldBeNil)
+
+		resp, err := c.DoCommand(context.Background(), map[string]interface{}{Command: Status})
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, resp, test.ShouldResemble, map[string]interface{}{
+			TimeVal:  base.Format(timeFormat),
+			RateVal:  1.0,
+			"paused": true,
+		})
+
+		resp, err = c.DoCommand(context.Background(), map[string]interface{}{Command: Seek, TimeVal: "2023-07-01T12:00:10Z"})
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, resp[TimeVal], test.ShouldEqual, base.Add(10*time.Second).Format(timeFormat))
+
+		resp, err = c.DoCommand(context.Background(), map[string]interface{}{Command: SetRate, RateVal: 4.0})
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, resp[RateVal], test.ShouldEqual, 4.0)
+
+		resp, err = c.DoCommand(context.Background(), map[string]interface{}{Command: Play})
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, resp["paused"], test.ShouldBeFalse)
+		time.Sleep(50 * time.Millisecond)
+		test.That(t, c.Now(), test.ShouldHappenOnOrAfter, base.Add(10*time.Second+200*time.Millisecond))
+
+		_, err = c.DoCommand(context.Background(), map[string]interface{}{Command: Seek, TimeVal: "now"})
+		test.That(t, err, test.ShouldNotBeNil)
+		_, err = c.DoCommand(context.Background(), map[string]interface{}{Command: SetRate, RateVal: 0.0})
+		test.That(t, err, test.ShouldNotBeNil)
+		_, err = c.DoCommand(context.Background(), map[string]interface{}{Command: "rewind"})
+		test.That(t, err.Error(), test.ShouldContainSubstring, "no such command")
+		_, err = c.DoCommand(context.Background(), map[string]interface{}{})
+		test.That(t, err, test.ShouldNotBeNil)
+	})
+
+	t.Run("wait until", func(t *testing.T) {
+		c, err := NewClock(generic.Named("clock"), &Config{Start: "2023-07-01T12:00:00Z", Rate: 10})
+		test.That(t, err, test.ShouldBeNil)
+
+		start := time.Now()
+		test.That(t, c.WaitUntil(context.Background(), base.Add(time.Second)), test.ShouldBeNil)
+		test.That(t, time.Since(start), test.ShouldBeGreaterThanOrEqualTo, 100*time.Millisecond)
+
+		// a paused clock only wakes waiters up once it is moved
+		_, err = c.DoCommand(context.Background(), map[string]interface{}{Command: Pause})
+		test.That(t, err, test.ShouldBeNil)
+		done := make(chan error)
+		go func() {
+			done <- c.WaitUntil(context.Background(), base.Add(time.Hour))
+		}()
+		select {
+		case <-done:
+			t.Fatal("wait returned while the clock was paused")
+		case <-time.After(50 * time.Millisecond):
+		}
+		_, err = c.DoCommand(context.Background(), map[string]interface{}{Command: Seek, TimeVal: "2023-07-01T13:00:00Z"})
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, <-done, test.ShouldBeNil)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		test.That(t, c.WaitUntil(ctx, base.Add(2*time.Hour)), test.ShouldBeError, context.Canceled)
+	})
+}
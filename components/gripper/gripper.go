@@ -50,6 +50,12 @@ type Gripper interface {
 	Grab(ctx context.Context, extra map[string]interface{}) (bool, error)
 }
 
+// FromDependencies is a helper for getting the named gripper from a collection of
+// dependencies.
+func FromDependencies(deps resource.Dependencies, name string) (Gripper, error) {
+	return resource.FromDependencies[Gripper](deps, Named(name))
+}
+
 // FromRobot is a helper for getting the named Gripper from the given Robot.
 func FromRobot(r robot.Robot, name string) (Gripper, error) {
 	return robot.ResourceFromRobot[Gripper](r, Named(name))
@@ -0,0 +1,475 @@
+// Package mapper implements an input.Controller that remaps the controls of another controller.
+package mapper
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/edaniels/golog"
+	"github.com/pkg/errors"
+	"go.uber.org/multierr"
+	"go.viam.com/utils"
+
+	"go.viam.com/rdk/components/input"
+	"go.viam.com/rdk/resource"
+	rdkutils "go.viam.com/rdk/utils"
+)
+
+var model = resource.DefaultModelFamily.WithModel("mapper")
+
+func init() {
+	resource.RegisterComponent(input.API, model, resource.Registration[input.Controller, *Config]{
+		Constructor: NewController,
+	})
+}
+
+// Response curves that can be applied to an axis.
+const (
+	CurveLinear    = "linear"
+	CurveQuadratic = "quadratic"
+	CurveCubic     = "cubic"
+)
+
+// Config is used for converting config attributes.
+type Config struct {
+	Source string `json:"source"`
+
+	Controls []ControlConfig `json:"controls,omitempty"`
+	Combos   []ComboConfig   `json:"combos,omitempty"`
+	Macros   []MacroConfig   `json:"macros,omitempty"`
+
+	// DropUnmapped hides controls of the source that have no mapping. By default they pass through unchanged.
+	DropUnmapped bool `json:"drop_unmapped,omitempty"`
+}
+
+// ControlConfig maps a control of the source controller onto a control of this controller.
+type ControlConfig struct {
+	Source input.Control `json:"source"`
+	// Target defaults to Source if unset.
+	Target input.Control `json:"target,omitempty"`
+	Invert bool          `json:"invert,omitempty"`
+	// Deadzone is the fraction of an axis' range around zero that reads as zero.
+	Deadzone float64 `json:"deadzone,omitempty"`
+	// Curve is one of linear (default), quadratic or cubic.
+	Curve string `json:"curve,omitempty"`
+}
+
+// ComboConfig describes a virtual button that is pressed while all of its controls are pressed.
+type ComboConfig struct {
+	Controls []input.Control `json:"controls"`
+	Target   input.Control   `json:"target"`
+}
+
+// MacroConfig describes a DoCommand to send to another resource when a control fires an event.
+type MacroConfig struct {
+	Control input.Control `json:"control"`
+	// Event defaults to ButtonPress if unset.
+	Event    input.EventType        `json:"event,omitempty"`
+	Resource string                 `json:"resource"`
+	Command  map[string]interface{} `json:"command"`
+}
+
+// Validate ensures all parts of the config are valid.
+func (conf *Config) Validate(path string) ([]string, error) {
+	if conf.Source == "" {
+		return nil, utils.NewConfigValidationFieldRequiredError(path, "source")
+	}
+	deps := []string{conf.Source}
+
+	targets := map[input.Control]bool{}
+	for i, ctrl := range conf.Controls {
+		ctrlPath := fmt.Sprintf("%s.controls.%d", path, i)
+		if ctrl.Source == "" {
+			return nil, utils.NewConfigValidationFieldRequiredError(ctrlPath, "source")
+		}
+		if ctrl.Deadzone < 0 || ctrl.Deadzone >= 1 {
+			return nil, utils.NewConfigValidationError(ctrlPath, errors.New("deadzone must be in [0, 1)"))
+		}
+		switch ctrl.Curve {
+		case "", CurveLinear, CurveQuadratic, CurveCubic:
+		default:
+			return nil, utils.NewConfigValidationError(ctrlPath, errors.Errorf("unknown curve %q", ctrl.Curve))
+		}
+		targets[ctrl.target()] = true
+	}
+	for i, combo := range conf.Combos {
+		comboPath := fmt.Sprintf("%s.combos.%d", path, i)
+		if len(combo.Controls) < 2 {
+			return nil, utils.NewConfigValidationError(comboPath, errors.New("a combo needs at least two controls"))
+		}
+		if combo.Target == "" {
+			return nil, utils.NewConfigValidationFieldRequiredError(comboPath, "target")
+		}
+		if targets[combo.Target] {
+			return nil, utils.NewConfigValidationError(comboPath, errors.Errorf("target %q is already mapped", combo.Target))
+		}
+		targets[combo.Target] = true
+	}
+	for i, macro := range conf.Macros {
+		macroPath := fmt.Sprintf("%s.macros.%d", path, i)
+		if macro.Control == "" {
+			return nil, utils.NewConfigValidationFieldRequiredError(macroPath, "control")
+		}
+		if macro.Resource == "" {
+			return nil, utils.NewConfigValidationFieldRequiredError(macroPath, "resource")
+		}
+		deps = append(deps, macro.Resource)
+	}
+	return deps, nil
+}
+
+func (ctrl ControlConfig) target() input.Control {
+	if ctrl.Target == "" {
+		return ctrl.Source
+	}
+	return ctrl.Target
+}
+
+// apply transforms an event from the source controller into an event on the target control.
+func (ctrl ControlConfig) apply(ev input.Event) input.Event {
+	ev.Control = ctrl.target()
+	switch ev.Event {
+	case input.ButtonPress, input.ButtonRelease:
+		if ctrl.Invert {
+			ev.Value = 1 - ev.Value
+			if ev.Event == input.ButtonPress {
+				ev.Event = input.ButtonRelease
+			} else {
+				ev.Event = input.ButtonPress
+			}
+		}
+	case input.PositionChangeAbs:
+		ev.Value = ctrl.applyAxis(ev.Value)
+	default:
+	}
+	return ev
+}
+
+// applyAxis applies the inversion, deadzone and response curve to an axis value.
+func (ctrl ControlConfig) applyAxis(value float64) float64 {
+	if ctrl.Invert {
+		value = -value
+	}
+	mag := math.Abs(value)
+	if mag <= ctrl.Deadzone {
+		return 0
+	}
+	// rescale so the output still covers the full range outside of the deadzone
+	mag = math.Min(1, (mag-ctrl.Deadzone)/(1-ctrl.Deadzone))
+	switch ctrl.Curve {
+	case CurveQuadratic:
+		mag *= mag
+	case CurveCubic:
+		mag = mag * mag * mag
+	default:
+	}
+	return math.Copysign(mag, value)
+}
+
+type macro struct {
+	MacroConfig
+	target resource.Resource
+}
+
+// NewController returns a new input.Controller that remaps the controls of its source.
+func NewController(
+	ctx context.Context,
+	deps resource.Dependencies,
+	conf resource.Config,
+	logger golog.Logger,
+) (input.Controller, error) {
+	newConf, err := resource.NativeConfig[*Config](conf)
+	if err != nil {
+		return nil, err
+	}
+
+	source, err := input.FromDependencies(deps, newConf.Source)
+	if err != nil {
+		return nil, err
+	}
+
+	closeCtx, cancel := context.WithCancel(context.Background())
+	m := &mapper{
+		Named:        conf.ResourceName().AsNamed(),
+		logger:       logger,
+		source:       source,
+		mappings:     map[input.Control]ControlConfig{},
+		dropUnmapped: newConf.DropUnmapped,
+		combos:       newConf.Combos,
+		comboActive:  make([]bool, len(newConf.Combos)),
+		pressed:      map[input.Control]bool{},
+		events:       map[input.Control]input.Event{},
+		callbacks:    map[input.Control]map[input.EventType]input.ControlFunction{},
+		closeCtx:     closeCtx,
+		cancelFunc:   cancel,
+	}
+	for _, ctrl := range newConf.Controls {
+		m.mappings[ctrl.Source] = ctrl
+	}
+	for _, mc := range newConf.Macros {
+		res, err := findResource(deps, mc.Resource)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		if mc.Event == "" {
+			mc.Event = input.ButtonPress
+		}
+		m.macros = append(m.macros, macro{MacroConfig: mc, target: res})
+	}
+
+	controls, err := source.Controls(ctx, nil)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	for _, ctrl := range controls {
+		if err := source.RegisterControlCallback(ctx, ctrl, []input.EventType{input.AllEvents}, m.handleEvent, nil); err != nil {
+			cancel()
+			return nil, multierr.Combine(err, m.unregisterSource(ctx))
+		}
+		m.sourceControls = append(m.sourceControls, ctrl)
+	}
+
+	return m, nil
+}
+
+// findResource finds a dependency by either its fully qualified or short name.
+func findResource(deps resource.Dependencies, name string) (resource.Resource, error) {
+	if resName, err := resource.NewFromString(name); err == nil {
+		return deps.Lookup(resName)
+	}
+	var found resource.Resource
+	for depName, res := range deps {
+		if depName.ShortName() != name {
+			continue
+		}
+		if found != nil {
+			return nil, rdkutils.NewRemoteResourceClashError(name)
+		}
+		found = res
+	}
+	if found == nil {
+		return nil, errors.Errorf("resource %q not found in dependencies", name)
+	}
+	return found, nil
+}
+
+// mapper is an input.Controller.
+type mapper struct {
+	resource.Named
+	resource.AlwaysRebuild
+
+	logger         golog.Logger
+	source         input.Controller
+	sourceControls []input.Control // controls of the source our callbacks are registered on
+	mappings       map[input.Control]ControlConfig
+	dropUnmapped   bool
+	combos         []ComboConfig
+	macros         []macro
+
+	mu          sync.RWMutex
+	comboActive []bool
+	pressed     map[input.Control]bool
+	events      map[input.Control]input.Event
+	callbacks   map[input.Control]map[input.EventType]input.ControlFunction
+
+	closeCtx                context.Context
+	cancelFunc              func()
+	activeBackgroundWorkers sync.WaitGroup
+}
+
+// mapEvent transforms an event from the source. It returns false if the event is dropped.
+func (m *mapper) mapEvent(ev input.Event) (input.Event, bool) {
+	ctrl, ok := m.mappings[ev.Control]
+	if !ok {
+		return ev, !m.dropUnmapped
+	}
+	return ctrl.apply(ev), true
+}
+
+func (m *mapper) handleEvent(ctx context.Context, ev input.Event) {
+	if m.closeCtx.Err() != nil {
+		return
+	}
+	mapped, ok := m.mapEvent(ev)
+	if !ok {
+		return
+	}
+	m.emit(ctx, mapped)
+
+	for _, comboEv := range m.updateCombos(mapped) {
+		m.emit(ctx, comboEv)
+	}
+}
+
+// emit records the event, calls any registered callbacks and runs matching macros.
+func (m *mapper) emit(ctx context.Context, ev input.Event) {
+	m.mu.Lock()
+	m.events[ev.Control] = ev
+	ctrlFunc := m.callbacks[ev.Control][ev.Event]
+	ctrlFuncAll := m.callbacks[ev.Control][input.AllEvents]
+	m.mu.Unlock()
+
+	if ctrlFunc != nil {
+		ctrlFunc(ctx, ev)
+	}
+	if ctrlFuncAll != nil {
+		ctrlFuncAll(ctx, ev)
+	}
+
+	for _, mc := range m.macros {
+		if mc.Control != ev.Control || mc.Event != ev.Event {
+			continue
+		}
+		mc := mc
+		m.activeBackgroundWorkers.Add(1)
+		utils.PanicCapturingGo(func() {
+			defer m.activeBackgroundWorkers.Done()
+			if _, err := mc.target.DoCommand(m.closeCtx, mc.Command); err != nil {
+				m.logger.Errorw("error running macro", "control", mc.Control, "resource", mc.Resource, "error", err)
+			}
+		})
+	}
+}
+
+// updateCombos tracks pressed buttons and returns the press and release events of any combos
+// whose state changed.
+func (m *mapper) updateCombos(ev input.Event) []input.Event {
+	switch ev.Event {
+	case input.ButtonPress:
+	case input.ButtonRelease:
+	default:
+		return nil
+	}
+	if len(m.combos) == 0 {
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pressed[ev.Control] = ev.Event == input.ButtonPress
+
+	var out []input.Event
+	for i, combo := range m.combos {
+		active := true
+		for _, ctrl := range combo.Controls {
+			if !m.pressed[ctrl] {
+				active = false
+				break
+			}
+		}
+		if active == m.comboActive[i] {
+			continue
+		}
+		m.comboActive[i] = active
+		comboEv := input.Event{Time: ev.Time, Event: input.ButtonRelease, Control: combo.Target}
+		if active {
+			comboEv.Event = input.ButtonPress
+			comboEv.Value = 1
+		}
+		out = append(out, comboEv)
+	}
+	return out
+}
+
+// Controls lists the controls of the source after mapping, followed by any combos.
+func (m *mapper) Controls(ctx context.Context, extra map[string]interface{}) ([]input.Control, error) {
+	controls, err := m.source.Controls(ctx, extra)
+	if err != nil {
+		return nil, err
+	}
+	seen := map[input.Control]bool{}
+	var controlsOut []input.Control
+	add := func(ctrl input.Control) {
+		if !seen[ctrl] {
+			seen[ctrl] = true
+			controlsOut = append(controlsOut, ctrl)
+		}
+	}
+	for _, ctrl := range controls {
+		if mapping, ok := m.mappings[ctrl]; ok {
+			add(mapping.target())
+		} else if !m.dropUnmapped {
+			add(ctrl)
+		}
+	}
+	for _, combo := range m.combos {
+		add(combo.Target)
+	}
+	return controlsOut, nil
+}
+
+// Events returns the most recent event of each mapped control.
+func (m *mapper) Events(ctx context.Context, extra map[string]interface{}) (map[input.Control]input.Event, error) {
+	eventsIn, err := m.source.Events(ctx, extra)
+	if err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	eventsOut := make(map[input.Control]input.Event, len(eventsIn))
+	for _, ev := range eventsIn {
+		mapped, ok := m.mapEvent(ev)
+		if !ok {
+			continue
+		}
+		if prev, ok := eventsOut[mapped.Control]; ok && prev.Time.After(mapped.Time) {
+			continue
+		}
+		eventsOut[mapped.Control] = mapped
+	}
+	for _, combo := range m.combos {
+		if ev, ok := m.events[combo.Target]; ok {
+			eventsOut[combo.Target] = ev
+		} else {
+			eventsOut[combo.Target] = input.Event{Time: time.Now(), Event: input.ButtonRelease, Control: combo.Target}
+		}
+	}
+	return eventsOut, nil
+}
+
+// RegisterControlCallback registers a callback function to be executed on the specified control's trigger Events.
+func (m *mapper) RegisterControlCallback(
+	ctx context.Context,
+	control input.Control,
+	triggers []input.EventType,
+	ctrlFunc input.ControlFunction,
+	extra map[string]interface{},
+) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.callbacks[control] == nil {
+		m.callbacks[control] = make(map[input.EventType]input.ControlFunction)
+	}
+
+	for _, trigger := range triggers {
+		if trigger == input.ButtonChange {
+			m.callbacks[control][input.ButtonRelease] = ctrlFunc
+			m.callbacks[control][input.ButtonPress] = ctrlFunc
+		} else {
+			m.callbacks[control][trigger] = ctrlFunc
+		}
+	}
+	return nil
+}
+
+// unregisterSource removes the callbacks registered on the controls of the source.
+func (m *mapper) unregisterSource(ctx context.Context) error {
+	var err error
+	for _, ctrl := range m.sourceControls {
+		err = multierr.Combine(err, m.source.RegisterControlCallback(ctx, ctrl, []input.EventType{input.AllEvents}, nil, nil))
+	}
+	return err
+}
+
+// Close unregisters from the source and terminates any running macros.
+func (m *mapper) Close(ctx context.Context) error {
+	m.cancelFunc()
+	err := m.unregisterSource(ctx)
+	m.activeBackgroundWorkers.Wait()
+	return err
+}
@@ -0,0 +1,234 @@
+package mapper
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/edaniels/golog"
+	"go.viam.com/test"
+
+	"go.viam.com/rdk/components/arm"
+	"go.viam.com/rdk/components/input"
+	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/testutils/inject"
+)
+
+type setupResult struct {
+	dev       input.Controller
+	trigger   func(ev input.Event)
+	callbacks map[input.Control]input.ControlFunction
+	commandMu sync.Mutex
+	commands  []map[string]interface{}
+}
+
+func setup(t *testing.T, conf *Config) *setupResult {
+	t.Helper()
+	s := &setupResult{}
+
+	callbacks := map[input.Control]input.ControlFunction{}
+	s.callbacks = callbacks
+	source := inject.NewInputController("source")
+	source.ControlsFunc = func(ctx context.Context, extra map[string]interface{}) ([]input.Control, error) {
+		return []input.Control{input.AbsoluteX, input.AbsoluteY, input.ButtonSouth, input.ButtonEast}, nil
+	}
+	source.EventsFunc = func(ctx context.Context, extra map[string]interface{}) (map[input.Control]input.Event, error) {
+		return map[input.Control]input.Event{
+			input.AbsoluteX: {Event: input.PositionChangeAbs, Control: input.AbsoluteX, Value: 0.05},
+			input.AbsoluteY: {Event: input.PositionChangeAbs, Control: input.AbsoluteY, Value: 0.5},
+		}, nil
+	}
+	source.RegisterControlCallbackFunc = func(
+		ctx context.Context,
+		control input.Control,
+		triggers []input.EventType,
+		ctrlFunc input.ControlFunction,
+		extra map[string]interface{},
+	) error {
+		test.That(t, triggers, test.ShouldResemble, []input.EventType{input.AllEvents})
+		callbacks[control] = ctrlFunc
+		return nil
+	}
+	s.trigger = func(ev input.Event) {
+		ev.Time = time.Now()
+		callbacks[ev.Control](context.Background(), ev)
+	}
+
+	injectArm := &inject.Arm{}
+	injectArm.DoFunc = func(ctx context.Context, cmd map[string]interface{}) (map[string]interface{}, error) {
+		s.commandMu.Lock()
+		defer s.commandMu.Unlock()
+		s.commands = append(s.commands, cmd)
+		return nil, nil
+	}
+
+	deps := resource.Dependencies{
+		input.Named("source"): source,
+		arm.Named("arm1"):     injectArm,
+	}
+	var err error
+	s.dev, err = NewController(context.Background(), deps, resource.Config{Name: "mapper", ConvertedAttributes: conf}, golog.NewTestLogger(t))
+	test.That(t, err, test.ShouldBeNil)
+	t.Cleanup(func() {
+		test.That(t, s.dev.Close(context.Background()), test.ShouldBeNil)
+	})
+	return s
+}
+
+func TestValidate(t *testing.T) {
+	conf := &Config{}
+	_, err := conf.Validate("path")
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "source")
+
+	conf = &Config{
+		Source: "source",
+		Macros: []MacroConfig{{Control: input.ButtonSouth, Resource: "arm1"}},
+	}
+	deps, err := conf.Validate("path")
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, deps, test.ShouldResemble, []string{"source", "arm1"})
+
+	conf = &Config{Source: "source", Controls: []ControlConfig{{Source: input.AbsoluteX, Deadzone: 1}}}
+	_, err = conf.Validate("path")
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "deadzone")
+
+	conf = &Config{Source: "source", Controls: []ControlConfig{{Source: input.AbsoluteX, Curve: "sine"}}}
+	_, err = conf.Validate("path")
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "unknown curve")
+
+	conf = &Config{
+		Source:   "source",
+		Controls: []ControlConfig{{Source: input.ButtonSouth, Target: input.ButtonMenu}},
+		Combos:   []ComboConfig{{Controls: []input.Control{input.ButtonSouth, input.ButtonEast}, Target: input.ButtonMenu}},
+	}
+	_, err = conf.Validate("path")
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "already mapped")
+}
+
+func TestApplyAxis(t *testing.T) {
+	ctrl := ControlConfig{}
+	test.That(t, ctrl.applyAxis(0.5), test.ShouldEqual, 0.5)
+
+	ctrl = ControlConfig{Invert: true}
+	test.That(t, ctrl.applyAxis(0.5), test.ShouldEqual, -0.5)
+
+	ctrl = ControlConfig{Deadzone: 0.2}
+	test.That(t, ctrl.applyAxis(0.1), test.ShouldEqual, 0)
+	test.That(t, ctrl.applyAxis(-0.2), test.ShouldEqual, 0)
+	test.That(t, ctrl.applyAxis(0.6), test.ShouldAlmostEqual, 0.5)
+	test.That(t, ctrl.applyAxis(-1), test.ShouldEqual, -1)
+
+	ctrl = ControlConfig{Curve: CurveQuadratic}
+	test.That(t, ctrl.applyAxis(-0.5), test.ShouldEqual, -0.25)
+
+	ctrl = ControlConfig{Curve: CurveCubic}
+	test.That(t, ctrl.applyAxis(0.5), test.ShouldEqual, 0.125)
+}
+
+func TestMapping(t *testing.T) {
+	s := setup(t, &Config{
+		Source: "source",
+		Controls: []ControlConfig{
+			{Source: input.AbsoluteX, Deadzone: 0.1},
+			{Source: input.AbsoluteY, Target: input.AbsoluteRY, Invert: true},
+			{Source: input.ButtonSouth, Target: input.ButtonNorth},
+		},
+		DropUnmapped: true,
+	})
+	ctx := context.Background()
+
+	controls, err := s.dev.Controls(ctx, nil)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, controls, test.ShouldResemble, []input.Control{input.AbsoluteX, input.AbsoluteRY, input.ButtonNorth})
+
+	events, err := s.dev.Events(ctx, nil)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, events, test.ShouldHaveLength, 2)
+	test.That(t, events[input.AbsoluteX].Value, test.ShouldEqual, 0)
+	test.That(t, events[input.AbsoluteRY].Value, test.ShouldEqual, -0.5)
+
+	var got []input.Event
+	err = s.dev.RegisterControlCallback(ctx, input.ButtonNorth, []input.EventType{input.ButtonChange},
+		func(ctx context.Context, ev input.Event) {
+			got = append(got, ev)
+		}, nil)
+	test.That(t, err, test.ShouldBeNil)
+	err = s.dev.RegisterControlCallback(ctx, input.ButtonEast, []input.EventType{input.ButtonChange},
+		func(ctx context.Context, ev input.Event) {
+			t.Fatal("unmapped control should be dropped")
+		}, nil)
+	test.That(t, err, test.ShouldBeNil)
+
+	s.trigger(input.Event{Event: input.ButtonPress, Control: input.ButtonSouth, Value: 1})
+	s.trigger(input.Event{Event: input.ButtonRelease, Control: input.ButtonSouth, Value: 0})
+	s.trigger(input.Event{Event: input.ButtonPress, Control: input.ButtonEast, Value: 1})
+	test.That(t, got, test.ShouldHaveLength, 2)
+	test.That(t, got[0].Control, test.ShouldEqual, input.ButtonNorth)
+	test.That(t, got[0].Event, test.ShouldEqual, input.ButtonPress)
+	test.That(t, got[1].Event, test.ShouldEqual, input.ButtonRelease)
+}
+
+func TestCombosAndMacros(t *testing.T) {
+	s := setup(t, &Config{
+		Source: "source",
+		Combos: []ComboConfig{{Controls: []input.Control{input.ButtonSouth, input.ButtonEast}, Target: input.ButtonMenu}},
+		Macros: []MacroConfig{
+			{Control: input.ButtonMenu, Resource: "arm1", Command: map[string]interface{}{"home": true}},
+			{
+				Control:  input.ButtonSouth,
+				Event:    input.ButtonRelease,
+				Resource: "rdk:component:arm/arm1",
+				Command:  map[string]interface{}{"stop": true},
+			},
+		},
+	})
+	ctx := context.Background()
+
+	controls, err := s.dev.Controls(ctx, nil)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, controls, test.ShouldContain, input.ButtonMenu)
+	test.That(t, controls, test.ShouldContain, input.ButtonSouth)
+
+	var comboEvents []input.EventType
+	err = s.dev.RegisterControlCallback(ctx, input.ButtonMenu, []input.EventType{input.AllEvents},
+		func(ctx context.Context, ev input.Event) {
+			comboEvents = append(comboEvents, ev.Event)
+		}, nil)
+	test.That(t, err, test.ShouldBeNil)
+
+	s.trigger(input.Event{Event: input.ButtonPress, Control: input.ButtonSouth, Value: 1})
+	test.That(t, comboEvents, test.ShouldBeEmpty)
+	s.trigger(input.Event{Event: input.ButtonPress, Control: input.ButtonEast, Value: 1})
+	test.That(t, comboEvents, test.ShouldResemble, []input.EventType{input.ButtonPress})
+	s.trigger(input.Event{Event: input.ButtonRelease, Control: input.ButtonSouth, Value: 0})
+	test.That(t, comboEvents, test.ShouldResemble, []input.EventType{input.ButtonPress, input.ButtonRelease})
+
+	events, err := s.dev.Events(ctx, nil)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, events[input.ButtonMenu].Event, test.ShouldEqual, input.ButtonRelease)
+
+	// macros run in the background so wait for them to finish
+	test.That(t, s.dev.Close(ctx), test.ShouldBeNil)
+	test.That(t, s.commands, test.ShouldHaveLength, 2)
+	test.That(t, s.commands, test.ShouldContain, map[string]interface{}{"home": true})
+	test.That(t, s.commands, test.ShouldContain, map[string]interface{}{"stop": true})
+}
+
+func TestCloseUnregisters(t *testing.T) {
+	s := setup(t, &Config{Source: "source"})
+	test.That(t, s.callbacks, test.ShouldHaveLength, 4)
+	for _, callback := range s.callbacks {
+		test.That(t, callback, test.ShouldNotBeNil)
+	}
+
+	test.That(t, s.dev.Close(context.Background()), test.ShouldBeNil)
+	test.That(t, s.callbacks, test.ShouldHaveLength, 4)
+	for _, callback := range s.callbacks {
+		test.That(t, callback, test.ShouldBeNil)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	_ "go.viam.com/rdk/components/input/fake"
 	_ "go.viam.com/rdk/components/input/gamepad"
 	_ "go.viam.com/rdk/components/input/gpio"
+	_ "go.viam.com/rdk/components/input/mapper"
 	_ "go.viam.com/rdk/components/input/mux"
 	_ "go.viam.com/rdk/components/input/webgamepad"
 )
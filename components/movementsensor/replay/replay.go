@@ -21,6 +21,7 @@ import (
 	"go.viam.com/rdk/components/movementsensor"
 	"go.viam.com/rdk/internal/cloud"
 	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/services/datamanager/datacapture"
 	"go.viam.com/rdk/spatialmath"
 	"go.viam.com/rdk/utils/contextutils"
 )
@@ -98,7 +99,11 @@ func (cfg *Config) Validate(path string) ([]string, error) {
 		return nil, errors.Errorf("batch_size must be between 1 and %d", maxCacheSize)
 	}
 
-	return []string{cloud.InternalServiceName.String()}, nil
+	deps := []string{cloud.InternalServiceName.String()}
+	if cfg.Clock != "" {
+		deps = append(deps, cfg.Clock)
+	}
+	return deps, nil
 }
 
 // Config describes how to configure the replay movement sensor.
@@ -110,6 +115,7 @@ type Config struct {
 	Interval       TimeInterval    `json:"time_interval,omitempty"`
 	BatchSize      *uint64         `json:"batch_size,omitempty"`
 	Properties     map[string]bool `json:"properties,omitempty"`
+	Clock          string          `json:"clock,omitempty"`
 }
 
 // TimeInterval holds the start and end time used to filter data.
@@ -140,6 +146,7 @@ type replayMovementSensor struct {
 	filter   *datapb.Filter
 
 	cache map[string][]*cacheEntry
+	clock datacapture.ReplayClock
 
 	mu     sync.RWMutex
 	closed bool
@@ -163,12 +170,6 @@ func newReplayMovementSensor(ctx context.Context, deps resource.Dependencies, co
 
 // Position returns the next position from the cache, in the form of a geo.Point and altitude.
 func (replay *replayMovementSensor) Position(ctx context.Context, extra map[string]interface{}) (*geo.Point, float64, error) {
-	replay.mu.Lock()
-	defer replay.mu.Unlock()
-	if replay.closed {
-		return nil, 0, errors.New("session closed")
-	}
-
 	data, err := replay.getDataFromCache(ctx, "Position")
 	if err != nil {
 		return nil, 0, err
@@ -181,12 +182,6 @@ func (replay *replayMovementSensor) Position(ctx context.Context, extra map[stri
 
 // LinearVelocity returns the next linear velocity from the cache in the form of an r3.Vector.
 func (replay *replayMovementSensor) LinearVelocity(ctx context.Context, extra map[string]interface{}) (r3.Vector, error) {
-	replay.mu.Lock()
-	defer replay.mu.Unlock()
-	if replay.closed {
-		return r3.Vector{}, errors.New("session closed")
-	}
-
 	data, err := replay.getDataFromCache(ctx, "LinearVelocity")
 	if err != nil {
 		return r3.Vector{}, err
@@ -203,12 +198,6 @@ func (replay *replayMovementSensor) LinearVelocity(ctx context.Context, extra ma
 func (replay *replayMovementSensor) AngularVelocity(ctx context.Context, extra map[string]interface{}) (
 	spatialmath.AngularVelocity, error,
 ) {
-	replay.mu.Lock()
-	defer replay.mu.Unlock()
-	if replay.closed {
-		return spatialmath.AngularVelocity{}, errors.New("session closed")
-	}
-
 	data, err := replay.getDataFromCache(ctx, "AngularVelocity")
 	if err != nil {
 		return spatialmath.AngularVelocity{}, err
@@ -223,12 +212,6 @@ func (replay *replayMovementSensor) AngularVelocity(ctx context.Context, extra m
 
 // LinearAcceleration returns the next linear acceleration from the cache in the form of an r3.Vector.
 func (replay *replayMovementSensor) LinearAcceleration(ctx context.Context, extra map[string]interface{}) (r3.Vector, error) {
-	replay.mu.Lock()
-	defer replay.mu.Unlock()
-	if replay.closed {
-		return r3.Vector{}, errors.New("session closed")
-	}
-
 	data, err := replay.getDataFromCache(ctx, "LinearAcceleration")
 	if err != nil {
 		return r3.Vector{}, err
@@ -243,12 +226,6 @@ func (replay *replayMovementSensor) LinearAcceleration(ctx context.Context, extr
 
 // CompassHeading returns the next compass heading from the cache as a float64.
 func (replay *replayMovementSensor) CompassHeading(ctx context.Context, extra map[string]interface{}) (float64, error) {
-	replay.mu.Lock()
-	defer replay.mu.Unlock()
-	if replay.closed {
-		return 0., errors.New("session closed")
-	}
-
 	data, err := replay.getDataFromCache(ctx, "CompassHeading")
 	if err != nil {
 		return 0., err
@@ -259,12 +236,6 @@ func (replay *replayMovementSensor) CompassHeading(ctx context.Context, extra ma
 
 // Orientation returns the next orientation from the cache as a spatialmath.Orientation created from a spatialmath.OrientationVector.
 func (replay *replayMovementSensor) Orientation(ctx context.Context, extra map[string]interface{}) (spatialmath.Orientation, error) {
-	replay.mu.Lock()
-	defer replay.mu.Unlock()
-	if replay.closed {
-		return nil, errors.New("session closed")
-	}
-
 	data, err := replay.getDataFromCache(ctx, "Orientation")
 	if err != nil {
 		return nil, err
@@ -340,6 +311,14 @@ func (replay *replayMovementSensor) Reconfigure(ctx context.Context, deps resour
 		}
 	}
 
+	replay.clock = nil
+	if replayMovementSensorConfig.Clock != "" {
+		replay.clock, err = datacapture.ReplayClockFromDependencies(deps, replayMovementSensorConfig.Clock)
+		if err != nil {
+			return err
+		}
+	}
+
 	if replayMovementSensorConfig.BatchSize == nil {
 		replay.limit = 1
 	} else {
@@ -442,12 +421,65 @@ func addGRPCMetadata(ctx context.Context, timeRequested, timeReceived *timestamp
 	return nil
 }
 
-// extractDataAndMetadata retrieves the next cached data and removes it from the cache. It assumes the write lock is being held.
+// getDataFromCache retrieves the next data of the method and removes it from the cache, waiting for it to be due on the replay clock
+// if there is one.
 func (replay *replayMovementSensor) getDataFromCache(ctx context.Context, method string) (*structpb.Struct, error) {
+	entry, clock, err := replay.nextEntry(ctx, method)
+	if err != nil {
+		return nil, err
+	}
+
+	// The lock is not held while waiting for the data to be due, so that a paused or slow replay clock does not block the other methods,
+	// Close and Reconfigure.
+	if clock != nil && entry.timeRequested != nil {
+		if err := clock.WaitUntil(ctx, entry.timeRequested.AsTime()); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := addGRPCMetadata(ctx, entry.timeRequested, entry.timeReceived); err != nil {
+		return nil, errors.Wrapf(err, "adding GRPC metadata failed")
+	}
+
+	return entry.data, nil
+}
+
+// nextEntry removes the next entry of the method from the cache and returns it, along with the replay clock it is played back on.
+// On a replay clock, entries which are already past due are skipped, as they are by datacapture.Player, so that the entry returned is
+// the latest one that is due or, if none are, the next one to be.
+func (replay *replayMovementSensor) nextEntry(ctx context.Context, method string) (*cacheEntry, datacapture.ReplayClock, error) {
+	replay.mu.Lock()
+	defer replay.mu.Unlock()
+	if replay.closed {
+		return nil, nil, errors.New("session closed")
+	}
+
 	// If no data remains in the cache, download a new batch of data
 	if len(replay.cache[method]) == 0 {
 		if err := replay.updateCache(ctx, method); err != nil {
-			return nil, errors.Wrapf(err, "could not update the cache")
+			return nil, nil, errors.Wrapf(err, "could not update the cache")
+		}
+	}
+
+	if replay.clock != nil {
+		if timeRequested := replay.cache[method][0].timeRequested; timeRequested != nil {
+			replay.clock.Register(timeRequested.AsTime())
+		}
+		now := replay.clock.Now()
+		for isDue(replay.cache[method][0], now) {
+			if len(replay.cache[method]) == 1 {
+				// the next batch may hold later entries which are due too
+				if err := replay.updateCache(ctx, method); err != nil {
+					if errors.Is(err, ErrEndOfDataset) {
+						break
+					}
+					return nil, nil, errors.Wrapf(err, "could not update the cache")
+				}
+			}
+			if !isDue(replay.cache[method][1], now) {
+				break
+			}
+			replay.cache[method] = replay.cache[method][1:]
 		}
 	}
 
@@ -455,12 +487,12 @@ func (replay *replayMovementSensor) getDataFromCache(ctx context.Context, method
 	methodCache := replay.cache[method]
 	entry := methodCache[0]
 	replay.cache[method] = methodCache[1:]
+	return entry, replay.clock, nil
+}
 
-	if err := addGRPCMetadata(ctx, entry.timeRequested, entry.timeReceived); err != nil {
-		return nil, errors.Wrapf(err, "adding GRPC metadata failed")
-	}
-
-	return entry.data, nil
+// isDue returns whether an entry was requested no later than now.
+func isDue(entry *cacheEntry, now time.Time) bool {
+	return entry.timeRequested != nil && !entry.timeRequested.AsTime().After(now)
 }
 
 // closeCloudConnection closes all parts of the cloud connection used by the replay movement sensor.
@@ -4,7 +4,9 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
+	"github.com/edaniels/golog"
 	"github.com/golang/geo/r3"
 	geo "github.com/kellydunn/golang-geo"
 	"github.com/pkg/errors"
@@ -12,6 +14,8 @@ import (
 	"go.viam.com/utils"
 	"google.golang.org/grpc"
 
+	"go.viam.com/rdk/components/generic"
+	"go.viam.com/rdk/components/generic/replayclock"
 	"go.viam.com/rdk/components/movementsensor"
 	"go.viam.com/rdk/internal/cloud"
 	"go.viam.com/rdk/resource"
@@ -768,3 +772,67 @@ func TestReplayMovementSensorReconfigure(t *testing.T) {
 
 	test.That(t, serverClose(), test.ShouldBeNil)
 }
+
+func TestReplayMovementSensorClock(t *testing.T) {
+	cases := []struct {
+		description string
+		batchSize   *uint64
+	}{
+		{description: "no batch size"},
+		{description: "batch size 4", batchSize: &batchSize4},
+	}
+	for _, tc := range cases {
+		batchSize := tc.batchSize
+		t.Run(tc.description, func(t *testing.T) {
+			ctx := context.Background()
+			logger := golog.NewTestLogger(t)
+			deps, serverClose := createMockCloudDependencies(ctx, t, logger, true)
+			clock, err := replayclock.NewClock(generic.Named("clock"), &replayclock.Config{Start: fmt.Sprintf(testTime, 3), Paused: true})
+			test.That(t, err, test.ShouldBeNil)
+			deps[generic.Named("clock")] = clock
+			cfg := &Config{
+				Source:         validSource,
+				RobotID:        validRobotID,
+				LocationID:     validLocationID,
+				OrganizationID: validOrganizationID,
+				BatchSize:      batchSize,
+				Clock:          "clock",
+			}
+			replay, err := newReplayMovementSensor(ctx, deps, resource.Config{ConvertedAttributes: cfg}, logger)
+			test.That(t, err, test.ShouldBeNil)
+
+			// readings which are already past due are skipped
+			testReplayMovementSensorMethod(ctx, t, replay, "Position", 3, true)
+
+			// a reading which is not yet due waits for the clock
+			waitCtx, cancel := context.WithCancel(ctx)
+			errCh := make(chan error, 1)
+			go func() {
+				_, _, err := replay.Position(waitCtx, nil)
+				errCh <- err
+			}()
+			select {
+			case err := <-errCh:
+				t.Fatalf("position returned before it was due: %v", err)
+			case <-time.After(100 * time.Millisecond):
+			}
+
+			// without blocking the other methods or closing the sensor
+			testReplayMovementSensorMethod(ctx, t, replay, "LinearVelocity", 3, true)
+			closed := make(chan error, 1)
+			go func() {
+				closed <- replay.Close(ctx)
+			}()
+			select {
+			case err := <-closed:
+				test.That(t, err, test.ShouldBeNil)
+			case <-time.After(5 * time.Second):
+				t.Fatal("close blocked on a reading waiting for the clock")
+			}
+
+			cancel()
+			test.That(t, errors.Is(<-errCh, context.Canceled), test.ShouldBeTrue)
+			test.That(t, serverClose(), test.ShouldBeNil)
+		})
+	}
+}
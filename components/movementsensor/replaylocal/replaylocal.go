@@ -0,0 +1,210 @@
+// Package replaylocal implements a replay movement sensor that plays back motion data from local
+// data capture files.
+package replaylocal
+
+import (
+	"context"
+
+	"github.com/edaniels/golog"
+	"github.com/golang/geo/r3"
+	geo "github.com/kellydunn/golang-geo"
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"go.viam.com/rdk/components/movementsensor"
+	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/services/datamanager/datacapture"
+	"go.viam.com/rdk/spatialmath"
+)
+
+var (
+	// model is the model of a local replay movement sensor.
+	model = resource.DefaultModelFamily.WithModel("replay_local")
+
+	// methodList is a list of all the base methods possible for a movement sensor to implement.
+	methodList = []string{"Position", "Orientation", "AngularVelocity", "LinearVelocity", "LinearAcceleration", "CompassHeading"}
+)
+
+// Config describes how to configure the local replay movement sensor.
+type Config = datacapture.ReplayConfig
+
+func init() {
+	resource.RegisterComponent(movementsensor.API, model, resource.Registration[movementsensor.MovementSensor, *Config]{
+		Constructor: newReplayMovementSensor,
+	})
+}
+
+// replayMovementSensor is a movement sensor model that plays back captured movement sensor data.
+// Methods without any captured data are reported as unsupported.
+type replayMovementSensor struct {
+	resource.Named
+	resource.AlwaysRebuild
+	resource.TriviallyCloseable
+
+	players map[string]*datacapture.Player
+}
+
+func newReplayMovementSensor(
+	ctx context.Context,
+	deps resource.Dependencies,
+	conf resource.Config,
+	logger golog.Logger,
+) (movementsensor.MovementSensor, error) {
+	newConf, err := resource.NativeConfig[*Config](conf)
+	if err != nil {
+		return nil, err
+	}
+	replay := &replayMovementSensor{
+		Named:   conf.ResourceName().AsNamed(),
+		players: map[string]*datacapture.Player{},
+	}
+	for _, method := range methodList {
+		player, err := datacapture.NewPlayer(deps, newConf, movementsensor.API, method)
+		if err != nil {
+			return nil, err
+		}
+		if player.Len() == 0 {
+			logger.Debugf("no %s data captured from %q found in %s", method, newConf.Source, newConf.Directory)
+			continue
+		}
+		replay.players[method] = player
+	}
+	if len(replay.players) == 0 {
+		logger.Warnf("no data captured from %q found in %s", newConf.Source, newConf.Directory)
+	}
+	return replay, nil
+}
+
+// next returns the fields of the next captured data for the method, or unimplementedErr if there
+// is no data for the method.
+func (replay *replayMovementSensor) next(ctx context.Context, method string, unimplementedErr error) (
+	map[string]*structpb.Value, error,
+) {
+	player, ok := replay.players[method]
+	if !ok {
+		return nil, unimplementedErr
+	}
+	data, _, err := player.Next(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not replay %s", method)
+	}
+	if err := datacapture.AddReplayMetadata(ctx, data); err != nil {
+		return nil, err
+	}
+	return data.GetStruct().GetFields(), nil
+}
+
+// vectorFromFields returns the vector held by fields captured from an r3.Vector.
+func vectorFromFields(fields map[string]*structpb.Value) r3.Vector {
+	return r3.Vector{
+		X: fields["X"].GetNumberValue(),
+		Y: fields["Y"].GetNumberValue(),
+		Z: fields["Z"].GetNumberValue(),
+	}
+}
+
+// Position returns the next captured position. Altitude is not captured so it is always zero.
+func (replay *replayMovementSensor) Position(ctx context.Context, extra map[string]interface{}) (*geo.Point, float64, error) {
+	fields, err := replay.next(ctx, "Position", movementsensor.ErrMethodUnimplementedPosition)
+	if err != nil {
+		return nil, 0, err
+	}
+	return geo.NewPoint(fields["Lat"].GetNumberValue(), fields["Lng"].GetNumberValue()), 0, nil
+}
+
+// LinearVelocity returns the next captured linear velocity.
+func (replay *replayMovementSensor) LinearVelocity(ctx context.Context, extra map[string]interface{}) (r3.Vector, error) {
+	fields, err := replay.next(ctx, "LinearVelocity", movementsensor.ErrMethodUnimplementedLinearVelocity)
+	if err != nil {
+		return r3.Vector{}, err
+	}
+	return vectorFromFields(fields), nil
+}
+
+// AngularVelocity returns the next captured angular velocity.
+func (replay *replayMovementSensor) AngularVelocity(ctx context.Context, extra map[string]interface{}) (
+	spatialmath.AngularVelocity, error,
+) {
+	fields, err := replay.next(ctx, "AngularVelocity", movementsensor.ErrMethodUnimplementedAngularVelocity)
+	if err != nil {
+		return spatialmath.AngularVelocity{}, err
+	}
+	return spatialmath.AngularVelocity(vectorFromFields(fields)), nil
+}
+
+// LinearAcceleration returns the next captured linear acceleration.
+func (replay *replayMovementSensor) LinearAcceleration(ctx context.Context, extra map[string]interface{}) (r3.Vector, error) {
+	fields, err := replay.next(ctx, "LinearAcceleration", movementsensor.ErrMethodUnimplementedLinearAcceleration)
+	if err != nil {
+		return r3.Vector{}, err
+	}
+	return vectorFromFields(fields), nil
+}
+
+// CompassHeading returns the next captured compass heading.
+func (replay *replayMovementSensor) CompassHeading(ctx context.Context, extra map[string]interface{}) (float64, error) {
+	fields, err := replay.next(ctx, "CompassHeading", movementsensor.ErrMethodUnimplementedCompassHeading)
+	if err != nil {
+		return 0, err
+	}
+	return fields["Heading"].GetNumberValue(), nil
+}
+
+// Orientation returns the next captured orientation. Orientations are captured in whatever
+// representation the movement sensor returned, which is recognized by its fields.
+func (replay *replayMovementSensor) Orientation(ctx context.Context, extra map[string]interface{}) (spatialmath.Orientation, error) {
+	fields, err := replay.next(ctx, "Orientation", movementsensor.ErrMethodUnimplementedOrientation)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case fields["Real"] != nil:
+		return &spatialmath.Quaternion{
+			Real: fields["Real"].GetNumberValue(),
+			Imag: fields["Imag"].GetNumberValue(),
+			Jmag: fields["Jmag"].GetNumberValue(),
+			Kmag: fields["Kmag"].GetNumberValue(),
+		}, nil
+	case fields["roll"] != nil:
+		return &spatialmath.EulerAngles{
+			Roll:  fields["roll"].GetNumberValue(),
+			Pitch: fields["pitch"].GetNumberValue(),
+			Yaw:   fields["yaw"].GetNumberValue(),
+		}, nil
+	case fields["th"] != nil:
+		return &spatialmath.OrientationVector{
+			Theta: fields["th"].GetNumberValue(),
+			OX:    fields["x"].GetNumberValue(),
+			OY:    fields["y"].GetNumberValue(),
+			OZ:    fields["z"].GetNumberValue(),
+		}, nil
+	default:
+		return nil, errors.New("captured data does not contain a known orientation")
+	}
+}
+
+// Properties reports the methods that have captured data as supported.
+func (replay *replayMovementSensor) Properties(ctx context.Context, extra map[string]interface{}) (*movementsensor.Properties, error) {
+	has := func(method string) bool {
+		_, ok := replay.players[method]
+		return ok
+	}
+	return &movementsensor.Properties{
+		LinearVelocitySupported:     has("LinearVelocity"),
+		AngularVelocitySupported:    has("AngularVelocity"),
+		OrientationSupported:        has("Orientation"),
+		PositionSupported:           has("Position"),
+		CompassHeadingSupported:     has("CompassHeading"),
+		LinearAccelerationSupported: has("LinearAcceleration"),
+	}, nil
+}
+
+// Accuracy is currently not defined for replay movement sensors.
+func (replay *replayMovementSensor) Accuracy(ctx context.Context, extra map[string]interface{}) (map[string]float32, error) {
+	return map[string]float32{}, movementsensor.ErrMethodUnimplementedAccuracy
+}
+
+// Readings returns the next captured data of every method that has any.
+func (replay *replayMovementSensor) Readings(ctx context.Context, extra map[string]interface{}) (map[string]interface{}, error) {
+	return movementsensor.Readings(ctx, replay, extra)
+}
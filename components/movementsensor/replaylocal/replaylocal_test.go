@@ -0,0 +1,100 @@
+package replaylocal
+
+import (
+	"context"
+	"testing"
+
+	"github.com/edaniels/golog"
+	"github.com/golang/geo/r3"
+	v1 "go.viam.com/api/app/datasync/v1"
+	"go.viam.com/test"
+	"go.viam.com/utils/protoutils"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"go.viam.com/rdk/components/movementsensor"
+	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/services/datamanager/datacapture"
+	"go.viam.com/rdk/spatialmath"
+)
+
+// writeCaptureFile writes the readings of a method of the movement sensor being replayed to a capture file named for the method.
+func writeCaptureFile(t *testing.T, dir, method string, readings ...interface{}) {
+	t.Helper()
+	md, err := datacapture.BuildCaptureMetadata(movementsensor.API, "ms1", method, nil, nil)
+	test.That(t, err, test.ShouldBeNil)
+	sensorData := make([]*v1.SensorData, 0, len(readings))
+	for _, reading := range readings {
+		pbReading, err := protoutils.StructToStructPb(reading)
+		test.That(t, err, test.ShouldBeNil)
+		now := timestamppb.Now()
+		sensorData = append(sensorData, &v1.SensorData{
+			Metadata: &v1.SensorMetadata{TimeRequested: now, TimeReceived: now},
+			Data:     &v1.SensorData_Struct{Struct: pbReading},
+		})
+	}
+	datacapture.WriteTestFile(t, dir, method, md, sensorData...)
+}
+
+func TestReplayMovementSensor(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	// these match the shapes written by the movement sensor collectors
+	writeCaptureFile(t, dir, "Position", struct{ Lat, Lng float64 }{Lat: 40.7, Lng: -74})
+	writeCaptureFile(t, dir, "LinearVelocity", r3.Vector{X: 1, Y: 2, Z: 3})
+	writeCaptureFile(t, dir, "AngularVelocity", spatialmath.AngularVelocity{Z: 0.5})
+	writeCaptureFile(t, dir, "CompassHeading", struct{ Heading float64 }{Heading: 90})
+	writeCaptureFile(t, dir, "Orientation",
+		&spatialmath.OrientationVector{Theta: 1, OZ: 1},
+		&spatialmath.EulerAngles{Yaw: 1},
+	)
+
+	ms, err := newReplayMovementSensor(ctx, nil, resource.Config{
+		Name:                "replay",
+		ConvertedAttributes: &Config{Directory: dir, Source: "ms1"},
+	}, golog.NewTestLogger(t))
+	test.That(t, err, test.ShouldBeNil)
+	defer func() {
+		test.That(t, ms.Close(ctx), test.ShouldBeNil)
+	}()
+
+	props, err := ms.Properties(ctx, nil)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, props, test.ShouldResemble, &movementsensor.Properties{
+		LinearVelocitySupported:  true,
+		AngularVelocitySupported: true,
+		OrientationSupported:     true,
+		PositionSupported:        true,
+		CompassHeadingSupported:  true,
+	})
+
+	pos, alt, err := ms.Position(ctx, nil)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, pos.Lat(), test.ShouldEqual, 40.7)
+	test.That(t, pos.Lng(), test.ShouldEqual, -74)
+	test.That(t, alt, test.ShouldEqual, 0)
+
+	vel, err := ms.LinearVelocity(ctx, nil)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, vel, test.ShouldResemble, r3.Vector{X: 1, Y: 2, Z: 3})
+
+	angVel, err := ms.AngularVelocity(ctx, nil)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, angVel, test.ShouldResemble, spatialmath.AngularVelocity{Z: 0.5})
+
+	heading, err := ms.CompassHeading(ctx, nil)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, heading, test.ShouldEqual, 90)
+
+	ori, err := ms.Orientation(ctx, nil)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, ori, test.ShouldResemble, &spatialmath.OrientationVector{Theta: 1, OZ: 1})
+	ori, err = ms.Orientation(ctx, nil)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, ori, test.ShouldResemble, &spatialmath.EulerAngles{Yaw: 1})
+	_, err = ms.Orientation(ctx, nil)
+	test.That(t, err, test.ShouldBeError, "could not replay Orientation: "+datacapture.ErrEndOfDataset.Error())
+
+	_, err = ms.LinearAcceleration(ctx, nil)
+	test.That(t, err, test.ShouldBeError, movementsensor.ErrMethodUnimplementedLinearAcceleration)
+}
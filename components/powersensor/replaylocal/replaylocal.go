@@ -0,0 +1,106 @@
+// Package replaylocal implements a replay power sensor that plays back data from local data capture files.
+package replaylocal
+
+import (
+	"context"
+
+	"github.com/edaniels/golog"
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"go.viam.com/rdk/components/powersensor"
+	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/services/datamanager/datacapture"
+)
+
+// model is the model of a local replay power sensor.
+var model = resource.DefaultModelFamily.WithModel("replay_local")
+
+// Config describes how to configure the local replay power sensor.
+type Config = datacapture.ReplayConfig
+
+func init() {
+	resource.RegisterComponent(powersensor.API, model, resource.Registration[powersensor.PowerSensor, *Config]{
+		Constructor: newReplayPowerSensor,
+	})
+}
+
+// replayPowerSensor is a power sensor model that plays back captured voltage, current and power.
+type replayPowerSensor struct {
+	resource.Named
+	resource.AlwaysRebuild
+	resource.TriviallyCloseable
+
+	players map[string]*datacapture.Player
+}
+
+func newReplayPowerSensor(
+	ctx context.Context,
+	deps resource.Dependencies,
+	conf resource.Config,
+	logger golog.Logger,
+) (powersensor.PowerSensor, error) {
+	newConf, err := resource.NativeConfig[*Config](conf)
+	if err != nil {
+		return nil, err
+	}
+	ps := &replayPowerSensor{
+		Named:   conf.ResourceName().AsNamed(),
+		players: map[string]*datacapture.Player{},
+	}
+	for _, method := range []string{"Voltage", "Current", "Power"} {
+		player, err := datacapture.NewPlayer(deps, newConf, powersensor.API, method)
+		if err != nil {
+			return nil, err
+		}
+		if player.Len() == 0 {
+			logger.Debugf("no %s data captured from %q found in %s", method, newConf.Source, newConf.Directory)
+		}
+		ps.players[method] = player
+	}
+	return ps, nil
+}
+
+// next returns the fields of the next captured data for the method.
+func (ps *replayPowerSensor) next(ctx context.Context, method string) (map[string]*structpb.Value, error) {
+	data, _, err := ps.players[method].Next(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not replay %s", method)
+	}
+	if err := datacapture.AddReplayMetadata(ctx, data); err != nil {
+		return nil, err
+	}
+	return data.GetStruct().GetFields(), nil
+}
+
+// Voltage returns the next captured voltage and whether it is AC.
+func (ps *replayPowerSensor) Voltage(ctx context.Context, extra map[string]interface{}) (float64, bool, error) {
+	fields, err := ps.next(ctx, "Voltage")
+	if err != nil {
+		return 0, false, err
+	}
+	return fields["Volts"].GetNumberValue(), fields["IsAc"].GetBoolValue(), nil
+}
+
+// Current returns the next captured current and whether it is AC.
+func (ps *replayPowerSensor) Current(ctx context.Context, extra map[string]interface{}) (float64, bool, error) {
+	fields, err := ps.next(ctx, "Current")
+	if err != nil {
+		return 0, false, err
+	}
+	return fields["Amperes"].GetNumberValue(), fields["IsAc"].GetBoolValue(), nil
+}
+
+// Power returns the next captured power.
+func (ps *replayPowerSensor) Power(ctx context.Context, extra map[string]interface{}) (float64, error) {
+	fields, err := ps.next(ctx, "Power")
+	if err != nil {
+		return 0, err
+	}
+	return fields["Watts"].GetNumberValue(), nil
+}
+
+// Readings returns the next captured voltage, current and power.
+func (ps *replayPowerSensor) Readings(ctx context.Context, extra map[string]interface{}) (map[string]interface{}, error) {
+	return powersensor.Readings(ctx, ps, extra)
+}
@@ -0,0 +1,57 @@
+package replaylocal
+
+import (
+	"context"
+	"testing"
+
+	"github.com/edaniels/golog"
+	v1 "go.viam.com/api/app/datasync/v1"
+	"go.viam.com/test"
+	"go.viam.com/utils/protoutils"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"go.viam.com/rdk/components/powersensor"
+	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/services/datamanager/datacapture"
+)
+
+func TestReplayPowerSensor(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	// only voltage was captured
+	md, err := datacapture.BuildCaptureMetadata(powersensor.API, "ps1", "Voltage", nil, nil)
+	test.That(t, err, test.ShouldBeNil)
+	f, err := datacapture.NewFile(dir, md)
+	test.That(t, err, test.ShouldBeNil)
+	reading, err := protoutils.StructToStructPb(struct {
+		Volts float64
+		IsAc  bool
+	}{Volts: 12.5, IsAc: false})
+	test.That(t, err, test.ShouldBeNil)
+	now := timestamppb.Now()
+	test.That(t, f.WriteNext(&v1.SensorData{
+		Metadata: &v1.SensorMetadata{TimeRequested: now, TimeReceived: now},
+		Data:     &v1.SensorData_Struct{Struct: reading},
+	}), test.ShouldBeNil)
+	test.That(t, f.Close(), test.ShouldBeNil)
+
+	ps, err := newReplayPowerSensor(ctx, nil, resource.Config{
+		Name:                "replay",
+		ConvertedAttributes: &Config{Directory: dir, Source: "ps1"},
+	}, golog.NewTestLogger(t))
+	test.That(t, err, test.ShouldBeNil)
+	defer func() {
+		test.That(t, ps.Close(ctx), test.ShouldBeNil)
+	}()
+
+	volts, isAC, err := ps.Voltage(ctx, nil)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, volts, test.ShouldEqual, 12.5)
+	test.That(t, isAC, test.ShouldBeFalse)
+
+	_, _, err = ps.Voltage(ctx, nil)
+	test.That(t, err, test.ShouldBeError, "could not replay Voltage: "+datacapture.ErrEndOfDataset.Error())
+	_, err = ps.Power(ctx, nil)
+	test.That(t, err, test.ShouldBeError, "could not replay Power: "+datacapture.ErrEndOfDataset.Error())
+}
@@ -0,0 +1,82 @@
+// Package replaylocal implements a replay sensor that plays back readings from local data capture files.
+package replaylocal
+
+import (
+	"context"
+
+	"github.com/edaniels/golog"
+	"github.com/pkg/errors"
+
+	"go.viam.com/rdk/components/sensor"
+	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/services/datamanager/datacapture"
+)
+
+// model is the model of a local replay sensor.
+var model = resource.DefaultModelFamily.WithModel("replay_local")
+
+// Config describes how to configure the local replay sensor.
+type Config = datacapture.ReplayConfig
+
+func init() {
+	resource.RegisterComponent(sensor.API, model, resource.Registration[sensor.Sensor, *Config]{
+		Constructor: newReplaySensor,
+	})
+}
+
+// replaySensor is a sensor model that plays back captured readings.
+type replaySensor struct {
+	resource.Named
+	resource.AlwaysRebuild
+	resource.TriviallyCloseable
+
+	readings *datacapture.Player
+}
+
+func newReplaySensor(ctx context.Context, deps resource.Dependencies, conf resource.Config, logger golog.Logger) (sensor.Sensor, error) {
+	newConf, err := resource.NativeConfig[*Config](conf)
+	if err != nil {
+		return nil, err
+	}
+	readings, err := datacapture.NewPlayer(deps, newConf, sensor.API, "Readings")
+	if err != nil {
+		return nil, err
+	}
+	if readings.Len() == 0 {
+		logger.Warnf("no readings captured from %q found in %s", newConf.Source, newConf.Directory)
+	}
+	return &replaySensor{
+		Named:    conf.ResourceName().AsNamed(),
+		readings: readings,
+	}, nil
+}
+
+// Readings returns the next captured readings.
+func (s *replaySensor) Readings(ctx context.Context, extra map[string]interface{}) (map[string]interface{}, error) {
+	data, _, err := s.readings.Next(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := datacapture.AddReplayMetadata(ctx, data); err != nil {
+		return nil, err
+	}
+
+	// readings are captured as a list of name and value records
+	records, ok := data.GetStruct().AsMap()["Readings"].([]interface{})
+	if !ok {
+		return nil, errors.New("captured data does not contain sensor readings")
+	}
+	readings := make(map[string]interface{}, len(records))
+	for _, record := range records {
+		fields, ok := record.(map[string]interface{})
+		if !ok {
+			return nil, errors.New("captured data contains a malformed sensor reading")
+		}
+		name, ok := fields["ReadingName"].(string)
+		if !ok {
+			return nil, errors.New("captured data contains a sensor reading without a name")
+		}
+		readings[name] = fields["Reading"]
+	}
+	return readings, nil
+}
@@ -0,0 +1,52 @@
+package replaylocal
+
+import (
+	"context"
+	"testing"
+
+	"github.com/edaniels/golog"
+	v1 "go.viam.com/api/app/datasync/v1"
+	"go.viam.com/test"
+	"go.viam.com/utils/protoutils"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"go.viam.com/rdk/components/sensor"
+	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/services/datamanager/datacapture"
+)
+
+func TestReplaySensor(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	md, err := datacapture.BuildCaptureMetadata(sensor.API, "sensor1", "Readings", nil, nil)
+	test.That(t, err, test.ShouldBeNil)
+	f, err := datacapture.NewFile(dir, md)
+	test.That(t, err, test.ShouldBeNil)
+	reading, err := protoutils.StructToStructPb(sensor.ReadingRecords{Readings: []sensor.ReadingRecord{
+		{ReadingName: "temperature", Reading: 21.5},
+		{ReadingName: "unit", Reading: "celsius"},
+	}})
+	test.That(t, err, test.ShouldBeNil)
+	now := timestamppb.Now()
+	test.That(t, f.WriteNext(&v1.SensorData{
+		Metadata: &v1.SensorMetadata{TimeRequested: now, TimeReceived: now},
+		Data:     &v1.SensorData_Struct{Struct: reading},
+	}), test.ShouldBeNil)
+	test.That(t, f.Close(), test.ShouldBeNil)
+
+	s, err := newReplaySensor(ctx, nil, resource.Config{
+		Name:                "replay",
+		ConvertedAttributes: &Config{Directory: dir, Source: "sensor1", Loop: true},
+	}, golog.NewTestLogger(t))
+	test.That(t, err, test.ShouldBeNil)
+	defer func() {
+		test.That(t, s.Close(ctx), test.ShouldBeNil)
+	}()
+
+	for i := 0; i < 2; i++ {
+		readings, err := s.Readings(ctx, nil)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, readings, test.ShouldResemble, map[string]interface{}{"temperature": 21.5, "unit": "celsius"})
+	}
+}
@@ -0,0 +1,56 @@
+package gpio
+
+import "math"
+
+// motionProfile is a trapezoidal velocity profile over a distance in degrees. It accelerates at
+// maxAcc up to maxVel, cruises, then decelerates to a stop. A zero maxAcc accelerates instantly
+// and a zero maxVel never stops accelerating until it has to decelerate.
+type motionProfile struct {
+	dist       float64
+	vel        float64 // peak velocity reached
+	acc        float64
+	accelTime  float64
+	cruiseTime float64
+}
+
+func newMotionProfile(dist, maxVel, maxAcc float64) motionProfile {
+	p := motionProfile{dist: dist, vel: maxVel, acc: maxAcc}
+	if dist <= 0 {
+		return motionProfile{}
+	}
+	if maxAcc <= 0 {
+		p.cruiseTime = dist / maxVel
+		return p
+	}
+	// Without a velocity limit, or without enough distance to reach it, the profile is a triangle.
+	if maxVel <= 0 || maxVel*maxVel/maxAcc > dist {
+		p.accelTime = math.Sqrt(dist / maxAcc)
+		p.vel = maxAcc * p.accelTime
+		return p
+	}
+	p.accelTime = maxVel / maxAcc
+	p.cruiseTime = (dist - maxVel*p.accelTime) / maxVel
+	return p
+}
+
+// duration returns how long the move takes in seconds.
+func (p motionProfile) duration() float64 {
+	return 2*p.accelTime + p.cruiseTime
+}
+
+// position returns the distance travelled after t seconds.
+func (p motionProfile) position(t float64) float64 {
+	switch {
+	case t <= 0:
+		return 0
+	case t >= p.duration():
+		return p.dist
+	case t < p.accelTime:
+		return 0.5 * p.acc * t * t
+	case t < p.accelTime+p.cruiseTime:
+		return 0.5*p.vel*p.accelTime + p.vel*(t-p.accelTime)
+	default:
+		remaining := p.duration() - t
+		return p.dist - 0.5*p.acc*remaining*remaining
+	}
+}
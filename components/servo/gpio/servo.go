@@ -22,8 +22,17 @@ const (
 	minWidthUs    uint    = 500  // absolute minimum PWM width
 	maxWidthUs    uint    = 2500 // absolute maximum PWM width
 	defaultFreq   uint    = 300
+
+	// profileStepPeriod is how often the pulse width is updated during a smooth move.
+	profileStepPeriod = 20 * time.Millisecond
 )
 
+// CalibrationPoint maps an angle of the servo to the pulse width that reaches it.
+type CalibrationPoint struct {
+	AngleDeg float64 `json:"angle_deg"`
+	WidthUs  float64 `json:"width_us"`
+}
+
 // We want to distinguish values that are 0 because the user set them to 0 from ones that are 0
 // because that's the default when the user didn't set them. Consequently, all numerical fields in
 // this struct are pointers. They'll be nil if they were unset, and point to a value (possibly 0!)
@@ -46,6 +55,16 @@ type servoConfig struct {
 	MinWidthUs *uint `json:"min_width_us,omitempty"`
 	// MaxWidthUs overrides the safe maximum PWM width in microseconds.
 	MaxWidthUs *uint `json:"max_width_us,omitempty"`
+	// Calibration maps angles to pulse widths, interpolating linearly between points. If omitted,
+	// min_angle_deg..max_angle_deg maps linearly onto min_width_us..max_width_us.
+	Calibration []CalibrationPoint `json:"calibration,omitempty"`
+	// TrimDeg is added to every commanded angle to correct for how the horn is mounted.
+	TrimDeg *float64 `json:"trim_deg,omitempty"`
+	// MaxVelocityDegPerSec limits how fast the servo is driven to a new angle. If omitted or 0, the
+	// servo is sent straight to the new angle unless an acceleration limit is set.
+	MaxVelocityDegPerSec *float64 `json:"max_velocity_deg_per_sec,omitempty"`
+	// MaxAccelerationDegPerSec2 limits how quickly the servo speeds up and slows down during a move.
+	MaxAccelerationDegPerSec2 *float64 `json:"max_acceleration_deg_per_sec_per_sec,omitempty"`
 }
 
 // Validate ensures all parts of the config are valid.
@@ -83,9 +102,44 @@ func (config *servoConfig) Validate(path string) ([]string, error) {
 	if config.MaxWidthUs != nil && *config.MaxWidthUs > maxWidthUs {
 		return nil, viamutils.NewConfigValidationError(path, errors.Errorf("max_width_us cannot be higher than %d", maxWidthUs))
 	}
+	if config.MaxVelocityDegPerSec != nil && *config.MaxVelocityDegPerSec < 0 {
+		return nil, viamutils.NewConfigValidationError(path, errors.New("max_velocity_deg_per_sec cannot be negative"))
+	}
+	if config.MaxAccelerationDegPerSec2 != nil && *config.MaxAccelerationDegPerSec2 < 0 {
+		return nil, viamutils.NewConfigValidationError(path, errors.New("max_acceleration_deg_per_sec_per_sec cannot be negative"))
+	}
+	if err := validateCalibration(config.Calibration); err != nil {
+		return nil, viamutils.NewConfigValidationError(path, err)
+	}
 	return deps, nil
 }
 
+// validateCalibration checks that a calibration table can be interpolated in both directions.
+func validateCalibration(points []CalibrationPoint) error {
+	if len(points) == 0 {
+		return nil
+	}
+	if len(points) < 2 {
+		return errors.New("calibration needs at least two points")
+	}
+	increasing := points[1].WidthUs > points[0].WidthUs
+	for i, point := range points {
+		if point.WidthUs < float64(minWidthUs) || point.WidthUs > float64(maxWidthUs) {
+			return errors.Errorf("calibration width_us must be between %d and %d, have %.1f", minWidthUs, maxWidthUs, point.WidthUs)
+		}
+		if i == 0 {
+			continue
+		}
+		if point.AngleDeg <= points[i-1].AngleDeg {
+			return errors.New("calibration angles must be strictly increasing")
+		}
+		if (point.WidthUs > points[i-1].WidthUs) != increasing || point.WidthUs == points[i-1].WidthUs {
+			return errors.New("calibration widths must be strictly increasing or strictly decreasing")
+		}
+	}
+	return nil
+}
+
 var model = resource.DefaultModelFamily.WithModel("gpio")
 
 func init() {
@@ -109,6 +163,13 @@ type servoGPIO struct {
 	maxUs     uint
 	pwmRes    uint
 	currPct   float64
+
+	calibration []CalibrationPoint
+	trimDeg     float64
+	maxVel      float64
+	maxAcc      float64
+	// currDeg is the last angle commanded, which smooth moves start from.
+	currDeg float64
 }
 
 func newGPIOServo(ctx context.Context, deps resource.Dependencies, conf resource.Config, logger golog.Logger) (servo.Servo, error) {
@@ -149,6 +210,26 @@ func newGPIOServo(ctx context.Context, deps resource.Dependencies, conf resource
 		maxUs = *newConf.MaxWidthUs
 	}
 
+	calibration := newConf.Calibration
+	if len(calibration) == 0 {
+		calibration = []CalibrationPoint{
+			{AngleDeg: minDeg, WidthUs: float64(minUs)},
+			{AngleDeg: maxDeg, WidthUs: float64(maxUs)},
+		}
+	}
+	trimDeg := 0.0
+	if newConf.TrimDeg != nil {
+		trimDeg = *newConf.TrimDeg
+	}
+	maxVel := 0.0
+	if newConf.MaxVelocityDegPerSec != nil {
+		maxVel = *newConf.MaxVelocityDegPerSec
+	}
+	maxAcc := 0.0
+	if newConf.MaxAccelerationDegPerSec2 != nil {
+		maxAcc = *newConf.MaxAccelerationDegPerSec2
+	}
+
 	// If the frequency isn't specified in the config, we'll use whatever it's currently set to
 	// instead. If it's currently set to 0, we'll default to using 300 Hz.
 	frequency, err := pin.PWMFreq(ctx, nil)
@@ -192,6 +273,13 @@ func newGPIOServo(ctx context.Context, deps resource.Dependencies, conf resource
 		minUs:     minUs,
 		maxUs:     maxUs,
 		currPct:   0,
+
+		calibration: calibration,
+		trimDeg:     trimDeg,
+		maxVel:      maxVel,
+		maxAcc:      maxAcc,
+		// the servo's actual position is unknown, so go straight to the start position
+		currDeg: startPos,
 	}
 
 	// Try to detect the PWM resolution.
@@ -208,31 +296,50 @@ func newGPIOServo(ctx context.Context, deps resource.Dependencies, conf resource
 	return servo, nil
 }
 
-// Given minUs, maxUs, deg, and frequency attempt to calculate the corresponding duty cycle pct.
-func mapDegToDutyCylePct(minUs, maxUs uint, minDeg, maxDeg, deg float64, frequency uint) float64 {
+// Given a calibration table, an angle, and a frequency, calculate the corresponding duty cycle pct.
+// The pulse width is kept between minUs and maxUs.
+func mapDegToDutyCylePct(calibration []CalibrationPoint, minUs, maxUs uint, deg float64, frequency uint) float64 {
 	period := 1.0 / float64(frequency)
-	degRange := maxDeg - minDeg
-	uSRange := float64(maxUs - minUs)
+	pwmWidthUs := interpolate(calibration, deg,
+		func(p CalibrationPoint) float64 { return p.AngleDeg },
+		func(p CalibrationPoint) float64 { return p.WidthUs })
 
-	usPerDeg := uSRange / degRange
-
-	pwmWidthUs := float64(minUs) + (deg-minDeg)*usPerDeg
+	pwmWidthUs = math.Max(float64(minUs), pwmWidthUs)
+	pwmWidthUs = math.Min(float64(maxUs), pwmWidthUs)
 	return (pwmWidthUs / (1000 * 1000)) / period
 }
 
-// Given minUs, maxUs, duty cycle pct, and frequency returns the position in degrees.
-func mapDutyCylePctToDeg(minUs, maxUs uint, minDeg, maxDeg, pct float64, frequency uint) float64 {
+// Given a calibration table, a duty cycle pct, and a frequency, returns the position in degrees.
+func mapDutyCylePctToDeg(calibration []CalibrationPoint, minUs, maxUs uint, pct float64, frequency uint) float64 {
 	period := 1.0 / float64(frequency)
 	pwmWidthUs := pct * period * 1000 * 1000
-	degRange := maxDeg - minDeg
-	uSRange := float64(maxUs - minUs)
 
 	pwmWidthUs = math.Max(float64(minUs), pwmWidthUs)
 	pwmWidthUs = math.Min(float64(maxUs), pwmWidthUs)
 
-	degsPerUs := degRange / uSRange
+	// interpolate needs the inputs in increasing order
+	inverse := calibration
+	if calibration[0].WidthUs > calibration[len(calibration)-1].WidthUs {
+		inverse = make([]CalibrationPoint, len(calibration))
+		for i, p := range calibration {
+			inverse[len(calibration)-1-i] = p
+		}
+	}
+	return interpolate(inverse, pwmWidthUs,
+		func(p CalibrationPoint) float64 { return p.WidthUs },
+		func(p CalibrationPoint) float64 { return p.AngleDeg })
+}
 
-	return math.Round(minDeg + (pwmWidthUs-float64(minUs))*degsPerUs)
+// interpolate linearly maps x to y using the segment of points that contains x, extending the
+// first or last segment when x is outside of the points. Points must be sorted by increasing x.
+func interpolate(points []CalibrationPoint, x float64, getX, getY func(CalibrationPoint) float64) float64 {
+	i := 1
+	for i < len(points)-1 && x > getX(points[i]) {
+		i++
+	}
+	x0, y0 := getX(points[i-1]), getY(points[i-1])
+	x1, y1 := getX(points[i]), getY(points[i])
+	return y0 + (x-x0)*(y1-y0)/(x1-x0)
 }
 
 // Attempt to find the PWM resolution assuming a hardware PWM
@@ -306,8 +413,8 @@ func (s *servoGPIO) findPWMResolution(ctx context.Context) error {
 	return nil
 }
 
-// Move moves the servo to the given angle (0-180 degrees)
-// This will block until done or a new operation cancels this one.
+// Move moves the servo to the given angle (0-180 degrees), limiting its velocity and acceleration
+// if configured to. This will block until done or a new operation cancels this one.
 func (s *servoGPIO) Move(ctx context.Context, ang uint32, extra map[string]interface{}) error {
 	ctx, done := s.opMgr.New(ctx)
 	defer done()
@@ -318,7 +425,35 @@ func (s *servoGPIO) Move(ctx context.Context, ang uint32, extra map[string]inter
 	if angle > s.maxDeg {
 		angle = s.maxDeg
 	}
-	pct := mapDegToDutyCylePct(s.minUs, s.maxUs, s.minDeg, s.maxDeg, angle, s.frequency)
+	if s.maxVel <= 0 && s.maxAcc <= 0 {
+		return s.setAngle(ctx, angle)
+	}
+
+	// Step the pulse width along the motion profile so the servo never moves faster than allowed.
+	startDeg := s.currDeg
+	dir := 1.0
+	if angle < startDeg {
+		dir = -1.0
+	}
+	profile := newMotionProfile(math.Abs(angle-startDeg), s.maxVel, s.maxAcc)
+	start := time.Now()
+	for {
+		elapsed := time.Since(start).Seconds()
+		if elapsed >= profile.duration() {
+			return s.setAngle(ctx, angle)
+		}
+		if err := s.setAngle(ctx, startDeg+dir*profile.position(elapsed)); err != nil {
+			return err
+		}
+		if !viamutils.SelectContextOrWait(ctx, profileStepPeriod) {
+			return ctx.Err()
+		}
+	}
+}
+
+// setAngle sets the pulse width for the given angle, including the trim.
+func (s *servoGPIO) setAngle(ctx context.Context, angle float64) error {
+	pct := mapDegToDutyCylePct(s.calibration, s.minUs, s.maxUs, angle+s.trimDeg, s.frequency)
 	if s.pwmRes != 0 {
 		realTick := math.Round(pct * float64(s.pwmRes))
 		pct = realTick / float64(s.pwmRes)
@@ -327,16 +462,19 @@ func (s *servoGPIO) Move(ctx context.Context, ang uint32, extra map[string]inter
 		return errors.Wrap(err, "couldn't move the servo")
 	}
 	s.currPct = pct
+	s.currDeg = angle
 	return nil
 }
 
-// Position returns the current set angle (degrees) of the servo.
+// Position returns the current set angle (degrees) of the servo. During a smooth move this is the
+// angle the servo is currently being driven to along the way.
 func (s *servoGPIO) Position(ctx context.Context, extra map[string]interface{}) (uint32, error) {
 	pct, err := s.pin.PWM(ctx, nil)
 	if err != nil {
 		return 0, errors.Wrap(err, "couldn't get servo pin duty cycle")
 	}
-	return uint32(mapDutyCylePctToDeg(s.minUs, s.maxUs, s.minDeg, s.maxDeg, pct, s.frequency)), nil
+	deg := math.Round(mapDutyCylePctToDeg(s.calibration, s.minUs, s.maxUs, pct, s.frequency) - s.trimDeg)
+	return uint32(math.Max(0, deg)), nil
 }
 
 // Stop stops the servo. It is assumed the servo stops immediately.
@@ -4,6 +4,7 @@ package gpio
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/edaniels/golog"
 	"github.com/pkg/errors"
@@ -96,6 +97,87 @@ func TestValidate(t *testing.T) {
 	test.That(t, err.Error(),
 		test.ShouldContainSubstring,
 		"error validating \"test\": \"pin\" is required")
+	cfg.Pin = "a"
+
+	cfg.MaxVelocityDegPerSec = ptr(-1.0)
+	_, err = cfg.Validate("test")
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "max_velocity_deg_per_sec cannot be negative")
+	cfg.MaxVelocityDegPerSec = ptr(90.0)
+
+	cfg.Calibration = []CalibrationPoint{{AngleDeg: 0, WidthUs: 1000}}
+	_, err = cfg.Validate("test")
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "at least two points")
+
+	cfg.Calibration = []CalibrationPoint{{AngleDeg: 0, WidthUs: 1000}, {AngleDeg: 90, WidthUs: 2600}}
+	_, err = cfg.Validate("test")
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "width_us must be between 500 and 2500")
+
+	cfg.Calibration = []CalibrationPoint{{AngleDeg: 0, WidthUs: 1000}, {AngleDeg: 90, WidthUs: 1500}, {AngleDeg: 180, WidthUs: 1200}}
+	_, err = cfg.Validate("test")
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "strictly increasing or strictly decreasing")
+
+	cfg.Calibration = []CalibrationPoint{{AngleDeg: 180, WidthUs: 2400}, {AngleDeg: 0, WidthUs: 600}}
+	_, err = cfg.Validate("test")
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "angles must be strictly increasing")
+
+	cfg.Calibration = []CalibrationPoint{{AngleDeg: 0, WidthUs: 2400}, {AngleDeg: 90, WidthUs: 1400}, {AngleDeg: 180, WidthUs: 600}}
+	_, err = cfg.Validate("test")
+	test.That(t, err, test.ShouldBeNil)
+}
+
+func TestCalibration(t *testing.T) {
+	calibration := []CalibrationPoint{
+		{AngleDeg: 0, WidthUs: 600},
+		{AngleDeg: 90, WidthUs: 1400},
+		{AngleDeg: 180, WidthUs: 2400},
+	}
+	toUs := func(pct float64) float64 { return pct * 1e6 / 50 }
+	toPct := func(us float64) float64 { return us * 50 / 1e6 }
+
+	test.That(t, toUs(mapDegToDutyCylePct(calibration, 500, 2500, 45, 50)), test.ShouldAlmostEqual, 1000)
+	test.That(t, toUs(mapDegToDutyCylePct(calibration, 500, 2500, 135, 50)), test.ShouldAlmostEqual, 1900)
+	// past the ends of the table the closest segment is extended, but never past the width limits
+	test.That(t, toUs(mapDegToDutyCylePct(calibration, 500, 2500, 189, 50)), test.ShouldAlmostEqual, 2500)
+	test.That(t, toUs(mapDegToDutyCylePct(calibration, 500, 2500, -9, 50)), test.ShouldAlmostEqual, 520)
+
+	test.That(t, mapDutyCylePctToDeg(calibration, 500, 2500, toPct(1000), 50), test.ShouldAlmostEqual, 45)
+	test.That(t, mapDutyCylePctToDeg(calibration, 500, 2500, toPct(1900), 50), test.ShouldAlmostEqual, 135)
+
+	reversed := []CalibrationPoint{
+		{AngleDeg: 0, WidthUs: 2400},
+		{AngleDeg: 180, WidthUs: 600},
+	}
+	test.That(t, toUs(mapDegToDutyCylePct(reversed, 500, 2500, 60, 50)), test.ShouldAlmostEqual, 1800)
+	test.That(t, mapDutyCylePctToDeg(reversed, 500, 2500, toPct(1800), 50), test.ShouldAlmostEqual, 60)
+}
+
+func TestMotionProfile(t *testing.T) {
+	// constant velocity
+	p := newMotionProfile(90, 180, 0)
+	test.That(t, p.duration(), test.ShouldAlmostEqual, 0.5)
+	test.That(t, p.position(0.25), test.ShouldAlmostEqual, 45)
+
+	// trapezoid: 0.5s to reach 90 deg/s covering 22.5 deg, 1s cruising, then 0.5s to stop
+	p = newMotionProfile(135, 90, 180)
+	test.That(t, p.duration(), test.ShouldAlmostEqual, 2)
+	test.That(t, p.position(0.5), test.ShouldAlmostEqual, 22.5)
+	test.That(t, p.position(1), test.ShouldAlmostEqual, 67.5)
+	test.That(t, p.position(1.75), test.ShouldAlmostEqual, 135-0.5*180*0.25*0.25)
+	test.That(t, p.position(3), test.ShouldEqual, 135)
+
+	// triangle: the velocity limit is never reached
+	p = newMotionProfile(20, 90, 20)
+	test.That(t, p.duration(), test.ShouldAlmostEqual, 2)
+	test.That(t, p.position(1), test.ShouldAlmostEqual, 10)
+
+	p = newMotionProfile(0, 90, 20)
+	test.That(t, p.duration(), test.ShouldEqual, 0)
+	test.That(t, p.position(1), test.ShouldEqual, 0)
 }
 
 func setupDependencies(t *testing.T) resource.Dependencies {
@@ -212,3 +294,61 @@ func TestServoMove(t *testing.T) {
 	test.That(t, err, test.ShouldBeNil)
 	test.That(t, pos, test.ShouldEqual, 63)
 }
+
+func TestServoSmoothMove(t *testing.T) {
+	logger := golog.NewTestLogger(t)
+	deps := setupDependencies(t)
+	ctx := context.Background()
+
+	conf := servoConfig{
+		Pin:                  "1",
+		Board:                "mock",
+		StartPos:             ptr(10.0),
+		TrimDeg:              ptr(5.0),
+		MaxVelocityDegPerSec: ptr(500.0),
+	}
+	servo, err := newGPIOServo(ctx, deps, resource.Config{ConvertedAttributes: &conf}, logger)
+	test.That(t, err, test.ShouldBeNil)
+	realServo, ok := servo.(*servoGPIO)
+	test.That(t, ok, test.ShouldBeTrue)
+
+	// the trim shifts the pulse width but not the reported position
+	pos, err := realServo.Position(ctx, nil)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, pos, test.ShouldEqual, 10)
+	test.That(t, realServo.currPct*1e6/50, test.ShouldAlmostEqual, 500+15*2000/180., 5)
+
+	var positions []uint32
+	pin, err := deps[board.Named("mock")].(*inject.Board).GPIOPinByName("1")
+	test.That(t, err, test.ShouldBeNil)
+	injectPin := pin.(*inject.GPIOPin)
+	setPWM := injectPin.SetPWMFunc
+	injectPin.SetPWMFunc = func(ctx context.Context, dutyCyclePct float64, extra map[string]interface{}) error {
+		if err := setPWM(ctx, dutyCyclePct, extra); err != nil {
+			return err
+		}
+		p, err := realServo.Position(ctx, nil)
+		positions = append(positions, p)
+		return err
+	}
+
+	start := time.Now()
+	err = realServo.Move(ctx, 110, nil)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, time.Since(start), test.ShouldBeGreaterThanOrEqualTo, 200*time.Millisecond)
+	test.That(t, len(positions), test.ShouldBeGreaterThan, 2)
+	for i := 1; i < len(positions); i++ {
+		test.That(t, positions[i], test.ShouldBeGreaterThanOrEqualTo, positions[i-1])
+	}
+	test.That(t, positions[0], test.ShouldBeLessThan, 110)
+	test.That(t, positions[len(positions)-1], test.ShouldEqual, 110)
+
+	// a canceled move stops part way
+	cancelCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	err = realServo.Move(cancelCtx, 10, nil)
+	test.That(t, err, test.ShouldNotBeNil)
+	pos, err = realServo.Position(ctx, nil)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, pos, test.ShouldBeBetween, 10, 110)
+}
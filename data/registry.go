@@ -26,6 +26,9 @@ type CollectorParams struct {
 	BufferSize    int
 	Logger        golog.Logger
 	Clock         clock.Clock
+	// Dependencies are the other resources a collector can use while capturing, like the camera
+	// that a vision service is run on.
+	Dependencies resource.Dependencies
 }
 
 // Validate validates that p contains all required parameters.
@@ -0,0 +1,93 @@
+package motionplan
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/golang/geo/r3"
+
+	"go.viam.com/rdk/motionplan/ik"
+	"go.viam.com/rdk/spatialmath"
+)
+
+// cartesianPath is a path for the end of a frame to follow exactly, running from its start at 0 to its goal at 1.
+type cartesianPath interface {
+	// poseAt returns the pose the given proportion of the way along the path.
+	poseAt(by float64) spatialmath.Pose
+	// steps returns the number of steps of at most stepSize mm or degrees the path is followed in.
+	steps(stepSize float64) int
+}
+
+// newCartesianPath returns a straight line from the start to the goal, or if an arc center is given, the arc about it from the
+// start to the goal.
+func newCartesianPath(from, to spatialmath.Pose, arcCenter *r3.Vector, tolerance float64) (cartesianPath, error) {
+	if arcCenter == nil {
+		return &linearPath{from: from, to: to}, nil
+	}
+	return newArcPath(from, to, *arcCenter, tolerance)
+}
+
+// linearPath moves along the straight line between two poses, slerping between their orientations.
+type linearPath struct {
+	from, to spatialmath.Pose
+}
+
+func (lp *linearPath) poseAt(by float64) spatialmath.Pose {
+	return spatialmath.Interpolate(lp.from, lp.to, by)
+}
+
+func (lp *linearPath) steps(stepSize float64) int {
+	return PathStepCount(lp.from, lp.to, stepSize)
+}
+
+// arcPath moves along the shorter arc of the circle about a center point between two poses, slerping between their orientations.
+type arcPath struct {
+	from, to spatialmath.Pose
+	center   r3.Vector
+	// the rotation about the center, in radians, which takes the start to the goal.
+	axis  r3.Vector
+	angle float64
+}
+
+func newArcPath(from, to spatialmath.Pose, center r3.Vector, tolerance float64) (*arcPath, error) {
+	u := from.Point().Sub(center)
+	v := to.Point().Sub(center)
+	if math.Abs(u.Norm()-v.Norm()) > tolerance {
+		return nil, fmt.Errorf(
+			"the start and goal are %.2fmm and %.2fmm from the arc center, which must be equal",
+			u.Norm(), v.Norm(),
+		)
+	}
+	axis := u.Cross(v)
+	if axis.Norm() < defaultEpsilon*u.Norm()*v.Norm() {
+		return nil, errArcAmbiguous
+	}
+	return &arcPath{
+		from:   from,
+		to:     to,
+		center: center,
+		axis:   axis.Normalize(),
+		angle:  float64(u.Angle(v)),
+	}, nil
+}
+
+func (ap *arcPath) poseAt(by float64) spatialmath.Pose {
+	u := ap.from.Point().Sub(ap.center)
+	// the radius is interpolated so that the path ends at the goal even if it is not quite as far from the center as the start
+	radius := u.Norm() + by*(ap.to.Point().Sub(ap.center).Norm()-u.Norm())
+	rotation := &spatialmath.R4AA{Theta: by * ap.angle, RX: ap.axis.X, RY: ap.axis.Y, RZ: ap.axis.Z}
+	rotated := spatialmath.Compose(spatialmath.NewPoseFromOrientation(rotation), spatialmath.NewPoseFromPoint(u)).Point()
+	return spatialmath.NewPose(
+		ap.center.Add(rotated.Normalize().Mul(radius)),
+		spatialmath.Interpolate(ap.from, ap.to, by).Orientation(),
+	)
+}
+
+func (ap *arcPath) steps(stepSize float64) int {
+	if stepSize == 0 {
+		stepSize = 1.
+	}
+	mmDist := ap.angle * ap.from.Point().Sub(ap.center).Norm()
+	degDist := ik.OrientDist(ap.from.Orientation(), ap.to.Orientation())
+	return int(math.Max(mmDist/stepSize, degDist/stepSize)) + 1
+}
@@ -0,0 +1,287 @@
+//go:build !windows
+
+package motionplan
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/edaniels/golog"
+
+	"go.viam.com/rdk/motionplan/ik"
+	"go.viam.com/rdk/referenceframe"
+	"go.viam.com/rdk/spatialmath"
+	"go.viam.com/rdk/utils"
+)
+
+const (
+	// Size of the steps, in mm or degrees, that a cartesian path is solved at before any subdivision.
+	defaultCartesianStepSize = 1.
+
+	// Largest change in any joint, in degrees, allowed over a full-sized step along a cartesian path.
+	defaultMaxJointStep = 10.
+
+	// Number of times a step along a cartesian path may be halved to follow it more closely.
+	maxCartesianSubdivisions = 5
+
+	// A joint which changes by more than this proportion of what it did over twice the distance has jumped, rather than moved.
+	jointFlipRatio = 0.75
+)
+
+// interpolations between steps which are checked against a cartesian path.
+var cartesianCheckpoints = []float64{0.25, 0.5, 0.75}
+
+type cartesianOptions struct {
+	// Size of the steps, in mm or degrees, the path is solved at. Steps are subdivided as needed to follow the path.
+	StepSize float64 `json:"cartesian_step_size"`
+
+	// Largest change in any joint, in degrees, allowed over a full-sized step. Joints changing faster than this are near
+	// a singularity.
+	MaxJointStep float64 `json:"max_joint_step_degs"`
+
+	// How far, in mm, the frame may stray from the path between steps.
+	LineTolerance float64 `json:"line_tolerance"`
+
+	// How far, in degrees, the frame may rotate away from the path between steps.
+	OrientTolerance float64 `json:"orient_tolerance"`
+}
+
+// newCartesianOptions creates a struct controlling the running of a single invocation of the cartesian planner. All values are
+// pre-set to reasonable defaults, but can be tweaked if needed.
+func newCartesianOptions(planOpts *plannerOptions) (*cartesianOptions, error) {
+	algOpts := &cartesianOptions{
+		StepSize:        defaultCartesianStepSize,
+		MaxJointStep:    defaultMaxJointStep,
+		LineTolerance:   defaultLinearDeviation,
+		OrientTolerance: defaultOrientationDeviation,
+	}
+	// convert map to json
+	jsonString, err := json.Marshal(planOpts.extra)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(jsonString, algOpts)
+	if err != nil {
+		return nil, err
+	}
+	if algOpts.StepSize <= 0 || algOpts.MaxJointStep <= 0 {
+		return nil, errors.New("cartesian_step_size and max_joint_step_degs must be positive")
+	}
+
+	return algOpts, nil
+}
+
+// cartesianMotionPlanner moves the end of a frame exactly along a line or an arc. The path is split into small steps, each of
+// which is solved for starting from the solution to the one before it, so the frame moves continuously. Steps are subdivided
+// until the motion of the joints between them keeps to the path. Planning fails if the path cannot be followed, such as when it
+// passes near a singularity, where the joints would need to move much faster than the frame does, or when following it would
+// need the joints to flip to another solution.
+type cartesianMotionPlanner struct {
+	*planner
+	fastGradDescent *ik.NloptIK
+	// set if the frame can be solved for in closed form.
+	analytic *ik.AnalyticIK
+	algOpts  *cartesianOptions
+}
+
+func newCartesianMotionPlanner(
+	frame referenceframe.Frame,
+	seed *rand.Rand,
+	logger golog.Logger,
+	opt *plannerOptions,
+) (motionPlanner, error) {
+	if opt == nil {
+		return nil, errNoPlannerOptions
+	}
+	mp, err := newPlanner(frame, seed, logger, opt)
+	if err != nil {
+		return nil, err
+	}
+	// nlopt should try only once, so that each step stays near the last
+	nlopt, err := ik.CreateNloptIKSolver(frame, logger, 1, true)
+	if err != nil {
+		return nil, err
+	}
+	algOpts, err := newCartesianOptions(opt)
+	if err != nil {
+		return nil, err
+	}
+	analytic, err := ik.CreateAnalyticIKSolver(frame, logger)
+	if err != nil {
+		analytic = nil
+	}
+	return &cartesianMotionPlanner{
+		planner:         mp,
+		fastGradDescent: nlopt,
+		analytic:        analytic,
+		algOpts:         algOpts,
+	}, nil
+}
+
+func (mp *cartesianMotionPlanner) plan(ctx context.Context,
+	goal spatialmath.Pose,
+	seed []referenceframe.Input,
+) ([]node, error) {
+	start, err := mp.frame.Transform(seed)
+	if err != nil {
+		return nil, err
+	}
+	path, err := newCartesianPath(start, goal, mp.planOpts.arcCenter, mp.algOpts.LineTolerance)
+	if err != nil {
+		return nil, err
+	}
+
+	steps := path.steps(mp.algOpts.StepSize)
+	nodes := []node{newConfigurationNode(seed)}
+	current := seed
+	for i := 0; i < steps; i++ {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+		}
+		from := float64(i) / float64(steps)
+		to := float64(i+1) / float64(steps)
+		configs, err := mp.followStep(ctx, path, current, from, to, float64(steps), 0, math.Inf(1))
+		if err != nil {
+			return nil, err
+		}
+		for _, config := range configs {
+			nodes = append(nodes, newConfigurationNode(config))
+		}
+		current = configs[len(configs)-1]
+	}
+	return nodes, nil
+}
+
+// followStep solves for the joint positions which follow the path from one proportion along it to another, starting from the
+// given joint positions. If the joints move too far, or stray from the path, the step is split in half. steps is the number of
+// full-sized steps in the path, and lastJump is how far the joints moved over the step this one was split from.
+func (mp *cartesianMotionPlanner) followStep(
+	ctx context.Context,
+	path cartesianPath,
+	seed []referenceframe.Input,
+	from, to, steps float64,
+	depth int,
+	lastJump float64,
+) ([][]referenceframe.Input, error) {
+	solution, err := mp.solveNear(ctx, path.poseAt(to), seed)
+	if err != nil {
+		return nil, fmt.Errorf("no joint positions reach the path %.1f%% of the way to the goal: %w", 100*to, err)
+	}
+
+	joint, jump := largestJointChange(seed, solution)
+	maxJump := utils.DegToRad(mp.algOpts.MaxJointStep) * (to - from) * steps
+	if jump <= maxJump && mp.keepsToPath(path, seed, solution, from, to) {
+		segment := &ik.Segment{StartConfiguration: seed, EndConfiguration: solution, Frame: mp.frame}
+		if ok, _ := mp.planOpts.CheckSegmentAndStateValidity(segment, mp.planOpts.Resolution); !ok {
+			_, failName := mp.planOpts.CheckStateConstraints(&ik.State{Configuration: solution, Frame: mp.frame})
+			return nil, fmt.Errorf("the path fails constraints %.1f%% of the way to the goal: %s", 100*to, failName)
+		}
+		return [][]referenceframe.Input{solution}, nil
+	}
+
+	if depth == maxCartesianSubdivisions {
+		switch {
+		case jump <= maxJump:
+			return nil, fmt.Errorf(
+				"the joints cannot move the frame within %.2fmm and %.2f degrees of the path %.1f%% of the way to the goal",
+				mp.algOpts.LineTolerance, mp.algOpts.OrientTolerance, 100*to,
+			)
+		case jump > jointFlipRatio*lastJump:
+			return nil, fmt.Errorf(
+				"joint %d would flip by %.1f degrees %.1f%% of the way to the goal, the path crosses a joint limit or another solution branch",
+				joint, utils.RadToDeg(jump), 100*to,
+			)
+		default:
+			return nil, fmt.Errorf(
+				"the path passes too near a singularity %.1f%% of the way to the goal, where joint %d moves %.1f times faster than allowed",
+				100*to, joint, jump/maxJump,
+			)
+		}
+	}
+
+	mid := (from + to) / 2
+	first, err := mp.followStep(ctx, path, seed, from, mid, steps, depth+1, jump)
+	if err != nil {
+		return nil, err
+	}
+	second, err := mp.followStep(ctx, path, first[len(first)-1], mid, to, steps, depth+1, jump)
+	if err != nil {
+		return nil, err
+	}
+	return append(first, second...), nil
+}
+
+// solveNear solves for the joint positions closest to the seed which put the frame at the goal.
+func (mp *cartesianMotionPlanner) solveNear(
+	ctx context.Context,
+	goal spatialmath.Pose,
+	seed []referenceframe.Input,
+) ([]referenceframe.Input, error) {
+	if mp.analytic != nil {
+		solutions := mp.analytic.Solutions(goal, seed)
+		if len(solutions) == 0 {
+			return nil, errIKSolve
+		}
+		return solutions[0], nil
+	}
+	solutionGen := make(chan *ik.Solution, 1)
+	err := mp.fastGradDescent.Solve(ctx, solutionGen, seed, ik.NewSquaredNormMetric(goal), mp.randseed.Int())
+	// We should have zero or one solutions
+	var solved *ik.Solution
+	select {
+	case solved = <-solutionGen:
+	default:
+	}
+	close(solutionGen)
+	if solved == nil {
+		if err == nil {
+			err = errIKSolve
+		}
+		return nil, err
+	}
+	return solved.Configuration, nil
+}
+
+// keepsToPath checks that moving the joints directly between two solutions keeps the frame on the path between them.
+func (mp *cartesianMotionPlanner) keepsToPath(
+	path cartesianPath,
+	start, end []referenceframe.Input,
+	from, to float64,
+) bool {
+	for _, by := range cartesianCheckpoints {
+		pose, err := mp.frame.Transform(referenceframe.InterpolateInputs(start, end, by))
+		if err != nil {
+			return false
+		}
+		expected := path.poseAt(from + by*(to-from))
+		if pose.Point().Distance(expected.Point()) > mp.algOpts.LineTolerance ||
+			ik.OrientDist(pose.Orientation(), expected.Orientation()) > mp.algOpts.OrientTolerance {
+			return false
+		}
+	}
+	return true
+}
+
+// smoothPath leaves the path as it is, as any shortcut would leave the cartesian path.
+func (mp *cartesianMotionPlanner) smoothPath(ctx context.Context, path []node) []node {
+	return path
+}
+
+// largestJointChange returns the index of the joint which changes the most between two sets of inputs, and by how much.
+func largestJointChange(from, to []referenceframe.Input) (int, float64) {
+	joint := 0
+	largest := 0.
+	for i := range from {
+		if change := math.Abs(to[i].Value - from[i].Value); change > largest {
+			joint = i
+			largest = change
+		}
+	}
+	return joint, largest
+}
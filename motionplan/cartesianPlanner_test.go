@@ -0,0 +1,143 @@
+package motionplan
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/golang/geo/r3"
+	pb "go.viam.com/api/service/motion/v1"
+	"go.viam.com/test"
+
+	"go.viam.com/rdk/motionplan/ik"
+	frame "go.viam.com/rdk/referenceframe"
+	"go.viam.com/rdk/spatialmath"
+	"go.viam.com/rdk/utils"
+)
+
+var ur5eStart = frame.FloatsToInputs([]float64{0, -1.2, 1.4, -1.8, -1.57, 0})
+
+// checkCartesianPlan checks that moving the joints between each step of a plan keeps the frame on the path.
+func checkCartesianPlan(t *testing.T, m frame.Frame, plan [][]frame.Input, path cartesianPath, tolerance float64) {
+	t.Helper()
+	test.That(t, len(plan), test.ShouldBeGreaterThan, 2)
+	for i := 0; i < len(plan)-1; i++ {
+		for _, by := range []float64{0, 0.5} {
+			pose, err := m.Transform(frame.InterpolateInputs(plan[i], plan[i+1], by))
+			test.That(t, err, test.ShouldBeNil)
+			// the closest point along the path, searched for finely enough to be within the tolerance
+			closest := 1e9
+			for s := 0.; s <= 1; s += 1e-4 {
+				closest = math.Min(closest, pose.Point().Distance(path.poseAt(s).Point()))
+			}
+			test.That(t, closest, test.ShouldBeLessThan, tolerance)
+		}
+	}
+}
+
+func TestCartesianLine(t *testing.T) {
+	m, err := frame.ParseModelJSONFile(utils.ResolveFile("components/arm/universalrobots/ur5e.json"), "")
+	test.That(t, err, test.ShouldBeNil)
+	start, err := m.Transform(ur5eStart)
+	test.That(t, err, test.ShouldBeNil)
+	goal := spatialmath.NewPose(start.Point().Add(r3.Vector{X: 100, Y: -50, Z: 30}), start.Orientation())
+
+	plan, err := PlanFrameMotion(context.Background(), logger.Sugar(), goal, m, ur5eStart, nil,
+		map[string]interface{}{"motion_profile": CartesianMotionProfile})
+	test.That(t, err, test.ShouldBeNil)
+	end, err := m.Transform(plan[len(plan)-1])
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, spatialmath.PoseAlmostEqualEps(end, goal, defaultEpsilon), test.ShouldBeTrue)
+	checkCartesianPlan(t, m, plan, &linearPath{from: start, to: goal}, 2*defaultLinearDeviation)
+}
+
+func TestCartesianLinearConstraint(t *testing.T) {
+	m, err := frame.ParseModelJSONFile(utils.ResolveFile("components/arm/universalrobots/ur5e.json"), "")
+	test.That(t, err, test.ShouldBeNil)
+	start, err := m.Transform(ur5eStart)
+	test.That(t, err, test.ShouldBeNil)
+	goal := spatialmath.NewPose(start.Point().Add(r3.Vector{X: -80, Y: 40, Z: -30}), start.Orientation())
+
+	// the cartesian planner follows the line within the tolerance of the linear constraint
+	lineTol := float32(0.5)
+	constraints := &pb.Constraints{LinearConstraint: []*pb.LinearConstraint{{LineToleranceMm: &lineTol}}}
+	motionConfig := map[string]interface{}{"motion_profile": CartesianMotionProfile}
+	plan, err := PlanFrameMotion(context.Background(), logger.Sugar(), goal, m, ur5eStart, constraints, motionConfig)
+	test.That(t, err, test.ShouldBeNil)
+	checkCartesianPlan(t, m, plan, &linearPath{from: start, to: goal}, 2*0.5)
+
+	pm := &planManager{}
+	test.That(t, pm.motionConfigForConstraints(constraints, motionConfig), test.ShouldResemble, map[string]interface{}{
+		"motion_profile": CartesianMotionProfile,
+		"line_tolerance": 0.5,
+	})
+	// a tolerance given in the motion config is kept
+	motionConfig = map[string]interface{}{"motion_profile": CartesianMotionProfile, "line_tolerance": 2.}
+	test.That(t, pm.motionConfigForConstraints(constraints, motionConfig), test.ShouldResemble, motionConfig)
+	// and a linear constraint alone does not select the cartesian planner
+	for _, opts := range []map[string]interface{}{nil, {"timeout": 5.}, {"motion_profile": LinearMotionProfile}} {
+		test.That(t, pm.motionConfigForConstraints(constraints, opts), test.ShouldResemble, opts)
+	}
+}
+
+func TestCartesianArc(t *testing.T) {
+	m, err := frame.ParseModelJSONFile(utils.ResolveFile("components/arm/universalrobots/ur5e.json"), "")
+	test.That(t, err, test.ShouldBeNil)
+	start, err := m.Transform(ur5eStart)
+	test.That(t, err, test.ShouldBeNil)
+	center := start.Point().Add(r3.Vector{Y: 60})
+	goal := spatialmath.NewPose(center.Add(r3.Vector{X: 60}), start.Orientation())
+
+	opts := map[string]interface{}{
+		"motion_profile": CartesianMotionProfile,
+		"arc_center":     map[string]interface{}{"x": center.X, "y": center.Y, "z": center.Z},
+	}
+	plan, err := PlanFrameMotion(context.Background(), logger.Sugar(), goal, m, ur5eStart, nil, opts)
+	test.That(t, err, test.ShouldBeNil)
+	path, err := newArcPath(start, goal, center, defaultEpsilon)
+	test.That(t, err, test.ShouldBeNil)
+	checkCartesianPlan(t, m, plan, path, 2*defaultLinearDeviation)
+	for _, step := range plan {
+		pose, err := m.Transform(step)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, pose.Point().Distance(center), test.ShouldAlmostEqual, 60, 0.1)
+	}
+
+	// the arc must be well defined
+	opts["arc_center"] = map[string]interface{}{"x": center.X + 30, "y": center.Y - 30, "z": center.Z}
+	_, err = PlanFrameMotion(context.Background(), logger.Sugar(), goal, m, ur5eStart, nil, opts)
+	test.That(t, err, test.ShouldBeError, errArcAmbiguous)
+	opts["arc_center"] = map[string]interface{}{"x": center.X + 10, "y": center.Y, "z": center.Z}
+	_, err = PlanFrameMotion(context.Background(), logger.Sugar(), goal, m, ur5eStart, nil, opts)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "must be equal")
+}
+
+func TestCartesianSingularity(t *testing.T) {
+	m, err := frame.ParseModelJSONFile(utils.ResolveFile("components/arm/universalrobots/ur5e.json"), "")
+	test.That(t, err, test.ShouldBeNil)
+	// turning the wrist through its singularity, where the fourth and sixth joints line up, needs them to spin around
+	start := frame.FloatsToInputs([]float64{0, -1.2, 1.4, -1.8, 0.2, 0})
+	startPose, err := m.Transform(start)
+	test.That(t, err, test.ShouldBeNil)
+	goal, err := m.Transform(frame.FloatsToInputs([]float64{0, -1.2, 1.4, -1.8, -0.2, 0}))
+	test.That(t, err, test.ShouldBeNil)
+	// the goal is reached by moving straight there, but only by going through the singularity
+	test.That(t, ik.OrientDist(startPose.Orientation(), goal.Orientation()), test.ShouldBeGreaterThan, 20)
+
+	_, err = PlanFrameMotion(context.Background(), logger.Sugar(), goal, m, start, nil,
+		map[string]interface{}{"motion_profile": CartesianMotionProfile})
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "singularity")
+}
+
+func TestCartesianOptions(t *testing.T) {
+	m, err := frame.ParseModelJSONFile(utils.ResolveFile("components/arm/universalrobots/ur5e.json"), "")
+	test.That(t, err, test.ShouldBeNil)
+	goal := spatialmath.NewPoseFromPoint(r3.Vector{X: 300, Y: 100, Z: 300})
+	_, err = PlanFrameMotion(context.Background(), logger.Sugar(), goal, m, ur5eStart, nil,
+		map[string]interface{}{"motion_profile": CartesianMotionProfile, "planning_alg": "rrtstar"})
+	test.That(t, err.Error(), test.ShouldContainSubstring, "planning_alg")
+	_, err = PlanFrameMotion(context.Background(), logger.Sugar(), goal, m, ur5eStart, nil,
+		map[string]interface{}{"motion_profile": CartesianMotionProfile, "cartesian_step_size": -1.})
+	test.That(t, err.Error(), test.ShouldContainSubstring, "must be positive")
+}
@@ -0,0 +1,58 @@
+// Animate a motion plan record, such as one exported by the motion service, as an HTML page
+// $./visualize_plan -plan=/path/to/plan.json -out=/path/to/plan.html [-replan]
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+
+	"github.com/edaniels/golog"
+	"github.com/pkg/errors"
+
+	"go.viam.com/rdk/motionplan"
+)
+
+var logger = golog.NewLogger("visualize_plan")
+
+func main() {
+	planPtr := flag.String("plan", "", "path to the plan record JSON")
+	outPtr := flag.String("out", "plan.html", "path to write the HTML page to")
+	resolutionPtr := flag.Int("resolution", 500, "size in pixels of each view of the plan")
+	replanPtr := flag.Bool("replan", false, "plan the recorded request again and show the new plan")
+	flag.Parse()
+	if *planPtr == "" {
+		logger.Fatal(errors.New("need a plan record to visualize"))
+	}
+
+	planRecord, err := motionplan.ReadPlanRecordJSONFile(*planPtr)
+	if err != nil {
+		logger.Fatal(errors.Wrapf(err, "path=%q", *planPtr))
+	}
+	if planRecord.Error != "" {
+		logger.Infof("recorded plan failed: %s", planRecord.Error)
+	}
+	if *replanPtr {
+		plan, err := planRecord.Replan(context.Background(), logger)
+		planRecord.SetResult(plan, err)
+		if err != nil {
+			logger.Infof("replanning failed: %v", err)
+		}
+	}
+
+	//nolint:gosec
+	out, err := os.Create(*outPtr)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	defer func() {
+		if err := out.Close(); err != nil {
+			logger.Error(err)
+		}
+	}()
+	if err := motionplan.WritePlanHTML(out, planRecord, *resolutionPtr); err != nil {
+		logger.Error(err)
+		return
+	}
+	logger.Infof("wrote %d steps to %s", len(planRecord.Plan), *outPtr)
+}
@@ -125,6 +125,13 @@ func (c *ConstraintHandler) CheckStateConstraintsAcrossSegment(ci *ik.Segment, r
 		return false, nil
 	}
 	steps := PathStepCount(ci.StartPosition, ci.EndPosition, resolution)
+	// the pose of a frame moving several components is that of only the first, so each must be checked at the resolution
+	if sf, ok := ci.Frame.(*solverFrame); ok && len(sf.components) > 1 {
+		steps, err = sf.componentStepCount(ci.StartConfiguration, ci.EndConfiguration, resolution)
+		if err != nil {
+			return false, nil
+		}
+	}
 
 	var lastGood []referenceframe.Input
 
@@ -0,0 +1,179 @@
+package costmap
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"math"
+
+	"github.com/golang/geo/r3"
+)
+
+// How many cells A* expands between checks of whether it has been cancelled.
+const cancelCheckInterval = 1000
+
+var neighborOffsets = [8][2]int{{1, 0}, {-1, 0}, {0, 1}, {0, -1}, {1, 1}, {1, -1}, {-1, 1}, {-1, -1}}
+
+// PlanPath plans a path for a base over the costmap from one point to another with A*, returning the waypoints along it. The cost of
+// travelling through each cell grows with its cost in the costmap, so paths keep away from obstacles when there is room to, and the same
+// request always gives the same path.
+//
+// The base may start in a cell in which it would touch an obstacle, so that it can leave it, but may not pass through any others.
+func (cm *Costmap) PlanPath(ctx context.Context, from, to r3.Vector) ([]r3.Vector, error) {
+	startI, startJ, ok := cm.cell(from)
+	if !ok {
+		return nil, errors.New("start of path is off the costmap")
+	}
+	goalI, goalJ, ok := cm.cell(to)
+	if !ok {
+		return nil, errors.New("end of path is off the costmap")
+	}
+	start, goal := cm.index(startI, startJ), cm.index(goalI, goalJ)
+	if cm.costs[goal] >= InscribedCost {
+		return nil, errors.New("end of path is in collision")
+	}
+
+	res := cm.opts.ResolutionMM
+	heuristic := func(k int) float64 {
+		di := math.Abs(float64(k%cm.width - goalI))
+		dj := math.Abs(float64(k/cm.width - goalJ))
+		return res * (math.Max(di, dj) + (math.Sqrt2-1)*math.Min(di, dj))
+	}
+
+	costSoFar := make([]float64, len(cm.costs))
+	for k := range costSoFar {
+		costSoFar[k] = math.Inf(1)
+	}
+	costSoFar[start] = 0
+	cameFrom := make([]int, len(cm.costs))
+	closed := make([]bool, len(cm.costs))
+	open := &cellQueue{}
+	heap.Push(open, &queuedCell{index: start, priority: heuristic(start)})
+	for expanded := 0; open.Len() > 0; expanded++ {
+		if expanded%cancelCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
+		current := heap.Pop(open).(*queuedCell).index
+		if current == goal {
+			return cm.smoothPath(from, to, cm.cellPath(cameFrom, start, goal)), nil
+		}
+		if closed[current] {
+			continue
+		}
+		closed[current] = true
+
+		i, j := current%cm.width, current/cm.width
+		for _, offset := range neighborOffsets {
+			ni, nj := i+offset[0], j+offset[1]
+			if !cm.inGrid(ni, nj) {
+				continue
+			}
+			next := cm.index(ni, nj)
+			if closed[next] || cm.costs[next] >= InscribedCost {
+				continue
+			}
+			newCost := costSoFar[current] + res*math.Hypot(float64(offset[0]), float64(offset[1]))*cm.travelCost(cm.costs[next])
+			if costSoFar[next] <= newCost {
+				continue
+			}
+			costSoFar[next] = newCost
+			cameFrom[next] = current
+			heap.Push(open, &queuedCell{index: next, priority: newCost + heuristic(next)})
+		}
+	}
+	return nil, errors.New("no path to the goal exists on the costmap")
+}
+
+// travelCost returns how many times more it costs to travel through a cell of the given cost than through a free cell.
+func (cm *Costmap) travelCost(cost uint8) float64 {
+	return 1 + cm.opts.CostWeight*float64(cost)/float64(InscribedCost-1)
+}
+
+// cellPath returns the centers of the cells along a path found by A*, from start to goal.
+func (cm *Costmap) cellPath(cameFrom []int, start, goal int) []r3.Vector {
+	path := []r3.Vector{}
+	for k := goal; k != start; k = cameFrom[k] {
+		path = append(path, cm.center(k%cm.width, k/cm.width))
+	}
+	path = append(path, cm.center(start%cm.width, start/cm.width))
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path
+}
+
+// smoothPath replaces the ends of a path through cell centers with the exact points it was planned between, then removes the waypoints
+// which can be skipped by going straight from an earlier waypoint to a later one. A waypoint is only skipped if the straight line passes
+// no closer to an obstacle than the path it replaces, so smoothing never brings a path closer to obstacles.
+func (cm *Costmap) smoothPath(from, to r3.Vector, path []r3.Vector) []r3.Vector {
+	path[0] = from
+	if len(path) > 1 {
+		path[len(path)-1] = to
+	} else {
+		path = append(path, to)
+	}
+
+	smoothed := []r3.Vector{path[0]}
+	for anchor := 0; anchor < len(path)-1; {
+		next := anchor + 1
+		highestCost := cm.segmentCost(path[anchor], path[next])
+		for candidate := next + 1; candidate < len(path); candidate++ {
+			highestCost = maxCost(highestCost, cm.segmentCost(path[candidate-1], path[candidate]))
+			if cm.segmentCost(path[anchor], path[candidate]) > highestCost {
+				break
+			}
+			next = candidate
+		}
+		smoothed = append(smoothed, path[next])
+		anchor = next
+	}
+	return smoothed
+}
+
+// segmentCost returns the highest cost of the cells along a straight line.
+func (cm *Costmap) segmentCost(from, to r3.Vector) uint8 {
+	steps := int(math.Ceil(2*from.Distance(to)/cm.opts.ResolutionMM)) + 1
+	highest := FreeCost
+	for step := 0; step <= steps; step++ {
+		highest = maxCost(highest, cm.Cost(from.Add(to.Sub(from).Mul(float64(step)/float64(steps)))))
+	}
+	return highest
+}
+
+func maxCost(a, b uint8) uint8 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+type queuedCell struct {
+	index    int
+	priority float64
+}
+
+// cellQueue is a priority queue of cells, lowest priority first. Ties are broken by index so that the order is deterministic.
+type cellQueue []*queuedCell
+
+func (q cellQueue) Len() int { return len(q) }
+
+func (q cellQueue) Less(i, j int) bool {
+	if q[i].priority == q[j].priority {
+		return q[i].index < q[j].index
+	}
+	return q[i].priority < q[j].priority
+}
+
+func (q cellQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *cellQueue) Push(x interface{}) { *q = append(*q, x.(*queuedCell)) }
+
+func (q *cellQueue) Pop() interface{} {
+	old := *q
+	n := len(old)
+	item := old[n-1]
+	*q = old[:n-1]
+	return item
+}
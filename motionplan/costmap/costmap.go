@@ -0,0 +1,238 @@
+// Package costmap builds 2D occupancy grids and inflated costmaps from point cloud maps, such as those made by SLAM, and plans the
+// motion of bases over them.
+package costmap
+
+import (
+	"errors"
+	"math"
+
+	"github.com/golang/geo/r3"
+
+	"go.viam.com/rdk/pointcloud"
+)
+
+const (
+	// FreeCost is the cost of a cell which is far enough from every obstacle that it does not matter how close to them it is.
+	FreeCost uint8 = 0
+	// InscribedCost is the cost of a cell in which a base would touch an obstacle.
+	InscribedCost uint8 = 253
+	// LethalCost is the cost of a cell which is occupied by an obstacle.
+	LethalCost uint8 = 254
+)
+
+const (
+	defaultResolutionMM       = 50.
+	defaultOccupancyThreshold = 50 // like the confidence threshold of a pointcloud.BasicOctree
+	defaultInflationMarginMM  = 500.
+	defaultCostScalingFactor  = 0.01 // per mm
+	defaultCostWeight         = 3.
+	defaultLookaheadMM        = 1000.
+	defaultGoalToleranceMM    = 100.
+	defaultAlphaSamples       = 21
+)
+
+// Options describes how a costmap is built from a point cloud and how paths are planned over it.
+type Options struct {
+	// The length of the side of each square cell of the grid.
+	ResolutionMM float64 `json:"resolution_mm"`
+
+	// Only points with heights between these bounds are obstacles. Points are not filtered by height unless the maximum height is
+	// greater than the minimum.
+	MinHeightMM float64 `json:"min_height_mm"`
+	MaxHeightMM float64 `json:"max_height_mm"`
+
+	// Points whose value, a probability between 0 and 100, is below this threshold are not obstacles. Points without a value always are.
+	OccupancyThreshold int `json:"occupancy_threshold"`
+
+	// The radius of the circle which encloses the base. Cells closer than this to an obstacle are in collision.
+	RobotRadiusMM float64 `json:"robot_radius_mm"`
+
+	// Cells closer than this to an obstacle cost more the closer they are to it, decaying exponentially at the cost scaling factor
+	// from the robot radius outward. Defaults to the robot radius plus 500mm.
+	InflationRadiusMM float64 `json:"inflation_radius_mm"`
+	CostScalingFactor float64 `json:"cost_scaling_factor"`
+
+	// How much more it costs to travel through a cell next to an obstacle than through a free cell. Larger weights keep paths further
+	// from obstacles at the expense of making them longer.
+	CostWeight float64 `json:"cost_weight"`
+
+	// How far ahead along the path the local planner aims, and how close to the end of the path the base must get.
+	LookaheadMM     float64 `json:"lookahead_mm"`
+	GoalToleranceMM float64 `json:"goal_tolerance_mm"`
+}
+
+// NewOptions returns the default options for building and planning over a costmap.
+func NewOptions() *Options {
+	return &Options{
+		ResolutionMM:       defaultResolutionMM,
+		OccupancyThreshold: defaultOccupancyThreshold,
+		CostScalingFactor:  defaultCostScalingFactor,
+		CostWeight:         defaultCostWeight,
+		LookaheadMM:        defaultLookaheadMM,
+		GoalToleranceMM:    defaultGoalToleranceMM,
+	}
+}
+
+func (opts *Options) inflationRadius() float64 {
+	if opts.InflationRadiusMM > 0 {
+		return math.Max(opts.InflationRadiusMM, opts.RobotRadiusMM)
+	}
+	return opts.RobotRadiusMM + defaultInflationMarginMM
+}
+
+// Costmap is a 2D grid over the XY plane whose cells hold the cost of a base being in them. Cells occupied by obstacles have the lethal
+// cost, cells in which the base would touch an obstacle have the inscribed cost, and the cost of the cells around them decays with their
+// distance from the nearest obstacle.
+type Costmap struct {
+	opts          *Options
+	minX, minY    float64
+	width, height int
+	costs         []uint8
+}
+
+// NewFromPointCloud builds a costmap covering the extent of a point cloud, whose points within the height bounds of the options are
+// obstacles.
+func NewFromPointCloud(pc pointcloud.PointCloud, opts *Options) (*Costmap, error) {
+	if opts == nil {
+		opts = NewOptions()
+	}
+	if opts.ResolutionMM <= 0 {
+		return nil, errors.New("costmap resolution must be greater than zero")
+	}
+	if pc.Size() == 0 {
+		return nil, errors.New("cannot build a costmap from an empty point cloud")
+	}
+	meta := pc.MetaData()
+	cm := &Costmap{
+		opts:   opts,
+		minX:   meta.MinX,
+		minY:   meta.MinY,
+		width:  int(math.Floor((meta.MaxX-meta.MinX)/opts.ResolutionMM+0.5)) + 1,
+		height: int(math.Floor((meta.MaxY-meta.MinY)/opts.ResolutionMM+0.5)) + 1,
+	}
+	cm.costs = make([]uint8, cm.width*cm.height)
+
+	filterHeight := opts.MaxHeightMM > opts.MinHeightMM
+	pc.Iterate(0, 0, func(p r3.Vector, d pointcloud.Data) bool {
+		if filterHeight && (p.Z < opts.MinHeightMM || p.Z > opts.MaxHeightMM) {
+			return true
+		}
+		if d != nil && d.HasValue() && d.Value() < opts.OccupancyThreshold {
+			return true
+		}
+		if i, j, ok := cm.cell(p); ok {
+			cm.costs[cm.index(i, j)] = LethalCost
+		}
+		return true
+	})
+	cm.inflate()
+	return cm, nil
+}
+
+// inflate sets the cost of each cell from its distance to the nearest obstacle.
+func (cm *Costmap) inflate() {
+	res := cm.opts.ResolutionMM
+	inflationRadius := cm.opts.inflationRadius()
+	reach := int(math.Ceil(inflationRadius / res))
+
+	// the distance from each cell to the nearest obstacle, found by stamping the cells around the edge of each obstacle
+	dists := make([]float64, len(cm.costs))
+	for k := range dists {
+		dists[k] = math.Inf(1)
+	}
+	for j := 0; j < cm.height; j++ {
+		for i := 0; i < cm.width; i++ {
+			if cm.costs[cm.index(i, j)] != LethalCost || !cm.onObstacleEdge(i, j) {
+				continue
+			}
+			for dj := -reach; dj <= reach; dj++ {
+				for di := -reach; di <= reach; di++ {
+					ni, nj := i+di, j+dj
+					if !cm.inGrid(ni, nj) {
+						continue
+					}
+					k := cm.index(ni, nj)
+					dists[k] = math.Min(dists[k], res*math.Hypot(float64(di), float64(dj)))
+				}
+			}
+		}
+	}
+
+	for k, dist := range dists {
+		if cm.costs[k] == LethalCost {
+			continue
+		}
+		cm.costs[k] = cm.inflatedCost(dist, inflationRadius)
+	}
+}
+
+// inflatedCost returns the cost of a cell at a distance from the nearest obstacle.
+func (cm *Costmap) inflatedCost(dist, inflationRadius float64) uint8 {
+	switch {
+	case dist <= cm.opts.RobotRadiusMM:
+		return InscribedCost
+	case dist > inflationRadius:
+		return FreeCost
+	default:
+		return uint8(float64(InscribedCost-1) * math.Exp(-cm.opts.CostScalingFactor*(dist-cm.opts.RobotRadiusMM)))
+	}
+}
+
+// onObstacleEdge returns whether an obstacle cell has a neighbor which is not an obstacle. Only these cells can be nearest to a cell
+// outside of an obstacle.
+func (cm *Costmap) onObstacleEdge(i, j int) bool {
+	for _, n := range [][2]int{{i - 1, j}, {i + 1, j}, {i, j - 1}, {i, j + 1}} {
+		if cm.inGrid(n[0], n[1]) && cm.costs[cm.index(n[0], n[1])] != LethalCost {
+			return true
+		}
+	}
+	return false
+}
+
+// Cost returns the cost of the cell containing a point. Points off the map are lethal, as nothing is known about them.
+func (cm *Costmap) Cost(pt r3.Vector) uint8 {
+	i, j, ok := cm.cell(pt)
+	if !ok {
+		return LethalCost
+	}
+	return cm.costs[cm.index(i, j)]
+}
+
+// Occupied returns whether the cell containing a point is occupied by an obstacle.
+func (cm *Costmap) Occupied(pt r3.Vector) bool {
+	return cm.Cost(pt) == LethalCost
+}
+
+// InCollision returns whether a base centered on a point would touch an obstacle.
+func (cm *Costmap) InCollision(pt r3.Vector) bool {
+	return cm.Cost(pt) >= InscribedCost
+}
+
+// Resolution returns the length of the side of each cell.
+func (cm *Costmap) Resolution() float64 {
+	return cm.opts.ResolutionMM
+}
+
+// Size returns the number of cells along the X and Y axes.
+func (cm *Costmap) Size() (int, int) {
+	return cm.width, cm.height
+}
+
+func (cm *Costmap) cell(pt r3.Vector) (int, int, bool) {
+	i := int(math.Floor((pt.X-cm.minX)/cm.opts.ResolutionMM + 0.5))
+	j := int(math.Floor((pt.Y-cm.minY)/cm.opts.ResolutionMM + 0.5))
+	return i, j, cm.inGrid(i, j)
+}
+
+// center returns the point at the center of a cell.
+func (cm *Costmap) center(i, j int) r3.Vector {
+	return r3.Vector{X: cm.minX + float64(i)*cm.opts.ResolutionMM, Y: cm.minY + float64(j)*cm.opts.ResolutionMM}
+}
+
+func (cm *Costmap) inGrid(i, j int) bool {
+	return i >= 0 && j >= 0 && i < cm.width && j < cm.height
+}
+
+func (cm *Costmap) index(i, j int) int {
+	return j*cm.width + i
+}
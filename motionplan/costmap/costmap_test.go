@@ -0,0 +1,148 @@
+package costmap
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/edaniels/golog"
+	"github.com/golang/geo/r3"
+	"go.viam.com/test"
+
+	"go.viam.com/rdk/motionplan/tpspace"
+	"go.viam.com/rdk/pointcloud"
+	"go.viam.com/rdk/spatialmath"
+)
+
+// wallPointCloud is a 5m square map with a wall from the bottom edge up to y=3000 along x=2500, and a shelf above the height of the base
+// near the top left corner.
+func wallPointCloud(t *testing.T) pointcloud.PointCloud {
+	t.Helper()
+	pc := pointcloud.New()
+	// corners of the map which are known to be free
+	for _, corner := range []r3.Vector{{X: 0, Y: 0}, {X: 5000, Y: 5000}} {
+		test.That(t, pc.Set(corner, pointcloud.NewValueData(0)), test.ShouldBeNil)
+	}
+	for y := 0.; y <= 3000; y += 25 {
+		test.That(t, pc.Set(r3.Vector{X: 2500, Y: y, Z: 500}, pointcloud.NewValueData(100)), test.ShouldBeNil)
+	}
+	for x := 500.; x <= 1500; x += 25 {
+		test.That(t, pc.Set(r3.Vector{X: x, Y: 4000, Z: 3000}, pointcloud.NewBasicData()), test.ShouldBeNil)
+	}
+	return pc
+}
+
+func wallCostmap(t *testing.T, opts *Options) *Costmap {
+	t.Helper()
+	if opts == nil {
+		opts = NewOptions()
+	}
+	opts.RobotRadiusMM = 200
+	opts.MinHeightMM = 0
+	opts.MaxHeightMM = 2000
+	cm, err := NewFromPointCloud(wallPointCloud(t), opts)
+	test.That(t, err, test.ShouldBeNil)
+	return cm
+}
+
+// pathClearance returns the smallest distance between a point and a path.
+func pathClearance(cm *Costmap, path []r3.Vector, pt r3.Vector) float64 {
+	clearance := math.Inf(1)
+	for _, p := range cm.densifyPath(path) {
+		clearance = math.Min(clearance, p.Distance(pt))
+	}
+	return clearance
+}
+
+func TestNewFromPointCloud(t *testing.T) {
+	cm := wallCostmap(t, nil)
+	width, height := cm.Size()
+	test.That(t, width, test.ShouldEqual, 101)
+	test.That(t, height, test.ShouldEqual, 101)
+
+	test.That(t, cm.Occupied(r3.Vector{X: 2500, Y: 1000}), test.ShouldBeTrue)
+	test.That(t, cm.InCollision(r3.Vector{X: 2350, Y: 1000}), test.ShouldBeTrue)
+	test.That(t, cm.Occupied(r3.Vector{X: 2350, Y: 1000}), test.ShouldBeFalse)
+	test.That(t, cm.Occupied(r3.Vector{X: 0, Y: 0}), test.ShouldBeFalse)
+
+	// the cost decays away from the wall
+	lastCost := InscribedCost
+	for x := 2250.; x >= 1900; x -= 50 {
+		cost := cm.Cost(r3.Vector{X: x, Y: 1000})
+		test.That(t, cost, test.ShouldBeLessThan, lastCost)
+		lastCost = cost
+	}
+	test.That(t, cm.Cost(r3.Vector{X: 1000, Y: 1000}), test.ShouldEqual, FreeCost)
+	test.That(t, cm.Cost(r3.Vector{X: -1000, Y: 1000}), test.ShouldEqual, LethalCost)
+
+	// the shelf is above the base, unless heights are not filtered
+	test.That(t, cm.Occupied(r3.Vector{X: 1000, Y: 4000}), test.ShouldBeFalse)
+	unfiltered, err := NewFromPointCloud(wallPointCloud(t), NewOptions())
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, unfiltered.Occupied(r3.Vector{X: 1000, Y: 4000}), test.ShouldBeTrue)
+
+	_, err = NewFromPointCloud(pointcloud.New(), nil)
+	test.That(t, err, test.ShouldNotBeNil)
+}
+
+func TestPlanPath(t *testing.T) {
+	cm := wallCostmap(t, nil)
+	from := r3.Vector{X: 1000, Y: 1000}
+	to := r3.Vector{X: 4000, Y: 1000}
+	path, err := cm.PlanPath(context.Background(), from, to)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, path[0], test.ShouldResemble, from)
+	test.That(t, path[len(path)-1], test.ShouldResemble, to)
+	// the path goes around the end of the wall without touching it
+	for i := 1; i < len(path); i++ {
+		test.That(t, cm.segmentCost(path[i-1], path[i]), test.ShouldBeLessThan, InscribedCost)
+	}
+	test.That(t, pathClearance(cm, path, r3.Vector{X: 2500, Y: 3000}), test.ShouldBeGreaterThan, 200)
+
+	// the same request gives the same path
+	again, err := cm.PlanPath(context.Background(), from, to)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, again, test.ShouldResemble, path)
+
+	// without costs the path hugs the end of the wall
+	opts := NewOptions()
+	opts.CostWeight = 0
+	shortest, err := wallCostmap(t, opts).PlanPath(context.Background(), from, to)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, pathClearance(cm, shortest, r3.Vector{X: 2500, Y: 3000}), test.ShouldBeLessThan,
+		pathClearance(cm, path, r3.Vector{X: 2500, Y: 3000}))
+
+	_, err = cm.PlanPath(context.Background(), from, r3.Vector{X: 2450, Y: 1000})
+	test.That(t, err, test.ShouldNotBeNil)
+	_, err = cm.PlanPath(context.Background(), r3.Vector{X: -1000}, to)
+	test.That(t, err, test.ShouldNotBeNil)
+}
+
+func TestFollowPath(t *testing.T) {
+	logger := golog.NewTestLogger(t)
+	frame, err := tpspace.NewPTGFrameFromTurningRadius("base", logger, 300, 0.3, 0, nil)
+	test.That(t, err, test.ShouldBeNil)
+	ptgs := frame.(tpspace.PTGProvider).PTGs()
+
+	cm := wallCostmap(t, nil)
+	from := r3.Vector{X: 1000, Y: 1000}
+	to := r3.Vector{X: 4000, Y: 1000}
+	path, err := cm.PlanPath(context.Background(), from, to)
+	test.That(t, err, test.ShouldBeNil)
+	steps, err := cm.FollowPath(context.Background(), ptgs, spatialmath.NewPoseFromPoint(from), path)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, len(steps), test.ShouldBeGreaterThan, 1)
+
+	// driving the steps keeps the base out of collision and brings it to the end of the path
+	pose := spatialmath.NewPoseFromPoint(from)
+	for _, step := range steps {
+		test.That(t, step, test.ShouldHaveLength, 3)
+		traj, err := ptgs[int(step[0].Value)].Trajectory(step[1].Value, step[2].Value)
+		test.That(t, err, test.ShouldBeNil)
+		for _, node := range traj {
+			test.That(t, cm.InCollision(spatialmath.Compose(pose, node.Pose).Point()), test.ShouldBeFalse)
+		}
+		pose = spatialmath.Compose(pose, traj[len(traj)-1].Pose)
+	}
+	test.That(t, pose.Point().Distance(to), test.ShouldBeLessThanOrEqualTo, NewOptions().GoalToleranceMM)
+}
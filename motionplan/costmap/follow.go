@@ -0,0 +1,141 @@
+package costmap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/golang/geo/r3"
+
+	"go.viam.com/rdk/motionplan/tpspace"
+	"go.viam.com/rdk/referenceframe"
+	"go.viam.com/rdk/spatialmath"
+)
+
+// ptgStep is a candidate step of a base along a PTG trajectory.
+type ptgStep struct {
+	ptg   int
+	alpha float64
+	dist  float64
+	end   spatialmath.Pose
+	score float64
+}
+
+// FollowPath plans the motion of a base along a path over the costmap, such as one returned by PlanPath, by chaining trajectories of its
+// PTGs from the pose it starts at. Each step tries a spread of the trajectories of every PTG, and takes the one which minimizes the
+// distance from its end to a point the lookahead distance further along the path plus the cost of the cells it passes through.
+// Trajectories are cut short where the base would touch an obstacle. The base so stays near the path and away from obstacles, while
+// moving only in the ways it is able to.
+//
+// The steps are returned as inputs to a PTG frame, [PTG index, alpha, distance], each starting where the one before it ends.
+func (cm *Costmap) FollowPath(
+	ctx context.Context,
+	ptgs []tpspace.PTG,
+	start spatialmath.Pose,
+	path []r3.Vector,
+) ([][]referenceframe.Input, error) {
+	if len(ptgs) == 0 {
+		return nil, errors.New("cannot follow a path without any PTGs")
+	}
+	if len(path) == 0 {
+		return nil, errors.New("cannot follow an empty path")
+	}
+	dense := cm.densifyPath(path)
+	goal := dense[len(dense)-1]
+	res := cm.opts.ResolutionMM
+	lookahead := math.Max(cm.opts.LookaheadMM, res)
+	// a step can move the base up to the lookahead distance, so a path is given plenty of chances to be followed
+	maxSteps := 4*int(math.Ceil(float64(len(dense))*res/lookahead)) + 20
+
+	steps := [][]referenceframe.Input{}
+	pose := start
+	progress := 0
+	for len(steps) < maxSteps {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if pose.Point().Distance(goal) <= cm.opts.GoalToleranceMM {
+			return steps, nil
+		}
+		progress = nearestAlongPath(dense, progress, int(2*lookahead/res), pose.Point())
+		carrot := len(dense) - 1
+		for i := progress; i < len(dense); i++ {
+			if dense[i].Distance(pose.Point()) >= lookahead {
+				carrot = i
+				break
+			}
+		}
+
+		step, err := cm.bestPTGStep(ptgs, pose, dense[carrot], lookahead)
+		if err != nil {
+			return nil, err
+		}
+		if step == nil {
+			return nil, fmt.Errorf("no trajectory leaves %v without collision", pose.Point())
+		}
+		steps = append(steps, referenceframe.FloatsToInputs([]float64{float64(step.ptg), step.alpha, step.dist}))
+		pose = step.end
+	}
+	return nil, fmt.Errorf("could not follow the path to its end in %d steps", maxSteps)
+}
+
+// bestPTGStep returns the step from a pose towards a target with the best score, or nil if every trajectory is blocked.
+func (cm *Costmap) bestPTGStep(ptgs []tpspace.PTG, pose spatialmath.Pose, target r3.Vector, lookahead float64) (*ptgStep, error) {
+	// a base which starts where it would touch an obstacle may move through such cells to leave them
+	startCost := cm.Cost(pose.Point())
+	var best *ptgStep
+	for k, ptg := range ptgs {
+		dist := math.Min(lookahead, ptg.MaxDistance())
+		for a := 0; a < defaultAlphaSamples; a++ {
+			alpha := math.Pi * (-1 + (2*float64(a)+1)/defaultAlphaSamples)
+			traj, err := ptg.Trajectory(alpha, dist)
+			if err != nil {
+				return nil, err
+			}
+			cost := 0.
+			lastDist := 0.
+			for _, node := range traj {
+				end := spatialmath.Compose(pose, node.Pose)
+				cellCost := cm.Cost(end.Point())
+				if cellCost == LethalCost || (cellCost >= InscribedCost && startCost < InscribedCost) {
+					break
+				}
+				cost += cm.opts.CostWeight * float64(cellCost) / float64(InscribedCost-1) * math.Abs(node.Dist-lastDist)
+				lastDist = node.Dist
+				if math.Abs(node.Dist) < cm.opts.ResolutionMM {
+					continue
+				}
+				score := end.Point().Distance(target) + cost
+				if best == nil || score < best.score {
+					best = &ptgStep{ptg: k, alpha: alpha, dist: node.Dist, end: end, score: score}
+				}
+			}
+		}
+	}
+	return best, nil
+}
+
+// densifyPath returns the points along a path at intervals of the resolution of the costmap.
+func (cm *Costmap) densifyPath(path []r3.Vector) []r3.Vector {
+	dense := []r3.Vector{path[0]}
+	for i := 1; i < len(path); i++ {
+		steps := int(math.Ceil(path[i-1].Distance(path[i]) / cm.opts.ResolutionMM))
+		for step := 1; step <= steps; step++ {
+			dense = append(dense, path[i-1].Add(path[i].Sub(path[i-1]).Mul(float64(step)/float64(steps))))
+		}
+	}
+	return dense
+}
+
+// nearestAlongPath returns the index of the point of a path nearest to pt, searching forward from the given index through window points.
+// Searching only a window keeps the base from skipping ahead to where the path passes back near itself.
+func nearestAlongPath(path []r3.Vector, from, window int, pt r3.Vector) int {
+	nearest := from
+	for i := from; i < len(path) && i <= from+window; i++ {
+		if path[i].Distance(pt) < path[nearest].Distance(pt) {
+			nearest = i
+		}
+	}
+	return nearest
+}
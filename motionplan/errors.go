@@ -19,6 +19,8 @@ var (
 	errInvalidCandidate = errors.New("candidate did not meet constraints")
 
 	errNoCandidates = errors.New("no candidates passed in, skipping")
+
+	errArcAmbiguous = errors.New("the arc center is in line with the start and goal, so there is no single arc between them")
 )
 
 func genIKConstraintErr(failures map[string]int, constraintFailCnt int) error {
@@ -0,0 +1,500 @@
+package ik
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/edaniels/golog"
+	"github.com/golang/geo/r3"
+	"github.com/pkg/errors"
+
+	"go.viam.com/rdk/referenceframe"
+	"go.viam.com/rdk/spatialmath"
+)
+
+const (
+	// distance in mm below which two joint axes are considered to intersect.
+	analyticDistEpsilon = 1e-4
+	// angle in radians below which two joint axes are considered parallel.
+	analyticAngleEpsilon = 1e-6
+	// distance in mm within which a solution must put the frame of the arm at the goal.
+	analyticGoalEpsilon = 1e-3
+)
+
+var (
+	errNotAnalytic     = errors.New("frame is not a 6DoF arm of a geometry with a closed form inverse kinematics solution")
+	errNoGoal          = errors.New("analytic inverse kinematics needs a goal pose, set with ContextWithGoal")
+	errNoAnalyticSolve = errors.New("no joint positions within limits reach the goal pose")
+)
+
+type goalContextKey struct{}
+
+// ContextWithGoal returns a context carrying the pose that the metric given to Solve is minimized at. Solvers able to
+// solve for a pose directly, like AnalyticIK, use it instead of searching for the minimum of the metric.
+func ContextWithGoal(ctx context.Context, goal spatialmath.Pose) context.Context {
+	return context.WithValue(ctx, goalContextKey{}, goal)
+}
+
+// GoalFromContext returns the goal pose set with ContextWithGoal, if any.
+func GoalFromContext(ctx context.Context) (spatialmath.Pose, bool) {
+	goal, ok := ctx.Value(goalContextKey{}).(spatialmath.Pose)
+	return goal, ok && goal != nil
+}
+
+// AnalyticIK solves for the joint positions of 6DoF arms in closed form. It supports arms whose last three joint axes
+// intersect (a spherical wrist), and arms whose second, third and fourth joint axes are parallel and whose last two
+// axes intersect (like Universal Robots arms). Every branch of the solution is found, so an arm reaching a pose can be
+// positioned in up to eight ways before accounting for joints which can turn more than a full revolution.
+type AnalyticIK struct {
+	model  referenceframe.Frame
+	logger golog.Logger
+	limits []referenceframe.Limit
+	// the axes of the joints when all inputs are zero, and the pose of the arm at that position.
+	screws []screw
+	home   spatialmath.Pose
+	solver branchSolver
+}
+
+// branchSolver returns the joint angles of every branch of the solution for the transform g, which is the goal pose
+// composed with the inverse of the home pose. Angles not determined by g are taken from the seed.
+type branchSolver func(g spatialmath.Pose, seed []float64) [][]float64
+
+// CreateAnalyticIKSolver creates an analytic solver for the given frame, returning an error if the geometry of its
+// joints is not one of those that can be solved for in closed form.
+func CreateAnalyticIKSolver(model referenceframe.Frame, logger golog.Logger) (*AnalyticIK, error) {
+	screws, home, err := frameScrews(model)
+	if err != nil {
+		return nil, err
+	}
+	ik := &AnalyticIK{
+		model:  model,
+		logger: logger,
+		limits: model.DoF(),
+		screws: screws,
+		home:   home,
+	}
+	if solver, ok := sphericalWristSolver(screws); ok {
+		ik.solver = solver
+	} else if solver, ok := offsetWristSolver(screws); ok {
+		ik.solver = solver
+	} else {
+		return nil, errNotAnalytic
+	}
+	return ik, nil
+}
+
+// Solve sends every solution for the goal pose set in the context with ContextWithGoal to the channel, ordered from
+// closest to furthest from the seed, and scored with the given metric.
+func (ik *AnalyticIK) Solve(ctx context.Context,
+	c chan<- *Solution,
+	seed []referenceframe.Input,
+	m StateMetric,
+	rseed int,
+) error {
+	goal, ok := GoalFromContext(ctx)
+	if !ok {
+		return errNoGoal
+	}
+	solutions := ik.Solutions(goal, seed)
+	if len(solutions) == 0 {
+		return errNoAnalyticSolve
+	}
+	for _, solution := range solutions {
+		pose, err := ik.model.Transform(solution)
+		if err != nil {
+			return err
+		}
+		score := m(&State{Position: pose, Configuration: solution, Frame: ik.model})
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case c <- &Solution{Configuration: solution, Score: score, Exact: score < defaultGoalThreshold}:
+		}
+	}
+	return nil
+}
+
+// Solutions returns every set of joint positions within the limits of the frame that reach the goal pose, ordered from
+// closest to furthest from the seed.
+func (ik *AnalyticIK) Solutions(goal spatialmath.Pose, seed []referenceframe.Input) [][]referenceframe.Input {
+	seedFloats := make([]float64, len(ik.limits))
+	if len(seed) == len(ik.limits) {
+		seedFloats = referenceframe.InputsToFloats(seed)
+	}
+	g := spatialmath.Compose(goal, spatialmath.PoseInverse(ik.home))
+
+	var solutions [][]referenceframe.Input
+	for _, branch := range ik.solver(g, seedFloats) {
+		// Branches are checked against the frame itself, which rules out those lost to numerical error near singularities.
+		pose, err := transformAllowingOOB(ik.model, referenceframe.FloatsToInputs(branch))
+		if err != nil || !spatialmath.PoseAlmostEqualEps(pose, goal, analyticGoalEpsilon) {
+			continue
+		}
+		for _, solution := range withinLimits(branch, ik.limits) {
+			inputs := referenceframe.FloatsToInputs(solution)
+			duplicate := false
+			for _, other := range solutions {
+				if referenceframe.InputsL2Distance(inputs, other) < analyticAngleEpsilon {
+					duplicate = true
+					break
+				}
+			}
+			if !duplicate {
+				solutions = append(solutions, inputs)
+			}
+		}
+	}
+	seedInputs := referenceframe.FloatsToInputs(seedFloats)
+	sort.SliceStable(solutions, func(i, j int) bool {
+		return referenceframe.InputsL2Distance(seedInputs, solutions[i]) < referenceframe.InputsL2Distance(seedInputs, solutions[j])
+	})
+	return solutions
+}
+
+// Frame returns the associated referenceframe.
+func (ik *AnalyticIK) Frame() referenceframe.Frame {
+	return ik.model
+}
+
+// withinLimits returns every way of writing the joint angles, by adding whole turns to each, within the joint limits.
+func withinLimits(angles []float64, limits []referenceframe.Limit) [][]float64 {
+	solutions := [][]float64{{}}
+	for i, angle := range angles {
+		options := turnsWithinLimit(angle, limits[i])
+		expanded := make([][]float64, 0, len(solutions)*len(options))
+		for _, solution := range solutions {
+			for _, option := range options {
+				expanded = append(expanded, append(append([]float64{}, solution...), option))
+			}
+		}
+		solutions = expanded
+	}
+	return solutions
+}
+
+// turnsWithinLimit returns the angle plus each number of whole turns which is within the limit. Unbounded joints are
+// kept within half a turn of zero.
+func turnsWithinLimit(angle float64, limit referenceframe.Limit) []float64 {
+	angle = math.Remainder(angle, 2*math.Pi)
+	if math.IsInf(limit.Min, 0) || math.IsInf(limit.Max, 0) {
+		return []float64{angle}
+	}
+	var options []float64
+	first := angle + 2*math.Pi*math.Ceil((limit.Min-angle-analyticAngleEpsilon)/(2*math.Pi))
+	for option := first; option <= limit.Max+analyticAngleEpsilon; option += 2 * math.Pi {
+		options = append(options, math.Max(limit.Min, math.Min(limit.Max, option)))
+	}
+	return options
+}
+
+// transformAllowingOOB is the pose of the frame at the given inputs, even if they are out of the bounds of its joints.
+func transformAllowingOOB(model referenceframe.Frame, inputs []referenceframe.Input) (spatialmath.Pose, error) {
+	pose, err := model.Transform(inputs)
+	if pose == nil || (err != nil && !strings.Contains(err.Error(), referenceframe.OOBErrString)) {
+		return nil, err
+	}
+	return pose, nil
+}
+
+// screw is the axis of a revolute joint, given by its direction and a point on it.
+type screw struct {
+	w, q r3.Vector
+}
+
+// rotate rotates the point p by theta about the axis.
+func (s screw) rotate(theta float64, p r3.Vector) r3.Vector {
+	return rotateVector(s.w, theta, p.Sub(s.q)).Add(s.q)
+}
+
+// pose is the transform of rotating by theta about the axis.
+func (s screw) pose(theta float64) spatialmath.Pose {
+	return spatialmath.NewPose(
+		s.q.Sub(rotateVector(s.w, theta, s.q)),
+		&spatialmath.R4AA{Theta: theta, RX: s.w.X, RY: s.w.Y, RZ: s.w.Z},
+	)
+}
+
+// contains returns whether the point p is on the axis.
+func (s screw) contains(p r3.Vector) bool {
+	return perpendicularComponent(s.w, p.Sub(s.q)).Norm() < analyticDistEpsilon
+}
+
+// frameScrews finds the axes of the joints of a 6DoF arm from the poses of the frame as each of its joints is rotated.
+// Each rotation by one radian from the home position must be a rotation about a fixed axis, and the product of
+// the rotations about the axes found must agree with the frame at other positions.
+func frameScrews(model referenceframe.Frame) ([]screw, spatialmath.Pose, error) {
+	if len(model.DoF()) != 6 {
+		return nil, nil, errNotAnalytic
+	}
+	inputs := make([]referenceframe.Input, 6)
+	home, err := transformAllowingOOB(model, inputs)
+	if err != nil {
+		return nil, nil, err
+	}
+	homeInverse := spatialmath.PoseInverse(home)
+	screws := make([]screw, 0, 6)
+	for i := range inputs {
+		inputs[i].Value = 1
+		pose, err := transformAllowingOOB(model, inputs)
+		inputs[i].Value = 0
+		if err != nil {
+			return nil, nil, err
+		}
+		d := spatialmath.Compose(pose, homeInverse)
+		aa := d.Orientation().AxisAngles()
+		if math.Abs(aa.Theta-1) > analyticAngleEpsilon {
+			return nil, nil, errNotAnalytic
+		}
+		w := r3.Vector{X: aa.RX, Y: aa.RY, Z: aa.RZ}.Normalize()
+		t := d.Point()
+		if math.Abs(t.Dot(w)) > analyticDistEpsilon {
+			return nil, nil, errNotAnalytic
+		}
+		// t = (I - R)q for the point q on the axis closest to the origin, which inverts to the below.
+		q := t.Mul(0.5).Add(w.Cross(t).Mul(0.5 / math.Tan(0.5)))
+		screws = append(screws, screw{w: w, q: q})
+	}
+
+	for _, check := range [][]float64{{0.3, -0.7, 1.1, -1.3, 0.5, 2.1}, {-2.5, 1.9, -0.4, 0.8, -1.7, -0.2}} {
+		expected, err := transformAllowingOOB(model, referenceframe.FloatsToInputs(check))
+		if err != nil {
+			return nil, nil, err
+		}
+		if !spatialmath.PoseAlmostEqualEps(productOfScrews(screws, check, home), expected, analyticGoalEpsilon) {
+			return nil, nil, errNotAnalytic
+		}
+	}
+	return screws, home, nil
+}
+
+// productOfScrews is the pose reached by rotating about each of the axes in turn, starting from the home pose.
+func productOfScrews(screws []screw, angles []float64, home spatialmath.Pose) spatialmath.Pose {
+	pose := home
+	for i := len(screws) - 1; i >= 0; i-- {
+		pose = spatialmath.Compose(screws[i].pose(angles[i]), pose)
+	}
+	return pose
+}
+
+// sphericalWristSolver solves for arms whose first two joint axes intersect and whose last three joint axes intersect,
+// by first positioning the wrist and then orienting it.
+func sphericalWristSolver(s []screw) (branchSolver, bool) {
+	shoulder, ok := intersection(s[0], s[1])
+	if !ok {
+		return nil, false
+	}
+	wrist, ok := intersection(s[3], s[4])
+	if !ok || !s[5].contains(wrist) || parallel(s[4].w, s[5].w) || s[2].contains(wrist) {
+		return nil, false
+	}
+	wristTool := wrist.Add(s[5].w)
+	toolOffset := wrist.Add(anyPerpendicular(s[5].w))
+
+	return func(g spatialmath.Pose, seed []float64) [][]float64 {
+		var branches [][]float64
+		// Only the elbow changes the distance from the shoulder to the wrist.
+		target := transformPoint(g, wrist)
+		for _, theta3 := range subproblem3(s[2], wrist, shoulder, target.Distance(shoulder)) {
+			for _, theta12 := range subproblem2(s[0], s[1], shoulder, s[2].rotate(theta3, wrist), target, seed[0]) {
+				arm := productOfScrews(s[:3], []float64{theta12[0], theta12[1], theta3}, spatialmath.NewZeroPose())
+				gWrist := spatialmath.Compose(spatialmath.PoseInverse(arm), g)
+				for _, theta45 := range subproblem2(s[3], s[4], wrist, wristTool, transformPoint(gWrist, wristTool), seed[3]) {
+					wrist45 := productOfScrews(s[3:5], theta45[:], spatialmath.NewZeroPose())
+					gTool := spatialmath.Compose(spatialmath.PoseInverse(wrist45), gWrist)
+					theta6 := subproblem1(s[5], toolOffset, transformPoint(gTool, toolOffset))
+					branches = append(branches, []float64{theta12[0], theta12[1], theta3, theta45[0], theta45[1], theta6})
+				}
+			}
+		}
+		return branches
+	}, true
+}
+
+// offsetWristSolver solves for arms whose second, third and fourth joint axes are parallel, and whose last two joint axes
+// intersect. The base and the last two joints are solved for from the fact that the arm between them moves in a
+// plane, which leaves a planar arm of three joints.
+func offsetWristSolver(s []screw) (branchSolver, bool) {
+	n := s[1].w
+	if !parallel(n, s[2].w) || !parallel(n, s[3].w) || parallel(n, s[0].w) || parallel(n, s[4].w) {
+		return nil, false
+	}
+	wrist, ok := intersection(s[4], s[5])
+	if !ok {
+		return nil, false
+	}
+	planarOffset := s[3].q.Add(anyPerpendicular(n))
+
+	return func(g spatialmath.Pose, seed []float64) [][]float64 {
+		var branches [][]float64
+		// The wrist must be rotated by the base into the plane the planar joints move it in.
+		u := transformPoint(g, wrist).Sub(s[0].q)
+		uParallel := s[0].w.Mul(s[0].w.Dot(u))
+		for _, theta1 := range solveTrig(
+			n.Dot(u.Sub(uParallel)),
+			-n.Dot(s[0].w.Cross(u)),
+			n.Dot(wrist.Sub(s[0].q))-n.Dot(uParallel),
+		) {
+			g1 := spatialmath.Compose(s[0].pose(-theta1), g)
+			tool := rotatePoseVector(g1, s[5].w)
+			// The planar joints do not change the direction of the last axis along their own, so the fifth joint must.
+			w6Parallel := s[4].w.Mul(s[4].w.Dot(s[5].w))
+			for _, theta5 := range solveTrig(
+				n.Dot(s[5].w.Sub(w6Parallel)),
+				n.Dot(s[4].w.Cross(s[5].w)),
+				n.Dot(tool)-n.Dot(w6Parallel),
+			) {
+				from := rotatePoseVector(spatialmath.PoseInverse(g1), n)
+				to := rotateVector(s[4].w, -theta5, n)
+				theta6 := seed[5]
+				if perpendicularComponent(s[5].w, from).Norm() > analyticAngleEpsilon {
+					theta6 = rotationAngle(s[5].w, from, to)
+				}
+				g2 := spatialmath.Compose(g1, spatialmath.PoseInverse(productOfScrews(
+					s[4:], []float64{theta5, theta6}, spatialmath.NewZeroPose(),
+				)))
+				target := transformPoint(g2, s[3].q)
+				for _, theta3 := range subproblem3(s[2], s[3].q, s[1].q, target.Distance(s[1].q)) {
+					theta2 := subproblem1(s[1], s[2].rotate(theta3, s[3].q), target)
+					arm := productOfScrews(s[1:3], []float64{theta2, theta3}, spatialmath.NewZeroPose())
+					g3 := spatialmath.Compose(spatialmath.PoseInverse(arm), g2)
+					theta4 := subproblem1(s[3], planarOffset, transformPoint(g3, planarOffset))
+					branches = append(branches, []float64{theta1, theta2, theta3, theta4, theta5, theta6})
+				}
+			}
+		}
+		return branches
+	}, true
+}
+
+// subproblem1 is the angle to rotate about the axis to bring the point p to the point q.
+func subproblem1(s screw, p, q r3.Vector) float64 {
+	return rotationAngle(s.w, p.Sub(s.q), q.Sub(s.q))
+}
+
+// subproblem2 finds the angles to rotate about the second and then the first axis, which intersect at r, to bring the
+// point p to the point q. If the rotations are not unique, the first angle is taken from the seed.
+func subproblem2(s1, s2 screw, r, p, q r3.Vector, seed1 float64) [][2]float64 {
+	u := p.Sub(r)
+	v := q.Sub(r)
+	if math.Abs(u.Norm()-v.Norm()) > analyticDistEpsilon {
+		return nil
+	}
+	w1, w2 := s1.w, s2.w
+	dot := w1.Dot(w2)
+	det := dot*dot - 1
+	alpha := (dot*w2.Dot(u) - w1.Dot(v)) / det
+	beta := (dot*w1.Dot(v) - w2.Dot(u)) / det
+	cross := w1.Cross(w2)
+	gammaSquared := (u.Norm2() - alpha*alpha - beta*beta - 2*alpha*beta*dot) / cross.Norm2()
+	if gammaSquared < -analyticDistEpsilon {
+		return nil
+	}
+	gammas := []float64{0}
+	if gammaSquared > analyticDistEpsilon*analyticDistEpsilon {
+		gamma := math.Sqrt(gammaSquared)
+		gammas = []float64{gamma, -gamma}
+	}
+
+	solutions := make([][2]float64, 0, len(gammas))
+	for _, gamma := range gammas {
+		z := w1.Mul(alpha).Add(w2.Mul(beta)).Add(cross.Mul(gamma))
+		theta1 := seed1
+		// When p and q are on the first axis, any rotation about it works.
+		if perpendicularComponent(w1, v).Norm() > analyticDistEpsilon {
+			theta1 = rotationAngle(w1, z, v)
+		}
+		theta2 := rotationAngle(w2, u, z)
+		solutions = append(solutions, [2]float64{theta1, theta2})
+	}
+	return solutions
+}
+
+// subproblem3 finds the angles to rotate about the axis to bring the point p to a distance delta from the point q.
+func subproblem3(s screw, p, q r3.Vector, delta float64) []float64 {
+	u := p.Sub(s.q)
+	v := q.Sub(s.q)
+	along := s.w.Dot(p.Sub(q))
+	uPerp := perpendicularComponent(s.w, u)
+	vPerp := perpendicularComponent(s.w, v)
+	if uPerp.Norm() < analyticDistEpsilon || vPerp.Norm() < analyticDistEpsilon {
+		return nil
+	}
+	cos := (uPerp.Norm2() + vPerp.Norm2() - (delta*delta - along*along)) / (2 * uPerp.Norm() * vPerp.Norm())
+	if math.Abs(cos) > 1+analyticDistEpsilon {
+		return nil
+	}
+	theta0 := rotationAngle(s.w, uPerp, vPerp)
+	phi := math.Acos(math.Max(-1, math.Min(1, cos)))
+	if phi < analyticAngleEpsilon {
+		return []float64{theta0}
+	}
+	return []float64{theta0 - phi, theta0 + phi}
+}
+
+// solveTrig finds the angles where a*cos(theta) + b*sin(theta) = c.
+func solveTrig(a, b, c float64) []float64 {
+	r := math.Hypot(a, b)
+	if r < analyticAngleEpsilon {
+		return nil
+	}
+	cos := c / r
+	if math.Abs(cos) > 1+analyticAngleEpsilon {
+		return nil
+	}
+	theta0 := math.Atan2(b, a)
+	phi := math.Acos(math.Max(-1, math.Min(1, cos)))
+	if phi < analyticAngleEpsilon {
+		return []float64{theta0}
+	}
+	return []float64{theta0 + phi, theta0 - phi}
+}
+
+// rotationAngle is the angle about the axis w which rotates the direction from onto the direction to, ignoring the
+// components of both along the axis.
+func rotationAngle(w, from, to r3.Vector) float64 {
+	from = perpendicularComponent(w, from)
+	to = perpendicularComponent(w, to)
+	return math.Atan2(w.Dot(from.Cross(to)), from.Dot(to))
+}
+
+// rotateVector rotates v by theta about the unit axis w.
+func rotateVector(w r3.Vector, theta float64, v r3.Vector) r3.Vector {
+	sin, cos := math.Sincos(theta)
+	return v.Mul(cos).Add(w.Cross(v).Mul(sin)).Add(w.Mul(w.Dot(v) * (1 - cos)))
+}
+
+// perpendicularComponent is the component of v perpendicular to the unit axis w.
+func perpendicularComponent(w, v r3.Vector) r3.Vector {
+	return v.Sub(w.Mul(w.Dot(v)))
+}
+
+// anyPerpendicular is a unit vector perpendicular to the unit vector w.
+func anyPerpendicular(w r3.Vector) r3.Vector {
+	return w.Cross(w.Ortho()).Normalize()
+}
+
+func parallel(a, b r3.Vector) bool {
+	return a.Cross(b).Norm() < analyticAngleEpsilon
+}
+
+// intersection returns the point where two axes meet, if they do.
+func intersection(a, b screw) (r3.Vector, bool) {
+	cross := a.w.Cross(b.w)
+	if cross.Norm() < analyticAngleEpsilon {
+		return r3.Vector{}, false
+	}
+	// The point on a closest to b.
+	d := b.q.Sub(a.q)
+	p := a.q.Add(a.w.Mul(d.Cross(b.w).Dot(cross) / cross.Norm2()))
+	return p, b.contains(p)
+}
+
+func transformPoint(pose spatialmath.Pose, p r3.Vector) r3.Vector {
+	return spatialmath.Compose(pose, spatialmath.NewPoseFromPoint(p)).Point()
+}
+
+func rotatePoseVector(pose spatialmath.Pose, v r3.Vector) r3.Vector {
+	return spatialmath.Compose(spatialmath.NewPoseFromOrientation(pose.Orientation()), spatialmath.NewPoseFromPoint(v)).Point()
+}
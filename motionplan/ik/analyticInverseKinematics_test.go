@@ -0,0 +1,98 @@
+package ik
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+
+	"github.com/edaniels/golog"
+	"go.viam.com/test"
+
+	frame "go.viam.com/rdk/referenceframe"
+	spatial "go.viam.com/rdk/spatialmath"
+	"go.viam.com/rdk/utils"
+)
+
+// checkAnalyticSolutions solves for the poses of random joint positions, checking that every solution reaches the pose
+// within the joint limits and that the joint positions the pose came from are among them.
+func checkAnalyticSolutions(t *testing.T, m frame.Model, ik *AnalyticIK) {
+	t.Helper()
+	//nolint: gosec
+	randSeed := rand.New(rand.NewSource(1))
+	for i := 0; i < 50; i++ {
+		expected := frame.RandomFrameInputs(m, randSeed)
+		goal, err := m.Transform(expected)
+		test.That(t, err, test.ShouldBeNil)
+
+		solutions := ik.Solutions(goal, home)
+		test.That(t, solutions, test.ShouldNotBeEmpty)
+		found := false
+		for _, solution := range solutions {
+			pose, err := m.Transform(solution)
+			test.That(t, err, test.ShouldBeNil)
+			test.That(t, spatial.PoseAlmostEqualEps(pose, goal, analyticGoalEpsilon), test.ShouldBeTrue)
+			if frame.InputsL2Distance(solution, expected) < 1e-4 {
+				found = true
+			}
+		}
+		test.That(t, found, test.ShouldBeTrue)
+		// solutions are ordered by their distance from the seed
+		test.That(t, frame.InputsL2Distance(home, solutions[0]), test.ShouldBeLessThanOrEqualTo,
+			frame.InputsL2Distance(home, solutions[len(solutions)-1]))
+	}
+}
+
+func TestAnalyticIKOffsetWrist(t *testing.T) {
+	m, err := frame.ParseModelJSONFile(utils.ResolveFile("components/arm/universalrobots/ur5e.json"), "")
+	test.That(t, err, test.ShouldBeNil)
+	ik, err := CreateAnalyticIKSolver(m, golog.NewTestLogger(t))
+	test.That(t, err, test.ShouldBeNil)
+	checkAnalyticSolutions(t, m, ik)
+}
+
+func TestAnalyticIKSphericalWrist(t *testing.T) {
+	m, err := frame.ParseModelJSONFile(utils.ResolveFile("components/arm/xarm/xarmlite_kinematics.json"), "")
+	test.That(t, err, test.ShouldBeNil)
+	ik, err := CreateAnalyticIKSolver(m, golog.NewTestLogger(t))
+	test.That(t, err, test.ShouldBeNil)
+	checkAnalyticSolutions(t, m, ik)
+}
+
+func TestAnalyticIKUnsupported(t *testing.T) {
+	logger := golog.NewTestLogger(t)
+	// the last joint axis of the xArm6 does not pass through the wrist
+	m, err := frame.ParseModelJSONFile(utils.ResolveFile("components/arm/xarm/xarm6_kinematics.json"), "")
+	test.That(t, err, test.ShouldBeNil)
+	_, err = CreateAnalyticIKSolver(m, logger)
+	test.That(t, err, test.ShouldBeError, errNotAnalytic)
+
+	m, err = frame.ParseModelJSONFile(utils.ResolveFile("components/arm/xarm/xarm7_kinematics.json"), "")
+	test.That(t, err, test.ShouldBeNil)
+	_, err = CreateAnalyticIKSolver(m, logger)
+	test.That(t, err, test.ShouldBeError, errNotAnalytic)
+}
+
+func TestAnalyticIKSolve(t *testing.T) {
+	logger := golog.NewTestLogger(t)
+	m, err := frame.ParseModelJSONFile(utils.ResolveFile("components/arm/universalrobots/ur5e.json"), "")
+	test.That(t, err, test.ShouldBeNil)
+	goalJP := frame.JointPositionsFromRadians([]float64{-4.128, 2.71, 2.798, 2.3, 1.291, 0.62})
+	goal, err := m.Transform(m.InputFromProtobuf(goalJP))
+	test.That(t, err, test.ShouldBeNil)
+
+	analytic, err := CreateAnalyticIKSolver(m, logger)
+	test.That(t, err, test.ShouldBeNil)
+	_, err = solveTest(context.Background(), analytic, goal, home)
+	test.That(t, err, test.ShouldNotBeNil)
+	solutions, err := solveTest(ContextWithGoal(context.Background(), goal), analytic, goal, home)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, solutions, test.ShouldResemble, analytic.Solutions(goal, home))
+
+	// the combined solver uses the analytic solver when it knows the goal
+	combined, err := CreateCombinedIKSolver(m, logger, nCPU, defaultGoalThreshold)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, combined.analytic, test.ShouldNotBeNil)
+	combinedSolutions, err := solveTest(ContextWithGoal(context.Background(), goal), combined, goal, home)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, combinedSolutions, test.ShouldResemble, solutions)
+}
@@ -15,14 +15,16 @@ import (
 
 // CombinedIK defines the fields necessary to run a combined solver.
 type CombinedIK struct {
-	solvers []InverseKinematics
-	model   referenceframe.Frame
-	logger  golog.Logger
+	solvers  []InverseKinematics
+	analytic *AnalyticIK
+	model    referenceframe.Frame
+	logger   golog.Logger
 }
 
 // CreateCombinedIKSolver creates a combined parallel IK solver with a number of nlopt solvers equal to the nCPU
 // passed in. Each will be given a different random seed. When asked to solve, all solvers will be run in parallel
-// and the first valid found solution will be returned.
+// and the first valid found solution will be returned. If the model has the geometry of an arm that can be solved for
+// in closed form, an analytic solver is used instead whenever the goal pose is given with ContextWithGoal.
 func CreateCombinedIKSolver(model referenceframe.Frame, logger golog.Logger, nCPU int, goalThreshold float64) (*CombinedIK, error) {
 	ik := &CombinedIK{}
 	ik.model = model
@@ -37,6 +39,10 @@ func CreateCombinedIKSolver(model referenceframe.Frame, logger golog.Logger, nCP
 		}
 		ik.solvers = append(ik.solvers, nlopt)
 	}
+	if analytic, err := CreateAnalyticIKSolver(model, logger); err == nil {
+		logger.Debugf("using analytic inverse kinematics for %s", model.Name())
+		ik.analytic = analytic
+	}
 	ik.logger = logger
 	return ik, nil
 }
@@ -49,6 +55,9 @@ func (ik *CombinedIK) Solve(ctx context.Context,
 	m StateMetric,
 	rseed int,
 ) error {
+	if _, ok := GoalFromContext(ctx); ok && ik.analytic != nil {
+		return ik.analytic.Solve(ctx, c, seed, m, rseed)
+	}
 	var err error
 	ctxWithCancel, cancel := context.WithCancel(ctx)
 	defer cancel()
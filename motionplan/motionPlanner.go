@@ -49,7 +49,7 @@ func PlanMotion(ctx context.Context,
 	constraintSpec *pb.Constraints,
 	planningOpts map[string]interface{},
 ) ([]map[string][]frame.Input, error) {
-	return motionPlanInternal(ctx, logger, dst, f, seedMap, fs, worldState, constraintSpec, planningOpts)
+	return motionPlanInternal(ctx, logger, dst, f, seedMap, fs, worldState, constraintSpec, planningOpts, nil)
 }
 
 // PlanFrameMotion plans a motion to destination for a given frame with no frame system. It will create a new FS just for the plan.
@@ -69,7 +69,7 @@ func PlanFrameMotion(ctx context.Context,
 	}
 	destination := frame.NewPoseInFrame(frame.World, dst)
 	seedMap := map[string][]frame.Input{f.Name(): seed}
-	solutionMap, err := motionPlanInternal(ctx, logger, destination, f, seedMap, fs, nil, constraintSpec, planningOpts)
+	solutionMap, err := motionPlanInternal(ctx, logger, destination, f, seedMap, fs, nil, constraintSpec, planningOpts, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -77,7 +77,7 @@ func PlanFrameMotion(ctx context.Context,
 }
 
 // motionPlanInternal is the internal private function that all motion planning access calls. This will construct the plan manager for each
-// waypoint, and return at the end.
+// waypoint, and return at the end. If a cache is given, plans are looked for in and added to it.
 func motionPlanInternal(ctx context.Context,
 	logger golog.Logger,
 	goal *frame.PoseInFrame,
@@ -87,6 +87,7 @@ func motionPlanInternal(ctx context.Context,
 	worldState *frame.WorldState,
 	constraintSpec *pb.Constraints,
 	motionConfig map[string]interface{},
+	cache *PlanCache,
 ) ([]map[string][]frame.Input, error) {
 	if goal == nil {
 		return nil, errors.New("no destination passed to Motion")
@@ -122,15 +123,11 @@ func motionPlanInternal(ctx context.Context,
 	logger.Debugf("constraint specs for this step: %v", constraintSpec)
 	logger.Debugf("motion config for this step: %v", motionConfig)
 
-	rseed := defaultRandomSeed
-	if seed, ok := motionConfig["rseed"].(int); ok {
-		rseed = seed
-	}
-
-	sfPlanner, err := newPlanManager(sf, fs, logger, rseed)
+	sfPlanner, err := newPlanManager(sf, fs, logger, randomSeed(motionConfig))
 	if err != nil {
 		return nil, err
 	}
+	sfPlanner.cache = cache
 	logger.Debug("created new plan manager")
 	resultSlices, err := sfPlanner.PlanSingleWaypoint(ctx, seedMap, goal.Pose(), worldState, constraintSpec, motionConfig)
 	if err != nil {
@@ -146,6 +143,19 @@ func motionPlanInternal(ctx context.Context,
 	return steps, nil
 }
 
+// randomSeed returns the seed for the random numbers used in planning, given by the "rseed" option or the default.
+func randomSeed(motionConfig map[string]interface{}) int {
+	switch seed := motionConfig["rseed"].(type) {
+	case int:
+		return seed
+	case float64:
+		// options which have been through JSON, such as those read from a PlanRecord, hold all numbers as floats
+		return int(seed)
+	default:
+		return defaultRandomSeed
+	}
+}
+
 type planner struct {
 	solver   ik.InverseKinematics
 	frame    frame.Frame
@@ -156,7 +166,13 @@ type planner struct {
 }
 
 func newPlanner(frame frame.Frame, seed *rand.Rand, logger golog.Logger, opt *plannerOptions) (*planner, error) {
-	solver, err := ik.CreateCombinedIKSolver(frame, logger, opt.NumThreads, opt.GoalThreshold)
+	var solver ik.InverseKinematics
+	var err error
+	if sf, ok := frame.(*solverFrame); ok && len(sf.components) > 0 && opt.componentGoals != nil {
+		solver, err = newComponentIK(sf, logger, opt)
+	} else {
+		solver, err = ik.CreateCombinedIKSolver(frame, logger, opt.NumThreads, opt.GoalThreshold)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -272,6 +288,10 @@ func (mp *planner) getSolutions(ctx context.Context, seed []frame.Input) ([]node
 
 	ctxWithCancel, cancel := context.WithCancel(ctx)
 	defer cancel()
+	// Knowing the goal pose lets solvers for arms with closed form kinematics solve for it directly.
+	if mp.planOpts.goal != nil {
+		ctxWithCancel = ik.ContextWithGoal(ctxWithCancel, mp.planOpts.goal)
+	}
 
 	solutionGen := make(chan *ik.Solution, mp.planOpts.NumThreads*2)
 	ikErr := make(chan error, 1)
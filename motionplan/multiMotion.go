@@ -0,0 +1,307 @@
+package motionplan
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/edaniels/golog"
+	"github.com/pkg/errors"
+	"go.uber.org/multierr"
+	commonpb "go.viam.com/api/common/v1"
+	pb "go.viam.com/api/service/motion/v1"
+	"go.viam.com/utils"
+
+	"go.viam.com/rdk/motionplan/ik"
+	frame "go.viam.com/rdk/referenceframe"
+	"go.viam.com/rdk/spatialmath"
+	rutils "go.viam.com/rdk/utils"
+)
+
+// PlanMultiMotion plans for several frames to move at once, each to its own goal, keyed by the name of the frame. Planning is done in
+// the combined configuration space of all the frames, so the frames are kept from colliding with each other while they move as well as
+// with obstacles. The plan is synchronized: between each step no frame moves more than the "sync_step_size" planning option, in mm or
+// degrees, so the frames stay in step with each other if each step is executed for all of them at once.
+//
+// The frames may not share any frames which move, such as two arms on one gantry. Only the free motion profile is supported.
+func PlanMultiMotion(ctx context.Context,
+	logger golog.Logger,
+	goals map[string]*frame.PoseInFrame,
+	seedMap map[string][]frame.Input,
+	fs frame.FrameSystem,
+	worldState *frame.WorldState,
+	constraintSpec *pb.Constraints,
+	planningOpts map[string]interface{},
+) ([]map[string][]frame.Input, error) {
+	if len(goals) == 0 {
+		return nil, errors.New("no destinations passed to Motion")
+	}
+
+	// sort the frames so that the same request is always planned the same way
+	names := make([]string, 0, len(goals))
+	for name := range goals {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	components := make([]*solverFrame, 0, len(names))
+	goalPoses := make([]spatialmath.Pose, 0, len(names))
+	for _, name := range names {
+		goal := goals[name]
+		if goal == nil {
+			return nil, fmt.Errorf("no destination passed for frame %q", name)
+		}
+		component, err := newSolverFrame(fs, name, goal.Parent(), seedMap)
+		if err != nil {
+			return nil, err
+		}
+		if len(component.DoF()) == 0 {
+			return nil, fmt.Errorf("frame %q has no degrees of freedom, cannot perform inverse kinematics", name)
+		}
+		goalPose := goal.Pose()
+		// If the component is world rooted, translate its goal pose into the world frame
+		if component.worldRooted {
+			tf, err := fs.Transform(seedMap, goal, frame.World)
+			if err != nil {
+				return nil, err
+			}
+			goalPose = tf.(*frame.PoseInFrame).Pose()
+		}
+		components = append(components, component)
+		goalPoses = append(goalPoses, goalPose)
+	}
+	sf, err := newMultiSolverFrame(components, seedMap)
+	if err != nil {
+		return nil, err
+	}
+
+	goalsPb := make(map[string]*commonpb.PoseInFrame, len(goals))
+	for name, goal := range goals {
+		goalsPb[name] = frame.PoseInFrameToProtobuf(goal)
+	}
+	logger.Infof("planning motion for frames %v\nGoals: %v\nStarting seed map %v\n, worldstate: %v\n",
+		names,
+		goalsPb,
+		seedMap,
+		worldState.String(),
+	)
+
+	sfPlanner, err := newPlanManager(sf, fs, logger, randomSeed(planningOpts))
+	if err != nil {
+		return nil, err
+	}
+	if sfPlanner.useTPspace {
+		return nil, errors.New("cannot plan for a TP-space frame together with other frames")
+	}
+	resultSlices, err := sfPlanner.PlanMultiWaypoint(ctx, seedMap, goalPoses, worldState, constraintSpec, planningOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	syncStepSize, ok := planningOpts["sync_step_size"].(float64)
+	if !ok {
+		syncStepSize = defaultSyncStepSize
+	}
+	steps := []map[string][]frame.Input{}
+	for _, resultSlice := range synchronizeSteps(sf, resultSlices, syncStepSize) {
+		steps = append(steps, sf.sliceToMap(resultSlice))
+	}
+	logger.Debugf("final plan steps: %v", steps)
+	return steps, nil
+}
+
+// componentStepCount returns the number of steps to take between two sets of inputs of a solver frame moving several components,
+// so that no component moves more than stepSize between steps.
+func (sf *solverFrame) componentStepCount(from, to []frame.Input, stepSize float64) (int, error) {
+	fromInputs := sf.componentInputs(from)
+	toInputs := sf.componentInputs(to)
+	steps := 1
+	for i, component := range sf.components {
+		fromPose, err := component.Transform(fromInputs[i])
+		if err != nil {
+			return 0, err
+		}
+		toPose, err := component.Transform(toInputs[i])
+		if err != nil {
+			return 0, err
+		}
+		steps = rutils.MaxInt(steps, PathStepCount(fromPose, toPose, stepSize))
+	}
+	return steps, nil
+}
+
+// synchronizeSteps adds steps to a plan for a solver frame moving several components, so that no component moves more than stepSize
+// between steps. Components executing the plan a step at a time then stay close to the path that was checked for collisions.
+func synchronizeSteps(sf *solverFrame, steps [][]frame.Input, stepSize float64) [][]frame.Input {
+	if len(steps) == 0 {
+		return steps
+	}
+	synced := [][]frame.Input{steps[0]}
+	for i := 1; i < len(steps); i++ {
+		nSteps, err := sf.componentStepCount(steps[i-1], steps[i], stepSize)
+		if err != nil {
+			nSteps = 1
+		}
+		for j := 1; j <= nSteps; j++ {
+			synced = append(synced, frame.InterpolateInputs(steps[i-1], steps[i], float64(j)/float64(nSteps)))
+		}
+	}
+	return synced
+}
+
+// newMultiGoalMetric returns a metric which converges when each component of a solver frame reaches its goal.
+func newMultiGoalMetric(sf *solverFrame, goals []spatialmath.Pose) ik.StateMetric {
+	metrics := make([]ik.StateMetric, 0, len(goals))
+	for _, goal := range goals {
+		metrics = append(metrics, ik.NewSquaredNormMetric(goal))
+	}
+	return func(state *ik.State) float64 {
+		total := 0.
+		for i, inputs := range sf.componentInputs(state.Configuration) {
+			pose, err := sf.components[i].Transform(inputs)
+			if err != nil {
+				return math.Inf(1)
+			}
+			total += metrics[i](&ik.State{Position: pose, Configuration: inputs, Frame: sf.components[i]})
+		}
+		return total
+	}
+}
+
+// componentIK solves for the goals of a solver frame moving several components by solving for the goal of each component
+// separately, which is possible because they share no moving frames. Every combination of the solutions found for each component is a
+// solution for the whole frame.
+type componentIK struct {
+	frame   *solverFrame
+	solvers []ik.InverseKinematics
+	goals   []spatialmath.Pose
+	metrics []ik.StateMetric
+}
+
+func newComponentIK(sf *solverFrame, logger golog.Logger, opt *plannerOptions) (*componentIK, error) {
+	if len(opt.componentGoals) != len(sf.components) {
+		return nil, fmt.Errorf("got %d goals for %d components", len(opt.componentGoals), len(sf.components))
+	}
+	cik := &componentIK{frame: sf, goals: opt.componentGoals}
+	for i, component := range sf.components {
+		solver, err := ik.CreateCombinedIKSolver(component, logger, opt.NumThreads, opt.GoalThreshold)
+		if err != nil {
+			return nil, err
+		}
+		cik.solvers = append(cik.solvers, solver)
+		cik.metrics = append(cik.metrics, ik.NewSquaredNormMetric(opt.componentGoals[i]))
+	}
+	return cik, nil
+}
+
+// componentSolution is a solution for one component of a componentIK.
+type componentSolution struct {
+	component int
+	solution  *ik.Solution
+}
+
+// Solve runs the solvers for every component at once, sending each new combination of their solutions to the channel as they are
+// found. The given metric scores the combined solutions.
+func (cik *componentIK) Solve(ctx context.Context,
+	c chan<- *ik.Solution,
+	seed []frame.Input,
+	m ik.StateMetric,
+	rseed int,
+) error {
+	ctxWithCancel, cancel := context.WithCancel(ctx)
+	defer cancel()
+	seeds := cik.frame.componentInputs(seed)
+
+	found := make(chan componentSolution)
+	errChan := make(chan error, len(cik.solvers))
+	var activeSolvers sync.WaitGroup
+	for i := range cik.solvers {
+		i := i
+		solutions := make(chan *ik.Solution)
+		activeSolvers.Add(2)
+		utils.PanicCapturingGo(func() {
+			defer activeSolvers.Done()
+			defer close(solutions)
+			goalCtx := ik.ContextWithGoal(ctxWithCancel, cik.goals[i])
+			if err := cik.solvers[i].Solve(goalCtx, solutions, seeds[i], cik.metrics[i], rseed+i); err != nil {
+				errChan <- errors.Wrapf(err, "failed to solve for %s", cik.frame.components[i].Name())
+			}
+		})
+		utils.PanicCapturingGo(func() {
+			defer activeSolvers.Done()
+			// keep reading after cancellation so that the solver can return
+			for solution := range solutions {
+				select {
+				case found <- componentSolution{component: i, solution: solution}:
+				case <-ctxWithCancel.Done():
+				}
+			}
+		})
+	}
+	allDone := make(chan struct{})
+	utils.PanicCapturingGo(func() {
+		activeSolvers.Wait()
+		close(allDone)
+	})
+
+	componentSolutions := make([][]*ik.Solution, len(cik.solvers))
+	for {
+		select {
+		case <-ctx.Done():
+			cancel()
+			<-allDone
+			return ctx.Err()
+		case <-allDone:
+			close(errChan)
+			var collectedErrs error
+			for err := range errChan {
+				collectedErrs = multierr.Combine(collectedErrs, err)
+			}
+			for _, solutions := range componentSolutions {
+				if len(solutions) == 0 {
+					if collectedErrs == nil {
+						collectedErrs = errors.New("a component has no inverse kinematics solutions")
+					}
+					return collectedErrs
+				}
+			}
+			return nil
+		case next := <-found:
+			componentSolutions[next.component] = append(componentSolutions[next.component], next.solution)
+			for _, combined := range cik.combinations(componentSolutions, next) {
+				score := m(&ik.State{Configuration: combined.Configuration, Frame: cik.frame})
+				combined.Score = score
+				select {
+				case c <- combined:
+				case <-ctx.Done():
+					cancel()
+					<-allDone
+					return ctx.Err()
+				}
+			}
+		}
+	}
+}
+
+// combinations returns every combination of a new solution for one component with the solutions found for the others.
+func (cik *componentIK) combinations(componentSolutions [][]*ik.Solution, next componentSolution) []*ik.Solution {
+	combined := []*ik.Solution{{Exact: true}}
+	for i, solutions := range componentSolutions {
+		if i == next.component {
+			solutions = []*ik.Solution{next.solution}
+		}
+		expanded := make([]*ik.Solution, 0, len(combined)*len(solutions))
+		for _, partial := range combined {
+			for _, solution := range solutions {
+				configuration := make([]frame.Input, 0, len(partial.Configuration)+len(solution.Configuration))
+				configuration = append(configuration, partial.Configuration...)
+				configuration = append(configuration, solution.Configuration...)
+				expanded = append(expanded, &ik.Solution{Configuration: configuration, Exact: partial.Exact && solution.Exact})
+			}
+		}
+		combined = expanded
+	}
+	return combined
+}
@@ -0,0 +1,109 @@
+package motionplan
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/geo/r3"
+	"go.viam.com/test"
+
+	"go.viam.com/rdk/motionplan/ik"
+	frame "go.viam.com/rdk/referenceframe"
+	"go.viam.com/rdk/spatialmath"
+	"go.viam.com/rdk/utils"
+)
+
+// dualArmFrameSystem has two ur5e arms facing each other, far enough apart that they do not collide at ur5eStart.
+func dualArmFrameSystem(t *testing.T) (frame.FrameSystem, map[string][]frame.Input) {
+	t.Helper()
+	fs := frame.NewEmptyFrameSystem("test")
+	for _, arm := range []struct {
+		name string
+		pose spatialmath.Pose
+	}{
+		{"left", spatialmath.NewZeroPose()},
+		{"right", spatialmath.NewPose(r3.Vector{X: -1500}, &spatialmath.OrientationVectorDegrees{OZ: 1, Theta: 180})},
+	} {
+		m, err := frame.ParseModelJSONFile(utils.ResolveFile("components/arm/universalrobots/ur5e.json"), arm.name)
+		test.That(t, err, test.ShouldBeNil)
+		offset, err := frame.NewStaticFrame(arm.name+"_offset", arm.pose)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, fs.AddFrame(offset, fs.World()), test.ShouldBeNil)
+		test.That(t, fs.AddFrame(m, offset), test.ShouldBeNil)
+	}
+	return fs, map[string][]frame.Input{"left": ur5eStart, "right": ur5eStart}
+}
+
+func TestPlanMultiMotion(t *testing.T) {
+	fs, seedMap := dualArmFrameSystem(t)
+	goals := map[string]*frame.PoseInFrame{}
+	for _, name := range []string{"left", "right"} {
+		tf, err := fs.Transform(seedMap, frame.NewPoseInFrame(name, spatialmath.NewZeroPose()), frame.World)
+		test.That(t, err, test.ShouldBeNil)
+		start := tf.(*frame.PoseInFrame).Pose()
+		goals[name] = frame.NewPoseInFrame(frame.World, spatialmath.NewPose(start.Point().Add(r3.Vector{Y: 50, Z: 30}), start.Orientation()))
+	}
+
+	plan, err := PlanMultiMotion(context.Background(), logger.Sugar(), goals, seedMap, fs, nil, nil, nil)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, plan[0]["left"], test.ShouldResemble, ur5eStart)
+	test.That(t, plan[0]["right"], test.ShouldResemble, ur5eStart)
+	for name, goal := range goals {
+		tf, err := fs.Transform(plan[len(plan)-1], frame.NewPoseInFrame(name, spatialmath.NewZeroPose()), frame.World)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, spatialmath.PoseAlmostCoincidentEps(tf.(*frame.PoseInFrame).Pose(), goal.Pose(), 0.1), test.ShouldBeTrue)
+	}
+
+	// both arms move together, a little at a time
+	for i := 1; i < len(plan); i++ {
+		for name := range goals {
+			from, err := fs.Transform(plan[i-1], frame.NewPoseInFrame(name, spatialmath.NewZeroPose()), frame.World)
+			test.That(t, err, test.ShouldBeNil)
+			to, err := fs.Transform(plan[i], frame.NewPoseInFrame(name, spatialmath.NewZeroPose()), frame.World)
+			test.That(t, err, test.ShouldBeNil)
+			dist := from.(*frame.PoseInFrame).Pose().Point().Distance(to.(*frame.PoseInFrame).Pose().Point())
+			test.That(t, dist, test.ShouldBeLessThanOrEqualTo, defaultSyncStepSize)
+		}
+	}
+
+	_, err = PlanMultiMotion(context.Background(), logger.Sugar(), goals, seedMap, fs, nil, nil,
+		map[string]interface{}{"motion_profile": LinearMotionProfile})
+	test.That(t, err, test.ShouldNotBeNil)
+}
+
+func TestMultiMotionArmCollisions(t *testing.T) {
+	fs, seedMap := dualArmFrameSystem(t)
+	var components []*solverFrame
+	var goals []spatialmath.Pose
+	for _, name := range []string{"left", "right"} {
+		component, err := newSolverFrame(fs, name, frame.World, seedMap)
+		test.That(t, err, test.ShouldBeNil)
+		components = append(components, component)
+		goal, err := component.Transform(ur5eStart)
+		test.That(t, err, test.ShouldBeNil)
+		goals = append(goals, goal)
+	}
+	sf, err := newMultiSolverFrame(components, seedMap)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, sf.DoF(), test.ShouldHaveLength, 12)
+
+	pm, err := newPlanManager(sf, fs, logger.Sugar(), 1)
+	test.That(t, err, test.ShouldBeNil)
+	opt, err := pm.plannerSetupFromMultiMoveRequest(goals, seedMap, nil, nil, nil)
+	test.That(t, err, test.ShouldBeNil)
+
+	seed, err := sf.mapToSlice(seedMap)
+	test.That(t, err, test.ShouldBeNil)
+	ok, _ := opt.CheckStateConstraints(&ik.State{Configuration: seed, Frame: sf})
+	test.That(t, ok, test.ShouldBeTrue)
+
+	// with both arms stretched out they reach into each other
+	stretched := frame.FloatsToInputs(make([]float64, 12))
+	ok, failName := opt.CheckStateConstraints(&ik.State{Configuration: stretched, Frame: sf})
+	test.That(t, ok, test.ShouldBeFalse)
+	test.That(t, failName, test.ShouldEqual, defaultSelfCollisionConstraintDesc)
+
+	// the arms may not share moving frames
+	_, err = newMultiSolverFrame([]*solverFrame{components[0], components[0]}, seedMap)
+	test.That(t, err, test.ShouldNotBeNil)
+}
@@ -0,0 +1,523 @@
+package motionplan
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"sync"
+
+	"github.com/edaniels/golog"
+	pb "go.viam.com/api/service/motion/v1"
+	"google.golang.org/protobuf/proto"
+
+	"go.viam.com/rdk/motionplan/ik"
+	"go.viam.com/rdk/referenceframe"
+	"go.viam.com/rdk/spatialmath"
+)
+
+const (
+	// Number of plans kept by a PlanCache, after which the least recently used plans are forgotten.
+	defaultPlanCacheSize = 100
+
+	// Number of configurations kept in the roadmap of each frame, after which plans are no longer added to it.
+	defaultRoadmapSize = 2000
+
+	// Number of roadmap configurations the start and goal are connected to.
+	defaultRoadmapNeighbors = 5
+
+	// Number of times a path through the roadmap is searched for, after finding the shortest one collides.
+	defaultRoadmapSearches = 10
+
+	// How far, in radians or mm across all inputs, the start may be from that of a cached plan for it to be reused.
+	defaultCacheStartTolerance = 0.01
+
+	// How far, in mm, the goal or an obstacle may be from where they were for a cached plan for it to be reused.
+	defaultCacheGoalTolerance = 1.
+
+	// How far, in degrees, the goal or an obstacle may be rotated from where they were for a cached plan for it to be reused.
+	defaultCacheOrientTolerance = 1.
+)
+
+// planning options which do not change which plans are valid, so do not need to match for a cached plan to be reused.
+var planCacheIgnoredOptions = map[string]bool{
+	"timeout":                     true,
+	"rseed":                       true,
+	"plan_cache_start_tolerance":  true,
+	"plan_cache_goal_tolerance":   true,
+	"plan_cache_orient_tolerance": true,
+}
+
+type planCacheOptions struct {
+	// How far, in radians or mm across all inputs, the start may be from that of a cached plan for it to be reused.
+	StartTolerance float64 `json:"plan_cache_start_tolerance"`
+
+	// How far, in mm, the goal or an obstacle may be from where they were for a cached plan for it to be reused.
+	GoalTolerance float64 `json:"plan_cache_goal_tolerance"`
+
+	// How far, in degrees, the goal or an obstacle may be rotated from where they were for a cached plan for it to be reused.
+	OrientTolerance float64 `json:"plan_cache_orient_tolerance"`
+}
+
+func newPlanCacheOptions(planningOpts map[string]interface{}) (*planCacheOptions, error) {
+	cacheOpts := &planCacheOptions{
+		StartTolerance:  defaultCacheStartTolerance,
+		GoalTolerance:   defaultCacheGoalTolerance,
+		OrientTolerance: defaultCacheOrientTolerance,
+	}
+	// convert map to json
+	jsonString, err := json.Marshal(planningOpts)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(jsonString, cacheOpts); err != nil {
+		return nil, err
+	}
+	return cacheOpts, nil
+}
+
+// PlanCache remembers the plans made for repeated motions so that they can be reused. A cached plan is reused when a request has
+// the same frame, constraints and planning options, and its start, goal and obstacles are all within tolerances of those the plan
+// was made for. Before being reused, a cached plan is checked against the constraints of the new request, so it will not be reused
+// if an obstacle now blocks it. When no cached plan can be reused, the cache keeps a roadmap of the configurations of every plan
+// it has made for each frame, which is searched for a path before planning from scratch.
+//
+// The cache does not hold plans for the cartesian motion profile, nor for TP-space frames.
+type PlanCache struct {
+	mu sync.Mutex
+	// ordered from most to least recently used.
+	plans    []*cachedPlan
+	roadmaps map[string]*roadmap
+}
+
+// NewPlanCache creates an empty PlanCache.
+func NewPlanCache() *PlanCache {
+	return &PlanCache{roadmaps: map[string]*roadmap{}}
+}
+
+// PlanMotion plans a motion as the package level PlanMotion does, reusing and adding to the plans held by the cache.
+func (pc *PlanCache) PlanMotion(ctx context.Context,
+	logger golog.Logger,
+	dst *referenceframe.PoseInFrame,
+	f referenceframe.Frame,
+	seedMap map[string][]referenceframe.Input,
+	fs referenceframe.FrameSystem,
+	worldState *referenceframe.WorldState,
+	constraintSpec *pb.Constraints,
+	planningOpts map[string]interface{},
+) ([]map[string][]referenceframe.Input, error) {
+	return motionPlanInternal(ctx, logger, dst, f, seedMap, fs, worldState, constraintSpec, planningOpts, pc)
+}
+
+// Clear forgets every plan held by the cache. This should be done when the frame system changes.
+func (pc *PlanCache) Clear() {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	pc.plans = nil
+	pc.roadmaps = map[string]*roadmap{}
+}
+
+// cachedPlan is a plan made for a frame, with what it was made for.
+type cachedPlan struct {
+	frameName string
+	// the serialized constraints and planning options the plan was made with.
+	optionsKey string
+	start      []referenceframe.Input
+	goal       spatialmath.Pose
+	// the obstacles in the world frame.
+	obstacles []spatialmath.Geometry
+	plan      [][]referenceframe.Input
+}
+
+// planCacheRequest describes a request to plan for a solver frame, in the terms it is looked up in a PlanCache with.
+type planCacheRequest struct {
+	frame      *solverFrame
+	optionsKey string
+	start      []referenceframe.Input
+	goal       spatialmath.Pose
+	obstacles  []spatialmath.Geometry
+	cacheOpts  *planCacheOptions
+}
+
+func newPlanCacheRequest(
+	sf *solverFrame,
+	fs referenceframe.FrameSystem,
+	seedMap map[string][]referenceframe.Input,
+	start []referenceframe.Input,
+	goal spatialmath.Pose,
+	worldState *referenceframe.WorldState,
+	constraintSpec *pb.Constraints,
+	planningOpts map[string]interface{},
+) (*planCacheRequest, error) {
+	cacheOpts, err := newPlanCacheOptions(planningOpts)
+	if err != nil {
+		return nil, err
+	}
+	keyOpts := make(map[string]interface{}, len(planningOpts))
+	for key, value := range planningOpts {
+		if !planCacheIgnoredOptions[key] {
+			keyOpts[key] = value
+		}
+	}
+	// maps are marshaled with sorted keys, so the same options always give the same key
+	optsJSON, err := json.Marshal(keyOpts)
+	if err != nil {
+		return nil, err
+	}
+	constraintsBytes, err := proto.MarshalOptions{Deterministic: true}.Marshal(constraintSpec)
+	if err != nil {
+		return nil, err
+	}
+	obstacles, err := worldState.ObstaclesInWorldFrame(fs, seedMap)
+	if err != nil {
+		return nil, err
+	}
+	return &planCacheRequest{
+		frame:      sf,
+		optionsKey: string(optsJSON) + string(constraintsBytes),
+		start:      start,
+		goal:       goal,
+		obstacles:  obstacles.Geometries(),
+		cacheOpts:  cacheOpts,
+	}, nil
+}
+
+// matches returns whether a cached plan was made for a request close enough to this one that it may be reused.
+func (req *planCacheRequest) matches(cached *cachedPlan) bool {
+	if cached.frameName != req.frame.Name() || cached.optionsKey != req.optionsKey || len(cached.start) != len(req.start) {
+		return false
+	}
+	if referenceframe.InputsL2Distance(cached.start, req.start) > req.cacheOpts.StartTolerance ||
+		!req.posesMatch(cached.goal, req.goal) ||
+		len(cached.obstacles) != len(req.obstacles) {
+		return false
+	}
+	for _, obstacle := range req.obstacles {
+		found := false
+		for _, cachedObstacle := range cached.obstacles {
+			if cachedObstacle.Label() == obstacle.Label() && req.posesMatch(cachedObstacle.Pose(), obstacle.Pose()) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func (req *planCacheRequest) posesMatch(a, b spatialmath.Pose) bool {
+	return a.Point().Distance(b.Point()) <= req.cacheOpts.GoalTolerance &&
+		ik.OrientDist(a.Orientation(), b.Orientation()) <= req.cacheOpts.OrientTolerance
+}
+
+// reusePlan returns a cached plan which can be followed for the request, adjusted to start at its start and end at its goal, or
+// nil if there is none. The plan is checked against the constraints of the given planner, which is set up for the request.
+func (pc *PlanCache) reusePlan(ctx context.Context, req *planCacheRequest, mp motionPlanner) [][]referenceframe.Input {
+	pc.mu.Lock()
+	candidates := []*cachedPlan{}
+	for _, cached := range pc.plans {
+		if req.matches(cached) {
+			candidates = append(candidates, cached)
+		}
+	}
+	pc.mu.Unlock()
+
+	for _, cached := range candidates {
+		plan := make([][]referenceframe.Input, 0, len(cached.plan)+1)
+		plan = append(plan, req.start)
+		plan = append(plan, cached.plan[1:]...)
+
+		// if the plan ends near but not at the goal, move on to the closest configuration that does reach it
+		end := plan[len(plan)-1]
+		if !reachesGoal(mp.opt(), req.frame, end) {
+			solutions, err := mp.getSolutions(ctx, end)
+			if err != nil {
+				continue
+			}
+			plan = append(plan, solutions[0].Q())
+		}
+
+		valid := true
+		for i := 0; i < len(plan)-1; i++ {
+			if !mp.checkPath(plan[i], plan[i+1]) {
+				valid = false
+				break
+			}
+		}
+		if valid {
+			pc.touch(cached)
+			return plan
+		}
+	}
+	return nil
+}
+
+// reachesGoal returns whether a configuration of a frame satisfies the goal of a set of planner options.
+func reachesGoal(opt *plannerOptions, f referenceframe.Frame, q []referenceframe.Input) bool {
+	if opt.goalMetric == nil {
+		return false
+	}
+	state := &ik.State{Configuration: q, Frame: f}
+	if err := resolveStatesToPositions(state); err != nil {
+		return false
+	}
+	return opt.goalMetric(state) <= defaultEpsilon
+}
+
+// touch marks a cached plan as the most recently used.
+func (pc *PlanCache) touch(cached *cachedPlan) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	for i, plan := range pc.plans {
+		if plan == cached {
+			copy(pc.plans[1:i+1], pc.plans[:i])
+			pc.plans[0] = cached
+			return
+		}
+	}
+}
+
+// add caches a plan made for a request, and adds its configurations to the roadmap of its frame.
+func (pc *PlanCache) add(req *planCacheRequest, plan [][]referenceframe.Input) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	pc.plans = append([]*cachedPlan{{
+		frameName:  req.frame.Name(),
+		optionsKey: req.optionsKey,
+		start:      req.start,
+		goal:       req.goal,
+		obstacles:  req.obstacles,
+		plan:       plan,
+	}}, pc.plans...)
+	if len(pc.plans) > defaultPlanCacheSize {
+		pc.plans = pc.plans[:defaultPlanCacheSize]
+	}
+
+	rm, ok := pc.roadmaps[req.frame.Name()]
+	if !ok || rm.dof != len(req.start) {
+		rm = newRoadmap(len(req.start))
+		pc.roadmaps[req.frame.Name()] = rm
+	}
+	rm.addPath(plan)
+}
+
+// searchRoadmap looks for a path from the start to any of the goals through the roadmap of a frame, which is checked against
+// the constraints of the given planner. It returns nil if there is none.
+func (pc *PlanCache) searchRoadmap(
+	ctx context.Context,
+	req *planCacheRequest,
+	mp motionPlanner,
+	goals []node,
+) []node {
+	pc.mu.Lock()
+	rm, ok := pc.roadmaps[req.frame.Name()]
+	if ok && rm.dof == len(req.start) {
+		rm = rm.copy()
+	} else {
+		rm = nil
+	}
+	pc.mu.Unlock()
+	if rm == nil {
+		return nil
+	}
+	return rm.search(ctx, mp, req.start, goals)
+}
+
+// roadmap is a graph of configurations which have been moved between, used as a probabilistic roadmap (PRM) for later plans.
+// Paths through it are checked lazily; only the edges along the shortest path are checked against constraints, and if any fail
+// it is searched again without them.
+type roadmap struct {
+	dof   int
+	nodes [][]referenceframe.Input
+	// the cost of the edges from each node, by the index of the node they go to.
+	edges []map[int]float64
+}
+
+func newRoadmap(dof int) *roadmap {
+	return &roadmap{dof: dof}
+}
+
+func (rm *roadmap) copy() *roadmap {
+	rmCopy := &roadmap{
+		dof:   rm.dof,
+		nodes: make([][]referenceframe.Input, len(rm.nodes)),
+		edges: make([]map[int]float64, len(rm.edges)),
+	}
+	copy(rmCopy.nodes, rm.nodes)
+	for i, edges := range rm.edges {
+		rmCopy.edges[i] = make(map[int]float64, len(edges))
+		for j, cost := range edges {
+			rmCopy.edges[i][j] = cost
+		}
+	}
+	return rmCopy
+}
+
+// addNode adds a configuration to the roadmap, or finds one already in it which is the same, returning its index. It returns -1 if
+// the roadmap is full.
+func (rm *roadmap) addNode(q []referenceframe.Input) int {
+	for i, existing := range rm.nodes {
+		if referenceframe.InputsL2Distance(existing, q) < defaultJointSolveDist {
+			return i
+		}
+	}
+	if len(rm.nodes) >= defaultRoadmapSize {
+		return -1
+	}
+	rm.nodes = append(rm.nodes, q)
+	rm.edges = append(rm.edges, map[int]float64{})
+	return len(rm.nodes) - 1
+}
+
+func (rm *roadmap) addEdge(i, j int) {
+	if i == j {
+		return
+	}
+	cost := referenceframe.InputsL2Distance(rm.nodes[i], rm.nodes[j])
+	rm.edges[i][j] = cost
+	rm.edges[j][i] = cost
+}
+
+// addPath adds the configurations of a plan to the roadmap, connected in order.
+func (rm *roadmap) addPath(path [][]referenceframe.Input) {
+	last := -1
+	for _, q := range path {
+		current := rm.addNode(q)
+		if current >= 0 && last >= 0 {
+			rm.addEdge(last, current)
+		}
+		last = current
+	}
+}
+
+// nearest returns the indices of the nodes closest to a configuration, up to n of them, excluding any in skip.
+func (rm *roadmap) nearest(q []referenceframe.Input, n int, skip map[int]bool) []int {
+	type candidate struct {
+		idx  int
+		dist float64
+	}
+	closest := []candidate{}
+	for i, node := range rm.nodes {
+		if skip[i] {
+			continue
+		}
+		dist := referenceframe.InputsL2Distance(node, q)
+		insertAt := len(closest)
+		for insertAt > 0 && closest[insertAt-1].dist > dist {
+			insertAt--
+		}
+		if insertAt < n {
+			closest = append(closest[:insertAt], append([]candidate{{i, dist}}, closest[insertAt:]...)...)
+			if len(closest) > n {
+				closest = closest[:n]
+			}
+		}
+	}
+	indices := make([]int, 0, len(closest))
+	for _, c := range closest {
+		indices = append(indices, c.idx)
+	}
+	return indices
+}
+
+// search connects the start and goals to the roadmap, and looks for the shortest path between them whose edges all pass the
+// constraints of the planner.
+func (rm *roadmap) search(ctx context.Context, mp motionPlanner, start []referenceframe.Input, goals []node) []node {
+	if len(rm.nodes) == 0 {
+		return nil
+	}
+	roadmapSize := len(rm.nodes)
+	startIdx := len(rm.nodes)
+	rm.nodes = append(rm.nodes, start)
+	rm.edges = append(rm.edges, map[int]float64{})
+	for _, neighbor := range rm.nearest(start, defaultRoadmapNeighbors, nil) {
+		rm.addEdge(startIdx, neighbor)
+	}
+	isGoal := map[int]bool{}
+	for _, goal := range goals {
+		goalIdx := len(rm.nodes)
+		rm.nodes = append(rm.nodes, goal.Q())
+		rm.edges = append(rm.edges, map[int]float64{})
+		isGoal[goalIdx] = true
+		for _, neighbor := range rm.nearest(goal.Q(), defaultRoadmapNeighbors, nil) {
+			if neighbor < roadmapSize {
+				rm.addEdge(goalIdx, neighbor)
+			}
+		}
+	}
+
+	// edges which have been found to pass constraints
+	checked := map[[2]int]bool{}
+	for i := 0; i < defaultRoadmapSearches; i++ {
+		select {
+		case <-ctx.Done():
+			return nil
+		default:
+		}
+		path := rm.shortestPath(startIdx, isGoal)
+		if path == nil {
+			return nil
+		}
+		valid := true
+		for j := 0; j < len(path)-1; j++ {
+			edge := [2]int{path[j], path[j+1]}
+			if checked[edge] {
+				continue
+			}
+			if !mp.checkPath(rm.nodes[path[j]], rm.nodes[path[j+1]]) {
+				delete(rm.edges[path[j]], path[j+1])
+				delete(rm.edges[path[j+1]], path[j])
+				valid = false
+				break
+			}
+			checked[edge] = true
+		}
+		if valid {
+			nodes := make([]node, 0, len(path))
+			for _, idx := range path {
+				nodes = append(nodes, newConfigurationNode(rm.nodes[idx]))
+			}
+			return nodes
+		}
+	}
+	return nil
+}
+
+// shortestPath uses Dijkstra's algorithm to find the cheapest path from the start to any of the goals, returning the indices of
+// the nodes along it, or nil if the goals cannot be reached.
+func (rm *roadmap) shortestPath(start int, isGoal map[int]bool) []int {
+	dist := make([]float64, len(rm.nodes))
+	prev := make([]int, len(rm.nodes))
+	done := make([]bool, len(rm.nodes))
+	for i := range dist {
+		dist[i] = math.Inf(1)
+		prev[i] = -1
+	}
+	dist[start] = 0
+	for {
+		current := -1
+		for i, d := range dist {
+			if !done[i] && !math.IsInf(d, 1) && (current < 0 || d < dist[current]) {
+				current = i
+			}
+		}
+		if current < 0 {
+			return nil
+		}
+		if isGoal[current] {
+			path := []int{}
+			for idx := current; idx >= 0; idx = prev[idx] {
+				path = append([]int{idx}, path...)
+			}
+			return path
+		}
+		done[current] = true
+		for next, cost := range rm.edges[current] {
+			if dist[current]+cost < dist[next] {
+				dist[next] = dist[current] + cost
+				prev[next] = current
+			}
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package motionplan
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/geo/r3"
+	"go.viam.com/test"
+
+	frame "go.viam.com/rdk/referenceframe"
+	"go.viam.com/rdk/spatialmath"
+	"go.viam.com/rdk/utils"
+)
+
+func TestRoadmapShortestPath(t *testing.T) {
+	rm := newRoadmap(2)
+	// two routes from (0, 0) to (2, 2), one much longer than the other
+	rm.addPath([][]frame.Input{
+		frame.FloatsToInputs([]float64{0, 0}),
+		frame.FloatsToInputs([]float64{1, 1}),
+		frame.FloatsToInputs([]float64{2, 2}),
+	})
+	rm.addPath([][]frame.Input{
+		frame.FloatsToInputs([]float64{0, 0}),
+		frame.FloatsToInputs([]float64{5, 0}),
+		frame.FloatsToInputs([]float64{2, 2}),
+	})
+	// configurations already in the roadmap are not added again
+	test.That(t, rm.nodes, test.ShouldHaveLength, 4)
+
+	path := rm.shortestPath(0, map[int]bool{2: true})
+	test.That(t, path, test.ShouldResemble, []int{0, 1, 2})
+
+	// once the shorter route is removed the longer one is found
+	delete(rm.edges[0], 1)
+	delete(rm.edges[1], 0)
+	path = rm.shortestPath(0, map[int]bool{2: true})
+	test.That(t, path, test.ShouldResemble, []int{0, 3, 2})
+
+	test.That(t, rm.shortestPath(1, map[int]bool{0: true}), test.ShouldResemble, []int{1, 2, 3, 0})
+	test.That(t, rm.nearest(frame.FloatsToInputs([]float64{4, 0}), 2, nil), test.ShouldResemble, []int{3, 2})
+}
+
+func TestPlanCache(t *testing.T) {
+	m, err := frame.ParseModelJSONFile(utils.ResolveFile("components/arm/universalrobots/ur5e.json"), "")
+	test.That(t, err, test.ShouldBeNil)
+	fs := frame.NewEmptyFrameSystem("test")
+	test.That(t, fs.AddFrame(m, fs.World()), test.ShouldBeNil)
+	start, err := m.Transform(ur5eStart)
+	test.That(t, err, test.ShouldBeNil)
+	goal := frame.NewPoseInFrame(frame.World, spatialmath.NewPose(start.Point().Add(r3.Vector{X: 50, Z: 20}), start.Orientation()))
+	box, err := spatialmath.NewBox(spatialmath.NewPoseFromPoint(r3.Vector{X: -800}), r3.Vector{X: 100, Y: 100, Z: 100}, "box")
+	test.That(t, err, test.ShouldBeNil)
+	obstacles := []*frame.GeometriesInFrame{frame.NewGeometriesInFrame(frame.World, []spatialmath.Geometry{box})}
+	worldState, err := frame.NewWorldState(obstacles, nil)
+	test.That(t, err, test.ShouldBeNil)
+
+	cache := NewPlanCache()
+	plan, err := cache.PlanMotion(context.Background(), logger.Sugar(), goal, m, map[string][]frame.Input{m.Name(): ur5eStart}, fs,
+		worldState, nil, nil)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, cache.plans, test.ShouldHaveLength, 1)
+	test.That(t, cache.roadmaps, test.ShouldHaveLength, 1)
+
+	// starting close to the cached plan reuses it, from the new start
+	nearStart := frame.FloatsToInputs([]float64{0.001, -1.2, 1.4, -1.8, -1.57, 0})
+	reused, err := cache.PlanMotion(context.Background(), logger.Sugar(), goal, m, map[string][]frame.Input{m.Name(): nearStart}, fs,
+		worldState, nil, nil)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, cache.plans, test.ShouldHaveLength, 1)
+	test.That(t, reused[0][m.Name()], test.ShouldResemble, nearStart)
+	test.That(t, reused[len(reused)-1][m.Name()], test.ShouldResemble, plan[len(plan)-1][m.Name()])
+
+	// a different world is planned for again
+	moved, err := spatialmath.NewBox(spatialmath.NewPoseFromPoint(r3.Vector{X: 800}), r3.Vector{X: 100, Y: 100, Z: 100}, "box")
+	test.That(t, err, test.ShouldBeNil)
+	movedObstacles := []*frame.GeometriesInFrame{frame.NewGeometriesInFrame(frame.World, []spatialmath.Geometry{moved})}
+	movedState, err := frame.NewWorldState(movedObstacles, nil)
+	test.That(t, err, test.ShouldBeNil)
+	_, err = cache.PlanMotion(context.Background(), logger.Sugar(), goal, m, map[string][]frame.Input{m.Name(): ur5eStart}, fs,
+		movedState, nil, nil)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, cache.plans, test.ShouldHaveLength, 2)
+
+	cache.Clear()
+	test.That(t, cache.plans, test.ShouldBeEmpty)
+	test.That(t, cache.roadmaps, test.ShouldBeEmpty)
+}
+
+func TestPlanCacheRejectsBlockedPlan(t *testing.T) {
+	m, err := frame.ParseModelJSONFile(utils.ResolveFile("components/arm/universalrobots/ur5e.json"), "")
+	test.That(t, err, test.ShouldBeNil)
+	fs := frame.NewEmptyFrameSystem("test")
+	test.That(t, fs.AddFrame(m, fs.World()), test.ShouldBeNil)
+	sf, err := newSolverFrame(fs, m.Name(), frame.World, map[string][]frame.Input{m.Name(): ur5eStart})
+	test.That(t, err, test.ShouldBeNil)
+	start, err := m.Transform(ur5eStart)
+	test.That(t, err, test.ShouldBeNil)
+	end := frame.FloatsToInputs([]float64{3, -1.2, 1.4, -1.8, -1.57, 0})
+	endPose, err := m.Transform(end)
+	test.That(t, err, test.ShouldBeNil)
+
+	// a box placed where the plan ends blocks it, even though the request matches the one the plan was made for
+	box, err := spatialmath.NewBox(spatialmath.NewPoseFromPoint(endPose.Point()), r3.Vector{X: 100, Y: 100, Z: 100}, "box")
+	test.That(t, err, test.ShouldBeNil)
+	obstacles := []*frame.GeometriesInFrame{frame.NewGeometriesInFrame(frame.World, []spatialmath.Geometry{box})}
+	worldState, err := frame.NewWorldState(obstacles, nil)
+	test.That(t, err, test.ShouldBeNil)
+	seedMap := map[string][]frame.Input{m.Name(): ur5eStart}
+	req, err := newPlanCacheRequest(sf, fs, seedMap, ur5eStart, endPose, worldState, nil, nil)
+	test.That(t, err, test.ShouldBeNil)
+
+	cache := NewPlanCache()
+	cache.add(req, [][]frame.Input{ur5eStart, end})
+	test.That(t, req.matches(cache.plans[0]), test.ShouldBeTrue)
+
+	pm, err := newPlanManager(sf, fs, logger.Sugar(), 1)
+	test.That(t, err, test.ShouldBeNil)
+	opt, err := pm.plannerSetupFromMoveRequest(start, endPose, seedMap, worldState, nil, nil)
+	test.That(t, err, test.ShouldBeNil)
+	mp, err := opt.PlannerConstructor(sf, pm.randseed, logger.Sugar(), opt)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, cache.reusePlan(context.Background(), req, mp), test.ShouldBeNil)
+}
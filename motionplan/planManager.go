@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/edaniels/golog"
+	"github.com/golang/geo/r3"
 	pb "go.viam.com/api/service/motion/v1"
 	"go.viam.com/utils"
 
@@ -36,6 +37,8 @@ type planManager struct {
 	activeBackgroundWorkers sync.WaitGroup
 
 	useTPspace bool
+	// if set, plans are looked for in and added to this cache.
+	cache *PlanCache
 }
 
 func newPlanManager(
@@ -86,6 +89,7 @@ func (pm *planManager) PlanSingleWaypoint(ctx context.Context,
 	if err != nil {
 		return nil, err
 	}
+	motionConfig = pm.motionConfigForConstraints(constraintSpec, motionConfig)
 
 	var cancel func()
 
@@ -109,6 +113,7 @@ func (pm *planManager) PlanSingleWaypoint(ctx context.Context,
 	var goals []spatialmath.Pose
 	var opts []*plannerOptions
 
+	startPos := seedPos
 	subWaypoints := false
 
 	// linear motion profile has known intermediate points, so solving can be broken up and sped up
@@ -120,6 +125,11 @@ func (pm *planManager) PlanSingleWaypoint(ctx context.Context,
 		subWaypoints = true
 	}
 
+	// the cartesian planner follows the whole path itself
+	if profile, ok := motionConfig["motion_profile"]; ok && profile == CartesianMotionProfile {
+		subWaypoints = false
+	}
+
 	if subWaypoints {
 		pathStepSize, ok := motionConfig["path_step_size"].(float64)
 		if !ok {
@@ -166,6 +176,33 @@ func (pm *planManager) PlanSingleWaypoint(ctx context.Context,
 		planners = append(planners, pathPlanner)
 	}
 
+	var cacheReq *planCacheRequest
+	if pm.usePlanCache(motionConfig) {
+		cacheReq, err = newPlanCacheRequest(pm.frame, pm.fs, seedMap, seed, goalPos, worldState, constraintSpec, motionConfig)
+		if err != nil {
+			return nil, err
+		}
+		// cached plans are checked against the constraints of the whole motion
+		cacheOpt, err := pm.plannerSetupFromMoveRequest(startPos, goalPos, seedMap, worldState, constraintSpec, motionConfig)
+		if err != nil {
+			return nil, err
+		}
+		//nolint: gosec
+		cachePlanner, err := cacheOpt.PlannerConstructor(
+			pm.frame,
+			rand.New(rand.NewSource(int64(pm.randseed.Int()))),
+			pm.logger,
+			cacheOpt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if plan := pm.cache.reusePlan(ctx, cacheReq, cachePlanner); plan != nil {
+			pm.logger.Debug("reusing cached plan")
+			return plan, nil
+		}
+	}
+
 	// If we have multiple sub-waypoints, make sure the final goal is not unreachable.
 	if len(goals) > 1 {
 		// Viability check; ensure that the waypoint is not impossible to reach
@@ -175,7 +212,12 @@ func (pm *planManager) PlanSingleWaypoint(ctx context.Context,
 		}
 	}
 
-	resultSlices, err := pm.planAtomicWaypoints(ctx, goals, seed, planners)
+	var resultSlices [][]referenceframe.Input
+	if cacheReq != nil && len(goals) == 1 {
+		resultSlices, err = pm.planFromRoadmap(ctx, cacheReq, goals[0], seed, planners[0])
+	} else {
+		resultSlices, err = pm.planAtomicWaypoints(ctx, goals, seed, planners)
+	}
 	pm.activeBackgroundWorkers.Wait()
 	if err != nil {
 		if len(goals) > 1 {
@@ -183,9 +225,134 @@ func (pm *planManager) PlanSingleWaypoint(ctx context.Context,
 		}
 		return nil, err
 	}
+	if cacheReq != nil {
+		pm.cache.add(cacheReq, resultSlices)
+	}
 	return resultSlices, nil
 }
 
+// PlanMultiWaypoint will solve a solver frame moving several components so that each of them reaches its own goal pose, given in the
+// order of its components.
+func (pm *planManager) PlanMultiWaypoint(ctx context.Context,
+	seedMap map[string][]referenceframe.Input,
+	goals []spatialmath.Pose,
+	worldState *referenceframe.WorldState,
+	constraintSpec *pb.Constraints,
+	motionConfig map[string]interface{},
+) ([][]referenceframe.Input, error) {
+	seed, err := pm.frame.mapToSlice(seedMap)
+	if err != nil {
+		return nil, err
+	}
+
+	// set timeout for entire planning process if specified
+	if timeout, ok := motionConfig["timeout"].(float64); ok {
+		var cancel func()
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(timeout*float64(time.Second)))
+		defer cancel()
+	}
+
+	opt, err := pm.plannerSetupFromMultiMoveRequest(goals, seedMap, worldState, constraintSpec, motionConfig)
+	if err != nil {
+		return nil, err
+	}
+	//nolint: gosec
+	pathPlanner, err := opt.PlannerConstructor(
+		pm.frame,
+		rand.New(rand.NewSource(int64(pm.randseed.Int()))),
+		pm.logger,
+		opt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// the goal pose of the first component is only passed along; the goal metric of the options converges on all of them
+	resultSlices, err := pm.planAtomicWaypoints(ctx, goals[:1], seed, []motionPlanner{pathPlanner})
+	pm.activeBackgroundWorkers.Wait()
+	if err != nil {
+		return nil, err
+	}
+	return resultSlices, nil
+}
+
+// motionConfigForConstraints returns the motion config to plan a request with. The cartesian planner is only used when the cartesian
+// motion profile is selected, in which case the tightest tolerances of the linear constraints of the request become its tolerances,
+// unless they are given in the motion config. Linear constraints otherwise keep their usual meaning.
+func (pm *planManager) motionConfigForConstraints(constraints *pb.Constraints, motionConfig map[string]interface{}) map[string]interface{} {
+	linearConstraints := constraints.GetLinearConstraint()
+	if profile, ok := motionConfig["motion_profile"]; !ok || profile != CartesianMotionProfile || len(linearConstraints) == 0 {
+		return motionConfig
+	}
+	lineTol, orientTol := math.Inf(1), math.Inf(1)
+	for _, linearConstraint := range linearConstraints {
+		if tol := float64(linearConstraint.GetLineToleranceMm()); tol > 0 {
+			lineTol = math.Min(lineTol, tol)
+		}
+		if tol := float64(linearConstraint.GetOrientationToleranceDegs()); tol > 0 {
+			orientTol = math.Min(orientTol, tol)
+		}
+	}
+	config := make(map[string]interface{}, len(motionConfig)+2)
+	for key, value := range motionConfig {
+		config[key] = value
+	}
+	if _, ok := config["line_tolerance"]; !ok && !math.IsInf(lineTol, 1) {
+		config["line_tolerance"] = lineTol
+	}
+	if _, ok := config["orient_tolerance"]; !ok && !math.IsInf(orientTol, 1) {
+		config["orient_tolerance"] = orientTol
+	}
+	return config
+}
+
+// usePlanCache returns whether plans for a request should be looked for in and added to the plan cache.
+func (pm *planManager) usePlanCache(motionConfig map[string]interface{}) bool {
+	if pm.cache == nil || pm.useTPspace {
+		return false
+	}
+	// cartesian plans follow their path exactly, so cannot be reused from even a slightly different start
+	profile, ok := motionConfig["motion_profile"]
+	return !ok || profile != CartesianMotionProfile
+}
+
+// planFromRoadmap plans a single atomic waypoint, first searching the roadmap of the plan cache for a path to any of the IK
+// solutions for the goal. If there is none, the same solutions are used to seed the planner.
+func (pm *planManager) planFromRoadmap(
+	ctx context.Context,
+	req *planCacheRequest,
+	goal spatialmath.Pose,
+	seed []referenceframe.Input,
+	pathPlanner motionPlanner,
+) ([][]referenceframe.Input, error) {
+	parPlan, ok := pathPlanner.(rrtParallelPlanner)
+	if !ok {
+		return pm.planAtomicWaypoints(ctx, []spatialmath.Pose{goal}, seed, []motionPlanner{pathPlanner})
+	}
+	planSeed := initRRTSolutions(ctx, parPlan, seed)
+	if planSeed.planerr != nil {
+		return nil, planSeed.planerr
+	}
+	if planSeed.steps != nil {
+		return nodesToInputs(planSeed.steps), nil
+	}
+
+	goalNodes := make([]node, 0, len(planSeed.maps.goalMap))
+	for goalNode := range planSeed.maps.goalMap {
+		goalNodes = append(goalNodes, goalNode)
+	}
+	if path := pm.cache.searchRoadmap(ctx, req, parPlan, goalNodes); path != nil {
+		pm.logger.Debug("found path through roadmap")
+		return nodesToInputs(parPlan.smoothPath(ctx, path)), nil
+	}
+
+	_, future, err := pm.planSingleAtomicWaypoint(ctx, goal, seed, parPlan, planSeed.maps)
+	if err != nil {
+		return nil, err
+	}
+	return future.result(ctx)
+}
+
 // planAtomicWaypoints will plan a single motion, which may be composed of one or more waypoints. Waypoints are here used to begin planning
 // the next motion as soon as its starting point is known. This is responsible for repeatedly calling planSingleAtomicWaypoint for each
 // intermediate waypoint. Waypoints here refer to points that the software has generated to.
@@ -448,6 +615,7 @@ func (pm *planManager) plannerSetupFromMoveRequest(
 	// Start with normal options
 	opt := newBasicPlannerOptions(pm.frame)
 	opt.SetGoalMetric(ik.NewSquaredNormMetric(to))
+	opt.goal = to
 
 	opt.extra = planningOpts
 
@@ -491,6 +659,15 @@ func (pm *planManager) plannerSetupFromMoveRequest(
 		return nil, err
 	}
 
+	if motionProfile == CartesianMotionProfile {
+		if _, ok := planningOpts["planning_alg"]; ok {
+			return nil, errors.New("cannot specify a planning_alg with the cartesian motion profile")
+		}
+		if pm.useTPspace {
+			return nil, errors.New("cannot use the cartesian motion profile when planning for a TP-space frame")
+		}
+	}
+
 	alg, ok := planningOpts["planning_alg"]
 	if ok {
 		planAlg, ok = alg.(string)
@@ -558,6 +735,17 @@ func (pm *planManager) plannerSetupFromMoveRequest(
 		opt.pathMetric = pathMetric
 	case PositionOnlyMotionProfile:
 		opt.SetGoalMetric(ik.NewPositionOnlyMetric(to))
+		// Any orientation at the goal position will do, so there is no single pose to solve for.
+		opt.goal = nil
+	case CartesianMotionProfile:
+		opt.PlannerConstructor = newCartesianMotionPlanner
+		opt.arcCenter, err = pm.arcCenterFromMoveRequest(seedMap, planningOpts)
+		if err != nil {
+			return nil, err
+		}
+		if opt.arcCenter != nil && len(constraints.GetLinearConstraint()) > 0 {
+			return nil, errors.New("cannot follow an arc with a linear constraint")
+		}
 	case FreeMotionProfile:
 		// No restrictions on motion
 		fallthrough
@@ -582,6 +770,72 @@ func (pm *planManager) plannerSetupFromMoveRequest(
 	return opt, nil
 }
 
+// plannerSetupFromMultiMoveRequest sets up the planner options for a solver frame moving several components to their goals. Options
+// which only apply to a single moving frame, such as motion profiles other than the free one, cannot be used.
+func (pm *planManager) plannerSetupFromMultiMoveRequest(
+	goals []spatialmath.Pose,
+	seedMap map[string][]referenceframe.Input,
+	worldState *referenceframe.WorldState,
+	constraints *pb.Constraints,
+	planningOpts map[string]interface{},
+) (*plannerOptions, error) {
+	if profile, ok := planningOpts["motion_profile"]; ok && profile != FreeMotionProfile {
+		return nil, fmt.Errorf("only the %s motion profile can be used when moving several components, got %v", FreeMotionProfile, profile)
+	}
+	if len(constraints.GetLinearConstraint()) > 0 || len(constraints.GetOrientationConstraint()) > 0 {
+		return nil, errors.New("linear and orientation constraints cannot be used when moving several components")
+	}
+	if len(goals) != len(pm.frame.components) {
+		return nil, fmt.Errorf("got %d goals for %d components", len(goals), len(pm.frame.components))
+	}
+
+	// With no topological constraints or motion profile, the options only depend on the goal through the goal metric, which is
+	// replaced by one for the goals of every component.
+	opt, err := pm.plannerSetupFromMoveRequest(goals[0], goals[0], seedMap, worldState, constraints, planningOpts)
+	if err != nil {
+		return nil, err
+	}
+	goalMetric := newMultiGoalMetric(pm.frame, goals)
+	for o := opt; o != nil; o = o.Fallback {
+		o.SetGoalMetric(goalMetric)
+		o.goal = nil
+		o.componentGoals = goals
+	}
+	return opt, nil
+}
+
+// arcCenterFromMoveRequest returns the center of the arc given by the arc_center planning option, which is in the frame of the
+// goal, translated into the frame which is planned in. It returns nil if no arc is given.
+func (pm *planManager) arcCenterFromMoveRequest(
+	seedMap map[string][]referenceframe.Input,
+	planningOpts map[string]interface{},
+) (*r3.Vector, error) {
+	arcCenter, ok := planningOpts["arc_center"]
+	if !ok {
+		return nil, nil
+	}
+	jsonString, err := json.Marshal(arcCenter)
+	if err != nil {
+		return nil, err
+	}
+	var center r3.Vector
+	if err := json.Unmarshal(jsonString, &center); err != nil {
+		return nil, errors.New("could not interpret arc_center field as a point with x, y and z")
+	}
+	if pm.frame.worldRooted {
+		tf, err := pm.frame.fss.Transform(
+			seedMap,
+			referenceframe.NewPoseInFrame(pm.frame.goalFrame.Name(), spatialmath.NewPoseFromPoint(center)),
+			referenceframe.World,
+		)
+		if err != nil {
+			return nil, err
+		}
+		center = tf.(*referenceframe.PoseInFrame).Pose().Point()
+	}
+	return &center, nil
+}
+
 // check whether the solution is within some amount of the optimal.
 func goodPlan(pr *rrtPlanReturn, opt *plannerOptions) (bool, float64) {
 	solutionCost := math.Inf(1)
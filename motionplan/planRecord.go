@@ -0,0 +1,244 @@
+package motionplan
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+
+	"github.com/edaniels/golog"
+	"github.com/pkg/errors"
+	commonpb "go.viam.com/api/common/v1"
+	robotpb "go.viam.com/api/robot/v1"
+	pb "go.viam.com/api/service/motion/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	frame "go.viam.com/rdk/referenceframe"
+)
+
+// planRecordFrameSystemName is the name given to frame systems rebuilt from a PlanRecord.
+const planRecordFrameSystemName = "plan_record"
+
+// PlanRecord holds everything that went into a call to PlanMotion, and what came out of it, so that the plan can be saved,
+// inspected and replanned away from the robot it was made for.
+type PlanRecord struct {
+	// The parts the frame system is built from, not including the transforms in the world state.
+	FrameSystemParts []*frame.FrameSystemPart
+	// The name of the frame which was moved.
+	Frame       string
+	Goal        *frame.PoseInFrame
+	StartState  map[string][]frame.Input
+	WorldState  *frame.WorldState
+	Constraints *pb.Constraints
+	Extra       map[string]interface{}
+	// The resulting steps, which are empty if planning failed.
+	Plan []map[string][]frame.Input
+	// The error planning failed with, if it did.
+	Error string
+}
+
+// planRecordJSON is the serialized form of a PlanRecord. Protobuf types are written with protojson, and inputs are written as
+// plain numbers.
+type planRecordJSON struct {
+	FrameSystemParts []json.RawMessage      `json:"frame_system_parts"`
+	Frame            string                 `json:"frame"`
+	Goal             json.RawMessage        `json:"goal"`
+	StartState       map[string][]float64   `json:"start_state"`
+	WorldState       json.RawMessage        `json:"world_state,omitempty"`
+	Constraints      json.RawMessage        `json:"constraints,omitempty"`
+	Extra            map[string]interface{} `json:"extra,omitempty"`
+	Plan             []map[string][]float64 `json:"plan"`
+	Error            string                 `json:"error,omitempty"`
+}
+
+// NewPlanRecord creates a record of the request to move a frame, before it is planned.
+func NewPlanRecord(
+	parts []*frame.FrameSystemPart,
+	frameName string,
+	goal *frame.PoseInFrame,
+	startState map[string][]frame.Input,
+	worldState *frame.WorldState,
+	constraints *pb.Constraints,
+	extra map[string]interface{},
+) *PlanRecord {
+	return &PlanRecord{
+		FrameSystemParts: parts,
+		Frame:            frameName,
+		Goal:             goal,
+		StartState:       startState,
+		WorldState:       worldState,
+		Constraints:      constraints,
+		Extra:            extra,
+	}
+}
+
+// SetResult records the outcome of planning.
+func (pr *PlanRecord) SetResult(plan []map[string][]frame.Input, err error) {
+	pr.Plan = plan
+	pr.Error = ""
+	if err != nil {
+		pr.Error = err.Error()
+	}
+}
+
+// FrameSystem rebuilds the frame system the plan was made in, including the transforms in the world state.
+func (pr *PlanRecord) FrameSystem() (frame.FrameSystem, error) {
+	return frame.NewFrameSystem(planRecordFrameSystemName, pr.FrameSystemParts, pr.WorldState.Transforms())
+}
+
+// Replan plans the recorded request again, returning the new plan without changing the record.
+func (pr *PlanRecord) Replan(ctx context.Context, logger golog.Logger) ([]map[string][]frame.Input, error) {
+	fs, err := pr.FrameSystem()
+	if err != nil {
+		return nil, err
+	}
+	f := fs.Frame(pr.Frame)
+	if f == nil {
+		return nil, frame.NewFrameMissingError(pr.Frame)
+	}
+	return PlanMotion(ctx, logger, pr.Goal, f, pr.StartState, fs, pr.WorldState, pr.Constraints, pr.Extra)
+}
+
+// MarshalJSON serializes a PlanRecord.
+func (pr *PlanRecord) MarshalJSON() ([]byte, error) {
+	prJSON := planRecordJSON{
+		Frame:      pr.Frame,
+		StartState: inputMapToFloats(pr.StartState),
+		Extra:      pr.Extra,
+		Plan:       make([]map[string][]float64, 0, len(pr.Plan)),
+		Error:      pr.Error,
+	}
+	for _, part := range pr.FrameSystemParts {
+		partPb, err := part.ToProtobuf()
+		if err != nil {
+			return nil, err
+		}
+		partJSON, err := protojson.Marshal(partPb)
+		if err != nil {
+			return nil, err
+		}
+		prJSON.FrameSystemParts = append(prJSON.FrameSystemParts, partJSON)
+	}
+	if pr.Goal != nil {
+		goalJSON, err := protojson.Marshal(frame.PoseInFrameToProtobuf(pr.Goal))
+		if err != nil {
+			return nil, err
+		}
+		prJSON.Goal = goalJSON
+	}
+	if pr.WorldState != nil {
+		worldStatePb, err := pr.WorldState.ToProtobuf()
+		if err != nil {
+			return nil, err
+		}
+		worldStateJSON, err := protojson.Marshal(worldStatePb)
+		if err != nil {
+			return nil, err
+		}
+		prJSON.WorldState = worldStateJSON
+	}
+	if pr.Constraints != nil {
+		constraintsJSON, err := protojson.Marshal(pr.Constraints)
+		if err != nil {
+			return nil, err
+		}
+		prJSON.Constraints = constraintsJSON
+	}
+	for _, step := range pr.Plan {
+		prJSON.Plan = append(prJSON.Plan, inputMapToFloats(step))
+	}
+	return json.Marshal(prJSON)
+}
+
+// UnmarshalJSON deserializes a PlanRecord.
+func (pr *PlanRecord) UnmarshalJSON(data []byte) error {
+	var prJSON planRecordJSON
+	if err := json.Unmarshal(data, &prJSON); err != nil {
+		return err
+	}
+	record := PlanRecord{
+		Frame:      prJSON.Frame,
+		StartState: floatsToInputMap(prJSON.StartState),
+		Extra:      prJSON.Extra,
+		Plan:       make([]map[string][]frame.Input, 0, len(prJSON.Plan)),
+		Error:      prJSON.Error,
+	}
+	for _, partJSON := range prJSON.FrameSystemParts {
+		partPb := &robotpb.FrameSystemConfig{}
+		if err := protojson.Unmarshal(partJSON, partPb); err != nil {
+			return err
+		}
+		part, err := frame.ProtobufToFrameSystemPart(partPb)
+		if err != nil {
+			return err
+		}
+		record.FrameSystemParts = append(record.FrameSystemParts, part)
+	}
+	if len(prJSON.Goal) > 0 {
+		goalPb := &commonpb.PoseInFrame{}
+		if err := protojson.Unmarshal(prJSON.Goal, goalPb); err != nil {
+			return err
+		}
+		record.Goal = frame.ProtobufToPoseInFrame(goalPb)
+	}
+	if len(prJSON.WorldState) > 0 {
+		worldStatePb := &commonpb.WorldState{}
+		if err := protojson.Unmarshal(prJSON.WorldState, worldStatePb); err != nil {
+			return err
+		}
+		worldState, err := frame.WorldStateFromProtobuf(worldStatePb)
+		if err != nil {
+			return err
+		}
+		record.WorldState = worldState
+	}
+	if len(prJSON.Constraints) > 0 {
+		record.Constraints = &pb.Constraints{}
+		if err := protojson.Unmarshal(prJSON.Constraints, record.Constraints); err != nil {
+			return err
+		}
+	}
+	for _, step := range prJSON.Plan {
+		record.Plan = append(record.Plan, floatsToInputMap(step))
+	}
+	*pr = record
+	return nil
+}
+
+// WriteJSONFile writes the record to the given file as JSON.
+func (pr *PlanRecord) WriteJSONFile(filename string) error {
+	data, err := json.MarshalIndent(pr, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, data, 0o600)
+}
+
+// ReadPlanRecordJSONFile reads a record written by WriteJSONFile.
+func ReadPlanRecordJSONFile(filename string) (*PlanRecord, error) {
+	//nolint:gosec
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read plan record")
+	}
+	pr := &PlanRecord{}
+	if err := json.Unmarshal(data, pr); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal plan record")
+	}
+	return pr, nil
+}
+
+func inputMapToFloats(inputMap map[string][]frame.Input) map[string][]float64 {
+	floatMap := make(map[string][]float64, len(inputMap))
+	for name, inputs := range inputMap {
+		floatMap[name] = frame.InputsToFloats(inputs)
+	}
+	return floatMap
+}
+
+func floatsToInputMap(floatMap map[string][]float64) map[string][]frame.Input {
+	inputMap := make(map[string][]frame.Input, len(floatMap))
+	for name, floats := range floatMap {
+		inputMap[name] = frame.FloatsToInputs(floats)
+	}
+	return inputMap
+}
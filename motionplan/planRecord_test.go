@@ -0,0 +1,101 @@
+package motionplan
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/geo/r3"
+	"go.viam.com/test"
+
+	frame "go.viam.com/rdk/referenceframe"
+	"go.viam.com/rdk/spatialmath"
+	"go.viam.com/rdk/utils"
+)
+
+// cartesianPlanRecord records a cartesian plan for a ur5e in a world with an obstacle, which is planned the same way every time.
+func cartesianPlanRecord(t *testing.T) *PlanRecord {
+	t.Helper()
+	m, err := frame.ParseModelJSONFile(utils.ResolveFile("components/arm/universalrobots/ur5e.json"), "")
+	test.That(t, err, test.ShouldBeNil)
+	parts := []*frame.FrameSystemPart{{
+		FrameConfig: frame.NewLinkInFrame(frame.World, spatialmath.NewZeroPose(), m.Name(), nil),
+		ModelFrame:  m,
+	}}
+	box, err := spatialmath.NewBox(spatialmath.NewPoseFromPoint(r3.Vector{X: -800}), r3.Vector{X: 100, Y: 100, Z: 100}, "box")
+	test.That(t, err, test.ShouldBeNil)
+	obstacles := []*frame.GeometriesInFrame{frame.NewGeometriesInFrame(frame.World, []spatialmath.Geometry{box})}
+	worldState, err := frame.NewWorldState(obstacles, nil)
+	test.That(t, err, test.ShouldBeNil)
+	start, err := m.Transform(ur5eStart)
+	test.That(t, err, test.ShouldBeNil)
+	goal := frame.NewPoseInFrame(frame.World, spatialmath.NewPose(start.Point().Add(r3.Vector{X: 50, Z: 20}), start.Orientation()))
+
+	planRecord := NewPlanRecord(parts, m.Name(), goal, map[string][]frame.Input{m.Name(): ur5eStart}, worldState, nil,
+		map[string]interface{}{"motion_profile": CartesianMotionProfile})
+	plan, err := planRecord.Replan(context.Background(), logger.Sugar())
+	test.That(t, err, test.ShouldBeNil)
+	planRecord.SetResult(plan, nil)
+	return planRecord
+}
+
+func TestPlanRecordRoundTrip(t *testing.T) {
+	planRecord := cartesianPlanRecord(t)
+	filename := filepath.Join(t.TempDir(), "plan.json")
+	test.That(t, planRecord.WriteJSONFile(filename), test.ShouldBeNil)
+	read, err := ReadPlanRecordJSONFile(filename)
+	test.That(t, err, test.ShouldBeNil)
+
+	test.That(t, read.Frame, test.ShouldEqual, planRecord.Frame)
+	test.That(t, read.FrameSystemParts, test.ShouldHaveLength, 1)
+	test.That(t, read.FrameSystemParts[0].FrameConfig.Name(), test.ShouldEqual, planRecord.Frame)
+	test.That(t, read.FrameSystemParts[0].ModelFrame.DoF(), test.ShouldResemble, planRecord.FrameSystemParts[0].ModelFrame.DoF())
+	test.That(t, spatialmath.PoseAlmostEqual(read.Goal.Pose(), planRecord.Goal.Pose()), test.ShouldBeTrue)
+	test.That(t, read.WorldState.ObstacleNames(), test.ShouldResemble, map[string]bool{"box": true})
+	test.That(t, read.StartState, test.ShouldResemble, planRecord.StartState)
+	test.That(t, read.Plan, test.ShouldResemble, planRecord.Plan)
+	test.That(t, read.Error, test.ShouldBeEmpty)
+
+	// the read record makes the same plan again, up to rounding in the model read back
+	plan, err := read.Replan(context.Background(), logger.Sugar())
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, plan, test.ShouldHaveLength, len(planRecord.Plan))
+	for i, step := range plan {
+		test.That(t, frame.InputsL2Distance(step[read.Frame], planRecord.Plan[i][read.Frame]), test.ShouldAlmostEqual, 0)
+	}
+
+	// failures are recorded too
+	read.Goal = frame.NewPoseInFrame(frame.World, spatialmath.NewPoseFromPoint(r3.Vector{X: 5000}))
+	plan, planErr := read.Replan(context.Background(), logger.Sugar())
+	test.That(t, planErr, test.ShouldNotBeNil)
+	read.SetResult(plan, planErr)
+	test.That(t, read.WriteJSONFile(filename), test.ShouldBeNil)
+	failed, err := ReadPlanRecordJSONFile(filename)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, failed.Plan, test.ShouldBeEmpty)
+	test.That(t, failed.Error, test.ShouldEqual, planErr.Error())
+}
+
+func TestVisualizePlanRecord(t *testing.T) {
+	planRecord := cartesianPlanRecord(t)
+	images, err := VisualizePlanRecord(planRecord, 100)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, images, test.ShouldHaveLength, len(planRecord.Plan))
+	test.That(t, images[0].Bounds().Dx(), test.ShouldEqual, 200)
+	test.That(t, images[0].Bounds().Dy(), test.ShouldEqual, 100)
+	// the goal is drawn in both views
+	count := 0
+	for x := 0; x < 200; x++ {
+		for y := 0; y < 100; y++ {
+			if images[0].RGBAAt(x, y) == planGoalColor {
+				count++
+			}
+		}
+	}
+	test.That(t, count, test.ShouldBeGreaterThan, 2)
+
+	var buf bytes.Buffer
+	test.That(t, WritePlanHTML(&buf, planRecord, 100), test.ShouldBeNil)
+	test.That(t, bytes.Count(buf.Bytes(), []byte("data:image/png;base64,")), test.ShouldEqual, len(planRecord.Plan)+1)
+}
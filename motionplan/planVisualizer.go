@@ -0,0 +1,230 @@
+package motionplan
+
+import (
+	"bytes"
+	"encoding/base64"
+	"html/template"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"math"
+
+	"github.com/golang/geo/r3"
+
+	frame "go.viam.com/rdk/referenceframe"
+	"go.viam.com/rdk/spatialmath"
+)
+
+var (
+	planRobotColor    = color.RGBA{0, 0, 255, 255}
+	planObstacleColor = color.RGBA{255, 0, 0, 255}
+	planPathColor     = color.RGBA{0, 0, 0, 255}
+	planGoalColor     = color.RGBA{0, 160, 0, 255}
+)
+
+// planScene is what is drawn for one step of a plan, in the world frame.
+type planScene struct {
+	robot     []r3.Vector
+	obstacles []r3.Vector
+	// the position of the moving frame.
+	position r3.Vector
+}
+
+// VisualizePlanRecord draws an image of the frame system for each step of a recorded plan, or of its start state if planning
+// failed. Each image is 2*resolution x resolution, with a top down view of the world XY plane on the left and a side view of
+// the XZ plane on the right, both scaled to fit everything in the plan. Frame geometries are drawn in blue, obstacles in red, the
+// path of the moving frame in black, and the goal in green.
+func VisualizePlanRecord(pr *PlanRecord, resolution int) ([]*image.RGBA, error) {
+	fs, err := pr.FrameSystem()
+	if err != nil {
+		return nil, err
+	}
+	goal, err := fs.Transform(pr.StartState, pr.Goal, frame.World)
+	if err != nil {
+		return nil, err
+	}
+	goalPoint := goal.(*frame.PoseInFrame).Pose().Point()
+
+	steps := pr.Plan
+	if len(steps) == 0 {
+		steps = []map[string][]frame.Input{pr.StartState}
+	}
+	scenes := make([]*planScene, 0, len(steps))
+	allPoints := []r3.Vector{goalPoint}
+	for _, step := range steps {
+		scene, err := newPlanScene(pr, fs, step)
+		if err != nil {
+			return nil, err
+		}
+		scenes = append(scenes, scene)
+		allPoints = append(allPoints, scene.position)
+		allPoints = append(allPoints, scene.robot...)
+		allPoints = append(allPoints, scene.obstacles...)
+	}
+
+	// fit everything into a cube, with a margin around it
+	minPt, maxPt := allPoints[0], allPoints[0]
+	for _, pt := range allPoints {
+		minPt = r3.Vector{X: math.Min(minPt.X, pt.X), Y: math.Min(minPt.Y, pt.Y), Z: math.Min(minPt.Z, pt.Z)}
+		maxPt = r3.Vector{X: math.Max(maxPt.X, pt.X), Y: math.Max(maxPt.Y, pt.Y), Z: math.Max(maxPt.Z, pt.Z)}
+	}
+	sideLength := 1.2 * math.Max(math.Max(maxPt.X-minPt.X, maxPt.Y-minPt.Y), math.Max(maxPt.Z-minPt.Z, 1))
+	pixelOffset := minPt.Add(maxPt).Mul(0.5).Sub(r3.Vector{X: sideLength / 2, Y: sideLength / 2, Z: sideLength / 2})
+	pixelScalar := float64(resolution) / sideLength
+
+	images := make([]*image.RGBA, 0, len(scenes))
+	for i, scene := range scenes {
+		// Create base image with a white background
+		img := image.NewRGBA(image.Rect(0, 0, 2*resolution, resolution))
+		draw.Draw(img, img.Bounds(), &image.Uniform{color.RGBA{255, 255, 255, 255}}, image.Point{}, draw.Src)
+		drawViewDivider(img, resolution)
+
+		for _, pt := range scene.obstacles {
+			setPlanPixel(img, pt, pixelOffset, pixelScalar, resolution, planObstacleColor)
+		}
+		for _, pt := range scene.robot {
+			setPlanPixel(img, pt, pixelOffset, pixelScalar, resolution, planRobotColor)
+		}
+		for _, previous := range scenes[:i+1] {
+			fillPlanSquare(img, previous.position, pixelOffset, pixelScalar, resolution, 1, planPathColor)
+		}
+		fillPlanSquare(img, goalPoint, pixelOffset, pixelScalar, resolution, 3, planGoalColor)
+		images = append(images, img)
+	}
+	return images, nil
+}
+
+// newPlanScene collects the points to draw for one step of a plan.
+func newPlanScene(pr *PlanRecord, fs frame.FrameSystem, step map[string][]frame.Input) (*planScene, error) {
+	// frames which are not moved by the plan stay where they started
+	inputs := make(map[string][]frame.Input, len(pr.StartState))
+	for name, input := range pr.StartState {
+		inputs[name] = input
+	}
+	for name, input := range step {
+		inputs[name] = input
+	}
+
+	scene := &planScene{}
+	geometries, err := frame.FrameSystemGeometries(fs, inputs)
+	if err != nil {
+		return nil, err
+	}
+	for _, geometriesInFrame := range geometries {
+		for _, geometry := range geometriesInFrame.Geometries() {
+			scene.robot = append(scene.robot, geometry.ToPoints(0)...)
+		}
+	}
+	obstacles, err := pr.WorldState.ObstaclesInWorldFrame(fs, inputs)
+	if err != nil {
+		return nil, err
+	}
+	for _, geometry := range obstacles.Geometries() {
+		scene.obstacles = append(scene.obstacles, geometry.ToPoints(0)...)
+	}
+	position, err := fs.Transform(inputs, frame.NewPoseInFrame(pr.Frame, spatialmath.NewZeroPose()), frame.World)
+	if err != nil {
+		return nil, err
+	}
+	scene.position = position.(*frame.PoseInFrame).Pose().Point()
+	return scene, nil
+}
+
+// planPixels returns where a point appears in the top down view and in the side view.
+func planPixels(pt, pixelOffset r3.Vector, pixelScalar float64, resolution int) (image.Point, image.Point) {
+	x := int((pt.X - pixelOffset.X) * pixelScalar)
+	// images count down from the top, so up is flipped
+	y := resolution - int((pt.Y-pixelOffset.Y)*pixelScalar)
+	z := resolution - int((pt.Z-pixelOffset.Z)*pixelScalar)
+	return image.Point{x, y}, image.Point{resolution + x, z}
+}
+
+// Set the pixels for a point in both views using the given color.
+func setPlanPixel(img *image.RGBA, pt, pixelOffset r3.Vector, pixelScalar float64, resolution int, c color.RGBA) {
+	top, side := planPixels(pt, pixelOffset, pixelScalar, resolution)
+	img.Set(top.X, top.Y, c)
+	img.Set(side.X, side.Y, c)
+}
+
+// Fill a square about a point in both views using the given color.
+func fillPlanSquare(img *image.RGBA, pt, pixelOffset r3.Vector, pixelScalar float64, resolution, halfSide int, c color.RGBA) {
+	top, side := planPixels(pt, pixelOffset, pixelScalar, resolution)
+	for i := -halfSide; i <= halfSide; i++ {
+		for j := -halfSide; j <= halfSide; j++ {
+			img.Set(top.X+i, top.Y+j, c)
+			img.Set(side.X+i, side.Y+j, c)
+		}
+	}
+}
+
+// Draw a line between the top down and side views.
+func drawViewDivider(img *image.RGBA, resolution int) {
+	for i := 0; i < resolution; i++ {
+		img.Set(resolution, i, color.RGBA{128, 128, 128, 255})
+	}
+}
+
+var planHTMLTemplate = template.Must(template.New("plan").Parse(`<!DOCTYPE html>
+<html>
+<head><title>Plan for {{.Frame}}</title></head>
+<body>
+<h3>Plan for {{.Frame}}: {{len .Steps}} steps</h3>
+{{if .Error}}<p style="color:red">Planning failed: {{.Error}}</p>{{end}}
+<img id="step" src="{{index .Steps 0}}"><br>
+<input id="slider" type="range" min="0" value="0" style="width:50%">
+<button id="play">Play</button> <span id="label">step 0</span>
+<script>
+const steps = [{{range .Steps}}{{.}},{{end}}];
+const img = document.getElementById("step");
+const slider = document.getElementById("slider");
+const label = document.getElementById("label");
+slider.max = steps.length - 1;
+function show(i) {
+	slider.value = i;
+	img.src = steps[i];
+	label.textContent = "step " + i;
+}
+slider.oninput = () => show(Number(slider.value));
+let timer = null;
+document.getElementById("play").onclick = () => {
+	clearInterval(timer);
+	let i = 0;
+	timer = setInterval(() => {
+		show(i);
+		if (++i >= steps.length) {
+			clearInterval(timer);
+		}
+	}, 100);
+};
+</script>
+</body>
+</html>
+`))
+
+// WritePlanHTML writes a standalone HTML page which animates the images from VisualizePlanRecord.
+func WritePlanHTML(w io.Writer, pr *PlanRecord, resolution int) error {
+	images, err := VisualizePlanRecord(pr, resolution)
+	if err != nil {
+		return err
+	}
+	steps := make([]template.URL, 0, len(images))
+	for _, img := range images {
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, img); err != nil {
+			return err
+		}
+		//nolint:gosec
+		steps = append(steps, template.URL("data:image/png;base64,"+base64.StdEncoding.EncodeToString(buf.Bytes())))
+	}
+	return planHTMLTemplate.Execute(w, struct {
+		Frame string
+		Error string
+		Steps []template.URL
+	}{
+		Frame: pr.Frame,
+		Error: pr.Error,
+		Steps: steps,
+	})
+}
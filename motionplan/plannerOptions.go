@@ -3,6 +3,7 @@ package motionplan
 import (
 	"runtime"
 
+	"github.com/golang/geo/r3"
 	pb "go.viam.com/api/service/motion/v1"
 
 	"go.viam.com/rdk/motionplan/ik"
@@ -52,6 +53,10 @@ const (
 	// When breaking down a path into smaller waypoints, add a waypoint every this many mm of movement.
 	defaultPathStepSize = 10
 
+	// When moving several components at once, add a waypoint every this many mm or degrees of movement of any of them, so that
+	// they stay in step with each other.
+	defaultSyncStepSize = 10.
+
 	// This is commented out due to Go compiler bug. See comment in newBasicPlannerOptions for explanation.
 	// var defaultPlanner = newCBiRRTMotionPlanner.
 )
@@ -65,6 +70,7 @@ const (
 	PseudolinearMotionProfile = "pseudolinear"
 	OrientationMotionProfile  = "orientation"
 	PositionOnlyMotionProfile = "position_only"
+	CartesianMotionProfile    = "cartesian"
 )
 
 // NewBasicPlannerOptions specifies a set of basic options for the planner.
@@ -102,9 +108,14 @@ func newBasicPlannerOptions(frame referenceframe.Frame) *plannerOptions {
 // plannerOptions are a set of options to be passed to a planner which will specify how to solve a motion planning problem.
 type plannerOptions struct {
 	ConstraintHandler
-	goalMetric   ik.StateMetric // Distance function which converges to the final goal position
+	goalMetric   ik.StateMetric   // Distance function which converges to the final goal position
+	goal         spatialmath.Pose // The pose goalMetric is minimized at, if it converges to a single pose
 	goalArcScore ik.SegmentMetric
 	pathMetric   ik.StateMetric // Distance function which converges on the valid manifold of intermediate path states
+	arcCenter    *r3.Vector     // Center of the arc followed by the cartesian planner, which follows a line if this is nil
+
+	// The poses each component of a frame moving several components at once is to reach, in the order of its components
+	componentGoals []spatialmath.Pose
 
 	extra map[string]interface{}
 
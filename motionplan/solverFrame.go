@@ -2,6 +2,8 @@ package motionplan
 
 import (
 	"errors"
+	"fmt"
+	"strings"
 
 	"go.uber.org/multierr"
 	pb "go.viam.com/api/component/arm/v1"
@@ -29,6 +31,10 @@ type solverFrame struct {
 	origSeed    map[string][]frame.Input // stores starting locations of all frames in fss that are NOT in `frames`
 
 	ptgs []tpspace.PTG
+
+	// If this solver frame moves several components at once, the solver frames of each of them, whose inputs are concatenated in
+	// this order. Transform gives the pose of the first of them.
+	components []*solverFrame
 }
 
 func newSolverFrame(fs frame.FrameSystem, solveFrameName, goalFrameName string, seedMap map[string][]frame.Input) (*solverFrame, error) {
@@ -159,6 +165,67 @@ func newSolverFrame(fs frame.FrameSystem, solveFrameName, goalFrameName string,
 	}, nil
 }
 
+// newMultiSolverFrame creates a solver frame which moves the frames of several solver frames at once, so that each can be solved
+// towards its own goal while planning in their combined configuration space. The components may not share any frames which move.
+func newMultiSolverFrame(components []*solverFrame, seedMap map[string][]frame.Input) (*solverFrame, error) {
+	if len(components) == 0 {
+		return nil, errors.New("no components to solve for")
+	}
+	moving := frame.NewEmptyFrameSystem("")
+	var frames []frame.Frame
+	names := make([]string, 0, len(components))
+	movedBy := map[string]string{}
+	for _, component := range components {
+		for _, f := range component.frames {
+			if len(f.DoF()) == 0 {
+				continue
+			}
+			if other, ok := movedBy[f.Name()]; ok {
+				return nil, fmt.Errorf("frame %q is moved by both %q and %q", f.Name(), other, component.Name())
+			}
+			movedBy[f.Name()] = component.Name()
+		}
+		if err := moving.MergeFrameSystem(component.movingFS, moving.World()); err != nil {
+			return nil, err
+		}
+		frames = append(frames, component.frames...)
+		names = append(names, component.Name())
+	}
+
+	origSeed := map[string][]frame.Input{}
+	for k, v := range seedMap {
+		origSeed[k] = v
+	}
+	for _, f := range frames {
+		delete(origSeed, f.Name())
+	}
+
+	first := components[0]
+	return &solverFrame{
+		name:        strings.Join(names, "+"),
+		fss:         first.fss,
+		movingFS:    moving,
+		frames:      frames,
+		solveFrame:  first.solveFrame,
+		goalFrame:   first.goalFrame,
+		worldRooted: first.worldRooted,
+		origSeed:    origSeed,
+		components:  components,
+	}, nil
+}
+
+// componentInputs splits the inputs of a solver frame moving several components into the inputs of each component.
+func (sf *solverFrame) componentInputs(inputs []frame.Input) [][]frame.Input {
+	split := make([][]frame.Input, 0, len(sf.components))
+	i := 0
+	for _, component := range sf.components {
+		dof := len(component.DoF())
+		split = append(split, inputs[i:i+dof])
+		i += dof
+	}
+	return split
+}
+
 // Name returns the name of the solver referenceframe.
 func (sf *solverFrame) Name() string {
 	return sf.name
@@ -0,0 +1,82 @@
+package motionplan
+
+import (
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+
+	frame "go.viam.com/rdk/referenceframe"
+)
+
+// TimedStep is a point along a time parameterized plan: the inputs of each frame at a time from the start of the plan, and the velocity
+// of each of those inputs, in input units per second.
+type TimedStep struct {
+	Time       time.Duration
+	Inputs     map[string][]frame.Input
+	Velocities map[string][]float64
+}
+
+// TimeParameterizePlan times a plan so that every input moves within the velocity, acceleration and jerk limits of its frame. Frames
+// move together, reaching each step of the plan at the same time, so whichever frame is closest to its limits sets the pace. If step is
+// zero the steps of the plan are returned with their times, otherwise points are sampled along the plan a step apart.
+func TimeParameterizePlan(plan []map[string][]frame.Input, fs frame.FrameSystem, step time.Duration) ([]TimedStep, error) {
+	if len(plan) == 0 {
+		return nil, errors.New("cannot time parameterize an empty plan")
+	}
+	names := make([]string, 0, len(plan[0]))
+	for name := range plan[0] {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// time every frame as one, with all of their inputs side by side
+	var limits []frame.DynamicLimit
+	for _, name := range names {
+		f := fs.Frame(name)
+		if f == nil {
+			return nil, frame.NewFrameMissingError(name)
+		}
+		limits = append(limits, frame.FrameDynamicLimits(f)...)
+	}
+	waypoints := make([][]frame.Input, 0, len(plan))
+	for _, planStep := range plan {
+		var inputs []frame.Input
+		for _, name := range names {
+			frameInputs, ok := planStep[name]
+			if !ok {
+				return nil, errors.Errorf("plan step has no inputs for frame %q", name)
+			}
+			inputs = append(inputs, frameInputs...)
+		}
+		waypoints = append(waypoints, inputs)
+	}
+	trajectory, err := frame.NewTrajectory(limits, waypoints)
+	if err != nil {
+		return nil, err
+	}
+
+	points := trajectory.Waypoints()
+	if step != 0 {
+		if points, err = trajectory.Sample(step); err != nil {
+			return nil, err
+		}
+	}
+	timed := make([]TimedStep, 0, len(points))
+	for _, point := range points {
+		timedStep := TimedStep{
+			Time:       point.Time,
+			Inputs:     map[string][]frame.Input{},
+			Velocities: map[string][]float64{},
+		}
+		i := 0
+		for _, name := range names {
+			dof := len(plan[0][name])
+			timedStep.Inputs[name] = point.Inputs[i : i+dof]
+			timedStep.Velocities[name] = point.Velocities[i : i+dof]
+			i += dof
+		}
+		timed = append(timed, timedStep)
+	}
+	return timed, nil
+}
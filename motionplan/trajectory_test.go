@@ -0,0 +1,51 @@
+package motionplan
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"go.viam.com/test"
+
+	frame "go.viam.com/rdk/referenceframe"
+	"go.viam.com/rdk/spatialmath"
+	"go.viam.com/rdk/utils"
+)
+
+func TestTimeParameterizePlan(t *testing.T) {
+	fs := frame.NewEmptyFrameSystem("test")
+	gantryConfig := frame.JointConfig{ID: "gantry", Type: frame.PrismaticJoint, Axis: spatialmath.AxisConfig{1, 0, 0}, Max: 500, MaxVel: 100}
+	gantry, err := gantryConfig.ToFrame()
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, fs.AddFrame(gantry, fs.World()), test.ShouldBeNil)
+	m, err := frame.ParseModelJSONFile(utils.ResolveFile("components/arm/universalrobots/ur5e.json"), "")
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, fs.AddFrame(m, gantry), test.ShouldBeNil)
+
+	// the arm could make its move in a second, but the gantry takes two
+	end := frame.FloatsToInputs([]float64{math.Pi, -1.2, 1.4, -1.8, -1.57, 0})
+	plan := []map[string][]frame.Input{
+		{"gantry": {{0}}, m.Name(): ur5eStart},
+		{"gantry": {{200}}, m.Name(): end},
+	}
+	steps, err := TimeParameterizePlan(plan, fs, 0)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, len(steps), test.ShouldEqual, 2)
+	test.That(t, steps[1].Time, test.ShouldEqual, 2*time.Second)
+	test.That(t, steps[1].Inputs, test.ShouldResemble, plan[1])
+
+	steps, err = TimeParameterizePlan(plan, fs, 100*time.Millisecond)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, len(steps), test.ShouldEqual, 21)
+	test.That(t, steps[10].Inputs["gantry"][0].Value, test.ShouldAlmostEqual, 100)
+	test.That(t, steps[10].Inputs[m.Name()][0].Value, test.ShouldAlmostEqual, math.Pi/2)
+	test.That(t, steps[10].Velocities["gantry"][0], test.ShouldAlmostEqual, 100)
+	test.That(t, steps[10].Velocities[m.Name()][0], test.ShouldAlmostEqual, math.Pi/2)
+
+	_, err = TimeParameterizePlan(nil, fs, 0)
+	test.That(t, err, test.ShouldNotBeNil)
+	_, err = TimeParameterizePlan([]map[string][]frame.Input{{"gantry": {{0}}}, {m.Name(): end}}, fs, 0)
+	test.That(t, err, test.ShouldNotBeNil)
+	_, err = TimeParameterizePlan([]map[string][]frame.Input{{"missing": {{0}}}}, fs, 0)
+	test.That(t, err, test.ShouldNotBeNil)
+}
@@ -0,0 +1,162 @@
+package pointcloud
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/golang/geo/r3"
+	"github.com/pkg/errors"
+
+	"go.viam.com/rdk/spatialmath"
+)
+
+const (
+	// The number of planes along each axis which are tried when splitting part of a point cloud in two.
+	convexSplitsPerAxis = 8
+	// The most samples across the longest side of a part that are taken of the surface of its hull to measure its concavity.
+	concavitySamples = 32
+)
+
+// convexPart is part of a point cloud which is being decomposed into convex parts.
+type convexPart struct {
+	points []r3.Vector
+	hull   *convexHull // nil if the points are coplanar
+	// how far the surface of the hull is from the points. The hull of a convex part is close to its points everywhere, while the hull of a
+	// concave one spans empty space
+	concavity  float64
+	splittable bool
+}
+
+// newConvexPart finds the hull and concavity of part of a point cloud, measuring the concavity at samples no further apart than spacing.
+func newConvexPart(pts []r3.Vector, spacing float64) *convexPart {
+	part := &convexPart{points: pts, splittable: len(pts) >= 8}
+	hull, err := newConvexHull(pts)
+	if err != nil {
+		// coplanar points are flat, so are as convex as they can be
+		return part
+	}
+	part.hull = hull
+
+	bounds := newBounds(pts)
+	spacing = math.Max(spacing, bounds[1].Sub(bounds[0]).Norm()/concavitySamples)
+	kd := NewKDTreeWithPrealloc(len(pts))
+	for _, pt := range pts {
+		// points are already distinct, so cannot fail to be set
+		//nolint:errcheck
+		kd.Set(pt, nil)
+	}
+	for _, sample := range hull.surfaceSamples(spacing) {
+		if _, _, dist, ok := kd.NearestNeighbor(sample); ok {
+			part.concavity = math.Max(part.concavity, dist)
+		}
+	}
+	return part
+}
+
+// newBounds returns the min and max corners of the box which bounds a set of points.
+func newBounds(pts []r3.Vector) [2]r3.Vector {
+	bounds := [2]r3.Vector{pts[0], pts[0]}
+	for _, pt := range pts {
+		bounds[0] = r3.Vector{X: math.Min(bounds[0].X, pt.X), Y: math.Min(bounds[0].Y, pt.Y), Z: math.Min(bounds[0].Z, pt.Z)}
+		bounds[1] = r3.Vector{X: math.Max(bounds[1].X, pt.X), Y: math.Max(bounds[1].Y, pt.Y), Z: math.Max(bounds[1].Z, pt.Z)}
+	}
+	return bounds
+}
+
+// volume returns the volume of the hull of the part, which is zero if its points are coplanar.
+func (part *convexPart) volume() float64 {
+	if part.hull == nil {
+		return 0
+	}
+	return part.hull.volume()
+}
+
+// split divides the part in two along the axis aligned plane which leaves the least space in the hulls of the halves. It returns false if
+// there is no plane which leaves enough points on either side to have a hull.
+func (part *convexPart) split(spacing float64) (*convexPart, *convexPart, bool) {
+	bounds := newBounds(part.points)
+	var bestLow, bestHigh *convexPart
+	bestVolume := math.Inf(1)
+	for axis := 0; axis < 3; axis++ {
+		lo, hi := coord(bounds[0], axis), coord(bounds[1], axis)
+		for i := 1; i < convexSplitsPerAxis; i++ {
+			plane := lo + (hi-lo)*float64(i)/convexSplitsPerAxis
+			var low, high []r3.Vector
+			for _, pt := range part.points {
+				if coord(pt, axis) < plane {
+					low = append(low, pt)
+				} else {
+					high = append(high, pt)
+				}
+			}
+			if len(low) < 4 || len(high) < 4 {
+				continue
+			}
+			lowPart, highPart := newConvexPart(low, spacing), newConvexPart(high, spacing)
+			if volume := lowPart.volume() + highPart.volume(); volume < bestVolume {
+				bestLow, bestHigh, bestVolume = lowPart, highPart, volume
+			}
+		}
+	}
+	return bestLow, bestHigh, bestLow != nil
+}
+
+// geometry returns the hull of the part as a mesh, or the box which bounds it if its points are coplanar.
+func (part *convexPart) geometry(label string) (spatialmath.Geometry, error) {
+	if part.hull != nil {
+		return part.hull.geometry(label)
+	}
+	bounds := newBounds(part.points)
+	return spatialmath.NewBox(spatialmath.NewPoseFromPoint(bounds[0].Add(bounds[1]).Mul(0.5)), bounds[1].Sub(bounds[0]), label)
+}
+
+// ConvexDecomposition approximately decomposes a point cloud into convex parts, and returns a mesh Geometry of the convex hull of each.
+// This fits concave obstacles, such as L shaped ones, much more tightly than a single box or hull.
+//
+// The most concave part, whose hull has surface furthest from any of its points, is repeatedly split in two along the axis aligned plane
+// which leaves the least space in the hulls of the halves. This stops once no part's hull is more than maxConcavity mm from its points,
+// or there are maxParts parts. maxConcavity should be larger than the spacing between points in the cloud, as even the hull of a convex
+// part is that far from its points. Parts whose points are all on one plane have no hull, so are returned as the boxes which bound them.
+// Geometries are labeled with the given label and the index of their part.
+func ConvexDecomposition(cloud PointCloud, maxConcavity float64, maxParts int, label string) ([]spatialmath.Geometry, error) {
+	if maxParts < 1 {
+		return nil, errors.Errorf("cannot decompose point cloud into %d parts", maxParts)
+	}
+	if cloud.Size() == 0 {
+		return nil, nil
+	}
+	spacing := maxConcavity / 2
+	parts := []*convexPart{newConvexPart(cloudPoints(cloud), spacing)}
+	for len(parts) < maxParts {
+		worst := -1
+		for i, part := range parts {
+			if part.splittable && part.concavity > maxConcavity && (worst < 0 || part.concavity > parts[worst].concavity) {
+				worst = i
+			}
+		}
+		if worst < 0 {
+			break
+		}
+		low, high, ok := parts[worst].split(spacing)
+		if !ok {
+			parts[worst].splittable = false
+			continue
+		}
+		parts[worst] = low
+		parts = append(parts, high)
+	}
+
+	geometries := make([]spatialmath.Geometry, 0, len(parts))
+	for i, part := range parts {
+		partLabel := label
+		if label != "" {
+			partLabel = fmt.Sprintf("%s_%d", label, i)
+		}
+		geometry, err := part.geometry(partLabel)
+		if err != nil {
+			return nil, err
+		}
+		geometries = append(geometries, geometry)
+	}
+	return geometries, nil
+}
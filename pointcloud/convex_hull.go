@@ -0,0 +1,327 @@
+package pointcloud
+
+import (
+	"math"
+
+	"github.com/golang/geo/r3"
+	"github.com/pkg/errors"
+
+	"go.viam.com/rdk/spatialmath"
+)
+
+// errDegenerateHull is returned when points are all on one plane, so they have no hull with any volume.
+var errDegenerateHull = errors.New("points are coplanar and do not enclose a volume")
+
+// ConvexHullFromPointCloud returns a mesh Geometry of the convex hull of all the points in the given point cloud.
+func ConvexHullFromPointCloud(cloud PointCloud) (spatialmath.Geometry, error) {
+	return ConvexHullFromPointCloudWithLabel(cloud, "")
+}
+
+// ConvexHullFromPointCloudWithLabel returns a mesh Geometry of the convex hull of all the points in the given point cloud. The mesh is
+// posed at the centroid of the hull's vertices.
+func ConvexHullFromPointCloudWithLabel(cloud PointCloud, label string) (spatialmath.Geometry, error) {
+	if cloud.Size() == 0 {
+		return nil, nil
+	}
+	hull, err := newConvexHull(cloudPoints(cloud))
+	if err != nil {
+		return nil, err
+	}
+	return hull.geometry(label)
+}
+
+// cloudPoints returns the positions of all the points in a point cloud.
+func cloudPoints(cloud PointCloud) []r3.Vector {
+	pts := make([]r3.Vector, 0, cloud.Size())
+	cloud.Iterate(0, 0, func(p r3.Vector, d Data) bool {
+		pts = append(pts, p)
+		return true
+	})
+	return pts
+}
+
+// hullFace is a triangular face of a convex hull. Its vertices are ordered counterclockwise seen from outside of the hull, so its normal
+// points outward.
+type hullFace struct {
+	vertices [3]int
+	normal   r3.Vector
+	offset   float64 // the distance of the face's plane from the origin along its normal
+	outside  []int   // the points above the face which are not yet within the hull
+	removed  bool
+}
+
+// distance returns the signed distance of a point above the plane of the face.
+func (f *hullFace) distance(pt r3.Vector) float64 {
+	return f.normal.Dot(pt) - f.offset
+}
+
+// convexHull is the convex hull of a set of points, found by the quickhull algorithm.
+type convexHull struct {
+	points []r3.Vector
+	faces  []*hullFace
+	eps    float64 // points within this distance of a face are considered to be on it
+}
+
+// newConvexHull finds the convex hull of a set of points, which must not all be on one plane.
+func newConvexHull(pts []r3.Vector) (*convexHull, error) {
+	if len(pts) < 4 {
+		return nil, errDegenerateHull
+	}
+	hull := &convexHull{points: pts}
+
+	// tolerances are relative to the size of the points, as floating point error is too
+	extremes := [6]int{}
+	for i, pt := range pts {
+		for axis := 0; axis < 3; axis++ {
+			if coord(pt, axis) < coord(pts[extremes[2*axis]], axis) {
+				extremes[2*axis] = i
+			}
+			if coord(pt, axis) > coord(pts[extremes[2*axis+1]], axis) {
+				extremes[2*axis+1] = i
+			}
+		}
+	}
+	scale := 0.
+	for axis := 0; axis < 3; axis++ {
+		scale += math.Max(math.Abs(coord(pts[extremes[2*axis]], axis)), math.Abs(coord(pts[extremes[2*axis+1]], axis)))
+	}
+	hull.eps = 1e-9 * math.Max(scale, 1)
+
+	simplex, err := hull.initialSimplex(extremes)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range simplex {
+		hull.addFace(f[0], f[1], f[2])
+	}
+	// make the faces of the simplex point outwards
+	center := pts[simplex[0][0]].Add(pts[simplex[0][1]]).Add(pts[simplex[0][2]]).Add(pts[simplex[1][2]]).Mul(0.25)
+	for _, f := range hull.faces {
+		if f.distance(center) > 0 {
+			f.vertices[1], f.vertices[2] = f.vertices[2], f.vertices[1]
+			f.normal = f.normal.Mul(-1)
+			f.offset = -f.offset
+		}
+	}
+	all := make([]int, len(pts))
+	for i := range all {
+		all[i] = i
+	}
+	hull.assignOutside(all, hull.faces)
+
+	for {
+		var face *hullFace
+		for _, f := range hull.faces {
+			if len(f.outside) > 0 {
+				face = f
+				break
+			}
+		}
+		if face == nil {
+			break
+		}
+		hull.addPoint(face)
+	}
+	return hull, nil
+}
+
+// initialSimplex returns the faces of a tetrahedron between four of the points, which is found from the points at the extremes of each
+// axis.
+func (hull *convexHull) initialSimplex(extremes [6]int) ([4][3]int, error) {
+	pts := hull.points
+	// the two extremes which are furthest apart
+	a, b := extremes[0], extremes[1]
+	for i := 0; i < 6; i++ {
+		for j := i + 1; j < 6; j++ {
+			if pts[extremes[i]].Sub(pts[extremes[j]]).Norm2() > pts[a].Sub(pts[b]).Norm2() {
+				a, b = extremes[i], extremes[j]
+			}
+		}
+	}
+	// the point furthest from the line between them
+	c, best := -1, hull.eps
+	dir := pts[b].Sub(pts[a]).Normalize()
+	for i, pt := range pts {
+		if d := pt.Sub(pts[a]).Cross(dir).Norm(); d > best {
+			c, best = i, d
+		}
+	}
+	if c < 0 {
+		return [4][3]int{}, errDegenerateHull
+	}
+	// the point furthest from the plane between all three
+	d, best := -1, hull.eps
+	normal := pts[b].Sub(pts[a]).Cross(pts[c].Sub(pts[a])).Normalize()
+	for i, pt := range pts {
+		if dist := math.Abs(normal.Dot(pt.Sub(pts[a]))); dist > best {
+			d, best = i, dist
+		}
+	}
+	if d < 0 {
+		return [4][3]int{}, errDegenerateHull
+	}
+	return [4][3]int{{a, b, c}, {a, b, d}, {b, c, d}, {c, a, d}}, nil
+}
+
+// addFace adds a face between three points to the hull.
+func (hull *convexHull) addFace(a, b, c int) *hullFace {
+	p0, p1, p2 := hull.points[a], hull.points[b], hull.points[c]
+	normal := p1.Sub(p0).Cross(p2.Sub(p0)).Normalize()
+	f := &hullFace{vertices: [3]int{a, b, c}, normal: normal, offset: normal.Dot(p0)}
+	hull.faces = append(hull.faces, f)
+	return f
+}
+
+// assignOutside assigns each point to the first face it is above. Points which are above none of the faces are within the hull.
+func (hull *convexHull) assignOutside(pts []int, faces []*hullFace) {
+	for _, i := range pts {
+		for _, f := range faces {
+			if f.distance(hull.points[i]) > hull.eps {
+				f.outside = append(f.outside, i)
+				break
+			}
+		}
+	}
+}
+
+// addPoint expands the hull to include the point furthest above a face. Every face the point is above is replaced by faces from the
+// point to the edges of the horizon around them.
+func (hull *convexHull) addPoint(face *hullFace) {
+	eye, best := -1, math.Inf(-1)
+	for _, i := range face.outside {
+		if d := face.distance(hull.points[i]); d > best {
+			eye, best = i, d
+		}
+	}
+	eyePt := hull.points[eye]
+
+	// map each directed edge to the face it belongs to, so the faces across each edge can be found
+	edges := map[[2]int]*hullFace{}
+	for _, f := range hull.faces {
+		for j := 0; j < 3; j++ {
+			edges[[2]int{f.vertices[j], f.vertices[(j+1)%3]}] = f
+		}
+	}
+
+	// the faces the point is above are connected, so can be found by searching outward from the first
+	visible := map[*hullFace]bool{face: true}
+	visibleFaces := []*hullFace{face}
+	for k := 0; k < len(visibleFaces); k++ {
+		f := visibleFaces[k]
+		for j := 0; j < 3; j++ {
+			a, b := f.vertices[j], f.vertices[(j+1)%3]
+			neighbor := edges[[2]int{b, a}]
+			if neighbor == nil || visible[neighbor] {
+				continue
+			}
+			if neighbor.distance(eyePt) > hull.eps {
+				visible[neighbor] = true
+				visibleFaces = append(visibleFaces, neighbor)
+			}
+		}
+	}
+	var orphans []int
+	var horizon [][2]int
+	for _, f := range visibleFaces {
+		f.removed = true
+		for _, i := range f.outside {
+			if i != eye {
+				orphans = append(orphans, i)
+			}
+		}
+		for j := 0; j < 3; j++ {
+			a, b := f.vertices[j], f.vertices[(j+1)%3]
+			if neighbor := edges[[2]int{b, a}]; neighbor != nil && !visible[neighbor] {
+				horizon = append(horizon, [2]int{a, b})
+			}
+		}
+	}
+
+	remaining := hull.faces[:0]
+	for _, f := range hull.faces {
+		if !f.removed {
+			remaining = append(remaining, f)
+		}
+	}
+	hull.faces = remaining
+
+	newFaces := make([]*hullFace, 0, len(horizon))
+	for _, edge := range horizon {
+		newFaces = append(newFaces, hull.addFace(edge[0], edge[1], eye))
+	}
+	hull.assignOutside(orphans, newFaces)
+}
+
+// volume returns the volume enclosed by the hull.
+func (hull *convexHull) volume() float64 {
+	volume := 0.
+	for _, f := range hull.faces {
+		p0, p1, p2 := hull.points[f.vertices[0]], hull.points[f.vertices[1]], hull.points[f.vertices[2]]
+		volume += p0.Dot(p1.Cross(p2)) / 6
+	}
+	return volume
+}
+
+// surfaceSamples returns points spread over the faces of the hull, no further apart than spacing.
+func (hull *convexHull) surfaceSamples(spacing float64) []r3.Vector {
+	var samples []r3.Vector
+	for _, f := range hull.faces {
+		p0, p1, p2 := hull.points[f.vertices[0]], hull.points[f.vertices[1]], hull.points[f.vertices[2]]
+		longest := math.Max(p1.Sub(p0).Norm(), math.Max(p2.Sub(p1).Norm(), p0.Sub(p2).Norm()))
+		steps := int(math.Max(1, math.Ceil(longest/spacing)))
+		e0, e1 := p1.Sub(p0), p2.Sub(p0)
+		for i := 0; i <= steps; i++ {
+			for j := 0; i+j <= steps; j++ {
+				samples = append(samples, p0.Add(e0.Mul(float64(i)/float64(steps))).Add(e1.Mul(float64(j)/float64(steps))))
+			}
+		}
+	}
+	return samples
+}
+
+// depth returns how far a point is within the hull, which is the distance to the nearest of its faces.
+func (hull *convexHull) depth(pt r3.Vector) float64 {
+	depth := math.Inf(1)
+	for _, f := range hull.faces {
+		depth = math.Min(depth, -f.distance(pt))
+	}
+	return depth
+}
+
+// geometry returns the hull as a mesh posed at the centroid of its vertices.
+func (hull *convexHull) geometry(label string) (spatialmath.Geometry, error) {
+	indices := map[int]int{}
+	var vertices []r3.Vector
+	faces := make([][3]int, 0, len(hull.faces))
+	for _, f := range hull.faces {
+		var face [3]int
+		for j, i := range f.vertices {
+			if _, ok := indices[i]; !ok {
+				indices[i] = len(vertices)
+				vertices = append(vertices, hull.points[i])
+			}
+			face[j] = indices[i]
+		}
+		faces = append(faces, face)
+	}
+	centroid := r3.Vector{}
+	for _, v := range vertices {
+		centroid = centroid.Add(v)
+	}
+	centroid = centroid.Mul(1 / float64(len(vertices)))
+	for i := range vertices {
+		vertices[i] = vertices[i].Sub(centroid)
+	}
+	return spatialmath.NewMesh(spatialmath.NewPoseFromPoint(centroid), vertices, faces, label)
+}
+
+func coord(v r3.Vector, axis int) float64 {
+	switch axis {
+	case 0:
+		return v.X
+	case 1:
+		return v.Y
+	default:
+		return v.Z
+	}
+}
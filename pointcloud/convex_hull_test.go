@@ -0,0 +1,134 @@
+package pointcloud
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/golang/geo/r3"
+	"go.viam.com/test"
+
+	"go.viam.com/rdk/spatialmath"
+)
+
+// makeGridCloud returns a cloud with points spaced 5mm apart throughout each of the given boxes, which are given by their min and max
+// corners.
+func makeGridCloud(t *testing.T, boxes ...[2]r3.Vector) PointCloud {
+	t.Helper()
+	cloud := New()
+	for _, b := range boxes {
+		for x := b[0].X; x <= b[1].X; x += 5 {
+			for y := b[0].Y; y <= b[1].Y; y += 5 {
+				for z := b[0].Z; z <= b[1].Z; z += 5 {
+					test.That(t, cloud.Set(NewVector(x, y, z), nil), test.ShouldBeNil)
+				}
+			}
+		}
+	}
+	return cloud
+}
+
+func TestConvexHullFromPointCloud(t *testing.T) {
+	t.Run("cube", func(t *testing.T) {
+		cloud := makeGridCloud(t, [2]r3.Vector{{0, 0, 0}, {100, 100, 100}})
+		hull, err := ConvexHullFromPointCloudWithLabel(cloud, "hull")
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, hull.Label(), test.ShouldEqual, "hull")
+		test.That(t, hull.Pose().Point(), test.ShouldResemble, r3.Vector{50, 50, 50})
+
+		// only the corners of the cube are vertices of the hull, so it is the same as a box
+		expected, err := spatialmath.NewBox(spatialmath.NewPoseFromPoint(r3.Vector{50, 50, 50}), r3.Vector{100, 100, 100}, "")
+		test.That(t, err, test.ShouldBeNil)
+		bounds, err := spatialmath.NewGeometryFromProto(hull.ToProtobuf())
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, bounds.AlmostEqual(expected), test.ShouldBeTrue)
+		for _, pt := range []r3.Vector{{50, 50, 50}, {1, 99, 1}, {100, 100, 100}} {
+			collides, err := hull.CollidesWith(spatialmath.NewPoint(pt, ""))
+			test.That(t, err, test.ShouldBeNil)
+			test.That(t, collides, test.ShouldBeTrue)
+		}
+		dist, err := hull.DistanceFrom(spatialmath.NewPoint(r3.Vector{50, 50, 110}, ""))
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, dist, test.ShouldAlmostEqual, 10)
+	})
+
+	t.Run("sphere", func(t *testing.T) {
+		randSeed := rand.New(rand.NewSource(1))
+		cloud := New()
+		var pts []r3.Vector
+		for i := 0; i < 500; i++ {
+			pt := r3.Vector{randSeed.NormFloat64(), randSeed.NormFloat64(), randSeed.NormFloat64()}.Normalize().Mul(100)
+			pts = append(pts, pt)
+			test.That(t, cloud.Set(pt, nil), test.ShouldBeNil)
+		}
+		hull, err := newConvexHull(pts)
+		test.That(t, err, test.ShouldBeNil)
+		// every point is on the sphere, so is a vertex of its hull
+		for _, pt := range pts {
+			test.That(t, math.Abs(hull.depth(pt)), test.ShouldBeLessThan, 1e-6)
+		}
+		test.That(t, len(hull.faces), test.ShouldEqual, 2*len(pts)-4)
+		test.That(t, hull.volume(), test.ShouldBeLessThan, 4*math.Pi*1e6/3)
+		test.That(t, hull.volume(), test.ShouldBeGreaterThan, 0.95*4*math.Pi*1e6/3)
+
+		geometry, err := ConvexHullFromPointCloud(cloud)
+		test.That(t, err, test.ShouldBeNil)
+		inside, err := spatialmath.NewPoint(r3.Vector{}, "").EncompassedBy(geometry)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, inside, test.ShouldBeTrue)
+	})
+
+	t.Run("degenerate", func(t *testing.T) {
+		_, err := ConvexHullFromPointCloud(makeGridCloud(t, [2]r3.Vector{{0, 0, 0}, {100, 100, 0}}))
+		test.That(t, err, test.ShouldNotBeNil)
+		hull, err := ConvexHullFromPointCloud(New())
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, hull, test.ShouldBeNil)
+	})
+}
+
+func TestConvexDecomposition(t *testing.T) {
+	// an L shaped obstacle, whose hull covers the corner between its arms
+	cloud := makeGridCloud(t, [2]r3.Vector{{0, 0, 0}, {100, 20, 20}}, [2]r3.Vector{{0, 25, 0}, {20, 100, 20}})
+	corner := spatialmath.NewPoint(r3.Vector{60, 60, 10}, "")
+	hull, err := ConvexHullFromPointCloud(cloud)
+	test.That(t, err, test.ShouldBeNil)
+	collides, err := hull.CollidesWith(corner)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, collides, test.ShouldBeTrue)
+
+	parts, err := ConvexDecomposition(cloud, 10, 4, "l")
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, len(parts), test.ShouldBeBetweenOrEqual, 2, 4)
+	test.That(t, parts[0].Label(), test.ShouldEqual, "l_0")
+	for _, part := range parts {
+		collides, err := part.CollidesWith(corner)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, collides, test.ShouldBeFalse)
+	}
+	// every point is still within one of the parts
+	cloud.Iterate(0, 0, func(p r3.Vector, d Data) bool {
+		found := false
+		for _, part := range parts {
+			collides, err := part.CollidesWith(spatialmath.NewPoint(p, ""))
+			test.That(t, err, test.ShouldBeNil)
+			found = found || collides
+		}
+		test.That(t, found, test.ShouldBeTrue)
+		return true
+	})
+
+	// a convex obstacle is not split
+	parts, err = ConvexDecomposition(makeGridCloud(t, [2]r3.Vector{{0, 0, 0}, {50, 50, 50}}), 10, 4, "")
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, len(parts), test.ShouldEqual, 1)
+
+	// nor is anything past the maximum number of parts
+	parts, err = ConvexDecomposition(cloud, 10, 1, "")
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, len(parts), test.ShouldEqual, 1)
+	test.That(t, parts[0].AlmostEqual(hull), test.ShouldBeTrue)
+
+	_, err = ConvexDecomposition(cloud, 10, 0, "")
+	test.That(t, err, test.ShouldNotBeNil)
+}
@@ -29,6 +29,29 @@ type Limit struct {
 	Max float64
 }
 
+// DynamicLimit represents the limits on how quickly a degree of freedom of a referenceframe may move. Velocity, acceleration and jerk
+// are in the units of the frame's inputs per second, per second squared and per second cubed, and zero means there is no limit.
+type DynamicLimit struct {
+	Velocity     float64
+	Acceleration float64
+	Jerk         float64
+}
+
+// DynamicLimited is a Frame whose degrees of freedom may have limits on their velocity, acceleration and jerk.
+type DynamicLimited interface {
+	// DynamicLimits returns a slice with an element for each degree of freedom of the frame, in the same order as DoF.
+	DynamicLimits() []DynamicLimit
+}
+
+// FrameDynamicLimits returns the dynamic limits of each degree of freedom of a frame, which are all unlimited for frames which are not
+// DynamicLimited.
+func FrameDynamicLimits(f Frame) []DynamicLimit {
+	if limited, ok := f.(DynamicLimited); ok {
+		return limited.DynamicLimits()
+	}
+	return make([]DynamicLimit, len(f.DoF()))
+}
+
 // RestrictedRandomFrameInputs will produce a list of valid, in-bounds inputs for the frame.
 // The range of selection is restricted to `restrictionPercent` percent of the limits, and the
 // selection frame is centered at reference.
@@ -113,8 +136,9 @@ type Frame interface {
 
 // baseFrame contains all the data and methods common to all frames, notably it does not implement the Frame interface itself.
 type baseFrame struct {
-	name   string
-	limits []Limit
+	name          string
+	limits        []Limit
+	dynamicLimits []DynamicLimit // unlimited if nil
 }
 
 // Name returns the name of the referenceframe.
@@ -127,6 +151,14 @@ func (bf *baseFrame) DoF() []Limit {
 	return bf.limits
 }
 
+// DynamicLimits returns the velocity, acceleration and jerk limits of each degree of freedom of the frame.
+func (bf *baseFrame) DynamicLimits() []DynamicLimit {
+	if bf.dynamicLimits == nil {
+		return make([]DynamicLimit, len(bf.limits))
+	}
+	return bf.dynamicLimits
+}
+
 // validInputs checks whether the given array of joint positions violates any joint limits.
 func (bf *baseFrame) validInputs(inputs []Input) error {
 	var errAll error
@@ -202,12 +234,12 @@ func NewStaticFrame(name string, pose spatial.Pose) (Frame, error) {
 	if pose == nil {
 		return nil, errors.New("pose is not allowed to be nil")
 	}
-	return &staticFrame{&baseFrame{name, []Limit{}}, pose, nil}, nil
+	return &staticFrame{&baseFrame{name: name, limits: []Limit{}}, pose, nil}, nil
 }
 
 // NewZeroStaticFrame creates a frame with no translation or orientation changes.
 func NewZeroStaticFrame(name string) Frame {
-	return &staticFrame{&baseFrame{name, []Limit{}}, spatial.NewZeroPose(), nil}
+	return &staticFrame{&baseFrame{name: name, limits: []Limit{}}, spatial.NewZeroPose(), nil}
 }
 
 // NewStaticFrameWithGeometry creates a frame given a pose relative to its parent.  The pose is fixed for all time.
@@ -216,7 +248,7 @@ func NewStaticFrameWithGeometry(name string, pose spatial.Pose, geometry spatial
 	if pose == nil {
 		return nil, errors.New("pose is not allowed to be nil")
 	}
-	return &staticFrame{&baseFrame{name, []Limit{}}, pose, geometry}, nil
+	return &staticFrame{&baseFrame{name: name, limits: []Limit{}}, pose, geometry}, nil
 }
 
 // Transform returns the pose associated with this static referenceframe.
@@ -349,6 +381,11 @@ func (pf translationalFrame) MarshalJSON() ([]byte, error) {
 		Max:  pf.limits[0].Max,
 		Min:  pf.limits[0].Min,
 	}
+	if pf.dynamicLimits != nil {
+		temp.MaxVel = pf.dynamicLimits[0].Velocity
+		temp.MaxAcc = pf.dynamicLimits[0].Acceleration
+		temp.MaxJerk = pf.dynamicLimits[0].Jerk
+	}
 	if pf.geometry != nil {
 		var err error
 		temp.Geometry, err = spatial.NewGeometryConfig(pf.geometry)
@@ -422,6 +459,11 @@ func (rf rotationalFrame) MarshalJSON() ([]byte, error) {
 		Max:  utils.RadToDeg(rf.limits[0].Max),
 		Min:  utils.RadToDeg(rf.limits[0].Min),
 	}
+	if rf.dynamicLimits != nil {
+		temp.MaxVel = utils.RadToDeg(rf.dynamicLimits[0].Velocity)
+		temp.MaxAcc = utils.RadToDeg(rf.dynamicLimits[0].Acceleration)
+		temp.MaxJerk = utils.RadToDeg(rf.dynamicLimits[0].Jerk)
+	}
 
 	return json.Marshal(temp)
 }
@@ -2,6 +2,7 @@ package referenceframe
 
 import (
 	"github.com/golang/geo/r3"
+	"github.com/pkg/errors"
 
 	spatial "go.viam.com/rdk/spatialmath"
 	"go.viam.com/rdk/utils"
@@ -32,6 +33,9 @@ type JointConfig struct {
 	Axis     spatial.AxisConfig      `json:"axis"`
 	Max      float64                 `json:"max"`                // in mm or degs
 	Min      float64                 `json:"min"`                // in mm or degs
+	MaxVel   float64                 `json:"max_vel,omitempty"`  // in mm/s or degs/s, unlimited if zero
+	MaxAcc   float64                 `json:"max_acc,omitempty"`  // in mm/s^2 or degs/s^2, unlimited if zero
+	MaxJerk  float64                 `json:"max_jerk,omitempty"` // in mm/s^3 or degs/s^3, unlimited if zero
 	Geometry *spatial.GeometryConfig `json:"geometry,omitempty"` // only valid for prismatic/translational joints
 }
 
@@ -42,8 +46,11 @@ type DHParamConfig struct {
 	A        float64                 `json:"a"`
 	D        float64                 `json:"d"`
 	Alpha    float64                 `json:"alpha"`
-	Max      float64                 `json:"max"` // in mm or degs
-	Min      float64                 `json:"min"` // in mm or degs
+	Max      float64                 `json:"max"`                // in mm or degs
+	Min      float64                 `json:"min"`                // in mm or degs
+	MaxVel   float64                 `json:"max_vel,omitempty"`  // in degs/s, unlimited if zero
+	MaxAcc   float64                 `json:"max_acc,omitempty"`  // in degs/s^2, unlimited if zero
+	MaxJerk  float64                 `json:"max_jerk,omitempty"` // in degs/s^3, unlimited if zero
 	Geometry *spatial.GeometryConfig `json:"geometry,omitempty"`
 }
 
@@ -102,16 +109,46 @@ func (cfg *LinkConfig) Pose() (spatial.Pose, error) {
 
 // ToFrame converts a JointConfig into a joint frame.
 func (cfg *JointConfig) ToFrame() (Frame, error) {
+	var frame Frame
+	var err error
+	// the scale from the units of the config to the units of the frame's inputs
+	scale := 1.
 	switch cfg.Type {
 	case RevoluteJoint:
-		return NewRotationalFrame(cfg.ID, cfg.Axis.ParseConfig(),
+		frame, err = NewRotationalFrame(cfg.ID, cfg.Axis.ParseConfig(),
 			Limit{Min: utils.DegToRad(cfg.Min), Max: utils.DegToRad(cfg.Max)})
+		scale = utils.DegToRad(1)
 	case PrismaticJoint:
-		return NewTranslationalFrame(cfg.ID, r3.Vector(cfg.Axis),
+		frame, err = NewTranslationalFrame(cfg.ID, r3.Vector(cfg.Axis),
 			Limit{Min: cfg.Min, Max: cfg.Max})
 	default:
 		return nil, NewUnsupportedJointTypeError(cfg.Type)
 	}
+	if err != nil {
+		return nil, err
+	}
+	return frame, setDynamicLimit(frame, cfg.MaxVel, cfg.MaxAcc, cfg.MaxJerk, scale)
+}
+
+// setDynamicLimit sets the dynamic limit of a frame with one degree of freedom from the limits in a config, which are scaled into the
+// units of the frame's inputs.
+func setDynamicLimit(frame Frame, vel, acc, jerk, scale float64) error {
+	if vel < 0 || acc < 0 || jerk < 0 {
+		return errors.Errorf("dynamic limits of %s must not be negative", frame.Name())
+	}
+	if vel == 0 && acc == 0 && jerk == 0 {
+		return nil
+	}
+	limit := DynamicLimit{Velocity: vel * scale, Acceleration: acc * scale, Jerk: jerk * scale}
+	switch f := frame.(type) {
+	case *rotationalFrame:
+		f.dynamicLimits = []DynamicLimit{limit}
+	case *translationalFrame:
+		f.dynamicLimits = []DynamicLimit{limit}
+	default:
+		return errors.Errorf("cannot set dynamic limits of %T", frame)
+	}
+	return nil
 }
 
 // ToDHFrames converts a DHParamConfig into a joint frame and a link frame.
@@ -122,6 +159,9 @@ func (cfg *DHParamConfig) ToDHFrames() (Frame, Frame, error) {
 	if err != nil {
 		return nil, nil, err
 	}
+	if err := setDynamicLimit(rFrame, cfg.MaxVel, cfg.MaxAcc, cfg.MaxJerk, utils.DegToRad(1)); err != nil {
+		return nil, nil, err
+	}
 
 	// Link part of DH param
 	linkID := cfg.ID
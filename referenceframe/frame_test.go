@@ -88,8 +88,9 @@ func TestPrismaticFrame(t *testing.T) {
 }
 
 func TestRevoluteFrame(t *testing.T) {
-	axis := r3.Vector{1, 0, 0}                                                                // axis of rotation is x axis
-	frame := &rotationalFrame{&baseFrame{"test", []Limit{{-math.Pi / 2, math.Pi / 2}}}, axis} // limits between -90 and 90 degrees
+	axis := r3.Vector{1, 0, 0} // axis of rotation is x axis
+	// limits between -90 and 90 degrees
+	frame := &rotationalFrame{&baseFrame{name: "test", limits: []Limit{{-math.Pi / 2, math.Pi / 2}}}, axis}
 	// expected output
 	expPose := spatial.NewPoseFromOrientation(&spatial.R4AA{math.Pi / 4, 1, 0, 0}) // 45 degrees
 	// get expected transform back
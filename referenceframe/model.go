@@ -17,7 +17,7 @@ import (
 )
 
 // errUnsupportedFileType is returned if we try to build a model from an inproper extension.
-var errUnsupportedFileType = errors.New("only files with .json and .urdf file extensions are supported")
+var errUnsupportedFileType = errors.New("only files with .json, .urdf, .xacro and .sdf file extensions are supported")
 
 // A Model represents a frame that can change its name, and can return itself as a ModelConfig struct.
 type Model interface {
@@ -167,6 +167,15 @@ func (m *SimpleModel) DoF() []Limit {
 	return limits
 }
 
+// DynamicLimits returns the velocity, acceleration and jerk limits of each degree of freedom within a model.
+func (m *SimpleModel) DynamicLimits() []DynamicLimit {
+	limits := make([]DynamicLimit, 0, len(m.OrdTransforms))
+	for _, transform := range m.OrdTransforms {
+		limits = append(limits, FrameDynamicLimits(transform)...)
+	}
+	return limits
+}
+
 // MarshalJSON serializes a Model.
 func (m *SimpleModel) MarshalJSON() ([]byte, error) {
 	return json.Marshal(m.modelConfig)
@@ -219,7 +228,7 @@ func (m *SimpleModel) inputsToFrames(inputs []Input, collectAll bool) ([]*static
 		composedTransformation = spatialmath.Compose(composedTransformation, pose)
 	}
 	// TODO(rb) as written this will return one too many frames, no need to return zeroth frame
-	poses = append(poses, &staticFrame{&baseFrame{"", []Limit{}}, composedTransformation, nil})
+	poses = append(poses, &staticFrame{&baseFrame{name: "", limits: []Limit{}}, composedTransformation, nil})
 	return poses, err
 }
 
@@ -280,6 +289,10 @@ func ModelFromPath(modelPath, name string) (Model, error) {
 	switch {
 	case strings.HasSuffix(modelPath, ".urdf"):
 		model, err = ParseURDFFile(modelPath, name)
+	case strings.HasSuffix(modelPath, ".xacro"):
+		model, err = ParseXacroFile(modelPath, name, nil)
+	case strings.HasSuffix(modelPath, ".sdf"):
+		model, err = ParseSDFFile(modelPath, name)
 	case strings.HasSuffix(modelPath, ".json"):
 		model, err = ParseModelJSONFile(modelPath, name)
 	default:
@@ -1,10 +1,13 @@
 package referenceframe
 
 import (
+	"encoding/json"
+	"math"
 	"testing"
 
 	"go.viam.com/test"
 
+	spatial "go.viam.com/rdk/spatialmath"
 	"go.viam.com/rdk/utils"
 )
 
@@ -64,3 +67,60 @@ func TestParseJSONFile(t *testing.T) {
 		})
 	}
 }
+
+func TestDynamicLimits(t *testing.T) {
+	model, err := ParseModelJSONFile(utils.ResolveFile("components/arm/universalrobots/ur5e.json"), "")
+	test.That(t, err, test.ShouldBeNil)
+	limits := FrameDynamicLimits(model)
+	test.That(t, len(limits), test.ShouldEqual, len(model.DoF()))
+	for _, limit := range limits {
+		test.That(t, limit, test.ShouldResemble, DynamicLimit{Velocity: math.Pi})
+	}
+
+	joint := JointConfig{
+		ID:      "slide",
+		Type:    PrismaticJoint,
+		Axis:    spatial.AxisConfig{1, 0, 0},
+		Max:     100,
+		MaxVel:  50,
+		MaxAcc:  200,
+		MaxJerk: 1000,
+	}
+	f, err := joint.ToFrame()
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, FrameDynamicLimits(f), test.ShouldResemble, []DynamicLimit{{Velocity: 50, Acceleration: 200, Jerk: 1000}})
+	data, err := f.MarshalJSON()
+	test.That(t, err, test.ShouldBeNil)
+	marshaled := JointConfig{}
+	test.That(t, json.Unmarshal(data, &marshaled), test.ShouldBeNil)
+	test.That(t, marshaled, test.ShouldResemble, joint)
+
+	// frames with no configured limits are unlimited
+	joint = JointConfig{ID: "spin", Type: RevoluteJoint, Axis: spatial.AxisConfig{0, 0, 1}, Max: 90, Min: -90}
+	f, err = joint.ToFrame()
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, FrameDynamicLimits(f), test.ShouldResemble, []DynamicLimit{{}})
+	static, err := NewStaticFrame("static", spatial.NewZeroPose())
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, FrameDynamicLimits(static), test.ShouldBeEmpty)
+
+	joint.MaxVel = -1
+	_, err = joint.ToFrame()
+	test.That(t, err, test.ShouldNotBeNil)
+
+	dh := DHParamConfig{ID: "dh", Max: 90, Min: -90, MaxVel: 90}
+	dhJoint, _, err := dh.ToDHFrames()
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, FrameDynamicLimits(dhJoint)[0].Velocity, test.ShouldAlmostEqual, math.Pi/2)
+
+	// URDF velocity limits are in radians or meters per second
+	mc, err := ConvertURDFToConfig([]byte(`<robot name="limited">
+		<link name="base"/><link name="arm"/><link name="carriage"/>
+		<joint name="spin" type="continuous"><parent link="base"/><child link="arm"/><limit velocity="3.14159265"/></joint>
+		<joint name="slide" type="prismatic"><parent link="arm"/><child link="carriage"/><axis xyz="1 0 0"/>
+			<limit lower="0" upper="1" velocity="0.5"/></joint>
+	</robot>`), "")
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, mc.Joints[0].MaxVel, test.ShouldAlmostEqual, 180, 1e-6)
+	test.That(t, mc.Joints[1].MaxVel, test.ShouldAlmostEqual, 500)
+}
@@ -0,0 +1,422 @@
+package referenceframe
+
+import (
+	"encoding/xml"
+	"math"
+	"os"
+
+	"github.com/golang/geo/r3"
+	"github.com/pkg/errors"
+
+	spatial "go.viam.com/rdk/spatialmath"
+	"go.viam.com/rdk/utils"
+)
+
+// SDF limits at or beyond this magnitude, which is the default limit of SDF joints, are treated as no limit at all.
+const sdfUnlimited = 1e16
+
+// the name SDF gives to the frame of the model itself.
+const sdfModelFrame = "__model__"
+
+// SDFConfig represents the supported fields of a Simulation Description Format (SDF) file, which describes a single model.
+type SDFConfig struct {
+	XMLName xml.Name   `xml:"sdf"`
+	Model   []SDFModel `xml:"model"`
+}
+
+// SDFModel is a struct which details the XML used in an SDF model element.
+type SDFModel struct {
+	Name   string     `xml:"name,attr"`
+	Links  []SDFLink  `xml:"link"`
+	Joints []SDFJoint `xml:"joint"`
+}
+
+// SDFPose is a struct which details the XML used in an SDF pose element. A missing pose is the identity pose.
+type SDFPose struct {
+	RelativeTo string `xml:"relative_to,attr"` // the frame the pose is relative to, which defaults to that of the parent element
+	Degrees    bool   `xml:"degrees,attr"`     // whether the angles are in degrees rather than radians
+	Value      string `xml:",chardata"`        // "x y z roll pitch yaw" format, in meters and fixed frame angles
+}
+
+// SDFLink is a struct which details the XML used in an SDF link element.
+type SDFLink struct {
+	Name      string         `xml:"name,attr"`
+	Pose      *SDFPose       `xml:"pose"`
+	Collision []SDFCollision `xml:"collision"`
+}
+
+// SDFCollision is a struct which details the XML used in an SDF collision element.
+type SDFCollision struct {
+	Name     string      `xml:"name,attr"`
+	Pose     *SDFPose    `xml:"pose"`
+	Geometry SDFGeometry `xml:"geometry"`
+}
+
+// SDFGeometry is a struct which details the XML used in an SDF geometry element. Exactly one of its shapes is set.
+type SDFGeometry struct {
+	Box *struct {
+		Size string `xml:"size"` // "x y z" format, in meters
+	} `xml:"box"`
+	Sphere *struct {
+		Radius float64 `xml:"radius"` // in meters
+	} `xml:"sphere"`
+	Cylinder *struct {
+		Radius float64 `xml:"radius"` // in meters
+		Length float64 `xml:"length"` // in meters
+	} `xml:"cylinder"`
+	Capsule *struct {
+		Radius float64 `xml:"radius"` // in meters
+		Length float64 `xml:"length"` // the length of the cylinder between the hemispheres, in meters
+	} `xml:"capsule"`
+}
+
+// SDFJoint is a struct which details the XML used in an SDF joint element.
+type SDFJoint struct {
+	Name   string   `xml:"name,attr"`
+	Type   string   `xml:"type,attr"`
+	Parent string   `xml:"parent"`
+	Child  string   `xml:"child"`
+	Pose   *SDFPose `xml:"pose"`
+	Axis   *struct {
+		XYZ struct {
+			ExpressedIn string `xml:"expressed_in,attr"` // the frame the axis is in, which defaults to that of the joint
+			Value       string `xml:",chardata"`         // "x y z" format, unitless
+		} `xml:"xyz"`
+		UseParentModelFrame bool `xml:"use_parent_model_frame"` // SDF 1.6 and older express axes in the model frame if this is set
+		Limit               *struct {
+			Lower    *float64 `xml:"lower"`    // translation limits are in meters, revolute limits are in radians
+			Upper    *float64 `xml:"upper"`    // translation limits are in meters, revolute limits are in radians
+			Velocity *float64 `xml:"velocity"` // in meters or radians per second, unlimited if negative
+		} `xml:"limit"`
+	} `xml:"axis"`
+}
+
+// ParseSDFFile will read a given file and parse the model described by its SDF XML data into an equivalent Model.
+func ParseSDFFile(filename, modelName string) (Model, error) {
+	//nolint:gosec
+	xmlData, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to read SDF file")
+	}
+
+	mc, err := ConvertSDFToConfig(xmlData, modelName)
+	if err != nil {
+		return nil, err
+	}
+
+	return mc.ParseConfig(modelName)
+}
+
+// ConvertSDFToConfig will transfer the first model of the given SDF XML data into an equivalent ModelConfig.
+//
+// SDF places every link and joint in the frame of the model, or of another element they are relative to, while a ModelConfig places
+// each relative to its parent. SDF joints also turn about their own frame, which is fixed to the child link, rather than the frame of the
+// parent. Each movable joint so becomes a static link from its parent link to its origin, named after the joint with an "_origin"
+// suffix, followed by the joint itself, and then its child link. Fixed joints attach their child link directly to the parent.
+func ConvertSDFToConfig(xmlData []byte, modelName string) (*ModelConfig, error) {
+	if len(xmlData) == 0 {
+		return nil, ErrNoModelInformation
+	}
+	sdf := &SDFConfig{}
+	if err := xml.Unmarshal(xmlData, sdf); err != nil {
+		return nil, errors.Wrap(err, "Failed to convert SDF data to equivalent SDFConfig struct")
+	}
+	if len(sdf.Model) == 0 {
+		return nil, ErrNoModelInformation
+	}
+	model := sdf.Model[0]
+	if modelName == "" {
+		modelName = model.Name
+	}
+
+	frames, err := newSDFFrames(model)
+	if err != nil {
+		return nil, err
+	}
+	// the parent of each link, which is the world for links which are not the child of any joint
+	linkParents := map[string]string{}
+	for _, joint := range model.Joints {
+		if joint.Name == World {
+			return nil, errors.New("Joints with the name 'world' are not supported by config parsers")
+		}
+		if _, ok := frames.links[joint.Child]; !ok {
+			return nil, errors.Errorf("joint %q has unknown child link %q", joint.Name, joint.Child)
+		}
+		if _, ok := linkParents[joint.Child]; ok {
+			return nil, errors.Errorf("link %q is the child of more than one joint", joint.Child)
+		}
+		parent := joint.Parent
+		if parent == "" {
+			parent = World
+		}
+		if joint.Type == FixedJoint {
+			linkParents[joint.Child] = parent
+		} else {
+			linkParents[joint.Child] = joint.Name
+		}
+	}
+
+	mc := &ModelConfig{Name: modelName, KinParamType: "SVA"}
+	for _, joint := range model.Joints {
+		if joint.Type == FixedJoint {
+			continue
+		}
+		jointCfg, err := frames.jointConfig(joint)
+		if err != nil {
+			return nil, err
+		}
+		jointPose, err := frames.pose(joint.Name)
+		if err != nil {
+			return nil, err
+		}
+		parentPose, err := frames.pose(jointCfg.Parent)
+		if err != nil {
+			return nil, err
+		}
+		originPose := spatial.Compose(spatial.PoseInverse(parentPose), jointPose)
+		origin, err := newStaticLinkConfig(joint.Name+"_origin", jointCfg.Parent, originPose)
+		if err != nil {
+			return nil, err
+		}
+		jointCfg.Parent = origin.ID
+		mc.Links = append(mc.Links, *origin)
+		mc.Joints = append(mc.Joints, *jointCfg)
+	}
+
+	for _, link := range model.Links {
+		parent, ok := linkParents[link.Name]
+		if !ok {
+			parent = World
+		}
+		parentPose, err := frames.pose(parent)
+		if err != nil {
+			return nil, err
+		}
+		linkPose, err := frames.pose(link.Name)
+		if err != nil {
+			return nil, err
+		}
+		relativePose := spatial.Compose(spatial.PoseInverse(parentPose), linkPose)
+		linkCfg, err := newStaticLinkConfig(link.Name, parent, relativePose)
+		if err != nil {
+			return nil, err
+		}
+		if len(link.Collision) > 0 {
+			// the geometry of a link is placed in the frame of its parent, rather than the frame of the link as in SDF
+			if linkCfg.Geometry, err = createConfigFromSDFCollision(link.Collision[0], link.Name, relativePose); err != nil {
+				return nil, err
+			}
+		}
+		mc.Links = append(mc.Links, *linkCfg)
+	}
+	return mc, nil
+}
+
+// sdfFrames resolves the poses of the links and joints of an SDF model in the frame of the model.
+type sdfFrames struct {
+	links    map[string]SDFLink
+	joints   map[string]SDFJoint
+	resolved map[string]spatial.Pose
+	visiting map[string]bool
+}
+
+func newSDFFrames(model SDFModel) (*sdfFrames, error) {
+	frames := &sdfFrames{
+		links:    map[string]SDFLink{},
+		joints:   map[string]SDFJoint{},
+		resolved: map[string]spatial.Pose{World: spatial.NewZeroPose(), sdfModelFrame: spatial.NewZeroPose()},
+		visiting: map[string]bool{},
+	}
+	for _, link := range model.Links {
+		if link.Name == World {
+			return nil, errors.New("Links with the name 'world' are not supported by config parsers")
+		}
+		frames.links[link.Name] = link
+	}
+	for _, joint := range model.Joints {
+		if _, ok := frames.links[joint.Name]; ok {
+			return nil, errors.Errorf("joint %q has the same name as a link", joint.Name)
+		}
+		frames.joints[joint.Name] = joint
+	}
+	return frames, nil
+}
+
+// pose returns the pose of a link or joint in the frame of the model.
+func (f *sdfFrames) pose(name string) (spatial.Pose, error) {
+	if name == "" {
+		name = sdfModelFrame
+	}
+	if pose, ok := f.resolved[name]; ok {
+		return pose, nil
+	}
+	if f.visiting[name] {
+		return nil, errors.Errorf("the pose of %q is relative to itself", name)
+	}
+	f.visiting[name] = true
+
+	var sdfPose *SDFPose
+	relativeTo := sdfModelFrame
+	if link, ok := f.links[name]; ok {
+		sdfPose = link.Pose
+	} else if joint, ok := f.joints[name]; ok {
+		sdfPose = joint.Pose
+		relativeTo = joint.Child
+	} else {
+		return nil, errors.Errorf("unknown frame %q", name)
+	}
+	if sdfPose != nil && sdfPose.RelativeTo != "" {
+		relativeTo = sdfPose.RelativeTo
+	}
+	base, err := f.pose(relativeTo)
+	if err != nil {
+		return nil, err
+	}
+	local, err := sdfPose.parse()
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid pose of %q", name)
+	}
+	f.resolved[name] = spatial.Compose(base, local)
+	return f.resolved[name], nil
+}
+
+// jointConfig converts the type, axis and limits of an SDF joint. The parent of the returned config is the parent link of the joint.
+func (f *sdfFrames) jointConfig(joint SDFJoint) (*JointConfig, error) {
+	parent := joint.Parent
+	if parent == "" {
+		parent = World
+	}
+	if _, ok := f.links[parent]; !ok && parent != World {
+		return nil, errors.Errorf("joint %q has unknown parent link %q", joint.Name, parent)
+	}
+	jointCfg := &JointConfig{ID: joint.Name, Parent: parent, Type: joint.Type}
+
+	// A joint without an axis rotates or translates about X
+	axis := r3.Vector{X: 1}
+	if joint.Axis != nil && joint.Axis.XYZ.Value != "" {
+		xyz := convStringAttrToFloats(joint.Axis.XYZ.Value)
+		if len(xyz) != 3 {
+			return nil, errors.Errorf("joint %q has an invalid axis %q", joint.Name, joint.Axis.XYZ.Value)
+		}
+		axis = r3.Vector{X: xyz[0], Y: xyz[1], Z: xyz[2]}
+		expressedIn := joint.Name
+		if joint.Axis.XYZ.ExpressedIn != "" {
+			expressedIn = joint.Axis.XYZ.ExpressedIn
+		} else if joint.Axis.UseParentModelFrame {
+			expressedIn = sdfModelFrame
+		}
+		if expressedIn != joint.Name {
+			from, err := f.pose(expressedIn)
+			if err != nil {
+				return nil, err
+			}
+			to, err := f.pose(joint.Name)
+			if err != nil {
+				return nil, err
+			}
+			rotation := spatial.NewPoseFromOrientation(spatial.Compose(spatial.PoseInverse(to), from).Orientation())
+			axis = spatial.Compose(rotation, spatial.NewPoseFromPoint(axis)).Point()
+		}
+	}
+	jointCfg.Axis = spatial.AxisConfig{axis.X, axis.Y, axis.Z}
+
+	lower, upper, velocity := math.Inf(-1), math.Inf(1), 0.
+	if joint.Axis != nil && joint.Axis.Limit != nil {
+		if l := joint.Axis.Limit.Lower; l != nil && *l > -sdfUnlimited {
+			lower = *l
+		}
+		if u := joint.Axis.Limit.Upper; u != nil && *u < sdfUnlimited {
+			upper = *u
+		}
+		if v := joint.Axis.Limit.Velocity; v != nil && *v > 0 {
+			velocity = *v
+		}
+	}
+	switch joint.Type {
+	case ContinuousJoint:
+		jointCfg.Type = RevoluteJoint // Currently, we treate a continuous joint as a special case of a revolute joint
+		jointCfg.Min, jointCfg.Max = math.Inf(-1), math.Inf(1)
+		jointCfg.MaxVel = utils.RadToDeg(velocity)
+	case RevoluteJoint:
+		jointCfg.Min, jointCfg.Max = utils.RadToDeg(lower), utils.RadToDeg(upper)
+		jointCfg.MaxVel = utils.RadToDeg(velocity)
+	case PrismaticJoint:
+		jointCfg.Min, jointCfg.Max = metersToMM(lower), metersToMM(upper)
+		jointCfg.MaxVel = metersToMM(velocity)
+	default:
+		return nil, NewUnsupportedJointTypeError(joint.Type)
+	}
+	return jointCfg, nil
+}
+
+// parse returns the pose, in mm, described by a pose element, relative to the frame it is relative to.
+func (p *SDFPose) parse() (spatial.Pose, error) {
+	if p == nil || p.Value == "" {
+		return spatial.NewZeroPose(), nil
+	}
+	values := convStringAttrToFloats(p.Value)
+	if len(values) != 6 {
+		return nil, errors.Errorf("pose %q does not have six values", p.Value)
+	}
+	for _, value := range values {
+		if math.IsNaN(value) {
+			return nil, errors.Errorf("pose %q is not numeric", p.Value)
+		}
+	}
+	ea := &spatial.EulerAngles{Roll: values[3], Pitch: values[4], Yaw: values[5]}
+	if p.Degrees {
+		ea = &spatial.EulerAngles{Roll: utils.DegToRad(values[3]), Pitch: utils.DegToRad(values[4]), Yaw: utils.DegToRad(values[5])}
+	}
+	return spatial.NewPose(r3.Vector{X: metersToMM(values[0]), Y: metersToMM(values[1]), Z: metersToMM(values[2])}, ea), nil
+}
+
+// newStaticLinkConfig returns a LinkConfig with the given pose relative to its parent.
+func newStaticLinkConfig(id, parent string, pose spatial.Pose) (*LinkConfig, error) {
+	orientation, err := spatial.NewOrientationConfig(pose.Orientation())
+	if err != nil {
+		return nil, err
+	}
+	return &LinkConfig{ID: id, Parent: parent, Translation: pose.Point(), Orientation: orientation}, nil
+}
+
+// createConfigFromSDFCollision creates a geometry config from an SDF collision element, whose pose is relative to its link, which has
+// the given pose. Cylinders are read as the capsules which enclose them, as capsules are the closest geometry supported.
+func createConfigFromSDFCollision(collision SDFCollision, linkName string, linkPose spatial.Pose) (*spatial.GeometryConfig, error) {
+	if collision.Pose != nil && collision.Pose.RelativeTo != "" && collision.Pose.RelativeTo != linkName {
+		return nil, errors.Errorf("collision of link %q must be relative to the link", linkName)
+	}
+	collisionPose, err := collision.Pose.parse()
+	if err != nil {
+		return nil, err
+	}
+	offset := spatial.Compose(linkPose, collisionPose)
+	orientation, err := spatial.NewOrientationConfig(offset.Orientation())
+	if err != nil {
+		return nil, err
+	}
+	geoCfg := &spatial.GeometryConfig{TranslationOffset: offset.Point(), OrientationOffset: *orientation}
+
+	switch geometry := collision.Geometry; {
+	case geometry.Box != nil:
+		boxDims := convStringAttrToFloats(geometry.Box.Size)
+		if len(boxDims) != 3 {
+			return nil, errors.Errorf("invalid box size %q for [ %v ] link", geometry.Box.Size, linkName)
+		}
+		geoCfg.Type, geoCfg.Label = "box", "box"
+		geoCfg.X, geoCfg.Y, geoCfg.Z = metersToMM(boxDims[0]), metersToMM(boxDims[1]), metersToMM(boxDims[2])
+	case geometry.Sphere != nil:
+		geoCfg.Type, geoCfg.Label = "sphere", "sphere"
+		geoCfg.R = metersToMM(geometry.Sphere.Radius)
+	case geometry.Cylinder != nil:
+		geoCfg.Type, geoCfg.Label = "capsule", "capsule"
+		geoCfg.R = metersToMM(geometry.Cylinder.Radius)
+		geoCfg.L = metersToMM(geometry.Cylinder.Length) + 2*geoCfg.R
+	case geometry.Capsule != nil:
+		geoCfg.Type, geoCfg.Label = "capsule", "capsule"
+		geoCfg.R = metersToMM(geometry.Capsule.Radius)
+		geoCfg.L = metersToMM(geometry.Capsule.Length) + 2*geoCfg.R
+	default:
+		return nil, errors.Errorf("Unsupported collision geometry type detected for [ %v ] link", linkName)
+	}
+	return geoCfg, nil
+}
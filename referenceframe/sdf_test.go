@@ -0,0 +1,87 @@
+package referenceframe
+
+import (
+	"math"
+	"testing"
+
+	"github.com/golang/geo/r3"
+	"go.viam.com/test"
+
+	spatial "go.viam.com/rdk/spatialmath"
+	"go.viam.com/rdk/utils"
+)
+
+func TestParseSDFFile(t *testing.T) {
+	model, err := ParseSDFFile(utils.ResolveFile("referenceframe/testurdf/two_joint_arm.sdf"), "")
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, model.Name(), test.ShouldEqual, "two_joint_arm")
+	test.That(t, model.DoF(), test.ShouldHaveLength, 2)
+	test.That(t, model.DoF()[1].Max, test.ShouldAlmostEqual, math.Pi/2, 1e-4)
+
+	for _, tc := range []struct {
+		inputs   []float64
+		expected spatial.Pose
+	}{
+		{[]float64{0, 0}, spatial.NewPoseFromPoint(r3.Vector{X: 800, Y: 0, Z: 100})},
+		{
+			[]float64{math.Pi / 2, 0},
+			spatial.NewPose(r3.Vector{X: 0, Y: 800, Z: 100}, &spatial.OrientationVector{OZ: 1, Theta: math.Pi / 2}),
+		},
+		{
+			[]float64{0, math.Pi / 2},
+			spatial.NewPose(r3.Vector{X: 500, Y: 0, Z: -200}, &spatial.EulerAngles{Pitch: math.Pi / 2}),
+		},
+	} {
+		pose, err := model.Transform(FloatsToInputs(tc.inputs))
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, spatial.PoseAlmostEqualEps(pose, tc.expected, 1e-6), test.ShouldBeTrue)
+	}
+
+	geometries, err := model.Geometries(FloatsToInputs([]float64{0, 0}))
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, geometries.Geometries(), test.ShouldHaveLength, 2)
+	base, err := spatial.NewCapsule(spatial.NewPoseFromPoint(r3.Vector{Z: 50}), 100, 300, "")
+	test.That(t, err, test.ShouldBeNil)
+	forearm, err := spatial.NewBox(
+		spatial.NewPose(r3.Vector{X: 650, Z: 100}, &spatial.EulerAngles{Pitch: math.Pi / 2}),
+		r3.Vector{X: 50, Y: 50, Z: 300},
+		"",
+	)
+	test.That(t, err, test.ShouldBeNil)
+	for _, expected := range []spatial.Geometry{base, forearm} {
+		found := false
+		for _, geometry := range geometries.Geometries() {
+			found = found || geometry.AlmostEqual(expected)
+		}
+		test.That(t, found, test.ShouldBeTrue)
+	}
+
+	model, err = ModelFromPath(utils.ResolveFile("referenceframe/testurdf/two_joint_arm.sdf"), "foo")
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, model.Name(), test.ShouldEqual, "foo")
+}
+
+func TestConvertSDFToConfig(t *testing.T) {
+	for _, sdf := range []string{
+		``,
+		`<sdf version="1.8"></sdf>`,
+		`<sdf version="1.8"><model name="m"><link name="a"><pose relative_to="b"/></link>` +
+			`<link name="b"><pose relative_to="a"/></link></model></sdf>`,
+		`<sdf version="1.8"><model name="m"><link name="a"/><joint name="j" type="revolute"><parent>a</parent>` +
+			`<child>missing</child></joint></model></sdf>`,
+		`<sdf version="1.8"><model name="m"><link name="a"/><link name="b"/><joint name="j" type="ball"><parent>a</parent>` +
+			`<child>b</child></joint></model></sdf>`,
+	} {
+		_, err := ConvertSDFToConfig([]byte(sdf), "")
+		test.That(t, err, test.ShouldNotBeNil)
+	}
+
+	// a joint without limits is unlimited, and one without an axis turns about X
+	mc, err := ConvertSDFToConfig([]byte(`<sdf version="1.8"><model name="m"><link name="a"/><link name="b"/>`+
+		`<joint name="j" type="revolute"><parent>a</parent><child>b</child></joint></model></sdf>`), "")
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, mc.Joints, test.ShouldHaveLength, 1)
+	test.That(t, mc.Joints[0].Axis, test.ShouldResemble, spatial.AxisConfig{1, 0, 0})
+	test.That(t, math.IsInf(mc.Joints[0].Min, -1), test.ShouldBeTrue)
+	test.That(t, math.IsInf(mc.Joints[0].Max, 1), test.ShouldBeTrue)
+}
@@ -0,0 +1,324 @@
+package referenceframe
+
+import (
+	"math"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Waypoints whose directions in and out differ by less than this are treated as being on a straight line, so are passed through
+// without stopping.
+const collinearTolerance = 1e-9
+
+// TrajectoryPoint is a point along a Trajectory: the inputs it reaches at a time from its start, and the velocities of those inputs, in
+// input units per second.
+type TrajectoryPoint struct {
+	Time       time.Duration
+	Inputs     []Input
+	Velocities []float64
+}
+
+// Trajectory is a time parameterized path through a series of waypoints in input space, which moves along straight lines between them
+// within the dynamic limits of each input. It comes to rest at each waypoint where the path turns, and passes straight through the
+// others, since no change of direction is possible at a finite acceleration without stopping.
+//
+// Each straight run is timed with a profile which accelerates to the highest velocity the run allows and decelerates back to rest. With
+// a jerk limit the profile is an S-curve, without one it is trapezoidal, and if inputs have no acceleration limit it moves at a constant
+// velocity.
+type Trajectory struct {
+	runs      []trajectoryRun
+	waypoints []TrajectoryPoint
+}
+
+// trajectoryRun is a straight part of a trajectory, which starts and ends at rest.
+type trajectoryRun struct {
+	start     float64 // in seconds from the start of the trajectory
+	from      []Input
+	direction []float64 // a unit vector in input space
+	profile   *pathProfile
+}
+
+// NewTrajectory time parameterizes a path through waypoints with the given limits for each input. Inputs with no velocity limit may move
+// as fast as the others allow, but each part of the path must move at least one input with a velocity limit.
+func NewTrajectory(limits []DynamicLimit, waypoints [][]Input) (*Trajectory, error) {
+	if len(waypoints) == 0 {
+		return nil, errors.New("cannot make a trajectory with no waypoints")
+	}
+	for _, waypoint := range waypoints {
+		if len(waypoint) != len(limits) {
+			return nil, NewIncorrectInputLengthError(len(waypoint), len(limits))
+		}
+	}
+
+	tr := &Trajectory{}
+	elapsed := 0.
+	runStart := 0
+	for runStart < len(waypoints)-1 {
+		// extend the run through every waypoint in the same direction
+		direction, length := inputDirection(waypoints[runStart], waypoints[runStart+1])
+		runEnd := runStart + 1
+		for ; runEnd < len(waypoints)-1; runEnd++ {
+			next, nextLength := inputDirection(waypoints[runEnd], waypoints[runEnd+1])
+			if nextLength > 0 && length > 0 && dot(direction, next) < 1-collinearTolerance {
+				break
+			}
+			if length == 0 {
+				direction = next
+			}
+			length += nextLength
+		}
+
+		run := trajectoryRun{start: elapsed, from: waypoints[runStart], direction: direction}
+		if length > 0 {
+			var err error
+			if run.profile, err = newPathProfile(length, pathLimit(limits, direction)); err != nil {
+				return nil, err
+			}
+		} else {
+			run.profile = &pathProfile{}
+		}
+		for i := runStart; i < runEnd; i++ {
+			// the time at which the run reaches each of its waypoints
+			_, offset := inputDirection(waypoints[runStart], waypoints[i])
+			t := run.profile.timeAt(offset)
+			tr.waypoints = append(tr.waypoints, run.point(elapsed+t, waypoints[i], t))
+		}
+		tr.runs = append(tr.runs, run)
+		elapsed += run.profile.duration
+		runStart = runEnd
+	}
+	last := waypoints[len(waypoints)-1]
+	tr.waypoints = append(tr.waypoints, TrajectoryPoint{
+		Time:       secondsToDuration(elapsed),
+		Inputs:     last,
+		Velocities: make([]float64, len(last)),
+	})
+	return tr, nil
+}
+
+// Duration returns how long the trajectory takes.
+func (tr *Trajectory) Duration() time.Duration {
+	return tr.waypoints[len(tr.waypoints)-1].Time
+}
+
+// Waypoints returns the waypoints the trajectory was made from, with the times they are reached.
+func (tr *Trajectory) Waypoints() []TrajectoryPoint {
+	return tr.waypoints
+}
+
+// At returns the point of the trajectory at a time from its start. Times outside of the trajectory are clamped to its start or end.
+func (tr *Trajectory) At(t time.Duration) TrajectoryPoint {
+	seconds := t.Seconds()
+	for _, run := range tr.runs {
+		if seconds < run.start+run.profile.duration {
+			local := math.Max(0, seconds-run.start)
+			dist, _ := run.profile.at(local)
+			inputs := make([]Input, len(run.from))
+			for i, from := range run.from {
+				inputs[i] = Input{from.Value + run.direction[i]*dist}
+			}
+			return run.point(math.Max(seconds, run.start), inputs, local)
+		}
+	}
+	return tr.waypoints[len(tr.waypoints)-1]
+}
+
+// Sample returns points spaced a step apart along the trajectory, from its start to its end.
+func (tr *Trajectory) Sample(step time.Duration) ([]TrajectoryPoint, error) {
+	if step <= 0 {
+		return nil, errors.New("trajectory sample step must be positive")
+	}
+	var points []TrajectoryPoint
+	for t := time.Duration(0); t < tr.Duration(); t += step {
+		points = append(points, tr.At(t))
+	}
+	return append(points, tr.At(tr.Duration())), nil
+}
+
+// point returns the point of the run at the given inputs, which it reaches a time from its start.
+func (run *trajectoryRun) point(elapsed float64, inputs []Input, t float64) TrajectoryPoint {
+	_, speed := run.profile.at(t)
+	velocities := make([]float64, len(run.direction))
+	for i, d := range run.direction {
+		velocities[i] = d * speed
+	}
+	return TrajectoryPoint{Time: secondsToDuration(elapsed), Inputs: inputs, Velocities: velocities}
+}
+
+// pathLimit returns the dynamic limits on moving along a direction in input space, which are set by whichever input reaches its limit
+// first. Limits which no input sets are infinite.
+func pathLimit(limits []DynamicLimit, direction []float64) DynamicLimit {
+	path := DynamicLimit{Velocity: math.Inf(1), Acceleration: math.Inf(1), Jerk: math.Inf(1)}
+	for i, d := range direction {
+		d = math.Abs(d)
+		if d == 0 {
+			continue
+		}
+		if limits[i].Velocity > 0 {
+			path.Velocity = math.Min(path.Velocity, limits[i].Velocity/d)
+		}
+		if limits[i].Acceleration > 0 {
+			path.Acceleration = math.Min(path.Acceleration, limits[i].Acceleration/d)
+		}
+		if limits[i].Jerk > 0 {
+			path.Jerk = math.Min(path.Jerk, limits[i].Jerk/d)
+		}
+	}
+	return path
+}
+
+// inputDirection returns the unit vector from one set of inputs to another, and the distance between them.
+func inputDirection(from, to []Input) ([]float64, float64) {
+	direction := make([]float64, len(from))
+	length := 0.
+	for i := range from {
+		direction[i] = to[i].Value - from[i].Value
+		length += direction[i] * direction[i]
+	}
+	length = math.Sqrt(length)
+	if length > 0 {
+		for i := range direction {
+			direction[i] /= length
+		}
+	}
+	return direction, length
+}
+
+func dot(a, b []float64) float64 {
+	total := 0.
+	for i := range a {
+		total += a[i] * b[i]
+	}
+	return total
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(math.Round(seconds * float64(time.Second)))
+}
+
+// pathProfile is the distance along a straight path over time, which is made of phases of constant jerk. It starts and ends at rest,
+// other than in a profile with no acceleration limit, which moves at a constant velocity throughout.
+type pathProfile struct {
+	length        float64
+	startVelocity float64
+	phases        []profilePhase
+	duration      float64
+}
+
+// profilePhase is a part of a profile during which jerk is constant. Acceleration is set at the start of each phase, which lets profiles
+// with no jerk limit change it instantly.
+type profilePhase struct {
+	duration     float64
+	acceleration float64
+	jerk         float64
+}
+
+// newPathProfile returns the fastest profile along a path of a given length within the given limits.
+func newPathProfile(length float64, limit DynamicLimit) (*pathProfile, error) {
+	vMax, aMax, jMax := limit.Velocity, limit.Acceleration, limit.Jerk
+	if math.IsInf(vMax, 1) {
+		return nil, errors.New("trajectory moves only inputs with no velocity limit")
+	}
+	profile := &pathProfile{length: length}
+	switch {
+	case math.IsInf(aMax, 1):
+		profile.startVelocity = vMax
+		profile.phases = []profilePhase{{duration: length / vMax}}
+	case math.IsInf(jMax, 1):
+		// trapezoidal, or triangular if the path is too short to reach full velocity
+		vPeak := math.Min(vMax, math.Sqrt(length*aMax))
+		accel := vPeak / aMax
+		profile.phases = []profilePhase{
+			{duration: accel, acceleration: aMax},
+			{duration: math.Max(0, (length-vPeak*accel)/vPeak)},
+			{duration: accel, acceleration: -aMax},
+		}
+	default:
+		// the time to reach a velocity from rest, and the distance moved in doing so and slowing back to rest is that velocity times it
+		accelTime := func(v float64) float64 {
+			if v >= aMax*aMax/jMax {
+				return v/aMax + aMax/jMax
+			}
+			return 2 * math.Sqrt(v/jMax)
+		}
+		vPeak := vMax
+		if vMax*accelTime(vMax) > length {
+			// the path is too short to reach full velocity, so find the highest which it can reach
+			lo, hi := 0., vMax
+			for i := 0; i < 100; i++ {
+				mid := (lo + hi) / 2
+				if mid*accelTime(mid) > length {
+					hi = mid
+				} else {
+					lo = mid
+				}
+			}
+			vPeak = lo
+		}
+		var accelPhases []profilePhase
+		if vPeak >= aMax*aMax/jMax {
+			ramp := aMax / jMax
+			accelPhases = []profilePhase{
+				{duration: ramp, jerk: jMax},
+				{duration: vPeak/aMax - ramp, acceleration: aMax},
+				{duration: ramp, acceleration: aMax, jerk: -jMax},
+			}
+		} else {
+			ramp := math.Sqrt(vPeak / jMax)
+			accelPhases = []profilePhase{
+				{duration: ramp, jerk: jMax},
+				{duration: ramp, acceleration: jMax * ramp, jerk: -jMax},
+			}
+		}
+		profile.phases = append(profile.phases, accelPhases...)
+		profile.phases = append(profile.phases, profilePhase{duration: math.Max(0, length/vPeak-accelTime(vPeak))})
+		for _, phase := range accelPhases {
+			profile.phases = append(profile.phases, profilePhase{
+				duration:     phase.duration,
+				acceleration: -phase.acceleration,
+				jerk:         -phase.jerk,
+			})
+		}
+	}
+	for _, phase := range profile.phases {
+		profile.duration += phase.duration
+	}
+	return profile, nil
+}
+
+// at returns the distance along the path, and the velocity along it, at a time from the start of the profile.
+func (p *pathProfile) at(t float64) (float64, float64) {
+	if t >= p.duration {
+		return p.length, 0
+	}
+	dist, v := 0., p.startVelocity
+	for _, phase := range p.phases {
+		dt := math.Min(t, phase.duration)
+		a, j := phase.acceleration, phase.jerk
+		dist += v*dt + a*dt*dt/2 + j*dt*dt*dt/6
+		v += a*dt + j*dt*dt/2
+		t -= dt
+		if t <= 0 {
+			break
+		}
+	}
+	return math.Min(dist, p.length), math.Max(v, 0)
+}
+
+// timeAt returns the time at which the profile reaches a distance along the path, which never decreases.
+func (p *pathProfile) timeAt(dist float64) float64 {
+	if dist <= 0 {
+		return 0
+	}
+	lo, hi := 0., p.duration
+	for i := 0; i < 100; i++ {
+		mid := (lo + hi) / 2
+		if d, _ := p.at(mid); d < dist {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return hi
+}
@@ -0,0 +1,119 @@
+package referenceframe
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"go.viam.com/test"
+)
+
+// testTrajectoryLimits checks that a trajectory ends at its last waypoint, and that sampled points along it are within its limits.
+func testTrajectoryLimits(t *testing.T, tr *Trajectory, limits []DynamicLimit, end []Input) {
+	t.Helper()
+	step := time.Millisecond
+	points, err := tr.Sample(step)
+	test.That(t, err, test.ShouldBeNil)
+	last := points[len(points)-1]
+	test.That(t, last.Time, test.ShouldEqual, tr.Duration())
+	for i, input := range end {
+		test.That(t, last.Inputs[i].Value, test.ShouldAlmostEqual, input.Value)
+		test.That(t, last.Velocities[i], test.ShouldEqual, 0)
+	}
+	for k, point := range points {
+		for i, limit := range limits {
+			test.That(t, math.Abs(point.Velocities[i]), test.ShouldBeLessThanOrEqualTo, limit.Velocity+1e-9)
+			if k > 0 && limit.Acceleration > 0 {
+				accel := (point.Velocities[i] - points[k-1].Velocities[i]) / (point.Time - points[k-1].Time).Seconds()
+				test.That(t, math.Abs(accel), test.ShouldBeLessThanOrEqualTo, limit.Acceleration*1.001)
+			}
+		}
+	}
+}
+
+func TestTrajectoryProfiles(t *testing.T) {
+	start, end := []Input{{0}}, []Input{{4}}
+	for _, tc := range []struct {
+		name     string
+		limit    DynamicLimit
+		end      []Input
+		duration time.Duration
+	}{
+		{"constant velocity", DynamicLimit{Velocity: 0.5}, end, 8 * time.Second},
+		// 1s to accelerate and decelerate, moving 0.5 each, then 3s at full velocity
+		{"trapezoidal", DynamicLimit{Velocity: 1, Acceleration: 1}, end, 5 * time.Second},
+		{"triangular", DynamicLimit{Velocity: 1, Acceleration: 1}, []Input{{0.25}}, time.Second},
+		// 1.5s to accelerate and decelerate, moving 0.75 each, then 2.5s at full velocity
+		{"s-curve", DynamicLimit{Velocity: 1, Acceleration: 1, Jerk: 2}, end, 5500 * time.Millisecond},
+		{"short s-curve", DynamicLimit{Velocity: 1, Acceleration: 1, Jerk: 2}, []Input{{0.1}}, 0},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			limits := []DynamicLimit{tc.limit}
+			tr, err := NewTrajectory(limits, [][]Input{start, tc.end})
+			test.That(t, err, test.ShouldBeNil)
+			if tc.duration != 0 {
+				test.That(t, tr.Duration(), test.ShouldEqual, tc.duration)
+			}
+			testTrajectoryLimits(t, tr, limits, tc.end)
+			waypoints := tr.Waypoints()
+			test.That(t, len(waypoints), test.ShouldEqual, 2)
+			test.That(t, waypoints[0].Time, test.ShouldEqual, 0)
+			test.That(t, waypoints[1].Time, test.ShouldEqual, tr.Duration())
+		})
+	}
+
+	tr, err := NewTrajectory([]DynamicLimit{{Velocity: 1, Acceleration: 1}}, [][]Input{start, end})
+	test.That(t, err, test.ShouldBeNil)
+	point := tr.At(500 * time.Millisecond)
+	test.That(t, point.Inputs[0].Value, test.ShouldAlmostEqual, 0.125)
+	test.That(t, point.Velocities[0], test.ShouldAlmostEqual, 0.5)
+	test.That(t, tr.At(-time.Second).Inputs, test.ShouldResemble, start)
+	test.That(t, tr.At(time.Minute).Inputs, test.ShouldResemble, end)
+}
+
+func TestTrajectoryWaypoints(t *testing.T) {
+	limits := []DynamicLimit{{Velocity: 1, Acceleration: 1}, {Velocity: 0.25, Acceleration: 1}}
+
+	// the path stops to turn the corner
+	corner := [][]Input{{{0}, {0}}, {{1}, {0}}, {{1}, {0.25}}}
+	tr, err := NewTrajectory(limits, corner)
+	test.That(t, err, test.ShouldBeNil)
+	waypoints := tr.Waypoints()
+	test.That(t, len(waypoints), test.ShouldEqual, 3)
+	test.That(t, waypoints[1].Time, test.ShouldEqual, 2*time.Second)
+	test.That(t, waypoints[1].Velocities, test.ShouldResemble, []float64{0, 0})
+	test.That(t, tr.Duration(), test.ShouldEqual, 2*time.Second+1250*time.Millisecond)
+	testTrajectoryLimits(t, tr, limits, corner[2])
+
+	// but passes straight through waypoints on a line, and duplicates
+	line := [][]Input{{{0}, {0}}, {{1}, {0}}, {{1}, {0}}, {{2}, {0}}}
+	tr, err = NewTrajectory(limits, line)
+	test.That(t, err, test.ShouldBeNil)
+	waypoints = tr.Waypoints()
+	test.That(t, len(waypoints), test.ShouldEqual, 4)
+	test.That(t, tr.Duration(), test.ShouldEqual, 3*time.Second)
+	test.That(t, waypoints[1].Time, test.ShouldEqual, 1500*time.Millisecond)
+	test.That(t, waypoints[1].Velocities[0], test.ShouldAlmostEqual, 1)
+	test.That(t, waypoints[2].Time, test.ShouldEqual, 1500*time.Millisecond)
+	testTrajectoryLimits(t, tr, limits, line[3])
+
+	// inputs moving together are slowed to the pace of whichever is closest to its limit
+	tr, err = NewTrajectory([]DynamicLimit{{Velocity: 1}, {Velocity: 0.25}}, [][]Input{{{0}, {0}}, {{2}, {1}}})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, tr.Duration(), test.ShouldEqual, 4*time.Second)
+	point := tr.At(time.Second)
+	test.That(t, point.Velocities[0], test.ShouldAlmostEqual, 0.5)
+	test.That(t, point.Velocities[1], test.ShouldAlmostEqual, 0.25)
+
+	// a single waypoint takes no time
+	tr, err = NewTrajectory(limits, corner[:1])
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, tr.Duration(), test.ShouldEqual, 0)
+
+	_, err = NewTrajectory(limits, nil)
+	test.That(t, err, test.ShouldNotBeNil)
+	_, err = NewTrajectory(limits, [][]Input{{{0}}})
+	test.That(t, err, test.ShouldNotBeNil)
+	_, err = NewTrajectory([]DynamicLimit{{}, {Velocity: 1}}, [][]Input{{{0}, {0}}, {{1}, {0}}})
+	test.That(t, err, test.ShouldNotBeNil)
+}
@@ -4,9 +4,11 @@ import (
 	"encoding/xml"
 	"math"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 
+	"github.com/edaniels/golog"
 	"github.com/golang/geo/r3"
 	"github.com/pkg/errors"
 
@@ -24,56 +26,64 @@ type URDFConfig struct {
 
 // URDFLink is a struct which details the XML used in a URDF link element.
 type URDFLink struct {
-	XMLName   xml.Name `xml:"link"`
-	Name      string   `xml:"name,attr"`
-	Collision []struct {
-		XMLName xml.Name `xml:"collision"`
-		Name    string   `xml:"name,attr"`
-		Origin  struct {
-			XMLName xml.Name `xml:"origin"`
-			RPY     string   `xml:"rpy,attr"` // Fixed frame angle "r p y" format, in radians
-			XYZ     string   `xml:"xyz,attr"` // "x y z" format, in meters
-		} `xml:"origin"`
-		Geometry struct {
-			XMLName xml.Name `xml:"geometry"`
-			Box     struct {
-				XMLName xml.Name `xml:"box"`
-				Size    string   `xml:"size,attr"` // "x y z" format, in meters
-			} `xml:"box"`
-			Sphere struct {
-				XMLName xml.Name `xml:"sphere"`
-				Radius  float64  `xml:"radius,attr"` // in meters
-			} `xml:"sphere"`
-		} `xml:"geometry"`
-	} `xml:"collision"`
+	XMLName   xml.Name        `xml:"link"`
+	Name      string          `xml:"name,attr"`
+	Collision []URDFCollision `xml:"collision"`
+}
+
+// URDFCollision is a struct which details the XML used in a URDF collision element.
+type URDFCollision struct {
+	XMLName  xml.Name     `xml:"collision"`
+	Name     string       `xml:"name,attr,omitempty"`
+	Origin   *URDFOrigin  `xml:"origin"`
+	Geometry URDFGeometry `xml:"geometry"`
+}
+
+// URDFOrigin is a struct which details the XML used in a URDF origin element. A missing origin is the identity pose.
+type URDFOrigin struct {
+	XMLName xml.Name `xml:"origin"`
+	RPY     string   `xml:"rpy,attr"` // Fixed frame angle "r p y" format, in radians
+	XYZ     string   `xml:"xyz,attr"` // "x y z" format, in meters
+}
+
+// URDFGeometry is a struct which details the XML used in a URDF geometry element. Exactly one of its shapes is set.
+type URDFGeometry struct {
+	XMLName xml.Name `xml:"geometry"`
+	Box     *struct {
+		Size string `xml:"size,attr"` // "x y z" format, in meters
+	} `xml:"box"`
+	Sphere *struct {
+		Radius float64 `xml:"radius,attr"` // in meters
+	} `xml:"sphere"`
+	Cylinder *struct {
+		Radius float64 `xml:"radius,attr"` // in meters
+		Length float64 `xml:"length,attr"` // in meters
+	} `xml:"cylinder"`
+	Mesh *struct {
+		Filename string `xml:"filename,attr"`        // relative to the URDF file, or a package:// or file:// URI
+		Scale    string `xml:"scale,attr,omitempty"` // "x y z" format, unitless
+	} `xml:"mesh"`
 }
 
 // URDFJoint is a struct which details the XML used in a URDF joint element.
 type URDFJoint struct {
-	XMLName xml.Name `xml:"joint"`
-	Name    string   `xml:"name,attr"`
-	Type    string   `xml:"type,attr"`
-	Origin  struct {
-		XMLName xml.Name `xml:"origin"`
-		RPY     string   `xml:"rpy,attr"` // Fixed frame angle "r p y" format, in radians
-		XYZ     string   `xml:"xyz,attr"` // "x y z" format, in meters
-	} `xml:"origin"`
-	Parent struct {
-		XMLName xml.Name `xml:"parent"`
-		Link    string   `xml:"link,attr"`
+	XMLName xml.Name    `xml:"joint"`
+	Name    string      `xml:"name,attr"`
+	Type    string      `xml:"type,attr"`
+	Origin  *URDFOrigin `xml:"origin"`
+	Parent  struct {
+		Link string `xml:"link,attr"`
 	} `xml:"parent"`
 	Child struct {
-		XMLName xml.Name `xml:"child"`
-		Link    string   `xml:"link,attr"`
+		Link string `xml:"link,attr"`
 	} `xml:"child"`
-	Axis struct {
-		XMLName xml.Name `xml:"axis"`
-		XYZ     string   `xml:"xyz,attr"` // "x y z" format, in meters
+	Axis *struct {
+		XYZ string `xml:"xyz,attr"` // "x y z" format, unitless
 	} `xml:"axis"`
-	Limit struct {
-		XMLName xml.Name `xml:"limit"`
-		Lower   float64  `xml:"lower,attr"` // translation limits are in meters, revolute limits are in radians
-		Upper   float64  `xml:"upper,attr"` // translation limits are in meters, revolute limits are in radians
+	Limit *struct {
+		Lower    float64 `xml:"lower,attr"`              // translation limits are in meters, revolute limits are in radians
+		Upper    float64 `xml:"upper,attr"`              // translation limits are in meters, revolute limits are in radians
+		Velocity float64 `xml:"velocity,attr,omitempty"` // in meters or radians per second, unlimited if zero
 	} `xml:"limit"`
 }
 
@@ -89,6 +99,9 @@ func ParseURDFFile(filename, modelName string) (Model, error) {
 	if err != nil {
 		return nil, err
 	}
+	if err := resolveMeshFiles(mc, filepath.Dir(filename)); err != nil {
+		return nil, err
+	}
 
 	return mc.ParseConfig(modelName)
 }
@@ -137,13 +150,24 @@ func ConvertURDFToConfig(xmlData []byte, modelName string) (*ModelConfig, error)
 		switch jointElem.Type {
 		case ContinuousJoint, RevoluteJoint, PrismaticJoint:
 			// Parse important details about each joint, including axes and limits
-			jointAxes := convStringAttrToFloats(jointElem.Axis.XYZ)
+			// A joint without an axis rotates or translates about X
+			jointAxes := []float64{1, 0, 0}
+			if jointElem.Axis != nil {
+				jointAxes = convStringAttrToFloats(jointElem.Axis.XYZ)
+				if len(jointAxes) != 3 {
+					return nil, errors.Errorf("joint %q has an invalid axis %q", jointElem.Name, jointElem.Axis.XYZ)
+				}
+			}
 			thisJoint := JointConfig{
 				ID:     jointElem.Name,
 				Type:   jointElem.Type,
 				Parent: jointElem.Parent.Link,
 				Axis:   spatial.AxisConfig{jointAxes[0], jointAxes[1], jointAxes[2]},
 			}
+			// A revolute or prismatic joint without limits has always been loaded as one that cannot move
+			if jointElem.Type != ContinuousJoint && jointElem.Limit == nil {
+				golog.Global().Warnf("%s joint %q has no limits, so its limits are zero", jointElem.Type, jointElem.Name)
+			}
 
 			// Slightly different limits handling for continuous, revolute, and prismatic joints
 			switch jointElem.Type {
@@ -151,49 +175,36 @@ func ConvertURDFToConfig(xmlData []byte, modelName string) (*ModelConfig, error)
 				thisJoint.Type = RevoluteJoint // Currently, we treate a continuous joint as a special case of a revolute joint
 				thisJoint.Min, thisJoint.Max = math.Inf(-1), math.Inf(1)
 			case PrismaticJoint:
-				thisJoint.Min, thisJoint.Max = metersToMM(jointElem.Limit.Lower), metersToMM(jointElem.Limit.Upper)
+				if jointElem.Limit != nil {
+					thisJoint.Min, thisJoint.Max = metersToMM(jointElem.Limit.Lower), metersToMM(jointElem.Limit.Upper)
+				}
 			case RevoluteJoint:
-				thisJoint.Min, thisJoint.Max = utils.RadToDeg(jointElem.Limit.Lower), utils.RadToDeg(jointElem.Limit.Upper)
+				if jointElem.Limit != nil {
+					thisJoint.Min, thisJoint.Max = utils.RadToDeg(jointElem.Limit.Lower), utils.RadToDeg(jointElem.Limit.Upper)
+				}
 			default:
 				return nil, err
 			}
+			// continuous joints may still have a limit on their velocity
+			if jointElem.Limit != nil {
+				if thisJoint.Type == PrismaticJoint {
+					thisJoint.MaxVel = metersToMM(jointElem.Limit.Velocity)
+				} else {
+					thisJoint.MaxVel = utils.RadToDeg(jointElem.Limit.Velocity)
+				}
+			}
 
 			mc.Joints = append(mc.Joints, thisJoint)
 
 			// Generate child link translation and orientation data, which is held by this joint per the URDF design
-			childXYZ := convStringAttrToFloats(jointElem.Origin.XYZ)
-			childRPY := convStringAttrToFloats(jointElem.Origin.RPY)
-			childEA := spatial.EulerAngles{Roll: childRPY[0], Pitch: childRPY[1], Yaw: childRPY[2]}
-			childOrient, err := spatial.NewOrientationConfig(childEA.AxisAngles())
-
-			// Note the conversion from meters to mm
-			childLink.Translation = r3.Vector{
-				metersToMM(childXYZ[0]),
-				metersToMM(childXYZ[1]),
-				metersToMM(childXYZ[2]),
-			}
-			childLink.Orientation = childOrient
-
+			childLink.Translation, childLink.Orientation, err = jointElem.Origin.parse()
 			if err != nil {
 				return nil, err
 			}
 		case FixedJoint:
 			// Handle fixed joint -> static link conversion instead of adding to Joints[]
 			thisLink := LinkConfig{ID: jointElem.Name, Parent: jointElem.Parent.Link}
-
-			linkXYZ := convStringAttrToFloats(jointElem.Origin.XYZ)
-			linkRPY := convStringAttrToFloats(jointElem.Origin.RPY)
-			linkEA := spatial.EulerAngles{Roll: linkRPY[0], Pitch: linkRPY[1], Yaw: linkRPY[2]}
-			linkOrient, err := spatial.NewOrientationConfig(linkEA.AxisAngles())
-
-			// Note the conversion from meters to mm
-			thisLink.Translation = r3.Vector{
-				metersToMM(linkXYZ[0]),
-				metersToMM(linkXYZ[1]),
-				metersToMM(linkXYZ[2]),
-			}
-			thisLink.Orientation = linkOrient
-
+			thisLink.Translation, thisLink.Orientation, err = jointElem.Origin.parse()
 			if err != nil {
 				return nil, err
 			}
@@ -247,6 +258,55 @@ func ConvertURDFToConfig(xmlData []byte, modelName string) (*ModelConfig, error)
 	return mc, nil
 }
 
+// parse returns the translation, in mm, and orientation of an origin element. A missing origin, or a missing attribute of one, is zero.
+func (origin *URDFOrigin) parse() (r3.Vector, *spatial.OrientationConfig, error) {
+	xyz := []float64{0, 0, 0}
+	rpy := []float64{0, 0, 0}
+	if origin != nil {
+		if origin.XYZ != "" {
+			xyz = convStringAttrToFloats(origin.XYZ)
+		}
+		if origin.RPY != "" {
+			rpy = convStringAttrToFloats(origin.RPY)
+		}
+	}
+	if len(xyz) != 3 || len(rpy) != 3 {
+		return r3.Vector{}, nil, errors.Errorf("invalid origin xyz %q rpy %q", origin.XYZ, origin.RPY)
+	}
+	ea := spatial.EulerAngles{Roll: rpy[0], Pitch: rpy[1], Yaw: rpy[2]}
+	orientation, err := spatial.NewOrientationConfig(ea.AxisAngles())
+	if err != nil {
+		return r3.Vector{}, nil, err
+	}
+
+	// Note the conversion from meters to mm
+	return r3.Vector{metersToMM(xyz[0]), metersToMM(xyz[1]), metersToMM(xyz[2])}, orientation, nil
+}
+
+// parseCollision returns the translation and orientation of the origin of a collision element, which has always been read differently to
+// that of a joint: its xyz is taken as mm, and its rpy is converted to degrees before being used as Euler angles.
+func (origin *URDFOrigin) parseCollision() (r3.Vector, *spatial.OrientationConfig, error) {
+	xyz := []float64{0, 0, 0}
+	rpy := []float64{0, 0, 0}
+	if origin != nil {
+		if origin.XYZ != "" {
+			xyz = convStringAttrToFloats(origin.XYZ)
+		}
+		if origin.RPY != "" {
+			rpy = convStringAttrToFloats(origin.RPY)
+		}
+	}
+	if len(xyz) != 3 || len(rpy) != 3 {
+		return r3.Vector{}, nil, errors.Errorf("invalid origin xyz %q rpy %q", origin.XYZ, origin.RPY)
+	}
+	ea := spatial.EulerAngles{Roll: utils.RadToDeg(rpy[0]), Pitch: utils.RadToDeg(rpy[1]), Yaw: utils.RadToDeg(rpy[2])}
+	orientation, err := spatial.NewOrientationConfig(ea.AxisAngles())
+	if err != nil {
+		return r3.Vector{}, nil, err
+	}
+	return r3.Vector{X: xyz[0], Y: xyz[1], Z: xyz[2]}, orientation, nil
+}
+
 // Convenience method to split up space-delimited fields in URDFs, such as xyz or rpy attributes.
 func convStringAttrToFloats(attr string) []float64 {
 	var converted []float64
@@ -265,29 +325,24 @@ func convStringAttrToFloats(attr string) []float64 {
 }
 
 // Convenience method to simplify creating geometry configs from URDF XML that has a collision element specified.
+// Cylinders are read as the capsules which enclose them, as capsules are the closest geometry supported.
 func createConfigFromCollision(link URDFLink) (spatial.GeometryConfig, error) {
 	var geoCfg spatial.GeometryConfig
-	boxGeometry := link.Collision[0].Geometry.Box
-	sphereGeometry := link.Collision[0].Geometry.Sphere
+	collision := link.Collision[0]
 
 	// Offset for the geometry origin from the reference link origin
-	geomXYZ := convStringAttrToFloats(link.Collision[0].Origin.XYZ)
-	geomTx := r3.Vector{geomXYZ[0], geomXYZ[1], geomXYZ[2]}
-	geomRPY := convStringAttrToFloats(link.Collision[0].Origin.RPY)
-	geomEA := spatial.EulerAngles{
-		Roll:  utils.RadToDeg(geomRPY[0]),
-		Pitch: utils.RadToDeg(geomRPY[1]),
-		Yaw:   utils.RadToDeg(geomRPY[2]),
-	}
-	geomOx, err := spatial.NewOrientationConfig(geomEA.AxisAngles())
+	geomTx, geomOx, err := collision.Origin.parseCollision()
 	if err != nil {
 		return spatial.GeometryConfig{}, err
 	}
 
 	// Logic specific to the geometry type
-	switch {
-	case len(boxGeometry.Size) > 0:
-		boxDims := convStringAttrToFloats(boxGeometry.Size)
+	switch geometry := collision.Geometry; {
+	case geometry.Box != nil:
+		boxDims := convStringAttrToFloats(geometry.Box.Size)
+		if len(boxDims) != 3 {
+			return spatial.GeometryConfig{}, errors.Errorf("invalid box size %q for [ %v ] link", geometry.Box.Size, link.Name)
+		}
 		geoCfg = spatial.GeometryConfig{
 			Type:              "box",
 			X:                 metersToMM(boxDims[0]),
@@ -297,8 +352,8 @@ func createConfigFromCollision(link URDFLink) (spatial.GeometryConfig, error) {
 			OrientationOffset: *geomOx,
 			Label:             "box",
 		}
-	case sphereGeometry.Radius > 0:
-		sphereRadius := metersToMM(sphereGeometry.Radius)
+	case geometry.Sphere != nil:
+		sphereRadius := metersToMM(geometry.Sphere.Radius)
 		geoCfg = spatial.GeometryConfig{
 			Type:              "sphere",
 			R:                 sphereRadius,
@@ -306,13 +361,77 @@ func createConfigFromCollision(link URDFLink) (spatial.GeometryConfig, error) {
 			OrientationOffset: *geomOx,
 			Label:             "sphere",
 		}
+	case geometry.Cylinder != nil:
+		radius := metersToMM(geometry.Cylinder.Radius)
+		geoCfg = spatial.GeometryConfig{
+			Type:              "capsule",
+			R:                 radius,
+			L:                 metersToMM(geometry.Cylinder.Length) + 2*radius,
+			TranslationOffset: geomTx,
+			OrientationOffset: *geomOx,
+			Label:             "capsule",
+		}
+	case geometry.Mesh != nil:
+		scale := 1.
+		if geometry.Mesh.Scale != "" {
+			scales := convStringAttrToFloats(geometry.Mesh.Scale)
+			if len(scales) != 3 || scales[0] != scales[1] || scales[0] != scales[2] {
+				return spatial.GeometryConfig{}, errors.Errorf("mesh scale %q for [ %v ] link must be uniform", geometry.Mesh.Scale, link.Name)
+			}
+			scale = scales[0]
+		}
+		geoCfg = spatial.GeometryConfig{
+			Type:              spatial.MeshType,
+			MeshFile:          geometry.Mesh.Filename,
+			MeshScale:         metersToMM(scale),
+			TranslationOffset: geomTx,
+			OrientationOffset: *geomOx,
+			Label:             "mesh",
+		}
 	default:
-		return spatial.GeometryConfig{}, errors.Errorf("Unsupported collision geometry type detected for [ %v ] link", link.Collision[0].Name)
+		return spatial.GeometryConfig{}, errors.Errorf("Unsupported collision geometry type detected for [ %v ] link", collision.Name)
 	}
 
 	return geoCfg, nil
 }
 
+// resolveMeshFiles rewrites the mesh files referenced by the geometries of a model, which a URDF gives relative to its own directory or as
+// package:// or file:// URIs, into paths which can be opened. Packages are looked up in ROS_PACKAGE_PATH.
+func resolveMeshFiles(mc *ModelConfig, dir string) error {
+	for _, link := range mc.Links {
+		if link.Geometry == nil || link.Geometry.Type != spatial.MeshType {
+			continue
+		}
+		meshFile := link.Geometry.MeshFile
+		switch {
+		case strings.HasPrefix(meshFile, "package://"):
+			pkg, path, _ := strings.Cut(strings.TrimPrefix(meshFile, "package://"), "/")
+			pkgDir, err := findROSPackage(pkg)
+			if err != nil {
+				return err
+			}
+			meshFile = filepath.Join(pkgDir, path)
+		case strings.HasPrefix(meshFile, "file://"):
+			meshFile = strings.TrimPrefix(meshFile, "file://")
+		case !filepath.IsAbs(meshFile):
+			meshFile = filepath.Join(dir, meshFile)
+		}
+		link.Geometry.MeshFile = meshFile
+	}
+	return nil
+}
+
+// findROSPackage returns the directory of a ROS package, which is found in one of the directories in ROS_PACKAGE_PATH.
+func findROSPackage(pkg string) (string, error) {
+	for _, dir := range filepath.SplitList(os.Getenv("ROS_PACKAGE_PATH")) {
+		pkgDir := filepath.Join(dir, pkg)
+		if info, err := os.Stat(pkgDir); err == nil && info.IsDir() {
+			return pkgDir, nil
+		}
+	}
+	return "", errors.Errorf("could not find package %q in ROS_PACKAGE_PATH", pkg)
+}
+
 // Convenience function to change engineering unit scale for the given input.
 func metersToMM(valMeters float64) float64 {
 	return valMeters * 1000
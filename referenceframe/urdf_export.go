@@ -0,0 +1,313 @@
+package referenceframe
+
+import (
+	"encoding/xml"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	spatial "go.viam.com/rdk/spatialmath"
+	"go.viam.com/rdk/utils"
+)
+
+// URDF values are rounded to this many decimal places, which is far finer than a nanometer, so that unit conversions do not leave
+// noise in the written files.
+const urdfPrecision = 9
+
+// MarshalURDF returns the URDF XML describing the kinematics of a model, so that models defined in Viam's JSON format can be used by
+// tools which read URDF, such as those of ROS.
+func MarshalURDF(model Model) ([]byte, error) {
+	mc := model.ModelConfig()
+	if mc == nil {
+		return nil, errors.Errorf("model %q has no config to convert to URDF", model.Name())
+	}
+	urdf, err := ConvertConfigToURDF(mc)
+	if err != nil {
+		return nil, err
+	}
+	urdf.Name = model.Name()
+	xmlData, err := xml.MarshalIndent(urdf, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), xmlData...), nil
+}
+
+// WriteURDFFile writes the URDF XML describing the kinematics of a model to a file.
+func WriteURDFFile(model Model, filename string) error {
+	xmlData, err := MarshalURDF(model)
+	if err != nil {
+		return err
+	}
+	//nolint:gosec
+	return os.WriteFile(filename, xmlData, 0o644)
+}
+
+// ConvertConfigToURDF converts a ModelConfig into the equivalent URDF, such that ConvertURDFToConfig converts it back into a ModelConfig
+// with the same kinematics and geometries. DH parameters are converted into the equivalent joints and links.
+//
+// Each joint of the model becomes a URDF joint whose child is the link attached to the joint, and whose origin is that link's pose.
+// Links attached directly to other links or to the world become fixed joints. Joints with no link attached to them are given an empty
+// child link named after the joint. Capsules are written as the cylinders between their hemispherical ends, as URDF has no capsules.
+func ConvertConfigToURDF(mc *ModelConfig) (*URDFConfig, error) {
+	links, joints, err := mc.svaConfigs()
+	if err != nil {
+		return nil, err
+	}
+	jointsByID := map[string]JointConfig{}
+	for _, joint := range joints {
+		if joint.Geometry != nil {
+			return nil, errors.Errorf("joint %q has a geometry, which cannot be represented in URDF", joint.ID)
+		}
+		jointsByID[joint.ID] = joint
+	}
+
+	// find the link which is the URDF child of each joint. This is only possible if the link is the joint's only child, as the other
+	// children would have to be attached to the link rather than to the joint.
+	childCounts := map[string]int{}
+	for _, link := range links {
+		childCounts[link.Parent]++
+	}
+	for _, joint := range joints {
+		childCounts[joint.Parent]++
+	}
+	childLinks := map[string]*LinkConfig{}
+	for i, link := range links {
+		if _, ok := jointsByID[link.Parent]; ok && childCounts[link.Parent] == 1 {
+			childLinks[link.Parent] = &links[i]
+		}
+	}
+	linkName := func(parent string) string {
+		if parent == "" || parent == World {
+			return World
+		}
+		if _, ok := jointsByID[parent]; ok {
+			if child, ok := childLinks[parent]; ok {
+				return child.ID
+			}
+			return parent + "_link"
+		}
+		return parent
+	}
+
+	urdf := &URDFConfig{Name: mc.Name, Links: []URDFLink{{Name: World}}}
+	for _, link := range links {
+		child, ok := childLinks[link.Parent]
+		isJointChild := ok && child.ID == link.ID
+		linkPose, err := link.Pose()
+		if err != nil {
+			return nil, err
+		}
+
+		urdfLink := URDFLink{Name: link.ID}
+		if link.Geometry != nil {
+			// The geometry of a link is placed in the frame of its parent, while URDF places it in the frame of the link. The child link
+			// of a joint is read back in the frame of the joint, which is the same, but a fixed link is read back in its own frame.
+			frame := linkPose
+			if isJointChild {
+				frame = spatial.NewZeroPose()
+			}
+			collision, err := newURDFCollision(link.Geometry, frame)
+			if err != nil {
+				return nil, errors.Wrapf(err, "cannot convert geometry of link %q", link.ID)
+			}
+			urdfLink.Collision = []URDFCollision{*collision}
+		}
+		urdf.Links = append(urdf.Links, urdfLink)
+
+		if isJointChild {
+			continue // written as the child of its joint
+		}
+		origin, err := newURDFOrigin(linkPose)
+		if err != nil {
+			return nil, err
+		}
+		fixed := URDFJoint{Name: link.ID + "_joint", Type: FixedJoint, Origin: origin}
+		fixed.Parent.Link = linkName(link.Parent)
+		fixed.Child.Link = link.ID
+		urdf.Joints = append(urdf.Joints, fixed)
+	}
+
+	for _, joint := range joints {
+		urdfJoint := URDFJoint{Name: joint.ID, Type: joint.Type}
+		urdfJoint.Parent.Link = linkName(joint.Parent)
+		urdfJoint.Child.Link = linkName(joint.ID)
+		if child, ok := childLinks[joint.ID]; ok {
+			childPose, err := child.Pose()
+			if err != nil {
+				return nil, err
+			}
+			if urdfJoint.Origin, err = newURDFOrigin(childPose); err != nil {
+				return nil, err
+			}
+		} else {
+			urdf.Links = append(urdf.Links, URDFLink{Name: urdfJoint.Child.Link})
+		}
+
+		urdfJoint.Axis = &struct {
+			XYZ string `xml:"xyz,attr"`
+		}{XYZ: formatURDFFloats(joint.Axis.X, joint.Axis.Y, joint.Axis.Z)}
+
+		var lower, upper, velocity float64
+		switch joint.Type {
+		case RevoluteJoint:
+			lower, upper, velocity = utils.DegToRad(joint.Min), utils.DegToRad(joint.Max), utils.DegToRad(joint.MaxVel)
+		case PrismaticJoint:
+			lower, upper, velocity = mmToMeters(joint.Min), mmToMeters(joint.Max), mmToMeters(joint.MaxVel)
+		default:
+			return nil, NewUnsupportedJointTypeError(joint.Type)
+		}
+		continuous := joint.Type == RevoluteJoint && math.IsInf(joint.Min, -1) && math.IsInf(joint.Max, 1)
+		if continuous {
+			// continuous joints have no position limits, though they may still have a velocity limit
+			urdfJoint.Type = ContinuousJoint
+			lower, upper = 0, 0
+		}
+		if !continuous || velocity > 0 {
+			urdfJoint.Limit = &struct {
+				Lower    float64 `xml:"lower,attr"`
+				Upper    float64 `xml:"upper,attr"`
+				Velocity float64 `xml:"velocity,attr,omitempty"`
+			}{Lower: roundURDF(lower), Upper: roundURDF(upper), Velocity: roundURDF(velocity)}
+		}
+		urdf.Joints = append(urdf.Joints, urdfJoint)
+	}
+	return urdf, nil
+}
+
+// svaConfigs returns the links and joints of a ModelConfig, converting DH parameters into the equivalent links and joints.
+func (cfg *ModelConfig) svaConfigs() ([]LinkConfig, []JointConfig, error) {
+	switch cfg.KinParamType {
+	case "SVA", "":
+		return cfg.Links, cfg.Joints, nil
+	case "DH":
+		links := make([]LinkConfig, 0, len(cfg.DHParams))
+		joints := make([]JointConfig, 0, len(cfg.DHParams))
+		for _, dh := range cfg.DHParams {
+			_, lFrame, err := dh.ToDHFrames()
+			if err != nil {
+				return nil, nil, err
+			}
+			pose, err := lFrame.Transform([]Input{})
+			if err != nil {
+				return nil, nil, err
+			}
+			orientation, err := spatial.NewOrientationConfig(pose.Orientation())
+			if err != nil {
+				return nil, nil, err
+			}
+			jointID := dh.ID + "_j"
+			joints = append(joints, JointConfig{
+				ID:     jointID,
+				Type:   RevoluteJoint,
+				Parent: dh.Parent,
+				Axis:   spatial.AxisConfig{0, 0, 1},
+				Min:    dh.Min,
+				Max:    dh.Max,
+			})
+			links = append(links, LinkConfig{
+				ID:          dh.ID,
+				Translation: pose.Point(),
+				Orientation: orientation,
+				Geometry:    dh.Geometry,
+				Parent:      jointID,
+			})
+		}
+		return links, joints, nil
+	default:
+		return nil, nil, errors.Errorf("unsupported param type: %s, supported params are SVA and DH", cfg.KinParamType)
+	}
+}
+
+// newURDFCollision converts a geometry config into a URDF collision element, whose origin is relative to the given frame.
+func newURDFCollision(cfg *spatial.GeometryConfig, frame spatial.Pose) (*URDFCollision, error) {
+	geometry, err := cfg.ParseConfig()
+	if err != nil {
+		return nil, err
+	}
+	// a config normalized from the geometry it describes, which has the type of the geometry even if the original config did not
+	normalized, err := spatial.NewGeometryConfig(geometry)
+	if err != nil {
+		return nil, err
+	}
+	collision := &URDFCollision{Name: cfg.Label, Origin: newURDFCollisionOrigin(spatial.Compose(spatial.PoseInverse(frame), geometry.Pose()))}
+	switch normalized.Type {
+	case spatial.BoxType:
+		collision.Geometry.Box = &struct {
+			Size string `xml:"size,attr"`
+		}{Size: formatURDFFloats(mmToMeters(normalized.X), mmToMeters(normalized.Y), mmToMeters(normalized.Z))}
+	case spatial.SphereType:
+		collision.Geometry.Sphere = &struct {
+			Radius float64 `xml:"radius,attr"`
+		}{Radius: roundURDF(mmToMeters(normalized.R))}
+	case spatial.CapsuleType:
+		collision.Geometry.Cylinder = &struct {
+			Radius float64 `xml:"radius,attr"`
+			Length float64 `xml:"length,attr"`
+		}{Radius: roundURDF(mmToMeters(normalized.R)), Length: roundURDF(mmToMeters(normalized.L - 2*normalized.R))}
+	case spatial.MeshType:
+		scale := formatURDFFloats(mmToMeters(normalized.MeshScale), mmToMeters(normalized.MeshScale), mmToMeters(normalized.MeshScale))
+		collision.Geometry.Mesh = &struct {
+			Filename string `xml:"filename,attr"`
+			Scale    string `xml:"scale,attr,omitempty"`
+		}{Filename: normalized.MeshFile, Scale: scale}
+	case spatial.PointType, spatial.UnknownType:
+		fallthrough
+	default:
+		return nil, errors.Errorf("geometry type %q cannot be represented in URDF", normalized.Type)
+	}
+	return collision, nil
+}
+
+// newURDFOrigin converts a pose into a URDF origin element, or nil if it is the identity pose.
+func newURDFOrigin(pose spatial.Pose) (*URDFOrigin, error) {
+	if spatial.PoseAlmostEqual(pose, spatial.NewZeroPose()) {
+		return nil, nil
+	}
+	translation := pose.Point()
+	ea := pose.Orientation().EulerAngles()
+	return &URDFOrigin{
+		XYZ: formatURDFFloats(mmToMeters(translation.X), mmToMeters(translation.Y), mmToMeters(translation.Z)),
+		RPY: formatURDFFloats(ea.Roll, ea.Pitch, ea.Yaw),
+	}, nil
+}
+
+// newURDFCollisionOrigin converts a pose into the origin element of a collision, as it is read by URDFOrigin.parseCollision, or nil if it
+// is the identity pose.
+func newURDFCollisionOrigin(pose spatial.Pose) *URDFOrigin {
+	if spatial.PoseAlmostEqual(pose, spatial.NewZeroPose()) {
+		return nil
+	}
+	translation := pose.Point()
+	ea := pose.Orientation().EulerAngles()
+	return &URDFOrigin{
+		XYZ: formatURDFFloats(translation.X, translation.Y, translation.Z),
+		RPY: formatURDFFloats(utils.DegToRad(ea.Roll), utils.DegToRad(ea.Pitch), utils.DegToRad(ea.Yaw)),
+	}
+}
+
+// formatURDFFloats formats values as a space-delimited field, such as an xyz or rpy attribute.
+func formatURDFFloats(values ...float64) string {
+	formatted := make([]string, 0, len(values))
+	for _, value := range values {
+		formatted = append(formatted, strconv.FormatFloat(roundURDF(value), 'f', -1, 64))
+	}
+	return strings.Join(formatted, " ")
+}
+
+func roundURDF(value float64) float64 {
+	scale := math.Pow(10, urdfPrecision)
+	rounded := math.Round(value*scale) / scale
+	if rounded == 0 {
+		return 0 // no negative zeros
+	}
+	return rounded
+}
+
+// Convenience function to change engineering unit scale for the given input.
+func mmToMeters(valMM float64) float64 {
+	return valMM / 1000
+}
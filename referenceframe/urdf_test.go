@@ -2,8 +2,11 @@ package referenceframe
 
 import (
 	"math/rand"
+	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/golang/geo/r3"
 	"go.viam.com/test"
 
 	spatial "go.viam.com/rdk/spatialmath"
@@ -34,6 +37,19 @@ func TestParseURDFFile(t *testing.T) {
 	u, err = ParseURDFFile(utils.ResolveFile("referenceframe/testurdf/ur5_minimal.urdf"), "foo")
 	test.That(t, err, test.ShouldBeNil)
 	test.That(t, u.Name(), test.ShouldEqual, "foo")
+
+	// Revolute and prismatic joints without limits are loaded with zero limits
+	mc, err := ConvertURDFToConfig([]byte(`<robot name="unlimited">
+		<link name="base"/><link name="l1"/><link name="l2"/>
+		<joint name="j1" type="revolute"><parent link="base"/><child link="l1"/></joint>
+		<joint name="j2" type="prismatic"><parent link="l1"/><child link="l2"/></joint>
+	</robot>`), "")
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, mc.Joints, test.ShouldHaveLength, 2)
+	for _, joint := range mc.Joints {
+		test.That(t, joint.Min, test.ShouldEqual, 0)
+		test.That(t, joint.Max, test.ShouldEqual, 0)
+	}
 }
 
 //nolint:dupl
@@ -85,4 +101,117 @@ func TestURDFGeometries(t *testing.T) {
 	inputs = make([]Input, len(ur5ViamModel.DoF()))
 	modelGeo, _ = ur5ViamModel.Geometries(inputs)
 	test.That(t, len(modelGeo.geometries), test.ShouldEqual, 5)
+
+	// mesh files are found relative to the URDF, and scaled from meters
+	meshArm, err := ParseURDFFile(utils.ResolveFile("referenceframe/testurdf/mesh_arm.urdf"), "")
+	test.That(t, err, test.ShouldBeNil)
+	modelGeo, err = meshArm.Geometries([]Input{{0}})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, len(modelGeo.Geometries()), test.ShouldEqual, 1)
+	bounds, err := spatial.NewGeometryFromProto(modelGeo.Geometries()[0].ToProtobuf())
+	test.That(t, err, test.ShouldBeNil)
+	expected, err := spatial.NewBox(spatial.NewPoseFromPoint(r3.Vector{0.2, 0, 0}), r3.Vector{100, 100, 100}, "")
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, bounds.AlmostEqual(expected), test.ShouldBeTrue)
+	collides, err := modelGeo.Geometries()[0].CollidesWith(spatial.NewPoint(r3.Vector{40, 0, 40}, ""))
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, collides, test.ShouldBeTrue)
+
+	_, err = ConvertURDFToConfig([]byte(`<robot name="bad"><link name="l"><collision><geometry>
+		<mesh filename="cube.stl" scale="1 2 1"/></geometry></collision></link></robot>`), "")
+	test.That(t, err, test.ShouldNotBeNil)
+}
+
+func TestURDFCollisionOrigins(t *testing.T) {
+	// collision origins are read as they always have been: xyz as mm, and rpy converted to degrees before being used as Euler angles
+	//nolint:gosec
+	xmlData, err := os.ReadFile(utils.ResolveFile("referenceframe/testurdf/ur5_viam.urdf"))
+	test.That(t, err, test.ShouldBeNil)
+	mc, err := ConvertURDFToConfig(xmlData, "")
+	test.That(t, err, test.ShouldBeNil)
+	offsets := map[string]r3.Vector{}
+	for _, link := range mc.Links {
+		if link.Geometry != nil {
+			offsets[link.ID] = link.Geometry.TranslationOffset
+		}
+	}
+	test.That(t, offsets["base_link"], test.ShouldResemble, r3.Vector{0, 0, 0.13})
+	test.That(t, offsets["upper_arm_link"], test.ShouldResemble, r3.Vector{-0.215, -0.13, 0})
+
+	mc, err = ConvertURDFToConfig([]byte(`<robot name="rotated"><link name="l"><collision>
+		<origin rpy="0 0 1.5707963267948966" xyz="0 0 0"/><geometry><box size="0.1 0.2 0.3"/></geometry>
+	</collision></link></robot>`), "")
+	test.That(t, err, test.ShouldBeNil)
+	geometry, err := mc.Links[0].Geometry.ParseConfig()
+	test.That(t, err, test.ShouldBeNil)
+	expected := &spatial.EulerAngles{Yaw: 90}
+	test.That(t, spatial.OrientationAlmostEqual(geometry.Pose().Orientation(), expected), test.ShouldBeTrue)
+}
+
+// testSameKinematics checks that two models have the same transform and geometries at a number of random configurations.
+func testSameKinematics(t *testing.T, expected, actual Model) {
+	t.Helper()
+	test.That(t, len(actual.DoF()), test.ShouldEqual, len(expected.DoF()))
+	for i, limit := range expected.DoF() {
+		test.That(t, actual.DoF()[i].Min, test.ShouldAlmostEqual, limit.Min, 1e-6)
+		test.That(t, actual.DoF()[i].Max, test.ShouldAlmostEqual, limit.Max, 1e-6)
+	}
+	// URDF only has velocity limits
+	for i, limit := range FrameDynamicLimits(expected) {
+		test.That(t, FrameDynamicLimits(actual)[i].Velocity, test.ShouldAlmostEqual, limit.Velocity, 1e-6)
+	}
+
+	randSeed := rand.New(rand.NewSource(1))
+	for i := 0; i < 10; i++ {
+		inputs := FloatsToInputs(GenerateRandomConfiguration(expected, randSeed))
+		expectedPose, err := expected.Transform(inputs)
+		test.That(t, err, test.ShouldBeNil)
+		actualPose, err := actual.Transform(inputs)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, spatial.PoseAlmostEqualEps(actualPose, expectedPose, 1e-6), test.ShouldBeTrue)
+
+		expectedGeoms, err := expected.Geometries(inputs)
+		test.That(t, err, test.ShouldBeNil)
+		actualGeoms, err := actual.Geometries(inputs)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, len(actualGeoms.Geometries()), test.ShouldEqual, len(expectedGeoms.Geometries()))
+		for _, expectedGeom := range expectedGeoms.Geometries() {
+			found := false
+			for _, actualGeom := range actualGeoms.Geometries() {
+				found = found || actualGeom.AlmostEqual(expectedGeom)
+			}
+			test.That(t, found, test.ShouldBeTrue)
+		}
+	}
+}
+
+func TestURDFExport(t *testing.T) {
+	for _, path := range []string{
+		"components/arm/universalrobots/ur5e.json",
+		"components/arm/xarm/xarm6_kinematics.json",
+		"referenceframe/testjson/ur5eDH.json",
+		"referenceframe/testurdf/ur5_viam.urdf",
+		"referenceframe/testurdf/mesh_arm.urdf",
+	} {
+		t.Run(path, func(t *testing.T) {
+			model, err := ModelFromPath(utils.ResolveFile(path), "")
+			test.That(t, err, test.ShouldBeNil)
+
+			filename := filepath.Join(t.TempDir(), "model.urdf")
+			test.That(t, WriteURDFFile(model, filename), test.ShouldBeNil)
+			exported, err := ParseURDFFile(filename, "")
+			test.That(t, err, test.ShouldBeNil)
+			test.That(t, exported.Name(), test.ShouldEqual, model.Name())
+			testSameKinematics(t, model, exported)
+		})
+	}
+
+	// URDF cannot describe geometries attached to joints
+	mc := &ModelConfig{
+		Name:   "joint_geometry",
+		Links:  []LinkConfig{{ID: "link", Parent: "joint"}},
+		Joints: []JointConfig{{ID: "joint", Type: RevoluteJoint, Parent: World, Geometry: &spatial.GeometryConfig{R: 1}}},
+	}
+	_, err := ConvertConfigToURDF(mc)
+	test.That(t, err, test.ShouldNotBeNil)
 }
@@ -0,0 +1,1005 @@
+package referenceframe
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+// The deepest that macros, includes and properties may be nested in a xacro file, which stops runaway recursion.
+const maxXacroDepth = 100
+
+// ParseXacroFile expands a xacro file into URDF, then parses it into a Model. Arguments declared with xacro:arg take their values from
+// args, or their defaults if args does not have them.
+func ParseXacroFile(filename, modelName string, args map[string]string) (Model, error) {
+	xmlData, err := ExpandXacroFile(filename, args)
+	if err != nil {
+		return nil, err
+	}
+	mc, err := ConvertURDFToConfig(xmlData, modelName)
+	if err != nil {
+		return nil, err
+	}
+	if err := resolveMeshFiles(mc, filepath.Dir(filename)); err != nil {
+		return nil, err
+	}
+	return mc.ParseConfig(modelName)
+}
+
+// ExpandXacroFile expands the properties, macros, conditionals and includes of a xacro file, returning the plain XML it describes.
+// Expressions in ${} may use arithmetic, comparisons, the usual math functions and pi. Includes are found relative to the including
+// file, and $(find package) resolves to the directory of the package in ROS_PACKAGE_PATH.
+func ExpandXacroFile(filename string, args map[string]string) ([]byte, error) {
+	root, err := readXMLFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	if isXacroElement(root) {
+		return nil, errors.New("the root element of a xacro file cannot be a xacro element")
+	}
+	expander := &xacroExpander{args: map[string]string{}}
+	for name, value := range args {
+		expander.args[name] = value
+	}
+	expanded, err := expander.expandElement(root, newXacroScope(nil), filepath.Dir(filename))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to expand xacro file %s", filename)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	if err := expanded[0].write(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// xmlNode is an element, or text if it has no name, of a parsed XML document.
+type xmlNode struct {
+	name     xml.Name
+	attrs    []xml.Attr
+	children []*xmlNode
+	text     string
+}
+
+func (n *xmlNode) attr(name string) (string, bool) {
+	for _, attr := range n.attrs {
+		if attr.Name.Local == name {
+			return attr.Value, true
+		}
+	}
+	return "", false
+}
+
+// write writes a node as XML, without namespaces, as the expanded document no longer needs them.
+func (n *xmlNode) write(w io.Writer) error {
+	if n.name.Local == "" {
+		return xml.EscapeText(w, []byte(n.text))
+	}
+	if _, err := fmt.Fprintf(w, "<%s", n.name.Local); err != nil {
+		return err
+	}
+	for _, attr := range n.attrs {
+		if attr.Name.Space == "xmlns" || attr.Name.Local == "xmlns" {
+			continue
+		}
+		if _, err := fmt.Fprintf(w, " %s=\"", attr.Name.Local); err != nil {
+			return err
+		}
+		if err := xml.EscapeText(w, []byte(attr.Value)); err != nil {
+			return err
+		}
+		if _, err := io.WriteString(w, "\""); err != nil {
+			return err
+		}
+	}
+	if _, err := io.WriteString(w, ">"); err != nil {
+		return err
+	}
+	for _, child := range n.children {
+		if err := child.write(w); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "</%s>", n.name.Local)
+	return err
+}
+
+// readXMLFile parses an XML file into a tree of nodes, dropping comments and whitespace between elements.
+func readXMLFile(filename string) (*xmlNode, error) {
+	//nolint:gosec
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	var stack []*xmlNode
+	var root *xmlNode
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse %s", filename)
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			node := &xmlNode{name: t.Name, attrs: t.Copy().Attr}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, node)
+			} else {
+				root = node
+			}
+			stack = append(stack, node)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			if len(stack) > 0 && strings.TrimSpace(string(t)) != "" {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, &xmlNode{text: string(t)})
+			}
+		}
+	}
+	if root == nil {
+		return nil, ErrNoModelInformation
+	}
+	return root, nil
+}
+
+// isXacroElement returns whether a node is in the xacro namespace, whether or not the namespace was declared.
+func isXacroElement(n *xmlNode) bool {
+	return n.name.Space == "xacro" || strings.Contains(n.name.Space, "ros.org/wiki/xacro")
+}
+
+type xacroProperty struct {
+	value string
+	scope *xacroScope // the scope the value is evaluated in
+}
+
+type xacroBlock struct {
+	nodes []*xmlNode
+	scope *xacroScope
+}
+
+type xacroParam struct {
+	name       string
+	defaultVal *string
+	block      bool
+	blockItems bool // a ** parameter, which inserts the children of the block rather than the block itself
+}
+
+type xacroMacro struct {
+	params []xacroParam
+	body   []*xmlNode
+}
+
+// xacroScope holds the properties, blocks and macros defined in a file or a macro.
+type xacroScope struct {
+	parent     *xacroScope
+	properties map[string]xacroProperty
+	blocks     map[string]xacroBlock
+	macros     map[string]*xacroMacro
+}
+
+func newXacroScope(parent *xacroScope) *xacroScope {
+	return &xacroScope{
+		parent:     parent,
+		properties: map[string]xacroProperty{},
+		blocks:     map[string]xacroBlock{},
+		macros:     map[string]*xacroMacro{},
+	}
+}
+
+func (s *xacroScope) property(name string) (xacroProperty, bool) {
+	for scope := s; scope != nil; scope = scope.parent {
+		if property, ok := scope.properties[name]; ok {
+			return property, true
+		}
+	}
+	return xacroProperty{}, false
+}
+
+func (s *xacroScope) block(name string) (xacroBlock, bool) {
+	for scope := s; scope != nil; scope = scope.parent {
+		if block, ok := scope.blocks[name]; ok {
+			return block, true
+		}
+	}
+	return xacroBlock{}, false
+}
+
+func (s *xacroScope) macro(name string) (*xacroMacro, bool) {
+	for scope := s; scope != nil; scope = scope.parent {
+		if macro, ok := scope.macros[name]; ok {
+			return macro, true
+		}
+	}
+	return nil, false
+}
+
+type xacroExpander struct {
+	args  map[string]string
+	depth int
+}
+
+func (x *xacroExpander) enter() error {
+	x.depth++
+	if x.depth > maxXacroDepth {
+		return errors.New("xacro is nested too deeply, it may be recursive")
+	}
+	return nil
+}
+
+func (x *xacroExpander) leave() {
+	x.depth--
+}
+
+// expandNodes expands a list of nodes in a scope. dir is the directory of the file the nodes are from.
+func (x *xacroExpander) expandNodes(nodes []*xmlNode, scope *xacroScope, dir string) ([]*xmlNode, error) {
+	expanded := []*xmlNode{}
+	for _, node := range nodes {
+		next, err := x.expandElement(node, scope, dir)
+		if err != nil {
+			return nil, err
+		}
+		expanded = append(expanded, next...)
+	}
+	return expanded, nil
+}
+
+// expandElement expands a single node, which may become any number of nodes.
+func (x *xacroExpander) expandElement(node *xmlNode, scope *xacroScope, dir string) ([]*xmlNode, error) {
+	if err := x.enter(); err != nil {
+		return nil, err
+	}
+	defer x.leave()
+
+	if node.name.Local == "" {
+		text, err := x.substitute(node.text, scope)
+		if err != nil {
+			return nil, err
+		}
+		return []*xmlNode{{text: text}}, nil
+	}
+	if !isXacroElement(node) {
+		expanded := &xmlNode{name: node.name}
+		for _, attr := range node.attrs {
+			value, err := x.substitute(attr.Value, scope)
+			if err != nil {
+				return nil, err
+			}
+			expanded.attrs = append(expanded.attrs, xml.Attr{Name: attr.Name, Value: value})
+		}
+		children, err := x.expandNodes(node.children, scope, dir)
+		if err != nil {
+			return nil, err
+		}
+		expanded.children = children
+		return []*xmlNode{expanded}, nil
+	}
+
+	switch node.name.Local {
+	case "property":
+		return nil, x.defineProperty(node, scope)
+	case "arg":
+		name, err := x.requiredAttr(node, "name", scope)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := x.args[name]; !ok {
+			defaultVal, ok := node.attr("default")
+			if !ok {
+				return nil, errors.Errorf("no value given for xacro arg %q, which has no default", name)
+			}
+			if x.args[name], err = x.substitute(defaultVal, scope); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	case "macro":
+		return nil, x.defineMacro(node, scope)
+	case "include":
+		filename, err := x.requiredAttr(node, "filename", scope)
+		if err != nil {
+			return nil, err
+		}
+		if !filepath.IsAbs(filename) {
+			filename = filepath.Join(dir, filename)
+		}
+		included, err := readXMLFile(filename)
+		if err != nil {
+			return nil, err
+		}
+		// the root element of an included file only holds its contents
+		return x.expandNodes(included.children, scope, filepath.Dir(filename))
+	case "if", "unless":
+		condition, err := x.requiredAttr(node, "value", scope)
+		if err != nil {
+			return nil, err
+		}
+		truth, err := xacroTruth(condition)
+		if err != nil {
+			return nil, err
+		}
+		if truth == (node.name.Local == "if") {
+			return x.expandNodes(node.children, scope, dir)
+		}
+		return nil, nil
+	case "insert_block":
+		name, err := x.requiredAttr(node, "name", scope)
+		if err != nil {
+			return nil, err
+		}
+		block, ok := scope.block(name)
+		if !ok {
+			return nil, errors.Errorf("undefined xacro block %q", name)
+		}
+		return x.expandNodes(block.nodes, block.scope, dir)
+	case "call":
+		name, err := x.requiredAttr(node, "macro", scope)
+		if err != nil {
+			return nil, err
+		}
+		return x.callMacro(name, node, scope, dir)
+	default:
+		return x.callMacro(node.name.Local, node, scope, dir)
+	}
+}
+
+// requiredAttr returns the substituted value of an attribute which a xacro element must have.
+func (x *xacroExpander) requiredAttr(node *xmlNode, name string, scope *xacroScope) (string, error) {
+	value, ok := node.attr(name)
+	if !ok {
+		return "", errors.Errorf("xacro:%s element is missing its %s attribute", node.name.Local, name)
+	}
+	return x.substitute(value, scope)
+}
+
+func (x *xacroExpander) defineProperty(node *xmlNode, scope *xacroScope) error {
+	name, err := x.requiredAttr(node, "name", scope)
+	if err != nil {
+		return err
+	}
+	target := scope
+	if scopeName, ok := node.attr("scope"); ok {
+		switch scopeName {
+		case "parent":
+			if scope.parent != nil {
+				target = scope.parent
+			}
+		case "global":
+			for target.parent != nil {
+				target = target.parent
+			}
+		default:
+			return errors.Errorf("unknown xacro property scope %q", scopeName)
+		}
+	}
+	if value, ok := node.attr("value"); ok {
+		// properties are evaluated when they are used, so that they may refer to properties defined after them
+		target.properties[name] = xacroProperty{value: value, scope: scope}
+		return nil
+	}
+	if defaultVal, ok := node.attr("default"); ok {
+		if _, defined := scope.property(name); !defined {
+			target.properties[name] = xacroProperty{value: defaultVal, scope: scope}
+		}
+		return nil
+	}
+	target.blocks[name] = xacroBlock{nodes: node.children, scope: scope}
+	return nil
+}
+
+func (x *xacroExpander) defineMacro(node *xmlNode, scope *xacroScope) error {
+	name, err := x.requiredAttr(node, "name", scope)
+	if err != nil {
+		return err
+	}
+	macro := &xacroMacro{body: node.children}
+	params, _ := node.attr("params")
+	for _, field := range strings.Fields(params) {
+		param := xacroParam{}
+		switch {
+		case strings.HasPrefix(field, "**"):
+			param.block, param.blockItems = true, true
+			field = field[2:]
+		case strings.HasPrefix(field, "*"):
+			param.block = true
+			field = field[1:]
+		}
+		if i := strings.Index(field, ":="); i >= 0 {
+			defaultVal := strings.TrimPrefix(field[i+2:], "^|")
+			param.defaultVal = &defaultVal
+			field = field[:i]
+		} else if i := strings.Index(field, "="); i >= 0 {
+			defaultVal := field[i+1:]
+			param.defaultVal = &defaultVal
+			field = field[:i]
+		}
+		param.name = field
+		macro.params = append(macro.params, param)
+	}
+	scope.macros[name] = macro
+	return nil
+}
+
+func (x *xacroExpander) callMacro(name string, node *xmlNode, scope *xacroScope, dir string) ([]*xmlNode, error) {
+	macro, ok := scope.macro(name)
+	if !ok {
+		return nil, errors.Errorf("undefined xacro macro %q", name)
+	}
+	macroScope := newXacroScope(scope)
+	var blockElements []*xmlNode
+	for _, child := range node.children {
+		if child.name.Local != "" {
+			blockElements = append(blockElements, child)
+		}
+	}
+	for _, param := range macro.params {
+		if param.block {
+			if len(blockElements) == 0 {
+				return nil, errors.Errorf("no block given for parameter %q of xacro macro %q", param.name, name)
+			}
+			block := []*xmlNode{blockElements[0]}
+			if param.blockItems {
+				block = blockElements[0].children
+			}
+			macroScope.blocks[param.name] = xacroBlock{nodes: block, scope: scope}
+			blockElements = blockElements[1:]
+			continue
+		}
+		value, ok := node.attr(param.name)
+		if !ok {
+			if param.defaultVal == nil {
+				return nil, errors.Errorf("no value given for parameter %q of xacro macro %q", param.name, name)
+			}
+			value = *param.defaultVal
+		}
+		value, err := x.substitute(value, scope)
+		if err != nil {
+			return nil, err
+		}
+		macroScope.properties[param.name] = xacroProperty{value: value, scope: macroScope}
+	}
+	return x.expandNodes(macro.body, macroScope, dir)
+}
+
+// substitute replaces the ${expression} and $(command) substitutions in a string with their values.
+func (x *xacroExpander) substitute(s string, scope *xacroScope) (string, error) {
+	if !strings.Contains(s, "$") {
+		return s, nil
+	}
+	if err := x.enter(); err != nil {
+		return "", err
+	}
+	defer x.leave()
+
+	var out strings.Builder
+	for {
+		start := strings.Index(s, "$")
+		if start < 0 || start == len(s)-1 {
+			out.WriteString(s)
+			return out.String(), nil
+		}
+		out.WriteString(s[:start])
+		open, closing := s[start+1], byte(0)
+		switch open {
+		case '{':
+			closing = '}'
+		case '(':
+			closing = ')'
+		default:
+			out.WriteByte('$')
+			s = s[start+1:]
+			continue
+		}
+		end := strings.IndexByte(s[start:], closing)
+		if end < 0 {
+			return "", errors.Errorf("unterminated substitution in %q", s)
+		}
+		inner := s[start+2 : start+end]
+		var value string
+		var err error
+		if open == '{' {
+			var v xacroValue
+			v, err = x.evaluate(inner, scope)
+			value = v.String()
+		} else {
+			value, err = x.command(inner, scope)
+		}
+		if err != nil {
+			return "", err
+		}
+		out.WriteString(value)
+		s = s[start+end+1:]
+	}
+}
+
+// command evaluates a $(command) substitution.
+func (x *xacroExpander) command(s string, scope *xacroScope) (string, error) {
+	fields := strings.Fields(s)
+	if len(fields) != 2 {
+		return "", errors.Errorf("unsupported xacro substitution $(%s)", s)
+	}
+	switch fields[0] {
+	case "arg":
+		value, ok := x.args[fields[1]]
+		if !ok {
+			return "", errors.Errorf("undefined xacro arg %q", fields[1])
+		}
+		return value, nil
+	case "env":
+		value, ok := os.LookupEnv(fields[1])
+		if !ok {
+			return "", errors.Errorf("undefined environment variable %q", fields[1])
+		}
+		return value, nil
+	case "find":
+		return findROSPackage(fields[1])
+	default:
+		return "", errors.Errorf("unsupported xacro substitution $(%s)", s)
+	}
+}
+
+// xacroValue is the value of a xacro expression, a number or a string.
+type xacroValue struct {
+	num   float64
+	str   string
+	isNum bool
+}
+
+func xacroNumber(num float64) xacroValue {
+	return xacroValue{num: num, isNum: true}
+}
+
+// parseXacroValue interprets a string as a number if it is one.
+func parseXacroValue(s string) xacroValue {
+	trimmed := strings.TrimSpace(s)
+	if num, err := strconv.ParseFloat(trimmed, 64); err == nil {
+		return xacroNumber(num)
+	}
+	switch trimmed {
+	case "true", "True":
+		return xacroNumber(1)
+	case "false", "False":
+		return xacroNumber(0)
+	}
+	return xacroValue{str: s}
+}
+
+func (v xacroValue) String() string {
+	if v.isNum {
+		return strconv.FormatFloat(v.num, 'f', -1, 64)
+	}
+	return v.str
+}
+
+func (v xacroValue) number() (float64, error) {
+	if !v.isNum {
+		return 0, errors.Errorf("%q is not a number", v.str)
+	}
+	return v.num, nil
+}
+
+// xacroTruth interprets the value of a xacro:if or xacro:unless condition.
+func xacroTruth(s string) (bool, error) {
+	v := parseXacroValue(s)
+	if !v.isNum {
+		return false, errors.Errorf("invalid xacro condition %q", s)
+	}
+	return v.num != 0, nil
+}
+
+var xacroFunctions = map[string]func(...float64) (float64, error){
+	"sin":     oneArgFunction(math.Sin),
+	"cos":     oneArgFunction(math.Cos),
+	"tan":     oneArgFunction(math.Tan),
+	"asin":    oneArgFunction(math.Asin),
+	"acos":    oneArgFunction(math.Acos),
+	"atan":    oneArgFunction(math.Atan),
+	"sqrt":    oneArgFunction(math.Sqrt),
+	"abs":     oneArgFunction(math.Abs),
+	"floor":   oneArgFunction(math.Floor),
+	"ceil":    oneArgFunction(math.Ceil),
+	"radians": oneArgFunction(func(deg float64) float64 { return deg * math.Pi / 180 }),
+	"degrees": oneArgFunction(func(rad float64) float64 { return rad * 180 / math.Pi }),
+	"atan2": func(args ...float64) (float64, error) {
+		if len(args) != 2 {
+			return 0, errors.New("atan2 takes two arguments")
+		}
+		return math.Atan2(args[0], args[1]), nil
+	},
+	"pow": func(args ...float64) (float64, error) {
+		if len(args) != 2 {
+			return 0, errors.New("pow takes two arguments")
+		}
+		return math.Pow(args[0], args[1]), nil
+	},
+}
+
+func oneArgFunction(f func(float64) float64) func(...float64) (float64, error) {
+	return func(args ...float64) (float64, error) {
+		if len(args) != 1 {
+			return 0, errors.New("function takes one argument")
+		}
+		return f(args[0]), nil
+	}
+}
+
+// evaluate evaluates a ${} expression.
+func (x *xacroExpander) evaluate(expr string, scope *xacroScope) (xacroValue, error) {
+	tokens, err := tokenizeXacro(expr)
+	if err != nil {
+		return xacroValue{}, err
+	}
+	p := &xacroParser{expander: x, scope: scope, tokens: tokens}
+	value, err := p.parseOr()
+	if err != nil {
+		return xacroValue{}, errors.Wrapf(err, "cannot evaluate ${%s}", expr)
+	}
+	if p.pos != len(p.tokens) {
+		return xacroValue{}, errors.Errorf("unexpected %q in ${%s}", p.tokens[p.pos], expr)
+	}
+	return value, nil
+}
+
+// tokenizeXacro splits an expression into numbers, names, quoted strings and operators.
+func tokenizeXacro(expr string) ([]string, error) {
+	var tokens []string
+	for i := 0; i < len(expr); {
+		c := rune(expr[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case unicode.IsDigit(c) || c == '.':
+			j := i
+			for j < len(expr) && (unicode.IsDigit(rune(expr[j])) || expr[j] == '.' || expr[j] == 'e' || expr[j] == 'E' ||
+				((expr[j] == '-' || expr[j] == '+') && j > i && (expr[j-1] == 'e' || expr[j-1] == 'E'))) {
+				j++
+			}
+			tokens = append(tokens, expr[i:j])
+			i = j
+		case unicode.IsLetter(c) || c == '_':
+			j := i
+			for j < len(expr) && (unicode.IsLetter(rune(expr[j])) || unicode.IsDigit(rune(expr[j])) || expr[j] == '_' || expr[j] == '.') {
+				j++
+			}
+			tokens = append(tokens, expr[i:j])
+			i = j
+		case c == '\'' || c == '"':
+			j := strings.IndexByte(expr[i+1:], expr[i])
+			if j < 0 {
+				return nil, errors.Errorf("unterminated string in ${%s}", expr)
+			}
+			tokens = append(tokens, expr[i:i+j+2])
+			i += j + 2
+		default:
+			if i+1 < len(expr) {
+				switch two := expr[i : i+2]; two {
+				case "**", "==", "!=", "<=", ">=":
+					tokens = append(tokens, two)
+					i += 2
+					continue
+				}
+			}
+			if !strings.ContainsRune("+-*/%()<>,", c) {
+				return nil, errors.Errorf("unexpected character %q in ${%s}", c, expr)
+			}
+			tokens = append(tokens, string(c))
+			i++
+		}
+	}
+	return tokens, nil
+}
+
+// xacroParser is a recursive descent parser which evaluates xacro expressions as it parses them.
+type xacroParser struct {
+	expander *xacroExpander
+	scope    *xacroScope
+	tokens   []string
+	pos      int
+}
+
+func (p *xacroParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *xacroParser) next() string {
+	token := p.peek()
+	p.pos++
+	return token
+}
+
+func (p *xacroParser) parseOr() (xacroValue, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return xacroValue{}, err
+	}
+	for p.peek() == "or" {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return xacroValue{}, err
+		}
+		left = boolValue(truthy(left) || truthy(right))
+	}
+	return left, nil
+}
+
+func (p *xacroParser) parseAnd() (xacroValue, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return xacroValue{}, err
+	}
+	for p.peek() == "and" {
+		p.next()
+		right, err := p.parseNot()
+		if err != nil {
+			return xacroValue{}, err
+		}
+		left = boolValue(truthy(left) && truthy(right))
+	}
+	return left, nil
+}
+
+func (p *xacroParser) parseNot() (xacroValue, error) {
+	if p.peek() == "not" {
+		p.next()
+		value, err := p.parseNot()
+		if err != nil {
+			return xacroValue{}, err
+		}
+		return boolValue(!truthy(value)), nil
+	}
+	return p.parseComparison()
+}
+
+func (p *xacroParser) parseComparison() (xacroValue, error) {
+	left, err := p.parseSum()
+	if err != nil {
+		return xacroValue{}, err
+	}
+	switch op := p.peek(); op {
+	case "==", "!=", "<", ">", "<=", ">=":
+		p.next()
+		right, err := p.parseSum()
+		if err != nil {
+			return xacroValue{}, err
+		}
+		if !left.isNum || !right.isNum {
+			switch op {
+			case "==":
+				return boolValue(left.String() == right.String()), nil
+			case "!=":
+				return boolValue(left.String() != right.String()), nil
+			default:
+				return xacroValue{}, errors.Errorf("cannot compare %q and %q with %s", left.String(), right.String(), op)
+			}
+		}
+		switch op {
+		case "==":
+			return boolValue(left.num == right.num), nil
+		case "!=":
+			return boolValue(left.num != right.num), nil
+		case "<":
+			return boolValue(left.num < right.num), nil
+		case ">":
+			return boolValue(left.num > right.num), nil
+		case "<=":
+			return boolValue(left.num <= right.num), nil
+		default:
+			return boolValue(left.num >= right.num), nil
+		}
+	}
+	return left, nil
+}
+
+func (p *xacroParser) parseSum() (xacroValue, error) {
+	left, err := p.parseProduct()
+	if err != nil {
+		return xacroValue{}, err
+	}
+	for p.peek() == "+" || p.peek() == "-" {
+		op := p.next()
+		right, err := p.parseProduct()
+		if err != nil {
+			return xacroValue{}, err
+		}
+		if op == "+" && (!left.isNum || !right.isNum) {
+			left = xacroValue{str: left.String() + right.String()}
+			continue
+		}
+		if left, err = arithmetic(op, left, right); err != nil {
+			return xacroValue{}, err
+		}
+	}
+	return left, nil
+}
+
+func (p *xacroParser) parseProduct() (xacroValue, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return xacroValue{}, err
+	}
+	for p.peek() == "*" || p.peek() == "/" || p.peek() == "%" {
+		op := p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return xacroValue{}, err
+		}
+		if left, err = arithmetic(op, left, right); err != nil {
+			return xacroValue{}, err
+		}
+	}
+	return left, nil
+}
+
+func (p *xacroParser) parseUnary() (xacroValue, error) {
+	if p.peek() == "-" || p.peek() == "+" {
+		op := p.next()
+		value, err := p.parseUnary()
+		if err != nil {
+			return xacroValue{}, err
+		}
+		num, err := value.number()
+		if err != nil {
+			return xacroValue{}, err
+		}
+		if op == "-" {
+			num = -num
+		}
+		return xacroNumber(num), nil
+	}
+	return p.parsePower()
+}
+
+func (p *xacroParser) parsePower() (xacroValue, error) {
+	base, err := p.parsePrimary()
+	if err != nil {
+		return xacroValue{}, err
+	}
+	if p.peek() == "**" {
+		p.next()
+		exponent, err := p.parseUnary()
+		if err != nil {
+			return xacroValue{}, err
+		}
+		return arithmetic("**", base, exponent)
+	}
+	return base, nil
+}
+
+func (p *xacroParser) parsePrimary() (xacroValue, error) {
+	token := p.next()
+	switch {
+	case token == "":
+		return xacroValue{}, errors.New("unexpected end of expression")
+	case token == "(":
+		value, err := p.parseOr()
+		if err != nil {
+			return xacroValue{}, err
+		}
+		if p.next() != ")" {
+			return xacroValue{}, errors.New("missing )")
+		}
+		return value, nil
+	case token[0] == '\'' || token[0] == '"':
+		return xacroValue{str: token[1 : len(token)-1]}, nil
+	case unicode.IsDigit(rune(token[0])) || token[0] == '.':
+		num, err := strconv.ParseFloat(token, 64)
+		if err != nil {
+			return xacroValue{}, err
+		}
+		return xacroNumber(num), nil
+	}
+
+	if p.peek() == "(" {
+		return p.parseCall(token)
+	}
+	name := strings.TrimPrefix(strings.TrimPrefix(token, "xacro."), "math.")
+	if property, ok := p.scope.property(name); ok {
+		value, err := p.expander.substitute(property.value, property.scope)
+		if err != nil {
+			return xacroValue{}, err
+		}
+		return parseXacroValue(value), nil
+	}
+	switch name {
+	case "pi":
+		return xacroNumber(math.Pi), nil
+	case "e":
+		return xacroNumber(math.E), nil
+	case "True", "true":
+		return xacroNumber(1), nil
+	case "False", "false":
+		return xacroNumber(0), nil
+	}
+	return xacroValue{}, errors.Errorf("undefined xacro property %q", token)
+}
+
+func (p *xacroParser) parseCall(name string) (xacroValue, error) {
+	f, ok := xacroFunctions[strings.TrimPrefix(strings.TrimPrefix(name, "xacro."), "math.")]
+	if !ok {
+		return xacroValue{}, errors.Errorf("unsupported function %q", name)
+	}
+	p.next() // (
+	var args []float64
+	for p.peek() != ")" {
+		value, err := p.parseOr()
+		if err != nil {
+			return xacroValue{}, err
+		}
+		num, err := value.number()
+		if err != nil {
+			return xacroValue{}, err
+		}
+		args = append(args, num)
+		if p.peek() == "," {
+			p.next()
+		} else if p.peek() != ")" {
+			return xacroValue{}, errors.Errorf("unexpected %q in arguments of %s", p.peek(), name)
+		}
+	}
+	p.next() // )
+	result, err := f(args...)
+	if err != nil {
+		return xacroValue{}, errors.Wrap(err, name)
+	}
+	return xacroNumber(result), nil
+}
+
+func arithmetic(op string, left, right xacroValue) (xacroValue, error) {
+	a, err := left.number()
+	if err != nil {
+		return xacroValue{}, err
+	}
+	b, err := right.number()
+	if err != nil {
+		return xacroValue{}, err
+	}
+	switch op {
+	case "+":
+		return xacroNumber(a + b), nil
+	case "-":
+		return xacroNumber(a - b), nil
+	case "*":
+		return xacroNumber(a * b), nil
+	case "/":
+		if b == 0 {
+			return xacroValue{}, errors.New("division by zero")
+		}
+		return xacroNumber(a / b), nil
+	case "%":
+		return xacroNumber(math.Mod(a, b)), nil
+	default:
+		return xacroNumber(math.Pow(a, b)), nil
+	}
+}
+
+func boolValue(b bool) xacroValue {
+	if b {
+		return xacroNumber(1)
+	}
+	return xacroNumber(0)
+}
+
+func truthy(v xacroValue) bool {
+	if v.isNum {
+		return v.num != 0
+	}
+	return v.str != ""
+}
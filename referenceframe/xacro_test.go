@@ -0,0 +1,76 @@
+package referenceframe
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.viam.com/test"
+
+	"go.viam.com/rdk/utils"
+)
+
+func TestParseXacroFile(t *testing.T) {
+	expected, err := ParseURDFFile(utils.ResolveFile("referenceframe/testurdf/ur5_viam.urdf"), "")
+	test.That(t, err, test.ShouldBeNil)
+	model, err := ParseXacroFile(utils.ResolveFile("referenceframe/testurdf/ur5_viam.xacro"), "", nil)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, model.Name(), test.ShouldEqual, "ur5")
+	testSameKinematics(t, expected, model)
+
+	// args are substituted into the names of the links and joints
+	model, err = ParseXacroFile(utils.ResolveFile("referenceframe/testurdf/ur5_viam.xacro"), "foo", map[string]string{"prefix": "left_"})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, model.Name(), test.ShouldEqual, "foo")
+	simple, ok := model.(*SimpleModel)
+	test.That(t, ok, test.ShouldBeTrue)
+	test.That(t, simple.OrdTransforms[len(simple.OrdTransforms)-1].Name(), test.ShouldEqual, "left_ee_link")
+
+	model, err = ModelFromPath(utils.ResolveFile("referenceframe/testurdf/ur5_viam.xacro"), "")
+	test.That(t, err, test.ShouldBeNil)
+	testSameKinematics(t, expected, model)
+}
+
+func TestExpandXacro(t *testing.T) {
+	expand := func(body string) (string, error) {
+		filename := filepath.Join(t.TempDir(), "test.xacro")
+		xacro := `<robot name="test" xmlns:xacro="http://www.ros.org/wiki/xacro">` + body + `</robot>`
+		test.That(t, os.WriteFile(filename, []byte(xacro), 0o600), test.ShouldBeNil)
+		expanded, err := ExpandXacroFile(filename, nil)
+		return string(expanded), err
+	}
+
+	expanded, err := expand(`
+		<xacro:property name="a" value="${b * 2}"/>
+		<xacro:property name="b" value="1.5"/>
+		<xacro:property name="name" value="link"/>
+		<link name="${name}_${a + 1}" x="${-a ** 2}" y="${atan2(1, 0) == pi / 2}" z="${radians(180)}"/>`)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, expanded, test.ShouldContainSubstring, `<link name="link_4" x="-9" y="1" z="3.141592653589793"></link>`)
+
+	// blocks, including those passed to macros as ** parameters, and properties set in a parent scope
+	expanded, err = expand(`
+		<xacro:property name="origin"><origin xyz="1 2 3"/></xacro:property>
+		<xacro:macro name="wrap" params="**contents">
+			<xacro:property name="wrapped" value="true" scope="parent"/>
+			<joint><xacro:insert_block name="contents"/></joint>
+		</xacro:macro>
+		<xacro:wrap><contents><xacro:insert_block name="origin"/><axis/></contents></xacro:wrap>
+		<xacro:if value="${wrapped}"><link name="wrapped"/></xacro:if>`)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, expanded, test.ShouldContainSubstring, `<joint><origin xyz="1 2 3"></origin><axis></axis></joint>`)
+	test.That(t, expanded, test.ShouldContainSubstring, `<link name="wrapped"></link>`)
+
+	for _, body := range []string{
+		`<link name="${undefined}"/>`,
+		`<xacro:undefined_macro/>`,
+		`<xacro:macro name="m" params="p"/><xacro:m/>`,
+		`<xacro:macro name="m" params="p"><xacro:m p="1"/></xacro:macro><xacro:m p="1"/>`,
+		`<xacro:arg name="a"/>`,
+		`<xacro:if value="maybe"/>`,
+		`<link name="${1 / 0}"/>`,
+	} {
+		_, err = expand(body)
+		test.That(t, err, test.ShouldNotBeNil)
+	}
+}
@@ -0,0 +1,366 @@
+package transform
+
+import (
+	"math"
+
+	"github.com/golang/geo/r2"
+	"github.com/golang/geo/r3"
+	"github.com/pkg/errors"
+	"gonum.org/v1/gonum/mat"
+
+	"go.viam.com/rdk/spatialmath"
+)
+
+// minCalibrationViews is the fewest views of a board that determine the intrinsics of a camera.
+const minCalibrationViews = 3
+
+// IntrinsicCalibration is the result of an intrinsic calibration. It marshals to the attributes a
+// camera's config expects.
+type IntrinsicCalibration struct {
+	Intrinsics *PinholeCameraIntrinsics `json:"intrinsic_parameters"`
+	Distortion *BrownConrady            `json:"distortion_parameters"`
+	// ReprojectionError is the root mean square distance, in pixels, between the corners found in
+	// the images and where the calibration projects them.
+	ReprojectionError float64 `json:"-"`
+}
+
+// CalibratePinholeIntrinsics solves for the intrinsics and Brown-Conrady distortion of a camera from
+// several views of a flat board, with Zhang's method: a closed form solution from the homography
+// of each view, refined by minimizing the reprojection error. boardPoints are the positions of the
+// corners on the board in mm, and each view holds the pixels the same corners were found at.
+func CalibratePinholeIntrinsics(width, height int, boardPoints []r2.Point, views [][]r2.Point) (*IntrinsicCalibration, error) {
+	if width <= 0 || height <= 0 {
+		return nil, errors.Errorf("invalid image size %dx%d", width, height)
+	}
+	if len(boardPoints) < 4 {
+		return nil, errors.Errorf("need at least 4 points on the board, only have %d", len(boardPoints))
+	}
+	if len(views) < minCalibrationViews {
+		return nil, errors.Errorf("need at least %d views of the board, only have %d", minCalibrationViews, len(views))
+	}
+	for i, view := range views {
+		if len(view) != len(boardPoints) {
+			return nil, errors.Errorf("view %d has %d points but the board has %d", i, len(view), len(boardPoints))
+		}
+	}
+
+	// the closed form solution is better conditioned in pixels scaled to around one
+	scale := math.Max(float64(width), float64(height))
+	homographies := make([]*mat.Dense, len(views))
+	for i, view := range views {
+		scaled := make([]r2.Point, len(view))
+		for j, p := range view {
+			scaled[j] = p.Mul(1 / scale)
+		}
+		h, err := planeHomography(boardPoints, scaled)
+		if err != nil {
+			return nil, errors.Wrapf(err, "view %d", i)
+		}
+		homographies[i] = h
+	}
+	fx, fy, cx, cy, err := intrinsicsFromHomographies(homographies)
+	if err != nil {
+		return nil, err
+	}
+
+	// parameters are the intrinsics, the distortion, then the rotation vector and translation of each view
+	params := make([]float64, numIntrinsicParams, numIntrinsicParams+6*len(views))
+	params[0], params[1], params[2], params[3] = fx*scale, fy*scale, cx*scale, cy*scale
+	for _, h := range homographies {
+		rotation, translation, err := extrinsicsFromHomography(fx, fy, cx, cy, h)
+		if err != nil {
+			return nil, err
+		}
+		params = append(params, rotation.X, rotation.Y, rotation.Z, translation.X, translation.Y, translation.Z)
+	}
+	residuals := func(p []float64) ([]float64, error) {
+		return reprojectionResiduals(p, boardPoints, views)
+	}
+	params, err = levenbergMarquardt(residuals, params, 100)
+	if err != nil {
+		return nil, err
+	}
+	res, err := residuals(params)
+	if err != nil {
+		return nil, err
+	}
+
+	intrinsics := &PinholeCameraIntrinsics{
+		Width:  width,
+		Height: height,
+		Fx:     params[0],
+		Fy:     params[1],
+		Ppx:    params[2],
+		Ppy:    params[3],
+	}
+	if err := intrinsics.CheckValid(); err != nil {
+		return nil, errors.Wrap(err, "calibration did not converge")
+	}
+	return &IntrinsicCalibration{
+		Intrinsics:        intrinsics,
+		Distortion:        distortionFromParams(params),
+		ReprojectionError: math.Sqrt(sumSquares(res) / float64(len(boardPoints)*len(views))),
+	}, nil
+}
+
+// numIntrinsicParams is the number of parameters for fx, fy, cx, cy and the five distortion terms.
+const numIntrinsicParams = 9
+
+func distortionFromParams(p []float64) *BrownConrady {
+	return &BrownConrady{
+		RadialK1:     p[4],
+		RadialK2:     p[5],
+		RadialK3:     p[6],
+		TangentialP1: p[7],
+		TangentialP2: p[8],
+	}
+}
+
+// reprojectionResiduals projects the board into every view and returns the differences from the
+// pixels the corners were found at.
+func reprojectionResiduals(p []float64, boardPoints []r2.Point, views [][]r2.Point) ([]float64, error) {
+	fx, fy, cx, cy := p[0], p[1], p[2], p[3]
+	distortion := distortionFromParams(p)
+	res := make([]float64, 0, 2*len(boardPoints)*len(views))
+	for i, view := range views {
+		extrinsics := p[numIntrinsicParams+6*i:]
+		rotation, err := rotationMatrix(r3.Vector{X: extrinsics[0], Y: extrinsics[1], Z: extrinsics[2]})
+		if err != nil {
+			return nil, err
+		}
+		translation := r3.Vector{X: extrinsics[3], Y: extrinsics[4], Z: extrinsics[5]}
+		for j, bp := range boardPoints {
+			pt := rotation.Mul(r3.Vector{X: bp.X, Y: bp.Y}).Add(translation)
+			x, y := distortion.Transform(pt.X/pt.Z, pt.Y/pt.Z)
+			res = append(res, fx*x+cx-view[j].X, fy*y+cy-view[j].Y)
+		}
+	}
+	return res, nil
+}
+
+// planeHomography estimates the homography from points on a plane to their pixels with the
+// normalized direct linear transform.
+func planeHomography(from, to []r2.Point) (*mat.Dense, error) {
+	fromMat, toMat := normalizingMatrix(from), normalizingMatrix(to)
+	normalize := func(m *mat.Dense, p r2.Point) r2.Point {
+		return r2.Point{X: m.At(0, 0)*p.X + m.At(0, 2), Y: m.At(1, 1)*p.Y + m.At(1, 2)}
+	}
+	a := mat.NewDense(2*len(from), 9, nil)
+	for i := range from {
+		p := normalize(fromMat, from[i])
+		q := normalize(toMat, to[i])
+		a.SetRow(2*i, []float64{p.X, p.Y, 1, 0, 0, 0, -q.X * p.X, -q.X * p.Y, -q.X})
+		a.SetRow(2*i+1, []float64{0, 0, 0, p.X, p.Y, 1, -q.Y * p.X, -q.Y * p.Y, -q.Y})
+	}
+	h, err := nullVector(a)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot estimate homography")
+	}
+	normalized := mat.NewDense(3, 3, h)
+
+	// undo the normalization of both sides
+	var toInv mat.Dense
+	if err := toInv.Inverse(toMat); err != nil {
+		return nil, errors.Wrap(err, "cannot estimate homography")
+	}
+	var result mat.Dense
+	result.Product(&toInv, normalized, fromMat)
+	if result.At(2, 2) == 0 {
+		return nil, errors.New("cannot estimate homography of degenerate points")
+	}
+	result.Scale(1/result.At(2, 2), &result)
+	return &result, nil
+}
+
+// normalizingMatrix moves points to have their centroid at the origin and an average distance of
+// sqrt(2) from it.
+func normalizingMatrix(points []r2.Point) *mat.Dense {
+	var centroid r2.Point
+	for _, p := range points {
+		centroid = centroid.Add(p)
+	}
+	centroid = centroid.Mul(1 / float64(len(points)))
+	dist := 0.
+	for _, p := range points {
+		dist += p.Sub(centroid).Norm()
+	}
+	s := 1.
+	if dist > 0 {
+		s = math.Sqrt2 * float64(len(points)) / dist
+	}
+	return mat.NewDense(3, 3, []float64{s, 0, -s * centroid.X, 0, s, -s * centroid.Y, 0, 0, 1})
+}
+
+// nullVector returns the unit vector that a matrix maps closest to zero.
+func nullVector(a mat.Matrix) ([]float64, error) {
+	var svd mat.SVD
+	if !svd.Factorize(a, mat.SVDFull) {
+		return nil, errors.New("singular value decomposition failed")
+	}
+	var v mat.Dense
+	svd.VTo(&v)
+	_, cols := v.Dims()
+	return mat.Col(nil, cols-1, &v), nil
+}
+
+// intrinsicsFromHomographies solves in closed form for the focal lengths and principal point that
+// make the homographies of all views come from rotations, assuming the pixels are not skewed.
+func intrinsicsFromHomographies(homographies []*mat.Dense) (float64, float64, float64, float64, error) {
+	constraint := func(h *mat.Dense, i, j int) []float64 {
+		return []float64{
+			h.At(0, i) * h.At(0, j),
+			h.At(0, i)*h.At(1, j) + h.At(1, i)*h.At(0, j),
+			h.At(1, i) * h.At(1, j),
+			h.At(2, i)*h.At(0, j) + h.At(0, i)*h.At(2, j),
+			h.At(2, i)*h.At(1, j) + h.At(1, i)*h.At(2, j),
+			h.At(2, i) * h.At(2, j),
+		}
+	}
+	v := mat.NewDense(2*len(homographies)+1, 6, nil)
+	for k, h := range homographies {
+		v.SetRow(2*k, constraint(h, 0, 1))
+		v11, v22 := constraint(h, 0, 0), constraint(h, 1, 1)
+		for i := range v11 {
+			v11[i] -= v22[i]
+		}
+		v.SetRow(2*k+1, v11)
+	}
+	v.SetRow(2*len(homographies), []float64{0, 1, 0, 0, 0, 0})
+	b, err := nullVector(v)
+	if err != nil {
+		return 0, 0, 0, 0, err
+	}
+	if b[0] < 0 {
+		for i := range b {
+			b[i] = -b[i]
+		}
+	}
+	b11, b12, b22, b13, b23, b33 := b[0], b[1], b[2], b[3], b[4], b[5]
+	denom := b11*b22 - b12*b12
+	if b11 <= 0 || denom <= 0 {
+		return 0, 0, 0, 0, errors.New("the views are degenerate, the board has to be seen from several different angles")
+	}
+	cy := (b12*b13 - b11*b23) / denom
+	lambda := b33 - (b13*b13+cy*(b12*b13-b11*b23))/b11
+	if lambda/b11 <= 0 {
+		return 0, 0, 0, 0, errors.New("the views are degenerate, the board has to be seen from several different angles")
+	}
+	fx := math.Sqrt(lambda / b11)
+	fy := math.Sqrt(lambda * b11 / denom)
+	cx := -b13 * fx * fx / lambda
+	return fx, fy, cx, cy, nil
+}
+
+// extrinsicsFromHomography returns the rotation vector and translation of a view of the board
+// from its homography and the intrinsics.
+func extrinsicsFromHomography(fx, fy, cx, cy float64, h *mat.Dense) (r3.Vector, r3.Vector, error) {
+	unproject := func(col int) r3.Vector {
+		return r3.Vector{
+			X: (h.At(0, col) - cx*h.At(2, col)) / fx,
+			Y: (h.At(1, col) - cy*h.At(2, col)) / fy,
+			Z: h.At(2, col),
+		}
+	}
+	col1, col2, t := unproject(0), unproject(1), unproject(2)
+	scale := 1 / col1.Norm()
+	// the board is in front of the camera
+	if t.Z < 0 {
+		scale = -scale
+	}
+	col1, col2, t = col1.Mul(scale), col2.Mul(scale), t.Mul(scale)
+	col3 := col1.Cross(col2)
+
+	// the columns are only roughly orthonormal, so use the closest rotation to them
+	m := mat.NewDense(3, 3, []float64{col1.X, col2.X, col3.X, col1.Y, col2.Y, col3.Y, col1.Z, col2.Z, col3.Z})
+	var svd mat.SVD
+	if !svd.Factorize(m, mat.SVDFull) {
+		return r3.Vector{}, r3.Vector{}, errors.New("singular value decomposition failed")
+	}
+	var u, v, closest mat.Dense
+	svd.UTo(&u)
+	svd.VTo(&v)
+	closest.Mul(&u, v.T())
+	rotation, err := spatialmath.NewRotationMatrix(closest.RawMatrix().Data)
+	if err != nil {
+		return r3.Vector{}, r3.Vector{}, err
+	}
+	return rotationVector(rotation), t, nil
+}
+
+func sumSquares(values []float64) float64 {
+	sum := 0.
+	for _, v := range values {
+		sum += v * v
+	}
+	return sum
+}
+
+// levenbergMarquardt minimizes the sum of the squares of the residuals, with a jacobian from
+// forward differences.
+func levenbergMarquardt(
+	residuals func([]float64) ([]float64, error), params []float64, maxIterations int,
+) ([]float64, error) {
+	res, err := residuals(params)
+	if err != nil {
+		return nil, err
+	}
+	cost := sumSquares(res)
+	damping := 1e-3
+	n := len(params)
+	for iter := 0; iter < maxIterations; iter++ {
+		jac := mat.NewDense(len(res), n, nil)
+		for j := range params {
+			step := 1e-6 * math.Max(1, math.Abs(params[j]))
+			shifted := append([]float64(nil), params...)
+			shifted[j] += step
+			shiftedRes, err := residuals(shifted)
+			if err != nil {
+				return nil, err
+			}
+			for i := range res {
+				jac.Set(i, j, (shiftedRes[i]-res[i])/step)
+			}
+		}
+		var jtj mat.Dense
+		jtj.Mul(jac.T(), jac)
+		var jtr mat.VecDense
+		jtr.MulVec(jac.T(), mat.NewVecDense(len(res), res))
+
+		improved := false
+		for attempt := 0; attempt < 10 && !improved; attempt++ {
+			damped := mat.DenseCopyOf(&jtj)
+			for j := 0; j < n; j++ {
+				damped.Set(j, j, damped.At(j, j)+damping*math.Max(damped.At(j, j), 1e-9))
+			}
+			var delta mat.VecDense
+			if err := delta.SolveVec(damped, &jtr); err != nil {
+				damping *= 10
+				continue
+			}
+			next := make([]float64, n)
+			for j := range params {
+				next[j] = params[j] - delta.AtVec(j)
+			}
+			nextRes, err := residuals(next)
+			if err != nil {
+				return nil, err
+			}
+			nextCost := sumSquares(nextRes)
+			if nextCost >= cost {
+				damping *= 10
+				continue
+			}
+			improved = true
+			converged := cost-nextCost < 1e-10*cost
+			params, res, cost = next, nextRes, nextCost
+			damping = math.Max(damping/10, 1e-12)
+			if converged {
+				return params, nil
+			}
+		}
+		if !improved {
+			break
+		}
+	}
+	return params, nil
+}
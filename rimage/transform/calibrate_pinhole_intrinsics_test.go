@@ -0,0 +1,85 @@
+package transform
+
+import (
+	"encoding/json"
+	"math/rand"
+	"testing"
+
+	"github.com/golang/geo/r2"
+	"github.com/golang/geo/r3"
+	"go.viam.com/test"
+)
+
+// calibrationTestViews are the tilts and positions of the board, which cover the whole image.
+var calibrationTestViews = []struct{ tilt, position r3.Vector }{
+	{r3.Vector{X: 0.3}, r3.Vector{Z: 450}},
+	{r3.Vector{X: -0.35, Z: 0.1}, r3.Vector{X: -90, Y: -60, Z: 500}},
+	{r3.Vector{Y: 0.4}, r3.Vector{X: 90, Y: -60, Z: 500}},
+	{r3.Vector{Y: -0.3, Z: -0.2}, r3.Vector{X: -90, Y: 60, Z: 500}},
+	{r3.Vector{X: 0.25, Y: 0.25}, r3.Vector{X: 90, Y: 60, Z: 500}},
+	{r3.Vector{X: -0.2, Y: -0.3, Z: 1.2}, r3.Vector{Z: 550}},
+}
+
+func TestCalibratePinholeIntrinsics(t *testing.T) {
+	cols, rows, square := 9, 6, 25.
+	board := CheckerboardPoints(cols, rows, square)
+	distortion := &BrownConrady{RadialK1: -0.2, RadialK2: 0.05, TangentialP1: 0.001, TangentialP2: -0.002}
+	//nolint:gosec
+	noise := rand.New(rand.NewSource(1))
+	var views [][]r2.Point
+	for _, view := range calibrationTestViews {
+		rotation, translation := checkerboardTestView(cols, rows, square, view.tilt, view.position)
+		pixels := projectCheckerboard(checkerboardTestIntrinsics, distortion, board, rotation, translation)
+		for j := range pixels {
+			pixels[j] = pixels[j].Add(r2.Point{X: noise.NormFloat64() * 0.1, Y: noise.NormFloat64() * 0.1})
+		}
+		views = append(views, pixels)
+	}
+
+	calib, err := CalibratePinholeIntrinsics(640, 480, board, views)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, calib.Intrinsics.Width, test.ShouldEqual, 640)
+	test.That(t, calib.Intrinsics.Height, test.ShouldEqual, 480)
+	test.That(t, calib.Intrinsics.Fx, test.ShouldAlmostEqual, checkerboardTestIntrinsics.Fx, 2)
+	test.That(t, calib.Intrinsics.Fy, test.ShouldAlmostEqual, checkerboardTestIntrinsics.Fy, 2)
+	test.That(t, calib.Intrinsics.Ppx, test.ShouldAlmostEqual, checkerboardTestIntrinsics.Ppx, 2)
+	test.That(t, calib.Intrinsics.Ppy, test.ShouldAlmostEqual, checkerboardTestIntrinsics.Ppy, 2)
+	test.That(t, calib.Distortion.RadialK1, test.ShouldAlmostEqual, distortion.RadialK1, 0.02)
+	test.That(t, calib.Distortion.TangentialP1, test.ShouldAlmostEqual, distortion.TangentialP1, 0.001)
+	test.That(t, calib.ReprojectionError, test.ShouldBeLessThan, 0.2)
+
+	// the result is the block of attributes a camera expects
+	out, err := json.Marshal(calib)
+	test.That(t, err, test.ShouldBeNil)
+	var attrs map[string]map[string]float64
+	test.That(t, json.Unmarshal(out, &attrs), test.ShouldBeNil)
+	test.That(t, attrs, test.ShouldContainKey, "intrinsic_parameters")
+	test.That(t, attrs["intrinsic_parameters"], test.ShouldContainKey, "fx")
+	test.That(t, attrs["distortion_parameters"], test.ShouldContainKey, "rk1")
+	test.That(t, attrs, test.ShouldHaveLength, 2)
+
+	_, err = CalibratePinholeIntrinsics(640, 480, board, views[:2])
+	test.That(t, err, test.ShouldNotBeNil)
+	_, err = CalibratePinholeIntrinsics(640, 480, board, [][]r2.Point{views[0], views[1], views[2][:10]})
+	test.That(t, err, test.ShouldNotBeNil)
+}
+
+func TestCalibratePinholeIntrinsicsFromImages(t *testing.T) {
+	cols, rows, square := 7, 5, 30.
+	board := CheckerboardPoints(cols, rows, square)
+	var views [][]r2.Point
+	for _, view := range calibrationTestViews {
+		rotation, translation := checkerboardTestView(cols, rows, square, view.tilt, view.position)
+		img := renderCheckerboard(checkerboardTestIntrinsics, cols, rows, square, rotation, translation)
+		corners, err := FindCheckerboardCorners(img, cols, rows)
+		test.That(t, err, test.ShouldBeNil)
+		views = append(views, corners)
+	}
+	calib, err := CalibratePinholeIntrinsics(640, 480, board, views)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, calib.Intrinsics.Fx, test.ShouldAlmostEqual, checkerboardTestIntrinsics.Fx, 10)
+	test.That(t, calib.Intrinsics.Fy, test.ShouldAlmostEqual, checkerboardTestIntrinsics.Fy, 10)
+	test.That(t, calib.Intrinsics.Ppx, test.ShouldAlmostEqual, checkerboardTestIntrinsics.Ppx, 10)
+	test.That(t, calib.Intrinsics.Ppy, test.ShouldAlmostEqual, checkerboardTestIntrinsics.Ppy, 10)
+	test.That(t, calib.ReprojectionError, test.ShouldBeLessThan, 0.5)
+}
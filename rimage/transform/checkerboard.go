@@ -0,0 +1,398 @@
+package transform
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"sort"
+
+	"github.com/golang/geo/r2"
+	"github.com/pkg/errors"
+
+	"go.viam.com/rdk/utils"
+)
+
+// CheckerboardPoints returns the positions in mm, on the plane of the board, of the inner corners
+// of a checkerboard with cols by rows inner corners, ordered row by row.
+func CheckerboardPoints(cols, rows int, squareMM float64) []r2.Point {
+	points := make([]r2.Point, 0, cols*rows)
+	for v := 0; v < rows; v++ {
+		for u := 0; u < cols; u++ {
+			points = append(points, r2.Point{X: float64(u) * squareMM, Y: float64(v) * squareMM})
+		}
+	}
+	return points
+}
+
+// FindCheckerboardCorners finds the inner corners of a checkerboard with cols by rows inner corners
+// in an image, with sub-pixel precision. The corners are ordered row by row, in the same order as
+// CheckerboardPoints, so the two can be matched up for calibration.
+func FindCheckerboardCorners(img image.Image, cols, rows int) ([]r2.Point, error) {
+	if cols < 2 || rows < 2 {
+		return nil, errors.Errorf("a checkerboard needs at least 2x2 inner corners, got %dx%d", cols, rows)
+	}
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	sigma := math.Max(1, float64(utils.MinInt(width, height))/320)
+	lum := make([]float64, width*height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			lum[y*width+x] = float64(color.GrayModel.Convert(img.At(bounds.Min.X+x, bounds.Min.Y+y)).(color.Gray).Y)
+		}
+	}
+	lum = gaussianBlur(lum, width, height, sigma)
+
+	candidates := saddlePoints(lum, width, height, int(math.Ceil(2*sigma)))
+	refineCorners(lum, width, height, candidates, int(math.Max(3, math.Round(3*sigma))))
+	candidates = mergeCloseCorners(candidates, 2*sigma)
+	candidates = crossingCorners(lum, width, height, candidates, 3*sigma)
+
+	// the strongest corners come first, so a few of them are enough seeds to find the board
+	var best map[image.Point]int
+	for seed := 0; seed < utils.MinInt(len(candidates), 50); seed++ {
+		grid := growGrid(candidates, seed)
+		if len(grid) > len(best) {
+			best = grid
+		}
+		if len(best) >= cols*rows {
+			break
+		}
+	}
+	corners, ok := orderGrid(candidates, best, cols, rows)
+	if !ok {
+		return nil, errors.Errorf("found %d corners in a grid but a %dx%d checkerboard has %d", len(best), cols, rows, cols*rows)
+	}
+	return corners, nil
+}
+
+// gaussianBlur blurs an image of floats with a separable gaussian kernel, clamping at the borders.
+func gaussianBlur(in []float64, width, height int, sigma float64) []float64 {
+	radius := int(math.Ceil(3 * sigma))
+	kernel := make([]float64, 2*radius+1)
+	sum := 0.
+	for i := range kernel {
+		d := float64(i - radius)
+		kernel[i] = math.Exp(-d * d / (2 * sigma * sigma))
+		sum += kernel[i]
+	}
+	for i := range kernel {
+		kernel[i] /= sum
+	}
+	clamp := func(v, hi int) int {
+		return utils.MaxInt(0, utils.MinInt(hi-1, v))
+	}
+	tmp := make([]float64, len(in))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			acc := 0.
+			for i, k := range kernel {
+				acc += k * in[y*width+clamp(x+i-radius, width)]
+			}
+			tmp[y*width+x] = acc
+		}
+	}
+	out := make([]float64, len(in))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			acc := 0.
+			for i, k := range kernel {
+				acc += k * tmp[clamp(y+i-radius, height)*width+x]
+			}
+			out[y*width+x] = acc
+		}
+	}
+	return out
+}
+
+// saddlePoints finds the pixels where the image curves up one way and down the other, which is
+// what the corner between four squares of a checkerboard looks like, as the local maxima of the
+// negated determinant of the hessian.
+func saddlePoints(lum []float64, width, height, radius int) []r2.Point {
+	response := make([]float64, width*height)
+	maxResponse := 0.
+	for y := 1; y < height-1; y++ {
+		for x := 1; x < width-1; x++ {
+			i := y*width + x
+			ixx := lum[i+1] - 2*lum[i] + lum[i-1]
+			iyy := lum[i+width] - 2*lum[i] + lum[i-width]
+			ixy := (lum[i+width+1] - lum[i+width-1] - lum[i-width+1] + lum[i-width-1]) / 4
+			if r := ixy*ixy - ixx*iyy; r > 0 {
+				response[i] = r
+				maxResponse = math.Max(maxResponse, r)
+			}
+		}
+	}
+
+	type candidate struct {
+		point    r2.Point
+		response float64
+	}
+	var found []candidate
+	threshold := 0.05 * maxResponse
+	for y := radius; y < height-radius; y++ {
+		for x := radius; x < width-radius; x++ {
+			r := response[y*width+x]
+			if r <= threshold {
+				continue
+			}
+			isMax := true
+			for dy := -radius; dy <= radius && isMax; dy++ {
+				for dx := -radius; dx <= radius; dx++ {
+					other := response[(y+dy)*width+x+dx]
+					// break ties towards the top left so plateaus give one point
+					if other > r || (other == r && (dy < 0 || (dy == 0 && dx < 0))) {
+						isMax = false
+						break
+					}
+				}
+			}
+			if isMax {
+				found = append(found, candidate{r2.Point{X: float64(x), Y: float64(y)}, r})
+			}
+		}
+	}
+	// the strongest corners are tried first as seeds for the grid
+	sort.SliceStable(found, func(i, j int) bool { return found[i].response > found[j].response })
+	points := make([]r2.Point, len(found))
+	for i, c := range found {
+		points[i] = c.point
+	}
+	return points
+}
+
+// refineCorners moves each corner to the point that the gradients around it are perpendicular to
+// the direction to, which is where the edges between the squares cross.
+func refineCorners(lum []float64, width, height int, corners []r2.Point, radius int) {
+	for i, corner := range corners {
+		for iter := 0; iter < 10; iter++ {
+			cx, cy := int(math.Round(corner.X)), int(math.Round(corner.Y))
+			if cx-radius < 1 || cy-radius < 1 || cx+radius >= width-1 || cy+radius >= height-1 {
+				break
+			}
+			var gxx, gxy, gyy, bx, by float64
+			for y := cy - radius; y <= cy+radius; y++ {
+				for x := cx - radius; x <= cx+radius; x++ {
+					gx := (lum[y*width+x+1] - lum[y*width+x-1]) / 2
+					gy := (lum[(y+1)*width+x] - lum[(y-1)*width+x]) / 2
+					gxx += gx * gx
+					gxy += gx * gy
+					gyy += gy * gy
+					bx += gx*gx*float64(x) + gx*gy*float64(y)
+					by += gx*gy*float64(x) + gy*gy*float64(y)
+				}
+			}
+			det := gxx*gyy - gxy*gxy
+			if det <= 1e-9 {
+				break
+			}
+			next := r2.Point{X: (gyy*bx - gxy*by) / det, Y: (gxx*by - gxy*bx) / det}
+			if next.Sub(corners[i]).Norm() > float64(radius) {
+				break
+			}
+			moved := next.Sub(corner).Norm()
+			corner = next
+			if moved < 0.01 {
+				break
+			}
+		}
+		corners[i] = corner
+	}
+}
+
+// mergeCloseCorners drops corners that refined onto a stronger corner.
+func mergeCloseCorners(corners []r2.Point, minDist float64) []r2.Point {
+	var kept []r2.Point
+	for _, c := range corners {
+		duplicate := false
+		for _, k := range kept {
+			if c.Sub(k).Norm() < minDist {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			kept = append(kept, c)
+		}
+	}
+	return kept
+}
+
+// crossingCorners keeps the corners that four squares meet at, by going around a circle centered on
+// each one and checking that it passes from dark to bright four times. The corners of the squares
+// on the edge of the board only do so twice.
+func crossingCorners(lum []float64, width, height int, corners []r2.Point, radius float64) []r2.Point {
+	const samples = 16
+	sample := func(p r2.Point) float64 {
+		x, y := int(math.Round(p.X)), int(math.Round(p.Y))
+		return lum[utils.MaxInt(0, utils.MinInt(height-1, y))*width+utils.MaxInt(0, utils.MinInt(width-1, x))]
+	}
+	var kept []r2.Point
+	for _, c := range corners {
+		values := make([]float64, samples)
+		lo, hi := math.Inf(1), math.Inf(-1)
+		for i := range values {
+			angle := 2 * math.Pi * float64(i) / samples
+			values[i] = sample(c.Add(r2.Point{X: radius * math.Cos(angle), Y: radius * math.Sin(angle)}))
+			lo, hi = math.Min(lo, values[i]), math.Max(hi, values[i])
+		}
+		if hi-lo < 20 {
+			continue
+		}
+		mid := (lo + hi) / 2
+		changes := 0
+		for i, v := range values {
+			if (v > mid) != (values[(i+1)%samples] > mid) {
+				changes++
+			}
+		}
+		if changes == 4 {
+			kept = append(kept, c)
+		}
+	}
+	return kept
+}
+
+// growGrid builds a grid of corners starting from a seed, by repeatedly predicting where the next
+// corner along each row and column should be from the corners already in the grid. It returns the
+// index of the corner at each grid position.
+func growGrid(corners []r2.Point, seed int) map[image.Point]int {
+	// the two closest corners that are roughly perpendicular to each other give the first steps
+	type neighbor struct {
+		index int
+		dist  float64
+	}
+	neighbors := make([]neighbor, 0, len(corners))
+	for i, c := range corners {
+		if i != seed {
+			neighbors = append(neighbors, neighbor{i, c.Sub(corners[seed]).Norm()})
+		}
+	}
+	if len(neighbors) < 2 {
+		return nil
+	}
+	sort.Slice(neighbors, func(i, j int) bool { return neighbors[i].dist < neighbors[j].dist })
+	first := neighbors[0]
+	stepU := corners[first.index].Sub(corners[seed])
+	second := -1
+	for _, n := range neighbors[1:utils.MinInt(len(neighbors), 8)] {
+		step := corners[n.index].Sub(corners[seed])
+		if n.dist > 2*first.dist {
+			break
+		}
+		if math.Abs(step.Dot(stepU))/(n.dist*first.dist) < 0.5 {
+			second = n.index
+			break
+		}
+	}
+	if second < 0 {
+		return nil
+	}
+
+	grid := map[image.Point]int{{0, 0}: seed, {1, 0}: first.index, {0, 1}: second}
+	used := map[int]bool{seed: true, first.index: true, second: true}
+	queue := []image.Point{{0, 0}, {1, 0}, {0, 1}}
+	directions := []image.Point{{1, 0}, {-1, 0}, {0, 1}, {0, -1}}
+	for len(queue) > 0 {
+		cell := queue[0]
+		queue = queue[1:]
+		pos := corners[grid[cell]]
+		for _, dir := range directions {
+			next := cell.Add(dir)
+			if _, ok := grid[next]; ok {
+				continue
+			}
+			step, ok := gridStep(corners, grid, cell, dir)
+			if !ok {
+				continue
+			}
+			predicted := pos.Add(step)
+			tolerance := 0.35 * step.Norm()
+			match, matchDist := -1, tolerance
+			for i, c := range corners {
+				if used[i] {
+					continue
+				}
+				if d := c.Sub(predicted).Norm(); d < matchDist {
+					match, matchDist = i, d
+				}
+			}
+			if match < 0 {
+				continue
+			}
+			grid[next] = match
+			used[match] = true
+			queue = append(queue, next)
+		}
+	}
+	return grid
+}
+
+// gridStep predicts the step from a cell of the grid to its neighbor in the given direction, from
+// the step between two cells already in the grid that are in line with it or next to it.
+func gridStep(corners []r2.Point, grid map[image.Point]int, cell, dir image.Point) (r2.Point, bool) {
+	perp := image.Point{dir.Y, dir.X}
+	for _, offset := range []image.Point{{}, perp, perp.Mul(-1)} {
+		from, to := cell.Add(offset), cell.Add(offset).Add(dir)
+		if a, ok := grid[from]; ok {
+			if b, ok := grid[to]; ok {
+				return corners[b].Sub(corners[a]), true
+			}
+		}
+		// the step behind the cell continues the same way
+		from, to = cell.Add(offset).Sub(dir), cell.Add(offset)
+		if a, ok := grid[from]; ok {
+			if b, ok := grid[to]; ok {
+				return corners[b].Sub(corners[a]), true
+			}
+		}
+	}
+	return r2.Point{}, false
+}
+
+// orderGrid orders the corners of a complete grid row by row, with cols corners in each row, so
+// that the order is not mirrored with respect to CheckerboardPoints.
+func orderGrid(corners []r2.Point, grid map[image.Point]int, cols, rows int) ([]r2.Point, bool) {
+	if len(grid) != cols*rows {
+		return nil, false
+	}
+	minCell := image.Point{math.MaxInt, math.MaxInt}
+	maxCell := image.Point{math.MinInt, math.MinInt}
+	for cell := range grid {
+		minCell = image.Point{utils.MinInt(minCell.X, cell.X), utils.MinInt(minCell.Y, cell.Y)}
+		maxCell = image.Point{utils.MaxInt(maxCell.X, cell.X), utils.MaxInt(maxCell.Y, cell.Y)}
+	}
+	size := maxCell.Sub(minCell).Add(image.Point{1, 1})
+	at := func(u, v int) r2.Point {
+		return corners[grid[minCell.Add(image.Point{u, v})]]
+	}
+	switch size {
+	case image.Point{cols, rows}:
+	case image.Point{rows, cols}:
+		at = func(u, v int) r2.Point {
+			return corners[grid[minCell.Add(image.Point{v, u})]]
+		}
+	default:
+		return nil, false
+	}
+
+	// the board is seen from the front, so its axes keep their handedness in the image
+	stepU, stepV := at(1, 0).Sub(at(0, 0)), at(0, 1).Sub(at(0, 0))
+	flipV := stepU.Cross(stepV) < 0
+	ordered := make([]r2.Point, 0, cols*rows)
+	for v := 0; v < rows; v++ {
+		for u := 0; u < cols; u++ {
+			if flipV {
+				ordered = append(ordered, at(u, rows-1-v))
+			} else {
+				ordered = append(ordered, at(u, v))
+			}
+		}
+	}
+	// start from the corner closest to the top left of the image, which only turns the board
+	if last := ordered[len(ordered)-1]; last.X+last.Y < ordered[0].X+ordered[0].Y {
+		for i, j := 0, len(ordered)-1; i < j; i, j = i+1, j-1 {
+			ordered[i], ordered[j] = ordered[j], ordered[i]
+		}
+	}
+	return ordered, true
+}
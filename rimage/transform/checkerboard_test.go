@@ -0,0 +1,128 @@
+package transform
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/golang/geo/r2"
+	"github.com/golang/geo/r3"
+	"go.viam.com/test"
+)
+
+var checkerboardTestIntrinsics = &PinholeCameraIntrinsics{
+	Width:  640,
+	Height: 480,
+	Fx:     500,
+	Fy:     520,
+	Ppx:    330,
+	Ppy:    235,
+}
+
+// checkerboardTestView places the center of a board with cols by rows inner corners at a position
+// in front of the camera, tilted by the given rotation vector, and returns the rotation vector and
+// translation of the view.
+func checkerboardTestView(cols, rows int, squareMM float64, tilt, position r3.Vector) (r3.Vector, r3.Vector) {
+	rotation, _ := rotationMatrix(tilt)
+	center := r3.Vector{X: float64(cols-1) * squareMM / 2, Y: float64(rows-1) * squareMM / 2}
+	return tilt, position.Sub(rotation.Mul(center))
+}
+
+// renderCheckerboard draws a board with cols by rows inner corners, and a white border, as a
+// camera without distortion sees it.
+func renderCheckerboard(
+	intrinsics *PinholeCameraIntrinsics, cols, rows int, squareMM float64, tilt, translation r3.Vector,
+) *image.Gray {
+	rotation, _ := rotationMatrix(tilt)
+	// the camera center and the rays through each pixel, in the frame of the board
+	toBoard := func(v r3.Vector) r3.Vector {
+		return r3.Vector{X: rotation.Col(0).Dot(v), Y: rotation.Col(1).Dot(v), Z: rotation.Col(2).Dot(v)}
+	}
+	center := toBoard(translation).Mul(-1)
+	shade := func(u, v float64) float64 {
+		ray := toBoard(r3.Vector{X: (u - intrinsics.Ppx) / intrinsics.Fx, Y: (v - intrinsics.Ppy) / intrinsics.Fy, Z: 1})
+		s := -center.Z / ray.Z
+		if s <= 0 {
+			return 128
+		}
+		p := center.Add(ray.Mul(s))
+		col, row := math.Floor(p.X/squareMM), math.Floor(p.Y/squareMM)
+		switch {
+		case col < -2 || col > float64(cols+1) || row < -2 || row > float64(rows+1):
+			return 128
+		case col < -1 || col > float64(cols-1) || row < -1 || row > float64(rows-1):
+			return 255
+		case int(col+row)%2 == 0:
+			return 20
+		default:
+			return 235
+		}
+	}
+	img := image.NewGray(image.Rect(0, 0, intrinsics.Width, intrinsics.Height))
+	for y := 0; y < intrinsics.Height; y++ {
+		for x := 0; x < intrinsics.Width; x++ {
+			// supersample to get edges with sub-pixel positions
+			sum := 0.
+			for _, dy := range []float64{-1. / 3, 0, 1. / 3} {
+				for _, dx := range []float64{-1. / 3, 0, 1. / 3} {
+					sum += shade(float64(x)+dx, float64(y)+dy)
+				}
+			}
+			img.SetGray(x, y, color.Gray{uint8(math.Round(sum / 9))})
+		}
+	}
+	return img
+}
+
+// projectCheckerboard returns the pixels the inner corners of a board project to.
+func projectCheckerboard(
+	intrinsics *PinholeCameraIntrinsics, distortion *BrownConrady, board []r2.Point, tilt, translation r3.Vector,
+) []r2.Point {
+	rotation, _ := rotationMatrix(tilt)
+	pixels := make([]r2.Point, len(board))
+	for i, bp := range board {
+		p := rotation.Mul(r3.Vector{X: bp.X, Y: bp.Y}).Add(translation)
+		x, y := distortion.Transform(p.X/p.Z, p.Y/p.Z)
+		pixels[i] = r2.Point{X: intrinsics.Fx*x + intrinsics.Ppx, Y: intrinsics.Fy*y + intrinsics.Ppy}
+	}
+	return pixels
+}
+
+func TestFindCheckerboardCorners(t *testing.T) {
+	cols, rows, square := 7, 5, 30.
+	board := CheckerboardPoints(cols, rows, square)
+	test.That(t, board, test.ShouldHaveLength, 35)
+	test.That(t, board[8], test.ShouldResemble, r2.Point{X: 30, Y: 30})
+
+	for _, tilt := range []r3.Vector{{}, {X: 0.4}, {Y: -0.5, Z: 0.3}, {Z: math.Pi / 2}} {
+		rotation, translation := checkerboardTestView(cols, rows, square, tilt, r3.Vector{Z: 600})
+		img := renderCheckerboard(checkerboardTestIntrinsics, cols, rows, square, rotation, translation)
+		corners, err := FindCheckerboardCorners(img, cols, rows)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, corners, test.ShouldHaveLength, cols*rows)
+
+		// the corners are found in order, or turned half way around since the board looks the same
+		expected := projectCheckerboard(checkerboardTestIntrinsics, nil, board, rotation, translation)
+		if corners[0].Sub(expected[0]).Norm() > corners[0].Sub(expected[len(expected)-1]).Norm() {
+			for i, j := 0, len(expected)-1; i < j; i, j = i+1, j-1 {
+				expected[i], expected[j] = expected[j], expected[i]
+			}
+		}
+		for i := range corners {
+			test.That(t, corners[i].Sub(expected[i]).Norm(), test.ShouldBeLessThan, 0.3)
+		}
+	}
+
+	// a board that is turned a quarter of the way around still has 7 corners per row
+	rotation, translation := checkerboardTestView(cols, rows, square, r3.Vector{Z: math.Pi / 2}, r3.Vector{Z: 600})
+	img := renderCheckerboard(checkerboardTestIntrinsics, cols, rows, square, rotation, translation)
+	corners, err := FindCheckerboardCorners(img, cols, rows)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, math.Abs(corners[1].Sub(corners[0]).Y), test.ShouldBeGreaterThan, math.Abs(corners[1].Sub(corners[0]).X))
+
+	_, err = FindCheckerboardCorners(img, 8, 5)
+	test.That(t, err, test.ShouldNotBeNil)
+	_, err = FindCheckerboardCorners(image.NewGray(image.Rect(0, 0, 100, 100)), 7, 5)
+	test.That(t, err, test.ShouldNotBeNil)
+}
@@ -0,0 +1,153 @@
+// Finds the intrinsic parameters and distortion of a camera from images of a checkerboard, and
+// prints them as the attributes the camera's config expects. The board should fill a good part of
+// the image, be seen from several different angles, and cover the corners of the image.
+// The images can be files:
+// $./intrinsic_calibration -cols=9 -rows=6 -square=25 /path/to/images/*.png
+// or frames from a camera of a running robot, taken while the board is moved around:
+// $./intrinsic_calibration -cols=9 -rows=6 -square=25 -address=localhost:8080 -camera=cam -frames=20
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"image"
+	"time"
+
+	"github.com/edaniels/golog"
+	"github.com/golang/geo/r2"
+	"github.com/pkg/errors"
+	"go.viam.com/utils"
+
+	"go.viam.com/rdk/components/camera"
+	"go.viam.com/rdk/rimage"
+	"go.viam.com/rdk/rimage/transform"
+	"go.viam.com/rdk/robot/client"
+)
+
+func main() {
+	cols := flag.Int("cols", 9, "number of inner corners in each row of the checkerboard")
+	rows := flag.Int("rows", 6, "number of inner corners in each column of the checkerboard")
+	square := flag.Float64("square", 25, "size of the squares of the checkerboard in mm")
+	address := flag.String("address", "", "address of a robot to take frames from, instead of image files")
+	cameraName := flag.String("camera", "", "name of the camera on the robot to take frames from")
+	frames := flag.Int("frames", 20, "number of frames with the board in them to take from the camera")
+	interval := flag.Duration("interval", time.Second, "time between frames taken from the camera")
+	flag.Parse()
+	logger := golog.NewLogger("intrinsic_calibration")
+
+	cal := newCalibrator(*cols, *rows, *square, logger)
+	var err error
+	if *address != "" {
+		err = cal.addCameraFrames(context.Background(), *address, *cameraName, *frames, *interval)
+	} else {
+		err = cal.addImageFiles(flag.Args())
+	}
+	if err != nil {
+		logger.Fatal(err)
+	}
+	calib, err := cal.calibrate()
+	if err != nil {
+		logger.Fatal(err)
+	}
+	logger.Infof("reprojection error: %.3f px", calib.ReprojectionError)
+	out, err := json.MarshalIndent(calib, "", "  ")
+	if err != nil {
+		logger.Fatal(err)
+	}
+	fmt.Println(string(out))
+}
+
+// calibrator collects the corners of a checkerboard from views of it.
+type calibrator struct {
+	cols, rows int
+	board      []r2.Point
+	size       image.Point
+	views      [][]r2.Point
+	logger     golog.Logger
+}
+
+func newCalibrator(cols, rows int, squareMM float64, logger golog.Logger) *calibrator {
+	return &calibrator{
+		cols:   cols,
+		rows:   rows,
+		board:  transform.CheckerboardPoints(cols, rows, squareMM),
+		logger: logger,
+	}
+}
+
+// addView finds the board in an image, and returns whether it was there.
+func (c *calibrator) addView(img image.Image) (bool, error) {
+	size := img.Bounds().Size()
+	if len(c.views) == 0 {
+		c.size = size
+	} else if size != c.size {
+		return false, errors.Errorf("image is %v but the earlier images are %v", size, c.size)
+	}
+	corners, err := transform.FindCheckerboardCorners(img, c.cols, c.rows)
+	if err != nil {
+		c.logger.Debug(err)
+		return false, nil
+	}
+	c.views = append(c.views, corners)
+	return true, nil
+}
+
+// addImageFiles adds a view for every image file the board is found in.
+func (c *calibrator) addImageFiles(paths []string) error {
+	if len(paths) == 0 {
+		return errors.New("no images given")
+	}
+	for _, path := range paths {
+		img, err := rimage.NewImageFromFile(path)
+		if err != nil {
+			return err
+		}
+		found, err := c.addView(img)
+		if err != nil {
+			return errors.Wrap(err, path)
+		}
+		if !found {
+			c.logger.Warnf("no %dx%d checkerboard found in %s", c.cols, c.rows, path)
+		}
+	}
+	return nil
+}
+
+// addCameraFrames takes frames from a camera of a robot until the board was found in enough of them.
+func (c *calibrator) addCameraFrames(ctx context.Context, address, name string, frames int, interval time.Duration) error {
+	robot, err := client.New(ctx, address, c.logger)
+	if err != nil {
+		return err
+	}
+	defer utils.UncheckedErrorFunc(func() error { return robot.Close(ctx) })
+	cam, err := camera.FromRobot(robot, name)
+	if err != nil {
+		return err
+	}
+	for len(c.views) < frames {
+		img, release, err := camera.ReadImage(ctx, cam)
+		if err != nil {
+			return err
+		}
+		found, err := c.addView(img)
+		release()
+		if err != nil {
+			return err
+		}
+		if found {
+			c.logger.Infof("found the board in %d of %d frames, move it to a new position", len(c.views), frames)
+		} else {
+			c.logger.Infof("no %dx%d checkerboard found", c.cols, c.rows)
+		}
+		if !utils.SelectContextOrWait(ctx, interval) {
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+func (c *calibrator) calibrate() (*transform.IntrinsicCalibration, error) {
+	return transform.CalibratePinholeIntrinsics(c.size.X, c.size.Y, c.board, c.views)
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"path/filepath"
+	"testing"
+
+	"github.com/edaniels/golog"
+	"go.viam.com/test"
+
+	"go.viam.com/rdk/rimage"
+)
+
+// flatCheckerboard draws a checkerboard with cols by rows inner corners facing the camera.
+func flatCheckerboard(width, height, cols, rows, squarePx int) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	for i := range img.Pix {
+		img.Pix[i] = 255
+	}
+	left, top := (width-(cols+1)*squarePx)/2, (height-(rows+1)*squarePx)/2
+	for y := 0; y < (rows+1)*squarePx; y++ {
+		for x := 0; x < (cols+1)*squarePx; x++ {
+			if (x/squarePx+y/squarePx)%2 == 0 {
+				img.SetGray(left+x, top+y, color.Gray{0})
+			}
+		}
+	}
+	return img
+}
+
+func TestAddImageFiles(t *testing.T) {
+	logger := golog.NewTestLogger(t)
+	dir := t.TempDir()
+	board := filepath.Join(dir, "board.png")
+	test.That(t, rimage.WriteImageToFile(board, flatCheckerboard(320, 240, 5, 4, 30)), test.ShouldBeNil)
+	blank := filepath.Join(dir, "blank.png")
+	test.That(t, rimage.WriteImageToFile(blank, image.NewGray(image.Rect(0, 0, 320, 240))), test.ShouldBeNil)
+	small := filepath.Join(dir, "small.png")
+	test.That(t, rimage.WriteImageToFile(small, flatCheckerboard(160, 120, 5, 4, 15)), test.ShouldBeNil)
+
+	cal := newCalibrator(5, 4, 25, logger)
+	test.That(t, cal.addImageFiles([]string{board, blank}), test.ShouldBeNil)
+	test.That(t, cal.views, test.ShouldHaveLength, 1)
+	test.That(t, cal.size, test.ShouldResemble, image.Pt(320, 240))
+	// the corners are on the edges between the squares, half way between pixel centers
+	test.That(t, cal.views[0][0].X, test.ShouldAlmostEqual, 99.5, 0.1)
+	test.That(t, cal.views[0][0].Y, test.ShouldAlmostEqual, 74.5, 0.1)
+
+	err := cal.addImageFiles([]string{small})
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "earlier images")
+	test.That(t, cal.addImageFiles(nil), test.ShouldNotBeNil)
+
+	// one view is not enough to calibrate
+	_, err = cal.calibrate()
+	test.That(t, err, test.ShouldNotBeNil)
+}
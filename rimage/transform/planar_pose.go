@@ -0,0 +1,76 @@
+package transform
+
+import (
+	"math"
+
+	"github.com/golang/geo/r2"
+	"github.com/golang/geo/r3"
+	"github.com/pkg/errors"
+
+	"go.viam.com/rdk/spatialmath"
+)
+
+// EstimatePlanarPose finds the pose, in a camera's frame, of a flat target from the pixels that
+// points on it are seen at. The points are given in mm in the target's xy plane, so the pose takes
+// points from the target's frame to the camera's frame. It starts from the pose the homography of
+// the target gives and refines it by minimizing the reprojection error, which is returned in pixels
+// as well. The distortion may be nil.
+func EstimatePlanarPose(
+	intrinsics *PinholeCameraIntrinsics, distortion Distorter, targetPoints, pixels []r2.Point,
+) (spatialmath.Pose, float64, error) {
+	if intrinsics == nil {
+		return nil, 0, errors.New("need intrinsic parameters to estimate a pose")
+	}
+	if err := intrinsics.CheckValid(); err != nil {
+		return nil, 0, err
+	}
+	if len(targetPoints) < 4 {
+		return nil, 0, errors.Errorf("need at least 4 points on the target, only have %d", len(targetPoints))
+	}
+	if len(targetPoints) != len(pixels) {
+		return nil, 0, errors.Errorf("have %d points on the target but %d pixels", len(targetPoints), len(pixels))
+	}
+	h, err := planeHomography(targetPoints, pixels)
+	if err != nil {
+		return nil, 0, err
+	}
+	rotation, translation, err := extrinsicsFromHomography(intrinsics.Fx, intrinsics.Fy, intrinsics.Ppx, intrinsics.Ppy, h)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	residuals := func(p []float64) ([]float64, error) {
+		rot, err := rotationMatrix(r3.Vector{X: p[0], Y: p[1], Z: p[2]})
+		if err != nil {
+			return nil, err
+		}
+		t := r3.Vector{X: p[3], Y: p[4], Z: p[5]}
+		res := make([]float64, 0, 2*len(targetPoints))
+		for i, tp := range targetPoints {
+			pt := rot.Mul(r3.Vector{X: tp.X, Y: tp.Y}).Add(t)
+			x, y := pt.X/pt.Z, pt.Y/pt.Z
+			if distortion != nil {
+				x, y = distortion.Transform(x, y)
+			}
+			res = append(res, intrinsics.Fx*x+intrinsics.Ppx-pixels[i].X, intrinsics.Fy*y+intrinsics.Ppy-pixels[i].Y)
+		}
+		return res, nil
+	}
+	params := []float64{rotation.X, rotation.Y, rotation.Z, translation.X, translation.Y, translation.Z}
+	params, err = levenbergMarquardt(residuals, params, 50)
+	if err != nil {
+		return nil, 0, err
+	}
+	res, err := residuals(params)
+	if err != nil {
+		return nil, 0, err
+	}
+	reprojectionError := math.Sqrt(sumSquares(res) / float64(len(targetPoints)))
+
+	// the axis angle orientation rotates points the same way as the rotation vector
+	orientation := &spatialmath.R4AA{RX: 0, RY: 0, RZ: 1}
+	if theta := math.Sqrt(params[0]*params[0] + params[1]*params[1] + params[2]*params[2]); theta > 0 {
+		orientation = &spatialmath.R4AA{Theta: theta, RX: params[0] / theta, RY: params[1] / theta, RZ: params[2] / theta}
+	}
+	return spatialmath.NewPose(r3.Vector{X: params[3], Y: params[4], Z: params[5]}, orientation), reprojectionError, nil
+}
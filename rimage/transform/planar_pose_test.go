@@ -0,0 +1,51 @@
+package transform
+
+import (
+	"testing"
+
+	"github.com/golang/geo/r2"
+	"github.com/golang/geo/r3"
+	"go.viam.com/test"
+
+	"go.viam.com/rdk/spatialmath"
+)
+
+func TestEstimatePlanarPose(t *testing.T) {
+	distortion := &BrownConrady{RadialK1: -0.1, RadialK2: 0.02}
+	square := []r2.Point{{X: -50, Y: 50}, {X: 50, Y: 50}, {X: 50, Y: -50}, {X: -50, Y: -50}}
+
+	for _, tc := range []struct {
+		tilt, translation r3.Vector
+		distortion        *BrownConrady
+	}{
+		{r3.Vector{}, r3.Vector{Z: 500}, nil},
+		{r3.Vector{X: 0.5, Y: -0.2}, r3.Vector{X: 40, Y: -30, Z: 700}, nil},
+		{r3.Vector{Y: 2.8, Z: 0.4}, r3.Vector{X: -80, Y: 20, Z: 400}, distortion},
+	} {
+		pixels := projectCheckerboard(checkerboardTestIntrinsics, tc.distortion, square, tc.tilt, tc.translation)
+		var distorter Distorter
+		if tc.distortion != nil {
+			distorter = tc.distortion
+		}
+		pose, reprojectionError, err := EstimatePlanarPose(checkerboardTestIntrinsics, distorter, square, pixels)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, reprojectionError, test.ShouldBeLessThan, 1e-3)
+		test.That(t, pose.Point().Distance(tc.translation), test.ShouldBeLessThan, 0.1)
+
+		// the pose takes points on the target to the camera's frame
+		rotation, err := rotationMatrix(tc.tilt)
+		test.That(t, err, test.ShouldBeNil)
+		for _, p := range []r3.Vector{{X: 30, Y: -10}, {Z: 40}} {
+			expected := rotation.Mul(p).Add(tc.translation)
+			actual := spatialmath.Compose(pose, spatialmath.NewPoseFromPoint(p)).Point()
+			test.That(t, actual.Distance(expected), test.ShouldBeLessThan, 0.1)
+		}
+	}
+
+	_, _, err := EstimatePlanarPose(nil, nil, square, square)
+	test.That(t, err, test.ShouldNotBeNil)
+	_, _, err = EstimatePlanarPose(checkerboardTestIntrinsics, nil, square[:3], square[:3])
+	test.That(t, err.Error(), test.ShouldContainSubstring, "at least 4 points")
+	_, _, err = EstimatePlanarPose(checkerboardTestIntrinsics, nil, square, square[:3])
+	test.That(t, err.Error(), test.ShouldContainSubstring, "3 pixels")
+}
@@ -0,0 +1,195 @@
+package transform
+
+import (
+	"image"
+	"image/draw"
+	"math"
+
+	"github.com/golang/geo/r3"
+	"github.com/pkg/errors"
+
+	"go.viam.com/rdk/spatialmath"
+)
+
+// StereoRectification rectifies the images of a calibrated stereo pair so that matching points lie
+// on the same row, or the same column if the cameras are stacked vertically, of both images.
+type StereoRectification struct {
+	// Intrinsics are shared by both rectified images.
+	Intrinsics *PinholeCameraIntrinsics
+	// Baseline is the translation from the rectified left camera to the rectified right camera,
+	// expressed in the rectified right camera's frame. It lies along the x or y axis.
+	Baseline r3.Vector
+
+	// left and right hold, for every pixel of each rectified image, the index of the pixel in the
+	// original image it comes from, or -1 if there is none.
+	left, right []int
+}
+
+// NewStereoRectification computes the rectification of a calibrated stereo pair with Bouguet's
+// method, which rotates each camera half way towards the other and then both together so that
+// the baseline lies along the rows or columns of the rectified images. The rotation and
+// translation (in mm) take points from the left camera's frame to the right camera's frame.
+func NewStereoRectification(
+	left, right *PinholeCameraIntrinsics,
+	leftDistortion, rightDistortion *BrownConrady,
+	rotation *spatialmath.RotationMatrix,
+	translation r3.Vector,
+) (*StereoRectification, error) {
+	if err := left.CheckValid(); err != nil {
+		return nil, errors.Wrap(err, "left intrinsics")
+	}
+	if err := right.CheckValid(); err != nil {
+		return nil, errors.Wrap(err, "right intrinsics")
+	}
+	if left.Width != right.Width || left.Height != right.Height {
+		return nil, errors.New("left and right images must be the same size")
+	}
+	if rotation == nil {
+		return nil, errors.New("rotation between the cameras cannot be nil")
+	}
+	if translation.Norm() == 0 {
+		return nil, errors.New("translation between the cameras cannot be zero")
+	}
+
+	// split the rotation between the cameras so that they face the same way
+	halfRotation := rotationVector(rotation).Mul(0.5)
+	leftHalf, err := rotationMatrix(halfRotation)
+	if err != nil {
+		return nil, err
+	}
+	rightHalf, err := rotationMatrix(halfRotation.Mul(-1))
+	if err != nil {
+		return nil, err
+	}
+
+	// then rotate both so the baseline lies along whichever image axis it is closest to
+	t := rightHalf.Mul(translation)
+	axis := r3.Vector{X: 1}
+	along := t.X
+	if math.Abs(t.Y) > math.Abs(t.X) {
+		axis, along = r3.Vector{Y: 1}, t.Y
+	}
+	if along < 0 {
+		axis = axis.Mul(-1)
+	}
+	var alignment r3.Vector
+	if cross := t.Cross(axis); cross.Norm() > 0 {
+		alignment = cross.Normalize().Mul(math.Acos(math.Abs(along) / t.Norm()))
+	}
+	align, err := rotationMatrix(alignment)
+	if err != nil {
+		return nil, err
+	}
+
+	rectified := &PinholeCameraIntrinsics{
+		Width:  left.Width,
+		Height: left.Height,
+		Fx:     math.Min(math.Min(left.Fx, left.Fy), math.Min(right.Fx, right.Fy)),
+		Ppx:    (left.Ppx + right.Ppx) / 2,
+		Ppy:    (left.Ppy + right.Ppy) / 2,
+	}
+	rectified.Fy = rectified.Fx
+	return &StereoRectification{
+		Intrinsics: rectified,
+		Baseline:   align.Mul(t),
+		left:       rectifyMap(rectified, spatialmath.MatMul(*align, *leftHalf), left, leftDistortion),
+		right:      rectifyMap(rectified, spatialmath.MatMul(*align, *rightHalf), right, rightDistortion),
+	}, nil
+}
+
+// rotationVector returns the axis of a rotation scaled by its angle in radians.
+func rotationVector(m *spatialmath.RotationMatrix) r3.Vector {
+	cos := (m.At(0, 0) + m.At(1, 1) + m.At(2, 2) - 1) / 2
+	theta := math.Acos(math.Max(-1, math.Min(1, cos)))
+	if theta < 1e-9 {
+		return r3.Vector{}
+	}
+	axis := r3.Vector{X: m.At(2, 1) - m.At(1, 2), Y: m.At(0, 2) - m.At(2, 0), Z: m.At(1, 0) - m.At(0, 1)}
+	return axis.Mul(theta / (2 * math.Sin(theta)))
+}
+
+// rotationMatrix returns the rotation matrix of a rotation vector with Rodrigues' formula.
+func rotationMatrix(v r3.Vector) (*spatialmath.RotationMatrix, error) {
+	// a zero axis makes the identity
+	var k r3.Vector
+	theta := v.Norm()
+	if theta > 0 {
+		k = v.Mul(1 / theta)
+	}
+	c, s := math.Cos(theta), math.Sin(theta)
+	return spatialmath.NewRotationMatrix([]float64{
+		c + k.X*k.X*(1-c), k.X*k.Y*(1-c) - k.Z*s, k.X*k.Z*(1-c) + k.Y*s,
+		k.Y*k.X*(1-c) + k.Z*s, c + k.Y*k.Y*(1-c), k.Y*k.Z*(1-c) - k.X*s,
+		k.Z*k.X*(1-c) - k.Y*s, k.Z*k.Y*(1-c) + k.X*s, c + k.Z*k.Z*(1-c),
+	})
+}
+
+// rectifyMap finds where each pixel of a rectified image comes from in the original image, by
+// rotating its ray back into the original camera and projecting it with that camera's distortion.
+func rectifyMap(
+	rectified *PinholeCameraIntrinsics,
+	rotation *spatialmath.RotationMatrix,
+	orig *PinholeCameraIntrinsics,
+	distortion *BrownConrady,
+) []int {
+	sources := make([]int, rectified.Width*rectified.Height)
+	for v := 0; v < rectified.Height; v++ {
+		for u := 0; u < rectified.Width; u++ {
+			ray := r3.Vector{
+				X: (float64(u) - rectified.Ppx) / rectified.Fx,
+				Y: (float64(v) - rectified.Ppy) / rectified.Fy,
+				Z: 1,
+			}
+			// the transpose undoes the rectifying rotation
+			origRay := r3.Vector{X: rotation.Col(0).Dot(ray), Y: rotation.Col(1).Dot(ray), Z: rotation.Col(2).Dot(ray)}
+			i := v*rectified.Width + u
+			sources[i] = -1
+			if origRay.Z <= 0 {
+				continue
+			}
+			x, y := distortion.Transform(origRay.X/origRay.Z, origRay.Y/origRay.Z)
+			sx := int(math.Round(orig.Fx*x + orig.Ppx))
+			sy := int(math.Round(orig.Fy*y + orig.Ppy))
+			if sx >= 0 && sx < orig.Width && sy >= 0 && sy < orig.Height {
+				sources[i] = sy*orig.Width + sx
+			}
+		}
+	}
+	return sources
+}
+
+// Rectify rectifies a pair of images from the left and right cameras. Pixels that fall outside of
+// the original images are black.
+func (sr *StereoRectification) Rectify(left, right image.Image) (*image.NRGBA, *image.NRGBA, error) {
+	rectLeft, err := sr.rectify(left, sr.left)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "left image")
+	}
+	rectRight, err := sr.rectify(right, sr.right)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "right image")
+	}
+	return rectLeft, rectRight, nil
+}
+
+func (sr *StereoRectification) rectify(img image.Image, sources []int) (*image.NRGBA, error) {
+	width, height := sr.Intrinsics.Width, sr.Intrinsics.Height
+	bounds := img.Bounds()
+	if bounds.Dx() != width || bounds.Dy() != height {
+		return nil, errors.Errorf("image is %dx%d but the intrinsics expect %dx%d",
+			bounds.Dx(), bounds.Dy(), width, height)
+	}
+	orig, ok := img.(*image.NRGBA)
+	if !ok || bounds.Min != (image.Point{}) || orig.Stride != 4*width {
+		orig = image.NewNRGBA(image.Rect(0, 0, width, height))
+		draw.Draw(orig, orig.Bounds(), img, bounds.Min, draw.Src)
+	}
+	out := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for i, src := range sources {
+		if src < 0 {
+			continue
+		}
+		copy(out.Pix[4*i:4*i+4], orig.Pix[4*src:4*src+4])
+	}
+	return out, nil
+}
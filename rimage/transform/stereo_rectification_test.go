@@ -0,0 +1,83 @@
+package transform
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/golang/geo/r3"
+	"go.viam.com/test"
+
+	"go.viam.com/rdk/spatialmath"
+)
+
+var stereoTestIntrinsics = &PinholeCameraIntrinsics{
+	Width:  20,
+	Height: 10,
+	Fx:     10,
+	Fy:     10,
+	Ppx:    10,
+	Ppy:    5,
+}
+
+func TestStereoRectificationAlignedPair(t *testing.T) {
+	// cameras that are already side by side and parallel are left as they are
+	identity, err := spatialmath.NewRotationMatrix([]float64{1, 0, 0, 0, 1, 0, 0, 0, 1})
+	test.That(t, err, test.ShouldBeNil)
+	rect, err := NewStereoRectification(stereoTestIntrinsics, stereoTestIntrinsics, nil, nil, identity, r3.Vector{X: -60})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, rect.Intrinsics, test.ShouldResemble, stereoTestIntrinsics)
+	test.That(t, rect.Baseline.X, test.ShouldAlmostEqual, -60)
+	test.That(t, rect.Baseline.Y, test.ShouldAlmostEqual, 0)
+
+	img := image.NewNRGBA(image.Rect(0, 0, 20, 10))
+	for y := 0; y < 10; y++ {
+		for x := 0; x < 20; x++ {
+			img.SetNRGBA(x, y, color.NRGBA{uint8(x), uint8(y), 0, 255})
+		}
+	}
+	left, right, err := rect.Rectify(img, img)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, left, test.ShouldResemble, img)
+	test.That(t, right, test.ShouldResemble, img)
+
+	_, _, err = rect.Rectify(img, image.NewNRGBA(image.Rect(0, 0, 10, 10)))
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "right image")
+
+	// a vertical baseline is aligned with the columns
+	rect, err = NewStereoRectification(stereoTestIntrinsics, stereoTestIntrinsics, nil, nil, identity, r3.Vector{Y: -60})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, rect.Baseline.X, test.ShouldAlmostEqual, 0)
+	test.That(t, rect.Baseline.Y, test.ShouldAlmostEqual, -60)
+}
+
+func TestStereoRectificationRotatedPair(t *testing.T) {
+	// the right camera is rolled relative to the left one, but the baseline lies along the rows of
+	// the right camera, so only the left camera is rolled to match it
+	angle := 10 * math.Pi / 180
+	rotation, err := spatialmath.NewRotationMatrix([]float64{
+		math.Cos(angle), -math.Sin(angle), 0,
+		math.Sin(angle), math.Cos(angle), 0,
+		0, 0, 1,
+	})
+	test.That(t, err, test.ShouldBeNil)
+	rect, err := NewStereoRectification(stereoTestIntrinsics, stereoTestIntrinsics, nil, nil, rotation, r3.Vector{X: -60})
+	test.That(t, err, test.ShouldBeNil)
+	width := stereoTestIntrinsics.Width
+	for i := range rect.right {
+		test.That(t, rect.right[i], test.ShouldEqual, i)
+	}
+	// the principal point does not move, but the corners do
+	center := 5*width + 10
+	test.That(t, rect.left[center], test.ShouldEqual, center)
+	test.That(t, rect.left[width-1], test.ShouldNotEqual, width-1)
+
+	_, err = NewStereoRectification(stereoTestIntrinsics, stereoTestIntrinsics, nil, nil, rotation, r3.Vector{})
+	test.That(t, err, test.ShouldNotBeNil)
+	_, err = NewStereoRectification(stereoTestIntrinsics, nil, nil, nil, rotation, r3.Vector{X: -60})
+	test.That(t, err, test.ShouldNotBeNil)
+	_, err = NewStereoRectification(stereoTestIntrinsics, stereoTestIntrinsics, nil, nil, nil, r3.Vector{X: -60})
+	test.That(t, err, test.ShouldNotBeNil)
+}
@@ -141,6 +141,14 @@ type RobotClient struct {
 	heartbeatWorkers   sync.WaitGroup
 	heartbeatCtx       context.Context
 	heartbeatCtxCancel func()
+
+	// offline queueing
+	queueWindow   time.Duration
+	queueMax      int
+	onStreamGap   func(StreamGap)
+	queueMu       sync.Mutex
+	queuedCalls   int
+	reconnectedCh chan struct{}
 }
 
 // RemoteTypeName is the type name used for a remote. This is for internal use.
@@ -190,8 +198,15 @@ func (rc *RobotClient) handleUnaryDisconnect(
 	}
 
 	if err := rc.checkConnected(); err != nil {
-		rc.Logger().Debugw("connection is down, skipping method call", "method", method)
-		return status.Error(codes.Unavailable, err.Error())
+		if !rc.queueingEnabled() || !isQueueable(method, req) {
+			rc.Logger().Debugw("connection is down, skipping method call", "method", method)
+			return status.Error(codes.Unavailable, err.Error())
+		}
+		if err := rc.waitForReconnect(ctx, method); err != nil {
+			return status.Error(codes.Unavailable, err.Error())
+		}
+		// the connection this call was started on has been replaced, so go through the new one.
+		return rc.conn.Invoke(ctx, method, req, reply, opts...)
 	}
 
 	err := invoker(ctx, method, req, reply, cc, opts...)
@@ -246,7 +261,20 @@ func (rc *RobotClient) handleStreamDisconnect(
 	if isClosedPipeError(err) {
 		return nil, status.Error(codes.Unavailable, rc.notConnectedToRemoteError().Error())
 	}
-	return &handleDisconnectClientStream{cs, rc}, err
+	if err != nil {
+		return nil, err
+	}
+	if rc.queueingEnabled() && isResumable(desc) && ctx.Value(ctxKeyInResumedStream) == nil {
+		return &resumableClientStream{
+			ClientStream: &handleDisconnectClientStream{cs, rc},
+			rc:           rc,
+			ctx:          ctx,
+			desc:         desc,
+			method:       method,
+			opts:         opts,
+		}, nil
+	}
+	return &handleDisconnectClientStream{cs, rc}, nil
 }
 
 // New constructs a new RobotClient that is served at the given address. The given
@@ -274,6 +302,9 @@ func New(ctx context.Context, address string, logger golog.Logger, opts ...Robot
 		sessionsDisabled:    rOpts.disableSessions,
 		heartbeatCtx:        heartbeatCtx,
 		heartbeatCtxCancel:  heartbeatCtxCancel,
+		queueWindow:         rOpts.queueWindow,
+		queueMax:            rOpts.queueMax,
+		onStreamGap:         rOpts.onStreamGap,
 	}
 
 	// interceptors are applied in order from first to last
@@ -388,6 +419,7 @@ func (rc *RobotClient) connect(ctx context.Context) error {
 	rc.client = client
 	rc.refClient = refClient
 	rc.connected.Store(true)
+	rc.signalConnected()
 	if len(rc.resourceClients) != 0 {
 		if err := rc.updateResources(ctx); err != nil {
 			return err
@@ -554,7 +586,8 @@ func (rc *RobotClient) RemoteByName(name string) (robot.Robot, bool) {
 
 // ResourceByName returns resource by name.
 func (rc *RobotClient) ResourceByName(name resource.Name) (resource.Resource, error) {
-	if err := rc.checkConnected(); err != nil {
+	// when queueing, calls on known resources are held until we reconnect.
+	if err := rc.checkConnected(); err != nil && !rc.queueingEnabled() {
 		return nil, err
 	}
 
@@ -32,6 +32,17 @@ type robotClientOpts struct {
 
 	// controls whether or not sessions are disabled.
 	disableSessions bool
+
+	// queueWindow is how long idempotent calls are held while the
+	// robot is disconnected. If <=0, calls fail immediately.
+	queueWindow time.Duration
+
+	// queueMax is the maximum number of calls held at once. If <=0,
+	// there is no limit.
+	queueMax int
+
+	// onStreamGap is called whenever a stream resumes after a disconnect.
+	onStreamGap func(StreamGap)
 }
 
 // RobotClientOption configures how we set up the connection.
@@ -92,6 +103,25 @@ func WithDisableSessions() RobotClientOption {
 	})
 }
 
+// WithRequestQueueing returns a RobotClientOption that holds idempotent calls (such as Get*
+// methods and DoCommand requests marked with QueuedDoCommandKey) for up to window while the
+// robot is disconnected instead of failing them immediately. At most maxQueued calls are held
+// at once; if maxQueued <= 0, there is no limit. Server streams are also resumed after a reconnect.
+func WithRequestQueueing(window time.Duration, maxQueued int) RobotClientOption {
+	return newFuncRobotClientOption(func(o *robotClientOpts) {
+		o.queueWindow = window
+		o.queueMax = maxQueued
+	})
+}
+
+// WithStreamGapHandler returns a RobotClientOption that sets a function to be called whenever
+// a stream is resumed after a disconnect. Only used in conjunction with WithRequestQueueing.
+func WithStreamGapHandler(f func(gap StreamGap)) RobotClientOption {
+	return newFuncRobotClientOption(func(o *robotClientOpts) {
+		o.onStreamGap = f
+	})
+}
+
 // WithDialOptions returns a RobotClientOption which sets the options for making
 // gRPC connections to other servers.
 func WithDialOptions(opts ...rpc.DialOption) RobotClientOption {
@@ -0,0 +1,221 @@
+package client
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// QueuedDoCommandKey is the key a DoCommand request can set to true in order to
+// mark itself as safe to be held while the client is reconnecting to the remote.
+const QueuedDoCommandKey = "queued"
+
+// queueableMethodPrefixes are the gRPC method name prefixes that are considered
+// idempotent and may be held until the remote is reachable again.
+var queueableMethodPrefixes = []string{"Get", "Is"}
+
+var queueableMethods = map[string]bool{
+	"/viam.robot.v1.RobotService/ResourceNames":       true,
+	"/viam.robot.v1.RobotService/ResourceRPCSubtypes": true,
+}
+
+// StreamGap describes a period of time during which a resumed stream was not receiving
+// messages because the connection to the remote was lost.
+type StreamGap struct {
+	Method string
+	Start  time.Time
+	End    time.Time
+}
+
+type commandRequest interface {
+	GetCommand() *structpb.Struct
+}
+
+func (rc *RobotClient) queueingEnabled() bool {
+	return rc.queueWindow > 0
+}
+
+// isQueueable returns whether or not the given unary call can be held until a reconnect.
+func isQueueable(method string, req interface{}) bool {
+	if queueableMethods[method] {
+		return true
+	}
+	methodName := method[strings.LastIndex(method, "/")+1:]
+	if methodName == "DoCommand" {
+		cmdReq, ok := req.(commandRequest)
+		if !ok || cmdReq.GetCommand() == nil {
+			return false
+		}
+		queued, ok := cmdReq.GetCommand().AsMap()[QueuedDoCommandKey].(bool)
+		return ok && queued
+	}
+	for _, prefix := range queueableMethodPrefixes {
+		if strings.HasPrefix(methodName, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// isResumable returns whether or not a stream can be transparently reopened after a reconnect.
+// Only server streams qualify since the whole request is known after CloseSend.
+func isResumable(desc *grpc.StreamDesc) bool {
+	return desc.ServerStreams && !desc.ClientStreams
+}
+
+// signalConnected wakes up any calls waiting on a reconnect. It must be called after
+// the connected state has been set.
+func (rc *RobotClient) signalConnected() {
+	rc.queueMu.Lock()
+	defer rc.queueMu.Unlock()
+	if rc.reconnectedCh != nil {
+		close(rc.reconnectedCh)
+		rc.reconnectedCh = nil
+	}
+}
+
+// waitForReconnect blocks until the client is connected again, the queue window
+// elapses, or the given context is done.
+func (rc *RobotClient) waitForReconnect(ctx context.Context, method string) error {
+	rc.queueMu.Lock()
+	if rc.connected.Load() {
+		rc.queueMu.Unlock()
+		return nil
+	}
+	if rc.queueMax > 0 && rc.queuedCalls >= rc.queueMax {
+		rc.queueMu.Unlock()
+		return errors.Errorf("%s: too many calls queued (%d)", rc.notConnectedToRemoteError(), rc.queueMax)
+	}
+	if rc.reconnectedCh == nil {
+		rc.reconnectedCh = make(chan struct{})
+	}
+	reconnected := rc.reconnectedCh
+	rc.queuedCalls++
+	rc.queueMu.Unlock()
+
+	defer func() {
+		rc.queueMu.Lock()
+		rc.queuedCalls--
+		rc.queueMu.Unlock()
+	}()
+
+	rc.Logger().Debugw("connection is down, queueing method call", "method", method)
+	timer := time.NewTimer(rc.queueWindow)
+	defer timer.Stop()
+	select {
+	case <-reconnected:
+		return nil
+	case <-timer.C:
+		return rc.notConnectedToRemoteError()
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-rc.backgroundCtx.Done():
+		return rc.notConnectedToRemoteError()
+	}
+}
+
+// resumableClientStream reopens its underlying stream on the current connection
+// whenever it is lost due to a disconnect. The messages sent are recorded so they
+// can be replayed to the new stream.
+type resumableClientStream struct {
+	grpc.ClientStream
+	rc     *RobotClient
+	ctx    context.Context
+	desc   *grpc.StreamDesc
+	method string
+	opts   []grpc.CallOption
+
+	mu        sync.Mutex
+	sendMsgs  []interface{}
+	closeSend bool
+}
+
+func (s *resumableClientStream) SendMsg(m interface{}) error {
+	s.mu.Lock()
+	s.sendMsgs = append(s.sendMsgs, m)
+	cs := s.ClientStream
+	s.mu.Unlock()
+	return cs.SendMsg(m)
+}
+
+func (s *resumableClientStream) CloseSend() error {
+	s.mu.Lock()
+	s.closeSend = true
+	cs := s.ClientStream
+	s.mu.Unlock()
+	return cs.CloseSend()
+}
+
+func (s *resumableClientStream) RecvMsg(m interface{}) error {
+	for {
+		s.mu.Lock()
+		cs := s.ClientStream
+		s.mu.Unlock()
+
+		err := cs.RecvMsg(m)
+		if err == nil || !s.lostConnection(err) {
+			return err
+		}
+
+		gapStart := time.Now()
+		s.rc.Logger().Debugw("stream interrupted by disconnect, waiting to resume", "method", s.method)
+		if err := s.rc.waitForReconnect(s.ctx, s.method); err != nil {
+			return status.Error(codes.Unavailable, err.Error())
+		}
+		if err := s.reopen(); err != nil {
+			return err
+		}
+		if s.rc.onStreamGap != nil {
+			s.rc.onStreamGap(StreamGap{Method: s.method, Start: gapStart, End: time.Now()})
+		}
+	}
+}
+
+// lostConnection determines if the error was caused by the remote going away rather
+// than by the caller or the server ending the stream.
+func (s *resumableClientStream) lostConnection(err error) bool {
+	if s.ctx.Err() != nil {
+		return false
+	}
+	if isClosedPipeError(err) || !s.rc.connected.Load() {
+		return true
+	}
+	switch status.Code(err) {
+	case codes.Unavailable, codes.Canceled:
+		return true
+	default:
+		return false
+	}
+}
+
+func (s *resumableClientStream) reopen() error {
+	cs, err := s.rc.conn.NewStream(
+		context.WithValue(s.ctx, ctxKeyInResumedStream, true),
+		s.desc,
+		s.method,
+		s.opts...,
+	)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ClientStream = cs
+	for _, msg := range s.sendMsgs {
+		if err := cs.SendMsg(msg); err != nil {
+			return err
+		}
+	}
+	if s.closeSend {
+		return cs.CloseSend()
+	}
+	return nil
+}
@@ -19,7 +19,10 @@ import (
 
 type ctxKey byte
 
-const ctxKeyInSessionMDReq = ctxKey(iota)
+const (
+	ctxKeyInSessionMDReq = ctxKey(iota)
+	ctxKeyInResumedStream
+)
 
 var exemptFromSession = map[string]bool{
 	"/grpc.reflection.v1alpha.ServerReflection/ServerReflectionInfo": true,
@@ -43,6 +43,8 @@ import (
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/reflection"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/structpb"
 
 	"go.viam.com/rdk/components/arm"
 	"go.viam.com/rdk/components/base"
@@ -1751,3 +1753,157 @@ func TestGetUnknownResource(t *testing.T) {
 	err = client.Close(context.Background())
 	test.That(t, err, test.ShouldBeNil)
 }
+
+func TestClientRequestQueueing(t *testing.T) {
+	logger := golog.NewTestLogger(t)
+	listener, err := net.Listen("tcp", "localhost:0")
+	test.That(t, err, test.ShouldBeNil)
+	addr := listener.Addr().String()
+
+	injectRobot := &inject.Robot{}
+	injectRobot.ResourceRPCAPIsFunc = func() []resource.RPCAPI { return nil }
+	injectRobot.ResourceNamesFunc = func() []resource.Name { return nil }
+	injectRobot.StatusFunc = func(ctx context.Context, rs []resource.Name) ([]robot.Status, error) {
+		return []robot.Status{}, nil
+	}
+	gServer := grpc.NewServer()
+	pb.RegisterRobotServiceServer(gServer, server.New(injectRobot))
+	go gServer.Serve(listener)
+
+	dur := 50 * time.Millisecond
+	client, err := New(
+		context.Background(),
+		addr,
+		logger,
+		WithCheckConnectedEvery(dur),
+		WithReconnectEvery(dur),
+		WithRequestQueueing(10*time.Second, 1),
+	)
+	test.That(t, err, test.ShouldBeNil)
+	defer func() {
+		// closing may interrupt a connection check which then reports a change, so drain it.
+		changed := client.Changed()
+		go func() {
+			for range changed {
+			}
+		}()
+		test.That(t, client.Close(context.Background()), test.ShouldBeNil)
+		gServer.Stop()
+	}()
+
+	gServer.Stop()
+	test.That(t, <-client.Changed(), test.ShouldBeTrue)
+	test.That(t, client.Connected(), test.ShouldBeFalse)
+
+	statusErr := make(chan error, 1)
+	go func() {
+		_, err := client.Status(context.Background(), []resource.Name{})
+		statusErr <- err
+	}()
+	gotestutils.WaitForAssertion(t, func(tb testing.TB) {
+		client.queueMu.Lock()
+		defer client.queueMu.Unlock()
+		test.That(tb, client.queuedCalls, test.ShouldEqual, 1)
+	})
+
+	t.Run("non-idempotent calls are not queued", func(t *testing.T) {
+		err := client.StopAll(context.Background(), nil)
+		test.That(t, status.Code(err), test.ShouldEqual, codes.Unavailable)
+	})
+
+	t.Run("queue is bounded", func(t *testing.T) {
+		_, err := client.Status(context.Background(), []resource.Name{})
+		test.That(t, status.Code(err), test.ShouldEqual, codes.Unavailable)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "too many calls queued")
+	})
+
+	listener, err = net.Listen("tcp", addr)
+	test.That(t, err, test.ShouldBeNil)
+	gServer = grpc.NewServer()
+	pb.RegisterRobotServiceServer(gServer, server.New(injectRobot))
+	go gServer.Serve(listener)
+
+	test.That(t, <-client.Changed(), test.ShouldBeTrue)
+	test.That(t, <-statusErr, test.ShouldBeNil)
+}
+
+func TestClientStreamResume(t *testing.T) {
+	logger := golog.NewTestLogger(t)
+	listener, err := net.Listen("tcp", "localhost:0")
+	test.That(t, err, test.ShouldBeNil)
+	addr := listener.Addr().String()
+
+	injectRobot := &inject.Robot{}
+	injectRobot.ResourceRPCAPIsFunc = func() []resource.RPCAPI { return nil }
+	injectRobot.ResourceNamesFunc = func() []resource.Name { return nil }
+	injectRobot.StatusFunc = func(ctx context.Context, rs []resource.Name) ([]robot.Status, error) {
+		return []robot.Status{}, nil
+	}
+	gServer := grpc.NewServer()
+	pb.RegisterRobotServiceServer(gServer, server.New(injectRobot))
+	go gServer.Serve(listener)
+
+	gaps := make(chan StreamGap, 1)
+	dur := 50 * time.Millisecond
+	client, err := New(
+		context.Background(),
+		addr,
+		logger,
+		WithCheckConnectedEvery(dur),
+		WithReconnectEvery(dur),
+		WithRequestQueueing(10*time.Second, 0),
+		WithStreamGapHandler(func(gap StreamGap) {
+			gaps <- gap
+		}),
+	)
+	test.That(t, err, test.ShouldBeNil)
+	defer func() {
+		// closing may interrupt a connection check which then reports a change, so drain it.
+		changed := client.Changed()
+		go func() {
+			for range changed {
+			}
+		}()
+		test.That(t, client.Close(context.Background()), test.ShouldBeNil)
+		gServer.Stop()
+	}()
+
+	ssc, err := client.client.StreamStatus(context.Background(), &pb.StreamStatusRequest{Every: durationpb.New(dur)})
+	test.That(t, err, test.ShouldBeNil)
+	_, err = ssc.Recv()
+	test.That(t, err, test.ShouldBeNil)
+
+	gServer.Stop()
+	test.That(t, <-client.Changed(), test.ShouldBeTrue)
+
+	recvErr := make(chan error, 1)
+	go func() {
+		_, err := ssc.Recv()
+		recvErr <- err
+	}()
+
+	listener, err = net.Listen("tcp", addr)
+	test.That(t, err, test.ShouldBeNil)
+	gServer = grpc.NewServer()
+	pb.RegisterRobotServiceServer(gServer, server.New(injectRobot))
+	go gServer.Serve(listener)
+
+	test.That(t, <-client.Changed(), test.ShouldBeTrue)
+	test.That(t, <-recvErr, test.ShouldBeNil)
+	gap := <-gaps
+	test.That(t, gap.Method, test.ShouldEqual, "/viam.robot.v1.RobotService/StreamStatus")
+	test.That(t, gap.End, test.ShouldHappenAfter, gap.Start)
+}
+
+func TestIsQueueable(t *testing.T) {
+	test.That(t, isQueueable("/viam.robot.v1.RobotService/GetStatus", nil), test.ShouldBeTrue)
+	test.That(t, isQueueable("/viam.component.motor.v1.MotorService/IsMoving", nil), test.ShouldBeTrue)
+	test.That(t, isQueueable("/viam.robot.v1.RobotService/StopAll", nil), test.ShouldBeFalse)
+	test.That(t, isQueueable("/viam.component.arm.v1.ArmService/MoveToPosition", nil), test.ShouldBeFalse)
+
+	cmd, err := structpb.NewStruct(map[string]interface{}{QueuedDoCommandKey: true})
+	test.That(t, err, test.ShouldBeNil)
+	method := "/viam.component.arm.v1.ArmService/DoCommand"
+	test.That(t, isQueueable(method, &commonpb.DoCommandRequest{Command: cmd}), test.ShouldBeTrue)
+	test.That(t, isQueueable(method, &commonpb.DoCommandRequest{}), test.ShouldBeFalse)
+}
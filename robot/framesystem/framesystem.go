@@ -42,6 +42,7 @@ type Service interface {
 	TransformPointCloud(ctx context.Context, srcpc pointcloud.PointCloud, srcName, dstName string) (pointcloud.PointCloud, error)
 	CurrentInputs(ctx context.Context) (map[string][]referenceframe.Input, map[string]referenceframe.InputEnabled, error)
 	FrameSystem(ctx context.Context, additionalTransforms []*referenceframe.LinkInFrame) (referenceframe.FrameSystem, error)
+	FrameSystemConfig(ctx context.Context) (*Config, error)
 }
 
 // FromDependencies is a helper for getting the framesystem from a collection of dependencies.
@@ -256,6 +257,15 @@ func (svc *frameSystemService) FrameSystem(
 	return referenceframe.NewFrameSystem(LocalFrameSystemName, svc.parts, additionalTransforms)
 }
 
+// FrameSystemConfig returns the parts the frame system of the robot is built from.
+func (svc *frameSystemService) FrameSystemConfig(ctx context.Context) (*Config, error) {
+	svc.partsMu.RLock()
+	defer svc.partsMu.RUnlock()
+	parts := make([]*referenceframe.FrameSystemPart, len(svc.parts))
+	copy(parts, svc.parts)
+	return &Config{Parts: parts}, nil
+}
+
 // TransformPointCloud applies the same pose offset to each point in a single pointcloud and returns the transformed point cloud.
 // if destination string is empty, defaults to transforming to the world frame.
 // Do not move the robot between the generation of the initial pointcloud and the receipt
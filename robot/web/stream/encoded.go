@@ -0,0 +1,268 @@
+package webstream
+
+import (
+	"context"
+	"image"
+	"sync"
+	"time"
+
+	"github.com/aler9/gortsplib/v2/pkg/formatdecenc/rtph264"
+	"github.com/aler9/gortsplib/v2/pkg/formatdecenc/rtph265"
+	"github.com/edaniels/golog"
+	"github.com/pion/mediadevices/pkg/prop"
+	"github.com/pion/mediadevices/pkg/wave"
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v3"
+	"github.com/pkg/errors"
+	"github.com/viamrobotics/gostream"
+	"go.viam.com/utils"
+
+	"go.viam.com/rdk/components/camera"
+)
+
+const (
+	// rtpPayloadMaxSize keeps packets under the MTU of most WebRTC connections.
+	rtpPayloadMaxSize = 1200
+	// rtpDynamicPayloadType is replaced by the negotiated payload type when packets are written.
+	rtpDynamicPayloadType = 96
+)
+
+var errNoEncodedVideoSource = errors.New("camera no longer has encoded video")
+
+// An EncodedStream is a stream of already encoded H.264 or H.265 video, which it passes on to its
+// WebRTC track without decoding and encoding it again.
+type EncodedStream struct {
+	name  string
+	codec string
+	track *webrtc.TrackLocalStaticRTP
+
+	mu               sync.RWMutex
+	started          bool
+	streamingReadyCh chan struct{}
+	shutdownCtx      context.Context
+	shutdownCancel   func()
+
+	packetize func(nalus [][]byte, pts time.Duration) ([]*rtp.Packet, error)
+}
+
+// NewEncodedStream returns a stream for video with the given MIME type, either
+// webrtc.MimeTypeH264 or webrtc.MimeTypeH265.
+func NewEncodedStream(name, codec string) (*EncodedStream, error) {
+	var packetize func(nalus [][]byte, pts time.Duration) ([]*rtp.Packet, error)
+	switch codec {
+	case webrtc.MimeTypeH264:
+		enc := &rtph264.Encoder{
+			PayloadType:       rtpDynamicPayloadType,
+			PayloadMaxSize:    rtpPayloadMaxSize,
+			PacketizationMode: 1,
+		}
+		enc.Init()
+		packetize = enc.Encode
+	case webrtc.MimeTypeH265:
+		enc := &rtph265.Encoder{
+			PayloadType:    rtpDynamicPayloadType,
+			PayloadMaxSize: rtpPayloadMaxSize,
+		}
+		enc.Init()
+		packetize = enc.Encode
+	default:
+		return nil, errors.Errorf("unsupported encoded video codec %q", codec)
+	}
+
+	track, err := webrtc.NewTrackLocalStaticRTP(webrtc.RTPCodecCapability{MimeType: codec}, "video", name)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &EncodedStream{
+		name:             name,
+		codec:            codec,
+		track:            track,
+		streamingReadyCh: make(chan struct{}),
+		shutdownCtx:      ctx,
+		shutdownCancel:   cancel,
+		packetize:        packetize,
+	}, nil
+}
+
+// Name returns the name of the stream.
+func (es *EncodedStream) Name() string {
+	return es.name
+}
+
+// Codec returns the MIME type of the video the stream passes on.
+func (es *EncodedStream) Codec() string {
+	return es.codec
+}
+
+// Start signals that the stream is ready for video.
+func (es *EncodedStream) Start() {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+	if es.started {
+		return
+	}
+	es.started = true
+	close(es.streamingReadyCh)
+}
+
+// Stop signals that the stream no longer wants video until it is started again.
+func (es *EncodedStream) Stop() {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+	if !es.started {
+		close(es.streamingReadyCh)
+	}
+	es.started = false
+	es.shutdownCancel()
+
+	// reset
+	es.shutdownCtx, es.shutdownCancel = context.WithCancel(context.Background())
+	es.streamingReadyCh = make(chan struct{})
+}
+
+// StreamingReady returns a channel that is closed once the stream has been started, and a context
+// that is done once it is stopped.
+func (es *EncodedStream) StreamingReady() (<-chan struct{}, context.Context) {
+	es.mu.RLock()
+	defer es.mu.RUnlock()
+	return es.streamingReadyCh, es.shutdownCtx
+}
+
+// InputVideoFrames always fails since the stream only takes encoded video.
+func (es *EncodedStream) InputVideoFrames(props prop.Video) (chan<- gostream.MediaReleasePair[image.Image], error) {
+	return nil, errors.New("stream only takes encoded video")
+}
+
+// InputAudioChunks always fails since the stream only takes encoded video.
+func (es *EncodedStream) InputAudioChunks(props prop.Audio) (chan<- gostream.MediaReleasePair[wave.Audio], error) {
+	return nil, errors.New("no audio in stream")
+}
+
+// VideoTrackLocal returns the WebRTC track of the stream.
+func (es *EncodedStream) VideoTrackLocal() (webrtc.TrackLocal, bool) {
+	return es.track, true
+}
+
+// AudioTrackLocal returns nothing since the stream has no audio.
+func (es *EncodedStream) AudioTrackLocal() (webrtc.TrackLocal, bool) {
+	return nil, false
+}
+
+// WriteFrame packetizes a frame and writes it to every connection of the stream. It is not safe to
+// call concurrently.
+func (es *EncodedStream) WriteFrame(frame camera.EncodedFrame) error {
+	pkts, err := es.packetize(frame.NALUs, frame.PTS)
+	if err != nil {
+		return err
+	}
+	for _, pkt := range pkts {
+		if err := es.track.WriteRTP(pkt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// StreamEncodedVideoSource passes encoded video from a source on to a stream whenever the stream is
+// ready, until the context is done. Errors are sent to a throttled error handler.
+func StreamEncodedVideoSource(
+	ctx context.Context,
+	source camera.EncodedVideoSource,
+	stream *EncodedStream,
+	backoffOpts *BackoffTuningOptions,
+	logger golog.Logger,
+) error {
+	errHandler := backoffOpts.getErrorThrottledHandler(logger)
+	for {
+		readyCh, readyCtx := stream.StreamingReady()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-readyCh:
+		}
+		if err := streamEncodedVideo(ctx, readyCtx, source, stream); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			errHandler(ctx, err)
+		}
+	}
+}
+
+// streamEncodedVideo passes encoded video on to a stream until the stream is stopped.
+func streamEncodedVideo(
+	ctx, readyCtx context.Context,
+	source camera.EncodedVideoSource,
+	stream *EncodedStream,
+) error {
+	streamCtx, cancel := utils.MergeContext(ctx, readyCtx)
+	defer cancel()
+
+	codec := source.EncodedVideoCodec()
+	if codec == "" {
+		return errNoEncodedVideoSource
+	}
+	if codec != stream.Codec() {
+		return errors.Errorf("camera video changed from %s to %s", stream.Codec(), codec)
+	}
+	videoStream, err := source.StreamEncoded(streamCtx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		utils.UncheckedError(videoStream.Close(ctx))
+	}()
+	for {
+		frame, err := videoStream.Next(streamCtx)
+		if err != nil {
+			if readyCtx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		if err := stream.WriteFrame(frame); err != nil {
+			return err
+		}
+	}
+}
+
+// A HotSwappableEncodedVideoSource is an encoded video source whose underlying source can be
+// swapped out, such as when a camera is reconfigured.
+type HotSwappableEncodedVideoSource struct {
+	mu  sync.RWMutex
+	src camera.EncodedVideoSource
+}
+
+// NewHotSwappableEncodedVideoSource returns a hot swappable encoded video source.
+func NewHotSwappableEncodedVideoSource(src camera.EncodedVideoSource) *HotSwappableEncodedVideoSource {
+	return &HotSwappableEncodedVideoSource{src: src}
+}
+
+// Swap replaces the underlying source. A nil source means the camera no longer has encoded video.
+func (swapper *HotSwappableEncodedVideoSource) Swap(src camera.EncodedVideoSource) {
+	swapper.mu.Lock()
+	defer swapper.mu.Unlock()
+	swapper.src = src
+}
+
+// EncodedVideoCodec returns the MIME type of the video of the underlying source.
+func (swapper *HotSwappableEncodedVideoSource) EncodedVideoCodec() string {
+	swapper.mu.RLock()
+	defer swapper.mu.RUnlock()
+	if swapper.src == nil {
+		return ""
+	}
+	return swapper.src.EncodedVideoCodec()
+}
+
+// StreamEncoded returns a stream of the underlying source. Streams end when their source is closed,
+// so a new stream has to be made to follow a swap.
+func (swapper *HotSwappableEncodedVideoSource) StreamEncoded(ctx context.Context) (camera.EncodedVideoStream, error) {
+	swapper.mu.RLock()
+	defer swapper.mu.RUnlock()
+	if swapper.src == nil {
+		return nil, errNoEncodedVideoSource
+	}
+	return swapper.src.StreamEncoded(ctx)
+}
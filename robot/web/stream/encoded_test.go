@@ -0,0 +1,117 @@
+package webstream_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/edaniels/golog"
+	"github.com/pion/mediadevices/pkg/prop"
+	"github.com/pion/webrtc/v3"
+	"github.com/viamrobotics/gostream"
+	"go.viam.com/test"
+	"go.viam.com/utils/testutils"
+
+	"go.viam.com/rdk/components/camera"
+	webstream "go.viam.com/rdk/robot/web/stream"
+)
+
+type mockEncodedVideoSource struct {
+	codec   string
+	opened  atomic.Int32
+	closed  atomic.Int32
+	written atomic.Int32
+}
+
+func (src *mockEncodedVideoSource) EncodedVideoCodec() string {
+	return src.codec
+}
+
+func (src *mockEncodedVideoSource) StreamEncoded(ctx context.Context) (camera.EncodedVideoStream, error) {
+	src.opened.Add(1)
+	return &mockEncodedVideoStream{src: src}, nil
+}
+
+type mockEncodedVideoStream struct {
+	src *mockEncodedVideoSource
+}
+
+func (stream *mockEncodedVideoStream) Next(ctx context.Context) (camera.EncodedFrame, error) {
+	select {
+	case <-ctx.Done():
+		return camera.EncodedFrame{}, ctx.Err()
+	case <-time.After(time.Millisecond):
+	}
+	stream.src.written.Add(1)
+	return camera.EncodedFrame{NALUs: [][]byte{{0x65, 0x88, 0x84}}}, nil
+}
+
+func (stream *mockEncodedVideoStream) Close(ctx context.Context) error {
+	stream.src.closed.Add(1)
+	return nil
+}
+
+func TestEncodedStream(t *testing.T) {
+	_, err := webstream.NewEncodedStream("cam", "video/VP8")
+	test.That(t, err, test.ShouldNotBeNil)
+
+	stream, err := webstream.NewEncodedStream("cam", webrtc.MimeTypeH265)
+	test.That(t, err, test.ShouldBeNil)
+	var _ gostream.Stream = stream
+	track, ok := stream.VideoTrackLocal()
+	test.That(t, ok, test.ShouldBeTrue)
+	test.That(t, track.Kind(), test.ShouldEqual, webrtc.RTPCodecTypeVideo)
+	_, ok = stream.AudioTrackLocal()
+	test.That(t, ok, test.ShouldBeFalse)
+	_, err = stream.InputVideoFrames(prop.Video{})
+	test.That(t, err, test.ShouldNotBeNil)
+}
+
+func TestStreamEncodedVideoSource(t *testing.T) {
+	logger := golog.NewTestLogger(t)
+	backoffOpts := &webstream.BackoffTuningOptions{BaseSleep: time.Millisecond, MaxSleep: time.Millisecond, Cooldown: time.Second}
+	stream, err := webstream.NewEncodedStream("cam", webrtc.MimeTypeH264)
+	test.That(t, err, test.ShouldBeNil)
+	src := &mockEncodedVideoSource{codec: webrtc.MimeTypeH264}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- webstream.StreamEncodedVideoSource(ctx, src, stream, backoffOpts, logger)
+	}()
+
+	// nothing is streamed until the stream is started
+	time.Sleep(10 * time.Millisecond)
+	test.That(t, src.opened.Load(), test.ShouldEqual, 0)
+
+	stream.Start()
+	testutils.WaitForAssertion(t, func(tb testing.TB) {
+		tb.Helper()
+		test.That(tb, src.written.Load(), test.ShouldBeGreaterThan, 5)
+	})
+
+	stream.Stop()
+	testutils.WaitForAssertion(t, func(tb testing.TB) {
+		tb.Helper()
+		test.That(tb, src.closed.Load(), test.ShouldEqual, 1)
+	})
+	test.That(t, src.opened.Load(), test.ShouldEqual, 1)
+
+	cancel()
+	test.That(t, <-done, test.ShouldBeError, context.Canceled)
+}
+
+func TestHotSwappableEncodedVideoSource(t *testing.T) {
+	src := &mockEncodedVideoSource{codec: webrtc.MimeTypeH264}
+	swapper := webstream.NewHotSwappableEncodedVideoSource(src)
+	test.That(t, swapper.EncodedVideoCodec(), test.ShouldEqual, webrtc.MimeTypeH264)
+	_, err := swapper.StreamEncoded(context.Background())
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, src.opened.Load(), test.ShouldEqual, 1)
+
+	swapper.Swap(nil)
+	test.That(t, swapper.EncodedVideoCodec(), test.ShouldEqual, "")
+	_, err = swapper.StreamEncoded(context.Background())
+	test.That(t, err, test.ShouldNotBeNil)
+}
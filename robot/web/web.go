@@ -193,6 +193,13 @@ func (svc *webService) refreshVideoSources() {
 		if err != nil {
 			continue
 		}
+		// cameras that already produce encoded video get streamed without being re-encoded
+		encoded, isEncoded := camera.EncodedVideoSourceFrom(cam)
+		if existing, ok := svc.encodedVideoSources[validSDPTrackName(name)]; ok {
+			existing.Swap(encoded)
+		} else if isEncoded {
+			svc.encodedVideoSources[validSDPTrackName(name)] = webstream.NewHotSwappableEncodedVideoSource(encoded)
+		}
 		existing, ok := svc.videoSources[validSDPTrackName(name)]
 		if ok {
 			existing.Swap(cam)
@@ -264,6 +271,8 @@ func New(r robot.Robot, logger golog.Logger, opts ...Option) Service {
 		opts:         wOpts,
 		videoSources: map[string]gostream.HotSwappableVideoSource{},
 		audioSources: map[string]gostream.HotSwappableAudioSource{},
+
+		encodedVideoSources: map[string]*webstream.HotSwappableEncodedVideoSource{},
 	}
 	return webSvc
 }
@@ -288,6 +297,9 @@ type webService struct {
 
 	videoSources map[string]gostream.HotSwappableVideoSource
 	audioSources map[string]gostream.HotSwappableAudioSource
+
+	// encodedVideoSources are the video sources that already produce encoded video.
+	encodedVideoSources map[string]*webstream.HotSwappableEncodedVideoSource
 }
 
 var internalWebServiceName = resource.NewName(
@@ -575,6 +587,23 @@ func (svc *webService) addNewStreams(ctx context.Context) error {
 	}
 
 	for name, source := range svc.videoSources {
+		if encoded, ok := svc.encodedVideoSources[name]; ok && encoded.EncodedVideoCodec() != "" {
+			stream, err := webstream.NewEncodedStream(name, encoded.EncodedVideoCodec())
+			if err != nil {
+				return err
+			}
+			err = svc.streamServer.Server.AddStream(stream)
+			var registeredError *gostream.StreamAlreadyRegisteredError
+			if errors.As(err, &registeredError) {
+				continue
+			} else if err != nil {
+				return err
+			}
+			svc.streamServer.HasStreams = true
+			svc.startEncodedVideoStream(ctx, encoded, stream)
+			continue
+		}
+
 		stream, alreadyRegistered, err := newStream(name)
 		if err != nil {
 			return err
@@ -616,6 +645,18 @@ func (svc *webService) makeStreamServer(ctx context.Context) (*StreamServer, err
 	addStream := func(streams []gostream.Stream, name string, isVideo bool) ([]gostream.Stream, error) {
 		config := *svc.opts.streamConfig
 		config.Name = name
+		if encoded, ok := svc.encodedVideoSources[name]; ok && isVideo && encoded.EncodedVideoCodec() != "" {
+			if runtime.GOOS == "windows" {
+				// TODO(RSDK-1771): support video on windows
+				svc.logger.Warnw("not starting video stream since not supported on Windows yet", "name", name)
+				return streams, nil
+			}
+			stream, err := webstream.NewEncodedStream(name, encoded.EncodedVideoCodec())
+			if err != nil {
+				return streams, err
+			}
+			return append(streams, stream), nil
+		}
 		if isVideo {
 			config.AudioEncoderFactory = nil
 
@@ -669,7 +710,9 @@ func (svc *webService) makeStreamServer(ctx context.Context) (*StreamServer, err
 	}
 
 	for idx, stream := range streams {
-		if streamTypes[idx] {
+		if encodedStream, ok := stream.(*webstream.EncodedStream); ok {
+			svc.startEncodedVideoStream(ctx, svc.encodedVideoSources[stream.Name()], encodedStream)
+		} else if streamTypes[idx] {
 			svc.startVideoStream(ctx, svc.videoSources[stream.Name()], stream)
 		} else {
 			svc.startAudioStream(ctx, svc.audioSources[stream.Name()], stream)
@@ -708,6 +751,18 @@ func (svc *webService) startVideoStream(ctx context.Context, source gostream.Vid
 	})
 }
 
+func (svc *webService) startEncodedVideoStream(
+	ctx context.Context,
+	source camera.EncodedVideoSource,
+	stream *webstream.EncodedStream,
+) {
+	svc.startStream(func(opts *webstream.BackoffTuningOptions) error {
+		// Merge ctx that may be coming from a Reconfigure.
+		streamVideoCtx, _ := utils.MergeContext(svc.cancelCtx, ctx)
+		return webstream.StreamEncodedVideoSource(streamVideoCtx, source, stream, opts, svc.logger)
+	})
+}
+
 func (svc *webService) startAudioStream(ctx context.Context, source gostream.AudioSource, stream gostream.Stream) {
 	svc.startStream(func(opts *webstream.BackoffTuningOptions) error {
 		// Merge ctx that may be coming from a Reconfigure.
@@ -0,0 +1,29 @@
+// Package armremotecontrol implements a remote control for an arm and gripper.
+package armremotecontrol
+
+import (
+	"go.viam.com/rdk/components/input"
+	"go.viam.com/rdk/resource"
+)
+
+// SubtypeName is the name of the type of service.
+const SubtypeName = "arm_remote_control"
+
+// API is a variable that identifies the remote control resource API.
+var API = resource.APINamespaceRDK.WithServiceType(SubtypeName)
+
+// Named is a helper for getting the named arm remote control service's typed resource name.
+func Named(name string) resource.Name {
+	return resource.NewName(API, name)
+}
+
+func init() {
+	resource.RegisterAPI(API, resource.APIRegistration[Service]{})
+}
+
+// A Service is the basis for the arm remote control.
+type Service interface {
+	resource.Resource
+	// ControllerInputs returns the list of inputs from the controller that are being monitored.
+	ControllerInputs() []input.Control
+}
@@ -0,0 +1,486 @@
+// Package builtin implements a remote control for an arm and gripper.
+package builtin
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/edaniels/golog"
+	"github.com/golang/geo/r3"
+	"github.com/pkg/errors"
+	vutils "go.viam.com/utils"
+
+	"go.viam.com/rdk/components/arm"
+	"go.viam.com/rdk/components/gripper"
+	"go.viam.com/rdk/components/input"
+	"go.viam.com/rdk/motionplan"
+	"go.viam.com/rdk/motionplan/ik"
+	"go.viam.com/rdk/referenceframe"
+	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/robot/framesystem"
+	"go.viam.com/rdk/services/armremotecontrol"
+	"go.viam.com/rdk/session"
+	"go.viam.com/rdk/spatialmath"
+	"go.viam.com/rdk/utils"
+)
+
+// Frames that the jog velocities can be expressed in, other than those in the robot's frame system. The base frame is the frame the
+// arm is attached to in the frame system.
+const (
+	baseFrame        = "base"
+	endEffectorFrame = "end_effector"
+)
+
+const (
+	defaultMaxLinearVelocity  = 50. // mm per sec
+	defaultMaxAngularVelocity = 15. // deg per sec
+	defaultControlFrequencyHz = 10. // jog steps per sec
+	defaultSpeedScale         = 0.5 // fraction of the max velocities
+	minSpeedScale             = 0.125
+	axisThreshold             = 0.05 // axis values below this are treated as zero
+
+	// Jogs up to this size are solved for with inverse kinematics seeded with the current joint positions, rather than planned in full.
+	maxIKJogLinear  = 10. // mm
+	maxIKJogAngular = 5.  // deg
+	// maxIKJogJointChange is the most any joint can move in a jog solved for with inverse kinematics, in radians or mm. A solution
+	// that moves a joint further has left the arm's current configuration, so the jog is planned in full instead.
+	maxIKJogJointChange = 0.5
+)
+
+func init() {
+	resource.RegisterService(armremotecontrol.API, resource.DefaultServiceModel, resource.Registration[armremotecontrol.Service, *Config]{
+		Constructor: NewBuiltIn,
+	})
+}
+
+// Config describes how to configure the service.
+type Config struct {
+	ArmName             string  `json:"arm"`
+	GripperName         string  `json:"gripper,omitempty"`
+	InputControllerName string  `json:"input_controller"`
+	Frame               string  `json:"frame,omitempty"`
+	MaxLinearVelocity   float64 `json:"max_linear_mm_per_sec,omitempty"`
+	MaxAngularVelocity  float64 `json:"max_angular_deg_per_sec,omitempty"`
+	ControlFrequencyHz  float64 `json:"control_frequency_hz,omitempty"`
+}
+
+// Validate creates the list of implicit dependencies.
+func (conf *Config) Validate(path string) ([]string, error) {
+	var deps []string
+	if conf.InputControllerName == "" {
+		return nil, vutils.NewConfigValidationFieldRequiredError(path, "input_controller")
+	}
+	deps = append(deps, conf.InputControllerName)
+
+	if conf.ArmName == "" {
+		return nil, vutils.NewConfigValidationFieldRequiredError(path, "arm")
+	}
+	deps = append(deps, conf.ArmName)
+
+	if conf.GripperName != "" {
+		deps = append(deps, conf.GripperName)
+	}
+	deps = append(deps, framesystem.InternalServiceName.String())
+	return deps, nil
+}
+
+// jogState holds the normalized velocities requested by the controller.
+type jogState struct {
+	mu         sync.Mutex
+	linear     r3.Vector
+	angular    r3.Vector
+	speedScale float64
+}
+
+// builtIn is the structure of the remote service.
+type builtIn struct {
+	resource.Named
+	resource.AlwaysRebuild
+
+	arm             arm.Arm
+	gripper         gripper.Gripper
+	inputController input.Controller
+	fsService       framesystem.Service
+	frame           string
+	maxLinear       float64
+	maxAngular      float64
+	stepPeriod      time.Duration
+
+	// solver solves small jogs of solverFrame. It is only used by the jog loop.
+	solver      *ik.CombinedIK
+	solverFrame referenceframe.Frame
+
+	state                   jogState
+	logger                  golog.Logger
+	cancel                  func()
+	cancelCtx               context.Context
+	activeBackgroundWorkers sync.WaitGroup
+}
+
+// NewBuiltIn returns a new arm remote control service.
+func NewBuiltIn(
+	ctx context.Context,
+	deps resource.Dependencies,
+	conf resource.Config,
+	logger golog.Logger,
+) (armremotecontrol.Service, error) {
+	svcConfig, err := resource.NativeConfig[*Config](conf)
+	if err != nil {
+		return nil, err
+	}
+	arm1, err := arm.FromDependencies(deps, svcConfig.ArmName)
+	if err != nil {
+		return nil, err
+	}
+	controller, err := input.FromDependencies(deps, svcConfig.InputControllerName)
+	if err != nil {
+		return nil, err
+	}
+	fsService, err := framesystem.FromDependencies(deps)
+	if err != nil {
+		return nil, err
+	}
+	var gripper1 gripper.Gripper
+	if svcConfig.GripperName != "" {
+		gripper1, err = gripper.FromDependencies(deps, svcConfig.GripperName)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	cancelCtx, cancel := context.WithCancel(context.Background())
+	svc := &builtIn{
+		Named:           conf.ResourceName().AsNamed(),
+		arm:             arm1,
+		gripper:         gripper1,
+		inputController: controller,
+		fsService:       fsService,
+		frame:           svcConfig.Frame,
+		maxLinear:       svcConfig.MaxLinearVelocity,
+		maxAngular:      svcConfig.MaxAngularVelocity,
+		logger:          logger,
+		cancelCtx:       cancelCtx,
+		cancel:          cancel,
+	}
+	if svc.frame == "" {
+		svc.frame = baseFrame
+	}
+	if svc.maxLinear <= 0 {
+		svc.maxLinear = defaultMaxLinearVelocity
+	}
+	if svc.maxAngular <= 0 {
+		svc.maxAngular = defaultMaxAngularVelocity
+	}
+	freq := svcConfig.ControlFrequencyHz
+	if freq <= 0 {
+		freq = defaultControlFrequencyHz
+	}
+	svc.stepPeriod = time.Duration(float64(time.Second) / freq)
+	svc.state.speedScale = defaultSpeedScale
+
+	if err := svc.registerCallbacks(ctx); err != nil {
+		cancel()
+		return nil, errors.Errorf("error with starting remote control service: %q", err)
+	}
+	svc.jogLoop()
+
+	return svc, nil
+}
+
+// ControllerInputs returns the list of inputs from the controller that are being monitored.
+func (svc *builtIn) ControllerInputs() []input.Control {
+	return []input.Control{
+		input.AbsoluteX, input.AbsoluteY, input.AbsoluteRX, input.AbsoluteRY,
+		input.AbsoluteHat0X, input.AbsoluteHat0Y,
+		input.ButtonSouth, input.ButtonEast, input.ButtonLT, input.ButtonRT,
+	}
+}
+
+// registerCallbacks registers events from the controller to the arm and gripper.
+func (svc *builtIn) registerCallbacks(ctx context.Context) error {
+	remoteCtl := func(ctx context.Context, event input.Event) {
+		if svc.cancelCtx.Err() != nil {
+			return
+		}
+		svc.processEvent(ctx, event)
+	}
+
+	stop := func(ctx context.Context, event input.Event) {
+		if svc.cancelCtx.Err() != nil {
+			return
+		}
+		// Connect and Disconnect events should both stop the arm completely.
+		svc.state.mu.Lock()
+		svc.state.linear = r3.Vector{}
+		svc.state.angular = r3.Vector{}
+		svc.state.mu.Unlock()
+		if err := svc.arm.Stop(ctx, nil); err != nil {
+			svc.logger.Error(err)
+		}
+	}
+
+	for _, control := range svc.ControllerInputs() {
+		triggers := []input.EventType{input.PositionChangeAbs}
+		switch control {
+		case input.ButtonSouth, input.ButtonEast, input.ButtonLT, input.ButtonRT:
+			triggers = []input.EventType{input.ButtonPress}
+		default:
+		}
+		if err := svc.inputController.RegisterControlCallback(ctx, control, triggers, remoteCtl, nil); err != nil {
+			return err
+		}
+		if err := svc.inputController.RegisterControlCallback(
+			ctx,
+			control,
+			[]input.EventType{input.Connect, input.Disconnect},
+			stop,
+			nil,
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// processEvent updates the jog state or actuates the gripper. The left stick translates in X and Y,
+// the right stick translates in Z and yaws, and the hat pitches and rolls. South grabs, east opens,
+// and the left and right bumpers halve and double the speed.
+func (svc *builtIn) processEvent(ctx context.Context, event input.Event) {
+	switch event.Control {
+	case input.ButtonSouth:
+		if svc.gripper != nil {
+			svc.runGripper(func(ctx context.Context) error {
+				_, err := svc.gripper.Grab(ctx, nil)
+				return err
+			})
+		}
+		return
+	case input.ButtonEast:
+		if svc.gripper != nil {
+			svc.runGripper(func(ctx context.Context) error {
+				return svc.gripper.Open(ctx, nil)
+			})
+		}
+		return
+	default:
+	}
+
+	svc.state.mu.Lock()
+	defer svc.state.mu.Unlock()
+
+	value := event.Value
+	if math.Abs(value) < axisThreshold {
+		value = 0
+	}
+	switch event.Control {
+	case input.AbsoluteY:
+		svc.state.linear.X = -value
+	case input.AbsoluteX:
+		svc.state.linear.Y = -value
+	case input.AbsoluteRY:
+		svc.state.linear.Z = -value
+	case input.AbsoluteRX:
+		svc.state.angular.Z = -value
+	case input.AbsoluteHat0Y:
+		svc.state.angular.Y = value
+	case input.AbsoluteHat0X:
+		svc.state.angular.X = value
+	case input.ButtonLT:
+		svc.state.speedScale = math.Max(minSpeedScale, svc.state.speedScale/2)
+	case input.ButtonRT:
+		svc.state.speedScale = math.Min(1, svc.state.speedScale*2)
+	default:
+	}
+
+	session.SafetyMonitor(ctx, svc.arm)
+}
+
+// runGripper runs the given gripper command in the background since grabbing can take a while.
+func (svc *builtIn) runGripper(f func(ctx context.Context) error) {
+	svc.activeBackgroundWorkers.Add(1)
+	vutils.ManagedGo(func() {
+		if err := f(svc.cancelCtx); err != nil {
+			svc.logger.Errorw("error actuating gripper", "error", err)
+		}
+	}, svc.activeBackgroundWorkers.Done)
+}
+
+// jogLoop moves the end effector one step at a time for as long as the controller requests a velocity.
+func (svc *builtIn) jogLoop() {
+	svc.activeBackgroundWorkers.Add(1)
+	vutils.ManagedGo(func() {
+		for {
+			if !vutils.SelectContextOrWait(svc.cancelCtx, svc.stepPeriod) {
+				return
+			}
+			svc.state.mu.Lock()
+			linear, angular, scale := svc.state.linear, svc.state.angular, svc.state.speedScale
+			svc.state.mu.Unlock()
+			if linear.Norm() == 0 && angular.Norm() == 0 {
+				continue
+			}
+
+			dt := svc.stepPeriod.Seconds() * scale
+			if err := svc.jog(svc.cancelCtx, linear.Mul(svc.maxLinear*dt), angular.Mul(utils.DegToRad(svc.maxAngular*dt))); err != nil {
+				svc.logger.Errorw("error jogging arm", "error", err)
+			}
+		}
+	}, svc.activeBackgroundWorkers.Done)
+}
+
+// jog moves the end effector by the given translation in mm and rotation vector in radians. Small jogs go straight to the joint positions
+// nearest the current ones that reach the goal, and larger ones are planned through the robot's frame system.
+func (svc *builtIn) jog(ctx context.Context, translation, rotation r3.Vector) error {
+	fs, err := svc.fsService.FrameSystem(ctx, nil)
+	if err != nil {
+		return err
+	}
+	inputs, _, err := svc.fsService.CurrentInputs(ctx)
+	if err != nil {
+		return err
+	}
+	armFrame := fs.Frame(svc.arm.Name().ShortName())
+	if armFrame == nil {
+		return referenceframe.NewFrameMissingError(svc.arm.Name().ShortName())
+	}
+	jogFrame, err := svc.jogFrame(fs, armFrame)
+	if err != nil {
+		return err
+	}
+
+	tf, err := fs.Transform(inputs, referenceframe.NewPoseInFrame(armFrame.Name(), spatialmath.NewZeroPose()), jogFrame)
+	if err != nil {
+		return err
+	}
+	current := tf.(*referenceframe.PoseInFrame).Pose()
+	goal := referenceframe.NewPoseInFrame(jogFrame, jogGoal(current, translation, rotation, svc.frame))
+	if translation.Norm() <= maxIKJogLinear && rotation.Norm() <= utils.DegToRad(maxIKJogAngular) {
+		joints, err := svc.solveJog(ctx, fs, armFrame, inputs, goal)
+		if err != nil {
+			return err
+		}
+		if joints != nil {
+			return arm.GoToWaypoints(ctx, svc.arm, [][]referenceframe.Input{joints})
+		}
+	}
+	if tf, err = fs.Transform(inputs, goal, referenceframe.World); err != nil {
+		return err
+	}
+	plan, err := motionplan.PlanMotion(ctx, svc.logger, tf.(*referenceframe.PoseInFrame), armFrame, inputs, fs, nil, nil, nil)
+	if err != nil {
+		return err
+	}
+	steps := make([][]referenceframe.Input, 0, len(plan))
+	for _, step := range plan {
+		steps = append(steps, step[armFrame.Name()])
+	}
+	return arm.GoToWaypoints(ctx, svc.arm, steps)
+}
+
+// solveJog solves for the joint positions of the arm nearest its current ones which put the end effector at the goal. It returns nil
+// joint positions if none are found in time, or if the nearest found would move a joint too far, so that the jog is planned instead.
+func (svc *builtIn) solveJog(
+	ctx context.Context,
+	fs referenceframe.FrameSystem,
+	armFrame referenceframe.Frame,
+	inputs map[string][]referenceframe.Input,
+	goal *referenceframe.PoseInFrame,
+) ([]referenceframe.Input, error) {
+	parent, err := fs.Parent(armFrame)
+	if err != nil {
+		return nil, err
+	}
+	tf, err := fs.Transform(inputs, goal, parent.Name())
+	if err != nil {
+		return nil, err
+	}
+	goalPose := tf.(*referenceframe.PoseInFrame).Pose()
+	if svc.solver == nil || svc.solverFrame != armFrame {
+		if svc.solver, err = ik.CreateCombinedIKSolver(armFrame, svc.logger, 1, 0); err != nil {
+			return nil, err
+		}
+		svc.solverFrame = armFrame
+	}
+
+	// the solver sends solutions until it is cancelled, so stop it at the first one
+	solveCtx, cancel := context.WithTimeout(ik.ContextWithGoal(ctx, goalPose), svc.stepPeriod)
+	defer cancel()
+	seed := inputs[armFrame.Name()]
+	solutions := make(chan *ik.Solution)
+	solveErr := make(chan error, 1)
+	vutils.PanicCapturingGo(func() {
+		solveErr <- svc.solver.Solve(solveCtx, solutions, seed, ik.NewSquaredNormMetric(goalPose), 0)
+	})
+	var solution *ik.Solution
+	for {
+		select {
+		case found := <-solutions:
+			if solution == nil {
+				solution = found
+				cancel()
+			}
+		case err := <-solveErr:
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			if solution == nil {
+				svc.logger.Debugw("could not solve for jog, so planning it instead", "error", err)
+				return nil, nil
+			}
+			for i, input := range solution.Configuration {
+				if math.Abs(input.Value-seed[i].Value) > maxIKJogJointChange {
+					svc.logger.Debugf("jog would move joint %d too far, so planning it instead", i)
+					return nil, nil
+				}
+			}
+			return solution.Configuration, nil
+		}
+	}
+}
+
+// jogFrame returns the name of the frame in the frame system which the jog velocities are expressed in. The end effector moves as it
+// is jogged, so its jog goals are expressed in the world frame.
+func (svc *builtIn) jogFrame(fs referenceframe.FrameSystem, armFrame referenceframe.Frame) (string, error) {
+	switch svc.frame {
+	case baseFrame:
+		parent, err := fs.Parent(armFrame)
+		if err != nil {
+			return "", err
+		}
+		return parent.Name(), nil
+	case endEffectorFrame:
+		return referenceframe.World, nil
+	default:
+		if fs.Frame(svc.frame) == nil {
+			return "", referenceframe.NewFrameMissingError(svc.frame)
+		}
+		return svc.frame, nil
+	}
+}
+
+// jogGoal returns the pose reached by moving the current pose by the given translation and rotation
+// vector, both expressed in either the frame the current pose is in or the end effector frame.
+func jogGoal(current spatialmath.Pose, translation, rotation r3.Vector, frame string) spatialmath.Pose {
+	var delta spatialmath.Orientation = spatialmath.NewZeroOrientation()
+	if theta := rotation.Norm(); theta > 0 {
+		axis := rotation.Mul(1 / theta)
+		delta = &spatialmath.R4AA{Theta: theta, RX: axis.X, RY: axis.Y, RZ: axis.Z}
+	}
+	if frame == endEffectorFrame {
+		return spatialmath.Compose(current, spatialmath.NewPose(translation, delta))
+	}
+	orientation := spatialmath.Compose(
+		spatialmath.NewPoseFromOrientation(delta),
+		spatialmath.NewPoseFromOrientation(current.Orientation()),
+	).Orientation()
+	return spatialmath.NewPose(current.Point().Add(translation), orientation)
+}
+
+// Close out of all remote control related systems.
+func (svc *builtIn) Close(_ context.Context) error {
+	svc.cancel()
+	svc.activeBackgroundWorkers.Wait()
+	return nil
+}
@@ -0,0 +1,292 @@
+package builtin
+
+import (
+	"context"
+	"math"
+	"sync/atomic"
+	"testing"
+
+	"github.com/edaniels/golog"
+	"github.com/golang/geo/r3"
+	"github.com/pkg/errors"
+	pb "go.viam.com/api/component/arm/v1"
+	"go.viam.com/test"
+	"go.viam.com/utils"
+	"go.viam.com/utils/testutils"
+
+	"go.viam.com/rdk/components/arm"
+	"go.viam.com/rdk/components/arm/fake"
+	"go.viam.com/rdk/components/gripper"
+	"go.viam.com/rdk/components/input"
+	"go.viam.com/rdk/referenceframe"
+	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/robot/framesystem"
+	"go.viam.com/rdk/services/armremotecontrol"
+	"go.viam.com/rdk/spatialmath"
+	"go.viam.com/rdk/testutils/inject"
+)
+
+func TestValidate(t *testing.T) {
+	cfg := &Config{InputControllerName: "inputTest"}
+	_, err := cfg.Validate("path")
+	test.That(t, err, test.ShouldBeError, utils.NewConfigValidationFieldRequiredError("path", "arm"))
+
+	cfg = &Config{ArmName: "armTest", GripperName: "gripperTest"}
+	_, err = cfg.Validate("path")
+	test.That(t, err, test.ShouldBeError, utils.NewConfigValidationFieldRequiredError("path", "input_controller"))
+
+	cfg.InputControllerName = "inputTest"
+	depNames, err := cfg.Validate("path")
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, utils.NewStringSet(depNames...), test.ShouldResemble,
+		utils.NewStringSet("armTest", "gripperTest", "inputTest", framesystem.InternalServiceName.String()))
+}
+
+func TestArmRemoteControl(t *testing.T) {
+	ctx := context.Background()
+	logger := golog.NewTestLogger(t)
+
+	callbacks := map[input.Control]map[input.EventType]input.ControlFunction{}
+	fakeController := &inject.InputController{}
+	fakeController.RegisterControlCallbackFunc = func(
+		ctx context.Context,
+		control input.Control,
+		triggers []input.EventType,
+		ctrlFunc input.ControlFunction,
+		extra map[string]interface{},
+	) error {
+		if callbacks[control] == nil {
+			callbacks[control] = map[input.EventType]input.ControlFunction{}
+		}
+		for _, trigger := range triggers {
+			callbacks[control][trigger] = ctrlFunc
+		}
+		return nil
+	}
+	trigger := func(control input.Control, eventType input.EventType, value float64) {
+		callbacks[control][eventType](ctx, input.Event{Control: control, Event: eventType, Value: value})
+	}
+
+	var stopped, grabbed, opened atomic.Int64
+	injectArm := inject.NewArm("armTest")
+	injectArm.StopFunc = func(ctx context.Context, extra map[string]interface{}) error {
+		stopped.Add(1)
+		return nil
+	}
+	// the jog loop is not under test here, so keep it from moving the arm.
+	injectFS := inject.NewFrameSystemService("")
+	injectFS.FrameSystemFunc = func(
+		ctx context.Context,
+		additionalTransforms []*referenceframe.LinkInFrame,
+	) (referenceframe.FrameSystem, error) {
+		return nil, errors.New("not moving")
+	}
+	injectGripper := inject.NewGripper("gripperTest")
+	injectGripper.GrabFunc = func(ctx context.Context, extra map[string]interface{}) (bool, error) {
+		grabbed.Add(1)
+		return true, nil
+	}
+	injectGripper.OpenFunc = func(ctx context.Context, extra map[string]interface{}) error {
+		opened.Add(1)
+		return nil
+	}
+
+	deps := resource.Dependencies{
+		input.Named("inputTest"):        fakeController,
+		arm.Named("armTest"):            injectArm,
+		gripper.Named("gripperTest"):    injectGripper,
+		framesystem.InternalServiceName: injectFS,
+	}
+	cfg := &Config{
+		ArmName:             "armTest",
+		GripperName:         "gripperTest",
+		InputControllerName: "inputTest",
+	}
+	tmpSvc, err := NewBuiltIn(ctx, deps,
+		resource.Config{
+			Name:                "arm_remote_control",
+			API:                 armremotecontrol.API,
+			ConvertedAttributes: cfg,
+		},
+		logger)
+	test.That(t, err, test.ShouldBeNil)
+	svc, ok := tmpSvc.(*builtIn)
+	test.That(t, ok, test.ShouldBeTrue)
+	defer func() {
+		test.That(t, svc.Close(ctx), test.ShouldBeNil)
+	}()
+
+	for _, control := range svc.ControllerInputs() {
+		test.That(t, callbacks[control][input.Connect], test.ShouldNotBeNil)
+		test.That(t, callbacks[control][input.Disconnect], test.ShouldNotBeNil)
+	}
+
+	t.Run("axes set the jog velocity", func(t *testing.T) {
+		trigger(input.AbsoluteY, input.PositionChangeAbs, -1)
+		trigger(input.AbsoluteX, input.PositionChangeAbs, 0.01)
+		trigger(input.AbsoluteRX, input.PositionChangeAbs, 0.5)
+		svc.state.mu.Lock()
+		test.That(t, svc.state.linear, test.ShouldResemble, r3.Vector{X: 1})
+		test.That(t, svc.state.angular, test.ShouldResemble, r3.Vector{Z: -0.5})
+		svc.state.mu.Unlock()
+	})
+
+	t.Run("bumpers scale the speed", func(t *testing.T) {
+		trigger(input.ButtonRT, input.ButtonPress, 1)
+		trigger(input.ButtonRT, input.ButtonPress, 1)
+		svc.state.mu.Lock()
+		test.That(t, svc.state.speedScale, test.ShouldEqual, 1)
+		svc.state.mu.Unlock()
+
+		for i := 0; i < 5; i++ {
+			trigger(input.ButtonLT, input.ButtonPress, 1)
+		}
+		svc.state.mu.Lock()
+		test.That(t, svc.state.speedScale, test.ShouldEqual, minSpeedScale)
+		svc.state.mu.Unlock()
+	})
+
+	t.Run("buttons actuate the gripper", func(t *testing.T) {
+		trigger(input.ButtonSouth, input.ButtonPress, 1)
+		trigger(input.ButtonEast, input.ButtonPress, 1)
+		testutils.WaitForAssertion(t, func(tb testing.TB) {
+			tb.Helper()
+			test.That(tb, grabbed.Load(), test.ShouldEqual, 1)
+			test.That(tb, opened.Load(), test.ShouldEqual, 1)
+		})
+	})
+
+	t.Run("disconnect stops the arm", func(t *testing.T) {
+		trigger(input.AbsoluteX, input.Disconnect, 0)
+		test.That(t, stopped.Load(), test.ShouldEqual, 1)
+		svc.state.mu.Lock()
+		test.That(t, svc.state.linear, test.ShouldResemble, r3.Vector{})
+		test.That(t, svc.state.angular, test.ShouldResemble, r3.Vector{})
+		svc.state.mu.Unlock()
+	})
+}
+
+func TestJogGoal(t *testing.T) {
+	current := spatialmath.NewPose(r3.Vector{X: 100, Y: 0, Z: 200}, &spatialmath.R4AA{Theta: math.Pi / 2, RZ: 1})
+	translation := r3.Vector{X: 10}
+	rotation := r3.Vector{Z: math.Pi / 2}
+
+	goal := jogGoal(current, translation, r3.Vector{}, baseFrame)
+	test.That(t, spatialmath.PoseAlmostEqual(goal, spatialmath.NewPose(r3.Vector{X: 110, Z: 200}, current.Orientation())), test.ShouldBeTrue)
+
+	// in the end effector frame, x points along the base frame's y because of the current yaw
+	goal = jogGoal(current, translation, r3.Vector{}, endEffectorFrame)
+	expected := spatialmath.NewPose(r3.Vector{X: 100, Y: 10, Z: 200}, current.Orientation())
+	test.That(t, spatialmath.PoseAlmostEqual(goal, expected), test.ShouldBeTrue)
+
+	// rotating in the base frame keeps the end effector in place
+	goal = jogGoal(current, r3.Vector{}, rotation, baseFrame)
+	expected = spatialmath.NewPose(current.Point(), &spatialmath.R4AA{Theta: math.Pi, RZ: 1})
+	test.That(t, spatialmath.PoseAlmostEqual(goal, expected), test.ShouldBeTrue)
+}
+
+func TestJog(t *testing.T) {
+	ctx := context.Background()
+	logger := golog.NewTestLogger(t)
+
+	fakeArm, err := fake.NewArm(ctx, nil, resource.Config{Name: "arm", ConvertedAttributes: &fake.Config{ArmModel: "ur5e"}}, logger)
+	test.That(t, err, test.ShouldBeNil)
+	startJoints := &pb.JointPositions{Values: []float64{0, -70, 80, -100, -90, 0}}
+	test.That(t, fakeArm.MoveToJointPositions(ctx, startJoints, nil), test.ShouldBeNil)
+	fakeController := &inject.InputController{}
+	fakeController.RegisterControlCallbackFunc = func(
+		ctx context.Context,
+		control input.Control,
+		triggers []input.EventType,
+		ctrlFunc input.ControlFunction,
+		extra map[string]interface{},
+	) error {
+		return nil
+	}
+
+	// the arm is mounted on a frame which is yawed a quarter turn in the world
+	deps := resource.Dependencies{input.Named("inputTest"): fakeController, arm.Named("arm"): fakeArm}
+	mount := spatialmath.NewPose(r3.Vector{X: 500}, &spatialmath.OrientationVectorDegrees{OZ: 1, Theta: 90})
+	fsParts := []*referenceframe.FrameSystemPart{
+		{FrameConfig: referenceframe.NewLinkInFrame(referenceframe.World, mount, "mount", nil)},
+		{FrameConfig: referenceframe.NewLinkInFrame("mount", spatialmath.NewZeroPose(), "arm", nil), ModelFrame: fakeArm.ModelFrame()},
+	}
+	fsSvc, err := framesystem.New(ctx, resource.Dependencies{}, logger)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, fsSvc.Reconfigure(ctx, deps, resource.Config{ConvertedAttributes: &framesystem.Config{Parts: fsParts}}), test.ShouldBeNil)
+	deps[framesystem.InternalServiceName] = fsSvc
+
+	endInWorld := func() r3.Vector {
+		pose, err := fsSvc.TransformPose(ctx, referenceframe.NewPoseInFrame("arm", spatialmath.NewZeroPose()), referenceframe.World, nil)
+		test.That(t, err, test.ShouldBeNil)
+		return pose.Pose().Point()
+	}
+
+	for _, tc := range []struct {
+		name        string
+		frame       string
+		translation r3.Vector
+		expected    r3.Vector // the motion of the end effector in the world frame
+	}{
+		{"small jog in world", "world", r3.Vector{X: 5}, r3.Vector{X: 5}},
+		// the base of the arm is its mount, whose x axis is the world's y axis
+		{"small jog in base", baseFrame, r3.Vector{X: 5}, r3.Vector{Y: 5}},
+		{"large jog in world", "world", r3.Vector{X: 50}, r3.Vector{X: 50}},
+		{"large jog in base", baseFrame, r3.Vector{X: 50}, r3.Vector{Y: 50}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &Config{ArmName: "arm", InputControllerName: "inputTest", Frame: tc.frame}
+			tmpSvc, err := NewBuiltIn(ctx, deps, resource.Config{Name: "arm_remote_control", ConvertedAttributes: cfg}, logger)
+			test.That(t, err, test.ShouldBeNil)
+			svc := tmpSvc.(*builtIn)
+			defer func() {
+				test.That(t, svc.Close(ctx), test.ShouldBeNil)
+			}()
+
+			start := endInWorld()
+			test.That(t, svc.jog(ctx, tc.translation, r3.Vector{}), test.ShouldBeNil)
+			test.That(t, spatialmath.R3VectorAlmostEqual(endInWorld().Sub(start), tc.expected, 0.1), test.ShouldBeTrue)
+		})
+	}
+
+	t.Run("small jogs are solved near the current joint positions", func(t *testing.T) {
+		cfg := &Config{ArmName: "arm", InputControllerName: "inputTest", Frame: "world"}
+		tmpSvc, err := NewBuiltIn(ctx, deps, resource.Config{Name: "arm_remote_control", ConvertedAttributes: cfg}, logger)
+		test.That(t, err, test.ShouldBeNil)
+		svc := tmpSvc.(*builtIn)
+		defer func() {
+			test.That(t, svc.Close(ctx), test.ShouldBeNil)
+		}()
+
+		fs, err := fsSvc.FrameSystem(ctx, nil)
+		test.That(t, err, test.ShouldBeNil)
+		inputs, _, err := fsSvc.CurrentInputs(ctx)
+		test.That(t, err, test.ShouldBeNil)
+		armFrame := fs.Frame("arm")
+		tf, err := fs.Transform(inputs, referenceframe.NewPoseInFrame("arm", spatialmath.NewZeroPose()), referenceframe.World)
+		test.That(t, err, test.ShouldBeNil)
+		current := tf.(*referenceframe.PoseInFrame).Pose()
+		goal := referenceframe.NewPoseInFrame(referenceframe.World,
+			spatialmath.NewPose(current.Point().Add(r3.Vector{Z: 5}), current.Orientation()))
+
+		joints, err := svc.solveJog(ctx, fs, armFrame, inputs, goal)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, joints, test.ShouldNotBeNil)
+		for i, joint := range joints {
+			test.That(t, joint.Value, test.ShouldAlmostEqual, inputs["arm"][i].Value, maxIKJogJointChange)
+		}
+		reached, err := armFrame.Transform(joints)
+		test.That(t, err, test.ShouldBeNil)
+		solved, err := fs.Transform(inputs, goal, "mount")
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, spatialmath.PoseAlmostCoincidentEps(reached, solved.(*referenceframe.PoseInFrame).Pose(), 0.1), test.ShouldBeTrue)
+	})
+
+	cfg := &Config{ArmName: "arm", InputControllerName: "inputTest", Frame: "missing"}
+	tmpSvc, err := NewBuiltIn(ctx, deps, resource.Config{Name: "arm_remote_control", ConvertedAttributes: cfg}, logger)
+	test.That(t, err, test.ShouldBeNil)
+	defer func() {
+		test.That(t, tmpSvc.Close(ctx), test.ShouldBeNil)
+	}()
+	test.That(t, tmpSvc.(*builtIn).jog(ctx, r3.Vector{X: 10}, r3.Vector{}), test.ShouldNotBeNil)
+}
@@ -0,0 +1,7 @@
+// Package register registers all relevant armremotecontrol models and also API specific functions
+package register
+
+import (
+	// for armremotecontrol models.
+	_ "go.viam.com/rdk/services/armremotecontrol/builtin"
+)
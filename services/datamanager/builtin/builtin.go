@@ -48,7 +48,11 @@ func init() {
 			},
 			// NOTE(erd): this would be better as a weak dependencies returned through a more
 			// typed validate or different system.
-			WeakDependencies: []internal.ResourceMatcher{internal.ComponentDependencyWildcardMatcher, internal.SLAMDependencyWildcardMatcher},
+			WeakDependencies: []internal.ResourceMatcher{
+				internal.ComponentDependencyWildcardMatcher,
+				internal.SLAMDependencyWildcardMatcher,
+				internal.VisionDependencyWildcardMatcher,
+			},
 		})
 }
 
@@ -198,6 +202,7 @@ func getDurationFromHz(captureFrequencyHz float32) time.Duration {
 func (svc *builtIn) initializeOrUpdateCollector(
 	md resourceMethodMetadata,
 	config *datamanager.DataCaptureConfig,
+	deps resource.Dependencies,
 ) (
 	*collectorAndConfig, error,
 ) {
@@ -264,6 +269,7 @@ func (svc *builtIn) initializeOrUpdateCollector(
 		BufferSize:    captureBufferSize,
 		Logger:        svc.logger,
 		Clock:         clock,
+		Dependencies:  deps,
 	}
 	collector, err := (*collectorConstructor)(config.Resource, params)
 	if err != nil {
@@ -393,7 +399,7 @@ func (svc *builtIn) Reconfigure(
 				// We only use service-level tags.
 				resConf.Tags = svcConfig.Tags
 
-				newCollectorAndConfig, err := svc.initializeOrUpdateCollector(componentMethodMetadata, resConf)
+				newCollectorAndConfig, err := svc.initializeOrUpdateCollector(componentMethodMetadata, resConf, deps)
 				if err != nil {
 					svc.logger.Errorw("failed to initialize or update collector", "error", err)
 				} else {
@@ -79,7 +79,12 @@ func ReadFile(f *os.File) (*File, error) {
 
 // NewFile creates a new File with the specified md in the specified directory.
 func NewFile(dir string, md *v1.DataCaptureMetadata) (*File, error) {
-	fileName := filepath.Join(dir, getFileTimestampName()) + InProgressFileExt
+	return newFile(filepath.Join(dir, getFileTimestampName()), md)
+}
+
+// newFile creates a new File with the specified md, at the given path without its extension.
+func newFile(path string, md *v1.DataCaptureMetadata) (*File, error) {
+	fileName := path + InProgressFileExt
 	//nolint:gosec
 	f, err := os.OpenFile(fileName, os.O_APPEND|os.O_RDWR|os.O_CREATE, 0o600)
 	if err != nil {
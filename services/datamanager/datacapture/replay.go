@@ -0,0 +1,316 @@
+package datacapture
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	v1 "go.viam.com/api/app/datasync/v1"
+	goutils "go.viam.com/utils"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"go.viam.com/rdk/components/generic"
+	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/utils/contextutils"
+)
+
+const replayTimeFormat = time.RFC3339
+
+// ErrEndOfDataset represents that a Player has returned all of its readings.
+var ErrEndOfDataset = errors.New("reached end of dataset")
+
+// ReplayConfig describes how to replay the data a component captured to local capture files.
+type ReplayConfig struct {
+	// Directory is searched recursively for capture files.
+	Directory string `json:"directory"`
+	// Source is the name of the component the data was captured from.
+	Source   string         `json:"source"`
+	Interval ReplayInterval `json:"time_interval,omitempty"`
+	// RealTime plays readings back with the same spacing they were captured with. Otherwise each
+	// call returns the next reading immediately.
+	RealTime bool `json:"real_time,omitempty"`
+	// Loop restarts playback from the first reading once the last one has been returned.
+	Loop bool `json:"loop,omitempty"`
+	// Clock is the name of a replay clock to play readings back on, shared with other replay models.
+	Clock string `json:"clock,omitempty"`
+}
+
+// A ReplayClock is a timeline of capture times shared by replay models, so that they all emit data
+// according to when it was captured.
+type ReplayClock interface {
+	resource.Resource
+	// Register tells the clock about the earliest capture time of a replay model.
+	Register(first time.Time)
+	// Now returns the current capture time of the timeline.
+	Now() time.Time
+	// WaitUntil blocks until the timeline reaches t.
+	WaitUntil(ctx context.Context, t time.Time) error
+}
+
+// ReplayClockFromDependencies is a helper for getting the named replay clock from a collection of
+// dependencies.
+func ReplayClockFromDependencies(deps resource.Dependencies, name string) (ReplayClock, error) {
+	return resource.FromDependencies[ReplayClock](deps, generic.Named(name))
+}
+
+// ReplayInterval holds the start and end time (UTC, RFC3339) used to select the readings to replay.
+type ReplayInterval struct {
+	Start string `json:"start,omitempty"`
+	End   string `json:"end,omitempty"`
+}
+
+// Validate ensures all parts of the config are valid.
+func (cfg *ReplayConfig) Validate(path string) ([]string, error) {
+	if cfg.Directory == "" {
+		return nil, goutils.NewConfigValidationFieldRequiredError(path, "directory")
+	}
+	if cfg.Source == "" {
+		return nil, goutils.NewConfigValidationFieldRequiredError(path, "source")
+	}
+	if _, _, err := cfg.interval(); err != nil {
+		return nil, goutils.NewConfigValidationError(path, err)
+	}
+	if cfg.Clock != "" {
+		if cfg.Loop {
+			return nil, goutils.NewConfigValidationError(path, errors.New("loop cannot be used with a clock"))
+		}
+		return []string{cfg.Clock}, nil
+	}
+	return nil, nil
+}
+
+// interval returns the parsed start and end times, which are zero if unset.
+func (cfg *ReplayConfig) interval() (time.Time, time.Time, error) {
+	var start, end time.Time
+	var err error
+	if cfg.Interval.Start != "" {
+		start, err = time.Parse(replayTimeFormat, cfg.Interval.Start)
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.New("invalid time format for start time (UTC), use RFC3339")
+		}
+	}
+	if cfg.Interval.End != "" {
+		end, err = time.Parse(replayTimeFormat, cfg.Interval.End)
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.New("invalid time format for end time (UTC), use RFC3339")
+		}
+	}
+	if !start.IsZero() && !end.IsZero() && start.After(end) {
+		return time.Time{}, time.Time{}, errors.New("end time (UTC) must be after start time (UTC)")
+	}
+	return start, end, nil
+}
+
+// replayEntry is a reading along with the metadata of the file it was read from.
+type replayEntry struct {
+	data *v1.SensorData
+	md   *v1.DataCaptureMetadata
+}
+
+// A Player plays back the readings captured for one method of a component, in the order they
+// were requested.
+type Player struct {
+	realTime bool
+	loop     bool
+	clock    ReplayClock
+
+	mu      sync.Mutex
+	entries []replayEntry
+	next    int
+	// playStart is when the first reading was returned, used to pace real time playback.
+	playStart time.Time
+}
+
+// NewPlayer reads every capture file under the configured directory that holds data captured by
+// the configured source of the given API for the given method.
+func NewPlayer(deps resource.Dependencies, cfg *ReplayConfig, api resource.API, method string) (*Player, error) {
+	start, end, err := cfg.interval()
+	if err != nil {
+		return nil, err
+	}
+	var clock ReplayClock
+	if cfg.Clock != "" {
+		clock, err = ReplayClockFromDependencies(deps, cfg.Clock)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var entries []replayEntry
+	err = filepath.WalkDir(cfg.Directory, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || filepath.Ext(path) != FileExt {
+			return nil
+		}
+		fileEntries, err := readReplayFile(path, api, cfg.Source, method)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read %s", path)
+		}
+		for _, entry := range fileEntries {
+			requested := entry.data.GetMetadata().GetTimeRequested().AsTime()
+			if (!start.IsZero() && requested.Before(start)) || (!end.IsZero() && requested.After(end)) {
+				continue
+			}
+			entries = append(entries, entry)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].data.GetMetadata().GetTimeRequested().AsTime().Before(
+			entries[j].data.GetMetadata().GetTimeRequested().AsTime())
+	})
+	if clock != nil && len(entries) > 0 {
+		clock.Register(entries[0].data.GetMetadata().GetTimeRequested().AsTime())
+	}
+	return &Player{realTime: cfg.RealTime, loop: cfg.Loop, clock: clock, entries: entries}, nil
+}
+
+// readReplayFile returns the readings in the capture file at path if it matches the component and method.
+func readReplayFile(path string, api resource.API, componentName, method string) ([]replayEntry, error) {
+	//nolint:gosec
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer goutils.UncheckedErrorFunc(f.Close)
+
+	dcFile, err := ReadFile(f)
+	if err != nil {
+		return nil, err
+	}
+	md := dcFile.ReadMetadata()
+	if md.GetComponentType() != api.String() || md.GetComponentName() != componentName || md.GetMethodName() != method {
+		return nil, nil
+	}
+
+	readings, err := SensorDataFromFile(dcFile)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]replayEntry, 0, len(readings))
+	for _, reading := range readings {
+		entries = append(entries, replayEntry{data: reading, md: md})
+	}
+	return entries, nil
+}
+
+// Len returns the number of readings the player plays back.
+func (p *Player) Len() int {
+	return len(p.entries)
+}
+
+// Next returns the next reading along with the metadata of the file it was captured to. In real
+// time playback, or when playing back on a clock, it waits until the reading is due and skips
+// readings that were due while nobody asked for them.
+func (p *Player) Next(ctx context.Context) (*v1.SensorData, *v1.DataCaptureMetadata, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.clock != nil {
+		return p.nextOnClock(ctx)
+	}
+
+	if p.next >= len(p.entries) {
+		if !p.loop || len(p.entries) == 0 {
+			return nil, nil, ErrEndOfDataset
+		}
+		p.next = 0
+		p.playStart = time.Time{}
+	}
+
+	if p.realTime {
+		if p.playStart.IsZero() {
+			p.playStart = time.Now()
+		}
+		elapsed := time.Since(p.playStart)
+		for p.next+1 < len(p.entries) && p.offset(p.next+1) <= elapsed {
+			p.next++
+		}
+		if wait := p.offset(p.next) - elapsed; wait > 0 {
+			if !goutils.SelectContextOrWait(ctx, wait) {
+				return nil, nil, ctx.Err()
+			}
+		}
+	}
+
+	entry := p.entries[p.next]
+	p.next++
+	return entry.data, entry.md, nil
+}
+
+// nextOnClock returns the reading that is due on the clock, following the clock if it was moved
+// back. It assumes the lock is being held.
+func (p *Player) nextOnClock(ctx context.Context) (*v1.SensorData, *v1.DataCaptureMetadata, error) {
+	now := p.clock.Now()
+	due := sort.Search(len(p.entries), func(i int) bool { return p.requested(i).After(now) }) - 1
+	if due >= p.next || due < p.next-1 {
+		p.next = due
+		if p.next < 0 {
+			p.next = 0
+		}
+	}
+	if p.next >= len(p.entries) {
+		return nil, nil, ErrEndOfDataset
+	}
+	if err := p.clock.WaitUntil(ctx, p.requested(p.next)); err != nil {
+		return nil, nil, err
+	}
+
+	entry := p.entries[p.next]
+	p.next++
+	return entry.data, entry.md, nil
+}
+
+// requested returns when the reading at index i was requested.
+func (p *Player) requested(i int) time.Time {
+	return p.entries[i].data.GetMetadata().GetTimeRequested().AsTime()
+}
+
+// offset returns how long after the first reading the reading at index i was requested.
+func (p *Player) offset(i int) time.Duration {
+	return p.requested(i).Sub(p.requested(0))
+}
+
+// MethodParameter returns the string value of the named method parameter the data was captured with.
+func MethodParameter(md *v1.DataCaptureMetadata, name string) string {
+	param, ok := md.GetMethodParameters()[name]
+	if !ok {
+		return ""
+	}
+	value := new(wrapperspb.StringValue)
+	if err := param.UnmarshalTo(value); err != nil {
+		return ""
+	}
+	return value.Value
+}
+
+// AddReplayMetadata adds the capture timestamps of a replayed reading to the gRPC response header
+// if one is found in the context.
+func AddReplayMetadata(ctx context.Context, data *v1.SensorData) error {
+	if stream := grpc.ServerTransportStreamFromContext(ctx); stream != nil {
+		var grpcMetadata metadata.MD = make(map[string][]string)
+		if timeRequested := data.GetMetadata().GetTimeRequested(); timeRequested != nil {
+			grpcMetadata.Set(contextutils.TimeRequestedMetadataKey, timeRequested.AsTime().Format(time.RFC3339Nano))
+		}
+		if timeReceived := data.GetMetadata().GetTimeReceived(); timeReceived != nil {
+			grpcMetadata.Set(contextutils.TimeReceivedMetadataKey, timeReceived.AsTime().Format(time.RFC3339Nano))
+		}
+		if err := grpc.SetHeader(ctx, grpcMetadata); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,214 @@
+package datacapture
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	v1 "go.viam.com/api/app/datasync/v1"
+	"go.viam.com/test"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"go.viam.com/rdk/components/generic"
+	"go.viam.com/rdk/components/generic/replayclock"
+	"go.viam.com/rdk/resource"
+)
+
+var replayAPI = resource.APINamespaceRDK.WithComponentType("sensor")
+
+func writeReplayFile(t *testing.T, dir, componentName, method string, times []time.Time) {
+	t.Helper()
+	md, err := BuildCaptureMetadata(replayAPI, componentName, method, nil, nil)
+	test.That(t, err, test.ShouldBeNil)
+	readings := make([]*v1.SensorData, 0, len(times))
+	for _, ts := range times {
+		reading, err := structpb.NewStruct(map[string]interface{}{"time": float64(ts.UnixMilli())})
+		test.That(t, err, test.ShouldBeNil)
+		readings = append(readings, &v1.SensorData{
+			Metadata: &v1.SensorMetadata{TimeRequested: timestamppb.New(ts), TimeReceived: timestamppb.New(ts)},
+			Data:     &v1.SensorData_Struct{Struct: reading},
+		})
+	}
+	WriteTestFile(t, dir, componentName+"_"+method, md, readings...)
+}
+
+func replayTimes(t *testing.T, p *Player) []int64 {
+	t.Helper()
+	var times []int64
+	for {
+		data, _, err := p.Next(context.Background())
+		if err != nil {
+			test.That(t, err, test.ShouldBeError, ErrEndOfDataset)
+			return times
+		}
+		times = append(times, int64(data.GetStruct().GetFields()["time"].GetNumberValue()))
+	}
+}
+
+func TestReplayConfigValidate(t *testing.T) {
+	cfg := &ReplayConfig{}
+	_, err := cfg.Validate("path")
+	test.That(t, err.Error(), test.ShouldContainSubstring, "directory")
+
+	cfg.Directory = "dir"
+	_, err = cfg.Validate("path")
+	test.That(t, err.Error(), test.ShouldContainSubstring, "source")
+
+	cfg.Source = "sensor1"
+	cfg.Interval = ReplayInterval{Start: "yesterday"}
+	_, err = cfg.Validate("path")
+	test.That(t, err.Error(), test.ShouldContainSubstring, "invalid time format for start time")
+
+	cfg.Interval = ReplayInterval{Start: "2023-07-02T00:00:00Z", End: "2023-07-01T00:00:00Z"}
+	_, err = cfg.Validate("path")
+	test.That(t, err.Error(), test.ShouldContainSubstring, "end time (UTC) must be after start time (UTC)")
+
+	cfg.Interval = ReplayInterval{Start: "2023-07-01T00:00:00Z", End: "2023-07-02T00:00:00Z"}
+	deps, err := cfg.Validate("path")
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, deps, test.ShouldBeEmpty)
+
+	cfg.Clock = "clock"
+	deps, err = cfg.Validate("path")
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, deps, test.ShouldResemble, []string{"clock"})
+
+	cfg.Loop = true
+	_, err = cfg.Validate("path")
+	test.That(t, err.Error(), test.ShouldContainSubstring, "loop cannot be used with a clock")
+}
+
+func TestPlayer(t *testing.T) {
+	dir := t.TempDir()
+	nested := filepath.Join(dir, "nested")
+	test.That(t, os.Mkdir(nested, 0o700), test.ShouldBeNil)
+
+	base := time.Date(2023, 7, 1, 12, 0, 0, 0, time.UTC)
+	at := func(ms int) time.Time { return base.Add(time.Duration(ms) * time.Millisecond) }
+	ms := func(ms int) int64 { return at(ms).UnixMilli() }
+
+	// readings are spread over files, out of order
+	writeReplayFile(t, dir, "sensor1", "Readings", []time.Time{at(200), at(300)})
+	writeReplayFile(t, nested, "sensor1", "Readings", []time.Time{at(0), at(100)})
+	writeReplayFile(t, dir, "sensor2", "Readings", []time.Time{at(50)})
+	writeReplayFile(t, dir, "sensor1", "Other", []time.Time{at(50)})
+
+	t.Run("plays back matching readings in order", func(t *testing.T) {
+		p, err := NewPlayer(nil, &ReplayConfig{Directory: dir, Source: "sensor1"}, replayAPI, "Readings")
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, p.Len(), test.ShouldEqual, 4)
+		test.That(t, replayTimes(t, p), test.ShouldResemble, []int64{ms(0), ms(100), ms(200), ms(300)})
+	})
+
+	t.Run("time window", func(t *testing.T) {
+		cfg := &ReplayConfig{
+			Directory: dir,
+			Source:    "sensor1",
+			Interval:  ReplayInterval{Start: "2023-07-01T12:00:00Z", End: "2023-07-01T12:00:00Z"},
+		}
+		p, err := NewPlayer(nil, cfg, replayAPI, "Readings")
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, replayTimes(t, p), test.ShouldResemble, []int64{ms(0)})
+	})
+
+	t.Run("loop", func(t *testing.T) {
+		p, err := NewPlayer(nil, &ReplayConfig{Directory: dir, Source: "sensor2", Loop: true}, replayAPI, "Readings")
+		test.That(t, err, test.ShouldBeNil)
+		for i := 0; i < 3; i++ {
+			data, md, err := p.Next(context.Background())
+			test.That(t, err, test.ShouldBeNil)
+			test.That(t, md.GetComponentName(), test.ShouldEqual, "sensor2")
+			test.That(t, int64(data.GetStruct().GetFields()["time"].GetNumberValue()), test.ShouldEqual, ms(50))
+		}
+	})
+
+	t.Run("real time", func(t *testing.T) {
+		p, err := NewPlayer(nil, &ReplayConfig{Directory: dir, Source: "sensor1", RealTime: true}, replayAPI, "Readings")
+		test.That(t, err, test.ShouldBeNil)
+
+		start := time.Now()
+		_, _, err = p.Next(context.Background())
+		test.That(t, err, test.ShouldBeNil)
+		data, _, err := p.Next(context.Background())
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, time.Since(start), test.ShouldBeGreaterThanOrEqualTo, 100*time.Millisecond)
+		test.That(t, int64(data.GetStruct().GetFields()["time"].GetNumberValue()), test.ShouldEqual, ms(100))
+
+		// readings that were due while nobody asked for them are skipped
+		time.Sleep(250 * time.Millisecond)
+		data, _, err = p.Next(context.Background())
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, int64(data.GetStruct().GetFields()["time"].GetNumberValue()), test.ShouldEqual, ms(300))
+		_, _, err = p.Next(context.Background())
+		test.That(t, err, test.ShouldBeError, ErrEndOfDataset)
+	})
+
+	t.Run("clock", func(t *testing.T) {
+		clock, err := replayclock.NewClock(generic.Named("clock"), &replayclock.Config{Paused: true})
+		test.That(t, err, test.ShouldBeNil)
+		deps := resource.Dependencies{clock.Name(): clock}
+		sensor1, err := NewPlayer(deps, &ReplayConfig{Directory: dir, Source: "sensor1", Clock: "clock"}, replayAPI, "Readings")
+		test.That(t, err, test.ShouldBeNil)
+		sensor2, err := NewPlayer(deps, &ReplayConfig{Directory: dir, Source: "sensor2", Clock: "clock"}, replayAPI, "Readings")
+		test.That(t, err, test.ShouldBeNil)
+
+		// the clock starts at the earliest reading of all players
+		test.That(t, clock.Now(), test.ShouldEqual, at(0))
+		data, _, err := sensor1.Next(context.Background())
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, int64(data.GetStruct().GetFields()["time"].GetNumberValue()), test.ShouldEqual, ms(0))
+
+		seek := func(ms int) {
+			_, err := clock.DoCommand(context.Background(), map[string]interface{}{
+				replayclock.Command: replayclock.Seek,
+				replayclock.TimeVal: at(ms).Format(time.RFC3339Nano),
+			})
+			test.That(t, err, test.ShouldBeNil)
+		}
+
+		// readings that were due while nobody asked for them are skipped
+		seek(250)
+		data, _, err = sensor1.Next(context.Background())
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, int64(data.GetStruct().GetFields()["time"].GetNumberValue()), test.ShouldEqual, ms(200))
+		data, _, err = sensor2.Next(context.Background())
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, int64(data.GetStruct().GetFields()["time"].GetNumberValue()), test.ShouldEqual, ms(50))
+
+		// the next reading waits for the clock
+		done := make(chan int64)
+		go func() {
+			data, _, err := sensor1.Next(context.Background())
+			test.That(t, err, test.ShouldBeNil)
+			done <- int64(data.GetStruct().GetFields()["time"].GetNumberValue())
+		}()
+		select {
+		case <-done:
+			t.Fatal("reading returned before it was due")
+		case <-time.After(50 * time.Millisecond):
+		}
+		seek(300)
+		test.That(t, <-done, test.ShouldEqual, ms(300))
+
+		// moving the clock back replays earlier readings
+		seek(100)
+		data, _, err = sensor1.Next(context.Background())
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, int64(data.GetStruct().GetFields()["time"].GetNumberValue()), test.ShouldEqual, ms(100))
+
+		seek(400)
+		_, _, err = sensor2.Next(context.Background())
+		test.That(t, err, test.ShouldBeError, ErrEndOfDataset)
+
+		_, err = NewPlayer(deps, &ReplayConfig{Directory: dir, Source: "sensor1", Clock: "other"}, replayAPI, "Readings")
+		test.That(t, err, test.ShouldNotBeNil)
+	})
+
+	t.Run("missing directory", func(t *testing.T) {
+		_, err := NewPlayer(nil, &ReplayConfig{Directory: filepath.Join(dir, "missing"), Source: "sensor1"}, replayAPI, "Readings")
+		test.That(t, err, test.ShouldNotBeNil)
+	})
+}
@@ -0,0 +1,22 @@
+package datacapture
+
+import (
+	"path/filepath"
+	"testing"
+
+	v1 "go.viam.com/api/app/datasync/v1"
+	"go.viam.com/test"
+)
+
+// WriteTestFile writes a capture file holding the given readings to dir, for tests which need capture files, such as those of replay
+// components. The file is given the name passed, rather than one from the time it is written, so that files written in quick succession
+// cannot clash.
+func WriteTestFile(t *testing.T, dir, name string, md *v1.DataCaptureMetadata, readings ...*v1.SensorData) {
+	t.Helper()
+	f, err := newFile(filepath.Join(dir, name), md)
+	test.That(t, err, test.ShouldBeNil)
+	for _, reading := range readings {
+		test.That(t, f.WriteNext(reading), test.ShouldBeNil)
+	}
+	test.That(t, f.Close(), test.ShouldBeNil)
+}
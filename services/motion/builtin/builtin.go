@@ -4,15 +4,23 @@ package builtin
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"math"
+	"os"
+	"path/filepath"
 	"sync"
+	"time"
 
 	"github.com/edaniels/golog"
 	"github.com/golang/geo/r3"
 	geo "github.com/kellydunn/golang-geo"
 	"github.com/pkg/errors"
+	"go.uber.org/multierr"
+	commonpb "go.viam.com/api/common/v1"
 	servicepb "go.viam.com/api/service/motion/v1"
+	"go.viam.com/utils"
+	"google.golang.org/protobuf/encoding/protojson"
 
 	"go.viam.com/rdk/components/base"
 	"go.viam.com/rdk/components/base/fake"
@@ -20,6 +28,8 @@ import (
 	"go.viam.com/rdk/components/movementsensor"
 	"go.viam.com/rdk/internal"
 	"go.viam.com/rdk/motionplan"
+	"go.viam.com/rdk/motionplan/costmap"
+	"go.viam.com/rdk/motionplan/tpspace"
 	"go.viam.com/rdk/operation"
 	"go.viam.com/rdk/pointcloud"
 	"go.viam.com/rdk/referenceframe"
@@ -46,8 +56,33 @@ func init() {
 const (
 	builtinOpLabel    = "motion-service"
 	maxTravelDistance = 5e+6 // mm (or 5km)
+
+	// If this key is set to true in the extra parameters of Move, a motionplan.PlanRecord of the plan is written to a new file in the
+	// plan export directory of the service config, whether or not planning succeeds.
+	exportPlanKey = "export_plan"
+
+	// If this key is set to true in the extra parameters of Move, plans are reused from and added to the plan cache of the
+	// service, which holds the plans for repeated motions. See motionplan.PlanCache.
+	planCacheKey = "plan_cache"
+
+	// If this key is set in the extra parameters of Move, the components it names are moved along with the one given, each to its own
+	// destination, planned together so that they do not collide with each other. It maps component names to destinations in the JSON
+	// form of a PoseInFrame, like {"arm2": {"reference_frame": "world", "pose": {"x": 100, "y": 0, "z": 300, "o_z": 1}}}.
+	additionalGoalsKey = "additional_goals"
+
+	// If this key is set in the extra parameters of MoveOnMap, the base is moved over a costmap built from the SLAM map rather than by
+	// planning against the map as an octree. A path is planned over the costmap with A*, then followed with the PTGs of the base if it
+	// has them. It is set to true or to the JSON form of costmap.Options, like {"resolution_mm": 50, "max_height_mm": 1500}.
+	costmapKey = "costmap"
 )
 
+// keys in the extra parameters of Move which are used by the service rather than passed on to the motion planner.
+var builtinOnlyKeys = map[string]bool{
+	exportPlanKey:      true,
+	planCacheKey:       true,
+	additionalGoalsKey: true,
+}
+
 // inputEnabledActuator is an actuator that interacts with the frame system.
 // This allows us to figure out where the actuator currently is and then
 // move it. Input units are always in meters or radians.
@@ -59,8 +94,11 @@ type inputEnabledActuator interface {
 // ErrNotImplemented is thrown when an unreleased function is called.
 var ErrNotImplemented = errors.New("function coming soon but not yet implemented")
 
-// Config describes how to configure the service; currently only used for specifying dependency on framesystem service.
-type Config struct{}
+// Config describes how to configure the service.
+type Config struct {
+	// PlanExportDir is the directory which plans are exported to when a Move asks for them. Plans are not exported if it is unset.
+	PlanExportDir string `json:"plan_export_dir,omitempty"`
+}
 
 // Validate here adds a dependency on the internal framesystem service.
 func (c *Config) Validate(path string) ([]string, error) {
@@ -70,8 +108,9 @@ func (c *Config) Validate(path string) ([]string, error) {
 // NewBuiltIn returns a new move and grab service for the given robot.
 func NewBuiltIn(ctx context.Context, deps resource.Dependencies, conf resource.Config, logger golog.Logger) (motion.Service, error) {
 	ms := &builtIn{
-		Named:  conf.ResourceName().AsNamed(),
-		logger: logger,
+		Named:     conf.ResourceName().AsNamed(),
+		logger:    logger,
+		planCache: motionplan.NewPlanCache(),
 	}
 
 	if err := ms.Reconfigure(ctx, deps, conf); err != nil {
@@ -89,6 +128,16 @@ func (ms *builtIn) Reconfigure(
 	ms.lock.Lock()
 	defer ms.lock.Unlock()
 
+	// the default service has no attributes
+	ms.planExportDir = ""
+	if conf.ConvertedAttributes != nil {
+		svcConfig, err := resource.NativeConfig[*Config](conf)
+		if err != nil {
+			return err
+		}
+		ms.planExportDir = svcConfig.PlanExportDir
+	}
+
 	movementSensors := make(map[resource.Name]movementsensor.MovementSensor)
 	slamServices := make(map[resource.Name]slam.Service)
 	components := make(map[resource.Name]resource.Resource)
@@ -107,6 +156,8 @@ func (ms *builtIn) Reconfigure(
 	ms.movementSensors = movementSensors
 	ms.slamServices = slamServices
 	ms.components = components
+	// the frame system may have changed, so cached plans may no longer be valid
+	ms.planCache.Clear()
 	return nil
 }
 
@@ -117,6 +168,8 @@ type builtIn struct {
 	movementSensors map[resource.Name]movementsensor.MovementSensor
 	slamServices    map[resource.Name]slam.Service
 	components      map[resource.Name]resource.Resource
+	planCache       *motionplan.PlanCache
+	planExportDir   string
 	logger          golog.Logger
 	lock            sync.Mutex
 }
@@ -162,32 +215,221 @@ func (ms *builtIn) Move(
 	}
 	goalPose, _ := tf.(*referenceframe.PoseInFrame)
 
+	otherGoals, err := additionalGoals(extra)
+	if err != nil {
+		return false, err
+	}
+
+	planningOpts := make(map[string]interface{}, len(extra))
+	for key, value := range extra {
+		if !builtinOnlyKeys[key] {
+			planningOpts[key] = value
+		}
+	}
+
 	// the goal is to move the component to goalPose which is specified in coordinates of goalFrameName
-	steps, err := motionplan.PlanMotion(ctx, ms.logger, goalPose, movingFrame, fsInputs, frameSys, worldState, constraints, extra)
+	var steps []map[string][]referenceframe.Input
+	if len(otherGoals) > 0 {
+		steps, err = ms.planMultiMotion(
+			ctx, movingFrame.Name(), goalPose, otherGoals, fsInputs, frameSys, worldState, constraints, planningOpts, extra,
+		)
+		if err != nil {
+			return false, err
+		}
+	} else if useCache, ok := extra[planCacheKey].(bool); ok && useCache {
+		steps, err = ms.planCache.PlanMotion(ctx, ms.logger, goalPose, movingFrame, fsInputs, frameSys, worldState, constraints, planningOpts)
+	} else {
+		steps, err = motionplan.PlanMotion(ctx, ms.logger, goalPose, movingFrame, fsInputs, frameSys, worldState, constraints, planningOpts)
+	}
+	if export, ok := extra[exportPlanKey].(bool); ok && export {
+		planRecord, recordErr := ms.newPlanRecord(ctx, movingFrame.Name(), goalPose, fsInputs, worldState, constraints, planningOpts)
+		if recordErr == nil {
+			planRecord.SetResult(steps, err)
+			recordErr = ms.exportPlanRecord(planRecord)
+		}
+		if recordErr != nil {
+			ms.logger.Warnw("failed to export motion plan", "error", recordErr)
+		}
+	}
 	if err != nil {
 		return false, err
 	}
 
 	// move all the components
 	for _, step := range steps {
-		// TODO(erh): what order? parallel?
-		for name, inputs := range step {
-			if len(inputs) == 0 {
-				continue
+		if len(otherGoals) > 0 {
+			// components planned to reach their goals together move at the same time
+			err = goToStep(ctx, step, resources)
+		} else {
+			// TODO(erh): what order? parallel?
+			err = goToStepSequentially(ctx, step, resources)
+		}
+		if err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// goToStepSequentially moves the components in a step of a plan one after another.
+func goToStepSequentially(
+	ctx context.Context,
+	step map[string][]referenceframe.Input,
+	resources map[string]referenceframe.InputEnabled,
+) error {
+	for name, inputs := range step {
+		if len(inputs) == 0 {
+			continue
+		}
+		if err := goToInputs(ctx, resources[name], inputs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// goToStep moves every component in a step of a plan at the same time, and returns once they have all arrived, so that components
+// planned to move together stay in step with each other.
+func goToStep(ctx context.Context, step map[string][]referenceframe.Input, resources map[string]referenceframe.InputEnabled) error {
+	var wg sync.WaitGroup
+	var errLock sync.Mutex
+	var allErrs error
+	for name, inputs := range step {
+		if len(inputs) == 0 {
+			continue
+		}
+		r := resources[name]
+		inputs := inputs
+		wg.Add(1)
+		utils.PanicCapturingGo(func() {
+			defer wg.Done()
+			if err := goToInputs(ctx, r, inputs); err != nil {
+				errLock.Lock()
+				allErrs = multierr.Combine(allErrs, err)
+				errLock.Unlock()
 			}
-			r := resources[name]
-			if err := r.GoToInputs(ctx, inputs); err != nil {
-				// If there is an error on GoToInputs, stop the component if possible before returning the error
-				if actuator, ok := r.(inputEnabledActuator); ok {
-					if stopErr := actuator.Stop(ctx, nil); stopErr != nil {
-						return false, errors.Wrap(err, stopErr.Error())
-					}
-				}
-				return false, err
+		})
+	}
+	wg.Wait()
+	return allErrs
+}
+
+// goToInputs moves a component to the given inputs, stopping it if possible when it fails to get there.
+func goToInputs(ctx context.Context, r referenceframe.InputEnabled, inputs []referenceframe.Input) error {
+	if err := r.GoToInputs(ctx, inputs); err != nil {
+		// If there is an error on GoToInputs, stop the component if possible before returning the error
+		if actuator, ok := r.(inputEnabledActuator); ok {
+			if stopErr := actuator.Stop(ctx, nil); stopErr != nil {
+				return errors.Wrap(err, stopErr.Error())
 			}
 		}
+		return err
 	}
-	return true, nil
+	return nil
+}
+
+// planMultiMotion plans for the moving component to reach its goal along with the other components given in the extra parameters of
+// Move, in the world frame.
+func (ms *builtIn) planMultiMotion(
+	ctx context.Context,
+	frameName string,
+	goalPose *referenceframe.PoseInFrame,
+	otherGoals map[string]*referenceframe.PoseInFrame,
+	fsInputs map[string][]referenceframe.Input,
+	frameSys referenceframe.FrameSystem,
+	worldState *referenceframe.WorldState,
+	constraints *servicepb.Constraints,
+	planningOpts map[string]interface{},
+	extra map[string]interface{},
+) ([]map[string][]referenceframe.Input, error) {
+	for _, key := range []string{planCacheKey, exportPlanKey} {
+		if _, ok := extra[key]; ok {
+			return nil, fmt.Errorf("cannot use %s when moving several components", key)
+		}
+	}
+	goals := map[string]*referenceframe.PoseInFrame{frameName: goalPose}
+	for name, destination := range otherGoals {
+		if _, ok := goals[name]; ok {
+			return nil, fmt.Errorf("component named %s is given more than one destination", name)
+		}
+		if frameSys.Frame(name) == nil {
+			return nil, fmt.Errorf("component named %s not found in robot frame system", name)
+		}
+		tf, err := frameSys.Transform(fsInputs, destination, referenceframe.World)
+		if err != nil {
+			return nil, err
+		}
+		goals[name], _ = tf.(*referenceframe.PoseInFrame)
+	}
+	return motionplan.PlanMultiMotion(ctx, ms.logger, goals, fsInputs, frameSys, worldState, constraints, planningOpts)
+}
+
+// additionalGoals reads the destinations of the components to move along with the one given to Move from its extra parameters.
+func additionalGoals(extra map[string]interface{}) (map[string]*referenceframe.PoseInFrame, error) {
+	value, ok := extra[additionalGoalsKey]
+	if !ok {
+		return nil, nil
+	}
+	destinations, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("could not interpret %s field as a map of component names to destinations", additionalGoalsKey)
+	}
+	goals := make(map[string]*referenceframe.PoseInFrame, len(destinations))
+	for name, destination := range destinations {
+		destinationJSON, err := json.Marshal(destination)
+		if err != nil {
+			return nil, err
+		}
+		destinationPb := &commonpb.PoseInFrame{}
+		if err := protojson.Unmarshal(destinationJSON, destinationPb); err != nil {
+			return nil, errors.Wrapf(err, "could not interpret destination of %s", name)
+		}
+		goals[name] = referenceframe.ProtobufToPoseInFrame(destinationPb)
+	}
+	return goals, nil
+}
+
+// newPlanRecord records a request to plan the motion of a frame in the robot's frame system.
+func (ms *builtIn) newPlanRecord(
+	ctx context.Context,
+	frameName string,
+	goal *referenceframe.PoseInFrame,
+	fsInputs map[string][]referenceframe.Input,
+	worldState *referenceframe.WorldState,
+	constraints *servicepb.Constraints,
+	planningOpts map[string]interface{},
+) (*motionplan.PlanRecord, error) {
+	fsConfig, err := ms.fsService.FrameSystemConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return motionplan.NewPlanRecord(fsConfig.Parts, frameName, goal, fsInputs, worldState, constraints, planningOpts), nil
+}
+
+// exportPlanRecord writes a plan record to a new file in the plan export directory, named for the time it was written.
+func (ms *builtIn) exportPlanRecord(planRecord *motionplan.PlanRecord) error {
+	if ms.planExportDir == "" {
+		return errors.New("no plan_export_dir is configured for the motion service")
+	}
+	data, err := json.MarshalIndent(planRecord, "", "  ")
+	if err != nil {
+		return err
+	}
+	filename := filepath.Join(ms.planExportDir, "plan_"+time.Now().UTC().Format("20060102T150405.000000000Z")+".json")
+	// never overwrite an existing file
+	//nolint:gosec
+	f, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		return multierr.Combine(err, f.Close())
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	ms.logger.Infow("exported motion plan", "path", filename)
+	return nil
 }
 
 // MoveOnMap will move the given component to the given destination on the slam map generated from a slam service specified by slamName.
@@ -496,6 +738,16 @@ func (ms *builtIn) planMoveOnMap(
 	if err != nil {
 		return nil, nil, err
 	}
+
+	costmapOpts, err := costmapOptions(extra)
+	if err != nil {
+		return nil, nil, err
+	}
+	if costmapOpts != nil {
+		plan, err := ms.planMoveOnCostmap(ctx, kb, motion.NewSLAMLocalizer(slamSvc), pointCloudData, destination, costmapOpts)
+		return plan, kb, err
+	}
+
 	// store slam point cloud data  in the form of a recursive octree for collision checking
 	octree, err := pointcloud.ReadPCDToBasicOctree(bytes.NewReader(pointCloudData))
 	if err != nil {
@@ -537,3 +789,106 @@ func (ms *builtIn) planMoveOnMap(
 	plan, err := motionplan.FrameStepsFromRobotPath(f.Name(), solutionMap)
 	return plan, kb, err
 }
+
+// planMoveOnCostmap plans the motion of a base to a destination over a costmap built from a SLAM map. A path is planned over the costmap,
+// then followed with the PTGs of the base if it has them. Otherwise the base drives straight between the waypoints of the path, each
+// of which becomes a step of the plan.
+func (ms *builtIn) planMoveOnCostmap(
+	ctx context.Context,
+	kb kinematicbase.KinematicBase,
+	localizer motion.Localizer,
+	pointCloudData []byte,
+	destination spatialmath.Pose,
+	opts *costmap.Options,
+) ([][]referenceframe.Input, error) {
+	pc, err := pointcloud.ReadPCD(bytes.NewReader(pointCloudData))
+	if err != nil {
+		return nil, err
+	}
+	if opts.RobotRadiusMM <= 0 {
+		geometries, err := kb.Geometries(ctx, nil)
+		if err != nil {
+			return nil, err
+		}
+		opts.RobotRadiusMM = footprintRadius(geometries)
+	}
+	cm, err := costmap.NewFromPointCloud(pc, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	position, err := localizer.CurrentPosition(ctx)
+	if err != nil {
+		return nil, err
+	}
+	path, err := cm.PlanPath(ctx, position.Pose().Point(), destination.Point())
+	if err != nil {
+		return nil, err
+	}
+	ms.logger.Debugf("costmap path: %v", path)
+
+	inputs, err := kb.CurrentInputs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	dof := len(kb.Kinematics().DoF())
+	if len(inputs) > dof {
+		// a base planned for in position-only mode has a heading which is not part of the plan
+		inputs = inputs[:dof]
+	}
+	plan := [][]referenceframe.Input{inputs}
+	if ptgProv, ok := kb.Kinematics().(tpspace.PTGProvider); ok {
+		steps, err := cm.FollowPath(ctx, ptgProv.PTGs(), position.Pose(), path)
+		if err != nil {
+			return nil, err
+		}
+		return append(plan, steps...), nil
+	}
+	for i := 1; i < len(path); i++ {
+		step := []referenceframe.Input{{Value: path[i].X}, {Value: path[i].Y}}
+		if dof == 3 {
+			step = append(step, referenceframe.Input{Value: math.Atan2(path[i].Y-path[i-1].Y, path[i].X-path[i-1].X)})
+		}
+		plan = append(plan, step)
+	}
+	return plan, nil
+}
+
+// costmapOptions returns the options for planning over a costmap given in the extra parameters of MoveOnMap, or nil if a costmap is not
+// to be used.
+func costmapOptions(extra map[string]interface{}) (*costmap.Options, error) {
+	value, ok := extra[costmapKey]
+	if !ok {
+		return nil, nil
+	}
+	switch v := value.(type) {
+	case bool:
+		if !v {
+			return nil, nil
+		}
+		return costmap.NewOptions(), nil
+	case map[string]interface{}:
+		optsJSON, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		opts := costmap.NewOptions()
+		if err := json.Unmarshal(optsJSON, opts); err != nil {
+			return nil, errors.Wrapf(err, "could not interpret %s field", costmapKey)
+		}
+		return opts, nil
+	default:
+		return nil, fmt.Errorf("could not interpret %s field as a bool or costmap options", costmapKey)
+	}
+}
+
+// footprintRadius returns the radius of the smallest circle about the origin of a base which encloses its geometries in the XY plane.
+func footprintRadius(geometries []spatialmath.Geometry) float64 {
+	radius := 0.
+	for _, geometry := range geometries {
+		for _, pt := range geometry.ToPoints(0) {
+			radius = math.Max(radius, math.Hypot(pt.X, pt.Y))
+		}
+	}
+	return radius
+}
@@ -2,6 +2,7 @@ package builtin
 
 import (
 	"context"
+	"encoding/json"
 	"math"
 	"os"
 	"path/filepath"
@@ -16,6 +17,7 @@ import (
 	"go.viam.com/test"
 	"go.viam.com/utils"
 	"go.viam.com/utils/artifact"
+	"google.golang.org/protobuf/encoding/protojson"
 
 	"go.viam.com/rdk/components/arm"
 	armFake "go.viam.com/rdk/components/arm/fake"
@@ -28,6 +30,8 @@ import (
 	"go.viam.com/rdk/components/movementsensor"
 	_ "go.viam.com/rdk/components/register"
 	"go.viam.com/rdk/config"
+	"go.viam.com/rdk/motionplan"
+	"go.viam.com/rdk/pointcloud"
 	"go.viam.com/rdk/referenceframe"
 	"go.viam.com/rdk/resource"
 	"go.viam.com/rdk/robot/framesystem"
@@ -180,8 +184,10 @@ func createMoveOnGlobeEnvironment(ctx context.Context, t *testing.T, gpsPoint *g
 }
 
 func createMoveOnMapEnvironment(ctx context.Context, t *testing.T, pcdPath string) motion.Service {
-	injectSlam := createInjectedSlam("test_slam", pcdPath)
+	return createMoveOnMapEnvironmentWithSlam(ctx, t, createInjectedSlam("test_slam", pcdPath))
+}
 
+func createMoveOnMapEnvironmentWithSlam(ctx context.Context, t *testing.T, injectSlam *inject.SLAMService) motion.Service {
 	cfg := resource.Config{
 		Name:  "test_base",
 		API:   base.API,
@@ -274,6 +280,44 @@ func TestMove(t *testing.T) {
 	})
 }
 
+func TestMoveMultipleArms(t *testing.T) {
+	ctx := context.Background()
+	ms, teardown := setupMotionServiceFromConfig(t, "../data/dual_arm.json")
+	defer teardown()
+
+	goals := map[resource.Name]*referenceframe.PoseInFrame{}
+	for _, name := range []resource.Name{arm.Named("armLeft"), arm.Named("armRight")} {
+		start, err := ms.GetPose(ctx, name, referenceframe.World, nil, nil)
+		test.That(t, err, test.ShouldBeNil)
+		// the arms start stretched out, so they are moved in towards their bases
+		goalPose := spatialmath.NewPose(start.Pose().Point().Add(r3.Vector{X: 100}), start.Pose().Orientation())
+		goals[name] = referenceframe.NewPoseInFrame(referenceframe.World, goalPose)
+	}
+
+	// destinations of other components are given as they would be after being sent over the network
+	rightGoalJSON, err := protojson.Marshal(referenceframe.PoseInFrameToProtobuf(goals[arm.Named("armRight")]))
+	test.That(t, err, test.ShouldBeNil)
+	var rightGoal map[string]interface{}
+	test.That(t, json.Unmarshal(rightGoalJSON, &rightGoal), test.ShouldBeNil)
+
+	extra := map[string]interface{}{additionalGoalsKey: map[string]interface{}{"armRight": rightGoal}}
+	_, err = ms.Move(ctx, arm.Named("armLeft"), goals[arm.Named("armLeft")], nil, nil, extra)
+	test.That(t, err, test.ShouldBeNil)
+	for name, goal := range goals {
+		pose, err := ms.GetPose(ctx, name, referenceframe.World, nil, nil)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, spatialmath.PoseAlmostCoincidentEps(pose.Pose(), goal.Pose(), 1), test.ShouldBeTrue)
+	}
+
+	extra = map[string]interface{}{additionalGoalsKey: map[string]interface{}{"armMissing": rightGoal}}
+	_, err = ms.Move(ctx, arm.Named("armLeft"), goals[arm.Named("armLeft")], nil, nil, extra)
+	test.That(t, err, test.ShouldNotBeNil)
+
+	extra = map[string]interface{}{additionalGoalsKey: "armRight"}
+	_, err = ms.Move(ctx, arm.Named("armLeft"), goals[arm.Named("armLeft")], nil, nil, extra)
+	test.That(t, err, test.ShouldNotBeNil)
+}
+
 func TestMoveWithObstacles(t *testing.T) {
 	ms, teardown := setupMotionServiceFromConfig(t, "../data/moving_arm.json")
 	defer teardown()
@@ -457,6 +501,55 @@ func TestMoveOnMap(t *testing.T) {
 	})
 }
 
+func TestMoveOnMapCostmap(t *testing.T) {
+	ctx := context.Background()
+	// a 4m square map with a wall across most of it between the base, which starts at the origin, and the goal
+	pc := pointcloud.New()
+	for _, corner := range []r3.Vector{{X: -2000, Y: -2000}, {X: 2000, Y: 2000}} {
+		test.That(t, pc.Set(corner, pointcloud.NewValueData(0)), test.ShouldBeNil)
+	}
+	for y := -2000.; y <= 500; y += 25 {
+		test.That(t, pc.Set(r3.Vector{X: 1000, Y: y}, pointcloud.NewValueData(100)), test.ShouldBeNil)
+	}
+	pcdPath := filepath.Join(t.TempDir(), "wall.pcd")
+	f, err := os.Create(pcdPath)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, pointcloud.ToPCD(pc, f, pointcloud.PCDBinary), test.ShouldBeNil)
+	test.That(t, f.Close(), test.ShouldBeNil)
+
+	injectSlam := inject.NewSLAMService("test_slam")
+	injectSlam.GetPointCloudMapFunc = func(ctx context.Context) (func() ([]byte, error), error) {
+		return getPointCloudMap(pcdPath)
+	}
+	injectSlam.GetPositionFunc = func(ctx context.Context) (spatialmath.Pose, string, error) {
+		return spatialmath.NewZeroPose(), "", nil
+	}
+	ms := createMoveOnMapEnvironmentWithSlam(ctx, t, injectSlam)
+	goal := spatialmath.NewPoseFromPoint(r3.Vector{X: 1800})
+	extra := map[string]interface{}{"costmap": map[string]interface{}{"resolution_mm": 50, "cost_weight": 2}}
+
+	plan, _, err := ms.(*builtIn).planMoveOnMap(ctx, base.Named("test_base"), goal, slam.Named("test_slam"),
+		kinematicbase.NewKinematicBaseOptions(), extra)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, len(plan), test.ShouldBeGreaterThan, 2)
+	// the base goes around the end of the wall, with each step an [x, y] waypoint
+	highest := math.Inf(-1)
+	for _, step := range plan {
+		test.That(t, step, test.ShouldHaveLength, 2)
+		highest = math.Max(highest, step[1].Value)
+	}
+	test.That(t, highest, test.ShouldBeGreaterThan, 600)
+	test.That(t, plan[len(plan)-1][0].Value, test.ShouldAlmostEqual, 1800)
+
+	success, err := ms.MoveOnMap(ctx, base.Named("test_base"), goal, slam.Named("test_slam"), extra)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, success, test.ShouldBeTrue)
+
+	extra["costmap"] = "yes"
+	_, err = ms.MoveOnMap(ctx, base.Named("test_base"), goal, slam.Named("test_slam"), extra)
+	test.That(t, err, test.ShouldNotBeNil)
+}
+
 func TestMoveOnMapTimeout(t *testing.T) {
 	ctx := context.Background()
 	logger := golog.NewTestLogger(t)
@@ -850,3 +943,23 @@ func TestStoppableMoveFunctions(t *testing.T) {
 		})
 	})
 }
+
+func TestExportPlanRecord(t *testing.T) {
+	goal := referenceframe.NewPoseInFrame(referenceframe.World, spatialmath.NewPoseFromPoint(r3.Vector{X: 100}))
+	planRecord := motionplan.NewPlanRecord(nil, "arm", goal, map[string][]referenceframe.Input{}, nil, nil, nil)
+	ms := &builtIn{logger: golog.NewTestLogger(t)}
+	test.That(t, ms.exportPlanRecord(planRecord), test.ShouldNotBeNil)
+
+	ms.planExportDir = t.TempDir()
+	test.That(t, ms.exportPlanRecord(planRecord), test.ShouldBeNil)
+	test.That(t, ms.exportPlanRecord(planRecord), test.ShouldBeNil)
+	files, err := os.ReadDir(ms.planExportDir)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, len(files), test.ShouldEqual, 2)
+	for _, file := range files {
+		test.That(t, file.Name(), test.ShouldNotContainSubstring, ":")
+		read, err := motionplan.ReadPlanRecordJSONFile(filepath.Join(ms.planExportDir, file.Name()))
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, read.Frame, test.ShouldEqual, "arm")
+	}
+}
@@ -3,6 +3,7 @@ package register
 
 import (
 	// register services.
+	_ "go.viam.com/rdk/services/armremotecontrol/register"
 	_ "go.viam.com/rdk/services/baseremotecontrol/register"
 	_ "go.viam.com/rdk/services/datamanager/register"
 	_ "go.viam.com/rdk/services/mlmodel/register"
@@ -11,5 +12,6 @@ import (
 	_ "go.viam.com/rdk/services/sensors/register"
 	_ "go.viam.com/rdk/services/shell/register"
 	_ "go.viam.com/rdk/services/slam/register"
+	_ "go.viam.com/rdk/services/videorecorder/register"
 	_ "go.viam.com/rdk/services/vision/register"
 )
@@ -0,0 +1,299 @@
+// Package builtin implements a video recorder that writes camera streams to segmented video files.
+package builtin
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/edaniels/golog"
+	"github.com/pkg/errors"
+	"go.uber.org/multierr"
+	goutils "go.viam.com/utils"
+
+	"go.viam.com/rdk/components/camera"
+	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/services/videorecorder"
+	"go.viam.com/rdk/utils"
+)
+
+// Containers that segments can be written in.
+const (
+	containerMKV = "mkv"
+	containerMP4 = "mp4"
+)
+
+// Muxers that can write segments.
+const (
+	muxerAuto    = ""
+	muxerBuiltin = "builtin"
+	muxerFFMPEG  = "ffmpeg"
+)
+
+const (
+	defaultFrameRate       = 10.
+	defaultSegmentDuration = time.Minute
+)
+
+var (
+	// viamCaptureDotDir is the default directory of the data manager, which datasync uploads
+	// arbitrary files from.
+	viamCaptureDotDir = filepath.Join(os.Getenv("HOME"), ".viam", "capture")
+	viamStagingDotDir = filepath.Join(os.Getenv("HOME"), ".viam", "video_recorder")
+
+	errDirectoryConfigurationDisabled = errors.New("changing the recording directories is prohibited in this environment")
+)
+
+func init() {
+	resource.RegisterService(videorecorder.API, resource.DefaultServiceModel, resource.Registration[videorecorder.Service, *Config]{
+		Constructor: NewBuiltIn,
+	})
+}
+
+// Config describes how to configure the service. Segments are written to the staging directory
+// and moved to the capture directory once finished, so the two should be on the same file system.
+type Config struct {
+	Cameras            []string `json:"cameras"`
+	CaptureDir         string   `json:"capture_dir,omitempty"`
+	StagingDir         string   `json:"staging_dir,omitempty"`
+	Container          string   `json:"container,omitempty"`
+	Muxer              string   `json:"muxer,omitempty"`
+	FrameRate          float64  `json:"frame_rate,omitempty"`
+	PreRollSec         float64  `json:"pre_roll_sec,omitempty"`
+	SegmentDurationSec float64  `json:"segment_duration_sec,omitempty"`
+	SegmentMaxBytes    int64    `json:"segment_max_bytes,omitempty"`
+	RecordOnStart      bool     `json:"record_on_start,omitempty"`
+}
+
+// Validate creates the list of implicit dependencies.
+func (conf *Config) Validate(path string) ([]string, error) {
+	if len(conf.Cameras) == 0 {
+		return nil, goutils.NewConfigValidationFieldRequiredError(path, "cameras")
+	}
+	for _, cam := range conf.Cameras {
+		if cam == "" {
+			return nil, goutils.NewConfigValidationError(path, errors.New("camera names cannot be empty"))
+		}
+	}
+	switch conf.Container {
+	case "", containerMKV:
+		if conf.Muxer != muxerAuto && conf.Muxer != muxerBuiltin && conf.Muxer != muxerFFMPEG {
+			return nil, goutils.NewConfigValidationError(path,
+				errors.Errorf("muxer must be %q or %q", muxerBuiltin, muxerFFMPEG))
+		}
+	case containerMP4:
+		if conf.Muxer != muxerAuto && conf.Muxer != muxerFFMPEG {
+			return nil, goutils.NewConfigValidationError(path, errors.Errorf("only the %q muxer writes %s", muxerFFMPEG, containerMP4))
+		}
+	default:
+		return nil, goutils.NewConfigValidationError(path,
+			errors.Errorf("container must be %q or %q", containerMKV, containerMP4))
+	}
+	if conf.FrameRate < 0 {
+		return nil, goutils.NewConfigValidationError(path, errors.New("frame_rate cannot be negative"))
+	}
+	if conf.PreRollSec < 0 {
+		return nil, goutils.NewConfigValidationError(path, errors.New("pre_roll_sec cannot be negative"))
+	}
+	if conf.SegmentDurationSec < 0 {
+		return nil, goutils.NewConfigValidationError(path, errors.New("segment_duration_sec cannot be negative"))
+	}
+	if conf.SegmentMaxBytes < 0 {
+		return nil, goutils.NewConfigValidationError(path, errors.New("segment_max_bytes cannot be negative"))
+	}
+	return conf.Cameras, nil
+}
+
+// builtIn records cameras with one recorder each.
+type builtIn struct {
+	resource.Named
+	resource.AlwaysRebuild
+
+	recorders []*cameraRecorder
+	logger    golog.Logger
+
+	mu          sync.Mutex
+	segments    int
+	lastSegment string
+
+	cancel                  func()
+	activeBackgroundWorkers sync.WaitGroup
+}
+
+// NewBuiltIn returns a new video recorder service.
+func NewBuiltIn(
+	ctx context.Context,
+	deps resource.Dependencies,
+	conf resource.Config,
+	logger golog.Logger,
+) (videorecorder.Service, error) {
+	svcConfig, err := resource.NativeConfig[*Config](conf)
+	if err != nil {
+		return nil, err
+	}
+
+	captureDir := filepath.Join(viamCaptureDotDir, videorecorder.SubtypeName, conf.Name)
+	stagingDir := filepath.Join(viamStagingDotDir, conf.Name)
+	if svcConfig.CaptureDir != "" || svcConfig.StagingDir != "" {
+		if !utils.IsTrustedEnvironment(ctx) {
+			return nil, errDirectoryConfigurationDisabled
+		}
+		if svcConfig.CaptureDir != "" {
+			captureDir = svcConfig.CaptureDir
+		}
+		if svcConfig.StagingDir != "" {
+			stagingDir = svcConfig.StagingDir
+		}
+	}
+
+	container := svcConfig.Container
+	if container == "" {
+		container = containerMKV
+	}
+	frameRate := svcConfig.FrameRate
+	if frameRate == 0 {
+		frameRate = defaultFrameRate
+	}
+	newWriter, err := segmentWriterFor(svcConfig.Muxer, container, frameRate)
+	if err != nil {
+		return nil, err
+	}
+	opts := &segmentOptions{
+		stagingDir:  stagingDir,
+		captureDir:  captureDir,
+		extension:   "." + container,
+		maxDuration: defaultSegmentDuration,
+		maxBytes:    svcConfig.SegmentMaxBytes,
+		preRoll:     time.Duration(svcConfig.PreRollSec * float64(time.Second)),
+		newWriter:   newWriter,
+	}
+	if svcConfig.SegmentDurationSec > 0 {
+		opts.maxDuration = time.Duration(svcConfig.SegmentDurationSec * float64(time.Second))
+	}
+
+	cams := make([]camera.Camera, 0, len(svcConfig.Cameras))
+	for _, name := range svcConfig.Cameras {
+		cam, err := camera.FromDependencies(deps, name)
+		if err != nil {
+			return nil, err
+		}
+		cams = append(cams, cam)
+	}
+
+	cancelCtx, cancel := context.WithCancel(context.Background())
+	svc := &builtIn{
+		Named:  conf.ResourceName().AsNamed(),
+		logger: logger,
+		cancel: cancel,
+	}
+	period := time.Duration(float64(time.Second) / frameRate)
+	for i, cam := range cams {
+		rec := newCameraRecorder(svcConfig.Cameras[i], opts, svc.segmentFinished)
+		if svcConfig.RecordOnStart {
+			goutils.UncheckedError(rec.start())
+		}
+		svc.recorders = append(svc.recorders, rec)
+		svc.activeBackgroundWorkers.Add(1)
+		goutils.ManagedGo(func() {
+			rec.run(cancelCtx, cam, period, logger)
+		}, svc.activeBackgroundWorkers.Done)
+	}
+	return svc, nil
+}
+
+// segmentWriterFor returns how to create the writers of segments for a muxer and container. Unless
+// a muxer is picked, ffmpeg is used when it is installed since its H.264 is much smaller than the
+// Motion JPEG of the builtin muxer.
+func segmentWriterFor(muxer, container string, frameRate float64) (func(string, time.Time) (segmentWriter, error), error) {
+	if muxer == muxerAuto {
+		muxer = muxerBuiltin
+		if _, err := exec.LookPath("ffmpeg"); err == nil {
+			muxer = muxerFFMPEG
+		}
+	}
+	if muxer == muxerBuiltin && container == containerMKV {
+		return func(path string, started time.Time) (segmentWriter, error) {
+			return newMKVWriter(path, started)
+		}, nil
+	}
+	if _, err := exec.LookPath("ffmpeg"); err != nil {
+		return nil, errors.Wrapf(err, "ffmpeg is needed to write %s video", container)
+	}
+	return func(path string, started time.Time) (segmentWriter, error) {
+		return newFFMPEGWriter(path, container, frameRate)
+	}, nil
+}
+
+func (svc *builtIn) segmentFinished(path string) {
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+	svc.segments++
+	svc.lastSegment = path
+}
+
+// Start starts recording every camera.
+func (svc *builtIn) Start(ctx context.Context, extra map[string]interface{}) error {
+	var err error
+	for _, rec := range svc.recorders {
+		err = multierr.Combine(err, rec.start())
+	}
+	return err
+}
+
+// Stop stops recording every camera and finishes their segments.
+func (svc *builtIn) Stop(ctx context.Context, extra map[string]interface{}) error {
+	var err error
+	for _, rec := range svc.recorders {
+		err = multierr.Combine(err, rec.stop())
+	}
+	return err
+}
+
+// Status returns whether any camera is being recorded and the segments finished so far.
+func (svc *builtIn) Status(ctx context.Context, extra map[string]interface{}) (videorecorder.Status, error) {
+	var status videorecorder.Status
+	for _, rec := range svc.recorders {
+		status.Recording = status.Recording || rec.isRecording()
+	}
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+	status.Segments = svc.segments
+	status.LastSegment = svc.lastSegment
+	return status, nil
+}
+
+// DoCommand starts, stops or reports on recording for clients without a typed API.
+func (svc *builtIn) DoCommand(ctx context.Context, cmd map[string]interface{}) (map[string]interface{}, error) {
+	name, ok := cmd["command"]
+	if !ok {
+		return nil, errors.New("missing 'command' value")
+	}
+	switch name {
+	case videorecorder.CommandStart:
+		return nil, svc.Start(ctx, nil)
+	case videorecorder.CommandStop:
+		return nil, svc.Stop(ctx, nil)
+	case videorecorder.CommandStatus:
+		status, err := svc.Status(ctx, nil)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{
+			"recording":    status.Recording,
+			"segments":     status.Segments,
+			"last_segment": status.LastSegment,
+		}, nil
+	default:
+		return nil, errors.Errorf("no such command: %s", name)
+	}
+}
+
+// Close stops reading the cameras and finishes any segments being recorded.
+func (svc *builtIn) Close(ctx context.Context) error {
+	svc.cancel()
+	svc.activeBackgroundWorkers.Wait()
+	return svc.Stop(ctx, nil)
+}
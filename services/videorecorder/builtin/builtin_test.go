@@ -0,0 +1,238 @@
+package builtin
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"image"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/edaniels/golog"
+	"github.com/viamrobotics/gostream"
+	"go.viam.com/test"
+	"go.viam.com/utils"
+	"go.viam.com/utils/testutils"
+
+	"go.viam.com/rdk/components/camera"
+	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/services/videorecorder"
+	"go.viam.com/rdk/testutils/inject"
+)
+
+func TestValidate(t *testing.T) {
+	cfg := &Config{}
+	_, err := cfg.Validate("path")
+	test.That(t, err, test.ShouldBeError, utils.NewConfigValidationFieldRequiredError("path", "cameras"))
+
+	cfg = &Config{Cameras: []string{"cam1", "cam2"}}
+	deps, err := cfg.Validate("path")
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, deps, test.ShouldResemble, []string{"cam1", "cam2"})
+
+	cfg = &Config{Cameras: []string{"cam"}, Container: "avi"}
+	_, err = cfg.Validate("path")
+	test.That(t, err, test.ShouldNotBeNil)
+
+	cfg = &Config{Cameras: []string{"cam"}, Container: containerMP4, Muxer: muxerBuiltin}
+	_, err = cfg.Validate("path")
+	test.That(t, err, test.ShouldNotBeNil)
+
+	cfg = &Config{Cameras: []string{"cam"}, PreRollSec: -1}
+	_, err = cfg.Validate("path")
+	test.That(t, err, test.ShouldNotBeNil)
+}
+
+type fakeFrame struct {
+	data      []byte
+	timestamp time.Duration
+}
+
+type fakeSegmentWriter struct {
+	path   string
+	frames []fakeFrame
+	size   int64
+}
+
+func (w *fakeSegmentWriter) WriteFrame(frame []byte, width, height int, timestamp time.Duration) error {
+	w.frames = append(w.frames, fakeFrame{frame, timestamp})
+	w.size += int64(len(frame))
+	return nil
+}
+
+func (w *fakeSegmentWriter) Size() int64 {
+	return w.size
+}
+
+func (w *fakeSegmentWriter) Close() error {
+	return os.WriteFile(w.path, nil, 0o600)
+}
+
+func TestCameraRecorder(t *testing.T) {
+	dir := t.TempDir()
+	var writers []*fakeSegmentWriter
+	var finished []string
+	opts := &segmentOptions{
+		stagingDir:  filepath.Join(dir, "staging"),
+		captureDir:  filepath.Join(dir, "capture"),
+		extension:   ".mkv",
+		maxDuration: 10 * time.Second,
+		maxBytes:    10,
+		preRoll:     2 * time.Second,
+		newWriter: func(path string, started time.Time) (segmentWriter, error) {
+			w := &fakeSegmentWriter{path: path}
+			writers = append(writers, w)
+			return w, nil
+		},
+	}
+	rec := newCameraRecorder("cam", opts, func(path string) {
+		finished = append(finished, path)
+	})
+
+	start := time.Now()
+	frameAt := func(sec int, data string) frame {
+		return frame{data: []byte(data), width: 2, height: 2, taken: start.Add(time.Duration(sec) * time.Second)}
+	}
+
+	t.Run("pre-roll frames start the first segment", func(t *testing.T) {
+		for i := 0; i < 5; i++ {
+			test.That(t, rec.addFrame(frameAt(i, "a")), test.ShouldBeNil)
+		}
+		test.That(t, writers, test.ShouldBeEmpty)
+		test.That(t, rec.start(), test.ShouldBeNil)
+		test.That(t, rec.isRecording(), test.ShouldBeTrue)
+		test.That(t, writers, test.ShouldHaveLength, 1)
+		firstTaken := frameAt(2, "").taken.UTC()
+		test.That(t, filepath.Base(writers[0].path), test.ShouldEqual, "cam_"+firstTaken.Format("20060102T150405.000000000Z")+".mkv")
+		test.That(t, writers[0].path, test.ShouldNotContainSubstring, ":")
+		test.That(t, writers[0].frames, test.ShouldResemble, []fakeFrame{
+			{[]byte("a"), 0},
+			{[]byte("a"), time.Second},
+			{[]byte("a"), 2 * time.Second},
+		})
+	})
+
+	t.Run("segments rotate by duration", func(t *testing.T) {
+		test.That(t, rec.addFrame(frameAt(12, "b")), test.ShouldBeNil)
+		test.That(t, rec.addFrame(frameAt(13, "b")), test.ShouldBeNil)
+		test.That(t, writers, test.ShouldHaveLength, 2)
+		test.That(t, writers[1].frames, test.ShouldResemble, []fakeFrame{{[]byte("b"), 0}, {[]byte("b"), time.Second}})
+		test.That(t, finished, test.ShouldHaveLength, 1)
+		test.That(t, filepath.Dir(finished[0]), test.ShouldEqual, filepath.Join(opts.captureDir, "cam"))
+		_, err := os.Stat(finished[0])
+		test.That(t, err, test.ShouldBeNil)
+		_, err = os.Stat(writers[0].path)
+		test.That(t, os.IsNotExist(err), test.ShouldBeTrue)
+	})
+
+	t.Run("segments rotate by size and frame size", func(t *testing.T) {
+		test.That(t, rec.addFrame(frameAt(14, "ccccccccc")), test.ShouldBeNil)
+		test.That(t, writers, test.ShouldHaveLength, 3)
+		f := frameAt(15, "d")
+		f.width = 4
+		test.That(t, rec.addFrame(f), test.ShouldBeNil)
+		test.That(t, writers, test.ShouldHaveLength, 4)
+		test.That(t, finished, test.ShouldHaveLength, 3)
+	})
+
+	t.Run("stop finishes the segment and frames are buffered again", func(t *testing.T) {
+		test.That(t, rec.stop(), test.ShouldBeNil)
+		test.That(t, rec.isRecording(), test.ShouldBeFalse)
+		test.That(t, finished, test.ShouldHaveLength, 4)
+		test.That(t, rec.addFrame(frameAt(16, "e")), test.ShouldBeNil)
+		test.That(t, writers, test.ShouldHaveLength, 4)
+		test.That(t, rec.stop(), test.ShouldBeNil)
+		test.That(t, finished, test.ShouldHaveLength, 4)
+	})
+}
+
+func TestMKVWriter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.mkv")
+	w, err := newMKVWriter(path, time.Now())
+	test.That(t, err, test.ShouldBeNil)
+	frames := [][]byte{[]byte("frame one"), []byte("frame two"), []byte("frame three")}
+	for i, f := range frames {
+		test.That(t, w.WriteFrame(f, 4, 2, time.Duration(i)*700*time.Millisecond), test.ShouldBeNil)
+	}
+	test.That(t, w.WriteFrame(frames[0], 2, 2, 3*time.Second), test.ShouldNotBeNil)
+	size := w.Size()
+	test.That(t, w.Close(), test.ShouldBeNil)
+
+	//nolint:gosec
+	data, err := os.ReadFile(path)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, int64(len(data)), test.ShouldBeGreaterThanOrEqualTo, size)
+	test.That(t, data[:4], test.ShouldResemble, []byte{0x1A, 0x45, 0xDF, 0xA3})
+	test.That(t, bytes.Contains(data, []byte(mkvCodecMJPEG)), test.ShouldBeTrue)
+	for _, f := range frames {
+		test.That(t, bytes.Contains(data, f), test.ShouldBeTrue)
+	}
+	// frames more than a cluster apart are split into clusters
+	test.That(t, bytes.Count(data, []byte{0x1F, 0x43, 0xB6, 0x75}), test.ShouldEqual, 2)
+
+	// the segment size is patched in as an eight byte vint
+	segment := bytes.Index(data, []byte{0x18, 0x53, 0x80, 0x67})
+	test.That(t, segment, test.ShouldBeGreaterThan, 0)
+	sizeStart := segment + 4
+	test.That(t, data[sizeStart], test.ShouldEqual, 0x01)
+	segmentSize := binary.BigEndian.Uint64(append([]byte{0}, data[sizeStart+1:sizeStart+8]...))
+	test.That(t, segmentSize, test.ShouldEqual, uint64(len(data)-sizeStart-8))
+
+	test.That(t, encodeVint(1, 0), test.ShouldResemble, []byte{0x81})
+	test.That(t, encodeVint(127, 0), test.ShouldResemble, []byte{0x40, 0x7f})
+	test.That(t, encodeVint(500, 0), test.ShouldResemble, []byte{0x41, 0xf4})
+}
+
+func TestBuiltIn(t *testing.T) {
+	logger := golog.NewTestLogger(t)
+	dir := t.TempDir()
+	cam := inject.NewCamera("cam")
+	cam.StreamFunc = func(ctx context.Context, errHandlers ...gostream.ErrorHandler) (gostream.VideoStream, error) {
+		return gostream.NewEmbeddedVideoStreamFromReader(gostream.VideoReaderFunc(func(ctx context.Context) (image.Image, func(), error) {
+			return image.NewNRGBA(image.Rect(0, 0, 8, 4)), func() {}, nil
+		})), nil
+	}
+	deps := resource.Dependencies{camera.Named("cam"): cam}
+	conf := resource.Config{
+		Name: "recorder",
+		ConvertedAttributes: &Config{
+			Cameras:    []string{"cam"},
+			CaptureDir: filepath.Join(dir, "capture"),
+			StagingDir: filepath.Join(dir, "staging"),
+			Muxer:      muxerBuiltin,
+			FrameRate:  100,
+		},
+	}
+	svc, err := NewBuiltIn(context.Background(), deps, conf, logger)
+	test.That(t, err, test.ShouldBeNil)
+
+	_, err = svc.DoCommand(context.Background(), map[string]interface{}{"command": "rewind"})
+	test.That(t, err, test.ShouldBeError, "no such command: rewind")
+
+	_, err = svc.DoCommand(context.Background(), map[string]interface{}{"command": videorecorder.CommandStart})
+	test.That(t, err, test.ShouldBeNil)
+	testutils.WaitForAssertion(t, func(tb testing.TB) {
+		tb.Helper()
+		files, err := os.ReadDir(filepath.Join(dir, "staging"))
+		test.That(tb, err, test.ShouldBeNil)
+		test.That(tb, files, test.ShouldHaveLength, 1)
+	})
+	_, err = svc.DoCommand(context.Background(), map[string]interface{}{"command": videorecorder.CommandStop})
+	test.That(t, err, test.ShouldBeNil)
+
+	resp, err := svc.DoCommand(context.Background(), map[string]interface{}{"command": videorecorder.CommandStatus})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, resp["recording"], test.ShouldBeFalse)
+	test.That(t, resp["segments"], test.ShouldEqual, 1)
+	segment, ok := resp["last_segment"].(string)
+	test.That(t, ok, test.ShouldBeTrue)
+	test.That(t, filepath.Dir(segment), test.ShouldEqual, filepath.Join(dir, "capture", "cam"))
+	test.That(t, filepath.Ext(segment), test.ShouldEqual, ".mkv")
+	info, err := os.Stat(segment)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, info.Size(), test.ShouldBeGreaterThan, 0)
+
+	test.That(t, svc.Close(context.Background()), test.ShouldBeNil)
+}
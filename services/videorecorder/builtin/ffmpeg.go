@@ -0,0 +1,96 @@
+package builtin
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ffmpegFormats are the ffmpeg muxers for each container.
+var ffmpegFormats = map[string]string{
+	containerMKV: "matroska",
+	containerMP4: "mp4",
+}
+
+// ffmpegWriter pipes JPEG frames to ffmpeg, which encodes them as H.264. ffmpeg times frames by
+// the frame rate rather than when they were taken, so video where frames were missed plays back
+// slightly faster than it happened.
+type ffmpegWriter struct {
+	path   string
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stderr bytes.Buffer
+
+	width, height int
+}
+
+// newFFMPEGWriter starts ffmpeg writing a file in the given container at the path.
+func newFFMPEGWriter(path, container string, frameRate float64) (*ffmpegWriter, error) {
+	w := &ffmpegWriter{path: path}
+	//nolint:gosec
+	w.cmd = exec.Command("ffmpeg",
+		"-hide_banner", "-loglevel", "error", "-y",
+		"-f", "image2pipe", "-c:v", "mjpeg", "-framerate", fmt.Sprint(frameRate), "-i", "pipe:0",
+		"-c:v", "libx264", "-preset", "veryfast", "-pix_fmt", "yuv420p",
+		"-f", ffmpegFormats[container], path)
+	w.cmd.Stderr = &w.stderr
+	stdin, err := w.cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	w.stdin = stdin
+	if err := w.cmd.Start(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// WriteFrame sends a frame to ffmpeg.
+func (w *ffmpegWriter) WriteFrame(frame []byte, width, height int, timestamp time.Duration) error {
+	if w.width == 0 {
+		w.width, w.height = width, height
+	}
+	if width != w.width || height != w.height {
+		return errors.Errorf("frame size changed from %dx%d to %dx%d", w.width, w.height, width, height)
+	}
+	if _, err := w.stdin.Write(frame); err != nil {
+		// ffmpeg has exited, and why is reported once it is closed
+		return errors.Wrap(err, "ffmpeg stopped taking frames")
+	}
+	return nil
+}
+
+// Size returns the size of the file ffmpeg has written so far.
+func (w *ffmpegWriter) Size() int64 {
+	info, err := os.Stat(w.path)
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
+
+// Close waits for ffmpeg to finish the file.
+func (w *ffmpegWriter) Close() error {
+	err := w.stdin.Close()
+	if waitErr := w.cmd.Wait(); waitErr != nil {
+		err = waitErr
+	}
+	if err != nil {
+		return w.wrapErr(err)
+	}
+	return nil
+}
+
+// wrapErr adds what ffmpeg logged to an error, once ffmpeg has exited.
+func (w *ffmpegWriter) wrapErr(err error) error {
+	if msg := strings.TrimSpace(w.stderr.String()); msg != "" {
+		return errors.Wrapf(err, "ffmpeg: %s", msg)
+	}
+	return errors.Wrap(err, "ffmpeg")
+}
@@ -0,0 +1,273 @@
+package builtin
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Matroska element IDs, which already include their length marker bits.
+const (
+	mkvEBML               = 0x1A45DFA3
+	mkvEBMLVersion        = 0x4286
+	mkvEBMLReadVersion    = 0x42F7
+	mkvEBMLMaxIDLength    = 0x42F2
+	mkvEBMLMaxSizeLength  = 0x42F3
+	mkvDocType            = 0x4282
+	mkvDocTypeVersion     = 0x4287
+	mkvDocTypeReadVersion = 0x4285
+	mkvSegment            = 0x18538067
+	mkvInfo               = 0x1549A966
+	mkvTimecodeScale      = 0x2AD7B1
+	mkvMuxingApp          = 0x4D80
+	mkvWritingApp         = 0x5741
+	mkvDateUTC            = 0x4461
+	mkvDuration           = 0x4489
+	mkvTracks             = 0x1654AE6B
+	mkvTrackEntry         = 0xAE
+	mkvTrackNumber        = 0xD7
+	mkvTrackUID           = 0x73C5
+	mkvTrackType          = 0x83
+	mkvFlagLacing         = 0x9C
+	mkvCodecID            = 0x86
+	mkvVideo              = 0xE0
+	mkvPixelWidth         = 0xB0
+	mkvPixelHeight        = 0xBA
+	mkvCluster            = 0x1F43B675
+	mkvTimecode           = 0xE7
+	mkvSimpleBlock        = 0xA3
+)
+
+const (
+	// mkvTimecodeScaleNanos makes every timecode a millisecond.
+	mkvTimecodeScaleNanos = int64(time.Millisecond)
+	// mkvClusterDuration is how much video is buffered before it is written out in a cluster. It
+	// must stay below the ~32s that a block's 16 bit timecode can be offset from its cluster.
+	mkvClusterDuration = time.Second
+	mkvTrackTypeVideo  = 1
+	mkvCodecMJPEG      = "V_MJPEG"
+	mkvAppName         = "viam-rdk video_recorder"
+	// mkvSegmentSizeLen is the length of the segment size, which is patched in once it is known.
+	mkvSegmentSizeLen = 8
+)
+
+// mkvEpoch is what the DateUTC of a Matroska file is relative to.
+var mkvEpoch = time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// mkvWriter writes JPEG frames to a Matroska file as Motion JPEG without any dependencies outside
+// of Go. The header is written with the first frame since it needs the frame's size.
+type mkvWriter struct {
+	f       *os.File
+	started time.Time
+
+	width, height    int
+	headerDone       bool
+	written          int64
+	segmentDataStart int64
+	durationOffset   int64
+
+	cluster        bytes.Buffer
+	clusterStart   time.Duration
+	clusterStarted bool
+	lastTimestamp  time.Duration
+}
+
+// newMKVWriter creates a Matroska file at the path for a recording that started at the given time.
+func newMKVWriter(path string, started time.Time) (*mkvWriter, error) {
+	//nolint:gosec
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	return &mkvWriter{f: f, started: started}, nil
+}
+
+// WriteFrame buffers a frame taken at the given offset from the start of the recording.
+func (w *mkvWriter) WriteFrame(frame []byte, width, height int, timestamp time.Duration) error {
+	if !w.headerDone {
+		w.width, w.height = width, height
+		if err := w.writeHeader(); err != nil {
+			return err
+		}
+	}
+	if width != w.width || height != w.height {
+		return errors.Errorf("frame size changed from %dx%d to %dx%d", w.width, w.height, width, height)
+	}
+	if w.clusterStarted && timestamp-w.clusterStart >= mkvClusterDuration {
+		if err := w.flushCluster(); err != nil {
+			return err
+		}
+	}
+	if !w.clusterStarted {
+		w.clusterStart = timestamp
+		w.clusterStarted = true
+		writeUintElement(&w.cluster, mkvTimecode, uint64(timestamp.Milliseconds()))
+	}
+
+	var block bytes.Buffer
+	// track number 1 as a one byte vint, the timecode relative to the cluster and the keyframe flag
+	block.WriteByte(0x81)
+	var relative [2]byte
+	//nolint:gosec
+	binary.BigEndian.PutUint16(relative[:], uint16((timestamp - w.clusterStart).Milliseconds()))
+	block.Write(relative[:])
+	block.WriteByte(0x80)
+	block.Write(frame)
+	writeElement(&w.cluster, mkvSimpleBlock, block.Bytes())
+
+	w.lastTimestamp = timestamp
+	return nil
+}
+
+// Size returns the number of bytes in the file so far, including those not yet flushed.
+func (w *mkvWriter) Size() int64 {
+	return w.written + int64(w.cluster.Len())
+}
+
+// Close flushes any buffered frames and fills in the segment size and duration.
+func (w *mkvWriter) Close() error {
+	if !w.headerDone {
+		return w.f.Close()
+	}
+	err := w.flushCluster()
+	if err == nil {
+		err = w.finish()
+	}
+	if closeErr := w.f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+func (w *mkvWriter) write(data []byte) error {
+	n, err := w.f.Write(data)
+	w.written += int64(n)
+	return err
+}
+
+func (w *mkvWriter) writeHeader() error {
+	var ebml bytes.Buffer
+	writeUintElement(&ebml, mkvEBMLVersion, 1)
+	writeUintElement(&ebml, mkvEBMLReadVersion, 1)
+	writeUintElement(&ebml, mkvEBMLMaxIDLength, 4)
+	writeUintElement(&ebml, mkvEBMLMaxSizeLength, 8)
+	writeElement(&ebml, mkvDocType, []byte("matroska"))
+	writeUintElement(&ebml, mkvDocTypeVersion, 4)
+	writeUintElement(&ebml, mkvDocTypeReadVersion, 2)
+
+	var header bytes.Buffer
+	writeElement(&header, mkvEBML, ebml.Bytes())
+	writeID(&header, mkvSegment)
+	// unknown until the file is closed
+	header.Write(encodeVint(1<<(7*mkvSegmentSizeLen)-1, mkvSegmentSizeLen))
+	w.segmentDataStart = int64(header.Len())
+
+	var info bytes.Buffer
+	writeUintElement(&info, mkvTimecodeScale, uint64(mkvTimecodeScaleNanos))
+	writeElement(&info, mkvMuxingApp, []byte(mkvAppName))
+	writeElement(&info, mkvWritingApp, []byte(mkvAppName))
+	var date [8]byte
+	//nolint:gosec
+	binary.BigEndian.PutUint64(date[:], uint64(w.started.Sub(mkvEpoch).Nanoseconds()))
+	writeElement(&info, mkvDateUTC, date[:])
+	// the duration goes last so that its offset is easy to find
+	var duration [8]byte
+	writeElement(&info, mkvDuration, duration[:])
+	writeElement(&header, mkvInfo, info.Bytes())
+	w.durationOffset = int64(header.Len() - len(duration))
+
+	var video bytes.Buffer
+	//nolint:gosec
+	writeUintElement(&video, mkvPixelWidth, uint64(w.width))
+	//nolint:gosec
+	writeUintElement(&video, mkvPixelHeight, uint64(w.height))
+	var track bytes.Buffer
+	writeUintElement(&track, mkvTrackNumber, 1)
+	writeUintElement(&track, mkvTrackUID, 1)
+	writeUintElement(&track, mkvTrackType, mkvTrackTypeVideo)
+	writeUintElement(&track, mkvFlagLacing, 0)
+	writeElement(&track, mkvCodecID, []byte(mkvCodecMJPEG))
+	writeElement(&track, mkvVideo, video.Bytes())
+	var tracks bytes.Buffer
+	writeElement(&tracks, mkvTrackEntry, track.Bytes())
+	writeElement(&header, mkvTracks, tracks.Bytes())
+
+	if err := w.write(header.Bytes()); err != nil {
+		return err
+	}
+	w.headerDone = true
+	return nil
+}
+
+func (w *mkvWriter) flushCluster() error {
+	if !w.clusterStarted {
+		return nil
+	}
+	var cluster bytes.Buffer
+	writeElement(&cluster, mkvCluster, w.cluster.Bytes())
+	w.cluster.Reset()
+	w.clusterStarted = false
+	return w.write(cluster.Bytes())
+}
+
+// finish patches in the size of the segment and the duration of the video once it is known.
+func (w *mkvWriter) finish() error {
+	var duration [8]byte
+	binary.BigEndian.PutUint64(duration[:], math.Float64bits(float64(w.lastTimestamp.Milliseconds())))
+	if _, err := w.f.WriteAt(duration[:], w.durationOffset); err != nil {
+		return err
+	}
+	//nolint:gosec
+	size := encodeVint(uint64(w.written-w.segmentDataStart), mkvSegmentSizeLen)
+	_, err := w.f.WriteAt(size, w.segmentDataStart-mkvSegmentSizeLen)
+	return err
+}
+
+func writeID(w *bytes.Buffer, id uint32) {
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], id)
+	i := 0
+	for i < 3 && b[i] == 0 {
+		i++
+	}
+	w.Write(b[i:])
+}
+
+func writeElement(w *bytes.Buffer, id uint32, data []byte) {
+	writeID(w, id)
+	w.Write(encodeVint(uint64(len(data)), 0))
+	w.Write(data)
+}
+
+func writeUintElement(w *bytes.Buffer, id uint32, v uint64) {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], v)
+	i := 0
+	for i < 7 && b[i] == 0 {
+		i++
+	}
+	writeElement(w, id, b[i:])
+}
+
+// encodeVint encodes an EBML variable length integer with the given length, or the shortest one
+// that fits the value if the length is zero.
+func encodeVint(v uint64, length int) []byte {
+	if length == 0 {
+		length = 1
+		// all ones is reserved for unknown sizes
+		for length < 8 && v >= 1<<(7*length)-1 {
+			length++
+		}
+	}
+	b := make([]byte, length)
+	for i := length - 1; i >= 0; i-- {
+		b[i] = byte(v)
+		v >>= 8
+	}
+	b[0] |= 1 << (8 - length)
+	return b
+}
@@ -0,0 +1,271 @@
+package builtin
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/edaniels/golog"
+	"github.com/pkg/errors"
+	"github.com/viamrobotics/gostream"
+	"go.uber.org/multierr"
+	goutils "go.viam.com/utils"
+
+	"go.viam.com/rdk/components/camera"
+	"go.viam.com/rdk/rimage"
+	"go.viam.com/rdk/utils"
+)
+
+// streamRetryInterval is how long to wait before opening the stream of a camera again after it
+// fails.
+const streamRetryInterval = time.Second
+
+// segmentTimeFormat is the layout of the time in the name of a segment file. Unlike RFC3339 it has
+// no colons, which are not allowed in file names on some platforms.
+const segmentTimeFormat = "20060102T150405.000000000Z"
+
+// segmentWriter writes the frames of one segment to a video file.
+type segmentWriter interface {
+	// WriteFrame writes a JPEG frame taken at the given offset from the start of the segment.
+	WriteFrame(frame []byte, width, height int, timestamp time.Duration) error
+	// Size returns roughly how many bytes the segment takes up so far.
+	Size() int64
+	Close() error
+}
+
+// A frame is a JPEG encoded image of a camera along with when it was taken.
+type frame struct {
+	data          []byte
+	width, height int
+	taken         time.Time
+}
+
+// segmentOptions describe how segments are written and when they are rotated.
+type segmentOptions struct {
+	stagingDir  string
+	captureDir  string
+	extension   string
+	maxDuration time.Duration
+	maxBytes    int64
+	preRoll     time.Duration
+	newWriter   func(path string, started time.Time) (segmentWriter, error)
+}
+
+// cameraRecorder records the frames of one camera to segments. While not recording it keeps the
+// last pre-roll worth of frames, which begin the first segment once recording starts. Segments are
+// written to the staging directory and moved to the capture directory once they are finished so
+// that datasync never uploads a partially written segment.
+type cameraRecorder struct {
+	camName  string
+	opts     *segmentOptions
+	finished func(path string)
+
+	mu            sync.Mutex
+	recording     bool
+	buffered      []frame
+	writer        segmentWriter
+	segmentPath   string
+	segmentStart  time.Time
+	segmentFrames int
+	width, height int
+}
+
+func newCameraRecorder(camName string, opts *segmentOptions, finished func(path string)) *cameraRecorder {
+	return &cameraRecorder{camName: camName, opts: opts, finished: finished}
+}
+
+// run reads frames from the camera at the frame rate until the context is done, opening its
+// stream again whenever it fails.
+func (r *cameraRecorder) run(ctx context.Context, cam camera.VideoSource, period time.Duration, logger golog.Logger) {
+	for {
+		if err := r.capture(ctx, cam, period); err != nil && ctx.Err() == nil {
+			logger.Errorw("failed to record camera", "camera", r.camName, "error", err)
+		}
+		if !goutils.SelectContextOrWait(ctx, streamRetryInterval) {
+			return
+		}
+	}
+}
+
+func (r *cameraRecorder) capture(ctx context.Context, cam camera.VideoSource, period time.Duration) error {
+	stream, err := cam.Stream(gostream.WithMIMETypeHint(ctx, utils.MimeTypeJPEG))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		goutils.UncheckedError(stream.Close(ctx))
+	}()
+
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+		if !r.wantsFrames() {
+			continue
+		}
+		img, release, err := stream.Next(ctx)
+		if err != nil {
+			return err
+		}
+		taken := time.Now()
+		bounds := img.Bounds()
+		data, err := rimage.EncodeImage(ctx, img, utils.MimeTypeJPEG)
+		release()
+		if err != nil {
+			return err
+		}
+		if err := r.addFrame(frame{data: data, width: bounds.Dx(), height: bounds.Dy(), taken: taken}); err != nil {
+			return err
+		}
+	}
+}
+
+// wantsFrames returns whether frames are being recorded or buffered.
+func (r *cameraRecorder) wantsFrames() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.recording || r.opts.preRoll > 0
+}
+
+// addFrame records a frame, or buffers it as pre-roll when not recording.
+func (r *cameraRecorder) addFrame(f frame) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.recording {
+		return r.writeFrame(f)
+	}
+	if r.opts.preRoll <= 0 {
+		return nil
+	}
+	r.buffered = append(r.buffered, f)
+	oldest := f.taken.Add(-r.opts.preRoll)
+	drop := 0
+	for drop < len(r.buffered) && r.buffered[drop].taken.Before(oldest) {
+		drop++
+	}
+	r.buffered = r.buffered[drop:]
+	return nil
+}
+
+// start starts recording, beginning with any buffered frames.
+func (r *cameraRecorder) start() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.recording {
+		return nil
+	}
+	r.recording = true
+	buffered := r.buffered
+	r.buffered = nil
+	for _, f := range buffered {
+		if err := r.writeFrame(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// stop stops recording and finishes the current segment.
+func (r *cameraRecorder) stop() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.recording = false
+	return r.finishSegment()
+}
+
+func (r *cameraRecorder) isRecording() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.recording
+}
+
+// writeFrame writes a frame to the current segment, rotating segments as needed. It must be called
+// with the lock held.
+func (r *cameraRecorder) writeFrame(f frame) error {
+	if r.writer != nil && r.shouldRotate(f) {
+		if err := r.finishSegment(); err != nil {
+			return err
+		}
+	}
+	if r.writer == nil {
+		if err := r.openSegment(f); err != nil {
+			return err
+		}
+	}
+	if err := r.writer.WriteFrame(f.data, f.width, f.height, f.taken.Sub(r.segmentStart)); err != nil {
+		return multierr.Combine(err, r.finishSegment())
+	}
+	r.segmentFrames++
+	return nil
+}
+
+// shouldRotate returns whether a frame belongs in a new segment.
+func (r *cameraRecorder) shouldRotate(f frame) bool {
+	switch {
+	case r.segmentFrames == 0:
+		return false
+	case f.width != r.width || f.height != r.height:
+		return true
+	case r.opts.maxDuration > 0 && f.taken.Sub(r.segmentStart) >= r.opts.maxDuration:
+		return true
+	case r.opts.maxBytes > 0 && r.writer.Size()+int64(len(f.data)) > r.opts.maxBytes:
+		return true
+	default:
+		return false
+	}
+}
+
+func (r *cameraRecorder) openSegment(f frame) error {
+	if err := os.MkdirAll(r.opts.stagingDir, 0o700); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s_%s%s", r.camName, f.taken.UTC().Format(segmentTimeFormat), r.opts.extension)
+	path := filepath.Join(r.opts.stagingDir, name)
+	writer, err := r.opts.newWriter(path, f.taken)
+	if err != nil {
+		return err
+	}
+	r.writer = writer
+	r.segmentPath = path
+	r.segmentStart = f.taken
+	r.segmentFrames = 0
+	r.width, r.height = f.width, f.height
+	return nil
+}
+
+// finishSegment closes the current segment and moves it to the capture directory. Segments that
+// failed to be written are left in the staging directory. It must be called with the lock held.
+func (r *cameraRecorder) finishSegment() error {
+	if r.writer == nil {
+		return nil
+	}
+	err := r.writer.Close()
+	r.writer = nil
+	if r.segmentFrames == 0 {
+		if removeErr := os.Remove(r.segmentPath); removeErr != nil && !os.IsNotExist(removeErr) {
+			return removeErr
+		}
+		return nil
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to finish segment %s", r.segmentPath)
+	}
+
+	dir := filepath.Join(r.opts.captureDir, r.camName)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	path := filepath.Join(dir, filepath.Base(r.segmentPath))
+	if err := os.Rename(r.segmentPath, path); err != nil {
+		return err
+	}
+	r.finished(path)
+	return nil
+}
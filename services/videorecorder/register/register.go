@@ -0,0 +1,7 @@
+// Package register registers all relevant videorecorder models and also API specific functions
+package register
+
+import (
+	// for videorecorder models.
+	_ "go.viam.com/rdk/services/videorecorder/builtin"
+)
@@ -0,0 +1,56 @@
+// Package videorecorder is the service that records camera streams to video files.
+package videorecorder
+
+import (
+	"context"
+
+	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/robot"
+)
+
+// SubtypeName is the name of the type of service.
+const SubtypeName = "video_recorder"
+
+// API is a variable that identifies the video recorder service resource API.
+var API = resource.APINamespaceRDK.WithServiceType(SubtypeName)
+
+// Named is a helper for getting the named video recorder service's typed resource name.
+func Named(name string) resource.Name {
+	return resource.NewName(API, name)
+}
+
+// FromRobot is a helper for getting the named video recorder service from the given Robot.
+func FromRobot(r robot.Robot, name string) (Service, error) {
+	return robot.ResourceFromRobot[Service](r, Named(name))
+}
+
+func init() {
+	resource.RegisterAPI(API, resource.APIRegistration[Service]{})
+}
+
+// DoCommand names that start, stop and report on recording for services without a typed client.
+const (
+	CommandStart  = "start"
+	CommandStop   = "stop"
+	CommandStatus = "status"
+)
+
+// A Service records the video of cameras to segmented video files.
+type Service interface {
+	resource.Resource
+	// Start starts recording. Any buffered pre-roll frames begin the first segment.
+	Start(ctx context.Context, extra map[string]interface{}) error
+	// Stop stops recording and finishes the segments being written.
+	Stop(ctx context.Context, extra map[string]interface{}) error
+	// Status returns whether the service is recording and which segments it has finished.
+	Status(ctx context.Context, extra map[string]interface{}) (Status, error)
+}
+
+// Status describes the state of a video recorder.
+type Status struct {
+	Recording bool
+	// Segments is the number of segments finished since the service was configured.
+	Segments int
+	// LastSegment is the path of the last finished segment, if any.
+	LastSegment string
+}
@@ -0,0 +1,355 @@
+package vision
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"image"
+
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"go.viam.com/rdk/components/camera"
+	"go.viam.com/rdk/data"
+	"go.viam.com/rdk/pointcloud"
+	"go.viam.com/rdk/rimage"
+	"go.viam.com/rdk/spatialmath"
+	"go.viam.com/rdk/utils"
+	viz "go.viam.com/rdk/vision"
+)
+
+type method int64
+
+const (
+	detections method = iota
+	classifications
+	getObjectPointClouds
+)
+
+func (m method) String() string {
+	switch m {
+	case detections:
+		return "Detections"
+	case classifications:
+		return "Classifications"
+	case getObjectPointClouds:
+		return "GetObjectPointClouds"
+	}
+	return "Unknown"
+}
+
+const (
+	// the method parameter naming the camera to run the vision service on, which is required.
+	cameraNameParam = "camera_name"
+	// the method parameter naming the mime type to store the image as, jpeg by default.
+	mimeTypeParam = "mime_type"
+	// the method parameter for how many of the top classifications to store, 1 by default.
+	numClassificationsParam = "n"
+)
+
+// CapturedImage is an image stored by a vision collector along with what the service found in it.
+type CapturedImage struct {
+	MimeType string `json:"mime_type"`
+	Width    int    `json:"width"`
+	Height   int    `json:"height"`
+	// Data is the image encoded as MimeType, in base64.
+	Data string `json:"data"`
+}
+
+// CapturedDetection is a detection stored by a vision collector.
+type CapturedDetection struct {
+	XMin       int     `json:"x_min"`
+	YMin       int     `json:"y_min"`
+	XMax       int     `json:"x_max"`
+	YMax       int     `json:"y_max"`
+	Label      string  `json:"label"`
+	Confidence float64 `json:"confidence"`
+}
+
+// CapturedClassification is a classification stored by a vision collector.
+type CapturedClassification struct {
+	Label      string  `json:"label"`
+	Confidence float64 `json:"confidence"`
+}
+
+// CapturedObject is a segmented object stored by a vision collector. Its geometry is relative to the camera.
+type CapturedObject struct {
+	Label    string            `json:"label"`
+	Geometry *CapturedGeometry `json:"geometry,omitempty"`
+	// PointCloud is the binary PCD of the points of the object, in base64.
+	PointCloud string `json:"point_cloud"`
+}
+
+// CapturedGeometry is the geometry around a segmented object, given as its center pose (in mm and an
+// orientation vector in degrees) and the dimensions of its shape.
+type CapturedGeometry struct {
+	Type  string  `json:"type"`
+	X     float64 `json:"x"`
+	Y     float64 `json:"y"`
+	Z     float64 `json:"z"`
+	OX    float64 `json:"o_x"`
+	OY    float64 `json:"o_y"`
+	OZ    float64 `json:"o_z"`
+	Theta float64 `json:"theta"`
+	DimX  float64 `json:"dim_x,omitempty"`
+	DimY  float64 `json:"dim_y,omitempty"`
+	DimZ  float64 `json:"dim_z,omitempty"`
+	R     float64 `json:"r,omitempty"`
+	L     float64 `json:"l,omitempty"`
+}
+
+// DetectionsCapture is the reading of a Detections collector. The detections are of the image, so
+// both share the time of the reading.
+type DetectionsCapture struct {
+	Image      CapturedImage       `json:"image"`
+	Detections []CapturedDetection `json:"detections"`
+}
+
+// ClassificationsCapture is the reading of a Classifications collector.
+type ClassificationsCapture struct {
+	Image           CapturedImage            `json:"image"`
+	Classifications []CapturedClassification `json:"classifications"`
+}
+
+// ObjectsCapture is the reading of a GetObjectPointClouds collector. The service reads the camera on
+// its own, so the image is the one read from the camera right before the objects were segmented.
+type ObjectsCapture struct {
+	Image   CapturedImage    `json:"image"`
+	Objects []CapturedObject `json:"objects"`
+}
+
+func newDetectionsCollector(resource interface{}, params data.CollectorParams) (data.Collector, error) {
+	vision, err := assertVision(resource)
+	if err != nil {
+		return nil, err
+	}
+	cam, mimeType, err := cameraAndMimeType(params)
+	if err != nil {
+		return nil, err
+	}
+
+	cFunc := data.CaptureFunc(func(ctx context.Context, _ map[string]*anypb.Any) (interface{}, error) {
+		_, span := trace.StartSpan(ctx, "vision::data::collector::CaptureFunc::Detections")
+		defer span.End()
+
+		img, release, err := camera.ReadImage(ctx, cam)
+		if err != nil {
+			return nil, data.FailedToReadErr(params.ComponentName, detections.String(), err)
+		}
+		defer release()
+		dets, err := vision.Detections(ctx, img, fromDMExtra())
+		if err != nil {
+			if errors.Is(err, data.ErrNoCaptureToStore) {
+				return nil, data.ErrNoCaptureToStore
+			}
+			return nil, data.FailedToReadErr(params.ComponentName, detections.String(), err)
+		}
+
+		captured, err := captureImage(ctx, img, mimeType)
+		if err != nil {
+			return nil, err
+		}
+		reading := DetectionsCapture{Image: captured, Detections: make([]CapturedDetection, 0, len(dets))}
+		for _, d := range dets {
+			box := d.BoundingBox()
+			reading.Detections = append(reading.Detections, CapturedDetection{
+				XMin:       box.Min.X,
+				YMin:       box.Min.Y,
+				XMax:       box.Max.X,
+				YMax:       box.Max.Y,
+				Label:      d.Label(),
+				Confidence: d.Score(),
+			})
+		}
+		return reading, nil
+	})
+	return data.NewCollector(cFunc, params)
+}
+
+func newClassificationsCollector(resource interface{}, params data.CollectorParams) (data.Collector, error) {
+	vision, err := assertVision(resource)
+	if err != nil {
+		return nil, err
+	}
+	cam, mimeType, err := cameraAndMimeType(params)
+	if err != nil {
+		return nil, err
+	}
+	n := 1
+	if param, ok := params.MethodParams[numClassificationsParam]; ok {
+		n64 := new(wrapperspb.Int64Value)
+		if err := param.UnmarshalTo(n64); err != nil {
+			return nil, errors.Wrapf(err, "method parameter %q must be an integer", numClassificationsParam)
+		}
+		n = int(n64.Value)
+	}
+
+	cFunc := data.CaptureFunc(func(ctx context.Context, _ map[string]*anypb.Any) (interface{}, error) {
+		_, span := trace.StartSpan(ctx, "vision::data::collector::CaptureFunc::Classifications")
+		defer span.End()
+
+		img, release, err := camera.ReadImage(ctx, cam)
+		if err != nil {
+			return nil, data.FailedToReadErr(params.ComponentName, classifications.String(), err)
+		}
+		defer release()
+		classes, err := vision.Classifications(ctx, img, n, fromDMExtra())
+		if err != nil {
+			if errors.Is(err, data.ErrNoCaptureToStore) {
+				return nil, data.ErrNoCaptureToStore
+			}
+			return nil, data.FailedToReadErr(params.ComponentName, classifications.String(), err)
+		}
+
+		captured, err := captureImage(ctx, img, mimeType)
+		if err != nil {
+			return nil, err
+		}
+		reading := ClassificationsCapture{Image: captured, Classifications: make([]CapturedClassification, 0, len(classes))}
+		for _, c := range classes {
+			reading.Classifications = append(reading.Classifications, CapturedClassification{
+				Label:      c.Label(),
+				Confidence: c.Score(),
+			})
+		}
+		return reading, nil
+	})
+	return data.NewCollector(cFunc, params)
+}
+
+func newGetObjectPointCloudsCollector(resource interface{}, params data.CollectorParams) (data.Collector, error) {
+	vision, err := assertVision(resource)
+	if err != nil {
+		return nil, err
+	}
+	cam, mimeType, err := cameraAndMimeType(params)
+	if err != nil {
+		return nil, err
+	}
+	cameraName := cam.Name().ShortName()
+
+	cFunc := data.CaptureFunc(func(ctx context.Context, _ map[string]*anypb.Any) (interface{}, error) {
+		_, span := trace.StartSpan(ctx, "vision::data::collector::CaptureFunc::GetObjectPointClouds")
+		defer span.End()
+
+		img, release, err := camera.ReadImage(ctx, cam)
+		if err != nil {
+			return nil, data.FailedToReadErr(params.ComponentName, getObjectPointClouds.String(), err)
+		}
+		defer release()
+		objects, err := vision.GetObjectPointClouds(ctx, cameraName, fromDMExtra())
+		if err != nil {
+			if errors.Is(err, data.ErrNoCaptureToStore) {
+				return nil, data.ErrNoCaptureToStore
+			}
+			return nil, data.FailedToReadErr(params.ComponentName, getObjectPointClouds.String(), err)
+		}
+
+		captured, err := captureImage(ctx, img, mimeType)
+		if err != nil {
+			return nil, err
+		}
+		reading := ObjectsCapture{Image: captured, Objects: make([]CapturedObject, 0, len(objects))}
+		for _, o := range objects {
+			object, err := captureObject(o)
+			if err != nil {
+				return nil, err
+			}
+			reading.Objects = append(reading.Objects, object)
+		}
+		return reading, nil
+	})
+	return data.NewCollector(cFunc, params)
+}
+
+// cameraAndMimeType finds the camera a vision collector is run on and the mime type to store its images as.
+func cameraAndMimeType(params data.CollectorParams) (camera.Camera, string, error) {
+	param, ok := params.MethodParams[cameraNameParam]
+	if !ok {
+		return nil, "", errors.Errorf("missing method parameter %q", cameraNameParam)
+	}
+	cameraName := new(wrapperspb.StringValue)
+	if err := param.UnmarshalTo(cameraName); err != nil {
+		return nil, "", err
+	}
+	cam, err := camera.FromDependencies(params.Dependencies, cameraName.Value)
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "could not find camera %q", cameraName.Value)
+	}
+
+	mimeType := utils.MimeTypeJPEG
+	if param, ok := params.MethodParams[mimeTypeParam]; ok {
+		mimeStr := new(wrapperspb.StringValue)
+		if err := param.UnmarshalTo(mimeStr); err != nil {
+			return nil, "", err
+		}
+		mimeType = mimeStr.Value
+	}
+	return cam, mimeType, nil
+}
+
+// fromDMExtra lets the vision service know that it is being called by data management, so that
+// modular filters can choose not to store a capture.
+func fromDMExtra() map[string]interface{} {
+	return map[string]interface{}{data.FromDMString: true}
+}
+
+func captureImage(ctx context.Context, img image.Image, mimeType string) (CapturedImage, error) {
+	encoded, err := rimage.EncodeImage(ctx, img, mimeType)
+	if err != nil {
+		return CapturedImage{}, err
+	}
+	return CapturedImage{
+		MimeType: mimeType,
+		Width:    img.Bounds().Dx(),
+		Height:   img.Bounds().Dy(),
+		Data:     base64.StdEncoding.EncodeToString(encoded),
+	}, nil
+}
+
+func captureObject(o *viz.Object) (CapturedObject, error) {
+	var object CapturedObject
+	if o.PointCloud != nil {
+		var buf bytes.Buffer
+		if err := pointcloud.ToPCD(o.PointCloud, &buf, pointcloud.PCDBinary); err != nil {
+			return CapturedObject{}, errors.Errorf("failed to convert object point cloud to PCD: %v", err)
+		}
+		object.PointCloud = base64.StdEncoding.EncodeToString(buf.Bytes())
+	}
+	if o.Geometry == nil {
+		return object, nil
+	}
+	conf, err := spatialmath.NewGeometryConfig(o.Geometry)
+	if err != nil {
+		return CapturedObject{}, err
+	}
+	pt := o.Geometry.Pose().Point()
+	ov := o.Geometry.Pose().Orientation().OrientationVectorDegrees()
+	object.Label = o.Geometry.Label()
+	object.Geometry = &CapturedGeometry{
+		Type:  string(conf.Type),
+		X:     pt.X,
+		Y:     pt.Y,
+		Z:     pt.Z,
+		OX:    ov.OX,
+		OY:    ov.OY,
+		OZ:    ov.OZ,
+		Theta: ov.Theta,
+		DimX:  conf.X,
+		DimY:  conf.Y,
+		DimZ:  conf.Z,
+		R:     conf.R,
+		L:     conf.L,
+	}
+	return object, nil
+}
+
+func assertVision(resource interface{}) (Service, error) {
+	visionService, ok := resource.(Service)
+	if !ok {
+		return nil, data.InvalidInterfaceErr(API)
+	}
+	return visionService, nil
+}
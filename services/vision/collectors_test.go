@@ -0,0 +1,199 @@
+package vision_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"image"
+	"image/color"
+	"testing"
+	"time"
+
+	"github.com/edaniels/golog"
+	"github.com/golang/geo/r3"
+	v1 "go.viam.com/api/app/datasync/v1"
+	"go.viam.com/test"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"go.viam.com/rdk/components/camera"
+	"go.viam.com/rdk/data"
+	"go.viam.com/rdk/pointcloud"
+	"go.viam.com/rdk/protoutils"
+	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/rimage"
+	"go.viam.com/rdk/services/vision"
+	"go.viam.com/rdk/testutils/inject"
+	"go.viam.com/rdk/utils"
+	viz "go.viam.com/rdk/vision"
+	"go.viam.com/rdk/vision/classification"
+	"go.viam.com/rdk/vision/objectdetection"
+)
+
+type fakeReader struct{}
+
+func (fakeReader) Read(ctx context.Context) (image.Image, func(), error) {
+	img := image.NewNRGBA(image.Rect(0, 0, 40, 30))
+	for x := 10; x < 20; x++ {
+		for y := 5; y < 15; y++ {
+			img.Set(x, y, color.NRGBA{R: 255, A: 255})
+		}
+	}
+	return img, func() {}, nil
+}
+
+func (fakeReader) Close(ctx context.Context) error {
+	return nil
+}
+
+// bufferedWriter sends everything written to it to a channel.
+type bufferedWriter struct {
+	written chan *v1.SensorData
+}
+
+func (bw *bufferedWriter) Write(item *v1.SensorData) error {
+	bw.written <- item
+	return nil
+}
+
+func (bw *bufferedWriter) Flush() error {
+	return nil
+}
+
+func (bw *bufferedWriter) Path() string {
+	return "/dev/null"
+}
+
+// captureOnce runs the collector of a vision service method until it captures a reading.
+func captureOnce(t *testing.T, svc vision.Service, method string, methodParams map[string]string) map[string]interface{} {
+	t.Helper()
+	src, err := camera.NewVideoSourceFromReader(context.Background(), fakeReader{}, nil, camera.ColorStream)
+	test.That(t, err, test.ShouldBeNil)
+	cam := camera.FromVideoSource(camera.Named("cam"), src)
+	params, err := protoutils.ConvertStringMapToAnyPBMap(methodParams)
+	test.That(t, err, test.ShouldBeNil)
+
+	constructor := data.CollectorLookup(data.MethodMetadata{API: vision.API, MethodName: method})
+	test.That(t, constructor, test.ShouldNotBeNil)
+	target := &bufferedWriter{written: make(chan *v1.SensorData, 100)}
+	collector, err := (*constructor)(svc, data.CollectorParams{
+		ComponentName: "vision1",
+		Interval:      10 * time.Millisecond,
+		MethodParams:  params,
+		Target:        target,
+		QueueSize:     10,
+		BufferSize:    10,
+		Logger:        golog.NewTestLogger(t),
+		Dependencies:  resource.Dependencies{camera.Named("cam"): cam},
+	})
+	test.That(t, err, test.ShouldBeNil)
+	collector.Collect()
+	defer collector.Close()
+
+	select {
+	case reading := <-target.written:
+		return reading.GetStruct().AsMap()
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for a capture")
+		return nil
+	}
+}
+
+// checkImage checks that a captured image is the image that was read from the camera.
+func checkImage(t *testing.T, captured interface{}) {
+	t.Helper()
+	img := captured.(map[string]interface{})
+	test.That(t, img["mime_type"], test.ShouldEqual, utils.MimeTypePNG)
+	test.That(t, img["width"], test.ShouldEqual, 40.)
+	test.That(t, img["height"], test.ShouldEqual, 30.)
+	encoded, err := base64.StdEncoding.DecodeString(img["data"].(string))
+	test.That(t, err, test.ShouldBeNil)
+	decoded, err := rimage.DecodeImage(context.Background(), encoded, utils.MimeTypePNG)
+	test.That(t, err, test.ShouldBeNil)
+	expected, _, err := fakeReader{}.Read(context.Background())
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, rimage.ConvertImage(decoded), test.ShouldResemble, rimage.ConvertImage(expected))
+}
+
+func TestDetectionsCollector(t *testing.T) {
+	svc := inject.NewVisionService("vision1")
+	svc.DetectionsFunc = func(ctx context.Context, img image.Image, extra map[string]interface{}) ([]objectdetection.Detection, error) {
+		test.That(t, extra[data.FromDMString], test.ShouldBeTrue)
+		// the detection is of the image that was read from the camera
+		test.That(t, img.At(15, 10), test.ShouldResemble, color.NRGBA{R: 255, A: 255})
+		return []objectdetection.Detection{objectdetection.NewDetection(image.Rect(10, 5, 20, 15), 0.8, "red")}, nil
+	}
+	reading := captureOnce(t, svc, "Detections", map[string]string{"camera_name": "cam", "mime_type": utils.MimeTypePNG})
+	checkImage(t, reading["image"])
+	test.That(t, reading["detections"], test.ShouldResemble, []interface{}{
+		map[string]interface{}{"x_min": 10., "y_min": 5., "x_max": 20., "y_max": 15., "label": "red", "confidence": 0.8},
+	})
+}
+
+func TestClassificationsCollector(t *testing.T) {
+	svc := inject.NewVisionService("vision1")
+	svc.ClassificationsFunc = func(ctx context.Context, img image.Image, n int, extra map[string]interface{},
+	) (classification.Classifications, error) {
+		test.That(t, n, test.ShouldEqual, 2)
+		return classification.Classifications{
+			classification.NewClassification(0.7, "apple"),
+			classification.NewClassification(0.2, "cherry"),
+		}, nil
+	}
+	reading := captureOnce(t, svc, "Classifications", map[string]string{"camera_name": "cam", "mime_type": utils.MimeTypePNG, "n": "2"})
+	checkImage(t, reading["image"])
+	test.That(t, reading["classifications"], test.ShouldResemble, []interface{}{
+		map[string]interface{}{"label": "apple", "confidence": 0.7},
+		map[string]interface{}{"label": "cherry", "confidence": 0.2},
+	})
+}
+
+func TestGetObjectPointCloudsCollector(t *testing.T) {
+	svc := inject.NewVisionService("vision1")
+	svc.GetObjectPointCloudsFunc = func(ctx context.Context, cameraName string, extra map[string]interface{}) ([]*viz.Object, error) {
+		test.That(t, cameraName, test.ShouldEqual, "cam")
+		cloud := pointcloud.New()
+		test.That(t, cloud.Set(r3.Vector{X: 0, Y: 0, Z: 100}, nil), test.ShouldBeNil)
+		test.That(t, cloud.Set(r3.Vector{X: 10, Y: 20, Z: 110}, nil), test.ShouldBeNil)
+		obj, err := viz.NewObjectWithLabel(cloud, "cup", nil)
+		test.That(t, err, test.ShouldBeNil)
+		return []*viz.Object{obj}, nil
+	}
+	reading := captureOnce(t, svc, "GetObjectPointClouds", map[string]string{"camera_name": "cam", "mime_type": utils.MimeTypePNG})
+	checkImage(t, reading["image"])
+	objects := reading["objects"].([]interface{})
+	test.That(t, objects, test.ShouldHaveLength, 1)
+	object := objects[0].(map[string]interface{})
+	test.That(t, object["label"], test.ShouldEqual, "cup")
+	geometry := object["geometry"].(map[string]interface{})
+	test.That(t, geometry["type"], test.ShouldEqual, "box")
+	test.That(t, geometry["x"], test.ShouldAlmostEqual, 5)
+	test.That(t, geometry["y"], test.ShouldAlmostEqual, 10)
+	test.That(t, geometry["z"], test.ShouldAlmostEqual, 105)
+	test.That(t, geometry["dim_z"], test.ShouldAlmostEqual, 10)
+	pcd, err := base64.StdEncoding.DecodeString(object["point_cloud"].(string))
+	test.That(t, err, test.ShouldBeNil)
+	cloud, err := pointcloud.ReadPCD(bytes.NewReader(pcd))
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, cloud.Size(), test.ShouldEqual, 2)
+}
+
+func TestCollectorNeedsCamera(t *testing.T) {
+	svc := inject.NewVisionService("vision1")
+	for _, method := range []string{"Detections", "Classifications", "GetObjectPointClouds"} {
+		constructor := data.CollectorLookup(data.MethodMetadata{API: vision.API, MethodName: method})
+		test.That(t, constructor, test.ShouldNotBeNil)
+		params := data.CollectorParams{
+			ComponentName: "vision1",
+			Target:        &bufferedWriter{},
+			Logger:        golog.NewTestLogger(t),
+			MethodParams:  map[string]*anypb.Any{},
+		}
+		_, err := (*constructor)(svc, params)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "missing method parameter \"camera_name\"")
+
+		params.MethodParams, err = protoutils.ConvertStringMapToAnyPBMap(map[string]string{"camera_name": "cam"})
+		test.That(t, err, test.ShouldBeNil)
+		_, err = (*constructor)(svc, params)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "could not find camera \"cam\"")
+	}
+}
@@ -0,0 +1,273 @@
+// Package fiducialdetector finds square fiducial markers, like ArUco markers, in images and
+// estimates their poses in the frame of the camera that saw them.
+package fiducialdetector
+
+import (
+	"context"
+	"image"
+	"strconv"
+
+	"github.com/edaniels/golog"
+	"github.com/golang/geo/r3"
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+	goutils "go.viam.com/utils"
+
+	"go.viam.com/rdk/components/camera"
+	"go.viam.com/rdk/pointcloud"
+	"go.viam.com/rdk/referenceframe"
+	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/robot"
+	"go.viam.com/rdk/services/vision"
+	"go.viam.com/rdk/spatialmath"
+	"go.viam.com/rdk/utils"
+	viz "go.viam.com/rdk/vision"
+	"go.viam.com/rdk/vision/fiducial"
+	objdet "go.viam.com/rdk/vision/objectdetection"
+)
+
+var model = resource.DefaultModelFamily.WithModel("fiducial_detector")
+
+// CommandMarkerPoses is the DoCommand that returns the poses of the markers a camera sees.
+const CommandMarkerPoses = "get_marker_poses"
+
+// markerThicknessMM is the thickness of the boxes that stand for markers in GetObjectPointClouds.
+const markerThicknessMM = 1
+
+func init() {
+	resource.RegisterService(vision.API, model, resource.Registration[vision.Service, *Config]{
+		DeprecatedRobotConstructor: func(ctx context.Context, r any, c resource.Config, logger golog.Logger) (vision.Service, error) {
+			attrs, err := resource.NativeConfig[*Config](c)
+			if err != nil {
+				return nil, err
+			}
+			actualR, err := utils.AssertType[robot.Robot](r)
+			if err != nil {
+				return nil, err
+			}
+			return registerFiducialDetector(ctx, c.ResourceName(), attrs, actualR)
+		},
+	})
+}
+
+// Config is the attribute struct for a fiducial detector. The markers come from a built in
+// dictionary, or from a custom one, like an AprilTag family, given as its codes.
+type Config struct {
+	Dictionary       string               `json:"dictionary,omitempty"`
+	CustomDictionary *fiducial.Dictionary `json:"custom_dictionary,omitempty"`
+	// MarkerSizeMM is the length of the side of a marker, including its black border.
+	MarkerSizeMM float64 `json:"marker_size_mm"`
+	// MarkerSizesMM overrides the size of markers of certain ids.
+	MarkerSizesMM map[string]float64 `json:"marker_sizes_mm,omitempty"`
+}
+
+// Validate ensures all parts of the config are valid.
+func (cfg *Config) Validate(path string) ([]string, error) {
+	if cfg.Dictionary != "" && cfg.CustomDictionary != nil {
+		return nil, goutils.NewConfigValidationError(path, errors.New("cannot have both a dictionary and a custom_dictionary"))
+	}
+	if cfg.CustomDictionary != nil {
+		if err := cfg.CustomDictionary.Validate(); err != nil {
+			return nil, goutils.NewConfigValidationError(path, errors.Wrap(err, "invalid custom_dictionary"))
+		}
+	} else if cfg.Dictionary != "" {
+		if _, err := fiducial.DictionaryByName(cfg.Dictionary); err != nil {
+			return nil, goutils.NewConfigValidationError(path, err)
+		}
+	}
+	if cfg.MarkerSizeMM <= 0 {
+		return nil, goutils.NewConfigValidationError(path, errors.New("marker_size_mm must be positive"))
+	}
+	for id, size := range cfg.MarkerSizesMM {
+		if _, err := strconv.Atoi(id); err != nil {
+			return nil, goutils.NewConfigValidationError(path, errors.Errorf("marker_sizes_mm has an id %q that is not a number", id))
+		}
+		if size <= 0 {
+			return nil, goutils.NewConfigValidationError(path, errors.Errorf("size of marker %s must be positive", id))
+		}
+	}
+	return nil, nil
+}
+
+// Service is a vision service that also gives the poses of the markers it finds, in the frame of
+// the camera that saw them, for use with the frame system.
+type Service interface {
+	vision.Service
+	MarkerPoses(ctx context.Context, cameraName string, extra map[string]interface{}) ([]*referenceframe.PoseInFrame, error)
+}
+
+type fiducialDetector struct {
+	vision.Service
+	r       robot.Robot
+	dict    *fiducial.Dictionary
+	sizeMM  float64
+	sizesMM map[int]float64
+}
+
+// registerFiducialDetector creates a new fiducial detector from the config.
+func registerFiducialDetector(
+	ctx context.Context,
+	name resource.Name,
+	conf *Config,
+	r robot.Robot,
+) (Service, error) {
+	_, span := trace.StartSpan(ctx, "service::vision::registerFiducialDetector")
+	defer span.End()
+	if conf == nil {
+		return nil, errors.New("config for fiducial detector cannot be nil")
+	}
+	dict := conf.CustomDictionary
+	if dict == nil {
+		dictName := conf.Dictionary
+		if dictName == "" {
+			dictName = fiducial.ArucoOriginal
+		}
+		var err error
+		if dict, err = fiducial.DictionaryByName(dictName); err != nil {
+			return nil, err
+		}
+	}
+	if err := dict.Validate(); err != nil {
+		return nil, errors.Wrapf(err, "error registering fiducial detector %q", name)
+	}
+	if conf.MarkerSizeMM <= 0 {
+		return nil, errors.New("marker_size_mm must be positive")
+	}
+	fd := &fiducialDetector{r: r, dict: dict, sizeMM: conf.MarkerSizeMM, sizesMM: map[int]float64{}}
+	for id, size := range conf.MarkerSizesMM {
+		idNum, err := strconv.Atoi(id)
+		if err != nil {
+			return nil, errors.Wrapf(err, "marker_sizes_mm has an id %q that is not a number", id)
+		}
+		fd.sizesMM[idNum] = size
+	}
+	svc, err := vision.NewService(name, r, nil, nil, fd.detect, fd.segment)
+	if err != nil {
+		return nil, err
+	}
+	fd.Service = svc
+	return fd, nil
+}
+
+// detect finds the markers in an image, labeled by their ids.
+func (fd *fiducialDetector) detect(ctx context.Context, img image.Image) ([]objdet.Detection, error) {
+	markers := fiducial.Detect(img, fd.dict)
+	detections := make([]objdet.Detection, 0, len(markers))
+	for _, m := range markers {
+		detections = append(detections, objdet.NewDetection(m.BoundingBox(), 1, strconv.Itoa(m.ID)))
+	}
+	return detections, nil
+}
+
+// segment returns a thin box, labeled by its id, for every marker the camera sees.
+func (fd *fiducialDetector) segment(ctx context.Context, src camera.VideoSource) ([]*viz.Object, error) {
+	ids, poses, err := fd.markerPoses(ctx, src)
+	if err != nil {
+		return nil, err
+	}
+	objects := make([]*viz.Object, 0, len(ids))
+	for i, id := range ids {
+		size := fd.markerSize(id)
+		box, err := spatialmath.NewBox(poses[i], r3.Vector{X: size, Y: size, Z: markerThicknessMM}, strconv.Itoa(id))
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, &viz.Object{PointCloud: pointcloud.New(), Geometry: box})
+	}
+	return objects, nil
+}
+
+// MarkerPoses returns the pose of every marker the camera sees, in the camera's frame and named
+// by the marker's id.
+func (fd *fiducialDetector) MarkerPoses(
+	ctx context.Context, cameraName string, extra map[string]interface{},
+) ([]*referenceframe.PoseInFrame, error) {
+	ctx, span := trace.StartSpan(ctx, "service::vision::fiducialDetector::MarkerPoses")
+	defer span.End()
+	cam, err := camera.FromRobot(fd.r, cameraName)
+	if err != nil {
+		return nil, err
+	}
+	ids, poses, err := fd.markerPoses(ctx, cam)
+	if err != nil {
+		return nil, err
+	}
+	posesInFrame := make([]*referenceframe.PoseInFrame, 0, len(ids))
+	for i, id := range ids {
+		pif := referenceframe.NewPoseInFrame(cameraName, poses[i])
+		pif.SetName(strconv.Itoa(id))
+		posesInFrame = append(posesInFrame, pif)
+	}
+	return posesInFrame, nil
+}
+
+// markerPoses finds the markers in the next image of a camera, and estimates their poses with the
+// camera's intrinsics.
+func (fd *fiducialDetector) markerPoses(ctx context.Context, src camera.VideoSource) ([]int, []spatialmath.Pose, error) {
+	props, err := src.Properties(ctx)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "could not get camera properties")
+	}
+	if props.IntrinsicParams == nil {
+		return nil, nil, errors.New("the camera needs intrinsic parameters to estimate the poses of markers")
+	}
+	img, release, err := camera.ReadImage(ctx, src)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "could not get image from camera")
+	}
+	defer release()
+
+	markers := fiducial.Detect(img, fd.dict)
+	ids := make([]int, 0, len(markers))
+	poses := make([]spatialmath.Pose, 0, len(markers))
+	for _, m := range markers {
+		pose, err := m.Pose(fd.markerSize(m.ID), props.IntrinsicParams, props.DistortionParams)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "could not estimate the pose of marker %d", m.ID)
+		}
+		ids = append(ids, m.ID)
+		poses = append(poses, pose)
+	}
+	return ids, poses, nil
+}
+
+func (fd *fiducialDetector) markerSize(id int) float64 {
+	if size, ok := fd.sizesMM[id]; ok {
+		return size
+	}
+	return fd.sizeMM
+}
+
+// DoCommand returns the poses of the markers a camera sees, for clients without the Go API.
+func (fd *fiducialDetector) DoCommand(ctx context.Context, cmd map[string]interface{}) (map[string]interface{}, error) {
+	name, ok := cmd["command"]
+	if !ok {
+		return nil, errors.New("missing 'command' value")
+	}
+	switch name {
+	case CommandMarkerPoses:
+		cameraName, ok := cmd["camera_name"].(string)
+		if !ok {
+			return nil, errors.New("missing 'camera_name' value")
+		}
+		posesInFrame, err := fd.MarkerPoses(ctx, cameraName, nil)
+		if err != nil {
+			return nil, err
+		}
+		poses := make([]interface{}, 0, len(posesInFrame))
+		for _, pif := range posesInFrame {
+			pose := spatialmath.PoseToProtobuf(pif.Pose())
+			poses = append(poses, map[string]interface{}{
+				"id":              pif.Name(),
+				"reference_frame": pif.Parent(),
+				"pose": map[string]interface{}{
+					"x": pose.X, "y": pose.Y, "z": pose.Z,
+					"o_x": pose.OX, "o_y": pose.OY, "o_z": pose.OZ, "theta": pose.Theta,
+				},
+			})
+		}
+		return map[string]interface{}{"poses": poses}, nil
+	default:
+		return nil, errors.Errorf("no such command: %s", name)
+	}
+}
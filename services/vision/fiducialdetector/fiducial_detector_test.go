@@ -0,0 +1,167 @@
+package fiducialdetector
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/golang/geo/r3"
+	"go.viam.com/test"
+
+	"go.viam.com/rdk/components/camera"
+	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/rimage/transform"
+	"go.viam.com/rdk/services/vision"
+	"go.viam.com/rdk/spatialmath"
+	"go.viam.com/rdk/testutils/inject"
+	"go.viam.com/rdk/vision/fiducial"
+)
+
+const (
+	markerID   = 42
+	cellPixels = 20
+	// the top left pixel of the marker, which is upright and faces the camera
+	markerLeft, markerTop = 300, 200
+)
+
+var testIntrinsics = &transform.PinholeCameraIntrinsics{Width: 640, Height: 480, Fx: 600, Fy: 600, Ppx: 320, Ppy: 240}
+
+// markerReader serves an image of a marker of the original ArUco dictionary.
+type markerReader struct{}
+
+func (markerReader) Read(ctx context.Context) (image.Image, func(), error) {
+	dict, err := fiducial.DictionaryByName(fiducial.ArucoOriginal)
+	if err != nil {
+		return nil, nil, err
+	}
+	img := image.NewGray(image.Rect(0, 0, testIntrinsics.Width, testIntrinsics.Height))
+	for y := 0; y < testIntrinsics.Height; y++ {
+		for x := 0; x < testIntrinsics.Width; x++ {
+			col, row := (x-markerLeft)/cellPixels, (y-markerTop)/cellPixels
+			value := uint8(235)
+			switch {
+			case x < markerLeft || y < markerTop || col > 6 || row > 6:
+			case col == 0 || row == 0 || col == 6 || row == 6:
+				value = 20
+			case dict.Codes[markerID]>>(24-((row-1)*5+col-1))&1 == 0:
+				value = 20
+			}
+			img.SetGray(x, y, color.Gray{value})
+		}
+	}
+	return img, func() {}, nil
+}
+
+func (markerReader) Close(ctx context.Context) error {
+	return nil
+}
+
+func TestFiducialDetector(t *testing.T) {
+	ctx := context.Background()
+	cameraModel := &transform.PinholeCameraModel{PinholeCameraIntrinsics: testIntrinsics}
+	withIntrinsics, err := camera.NewVideoSourceFromReader(ctx, markerReader{}, cameraModel, camera.ColorStream)
+	test.That(t, err, test.ShouldBeNil)
+	noIntrinsics, err := camera.NewVideoSourceFromReader(ctx, markerReader{}, nil, camera.ColorStream)
+	test.That(t, err, test.ShouldBeNil)
+	cams := map[string]camera.Camera{
+		"cam":               camera.FromVideoSource(camera.Named("cam"), withIntrinsics),
+		"no_intrinsics_cam": camera.FromVideoSource(camera.Named("no_intrinsics_cam"), noIntrinsics),
+	}
+	r := &inject.Robot{}
+	r.ResourceByNameFunc = func(n resource.Name) (resource.Resource, error) {
+		cam, ok := cams[n.Name]
+		if !ok {
+			return nil, resource.NewNotFoundError(n)
+		}
+		return cam, nil
+	}
+
+	name := vision.Named("test_fd")
+	srv, err := registerFiducialDetector(ctx, name, &Config{MarkerSizeMM: 100, MarkerSizesMM: map[string]float64{"7": 50}}, r)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, srv.Name(), test.ShouldResemble, name)
+
+	// the marker spans 7 cells, and its edges are half a pixel out from the centers of its outer pixels
+	side := float64(7 * cellPixels)
+	center := r3.Vector{X: markerLeft - 0.5 + side/2, Y: markerTop - 0.5 + side/2}
+	depth := testIntrinsics.Fx * 100 / side
+	expected := spatialmath.NewPose(
+		r3.Vector{
+			X: (center.X - testIntrinsics.Ppx) * depth / testIntrinsics.Fx,
+			Y: (center.Y - testIntrinsics.Ppy) * depth / testIntrinsics.Fy,
+			Z: depth,
+		},
+		// the marker's z axis comes out of its face, towards the camera
+		&spatialmath.R4AA{Theta: math.Pi, RX: 1},
+	)
+
+	t.Run("detections", func(t *testing.T) {
+		detections, err := srv.DetectionsFromCamera(ctx, "cam", nil)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, detections, test.ShouldHaveLength, 1)
+		test.That(t, detections[0].Label(), test.ShouldEqual, "42")
+		box := detections[0].BoundingBox()
+		test.That(t, box.Min.X, test.ShouldBeBetweenOrEqual, markerLeft-2, markerLeft)
+		test.That(t, box.Max.X, test.ShouldBeBetweenOrEqual, markerLeft+7*cellPixels, markerLeft+7*cellPixels+2)
+
+		_, err = srv.Classifications(ctx, image.NewGray(image.Rect(0, 0, 10, 10)), 1, nil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "does not implement")
+	})
+
+	t.Run("poses", func(t *testing.T) {
+		poses, err := srv.MarkerPoses(ctx, "cam", nil)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, poses, test.ShouldHaveLength, 1)
+		test.That(t, poses[0].Parent(), test.ShouldEqual, "cam")
+		test.That(t, poses[0].Name(), test.ShouldEqual, "42")
+		test.That(t, spatialmath.PoseAlmostEqualEps(poses[0].Pose(), expected, 1), test.ShouldBeTrue)
+
+		objects, err := srv.GetObjectPointClouds(ctx, "cam", nil)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, objects, test.ShouldHaveLength, 1)
+		test.That(t, objects[0].Geometry.Label(), test.ShouldEqual, "42")
+		test.That(t, spatialmath.PoseAlmostEqualEps(objects[0].Geometry.Pose(), expected, 1), test.ShouldBeTrue)
+
+		_, err = srv.MarkerPoses(ctx, "no_intrinsics_cam", nil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "intrinsic parameters")
+		_, err = srv.MarkerPoses(ctx, "missing_cam", nil)
+		test.That(t, err, test.ShouldNotBeNil)
+	})
+
+	t.Run("do command", func(t *testing.T) {
+		resp, err := srv.DoCommand(ctx, map[string]interface{}{"command": CommandMarkerPoses, "camera_name": "cam"})
+		test.That(t, err, test.ShouldBeNil)
+		poses := resp["poses"].([]interface{})
+		test.That(t, poses, test.ShouldHaveLength, 1)
+		pose := poses[0].(map[string]interface{})
+		test.That(t, pose["id"], test.ShouldEqual, "42")
+		test.That(t, pose["reference_frame"], test.ShouldEqual, "cam")
+		test.That(t, pose["pose"].(map[string]interface{})["z"], test.ShouldAlmostEqual, depth, 1)
+
+		_, err = srv.DoCommand(ctx, map[string]interface{}{"command": CommandMarkerPoses})
+		test.That(t, err.Error(), test.ShouldContainSubstring, "camera_name")
+		_, err = srv.DoCommand(ctx, map[string]interface{}{"command": "dance"})
+		test.That(t, err.Error(), test.ShouldContainSubstring, "no such command")
+	})
+}
+
+func TestFiducialDetectorConfig(t *testing.T) {
+	_, err := (&Config{MarkerSizeMM: 100}).Validate("path")
+	test.That(t, err, test.ShouldBeNil)
+	_, err = (&Config{}).Validate("path")
+	test.That(t, err.Error(), test.ShouldContainSubstring, "marker_size_mm")
+	_, err = (&Config{MarkerSizeMM: 100, Dictionary: "tag36h11"}).Validate("path")
+	test.That(t, err.Error(), test.ShouldContainSubstring, "tag36h11")
+	_, err = (&Config{MarkerSizeMM: 100, MarkerSizesMM: map[string]float64{"one": 10}}).Validate("path")
+	test.That(t, err.Error(), test.ShouldContainSubstring, "not a number")
+	custom := &fiducial.Dictionary{BitsPerSide: 3, Codes: []uint64{0b111_000_000}}
+	_, err = (&Config{MarkerSizeMM: 100, Dictionary: fiducial.ArucoOriginal, CustomDictionary: custom}).Validate("path")
+	test.That(t, err.Error(), test.ShouldContainSubstring, "both")
+	_, err = (&Config{MarkerSizeMM: 100, CustomDictionary: custom}).Validate("path")
+	test.That(t, err, test.ShouldBeNil)
+
+	_, err = registerFiducialDetector(context.Background(), vision.Named("test_fd"), nil, &inject.Robot{})
+	test.That(t, err.Error(), test.ShouldContainSubstring, "cannot be nil")
+}
@@ -0,0 +1,406 @@
+// Package objecttracker follows the detections a detector makes in the images of a camera over
+// time, giving each object an id that lasts while it is in view, and reporting when objects enter
+// or exit regions of the image.
+package objecttracker
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"sync"
+	"time"
+
+	"github.com/edaniels/golog"
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+	goutils "go.viam.com/utils"
+
+	"go.viam.com/rdk/components/camera"
+	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/robot"
+	"go.viam.com/rdk/services/vision"
+	"go.viam.com/rdk/utils"
+	viz "go.viam.com/rdk/vision"
+	"go.viam.com/rdk/vision/classification"
+	"go.viam.com/rdk/vision/objectdetection"
+	"go.viam.com/rdk/vision/objecttracking"
+)
+
+var model = resource.DefaultModelFamily.WithModel("object_tracker")
+
+// The commands of DoCommand.
+const (
+	CommandTracks = "get_tracks"
+	CommandEvents = "get_events"
+	CommandCounts = "get_counts"
+)
+
+const (
+	defaultFrequencyHz = 10
+	// maxEvents is how many events are kept for clients that have not asked for them yet.
+	maxEvents = 1000
+)
+
+func init() {
+	resource.RegisterService(vision.API, model, resource.Registration[vision.Service, *Config]{
+		DeprecatedRobotConstructor: func(ctx context.Context, r any, c resource.Config, logger golog.Logger) (vision.Service, error) {
+			attrs, err := resource.NativeConfig[*Config](c)
+			if err != nil {
+				return nil, err
+			}
+			actualR, err := utils.AssertType[robot.Robot](r)
+			if err != nil {
+				return nil, err
+			}
+			return registerObjectTracker(ctx, c.ResourceName(), attrs, actualR, logger)
+		},
+	})
+}
+
+// Config is the attribute struct for an object tracker.
+type Config struct {
+	CameraName   string `json:"camera_name"`
+	DetectorName string `json:"detector_name"`
+	// FrequencyHz is how often the camera's images are run through the detector.
+	FrequencyHz      float64 `json:"frequency_hz,omitempty"`
+	ConfidenceThresh float64 `json:"confidence_threshold,omitempty"`
+	MinIoU           float64 `json:"min_iou,omitempty"`
+	MaxMissedFrames  int     `json:"max_missed_frames,omitempty"`
+	MinHits          int     `json:"min_hits,omitempty"`
+	// Regions are the areas of the image that objects entering and exiting make events for.
+	Regions []RegionConfig `json:"regions,omitempty"`
+}
+
+// RegionConfig is a named rectangle of the camera's image, in pixels.
+type RegionConfig struct {
+	Name string `json:"name"`
+	XMin int    `json:"x_min"`
+	YMin int    `json:"y_min"`
+	XMax int    `json:"x_max"`
+	YMax int    `json:"y_max"`
+}
+
+// Validate ensures all parts of the config are valid.
+func (cfg *Config) Validate(path string) ([]string, error) {
+	if cfg.CameraName == "" {
+		return nil, goutils.NewConfigValidationFieldRequiredError(path, "camera_name")
+	}
+	if cfg.DetectorName == "" {
+		return nil, goutils.NewConfigValidationFieldRequiredError(path, "detector_name")
+	}
+	if cfg.FrequencyHz < 0 {
+		return nil, goutils.NewConfigValidationError(path, errors.New("frequency_hz cannot be negative"))
+	}
+	if cfg.ConfidenceThresh < 0 || cfg.ConfidenceThresh > 1 {
+		return nil, goutils.NewConfigValidationError(path, errors.New("confidence_threshold must be between 0 and 1"))
+	}
+	if cfg.MinIoU < 0 || cfg.MinIoU > 1 {
+		return nil, goutils.NewConfigValidationError(path, errors.New("min_iou must be between 0 and 1"))
+	}
+	if cfg.MaxMissedFrames < 0 {
+		return nil, goutils.NewConfigValidationError(path, errors.New("max_missed_frames cannot be negative"))
+	}
+	if cfg.MinHits < 0 {
+		return nil, goutils.NewConfigValidationError(path, errors.New("min_hits cannot be negative"))
+	}
+	names := map[string]bool{}
+	for _, r := range cfg.Regions {
+		if r.Name == "" {
+			return nil, goutils.NewConfigValidationError(path, errors.New("every region needs a name"))
+		}
+		if names[r.Name] {
+			return nil, goutils.NewConfigValidationError(path, errors.Errorf("more than one region is named %q", r.Name))
+		}
+		names[r.Name] = true
+		if r.XMax <= r.XMin || r.YMax <= r.YMin {
+			return nil, goutils.NewConfigValidationError(path, errors.Errorf("region %q is empty", r.Name))
+		}
+	}
+	return []string{cfg.CameraName, cfg.DetectorName}, nil
+}
+
+// Service is a vision service that also gives the tracks it follows, and the events of them entering
+// and exiting regions.
+type Service interface {
+	vision.Service
+	Tracks(ctx context.Context, extra map[string]interface{}) ([]objecttracking.Track, error)
+	// Events returns the events since the last time they were asked for.
+	Events(ctx context.Context, extra map[string]interface{}) ([]objecttracking.Event, error)
+	// Counts returns how many objects have entered each region.
+	Counts(ctx context.Context, extra map[string]interface{}) (map[string]int, error)
+}
+
+// objectTracker runs the images of a camera through a detector and tracks the detections.
+type objectTracker struct {
+	resource.Named
+	resource.AlwaysRebuild
+
+	cameraName       string
+	cam              camera.Camera
+	detector         vision.Service
+	confidenceThresh float64
+	logger           golog.Logger
+
+	mu      sync.Mutex
+	tracker *objecttracking.Tracker
+	tracks  []objecttracking.Track
+	events  []objecttracking.Event
+	counts  map[string]int
+
+	cancel                  func()
+	activeBackgroundWorkers sync.WaitGroup
+}
+
+// registerObjectTracker creates an object tracker from the config, and starts tracking.
+func registerObjectTracker(
+	ctx context.Context,
+	name resource.Name,
+	conf *Config,
+	r robot.Robot,
+	logger golog.Logger,
+) (Service, error) {
+	_, span := trace.StartSpan(ctx, "service::vision::registerObjectTracker")
+	defer span.End()
+	ot, err := newObjectTracker(name, conf, r, logger)
+	if err != nil {
+		return nil, err
+	}
+	frequency := conf.FrequencyHz
+	if frequency == 0 {
+		frequency = defaultFrequencyHz
+	}
+	cancelCtx, cancel := context.WithCancel(context.Background())
+	ot.cancel = cancel
+	ot.activeBackgroundWorkers.Add(1)
+	goutils.ManagedGo(func() {
+		ot.run(cancelCtx, time.Duration(float64(time.Second)/frequency))
+	}, ot.activeBackgroundWorkers.Done)
+	return ot, nil
+}
+
+// newObjectTracker creates an object tracker that does not track until it is run.
+func newObjectTracker(name resource.Name, conf *Config, r robot.Robot, logger golog.Logger) (*objectTracker, error) {
+	if conf == nil {
+		return nil, errors.New("config for object tracker cannot be nil")
+	}
+	cam, err := camera.FromRobot(r, conf.CameraName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not find necessary dependency, camera %q", conf.CameraName)
+	}
+	detector, err := vision.FromRobot(r, conf.DetectorName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not find necessary dependency, detector %q", conf.DetectorName)
+	}
+	opts := objecttracking.Options{MinIoU: conf.MinIoU, MaxMissed: conf.MaxMissedFrames, MinHits: conf.MinHits}
+	counts := map[string]int{}
+	for _, r := range conf.Regions {
+		opts.Regions = append(opts.Regions, objecttracking.Region{Name: r.Name, Bounds: image.Rect(r.XMin, r.YMin, r.XMax, r.YMax)})
+		counts[r.Name] = 0
+	}
+	return &objectTracker{
+		Named:            name.AsNamed(),
+		cameraName:       conf.CameraName,
+		cam:              cam,
+		detector:         detector,
+		confidenceThresh: conf.ConfidenceThresh,
+		logger:           logger,
+		tracker:          objecttracking.NewTracker(opts),
+		counts:           counts,
+		cancel:           func() {},
+	}, nil
+}
+
+// run tracks the images of the camera until it is canceled.
+func (ot *objectTracker) run(ctx context.Context, period time.Duration) {
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := ot.step(ctx, time.Now()); err != nil && ctx.Err() == nil {
+			ot.logger.Debugw("could not track the next image", "camera", ot.cameraName, "error", err)
+		}
+	}
+}
+
+// step detects the objects in the next image of the camera, taken at the given time, and tracks
+// them.
+func (ot *objectTracker) step(ctx context.Context, at time.Time) error {
+	img, release, err := camera.ReadImage(ctx, ot.cam)
+	if err != nil {
+		return errors.Wrapf(err, "could not get image from camera %q", ot.cameraName)
+	}
+	defer release()
+	detections, err := ot.detector.Detections(ctx, img, nil)
+	if err != nil {
+		return err
+	}
+	if ot.confidenceThresh > 0 {
+		detections = objectdetection.NewScoreFilter(ot.confidenceThresh)(detections)
+	}
+
+	ot.mu.Lock()
+	defer ot.mu.Unlock()
+	events := ot.tracker.Update(detections, at)
+	ot.tracks = ot.tracker.Tracks()
+	for _, e := range events {
+		if e.Type == objecttracking.EventEnter {
+			ot.counts[e.Region]++
+		}
+	}
+	ot.events = append(ot.events, events...)
+	if len(ot.events) > maxEvents {
+		ot.events = ot.events[len(ot.events)-maxEvents:]
+	}
+	return nil
+}
+
+// trackLabel is the label of the detection of a track, which has its id in it.
+func trackLabel(tr objecttracking.Track) string {
+	if tr.Label == "" {
+		return fmt.Sprint(tr.ID)
+	}
+	return fmt.Sprintf("%s_%d", tr.Label, tr.ID)
+}
+
+// DetectionsFromCamera returns the tracks that were detected in the latest image, labeled with
+// their ids, like "person_3".
+func (ot *objectTracker) DetectionsFromCamera(
+	ctx context.Context, cameraName string, extra map[string]interface{},
+) ([]objectdetection.Detection, error) {
+	if cameraName != ot.cameraName {
+		return nil, errors.Errorf("object tracker %q only tracks camera %q", ot.Name(), ot.cameraName)
+	}
+	tracks, err := ot.Tracks(ctx, extra)
+	if err != nil {
+		return nil, err
+	}
+	detections := make([]objectdetection.Detection, 0, len(tracks))
+	for _, tr := range tracks {
+		if tr.Missed == 0 {
+			detections = append(detections, objectdetection.NewDetection(tr.BoundingBox, tr.Score, trackLabel(tr)))
+		}
+	}
+	return detections, nil
+}
+
+// Detections cannot track a single image, since tracks come from the camera's images over time.
+func (ot *objectTracker) Detections(ctx context.Context, img image.Image, extra map[string]interface{},
+) ([]objectdetection.Detection, error) {
+	return nil, errors.Errorf("object tracker %q only tracks camera %q, use DetectionsFromCamera", ot.Name(), ot.cameraName)
+}
+
+func (ot *objectTracker) ClassificationsFromCamera(
+	ctx context.Context, cameraName string, n int, extra map[string]interface{},
+) (classification.Classifications, error) {
+	return nil, errors.Errorf("vision model %q does not implement a Classifier", ot.Name())
+}
+
+func (ot *objectTracker) Classifications(
+	ctx context.Context, img image.Image, n int, extra map[string]interface{},
+) (classification.Classifications, error) {
+	return nil, errors.Errorf("vision model %q does not implement a Classifier", ot.Name())
+}
+
+func (ot *objectTracker) GetObjectPointClouds(ctx context.Context, cameraName string, extra map[string]interface{},
+) ([]*viz.Object, error) {
+	return nil, errors.Errorf("vision model %q does not implement a 3D segmenter", ot.Name())
+}
+
+// Tracks returns the tracks being followed, including ones that were missed in the latest images.
+func (ot *objectTracker) Tracks(ctx context.Context, extra map[string]interface{}) ([]objecttracking.Track, error) {
+	ot.mu.Lock()
+	defer ot.mu.Unlock()
+	return append([]objecttracking.Track{}, ot.tracks...), nil
+}
+
+// Events returns the events since the last time they were asked for.
+func (ot *objectTracker) Events(ctx context.Context, extra map[string]interface{}) ([]objecttracking.Event, error) {
+	ot.mu.Lock()
+	defer ot.mu.Unlock()
+	events := ot.events
+	ot.events = nil
+	return events, nil
+}
+
+// Counts returns how many objects have entered each region.
+func (ot *objectTracker) Counts(ctx context.Context, extra map[string]interface{}) (map[string]int, error) {
+	ot.mu.Lock()
+	defer ot.mu.Unlock()
+	counts := make(map[string]int, len(ot.counts))
+	for region, count := range ot.counts {
+		counts[region] = count
+	}
+	return counts, nil
+}
+
+// DoCommand returns the tracks, events or counts for clients without the Go API.
+func (ot *objectTracker) DoCommand(ctx context.Context, cmd map[string]interface{}) (map[string]interface{}, error) {
+	name, ok := cmd["command"]
+	if !ok {
+		return nil, errors.New("missing 'command' value")
+	}
+	switch name {
+	case CommandTracks:
+		tracks, err := ot.Tracks(ctx, nil)
+		if err != nil {
+			return nil, err
+		}
+		resp := make([]interface{}, 0, len(tracks))
+		for _, tr := range tracks {
+			resp = append(resp, map[string]interface{}{
+				"id":         tr.ID,
+				"label":      tr.Label,
+				"score":      tr.Score,
+				"x_min":      tr.BoundingBox.Min.X,
+				"y_min":      tr.BoundingBox.Min.Y,
+				"x_max":      tr.BoundingBox.Max.X,
+				"y_max":      tr.BoundingBox.Max.Y,
+				"velocity_x": tr.Velocity.X,
+				"velocity_y": tr.Velocity.Y,
+				"missed":     tr.Missed,
+				"first_seen": tr.FirstSeen.Format(time.RFC3339Nano),
+				"last_seen":  tr.LastSeen.Format(time.RFC3339Nano),
+			})
+		}
+		return map[string]interface{}{"tracks": resp}, nil
+	case CommandEvents:
+		events, err := ot.Events(ctx, nil)
+		if err != nil {
+			return nil, err
+		}
+		resp := make([]interface{}, 0, len(events))
+		for _, e := range events {
+			resp = append(resp, map[string]interface{}{
+				"type":     string(e.Type),
+				"region":   e.Region,
+				"track_id": e.TrackID,
+				"label":    e.Label,
+				"time":     e.Time.Format(time.RFC3339Nano),
+			})
+		}
+		return map[string]interface{}{"events": resp}, nil
+	case CommandCounts:
+		counts, err := ot.Counts(ctx, nil)
+		if err != nil {
+			return nil, err
+		}
+		resp := make(map[string]interface{}, len(counts))
+		for region, count := range counts {
+			resp[region] = count
+		}
+		return map[string]interface{}{"counts": resp}, nil
+	default:
+		return nil, errors.Errorf("no such command: %s", name)
+	}
+}
+
+// Close stops tracking.
+func (ot *objectTracker) Close(ctx context.Context) error {
+	ot.cancel()
+	ot.activeBackgroundWorkers.Wait()
+	return nil
+}
@@ -0,0 +1,169 @@
+package objecttracker
+
+import (
+	"context"
+	"image"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/edaniels/golog"
+	"go.viam.com/test"
+	"go.viam.com/utils/testutils"
+
+	"go.viam.com/rdk/components/camera"
+	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/services/vision"
+	"go.viam.com/rdk/testutils/inject"
+	"go.viam.com/rdk/vision/objectdetection"
+)
+
+type blankReader struct{}
+
+func (blankReader) Read(ctx context.Context) (image.Image, func(), error) {
+	return image.NewGray(image.Rect(0, 0, 640, 480)), func() {}, nil
+}
+
+func (blankReader) Close(ctx context.Context) error {
+	return nil
+}
+
+// conveyorDetector detects a part moving 10 pixels to the right in every image it is given.
+type conveyorDetector struct {
+	mu    sync.Mutex
+	frame int
+}
+
+func (cd *conveyorDetector) detections(ctx context.Context, img image.Image, extra map[string]interface{},
+) ([]objectdetection.Detection, error) {
+	cd.mu.Lock()
+	defer cd.mu.Unlock()
+	x := 10 * cd.frame
+	cd.frame++
+	return []objectdetection.Detection{
+		objectdetection.NewDetection(image.Rect(x, 100, x+50, 150), 0.9, "part"),
+		objectdetection.NewDetection(image.Rect(400, 300, 450, 350), 0.2, "part"),
+	}, nil
+}
+
+func setupRobot(t *testing.T) *inject.Robot {
+	t.Helper()
+	src, err := camera.NewVideoSourceFromReader(context.Background(), blankReader{}, nil, camera.ColorStream)
+	test.That(t, err, test.ShouldBeNil)
+	cam := camera.FromVideoSource(camera.Named("cam"), src)
+	detector := inject.NewVisionService("detector")
+	detector.DetectionsFunc = (&conveyorDetector{}).detections
+
+	r := &inject.Robot{}
+	r.ResourceByNameFunc = func(n resource.Name) (resource.Resource, error) {
+		switch n {
+		case camera.Named("cam"):
+			return cam, nil
+		case vision.Named("detector"):
+			return detector, nil
+		default:
+			return nil, resource.NewNotFoundError(n)
+		}
+	}
+	return r
+}
+
+func TestObjectTracker(t *testing.T) {
+	ctx := context.Background()
+	conf := &Config{
+		CameraName:       "cam",
+		DetectorName:     "detector",
+		ConfidenceThresh: 0.5,
+		Regions:          []RegionConfig{{Name: "bin", XMin: 200, YMin: 0, XMax: 300, YMax: 480}},
+	}
+	ot, err := newObjectTracker(vision.Named("tracker"), conf, setupRobot(t), golog.NewTestLogger(t))
+	test.That(t, err, test.ShouldBeNil)
+	// the part goes through the bin between frames 18 and 27, at 10 frames per second
+	start := time.Unix(1000, 0)
+	for frame := 0; frame < 30; frame++ {
+		test.That(t, ot.step(ctx, start.Add(time.Duration(frame)*100*time.Millisecond)), test.ShouldBeNil)
+	}
+
+	tracks, err := ot.Tracks(ctx, nil)
+	test.That(t, err, test.ShouldBeNil)
+	// the detection below the confidence threshold is not tracked
+	test.That(t, tracks, test.ShouldHaveLength, 1)
+	test.That(t, tracks[0].ID, test.ShouldEqual, 1)
+	test.That(t, tracks[0].Velocity.X, test.ShouldAlmostEqual, 100, 2)
+
+	detections, err := ot.DetectionsFromCamera(ctx, "cam", nil)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, detections, test.ShouldHaveLength, 1)
+	test.That(t, detections[0].Label(), test.ShouldEqual, "part_1")
+	_, err = ot.DetectionsFromCamera(ctx, "other_cam", nil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "only tracks camera")
+	_, err = ot.Detections(ctx, image.NewGray(image.Rect(0, 0, 10, 10)), nil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "DetectionsFromCamera")
+	_, err = ot.GetObjectPointClouds(ctx, "cam", nil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "does not implement")
+
+	resp, err := ot.DoCommand(ctx, map[string]interface{}{"command": CommandCounts})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, resp["counts"], test.ShouldResemble, map[string]interface{}{"bin": 1})
+
+	resp, err = ot.DoCommand(ctx, map[string]interface{}{"command": CommandEvents})
+	test.That(t, err, test.ShouldBeNil)
+	events := resp["events"].([]interface{})
+	test.That(t, events, test.ShouldHaveLength, 2)
+	test.That(t, events[0].(map[string]interface{})["type"], test.ShouldEqual, "enter")
+	test.That(t, events[1].(map[string]interface{})["type"], test.ShouldEqual, "exit")
+	test.That(t, events[1].(map[string]interface{})["track_id"], test.ShouldEqual, 1)
+	// events are only returned once
+	resp, err = ot.DoCommand(ctx, map[string]interface{}{"command": CommandEvents})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, resp["events"], test.ShouldBeEmpty)
+
+	resp, err = ot.DoCommand(ctx, map[string]interface{}{"command": CommandTracks})
+	test.That(t, err, test.ShouldBeNil)
+	track := resp["tracks"].([]interface{})[0].(map[string]interface{})
+	test.That(t, track["label"], test.ShouldEqual, "part")
+	test.That(t, track["velocity_x"], test.ShouldAlmostEqual, 100, 2)
+
+	_, err = ot.DoCommand(ctx, map[string]interface{}{"command": "dance"})
+	test.That(t, err.Error(), test.ShouldContainSubstring, "no such command")
+	test.That(t, ot.Close(ctx), test.ShouldBeNil)
+}
+
+func TestObjectTrackerRuns(t *testing.T) {
+	ctx := context.Background()
+	conf := &Config{CameraName: "cam", DetectorName: "detector", FrequencyHz: 100}
+	svc, err := registerObjectTracker(ctx, vision.Named("tracker"), conf, setupRobot(t), golog.NewTestLogger(t))
+	test.That(t, err, test.ShouldBeNil)
+	testutils.WaitForAssertion(t, func(tb testing.TB) {
+		tracks, err := svc.Tracks(ctx, nil)
+		test.That(tb, err, test.ShouldBeNil)
+		test.That(tb, tracks, test.ShouldNotBeEmpty)
+	})
+	test.That(t, svc.Close(ctx), test.ShouldBeNil)
+
+	_, err = registerObjectTracker(ctx, vision.Named("tracker"), &Config{CameraName: "cam", DetectorName: "nope"},
+		setupRobot(t), golog.NewTestLogger(t))
+	test.That(t, err.Error(), test.ShouldContainSubstring, "detector \"nope\"")
+}
+
+func TestObjectTrackerConfig(t *testing.T) {
+	conf := &Config{CameraName: "cam", DetectorName: "detector"}
+	deps, err := conf.Validate("path")
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, deps, test.ShouldResemble, []string{"cam", "detector"})
+
+	_, err = (&Config{DetectorName: "detector"}).Validate("path")
+	test.That(t, err.Error(), test.ShouldContainSubstring, "camera_name")
+	_, err = (&Config{CameraName: "cam"}).Validate("path")
+	test.That(t, err.Error(), test.ShouldContainSubstring, "detector_name")
+	conf.MinIoU = 2
+	_, err = conf.Validate("path")
+	test.That(t, err.Error(), test.ShouldContainSubstring, "min_iou")
+	conf.MinIoU = 0
+	conf.Regions = []RegionConfig{{Name: "a", XMax: 10, YMax: 10}, {Name: "a", XMax: 10, YMax: 10}}
+	_, err = conf.Validate("path")
+	test.That(t, err.Error(), test.ShouldContainSubstring, "more than one region")
+	conf.Regions = []RegionConfig{{Name: "a", XMin: 10, XMax: 10, YMax: 10}}
+	_, err = conf.Validate("path")
+	test.That(t, err.Error(), test.ShouldContainSubstring, "empty")
+}
@@ -5,7 +5,9 @@ import (
 	// for vision models.
 	_ "go.viam.com/rdk/services/vision/colordetector"
 	_ "go.viam.com/rdk/services/vision/detectionstosegments"
+	_ "go.viam.com/rdk/services/vision/fiducialdetector"
 	_ "go.viam.com/rdk/services/vision/mlvision"
+	_ "go.viam.com/rdk/services/vision/objecttracker"
 	_ "go.viam.com/rdk/services/vision/obstaclesdepth"
 	_ "go.viam.com/rdk/services/vision/obstaclesdistance"
 	_ "go.viam.com/rdk/services/vision/obstaclespointcloud"
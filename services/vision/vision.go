@@ -11,6 +11,7 @@ import (
 	servicepb "go.viam.com/api/service/vision/v1"
 
 	"go.viam.com/rdk/components/camera"
+	"go.viam.com/rdk/data"
 	"go.viam.com/rdk/resource"
 	"go.viam.com/rdk/robot"
 	viz "go.viam.com/rdk/vision"
@@ -26,6 +27,18 @@ func init() {
 		RPCServiceDesc:              &servicepb.VisionService_ServiceDesc,
 		RPCClient:                   NewClientFromConn,
 	})
+	data.RegisterCollector(data.MethodMetadata{
+		API:        API,
+		MethodName: detections.String(),
+	}, newDetectionsCollector)
+	data.RegisterCollector(data.MethodMetadata{
+		API:        API,
+		MethodName: classifications.String(),
+	}, newClassificationsCollector)
+	data.RegisterCollector(data.MethodMetadata{
+		API:        API,
+		MethodName: getObjectPointClouds.String(),
+	}, newGetObjectPointCloudsCollector)
 }
 
 // A Service that implements various computer vision algorithms like detection and segmentation.
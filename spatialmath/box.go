@@ -157,6 +157,9 @@ func (b *box) CollidesWith(g Geometry) (bool, error) {
 	if other, ok := g.(*point); ok {
 		return pointVsBoxCollision(other.position, b), nil
 	}
+	if other, ok := g.(*mesh); ok {
+		return other.CollidesWith(b)
+	}
 	return true, newCollisionTypeUnsupportedError(b, g)
 }
 
@@ -173,6 +176,9 @@ func (b *box) DistanceFrom(g Geometry) (float64, error) {
 	if other, ok := g.(*point); ok {
 		return pointVsBoxDistance(other.position, b), nil
 	}
+	if other, ok := g.(*mesh); ok {
+		return other.DistanceFrom(b)
+	}
 	return math.Inf(-1), newCollisionTypeUnsupportedError(b, g)
 }
 
@@ -189,6 +195,9 @@ func (b *box) EncompassedBy(g Geometry) (bool, error) {
 	if _, ok := g.(*point); ok {
 		return false, nil
 	}
+	if other, ok := g.(*mesh); ok {
+		return geometryInMesh(b, other)
+	}
 	return false, newCollisionTypeUnsupportedError(b, g)
 }
 
@@ -239,21 +248,25 @@ func (b *box) vertices() []r3.Vector {
 	return verts
 }
 
-// vertices returns the vertices defining the box.
+// toMesh returns the box as a mesh of the triangles which tile its exterior.
 func (b *box) toMesh() *mesh {
 	if b.mesh == nil {
-		m := &mesh{pose: b.pose}
-		triangles := make([]*triangle, 0, 12)
-		verts := b.vertices()
-		for _, tri := range boxTriangles {
-			triangles = append(triangles, newTriangle(verts[tri[0]], verts[tri[1]], verts[tri[2]]))
-		}
-		m.triangles = triangles
-		b.mesh = m
+		local := &box{pose: NewZeroPose(), halfSize: b.halfSize}
+		b.mesh = newMesh(b.pose, local.triangles(), b.label)
 	}
 	return b.mesh
 }
 
+// triangles returns the triangles which tile the box exterior.
+func (b *box) triangles() []*triangle {
+	triangles := make([]*triangle, 0, 12)
+	verts := b.vertices()
+	for _, tri := range boxTriangles {
+		triangles = append(triangles, newTriangle(verts[tri[0]], verts[tri[1]], verts[tri[2]]))
+	}
+	return triangles
+}
+
 // rotationMatrix returns the cached matrix if it exists, and generates it if not.
 func (b *box) rotationMatrix() *RotationMatrix {
 	b.once.Do(func() { b.rotMatrix = b.pose.Orientation().RotationMatrix() })
@@ -153,6 +153,9 @@ func (c *capsule) CollidesWith(g Geometry) (bool, error) {
 	if err != nil {
 		return true, err
 	}
+	if other, ok := g.(*mesh); ok {
+		return other.CollidesWith(c)
+	}
 	return dist <= CollisionBuffer, nil
 }
 
@@ -170,6 +173,9 @@ func (c *capsule) DistanceFrom(g Geometry) (float64, error) {
 	if other, ok := g.(*sphere); ok {
 		return capsuleVsSphereDistance(c, other), nil
 	}
+	if other, ok := g.(*mesh); ok {
+		return other.DistanceFrom(c)
+	}
 	return math.Inf(-1), newCollisionTypeUnsupportedError(c, g)
 }
 
@@ -186,6 +192,9 @@ func (c *capsule) EncompassedBy(g Geometry) (bool, error) {
 	if _, ok := g.(*point); ok {
 		return false, nil
 	}
+	if other, ok := g.(*mesh); ok {
+		return geometryInMesh(c, other)
+	}
 	return true, newCollisionTypeUnsupportedError(c, g)
 }
 
@@ -261,15 +270,12 @@ func capsuleVsBoxDistance(c *capsule, other *box) float64 {
 // IMPORTANT: meshes are not considered solid. A mesh is not guaranteed to represent an enclosed area. This will measure ONLY the distance
 // to the closest triangle in the mesh.
 func capsuleVsMeshDistance(c *capsule, other *mesh) float64 {
-	lowDist := math.Inf(1)
-	for _, t := range other.triangles {
-		// Measure distance to each mesh triangle
-		dist := capsuleVsTriangleDistance(c, t)
-		if dist < lowDist {
-			lowDist = dist
-		}
+	// Measure distance to each mesh triangle
+	dist, err := other.surfaceDistance(c, math.Inf(-1))
+	if err != nil {
+		return math.Inf(-1)
 	}
-	return lowDist
+	return dist
 }
 
 func capsuleVsTriangleDistance(c *capsule, other *triangle) float64 {
@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/golang/geo/r3"
+	"github.com/pkg/errors"
 	commonpb "go.viam.com/api/common/v1"
 )
 
@@ -37,6 +38,7 @@ const (
 	SphereType      = GeometryType("sphere")
 	CapsuleType     = GeometryType("capsule")
 	PointType       = GeometryType("point")
+	MeshType        = GeometryType("mesh")
 	CollisionBuffer = 1e-8 // objects must be separated by this many mm to not be in collision
 
 	// Point density corresponding to how many points per square mm.
@@ -58,6 +60,10 @@ type GeometryConfig struct {
 	// parameter used for defining a capsule's length
 	L float64 `json:"l"`
 
+	// parameters used for defining a mesh, which is loaded from an STL, PLY or OBJ file, and the number of mm per unit of that file
+	MeshFile  string  `json:"mesh_file,omitempty"`
+	MeshScale float64 `json:"mesh_scale,omitempty"`
+
 	// define an offset to position the geometry
 	TranslationOffset r3.Vector         `json:"translation,omitempty"`
 	OrientationOffset OrientationConfig `json:"orientation,omitempty"`
@@ -87,6 +93,14 @@ func NewGeometryConfig(gc Geometry) (*GeometryConfig, error) {
 	case *point:
 		config.Type = PointType
 		config.Label = gc.(*point).label
+	case *mesh:
+		if gc.(*mesh).fileName == "" {
+			return nil, errors.New("cannot create a config for a mesh which was not loaded from a file")
+		}
+		config.Type = MeshType
+		config.MeshFile = gc.(*mesh).fileName
+		config.MeshScale = gc.(*mesh).scale
+		config.Label = gc.(*mesh).label
 	default:
 		return nil, fmt.Errorf("%w %s", ErrGeometryTypeUnsupported, fmt.Sprintf("%T", gcType))
 	}
@@ -120,6 +134,12 @@ func (config *GeometryConfig) ParseConfig() (Geometry, error) {
 		return NewCapsule(offset, config.R, config.L, config.Label)
 	case PointType:
 		return NewPoint(offset.Point(), config.Label), nil
+	case MeshType:
+		scale := config.MeshScale
+		if scale == 0 {
+			scale = 1
+		}
+		return NewMeshFromFile(offset, config.MeshFile, scale, config.Label)
 	case UnknownType:
 		// no type specified, iterate through supported types and try to infer intent
 		boxDims := r3.Vector{X: config.X, Y: config.Y, Z: config.Z}
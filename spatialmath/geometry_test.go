@@ -39,6 +39,12 @@ func TestGeometrySerialization(t *testing.T) {
 		{"bad type", GeometryConfig{Type: "bad"}, false},
 		{"c", GeometryConfig{Type: "capsule", L: 4, R: 1, TranslationOffset: translation, OrientationOffset: orientation, Label: "c"}, true},
 		{"infer c", GeometryConfig{L: 4, R: 1, TranslationOffset: translation, OrientationOffset: orientation, Label: "infer c"}, true},
+		{
+			"mesh",
+			GeometryConfig{Type: "mesh", MeshFile: "data/cube.stl", MeshScale: 10, TranslationOffset: translation, Label: "mesh"},
+			true,
+		},
+		{"mesh missing file", GeometryConfig{Type: "mesh", MeshFile: "data/missing.stl"}, false},
 	}
 
 	pose := NewPoseFromPoint(r3.Vector{X: 1, Y: 1, Z: 1})
@@ -1,17 +1,364 @@
 package spatialmath
 
 import (
+	"encoding/json"
+	"fmt"
+	"math"
+
 	"github.com/golang/geo/r3"
+	"github.com/pkg/errors"
+	commonpb "go.viam.com/api/common/v1"
 )
 
 // This file incorporates work covered by the Brax project -- https://github.com/google/brax/blob/main/LICENSE.
 // Copyright 2021 The Brax Authors, which is licensed under the Apache License Version 2.0 (the “License”).
 // You may obtain a copy of the license at http://www.apache.org/licenses/LICENSE-2.0.
 
+// Rays cast to find whether a point is inside a closed mesh. They point in skewed directions so that they are unlikely to pass exactly
+// through an edge or vertex of a mesh, and a point is inside if most of them cross the mesh an odd number of times.
+var meshInsideRays = [3]r3.Vector{
+	{X: 0.5773, Y: 0.5779, Z: 0.5768},
+	{X: -0.6242, Y: 0.3118, Z: 0.7163},
+	{X: 0.2091, Y: -0.8364, Z: -0.5067},
+}
+
 // mesh is a collision geometry that represents a set of triangles that represent a mesh.
+//
+// A mesh is a surface, so it collides with the geometries which touch its triangles. A closed mesh, in which every edge is shared by
+// exactly two triangles, also encloses the volume inside it, so it collides with the geometries inside that volume too.
 type mesh struct {
 	pose      Pose
-	triangles []*triangle
+	triangles []*triangle // relative to the pose of the mesh
+	label     string
+
+	// the file the mesh was loaded from, if any, and the number of mm per unit of the file
+	fileName string
+	scale    float64
+
+	// These values are generated at geometry creation time and shared between transformed copies of the mesh, since they are relative
+	// to its pose
+	bvh    *bvhNode
+	closed bool
+}
+
+// NewMesh instantiates a new mesh Geometry from a set of vertices, in mm relative to the pose of the mesh, and the triangles between them,
+// which are each given as the indices of their three vertices. Triangles with no area are ignored.
+func NewMesh(pose Pose, vertices []r3.Vector, faces [][3]int, label string) (Geometry, error) {
+	triangles := make([]*triangle, 0, len(faces))
+	for _, face := range faces {
+		for _, idx := range face {
+			if idx < 0 || idx >= len(vertices) {
+				return nil, errors.Errorf("mesh face refers to vertex %d but there are only %d vertices", idx, len(vertices))
+			}
+		}
+		p0, p1, p2 := vertices[face[0]], vertices[face[1]], vertices[face[2]]
+		if p1.Sub(p0).Cross(p2.Sub(p0)).Norm() == 0 {
+			continue
+		}
+		triangles = append(triangles, newTriangle(p0, p1, p2))
+	}
+	if len(triangles) == 0 {
+		return nil, newBadGeometryDimensionsError(&mesh{})
+	}
+	return newMesh(pose, triangles, label), nil
+}
+
+// newMesh creates a mesh from triangles relative to its pose.
+func newMesh(pose Pose, triangles []*triangle, label string) *mesh {
+	return &mesh{pose: pose, triangles: triangles, label: label, bvh: newBVH(triangles), closed: trianglesAreClosed(triangles)}
+}
+
+// trianglesAreClosed returns whether every edge of a set of triangles is shared by exactly two of them.
+func trianglesAreClosed(triangles []*triangle) bool {
+	type edge struct{ a, b r3.Vector }
+	edges := map[edge]int{}
+	for _, t := range triangles {
+		for _, e := range [3][2]r3.Vector{{t.p0, t.p1}, {t.p1, t.p2}, {t.p2, t.p0}} {
+			a, b := e[0], e[1]
+			if b.X < a.X || (b.X == a.X && (b.Y < a.Y || (b.Y == a.Y && b.Z < a.Z))) {
+				a, b = b, a
+			}
+			edges[edge{a, b}]++
+		}
+	}
+	for _, count := range edges {
+		if count != 2 {
+			return false
+		}
+	}
+	return true
+}
+
+// String returns a human readable string that represents the mesh.
+func (m *mesh) String() string {
+	return fmt.Sprintf("Type: Mesh, Triangles: %d", len(m.triangles))
+}
+
+func (m *mesh) MarshalJSON() ([]byte, error) {
+	config, err := NewGeometryConfig(m)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(config)
+}
+
+// Label returns the label of this mesh.
+func (m *mesh) Label() string {
+	return m.label
+}
+
+// SetLabel sets the label of this mesh.
+func (m *mesh) SetLabel(label string) {
+	m.label = label
+}
+
+// Pose returns the pose of the mesh.
+func (m *mesh) Pose() Pose {
+	return m.pose
+}
+
+// AlmostEqual compares the mesh with another geometry and checks if they are equivalent.
+func (m *mesh) AlmostEqual(g Geometry) bool {
+	other, ok := g.(*mesh)
+	if !ok || len(m.triangles) != len(other.triangles) || !PoseAlmostEqual(m.pose, other.pose) {
+		return false
+	}
+	for i, t := range m.triangles {
+		o := other.triangles[i]
+		if !R3VectorAlmostEqual(t.p0, o.p0, 1e-8) || !R3VectorAlmostEqual(t.p1, o.p1, 1e-8) || !R3VectorAlmostEqual(t.p2, o.p2, 1e-8) {
+			return false
+		}
+	}
+	return true
+}
+
+// Transform premultiplies the mesh pose with a transform, allowing the mesh to be moved in space.
+func (m *mesh) Transform(toPremultiply Pose) Geometry {
+	transformed := *m
+	transformed.pose = Compose(toPremultiply, m.pose)
+	return &transformed
+}
+
+// ToProtobuf converts the mesh to a Geometry proto message. The API has no message for meshes, so the mesh is sent as the box which
+// bounds it, which is a conservative stand in for it.
+func (m *mesh) ToProtobuf() *commonpb.Geometry {
+	bounds := m.bvh.bounds
+	size := bounds.size()
+	return &commonpb.Geometry{
+		Center: PoseToProtobuf(Compose(m.pose, NewPoseFromPoint(bounds.center()))),
+		GeometryType: &commonpb.Geometry_Box{
+			Box: &commonpb.RectangularPrism{DimsMm: &commonpb.Vector3{X: size.X, Y: size.Y, Z: size.Z}},
+		},
+		Label: m.label,
+	}
+}
+
+// CollidesWith checks if the given mesh collides with the given geometry and returns true if it does.
+func (m *mesh) CollidesWith(g Geometry) (bool, error) {
+	dist, err := m.distanceFrom(g, CollisionBuffer)
+	if err != nil {
+		return true, err
+	}
+	return dist <= CollisionBuffer, nil
+}
+
+// DistanceFrom returns the distance between the mesh and the given geometry. Distances are measured between the triangles of the mesh
+// and the other geometry, so a geometry within a closed mesh is in collision with it, but its negative distance is only a lower bound on
+// the depth of the penetration.
+func (m *mesh) DistanceFrom(g Geometry) (float64, error) {
+	return m.distanceFrom(g, math.Inf(-1))
+}
+
+// EncompassedBy returns whether the mesh is completely within the given geometry.
+func (m *mesh) EncompassedBy(g Geometry) (bool, error) {
+	switch other := g.(type) {
+	case *box, *sphere, *capsule:
+		// the geometries are convex, so they contain the mesh if they contain all of its vertices
+		for _, t := range m.triangles {
+			for _, pt := range [3]r3.Vector{t.p0, t.p1, t.p2} {
+				if inside, err := NewPoint(Compose(m.pose, NewPoseFromPoint(pt)).Point(), "").CollidesWith(other); err != nil || !inside {
+					return false, err
+				}
+			}
+		}
+		return true, nil
+	case *mesh:
+		return geometryInMesh(m, other)
+	case *point:
+		return false, nil
+	default:
+		return false, newCollisionTypeUnsupportedError(m, g)
+	}
+}
+
+// ToPoints converts the mesh into points spread over the surfaces of its triangles. The argument determines how many points to place per
+// square mm, and if it is 0 defaultPointDensity is used.
+func (m *mesh) ToPoints(resolution float64) []r3.Vector {
+	if resolution <= 0 {
+		resolution = defaultPointDensity
+	}
+	spacing := 1 / math.Sqrt(resolution)
+	seen := map[r3.Vector]bool{}
+	var pts []r3.Vector
+	for _, t := range m.triangles {
+		longest := math.Max(t.p1.Sub(t.p0).Norm(), math.Max(t.p2.Sub(t.p1).Norm(), t.p0.Sub(t.p2).Norm()))
+		steps := int(math.Max(1, math.Ceil(longest/spacing)))
+		e0, e1 := t.p1.Sub(t.p0), t.p2.Sub(t.p0)
+		for i := 0; i <= steps; i++ {
+			for j := 0; i+j <= steps; j++ {
+				pt := t.p0.Add(e0.Mul(float64(i) / float64(steps))).Add(e1.Mul(float64(j) / float64(steps)))
+				if !seen[pt] {
+					seen[pt] = true
+					pts = append(pts, pt)
+				}
+			}
+		}
+	}
+	return transformPointsToPose(pts, m.pose)
+}
+
+// distanceFrom returns the distance between the mesh and a geometry, which may stop being refined once it is no more than stop.
+func (m *mesh) distanceFrom(g Geometry, stop float64) (float64, error) {
+	dist, err := m.surfaceDistance(g, stop)
+	if err != nil || dist <= CollisionBuffer {
+		return dist, err
+	}
+	// the geometries do not touch, but one may be within the other
+	inside, err := m.encloses(g)
+	if err == nil && !inside {
+		if other, ok := g.(*mesh); ok {
+			inside, err = other.encloses(m)
+		}
+	}
+	if err != nil || inside {
+		return -dist, err
+	}
+	return dist, nil
+}
+
+// surfaceDistance returns the distance between the triangles of the mesh and a geometry, which may stop being refined once it is no more
+// than stop. Geometries other than meshes are solid, so the distance to a triangle within one is not positive.
+func (m *mesh) surfaceDistance(g Geometry, stop float64) (float64, error) {
+	// measure in the frame of the mesh, so that its triangles and hierarchy can be used as they are
+	toMesh := PoseInverse(m.pose)
+	switch other := g.(type) {
+	case *point:
+		pt := Compose(toMesh, NewPoseFromPoint(other.position)).Point()
+		return m.bvh.minDistance(newAABB(pt), func(t *triangle) float64 {
+			return t.closestPointToPoint(pt).Sub(pt).Norm()
+		}, math.Inf(1), stop), nil
+	case *sphere:
+		center := Compose(toMesh, other.pose).Point()
+		return m.bvh.minDistance(newAABB(center).expand(other.radius), func(t *triangle) float64 {
+			return t.closestPointToPoint(center).Sub(center).Norm() - other.radius
+		}, math.Inf(1), stop), nil
+	case *capsule:
+		c, ok := other.Transform(toMesh).(*capsule)
+		if !ok {
+			return math.Inf(-1), newCollisionTypeUnsupportedError(m, g)
+		}
+		return m.bvh.minDistance(newAABB(c.segA, c.segB).expand(c.radius), func(t *triangle) float64 {
+			return capsuleVsTriangleDistance(c, t)
+		}, math.Inf(1), stop), nil
+	case *box:
+		b, ok := other.Transform(toMesh).(*box)
+		if !ok {
+			return math.Inf(-1), newCollisionTypeUnsupportedError(m, g)
+		}
+		boxTris := b.triangles()
+		return m.bvh.minDistance(newAABB(b.vertices()...), func(t *triangle) float64 {
+			return boxVsTriangleDistance(b, boxTris, t)
+		}, math.Inf(1), stop), nil
+	case *mesh:
+		// put the other mesh in the frame of this one
+		posed := newMesh(NewZeroPose(), other.Transform(toMesh).(*mesh).posedTriangles(), "")
+		return minPairDistance(m.bvh, posed.bvh, triangleVsTriangleDistance, math.Inf(1), stop), nil
+	default:
+		return math.Inf(-1), newCollisionTypeUnsupportedError(m, g)
+	}
+}
+
+// encloses returns whether a geometry, which must not touch the triangles of the mesh, is within the volume the mesh encloses. Only closed
+// meshes enclose anything. A mesh is tested by one of its vertices, so it must not have parts both inside and outside of this one.
+func (m *mesh) encloses(g Geometry) (bool, error) {
+	if !m.closed {
+		return false, nil
+	}
+	var pt r3.Vector
+	switch other := g.(type) {
+	case *point:
+		pt = other.position
+	case *box, *sphere, *capsule:
+		pt = other.Pose().Point()
+	case *mesh:
+		pt = Compose(other.pose, NewPoseFromPoint(other.triangles[0].p0)).Point()
+	default:
+		return false, newCollisionTypeUnsupportedError(m, g)
+	}
+	return m.containsPoint(Compose(PoseInverse(m.pose), NewPoseFromPoint(pt)).Point()), nil
+}
+
+// containsPoint returns whether a point, relative to the pose of a closed mesh, is within it.
+func (m *mesh) containsPoint(pt r3.Vector) bool {
+	inside := 0
+	for _, dir := range meshInsideRays {
+		if m.bvh.countRayHits(pt, dir)%2 == 1 {
+			inside++
+		}
+	}
+	return inside > len(meshInsideRays)/2
+}
+
+// posedTriangles returns the triangles of the mesh in the frame its pose is relative to.
+func (m *mesh) posedTriangles() []*triangle {
+	posed := make([]*triangle, 0, len(m.triangles))
+	for _, t := range m.triangles {
+		posed = append(posed, newTriangle(
+			Compose(m.pose, NewPoseFromPoint(t.p0)).Point(),
+			Compose(m.pose, NewPoseFromPoint(t.p1)).Point(),
+			Compose(m.pose, NewPoseFromPoint(t.p2)).Point(),
+		))
+	}
+	return posed
+}
+
+// geometryInMesh returns whether a geometry is completely within a closed mesh.
+func geometryInMesh(g Geometry, m *mesh) (bool, error) {
+	dist, err := m.surfaceDistance(g, CollisionBuffer)
+	if err != nil || dist <= CollisionBuffer {
+		return false, err
+	}
+	return m.encloses(g)
+}
+
+// boxVsTriangleDistance returns the distance between a solid box and a triangle, given the triangles of the box's surface.
+func boxVsTriangleDistance(b *box, boxTris []*triangle, t *triangle) float64 {
+	for _, pt := range [3]r3.Vector{t.p0, t.p1, t.p2} {
+		if pointVsBoxCollision(pt, b) {
+			return 0
+		}
+	}
+	best := math.Inf(1)
+	for _, bt := range boxTris {
+		best = math.Min(best, triangleVsTriangleDistance(bt, t))
+	}
+	return best
+}
+
+// triangleVsTriangleDistance returns the distance between two triangles. If they do not intersect the closest points between them lie
+// on an edge of one of them, and if they do an edge of one passes through the other.
+func triangleVsTriangleDistance(a, b *triangle) float64 {
+	best := math.Inf(1)
+	for _, pair := range [2][2]*triangle{{a, b}, {b, a}} {
+		t, other := pair[0], pair[1]
+		for _, edge := range [3][2]r3.Vector{{t.p0, t.p1}, {t.p1, t.p2}, {t.p2, t.p0}} {
+			segPt, triPt := closestPointsSegmentTriangle(edge[0], edge[1], other)
+			if d := segPt.Sub(triPt).Norm(); d < best {
+				best = d
+			}
+		}
+	}
+	return best
 }
 
 type triangle struct {
@@ -31,6 +378,36 @@ func newTriangle(p0, p1, p2 r3.Vector) *triangle {
 	}
 }
 
+func (t *triangle) bounds() aabb {
+	return newAABB(t.p0, t.p1, t.p2)
+}
+
+func (t *triangle) centroid() r3.Vector {
+	return t.p0.Add(t.p1).Add(t.p2).Mul(1. / 3)
+}
+
+// hitByRay returns whether a ray from origin in the direction dir passes through the triangle, using the Möller–Trumbore algorithm.
+func (t *triangle) hitByRay(origin, dir r3.Vector) bool {
+	e0 := t.p1.Sub(t.p0)
+	e1 := t.p2.Sub(t.p0)
+	h := dir.Cross(e1)
+	det := e0.Dot(h)
+	if math.Abs(det) < floatEpsilon {
+		return false // the ray is parallel to the triangle
+	}
+	s := origin.Sub(t.p0)
+	u := s.Dot(h) / det
+	if u < 0 || u > 1 {
+		return false
+	}
+	q := s.Cross(e0)
+	v := dir.Dot(q) / det
+	if v < 0 || u+v > 1 {
+		return false
+	}
+	return e1.Dot(q)/det > 0
+}
+
 // closestPointToCoplanarPoint takes a point, and returns the closest point on the triangle to the given point
 // The given point *MUST* be coplanar with the triangle. If it is known ahead of time that the point is coplanar, this is faster.
 func (t *triangle) closestPointToCoplanarPoint(pt r3.Vector) r3.Vector {
@@ -0,0 +1,208 @@
+package spatialmath
+
+import (
+	"math"
+	"sort"
+
+	"github.com/golang/geo/r3"
+)
+
+// The most triangles held by a leaf of a bounding volume hierarchy.
+const bvhLeafSize = 4
+
+// aabb is an axis aligned bounding box.
+type aabb struct {
+	min r3.Vector
+	max r3.Vector
+}
+
+// newAABB returns the smallest axis aligned box which contains all of the given points.
+func newAABB(pts ...r3.Vector) aabb {
+	bounds := aabb{
+		min: r3.Vector{X: math.Inf(1), Y: math.Inf(1), Z: math.Inf(1)},
+		max: r3.Vector{X: math.Inf(-1), Y: math.Inf(-1), Z: math.Inf(-1)},
+	}
+	for _, pt := range pts {
+		bounds = bounds.extend(pt)
+	}
+	return bounds
+}
+
+func (a aabb) extend(pt r3.Vector) aabb {
+	return aabb{
+		min: r3.Vector{X: math.Min(a.min.X, pt.X), Y: math.Min(a.min.Y, pt.Y), Z: math.Min(a.min.Z, pt.Z)},
+		max: r3.Vector{X: math.Max(a.max.X, pt.X), Y: math.Max(a.max.Y, pt.Y), Z: math.Max(a.max.Z, pt.Z)},
+	}
+}
+
+func (a aabb) union(b aabb) aabb {
+	return a.extend(b.min).extend(b.max)
+}
+
+// expand returns the box grown by a distance in every direction.
+func (a aabb) expand(dist float64) aabb {
+	offset := r3.Vector{X: dist, Y: dist, Z: dist}
+	return aabb{min: a.min.Sub(offset), max: a.max.Add(offset)}
+}
+
+func (a aabb) center() r3.Vector {
+	return a.min.Add(a.max).Mul(0.5)
+}
+
+func (a aabb) size() r3.Vector {
+	return a.max.Sub(a.min)
+}
+
+// distance returns the distance between two boxes, which is zero if they overlap.
+func (a aabb) distance(b aabb) float64 {
+	gap := r3.Vector{
+		X: math.Max(0, math.Max(a.min.X-b.max.X, b.min.X-a.max.X)),
+		Y: math.Max(0, math.Max(a.min.Y-b.max.Y, b.min.Y-a.max.Y)),
+		Z: math.Max(0, math.Max(a.min.Z-b.max.Z, b.min.Z-a.max.Z)),
+	}
+	return gap.Norm()
+}
+
+// hitByRay returns whether a ray from origin in the direction dir passes through the box.
+func (a aabb) hitByRay(origin, dir r3.Vector) bool {
+	tMin, tMax := 0., math.Inf(1)
+	for _, axis := range [3][4]float64{
+		{origin.X, dir.X, a.min.X, a.max.X},
+		{origin.Y, dir.Y, a.min.Y, a.max.Y},
+		{origin.Z, dir.Z, a.min.Z, a.max.Z},
+	} {
+		o, d, lo, hi := axis[0], axis[1], axis[2], axis[3]
+		if d == 0 {
+			if o < lo || o > hi {
+				return false
+			}
+			continue
+		}
+		t0, t1 := (lo-o)/d, (hi-o)/d
+		if t0 > t1 {
+			t0, t1 = t1, t0
+		}
+		tMin, tMax = math.Max(tMin, t0), math.Min(tMax, t1)
+		if tMin > tMax {
+			return false
+		}
+	}
+	return true
+}
+
+// bvhNode is a node of a bounding volume hierarchy of triangles, which lets queries skip every triangle whose node is too far away to
+// matter. Leaves hold triangles, and other nodes hold exactly two children.
+type bvhNode struct {
+	bounds    aabb
+	left      *bvhNode
+	right     *bvhNode
+	triangles []*triangle
+}
+
+// newBVH builds a bounding volume hierarchy over a set of triangles by recursively splitting them in half along the longest axis of
+// their centroids.
+func newBVH(triangles []*triangle) *bvhNode {
+	if len(triangles) == 0 {
+		return nil
+	}
+	node := &bvhNode{bounds: newAABB()}
+	centroids := newAABB()
+	for _, t := range triangles {
+		node.bounds = node.bounds.union(t.bounds())
+		centroids = centroids.extend(t.centroid())
+	}
+	if len(triangles) <= bvhLeafSize {
+		node.triangles = triangles
+		return node
+	}
+
+	size := centroids.size()
+	axis := func(v r3.Vector) float64 { return v.X }
+	if size.Y > size.X && size.Y >= size.Z {
+		axis = func(v r3.Vector) float64 { return v.Y }
+	} else if size.Z > size.X && size.Z > size.Y {
+		axis = func(v r3.Vector) float64 { return v.Z }
+	}
+	sorted := make([]*triangle, len(triangles))
+	copy(sorted, triangles)
+	sort.SliceStable(sorted, func(i, j int) bool { return axis(sorted[i].centroid()) < axis(sorted[j].centroid()) })
+	half := len(sorted) / 2
+	node.left = newBVH(sorted[:half])
+	node.right = newBVH(sorted[half:])
+	return node
+}
+
+func (n *bvhNode) isLeaf() bool {
+	return n.left == nil
+}
+
+// minDistance returns the smallest of best and the distances, given by dist, between the triangles under the node and a query which lies
+// within the given bounds. Nodes further from the bounds than the best distance so far are skipped, as is everything once the best
+// distance is no more than stop, so a query which only needs to know whether something is within a distance can return early.
+func (n *bvhNode) minDistance(query aabb, dist func(*triangle) float64, best, stop float64) float64 {
+	if n == nil || best <= stop || n.bounds.distance(query) >= best {
+		return best
+	}
+	if n.isLeaf() {
+		for _, t := range n.triangles {
+			if d := dist(t); d < best {
+				best = d
+				if best <= stop {
+					return best
+				}
+			}
+		}
+		return best
+	}
+	first, second := n.left, n.right
+	if second.bounds.distance(query) < first.bounds.distance(query) {
+		first, second = second, first
+	}
+	best = first.minDistance(query, dist, best, stop)
+	return second.minDistance(query, dist, best, stop)
+}
+
+// minPairDistance returns the smallest of best and the distances, given by dist, between the triangles under two nodes in the same frame,
+// skipping pairs of nodes as minDistance does.
+func minPairDistance(a, b *bvhNode, dist func(ta, tb *triangle) float64, best, stop float64) float64 {
+	if a == nil || b == nil || best <= stop || a.bounds.distance(b.bounds) >= best {
+		return best
+	}
+	if a.isLeaf() && b.isLeaf() {
+		for _, ta := range a.triangles {
+			for _, tb := range b.triangles {
+				if d := dist(ta, tb); d < best {
+					best = d
+					if best <= stop {
+						return best
+					}
+				}
+			}
+		}
+		return best
+	}
+	// descend into the larger of the nodes, so that both shrink together
+	if b.isLeaf() || (!a.isLeaf() && a.bounds.size().Norm2() >= b.bounds.size().Norm2()) {
+		best = minPairDistance(a.left, b, dist, best, stop)
+		return minPairDistance(a.right, b, dist, best, stop)
+	}
+	best = minPairDistance(a, b.left, dist, best, stop)
+	return minPairDistance(a, b.right, dist, best, stop)
+}
+
+// countRayHits returns the number of triangles under the node which a ray from origin in the direction dir passes through.
+func (n *bvhNode) countRayHits(origin, dir r3.Vector) int {
+	if n == nil || !n.bounds.hitByRay(origin, dir) {
+		return 0
+	}
+	if n.isLeaf() {
+		hits := 0
+		for _, t := range n.triangles {
+			if t.hitByRay(origin, dir) {
+				hits++
+			}
+		}
+		return hits
+	}
+	return n.left.countRayHits(origin, dir) + n.right.countRayHits(origin, dir)
+}
@@ -0,0 +1,387 @@
+package spatialmath
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/golang/geo/r3"
+	"github.com/pkg/errors"
+)
+
+// NewMeshFromFile instantiates a new mesh Geometry from an STL, PLY or OBJ file, whose format is given by its extension. The scale is the
+// number of mm per unit of the file, so a file in meters has a scale of 1000.
+func NewMeshFromFile(pose Pose, filename string, scale float64, label string) (Geometry, error) {
+	if scale <= 0 {
+		return nil, errors.Errorf("mesh scale must be positive, got %f", scale)
+	}
+	//nolint:gosec
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var vertices []r3.Vector
+	var faces [][3]int
+	switch ext := strings.ToLower(filepath.Ext(filename)); ext {
+	case ".stl":
+		vertices, faces, err = parseSTL(data)
+	case ".ply":
+		vertices, faces, err = parsePLY(data)
+	case ".obj":
+		vertices, faces, err = parseOBJ(data)
+	default:
+		return nil, errors.Errorf("unsupported mesh file extension %q, must be one of .stl, .ply or .obj", ext)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot parse mesh file %s", filename)
+	}
+	for i := range vertices {
+		vertices[i] = vertices[i].Mul(scale)
+	}
+	g, err := NewMesh(pose, vertices, faces, label)
+	if err != nil {
+		return nil, err
+	}
+	m := g.(*mesh)
+	m.fileName = filename
+	m.scale = scale
+	return m, nil
+}
+
+// fanTriangulate splits a convex polygon, given by the indices of its vertices in order, into triangles which share its first vertex.
+func fanTriangulate(polygon []int) ([][3]int, error) {
+	if len(polygon) < 3 {
+		return nil, errors.Errorf("face must have at least 3 vertices, got %d", len(polygon))
+	}
+	faces := make([][3]int, 0, len(polygon)-2)
+	for i := 1; i+1 < len(polygon); i++ {
+		faces = append(faces, [3]int{polygon[0], polygon[i], polygon[i+1]})
+	}
+	return faces, nil
+}
+
+// parseSTL reads a binary or ASCII STL file. STL files list the vertices of every triangle separately, so each triangle has its own three
+// vertices.
+func parseSTL(data []byte) ([]r3.Vector, [][3]int, error) {
+	// a binary file is an 80 byte header, a triangle count, then 50 bytes for each triangle. ASCII files may also begin with "solid", so
+	// the size is the more reliable test
+	if len(data) >= 84 {
+		count := binary.LittleEndian.Uint32(data[80:84])
+		if uint64(len(data)) == 84+50*uint64(count) {
+			return parseBinarySTL(data[84:], int(count))
+		}
+	}
+	if !bytes.HasPrefix(bytes.TrimSpace(data), []byte("solid")) {
+		return nil, nil, errors.New("STL file is neither binary nor ASCII")
+	}
+	return parseASCIISTL(data)
+}
+
+func parseBinarySTL(data []byte, count int) ([]r3.Vector, [][3]int, error) {
+	vertices := make([]r3.Vector, 0, 3*count)
+	faces := make([][3]int, 0, count)
+	for i := 0; i < count; i++ {
+		// each triangle is a normal, three vertices, then two bytes of attributes
+		record := data[50*i+12 : 50*i+48]
+		for j := 0; j < 3; j++ {
+			vertices = append(vertices, r3.Vector{
+				X: float64(math.Float32frombits(binary.LittleEndian.Uint32(record[12*j:]))),
+				Y: float64(math.Float32frombits(binary.LittleEndian.Uint32(record[12*j+4:]))),
+				Z: float64(math.Float32frombits(binary.LittleEndian.Uint32(record[12*j+8:]))),
+			})
+		}
+		faces = append(faces, [3]int{3 * i, 3*i + 1, 3*i + 2})
+	}
+	return vertices, faces, nil
+}
+
+func parseASCIISTL(data []byte) ([]r3.Vector, [][3]int, error) {
+	var vertices []r3.Vector
+	var faces [][3]int
+	var facet []int
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "facet":
+			facet = facet[:0]
+		case "vertex":
+			if len(fields) != 4 {
+				return nil, nil, errors.Errorf("STL vertex must have 3 coordinates: %q", scanner.Text())
+			}
+			pt, err := parseVector(fields[1:])
+			if err != nil {
+				return nil, nil, err
+			}
+			facet = append(facet, len(vertices))
+			vertices = append(vertices, pt)
+		case "endfacet":
+			if len(facet) != 3 {
+				return nil, nil, errors.Errorf("STL facet must have 3 vertices, got %d", len(facet))
+			}
+			faces = append(faces, [3]int{facet[0], facet[1], facet[2]})
+		}
+	}
+	return vertices, faces, scanner.Err()
+}
+
+// plyElement is an element declared in the header of a PLY file, such as its vertices or faces.
+type plyElement struct {
+	name       string
+	count      int
+	properties []plyProperty
+}
+
+// plyProperty is a property of a PLY element. A list property has a count of type countType followed by that many values.
+type plyProperty struct {
+	name      string
+	valueType string
+	countType string
+}
+
+// parsePLY reads an ASCII or binary PLY file.
+func parsePLY(data []byte) ([]r3.Vector, [][3]int, error) {
+	reader := bufio.NewReader(bytes.NewReader(data))
+	format := ""
+	var elements []*plyElement
+	// every value in the body takes at least a byte, so no list can have more values than there are bytes after the header
+	bodySize := len(data)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, nil, errors.New("PLY header has no end_header")
+		}
+		bodySize -= len(line)
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "format":
+			if len(fields) < 2 {
+				return nil, nil, errors.New("PLY format has no type")
+			}
+			format = fields[1]
+		case "element":
+			if len(fields) != 3 {
+				return nil, nil, errors.Errorf("bad PLY element: %q", line)
+			}
+			count, err := strconv.Atoi(fields[2])
+			if err != nil {
+				return nil, nil, err
+			}
+			if count < 0 {
+				return nil, nil, errors.Errorf("PLY element %q has negative count %d", fields[1], count)
+			}
+			elements = append(elements, &plyElement{name: fields[1], count: count})
+		case "property":
+			if len(elements) == 0 {
+				return nil, nil, errors.New("PLY property declared before any element")
+			}
+			element := elements[len(elements)-1]
+			switch {
+			case len(fields) == 5 && fields[1] == "list":
+				element.properties = append(element.properties, plyProperty{name: fields[4], valueType: fields[3], countType: fields[2]})
+			case len(fields) == 3:
+				element.properties = append(element.properties, plyProperty{name: fields[2], valueType: fields[1]})
+			default:
+				return nil, nil, errors.Errorf("bad PLY property: %q", line)
+			}
+		}
+		if fields[0] == "end_header" {
+			break
+		}
+	}
+
+	var read func(valueType string) (float64, error)
+	switch format {
+	case "ascii":
+		read = newPLYASCIIReader(reader)
+	case "binary_little_endian":
+		read = newPLYBinaryReader(reader, binary.LittleEndian)
+	case "binary_big_endian":
+		read = newPLYBinaryReader(reader, binary.BigEndian)
+	default:
+		return nil, nil, errors.Errorf("unsupported PLY format %q", format)
+	}
+
+	var vertices []r3.Vector
+	var faces [][3]int
+	for _, element := range elements {
+		for i := 0; i < element.count; i++ {
+			var pt r3.Vector
+			var polygon []int
+			for _, prop := range element.properties {
+				if prop.countType == "" {
+					value, err := read(prop.valueType)
+					if err != nil {
+						return nil, nil, err
+					}
+					switch prop.name {
+					case "x":
+						pt.X = value
+					case "y":
+						pt.Y = value
+					case "z":
+						pt.Z = value
+					}
+					continue
+				}
+				count, err := read(prop.countType)
+				if err != nil {
+					return nil, nil, err
+				}
+				if count < 0 || count > float64(bodySize) {
+					return nil, nil, errors.Errorf("PLY %s has bad list length %v", prop.name, count)
+				}
+				values := make([]int, 0, int(count))
+				for j := 0; j < int(count); j++ {
+					value, err := read(prop.valueType)
+					if err != nil {
+						return nil, nil, err
+					}
+					values = append(values, int(value))
+				}
+				if prop.name == "vertex_indices" || prop.name == "vertex_index" {
+					polygon = values
+				}
+			}
+			switch element.name {
+			case "vertex":
+				vertices = append(vertices, pt)
+			case "face":
+				polygonFaces, err := fanTriangulate(polygon)
+				if err != nil {
+					return nil, nil, errors.Wrap(err, "bad PLY face")
+				}
+				faces = append(faces, polygonFaces...)
+			}
+		}
+	}
+	return vertices, faces, nil
+}
+
+// newPLYASCIIReader returns a function which reads the next value of the body of an ASCII PLY file.
+func newPLYASCIIReader(r io.Reader) func(string) (float64, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Split(bufio.ScanWords)
+	return func(string) (float64, error) {
+		if !scanner.Scan() {
+			if err := scanner.Err(); err != nil {
+				return 0, err
+			}
+			return 0, io.ErrUnexpectedEOF
+		}
+		return strconv.ParseFloat(scanner.Text(), 64)
+	}
+}
+
+// newPLYBinaryReader returns a function which reads the next value, of a given PLY type, of the body of a binary PLY file.
+func newPLYBinaryReader(r io.Reader, order binary.ByteOrder) func(string) (float64, error) {
+	return func(valueType string) (float64, error) {
+		var err error
+		switch valueType {
+		case "char", "int8":
+			var v int8
+			err = binary.Read(r, order, &v)
+			return float64(v), err
+		case "uchar", "uint8":
+			var v uint8
+			err = binary.Read(r, order, &v)
+			return float64(v), err
+		case "short", "int16":
+			var v int16
+			err = binary.Read(r, order, &v)
+			return float64(v), err
+		case "ushort", "uint16":
+			var v uint16
+			err = binary.Read(r, order, &v)
+			return float64(v), err
+		case "int", "int32":
+			var v int32
+			err = binary.Read(r, order, &v)
+			return float64(v), err
+		case "uint", "uint32":
+			var v uint32
+			err = binary.Read(r, order, &v)
+			return float64(v), err
+		case "float", "float32":
+			var v float32
+			err = binary.Read(r, order, &v)
+			return float64(v), err
+		case "double", "float64":
+			var v float64
+			err = binary.Read(r, order, &v)
+			return v, err
+		default:
+			return 0, errors.Errorf("unsupported PLY property type %q", valueType)
+		}
+	}
+}
+
+// parseOBJ reads the vertices and faces of a Wavefront OBJ file, ignoring everything else in it.
+func parseOBJ(data []byte) ([]r3.Vector, [][3]int, error) {
+	var vertices []r3.Vector
+	var faces [][3]int
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "v":
+			if len(fields) < 4 {
+				return nil, nil, errors.Errorf("OBJ vertex must have 3 coordinates: %q", scanner.Text())
+			}
+			pt, err := parseVector(fields[1:4])
+			if err != nil {
+				return nil, nil, err
+			}
+			vertices = append(vertices, pt)
+		case "f":
+			polygon := make([]int, 0, len(fields)-1)
+			for _, field := range fields[1:] {
+				// a face vertex may also refer to texture coordinates and normals, as in "1/2/3"
+				idx, err := strconv.Atoi(strings.Split(field, "/")[0])
+				if err != nil {
+					return nil, nil, err
+				}
+				// indices start at 1, and negative indices count back from the most recent vertex
+				if idx < 0 {
+					idx += len(vertices)
+				} else {
+					idx--
+				}
+				polygon = append(polygon, idx)
+			}
+			polygonFaces, err := fanTriangulate(polygon)
+			if err != nil {
+				return nil, nil, errors.Wrap(err, "bad OBJ face")
+			}
+			faces = append(faces, polygonFaces...)
+		}
+	}
+	return vertices, faces, scanner.Err()
+}
+
+func parseVector(fields []string) (r3.Vector, error) {
+	var coords [3]float64
+	for i, field := range fields {
+		value, err := strconv.ParseFloat(field, 64)
+		if err != nil {
+			return r3.Vector{}, err
+		}
+		coords[i] = value
+	}
+	return r3.Vector{X: coords[0], Y: coords[1], Z: coords[2]}, nil
+}
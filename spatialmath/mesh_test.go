@@ -1,6 +1,11 @@
 package spatialmath
 
 import (
+	"encoding/binary"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/golang/geo/r3"
@@ -21,3 +26,191 @@ func TestClosestPoint(t *testing.T) {
 	test.That(t, cp3.ApproxEqual(qp1), test.ShouldBeTrue)
 	test.That(t, cp1.ApproxEqual(cp2), test.ShouldBeTrue)
 }
+
+func TestNewMeshFromFile(t *testing.T) {
+	expected := &box{pose: NewZeroPose(), halfSize: [3]float64{50, 50, 50}}
+	for _, filename := range []string{"data/cube.stl", "data/cube.ply", "data/cube.obj"} {
+		t.Run(filename, func(t *testing.T) {
+			g, err := NewMeshFromFile(NewZeroPose(), filename, 100, "cube")
+			test.That(t, err, test.ShouldBeNil)
+			m := g.(*mesh)
+			test.That(t, len(m.triangles), test.ShouldEqual, 12)
+			test.That(t, m.closed, test.ShouldBeTrue)
+			test.That(t, m.Label(), test.ShouldEqual, "cube")
+			for _, tri := range m.triangles {
+				for _, pt := range []r3.Vector{tri.p0, tri.p1, tri.p2} {
+					test.That(t, pointVsBoxDistance(pt, expected), test.ShouldAlmostEqual, 0)
+				}
+			}
+		})
+	}
+
+	// a binary STL of the same cube
+	ascii, err := NewMeshFromFile(NewZeroPose(), "data/cube.stl", 1, "")
+	test.That(t, err, test.ShouldBeNil)
+	triangles := ascii.(*mesh).triangles
+	data := make([]byte, 84, 84+50*len(triangles))
+	binary.LittleEndian.PutUint32(data[80:], uint32(len(triangles)))
+	for _, tri := range triangles {
+		record := make([]byte, 50)
+		for i, pt := range []r3.Vector{tri.p0, tri.p1, tri.p2} {
+			for j, coord := range []float64{pt.X, pt.Y, pt.Z} {
+				binary.LittleEndian.PutUint32(record[12+12*i+4*j:], math.Float32bits(float32(coord)))
+			}
+		}
+		data = append(data, record...)
+	}
+	filename := filepath.Join(t.TempDir(), "cube.stl")
+	test.That(t, os.WriteFile(filename, data, 0o600), test.ShouldBeNil)
+	binaryMesh, err := NewMeshFromFile(NewZeroPose(), filename, 1, "")
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, binaryMesh.AlmostEqual(ascii), test.ShouldBeTrue)
+
+	_, err = NewMeshFromFile(NewZeroPose(), "data/orientations.json", 1, "")
+	test.That(t, err, test.ShouldNotBeNil)
+	_, err = NewMeshFromFile(NewZeroPose(), "data/cube.stl", 0, "")
+	test.That(t, err, test.ShouldNotBeNil)
+
+	plyHeader := "ply\nformat ascii 1.0\nelement vertex 3\nproperty float x\nproperty float y\nproperty float z\n" +
+		"element face 1\nproperty list char int vertex_indices\nend_header\n0 0 0\n1 0 0\n0 1 0\n"
+	for name, contents := range map[string]string{
+		"negative_list.ply": plyHeader + "-1 0 1 2\n",
+		"long_list.ply":     plyHeader + "100 0 1 2\n",
+		"short_face.ply":    plyHeader + "2 0 1\n",
+		"short_face.obj":    "v 0 0 0\nv 1 0 0\nv 0 1 0\nf 1 2\n",
+	} {
+		filename := filepath.Join(t.TempDir(), name)
+		test.That(t, os.WriteFile(filename, []byte(contents), 0o600), test.ShouldBeNil)
+		_, err = NewMeshFromFile(NewZeroPose(), filename, 1, "")
+		test.That(t, err, test.ShouldNotBeNil)
+		if strings.HasPrefix(name, "short_face") {
+			test.That(t, err.Error(), test.ShouldContainSubstring, "face must have at least 3 vertices")
+		}
+	}
+}
+
+func TestNewMesh(t *testing.T) {
+	vertices := []r3.Vector{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}, {2, 0, 0}}
+	m, err := NewMesh(NewZeroPose(), vertices, [][3]int{{0, 1, 2}, {0, 1, 3}}, "")
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, len(m.(*mesh).triangles), test.ShouldEqual, 1) // the collinear triangle is skipped
+	test.That(t, m.(*mesh).closed, test.ShouldBeFalse)
+
+	_, err = NewMesh(NewZeroPose(), vertices, [][3]int{{0, 1, 4}}, "")
+	test.That(t, err, test.ShouldNotBeNil)
+	_, err = NewMesh(NewZeroPose(), vertices, [][3]int{{0, 1, 3}}, "")
+	test.That(t, err, test.ShouldNotBeNil)
+}
+
+func TestMeshCollisions(t *testing.T) {
+	pose := NewPose(r3.Vector{10, 20, 30}, &OrientationVectorDegrees{OX: 1, OY: 1, OZ: 1, Theta: 30})
+	cube, err := NewMeshFromFile(pose, "data/cube.obj", 100, "")
+	test.That(t, err, test.ShouldBeNil)
+	equivalent, err := NewBox(pose, r3.Vector{100, 100, 100}, "")
+	test.That(t, err, test.ShouldBeNil)
+
+	// place geometries relative to the cube
+	at := func(x, y, z float64) Pose { return Compose(pose, NewPoseFromPoint(r3.Vector{x, y, z})) }
+	mustGeometry := func(g Geometry, err error) Geometry {
+		test.That(t, err, test.ShouldBeNil)
+		return g
+	}
+	openSquare := mustGeometry(NewMesh(at(0, 0, 100), []r3.Vector{{-10, -10, 0}, {10, -10, 0}, {10, 10, 0}, {-10, 10, 0}},
+		[][3]int{{0, 1, 2}, {0, 2, 3}}, ""))
+
+	testCases := []struct {
+		name     string
+		other    Geometry
+		collides bool
+		distance float64 // only checked for geometries which do not collide
+	}{
+		{"point inside", NewPoint(at(10, 0, 0).Point(), ""), true, 0},
+		{"point outside", NewPoint(at(0, 0, 60).Point(), ""), false, 10},
+		{"sphere inside", mustGeometry(NewSphere(at(0, 0, 0), 10, "")), true, 0},
+		{"sphere touching", mustGeometry(NewSphere(at(0, 0, 59), 10, "")), true, 0},
+		{"sphere outside", mustGeometry(NewSphere(at(0, 0, 70), 10, "")), false, 10},
+		{"capsule through", mustGeometry(NewCapsule(at(0, 0, 50), 5, 60, "")), true, 0},
+		{"capsule outside", mustGeometry(NewCapsule(at(80, 0, 0), 5, 20, "")), false, 25},
+		{"box overlapping", mustGeometry(NewBox(at(60, 0, 0), r3.Vector{30, 30, 30}, "")), true, 0},
+		{"box inside", mustGeometry(NewBox(at(0, 0, 0), r3.Vector{10, 10, 10}, "")), true, 0},
+		{"box outside", mustGeometry(NewBox(at(0, 80, 0), r3.Vector{20, 20, 20}, "")), false, 20},
+		{"box around", mustGeometry(NewBox(at(0, 0, 0), r3.Vector{200, 200, 200}, "")), true, 0},
+		{"mesh overlapping", cube.Transform(NewPoseFromPoint(r3.Vector{50, 0, 0})), true, 0},
+		{"open mesh outside", openSquare, false, 50},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			collides, err := cube.CollidesWith(tc.other)
+			test.That(t, err, test.ShouldBeNil)
+			test.That(t, collides, test.ShouldEqual, tc.collides)
+			reverse, err := tc.other.CollidesWith(cube)
+			test.That(t, err, test.ShouldBeNil)
+			test.That(t, reverse, test.ShouldEqual, tc.collides)
+
+			dist, err := cube.DistanceFrom(tc.other)
+			test.That(t, err, test.ShouldBeNil)
+			reverseDist, err := tc.other.DistanceFrom(cube)
+			test.That(t, err, test.ShouldBeNil)
+			test.That(t, reverseDist, test.ShouldAlmostEqual, dist)
+			if tc.collides {
+				test.That(t, dist, test.ShouldBeLessThanOrEqualTo, CollisionBuffer)
+				return
+			}
+			test.That(t, dist, test.ShouldAlmostEqual, tc.distance, 1e-6)
+			// distances from boxes to points and spheres are exact, so should match those from the mesh of a box
+			switch tc.other.(type) {
+			case *point, *sphere:
+				boxDist, err := equivalent.DistanceFrom(tc.other)
+				test.That(t, err, test.ShouldBeNil)
+				test.That(t, dist, test.ShouldAlmostEqual, boxDist, 1e-6)
+			}
+		})
+	}
+}
+
+func TestMeshEncompassed(t *testing.T) {
+	cube, err := NewMeshFromFile(NewZeroPose(), "data/cube.ply", 100, "")
+	test.That(t, err, test.ShouldBeNil)
+	small, err := NewBox(NewZeroPose(), r3.Vector{20, 20, 20}, "")
+	test.That(t, err, test.ShouldBeNil)
+	large, err := NewSphere(NewZeroPose(), 100, "")
+	test.That(t, err, test.ShouldBeNil)
+
+	encompassed, err := small.EncompassedBy(cube)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, encompassed, test.ShouldBeTrue)
+	encompassed, err = small.Transform(NewPoseFromPoint(r3.Vector{45, 0, 0})).EncompassedBy(cube)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, encompassed, test.ShouldBeFalse)
+	encompassed, err = NewPoint(r3.Vector{0, 0, 49}, "").EncompassedBy(cube)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, encompassed, test.ShouldBeTrue)
+
+	encompassed, err = cube.EncompassedBy(large)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, encompassed, test.ShouldBeTrue)
+	encompassed, err = cube.EncompassedBy(small)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, encompassed, test.ShouldBeFalse)
+}
+
+func TestMeshToPointsAndProtobuf(t *testing.T) {
+	pose := NewPoseFromPoint(r3.Vector{0, 0, 100})
+	cube, err := NewMeshFromFile(pose, "data/cube.stl", 100, "cube")
+	test.That(t, err, test.ShouldBeNil)
+	equivalent, err := NewBox(pose, r3.Vector{100, 100, 100}, "")
+	test.That(t, err, test.ShouldBeNil)
+
+	pts := cube.ToPoints(0.01)
+	test.That(t, len(pts), test.ShouldBeGreaterThan, 8)
+	for _, pt := range pts {
+		test.That(t, math.Abs(pointVsBoxDistance(pt, equivalent.(*box))), test.ShouldBeLessThan, 1e-6)
+	}
+
+	// the API has no message for meshes, so they are sent as their bounding boxes
+	proto := cube.ToProtobuf()
+	test.That(t, proto.Label, test.ShouldEqual, "cube")
+	fromProto, err := NewGeometryFromProto(proto)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, fromProto.AlmostEqual(equivalent), test.ShouldBeTrue)
+}
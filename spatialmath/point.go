@@ -90,6 +90,9 @@ func (pt *point) CollidesWith(g Geometry) (bool, error) {
 	if other, ok := g.(*point); ok {
 		return pt.AlmostEqual(other), nil
 	}
+	if other, ok := g.(*mesh); ok {
+		return other.CollidesWith(pt)
+	}
 	return true, newCollisionTypeUnsupportedError(pt, g)
 }
 
@@ -107,11 +110,17 @@ func (pt *point) DistanceFrom(g Geometry) (float64, error) {
 	if other, ok := g.(*point); ok {
 		return pt.position.Sub(other.position).Norm(), nil
 	}
+	if other, ok := g.(*mesh); ok {
+		return other.DistanceFrom(pt)
+	}
 	return math.Inf(-1), newCollisionTypeUnsupportedError(pt, g)
 }
 
 // EncompassedBy returns a bool describing if the given point is completely encompassed by the given geometry.
 func (pt *point) EncompassedBy(g Geometry) (bool, error) {
+	if other, ok := g.(*mesh); ok {
+		return geometryInMesh(pt, other)
+	}
 	return pt.CollidesWith(g)
 }
 
@@ -102,6 +102,9 @@ func (s *sphere) CollidesWith(g Geometry) (bool, error) {
 	if other, ok := g.(*point); ok {
 		return sphereVsPointDistance(s, other.position) <= CollisionBuffer, nil
 	}
+	if other, ok := g.(*mesh); ok {
+		return other.CollidesWith(s)
+	}
 	return true, newCollisionTypeUnsupportedError(s, g)
 }
 
@@ -118,6 +121,9 @@ func (s *sphere) DistanceFrom(g Geometry) (float64, error) {
 	if other, ok := g.(*point); ok {
 		return sphereVsPointDistance(s, other.position), nil
 	}
+	if other, ok := g.(*mesh); ok {
+		return other.DistanceFrom(s)
+	}
 	return math.Inf(-1), newCollisionTypeUnsupportedError(s, g)
 }
 
@@ -134,6 +140,9 @@ func (s *sphere) EncompassedBy(g Geometry) (bool, error) {
 	if _, ok := g.(*point); ok {
 		return false, nil
 	}
+	if other, ok := g.(*mesh); ok {
+		return geometryInMesh(s, other)
+	}
 	return true, newCollisionTypeUnsupportedError(s, g)
 }
 
@@ -31,7 +31,8 @@ type FrameSystemService struct {
 		ctx context.Context,
 		additionalTransforms []*referenceframe.LinkInFrame,
 	) (referenceframe.FrameSystem, error)
-	DoCommandFunc func(
+	FrameSystemConfigFunc func(ctx context.Context) (*framesystem.Config, error)
+	DoCommandFunc         func(
 		ctx context.Context,
 		cmd map[string]interface{},
 	) (map[string]interface{}, error)
@@ -98,6 +99,14 @@ func (fs *FrameSystemService) FrameSystem(
 	return fs.FrameSystemFunc(ctx, additionalTransforms)
 }
 
+// FrameSystemConfig calls the injected method or the real variant.
+func (fs *FrameSystemService) FrameSystemConfig(ctx context.Context) (*framesystem.Config, error) {
+	if fs.FrameSystemConfigFunc == nil {
+		return fs.Service.FrameSystemConfig(ctx)
+	}
+	return fs.FrameSystemConfigFunc(ctx)
+}
+
 // DoCommand calls the injected DoCommand or the real variant.
 func (fs *FrameSystemService) DoCommand(ctx context.Context,
 	cmd map[string]interface{},
@@ -0,0 +1,130 @@
+package fiducial
+
+import (
+	"math/bits"
+
+	"github.com/pkg/errors"
+
+	"go.viam.com/rdk/utils"
+)
+
+// ArucoOriginal is the name of the dictionary of the original ArUco library, with 1024 markers of
+// 5x5 bits. A few of its markers, like 1023, look the same turned half way around, so which of
+// their corners is the top left one is ambiguous.
+const ArucoOriginal = "aruco_original"
+
+// maxBitsPerSide keeps the bits of a marker within a uint64.
+const maxBitsPerSide = 8
+
+// Dictionary is a family of square markers. Every marker is a grid of bits surrounded by a black
+// border one bit wide, and is told apart from the others by its code.
+type Dictionary struct {
+	Name string `json:"name,omitempty"`
+	// BitsPerSide is the number of bits along a side of a marker, not counting its border.
+	BitsPerSide int `json:"bits_per_side"`
+	// Codes are the bits of each marker, with the id of the marker being its index. The bits are in
+	// row major order starting from the top left, with the first bit being the most significant one.
+	// White bits are 1 and black bits are 0.
+	Codes []uint64 `json:"codes"`
+	// MaxCorrectionBits is how many wrongly read bits are corrected. It has to be less than half the
+	// Hamming distance between any two codes in any of their rotations.
+	MaxCorrectionBits int `json:"max_correction_bits,omitempty"`
+}
+
+// Validate makes sure the dictionary can be used to detect markers.
+func (d *Dictionary) Validate() error {
+	if d.BitsPerSide < 2 || d.BitsPerSide > maxBitsPerSide {
+		return errors.Errorf("bits_per_side must be between 2 and %d, got %d", maxBitsPerSide, d.BitsPerSide)
+	}
+	if len(d.Codes) == 0 {
+		return errors.New("a dictionary needs at least one code")
+	}
+	numBits := d.BitsPerSide * d.BitsPerSide
+	for id, code := range d.Codes {
+		if numBits < 64 && code>>numBits != 0 {
+			return errors.Errorf("code of marker %d has more than %d bits", id, numBits)
+		}
+	}
+	if d.MaxCorrectionBits < 0 {
+		return errors.New("max_correction_bits cannot be negative")
+	}
+	if distance := d.minDistance(); d.MaxCorrectionBits > 0 && 2*d.MaxCorrectionBits >= distance {
+		return errors.Errorf("max_correction_bits is %d, but some codes are only %d bits apart", d.MaxCorrectionBits, distance)
+	}
+	return nil
+}
+
+// DictionaryByName returns one of the built in dictionaries.
+func DictionaryByName(name string) (*Dictionary, error) {
+	switch name {
+	case ArucoOriginal:
+		return arucoOriginal(), nil
+	default:
+		return nil, errors.Errorf("no built in dictionary named %q", name)
+	}
+}
+
+// arucoOriginal generates the dictionary of the original ArUco library. Each of the 5 rows of a
+// marker holds 2 bits of its id, encoded as one of 4 words.
+func arucoOriginal() *Dictionary {
+	words := []uint64{0x10, 0x17, 0x09, 0x0e}
+	codes := make([]uint64, 1024)
+	for id := range codes {
+		var code uint64
+		for row := 0; row < 5; row++ {
+			code = code<<5 | words[(id>>(2*(4-row)))&3]
+		}
+		codes[id] = code
+	}
+	return &Dictionary{Name: ArucoOriginal, BitsPerSide: 5, Codes: codes}
+}
+
+// rotateCode turns the bits of a marker a quarter turn clockwise.
+func rotateCode(code uint64, bitsPerSide int) uint64 {
+	var rotated uint64
+	for row := 0; row < bitsPerSide; row++ {
+		for col := 0; col < bitsPerSide; col++ {
+			// the bit in the top row moves to the right column, and so on
+			bit := code >> (bitsPerSide*bitsPerSide - 1 - (row*bitsPerSide + col)) & 1
+			newRow, newCol := col, bitsPerSide-1-row
+			rotated |= bit << (bitsPerSide*bitsPerSide - 1 - (newRow*bitsPerSide + newCol))
+		}
+	}
+	return rotated
+}
+
+// minDistance is the smallest Hamming distance between two codes, in any of their rotations, or
+// between a code and its own rotations.
+func (d *Dictionary) minDistance() int {
+	distance := d.BitsPerSide * d.BitsPerSide
+	for i, code := range d.Codes {
+		rotated := code
+		for r := 0; r < 4; r++ {
+			if r > 0 {
+				distance = utils.MinInt(distance, bits.OnesCount64(rotated^code))
+			}
+			for _, other := range d.Codes[i+1:] {
+				distance = utils.MinInt(distance, bits.OnesCount64(rotated^other))
+			}
+			rotated = rotateCode(rotated, d.BitsPerSide)
+		}
+	}
+	return distance
+}
+
+// match finds the marker the bits read from an image are closest to. It returns the id of the
+// marker, how many quarter turns clockwise the bits have to be turned to match it, and whether
+// any marker was close enough.
+func (d *Dictionary) match(code uint64) (int, int, bool) {
+	bestID, bestTurns, bestDistance := 0, 0, d.MaxCorrectionBits+1
+	rotated := code
+	for turns := 0; turns < 4; turns++ {
+		for id, c := range d.Codes {
+			if distance := bits.OnesCount64(rotated ^ c); distance < bestDistance {
+				bestID, bestTurns, bestDistance = id, turns, distance
+			}
+		}
+		rotated = rotateCode(rotated, d.BitsPerSide)
+	}
+	return bestID, bestTurns, bestDistance <= d.MaxCorrectionBits
+}
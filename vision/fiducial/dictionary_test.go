@@ -0,0 +1,68 @@
+package fiducial
+
+import (
+	"testing"
+
+	"go.viam.com/test"
+)
+
+func TestArucoOriginal(t *testing.T) {
+	dict, err := DictionaryByName(ArucoOriginal)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, dict.Validate(), test.ShouldBeNil)
+	test.That(t, dict.Codes, test.ShouldHaveLength, 1024)
+	// every row of marker 0 is the first word, and every row of marker 1023 the last one
+	test.That(t, dict.Codes[0], test.ShouldEqual, uint64(0b10000_10000_10000_10000_10000))
+	test.That(t, dict.Codes[1023], test.ShouldEqual, uint64(0b01110_01110_01110_01110_01110))
+	test.That(t, dict.Codes[0b01_10_11_00_01], test.ShouldEqual, uint64(0b10111_01001_01110_10000_10111))
+
+	_, err = DictionaryByName("tag36h11")
+	test.That(t, err.Error(), test.ShouldContainSubstring, "tag36h11")
+}
+
+func TestRotateCode(t *testing.T) {
+	// the top row turns into the right column
+	test.That(t, rotateCode(0b111_000_000, 3), test.ShouldEqual, uint64(0b001_001_001))
+	test.That(t, rotateCode(0b100_000_000, 3), test.ShouldEqual, uint64(0b001_000_000))
+	code := uint64(0b1011_0010_1110_0001)
+	rotated := code
+	for i := 0; i < 4; i++ {
+		rotated = rotateCode(rotated, 4)
+	}
+	test.That(t, rotated, test.ShouldEqual, code)
+}
+
+func TestDictionaryMatch(t *testing.T) {
+	dict := &Dictionary{
+		BitsPerSide:       4,
+		Codes:             []uint64{0b1011_0010_1110_0001, 0b0100_0111_1001_1100},
+		MaxCorrectionBits: 1,
+	}
+	test.That(t, dict.Validate(), test.ShouldBeNil)
+
+	id, turns, ok := dict.match(dict.Codes[1])
+	test.That(t, ok, test.ShouldBeTrue)
+	test.That(t, id, test.ShouldEqual, 1)
+	test.That(t, turns, test.ShouldEqual, 0)
+
+	// read turned a quarter counter clockwise, and with a wrong bit
+	code := rotateCode(rotateCode(rotateCode(dict.Codes[0], 4), 4), 4) ^ 0b0000_0100_0000_0000
+	id, turns, ok = dict.match(code)
+	test.That(t, ok, test.ShouldBeTrue)
+	test.That(t, id, test.ShouldEqual, 0)
+	test.That(t, turns, test.ShouldEqual, 1)
+
+	_, _, ok = dict.match(dict.Codes[0] ^ 0b1100_0000_0000_0000)
+	test.That(t, ok, test.ShouldBeFalse)
+}
+
+func TestDictionaryValidate(t *testing.T) {
+	dict := &Dictionary{BitsPerSide: 1, Codes: []uint64{1}}
+	test.That(t, dict.Validate().Error(), test.ShouldContainSubstring, "bits_per_side")
+	dict = &Dictionary{BitsPerSide: 3}
+	test.That(t, dict.Validate().Error(), test.ShouldContainSubstring, "at least one code")
+	dict = &Dictionary{BitsPerSide: 3, Codes: []uint64{1 << 9}}
+	test.That(t, dict.Validate().Error(), test.ShouldContainSubstring, "more than 9 bits")
+	dict = &Dictionary{BitsPerSide: 3, Codes: []uint64{0b111_000_000, 0b000_000_111}, MaxCorrectionBits: 2}
+	test.That(t, dict.Validate().Error(), test.ShouldContainSubstring, "only 0 bits apart")
+}
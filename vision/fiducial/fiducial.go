@@ -0,0 +1,167 @@
+// Package fiducial detects square fiducial markers, like ArUco markers, in images and estimates
+// their poses.
+package fiducial
+
+import (
+	"image"
+	"math"
+
+	"github.com/golang/geo/r2"
+	"github.com/pkg/errors"
+	"gonum.org/v1/gonum/mat"
+
+	"go.viam.com/rdk/rimage/transform"
+	"go.viam.com/rdk/spatialmath"
+)
+
+// minCellPixels is the smallest size in pixels of a bit of a marker that is still read.
+const minCellPixels = 3
+
+// Marker is a marker found in an image.
+type Marker struct {
+	ID int
+	// Corners are the top left, top right, bottom right and bottom left corners of the marker, as
+	// it is drawn in its dictionary, in pixels.
+	Corners [4]r2.Point
+}
+
+// BoundingBox returns the smallest rectangle of pixels that contains the marker.
+func (m *Marker) BoundingBox() image.Rectangle {
+	minPt, maxPt := m.Corners[0], m.Corners[0]
+	for _, c := range m.Corners[1:] {
+		minPt = r2.Point{X: math.Min(minPt.X, c.X), Y: math.Min(minPt.Y, c.Y)}
+		maxPt = r2.Point{X: math.Max(maxPt.X, c.X), Y: math.Max(maxPt.Y, c.Y)}
+	}
+	return image.Rect(int(math.Floor(minPt.X)), int(math.Floor(minPt.Y)), int(math.Ceil(maxPt.X))+1, int(math.Ceil(maxPt.Y))+1)
+}
+
+// MarkerPoints returns the corners of a marker of the given size in mm, in the same order as the
+// corners of a Marker, in the frame of the marker. The frame is centered on the marker, with x to
+// its right, y to its top, and z coming out of its face.
+func MarkerPoints(sizeMM float64) []r2.Point {
+	half := sizeMM / 2
+	return []r2.Point{{X: -half, Y: half}, {X: half, Y: half}, {X: half, Y: -half}, {X: -half, Y: -half}}
+}
+
+// Pose estimates the pose of a marker of the given size in mm in the frame of the camera the image
+// it was found in came from. The pose takes points from the marker's frame, as MarkerPoints
+// defines it, to the camera's frame. The distortion may be nil.
+func (m *Marker) Pose(sizeMM float64, intrinsics *transform.PinholeCameraIntrinsics, distortion transform.Distorter,
+) (spatialmath.Pose, error) {
+	if sizeMM <= 0 {
+		return nil, errors.New("size of marker must be positive")
+	}
+	pose, _, err := transform.EstimatePlanarPose(intrinsics, distortion, MarkerPoints(sizeMM), m.Corners[:])
+	return pose, err
+}
+
+// Detect finds the markers of a dictionary in an image.
+func Detect(img image.Image, dict *Dictionary) []*Marker {
+	g := toGray(img)
+	cells := dict.BitsPerSide + 2
+	minSide := float64(minCellPixels * cells)
+	var markers []*Marker
+	for _, boundary := range blobBoundaries(darkPixels(g), g.width, g.height, int(4*minSide)) {
+		quad, ok := fitQuad(boundary, minSide)
+		if !ok {
+			continue
+		}
+		if refined, ok := refineQuad(g, quad); ok {
+			quad = refined
+		}
+		if marker, ok := decode(g, quad, dict); ok {
+			markers = append(markers, marker)
+		}
+	}
+	return markers
+}
+
+// decode reads the bits inside a quad, and matches them to a marker of the dictionary.
+func decode(g *grayImage, quad [4]r2.Point, dict *Dictionary) (*Marker, bool) {
+	cells := float64(dict.BitsPerSide + 2)
+	h, ok := squareHomography(cells, quad)
+	if !ok {
+		return nil, false
+	}
+	// average a few samples around the middle of a cell, in units of cells
+	sampleCell := func(x, y float64) float64 {
+		sum := 0.
+		for _, dy := range []float64{-0.2, 0, 0.2} {
+			for _, dx := range []float64{-0.2, 0, 0.2} {
+				sum += g.sample(h(x+dx, y+dy))
+			}
+		}
+		return sum / 9
+	}
+
+	// the border is black, and there has to be white around it
+	black, white := 0., 0.
+	numBorder := 0
+	var border []float64
+	for i := 0.; i < cells; i++ {
+		for _, c := range [][2]float64{{i, 0}, {i, cells - 1}, {0, i}, {cells - 1, i}} {
+			v := sampleCell(c[0]+0.5, c[1]+0.5)
+			border = append(border, v)
+			black += v
+			numBorder++
+		}
+		white += g.sample(h(i+0.5, -0.25)) + g.sample(h(i+0.5, cells+0.25)) +
+			g.sample(h(-0.25, i+0.5)) + g.sample(h(cells+0.25, i+0.5))
+	}
+	black /= float64(numBorder)
+	white /= 4 * cells
+	if white-black < minContrast {
+		return nil, false
+	}
+	threshold := (black + white) / 2
+	for _, v := range border {
+		if v >= threshold {
+			return nil, false
+		}
+	}
+
+	var code uint64
+	for row := 1; row <= dict.BitsPerSide; row++ {
+		for col := 1; col <= dict.BitsPerSide; col++ {
+			code <<= 1
+			if sampleCell(float64(col)+0.5, float64(row)+0.5) >= threshold {
+				code |= 1
+			}
+		}
+	}
+	id, turns, ok := dict.match(code)
+	if !ok {
+		return nil, false
+	}
+	// the bits had to be turned clockwise to match, so the marker is turned counter clockwise in the
+	// image and its top left corner is that many corners back along the quad
+	marker := &Marker{ID: id}
+	for i := range marker.Corners {
+		marker.Corners[i] = quad[(i+4-turns)%4]
+	}
+	return marker, true
+}
+
+// squareHomography returns the projection of a square of the given size, with its corners at
+// (0, 0), (size, 0), (size, size) and (0, size), onto a quad.
+func squareHomography(size float64, quad [4]r2.Point) (func(x, y float64) r2.Point, bool) {
+	square := [4]r2.Point{{X: 0, Y: 0}, {X: size, Y: 0}, {X: size, Y: size}, {X: 0, Y: size}}
+	a := mat.NewDense(8, 8, nil)
+	b := mat.NewVecDense(8, nil)
+	for i := range square {
+		x, y, u, v := square[i].X, square[i].Y, quad[i].X, quad[i].Y
+		a.SetRow(2*i, []float64{x, y, 1, 0, 0, 0, -u * x, -u * y})
+		a.SetRow(2*i+1, []float64{0, 0, 0, x, y, 1, -v * x, -v * y})
+		b.SetVec(2*i, u)
+		b.SetVec(2*i+1, v)
+	}
+	var hv mat.VecDense
+	if err := hv.SolveVec(a, b); err != nil {
+		return nil, false
+	}
+	h := hv.RawVector().Data
+	return func(x, y float64) r2.Point {
+		w := h[6]*x + h[7]*y + 1
+		return r2.Point{X: (h[0]*x + h[1]*y + h[2]) / w, Y: (h[3]*x + h[4]*y + h[5]) / w}
+	}, true
+}
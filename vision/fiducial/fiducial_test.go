@@ -0,0 +1,176 @@
+package fiducial
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/golang/geo/r2"
+	"github.com/golang/geo/r3"
+	"go.viam.com/test"
+
+	"go.viam.com/rdk/rimage/transform"
+	"go.viam.com/rdk/spatialmath"
+)
+
+var testIntrinsics = &transform.PinholeCameraIntrinsics{
+	Width:  640,
+	Height: 480,
+	Fx:     600,
+	Fy:     600,
+	Ppx:    320,
+	Ppy:    240,
+}
+
+// testMarker is a marker of a dictionary placed in front of a camera.
+type testMarker struct {
+	code   uint64
+	sizeMM float64
+	pose   spatialmath.Pose
+}
+
+// shade is the intensity of a point of the marker's plane, or false if it is not near the marker.
+func (tm *testMarker) shade(p r3.Vector, bitsPerSide int) (float64, bool) {
+	cells := bitsPerSide + 2
+	cellMM := tm.sizeMM / float64(cells)
+	col := int(math.Floor((p.X + tm.sizeMM/2) / cellMM))
+	row := int(math.Floor((tm.sizeMM/2 - p.Y) / cellMM))
+	switch {
+	case col < -1 || row < -1 || col > cells || row > cells:
+		return 0, false
+	case col == -1 || row == -1 || col == cells || row == cells:
+		return 235, true
+	case col == 0 || row == 0 || col == cells-1 || row == cells-1:
+		return 20, true
+	}
+	bit := tm.code >> (bitsPerSide*bitsPerSide - 1 - ((row-1)*bitsPerSide + col - 1)) & 1
+	return 20 + 215*float64(bit), true
+}
+
+// renderMarkers draws markers, each with a white margin one bit wide, as a camera without distortion
+// sees them.
+func renderMarkers(bitsPerSide int, markers ...testMarker) *image.Gray {
+	// the camera center and the camera's axes, in the frame of each marker
+	type frame struct{ center, x, y, z r3.Vector }
+	frames := make([]frame, len(markers))
+	for i, tm := range markers {
+		toMarker := spatialmath.PoseInverse(tm.pose)
+		moved := func(p r3.Vector) r3.Vector {
+			return spatialmath.Compose(toMarker, spatialmath.NewPoseFromPoint(p)).Point()
+		}
+		center := moved(r3.Vector{})
+		frames[i] = frame{center, moved(r3.Vector{X: 1}).Sub(center), moved(r3.Vector{Y: 1}).Sub(center), moved(r3.Vector{Z: 1}).Sub(center)}
+	}
+	shade := func(u, v float64) float64 {
+		for i, tm := range markers {
+			f := frames[i]
+			dir := f.x.Mul((u - testIntrinsics.Ppx) / testIntrinsics.Fx).Add(f.y.Mul((v - testIntrinsics.Ppy) / testIntrinsics.Fy)).Add(f.z)
+			s := -f.center.Z / dir.Z
+			if s <= 0 {
+				continue
+			}
+			if value, ok := tm.shade(f.center.Add(dir.Mul(s)), bitsPerSide); ok {
+				return value
+			}
+		}
+		return 128
+	}
+	img := image.NewGray(image.Rect(0, 0, testIntrinsics.Width, testIntrinsics.Height))
+	for y := 0; y < testIntrinsics.Height; y++ {
+		for x := 0; x < testIntrinsics.Width; x++ {
+			// supersample to get edges with sub-pixel positions
+			sum := 0.
+			for _, dy := range []float64{-3. / 8, -1. / 8, 1. / 8, 3. / 8} {
+				for _, dx := range []float64{-3. / 8, -1. / 8, 1. / 8, 3. / 8} {
+					sum += shade(float64(x)+dx, float64(y)+dy)
+				}
+			}
+			img.SetGray(x, y, color.Gray{uint8(math.Round(sum / 16))})
+		}
+	}
+	return img
+}
+
+// projectMarker returns the pixels the corners of a marker project to.
+func projectMarker(tm testMarker) []r2.Point {
+	var pixels []r2.Point
+	for _, corner := range MarkerPoints(tm.sizeMM) {
+		p := spatialmath.Compose(tm.pose, spatialmath.NewPoseFromPoint(r3.Vector{X: corner.X, Y: corner.Y})).Point()
+		pixels = append(pixels, r2.Point{
+			X: testIntrinsics.Fx*p.X/p.Z + testIntrinsics.Ppx,
+			Y: testIntrinsics.Fy*p.Y/p.Z + testIntrinsics.Ppy,
+		})
+	}
+	return pixels
+}
+
+// facingCamera returns the pose of a marker at a point in front of the camera, turned by an axis
+// angle away from facing it upright.
+func facingCamera(point r3.Vector, turn *spatialmath.R4AA) spatialmath.Pose {
+	// the marker's y axis is up and its z axis towards the camera
+	facing := spatialmath.NewPoseFromOrientation(&spatialmath.R4AA{Theta: math.Pi, RX: 1})
+	return spatialmath.Compose(spatialmath.NewPoseFromPoint(point), spatialmath.Compose(facing, spatialmath.NewPoseFromOrientation(turn)))
+}
+
+func TestDetectArucoMarkers(t *testing.T) {
+	dict, err := DictionaryByName(ArucoOriginal)
+	test.That(t, err, test.ShouldBeNil)
+
+	for _, tc := range []struct {
+		id   int
+		pose spatialmath.Pose
+	}{
+		{7, facingCamera(r3.Vector{Z: 500}, &spatialmath.R4AA{RZ: 1})},
+		{300, facingCamera(r3.Vector{X: 60, Y: -40, Z: 600}, &spatialmath.R4AA{Theta: math.Pi / 2, RZ: 1})},
+		{1000, facingCamera(r3.Vector{X: -50, Z: 450}, &spatialmath.R4AA{Theta: math.Pi, RZ: 1})},
+		{514, facingCamera(r3.Vector{Y: 30, Z: 550}, &spatialmath.R4AA{Theta: 0.6, RX: 1, RY: 0.5, RZ: -1.5})},
+	} {
+		tm := testMarker{code: dict.Codes[tc.id], sizeMM: 100, pose: tc.pose}
+		markers := Detect(renderMarkers(dict.BitsPerSide, tm), dict)
+		test.That(t, markers, test.ShouldHaveLength, 1)
+		test.That(t, markers[0].ID, test.ShouldEqual, tc.id)
+		for i, expected := range projectMarker(tm) {
+			test.That(t, markers[0].Corners[i].Sub(expected).Norm(), test.ShouldBeLessThan, 0.3)
+		}
+		bounds := markers[0].BoundingBox()
+		for _, c := range markers[0].Corners {
+			test.That(t, image.Pt(int(c.X), int(c.Y)).In(bounds), test.ShouldBeTrue)
+		}
+
+		pose, err := markers[0].Pose(tm.sizeMM, testIntrinsics, nil)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, pose.Point().Distance(tc.pose.Point()), test.ShouldBeLessThan, 3)
+		test.That(t, spatialmath.OrientationAlmostEqualEps(pose.Orientation(), tc.pose.Orientation(), 0.02), test.ShouldBeTrue)
+	}
+}
+
+func TestDetectSeveralMarkers(t *testing.T) {
+	dict := &Dictionary{
+		BitsPerSide:       4,
+		Codes:             []uint64{0b1011_0010_1110_0001, 0b0100_0111_1001_1100},
+		MaxCorrectionBits: 1,
+	}
+	left := testMarker{code: dict.Codes[0], sizeMM: 80, pose: facingCamera(r3.Vector{X: -100, Z: 500}, &spatialmath.R4AA{RZ: 1})}
+	// a bit of the right marker is wrong, which is corrected
+	right := testMarker{
+		code:   dict.Codes[1] ^ 0b0000_0000_0010_0000,
+		sizeMM: 80,
+		pose:   facingCamera(r3.Vector{X: 100, Z: 500}, &spatialmath.R4AA{Theta: -0.3, RZ: 1}),
+	}
+	// not in the dictionary
+	other := testMarker{code: 0b1111_0000_1111_0000, sizeMM: 80, pose: facingCamera(r3.Vector{Y: -120, Z: 500}, &spatialmath.R4AA{RZ: 1})}
+	markers := Detect(renderMarkers(dict.BitsPerSide, left, right, other), dict)
+	test.That(t, markers, test.ShouldHaveLength, 2)
+	ids := map[int]r2.Point{}
+	for _, m := range markers {
+		ids[m.ID] = m.Corners[0]
+	}
+	test.That(t, ids[0].X, test.ShouldBeLessThan, 320)
+	test.That(t, ids[1].X, test.ShouldBeGreaterThan, 320)
+
+	test.That(t, Detect(image.NewGray(image.Rect(0, 0, 100, 100)), dict), test.ShouldBeEmpty)
+
+	_, err := markers[0].Pose(0, testIntrinsics, nil)
+	test.That(t, err, test.ShouldNotBeNil)
+}
@@ -0,0 +1,330 @@
+package fiducial
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"sort"
+
+	"github.com/golang/geo/r2"
+)
+
+const (
+	// tileSize is the size in pixels of the tiles the range of intensities is found in to threshold
+	// an image.
+	tileSize = 4
+	// minContrast is the smallest difference in intensity between black and white that is looked at.
+	minContrast = 20
+	// maxHullToQuadArea is how much bigger the convex hull of a dark blob can be than the quad fit to
+	// it, for the blob to still be taken as a quad.
+	maxHullToQuadArea = 1.1
+)
+
+// grayImage holds the intensities of an image, with the centers of pixels at whole coordinates.
+type grayImage struct {
+	width, height int
+	pix           []float64
+}
+
+func toGray(img image.Image) *grayImage {
+	bounds := img.Bounds()
+	g := &grayImage{width: bounds.Dx(), height: bounds.Dy(), pix: make([]float64, bounds.Dx()*bounds.Dy())}
+	for y := 0; y < g.height; y++ {
+		for x := 0; x < g.width; x++ {
+			g.pix[y*g.width+x] = float64(color.GrayModel.Convert(img.At(bounds.Min.X+x, bounds.Min.Y+y)).(color.Gray).Y)
+		}
+	}
+	return g
+}
+
+// sample interpolates the intensity at a point, clamping it to the image.
+func (g *grayImage) sample(p r2.Point) float64 {
+	x := math.Max(0, math.Min(float64(g.width-1), p.X))
+	y := math.Max(0, math.Min(float64(g.height-1), p.Y))
+	x0, y0 := int(x), int(y)
+	x1, y1 := x0+1, y0+1
+	if x1 >= g.width {
+		x1 = x0
+	}
+	if y1 >= g.height {
+		y1 = y0
+	}
+	fx, fy := x-float64(x0), y-float64(y0)
+	top := g.pix[y0*g.width+x0]*(1-fx) + g.pix[y0*g.width+x1]*fx
+	bottom := g.pix[y1*g.width+x0]*(1-fx) + g.pix[y1*g.width+x1]*fx
+	return top*(1-fy) + bottom*fy
+}
+
+// darkPixels thresholds an image at the middle of the range of intensities around each pixel.
+// Pixels in areas without enough contrast are neither dark nor light, so large flat areas do not
+// join up with the edges of markers.
+func darkPixels(g *grayImage) []bool {
+	tw, th := (g.width+tileSize-1)/tileSize, (g.height+tileSize-1)/tileSize
+	tileMin, tileMax := make([]float64, tw*th), make([]float64, tw*th)
+	for i := range tileMin {
+		tileMin[i], tileMax[i] = math.Inf(1), math.Inf(-1)
+	}
+	for y := 0; y < g.height; y++ {
+		for x := 0; x < g.width; x++ {
+			v, t := g.pix[y*g.width+x], (y/tileSize)*tw+x/tileSize
+			tileMin[t], tileMax[t] = math.Min(tileMin[t], v), math.Max(tileMax[t], v)
+		}
+	}
+	// the range of each tile and its neighbors, so that edges on the border of a tile are seen
+	lows, highs := make([]float64, tw*th), make([]float64, tw*th)
+	for ty := 0; ty < th; ty++ {
+		for tx := 0; tx < tw; tx++ {
+			low, high := math.Inf(1), math.Inf(-1)
+			for ny := ty - 1; ny <= ty+1; ny++ {
+				for nx := tx - 1; nx <= tx+1; nx++ {
+					if nx < 0 || ny < 0 || nx >= tw || ny >= th {
+						continue
+					}
+					low, high = math.Min(low, tileMin[ny*tw+nx]), math.Max(high, tileMax[ny*tw+nx])
+				}
+			}
+			lows[ty*tw+tx], highs[ty*tw+tx] = low, high
+		}
+	}
+	dark := make([]bool, g.width*g.height)
+	for y := 0; y < g.height; y++ {
+		for x := 0; x < g.width; x++ {
+			t := (y/tileSize)*tw + x/tileSize
+			if highs[t]-lows[t] < minContrast {
+				continue
+			}
+			dark[y*g.width+x] = g.pix[y*g.width+x] < (lows[t]+highs[t])/2
+		}
+	}
+	return dark
+}
+
+// blobBoundaries finds the 4-connected blobs of dark pixels that do not touch the edge of the image,
+// and returns the pixels on the boundary of each blob with at least minBoundary of them.
+func blobBoundaries(dark []bool, width, height, minBoundary int) [][]r2.Point {
+	visited := make([]bool, len(dark))
+	var blobs [][]r2.Point
+	var stack []int
+	neighbors := func(i int) []int {
+		x, y := i%width, i/width
+		n := make([]int, 0, 4)
+		if x > 0 {
+			n = append(n, i-1)
+		}
+		if x < width-1 {
+			n = append(n, i+1)
+		}
+		if y > 0 {
+			n = append(n, i-width)
+		}
+		if y < height-1 {
+			n = append(n, i+width)
+		}
+		return n
+	}
+	for start := range dark {
+		if !dark[start] || visited[start] {
+			continue
+		}
+		visited[start] = true
+		stack = append(stack[:0], start)
+		var boundary []r2.Point
+		touchesEdge := false
+		for len(stack) > 0 {
+			i := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			ns := neighbors(i)
+			if len(ns) < 4 {
+				touchesEdge = true
+			}
+			onBoundary := false
+			for _, n := range ns {
+				if !dark[n] {
+					onBoundary = true
+					continue
+				}
+				if !visited[n] {
+					visited[n] = true
+					stack = append(stack, n)
+				}
+			}
+			if onBoundary {
+				boundary = append(boundary, r2.Point{X: float64(i % width), Y: float64(i / width)})
+			}
+		}
+		if !touchesEdge && len(boundary) >= minBoundary {
+			blobs = append(blobs, boundary)
+		}
+	}
+	return blobs
+}
+
+// convexHull returns the convex hull of points, clockwise in image coordinates.
+func convexHull(points []r2.Point) []r2.Point {
+	sorted := append([]r2.Point{}, points...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].X != sorted[j].X {
+			return sorted[i].X < sorted[j].X
+		}
+		return sorted[i].Y < sorted[j].Y
+	})
+	hull := make([]r2.Point, 0, 2*len(sorted))
+	for pass := 0; pass < 2; pass++ {
+		start := len(hull)
+		for _, p := range sorted {
+			for len(hull) >= start+2 && hull[len(hull)-1].Sub(hull[len(hull)-2]).Cross(p.Sub(hull[len(hull)-2])) <= 0 {
+				hull = hull[:len(hull)-1]
+			}
+			hull = append(hull, p)
+		}
+		// the last point is the first one of the other half
+		hull = hull[:len(hull)-1]
+		for i, j := 0, len(sorted)-1; i < j; i, j = i+1, j-1 {
+			sorted[i], sorted[j] = sorted[j], sorted[i]
+		}
+	}
+	return hull
+}
+
+// polygonArea is the area of a polygon, positive when it is clockwise in image coordinates.
+func polygonArea(points []r2.Point) float64 {
+	area := 0.
+	for i, p := range points {
+		area += p.Cross(points[(i+1)%len(points)])
+	}
+	return area / 2
+}
+
+// fitQuad finds the quad that a blob is the outline of, with its corners clockwise in image
+// coordinates, or returns false if the blob is not close enough to a quad.
+func fitQuad(boundary []r2.Point, minSide float64) ([4]r2.Point, bool) {
+	var quad [4]r2.Point
+	hull := convexHull(boundary)
+	if len(hull) < 4 {
+		return quad, false
+	}
+	var mean r2.Point
+	for _, p := range hull {
+		mean = mean.Add(p)
+	}
+	mean = mean.Mul(1 / float64(len(hull)))
+	farthest := func(from r2.Point) r2.Point {
+		best := hull[0]
+		for _, p := range hull {
+			if p.Sub(from).Norm() > best.Sub(from).Norm() {
+				best = p
+			}
+		}
+		return best
+	}
+	// the two corners farthest apart make a diagonal, and the other two are the farthest from it on
+	// either side
+	quad[0] = farthest(mean)
+	quad[2] = farthest(quad[0])
+	diagonal := quad[2].Sub(quad[0])
+	if diagonal.Norm() < minSide {
+		return quad, false
+	}
+	low, high := 0., 0.
+	for _, p := range hull {
+		side := diagonal.Cross(p.Sub(quad[0])) / diagonal.Norm()
+		if side < low {
+			low, quad[1] = side, p
+		}
+		if side > high {
+			high, quad[3] = side, p
+		}
+	}
+	if -low < minSide/2 || high < minSide/2 {
+		return quad, false
+	}
+	for i := range quad {
+		if quad[i].Sub(quad[(i+1)%4]).Norm() < minSide {
+			return quad, false
+		}
+	}
+	quadArea := polygonArea(quad[:])
+	if quadArea < 0 {
+		quad[1], quad[3] = quad[3], quad[1]
+		quadArea = -quadArea
+	}
+	if math.Abs(polygonArea(hull)) > maxHullToQuadArea*quadArea {
+		return quad, false
+	}
+	return quad, true
+}
+
+// refineQuad moves the corners of a quad, which is dark inside and light outside, to where the
+// lines that best fit the edges of its sides in the image meet.
+func refineQuad(g *grayImage, quad [4]r2.Point) ([4]r2.Point, bool) {
+	var lines [4][2]r2.Point
+	for i := range quad {
+		start, end := quad[i], quad[(i+1)%4]
+		length := end.Sub(start).Norm()
+		along := end.Sub(start).Mul(1 / length)
+		outward := r2.Point{X: along.Y, Y: -along.X}
+		reach := math.Max(2, length/20)
+
+		var edge []r2.Point
+		// stay away from the corners, where the edges of other sides are
+		for s := 0.15 * length; s <= 0.85*length; s++ {
+			p := start.Add(along.Mul(s))
+			// the edge is where the intensity crosses halfway between the inside and the outside
+			inside, outside := g.sample(p.Add(outward.Mul(-reach))), g.sample(p.Add(outward.Mul(reach)))
+			if outside-inside < minContrast {
+				continue
+			}
+			middle := (inside + outside) / 2
+			previous := inside
+			for offset := -reach + 0.25; offset <= reach; offset += 0.25 {
+				v := g.sample(p.Add(outward.Mul(offset)))
+				if v >= middle {
+					crossing := offset - 0.25*(v-middle)/(v-previous)
+					edge = append(edge, p.Add(outward.Mul(crossing)))
+					break
+				}
+				previous = v
+			}
+		}
+		if len(edge) < 3 {
+			return quad, false
+		}
+		lines[i] = fitLine(edge)
+	}
+	var refined [4]r2.Point
+	for i := range refined {
+		corner, ok := intersect(lines[(i+3)%4], lines[i])
+		if !ok || corner.Sub(quad[i]).Norm() > 0.1*quad[i].Sub(quad[(i+1)%4]).Norm()+2 {
+			return quad, false
+		}
+		refined[i] = corner
+	}
+	return refined, true
+}
+
+// fitLine returns a point on the line that best fits points, and the direction of the line.
+func fitLine(points []r2.Point) [2]r2.Point {
+	var mean r2.Point
+	for _, p := range points {
+		mean = mean.Add(p)
+	}
+	mean = mean.Mul(1 / float64(len(points)))
+	var xx, xy, yy float64
+	for _, p := range points {
+		d := p.Sub(mean)
+		xx, xy, yy = xx+d.X*d.X, xy+d.X*d.Y, yy+d.Y*d.Y
+	}
+	// the direction of the largest spread of the points
+	angle := math.Atan2(2*xy, xx-yy) / 2
+	return [2]r2.Point{mean, {X: math.Cos(angle), Y: math.Sin(angle)}}
+}
+
+// intersect finds where two lines, each given as a point and a direction, meet.
+func intersect(a, b [2]r2.Point) (r2.Point, bool) {
+	denom := a[1].Cross(b[1])
+	if math.Abs(denom) < 1e-9 {
+		return r2.Point{}, false
+	}
+	s := b[0].Sub(a[0]).Cross(b[1]) / denom
+	return a[0].Add(a[1].Mul(s)), true
+}
@@ -0,0 +1,85 @@
+package objecttracking
+
+import (
+	"math"
+
+	"go.viam.com/rdk/utils"
+)
+
+// assign pairs up rows and columns of a cost matrix so that the total cost of the pairs is as small
+// as possible, with the Hungarian algorithm. It returns the column of each row, or -1 for rows left
+// without one when there are more rows than columns.
+func assign(cost [][]float64) []int {
+	rows := len(cost)
+	if rows == 0 {
+		return nil
+	}
+	cols := len(cost[0])
+	// the algorithm needs at least as many columns as rows, so pad the matrix to a square
+	n := utils.MaxInt(rows, cols)
+	at := func(r, c int) float64 {
+		if r < rows && c < cols {
+			return cost[r][c]
+		}
+		return 0
+	}
+
+	// potentials of the rows and columns, and the row matched to each column, all one based so that
+	// column 0 can stand for the row being added
+	u, v := make([]float64, n+1), make([]float64, n+1)
+	match := make([]int, n+1)
+	way := make([]int, n+1)
+	for r := 1; r <= n; r++ {
+		match[0] = r
+		col := 0
+		minSlack := make([]float64, n+1)
+		used := make([]bool, n+1)
+		for c := range minSlack {
+			minSlack[c] = math.Inf(1)
+		}
+		for {
+			used[col] = true
+			row, delta, next := match[col], math.Inf(1), 0
+			for c := 1; c <= n; c++ {
+				if used[c] {
+					continue
+				}
+				if slack := at(row-1, c-1) - u[row] - v[c]; slack < minSlack[c] {
+					minSlack[c], way[c] = slack, col
+				}
+				if minSlack[c] < delta {
+					delta, next = minSlack[c], c
+				}
+			}
+			for c := 0; c <= n; c++ {
+				if used[c] {
+					u[match[c]] += delta
+					v[c] -= delta
+				} else {
+					minSlack[c] -= delta
+				}
+			}
+			col = next
+			if match[col] == 0 {
+				break
+			}
+		}
+		// flip the matches along the augmenting path
+		for col != 0 {
+			prev := way[col]
+			match[col] = match[prev]
+			col = prev
+		}
+	}
+
+	assignment := make([]int, rows)
+	for r := range assignment {
+		assignment[r] = -1
+	}
+	for c := 1; c <= n; c++ {
+		if r := match[c] - 1; r >= 0 && r < rows && c-1 < cols {
+			assignment[r] = c - 1
+		}
+	}
+	return assignment
+}
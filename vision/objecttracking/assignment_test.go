@@ -0,0 +1,34 @@
+package objecttracking
+
+import (
+	"testing"
+
+	"go.viam.com/test"
+)
+
+func TestAssign(t *testing.T) {
+	test.That(t, assign(nil), test.ShouldBeEmpty)
+
+	// the cheapest pair of each row is not the best assignment overall
+	cost := [][]float64{
+		{4, 1, 3},
+		{2, 0, 5},
+		{3, 2, 2},
+	}
+	test.That(t, assign(cost), test.ShouldResemble, []int{1, 0, 2})
+
+	// more columns than rows
+	cost = [][]float64{
+		{9, 2, 7, 8},
+		{6, 4, 3, 7},
+	}
+	test.That(t, assign(cost), test.ShouldResemble, []int{1, 2})
+
+	// more rows than columns leaves the rows that would cost most without a column
+	cost = [][]float64{
+		{1, 9},
+		{9, 9},
+		{9, 1},
+	}
+	test.That(t, assign(cost), test.ShouldResemble, []int{0, -1, 1})
+}
@@ -0,0 +1,114 @@
+package objecttracking
+
+import (
+	"image"
+	"math"
+
+	"github.com/golang/geo/r2"
+
+	"go.viam.com/rdk/utils"
+)
+
+const (
+	// measurementNoise is the standard deviation of the edges of detected boxes, as a fraction of
+	// the size of the box.
+	measurementNoise = 0.05
+	// accelerationNoise is the standard deviation of how fast the velocity of a box changes, in
+	// sizes of the box per second squared.
+	accelerationNoise = 1.0
+	// initialVelocityNoise is the standard deviation of the velocity of a new box, in sizes of the
+	// box per second, so that the first detections after it decide its velocity.
+	initialVelocityNoise = 10.0
+)
+
+// constantVelocity is a Kalman filter of a value that changes at a steady rate.
+type constantVelocity struct {
+	value, rate float64
+	// the covariance of the value and the rate
+	valueVar, covar, rateVar float64
+}
+
+func newConstantVelocity(value, valueStdDev, rateStdDev float64) constantVelocity {
+	return constantVelocity{value: value, valueVar: valueStdDev * valueStdDev, rateVar: rateStdDev * rateStdDev}
+}
+
+// predict moves the value ahead by dt seconds, with the rate changing randomly with the given
+// standard deviation per second.
+func (kf *constantVelocity) predict(dt, accelStdDev float64) {
+	if dt <= 0 {
+		return
+	}
+	q := accelStdDev * accelStdDev
+	kf.value += kf.rate * dt
+	kf.valueVar += 2*dt*kf.covar + dt*dt*kf.rateVar + q*dt*dt*dt*dt/4
+	kf.covar += dt*kf.rateVar + q*dt*dt*dt/2
+	kf.rateVar += q * dt * dt
+}
+
+// update corrects the value with a measurement of it.
+func (kf *constantVelocity) update(measurement, stdDev float64) {
+	innovationVar := kf.valueVar + stdDev*stdDev
+	valueGain, rateGain := kf.valueVar/innovationVar, kf.covar/innovationVar
+	innovation := measurement - kf.value
+	kf.value += valueGain * innovation
+	kf.rate += rateGain * innovation
+	kf.rateVar -= rateGain * kf.covar
+	kf.covar -= valueGain * kf.covar
+	kf.valueVar -= valueGain * kf.valueVar
+}
+
+// boxFilter follows the center and size of a box that moves and grows steadily.
+type boxFilter struct {
+	centerX, centerY, width, height constantVelocity
+}
+
+func newBoxFilter(box image.Rectangle) *boxFilter {
+	size := boxSize(box)
+	posStdDev, rateStdDev := measurementNoise*size, initialVelocityNoise*size
+	return &boxFilter{
+		centerX: newConstantVelocity(float64(box.Min.X+box.Max.X)/2, posStdDev, rateStdDev),
+		centerY: newConstantVelocity(float64(box.Min.Y+box.Max.Y)/2, posStdDev, rateStdDev),
+		width:   newConstantVelocity(float64(box.Dx()), posStdDev, rateStdDev),
+		height:  newConstantVelocity(float64(box.Dy()), posStdDev, rateStdDev),
+	}
+}
+
+func (bf *boxFilter) predict(dt float64) {
+	accel := accelerationNoise * math.Max(bf.width.value, bf.height.value)
+	for _, kf := range bf.values() {
+		kf.predict(dt, accel)
+	}
+	// keep the box from shrinking to nothing while it coasts
+	bf.width.value, bf.height.value = math.Max(bf.width.value, 1), math.Max(bf.height.value, 1)
+}
+
+func (bf *boxFilter) update(box image.Rectangle) {
+	stdDev := measurementNoise * boxSize(box)
+	bf.centerX.update(float64(box.Min.X+box.Max.X)/2, stdDev)
+	bf.centerY.update(float64(box.Min.Y+box.Max.Y)/2, stdDev)
+	bf.width.update(float64(box.Dx()), stdDev)
+	bf.height.update(float64(box.Dy()), stdDev)
+}
+
+func (bf *boxFilter) values() []*constantVelocity {
+	return []*constantVelocity{&bf.centerX, &bf.centerY, &bf.width, &bf.height}
+}
+
+// box returns the box the filter estimates.
+func (bf *boxFilter) box() image.Rectangle {
+	halfW, halfH := math.Max(bf.width.value, 1)/2, math.Max(bf.height.value, 1)/2
+	return image.Rect(
+		int(math.Round(bf.centerX.value-halfW)), int(math.Round(bf.centerY.value-halfH)),
+		int(math.Round(bf.centerX.value+halfW)), int(math.Round(bf.centerY.value+halfH)),
+	)
+}
+
+// velocity returns how fast the center of the box moves, in pixels per second.
+func (bf *boxFilter) velocity() r2.Point {
+	return r2.Point{X: bf.centerX.rate, Y: bf.centerY.rate}
+}
+
+// boxSize is the larger side of a box, and at least one pixel.
+func boxSize(box image.Rectangle) float64 {
+	return math.Max(1, float64(utils.MaxInt(box.Dx(), box.Dy())))
+}
@@ -0,0 +1,254 @@
+// Package objecttracking follows the detections of objects across the frames of a video, giving
+// every object an id that stays the same while it is in view.
+package objecttracking
+
+import (
+	"image"
+	"time"
+
+	"github.com/golang/geo/r2"
+
+	"go.viam.com/rdk/vision/objectdetection"
+)
+
+const (
+	defaultMinIoU    = 0.3
+	defaultMaxMissed = 5
+	defaultMinHits   = 3
+)
+
+// Options are the parameters of a Tracker. Zero values are replaced by defaults.
+type Options struct {
+	// MinIoU is the smallest intersection over union that a detection can have with where a track
+	// is predicted to be, to be part of that track.
+	MinIoU float64
+	// MaxMissed is how many frames in a row a track can go without a detection before it is dropped.
+	MaxMissed int
+	// MinHits is how many frames an object has to be detected in before it becomes a track, so that
+	// spurious detections do not.
+	MinHits int
+	// Regions are the areas of the image that tracks entering and exiting make events for.
+	Regions []Region
+}
+
+// Region is a named area of an image.
+type Region struct {
+	Name   string
+	Bounds image.Rectangle
+}
+
+// Track is an object that has been detected in several frames.
+type Track struct {
+	ID    int
+	Label string
+	// Score is the score of the latest detection of the object.
+	Score       float64
+	BoundingBox image.Rectangle
+	// Velocity is how fast the center of the bounding box moves, in pixels per second.
+	Velocity r2.Point
+	// Missed is how many of the latest frames the object was not detected in. While it is missed,
+	// its bounding box is where it is predicted to be.
+	Missed    int
+	FirstSeen time.Time
+	LastSeen  time.Time
+}
+
+// EventType is what happened in an Event.
+type EventType string
+
+// The types of events.
+const (
+	EventEnter = EventType("enter")
+	EventExit  = EventType("exit")
+)
+
+// Event is a track entering or exiting a region. A track exits the regions it is in when it is
+// dropped.
+type Event struct {
+	Type    EventType
+	Region  string
+	TrackID int
+	Label   string
+	Time    time.Time
+}
+
+// track is a Track along with the state it is followed with. Until it is confirmed it has no id.
+type track struct {
+	Track
+	hits    int
+	filter  *boxFilter
+	regions map[string]bool
+}
+
+// Tracker matches the detections of each frame to the tracks of the earlier frames, in the way of
+// SORT (Bewley et al., "Simple Online and Realtime Tracking", 2016). Each track is followed by a
+// Kalman filter of its bounding box, and detections are matched to where the tracks are predicted
+// to be by their intersection over union. Only detections with the same label are matched.
+// A Tracker is not safe to use from several goroutines at once.
+type Tracker struct {
+	opts     Options
+	tracks   []*track
+	nextID   int
+	lastTime time.Time
+}
+
+// NewTracker returns a tracker with no tracks.
+func NewTracker(opts Options) *Tracker {
+	if opts.MinIoU <= 0 {
+		opts.MinIoU = defaultMinIoU
+	}
+	if opts.MaxMissed <= 0 {
+		opts.MaxMissed = defaultMaxMissed
+	}
+	if opts.MinHits <= 0 {
+		opts.MinHits = defaultMinHits
+	}
+	return &Tracker{opts: opts, nextID: 1}
+}
+
+// Update adds the detections of a frame taken at the given time, and returns the events that it
+// caused.
+func (t *Tracker) Update(detections []objectdetection.Detection, at time.Time) []Event {
+	dt := 0.
+	if !t.lastTime.IsZero() {
+		dt = at.Sub(t.lastTime).Seconds()
+	}
+	t.lastTime = at
+	for _, tr := range t.tracks {
+		tr.filter.predict(dt)
+		tr.BoundingBox = tr.filter.box()
+	}
+
+	matchedDetections := make([]bool, len(detections))
+	matchedTracks := make([]bool, len(t.tracks))
+	if len(t.tracks) > 0 && len(detections) > 0 {
+		cost := make([][]float64, len(t.tracks))
+		for i, tr := range t.tracks {
+			cost[i] = make([]float64, len(detections))
+			for j, d := range detections {
+				cost[i][j] = 1 - IoU(tr.BoundingBox, *d.BoundingBox())
+				if d.Label() != tr.Label {
+					// more than any pair of the same label
+					cost[i][j] = 2
+				}
+			}
+		}
+		for i, j := range assign(cost) {
+			if j < 0 || cost[i][j] > 1-t.opts.MinIoU {
+				continue
+			}
+			matchedTracks[i], matchedDetections[j] = true, true
+			t.tracks[i].addDetection(detections[j], at)
+		}
+	}
+
+	var events []Event
+	kept := t.tracks[:0]
+	for i, tr := range t.tracks {
+		if !matchedTracks[i] {
+			tr.Missed++
+			// tracks that are not confirmed yet have to be detected in every frame
+			if tr.ID == 0 || tr.Missed > t.opts.MaxMissed {
+				events = append(events, t.exitAll(tr, at)...)
+				continue
+			}
+		}
+		kept = append(kept, tr)
+	}
+	t.tracks = kept
+	for j, d := range detections {
+		if !matchedDetections[j] {
+			t.tracks = append(t.tracks, newTrack(d, at))
+		}
+	}
+
+	for _, tr := range t.tracks {
+		if tr.ID == 0 && tr.hits >= t.opts.MinHits {
+			tr.ID = t.nextID
+			t.nextID++
+		}
+		if tr.ID != 0 && tr.Missed == 0 {
+			events = append(events, t.regionEvents(tr, at)...)
+		}
+	}
+	return events
+}
+
+// Tracks returns the tracks being followed.
+func (t *Tracker) Tracks() []Track {
+	tracks := make([]Track, 0, len(t.tracks))
+	for _, tr := range t.tracks {
+		if tr.ID != 0 {
+			tracks = append(tracks, tr.Track)
+		}
+	}
+	return tracks
+}
+
+func newTrack(d objectdetection.Detection, at time.Time) *track {
+	box := *d.BoundingBox()
+	return &track{
+		Track: Track{
+			Label:       d.Label(),
+			Score:       d.Score(),
+			BoundingBox: box,
+			FirstSeen:   at,
+			LastSeen:    at,
+		},
+		hits:    1,
+		filter:  newBoxFilter(box),
+		regions: map[string]bool{},
+	}
+}
+
+func (tr *track) addDetection(d objectdetection.Detection, at time.Time) {
+	tr.filter.update(*d.BoundingBox())
+	tr.hits++
+	tr.Missed = 0
+	tr.Score = d.Score()
+	tr.BoundingBox = tr.filter.box()
+	tr.Velocity = tr.filter.velocity()
+	tr.LastSeen = at
+}
+
+// regionEvents updates which regions the center of a track is in, and returns the events for the
+// ones it entered or exited.
+func (t *Tracker) regionEvents(tr *track, at time.Time) []Event {
+	var events []Event
+	center := image.Pt((tr.BoundingBox.Min.X+tr.BoundingBox.Max.X)/2, (tr.BoundingBox.Min.Y+tr.BoundingBox.Max.Y)/2)
+	for _, r := range t.opts.Regions {
+		inside := center.In(r.Bounds)
+		if inside == tr.regions[r.Name] {
+			continue
+		}
+		tr.regions[r.Name] = inside
+		eventType := EventExit
+		if inside {
+			eventType = EventEnter
+		}
+		events = append(events, Event{Type: eventType, Region: r.Name, TrackID: tr.ID, Label: tr.Label, Time: at})
+	}
+	return events
+}
+
+// exitAll returns the events for a dropped track exiting the regions it was in.
+func (t *Tracker) exitAll(tr *track, at time.Time) []Event {
+	var events []Event
+	for _, r := range t.opts.Regions {
+		if tr.regions[r.Name] {
+			events = append(events, Event{Type: EventExit, Region: r.Name, TrackID: tr.ID, Label: tr.Label, Time: at})
+		}
+	}
+	return events
+}
+
+// IoU returns the intersection over union of two boxes.
+func IoU(a, b image.Rectangle) float64 {
+	inter := a.Intersect(b)
+	interArea := float64(inter.Dx() * inter.Dy())
+	union := float64(a.Dx()*a.Dy()+b.Dx()*b.Dy()) - interArea
+	if union <= 0 {
+		return 0
+	}
+	return interArea / union
+}
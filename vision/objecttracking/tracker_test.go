@@ -0,0 +1,170 @@
+package objecttracking
+
+import (
+	"image"
+	"testing"
+	"time"
+
+	"go.viam.com/test"
+
+	"go.viam.com/rdk/vision/objectdetection"
+)
+
+// frameTime is the time of a frame of a video at 10 frames per second.
+func frameTime(frame int) time.Time {
+	return time.Unix(1000, 0).Add(time.Duration(frame) * 100 * time.Millisecond)
+}
+
+// movingBox is a 40x30 box that starts at x and moves 5 pixels to the right every frame.
+func movingBox(x, y, frame int) image.Rectangle {
+	return image.Rect(x+5*frame, y, x+5*frame+40, y+30)
+}
+
+func TestIoU(t *testing.T) {
+	a := image.Rect(0, 0, 10, 10)
+	test.That(t, IoU(a, a), test.ShouldEqual, 1)
+	test.That(t, IoU(a, image.Rect(5, 0, 15, 10)), test.ShouldAlmostEqual, 50./150)
+	test.That(t, IoU(a, image.Rect(20, 20, 30, 30)), test.ShouldEqual, 0)
+	test.That(t, IoU(image.Rectangle{}, image.Rectangle{}), test.ShouldEqual, 0)
+}
+
+func TestTrackerFollowsObjects(t *testing.T) {
+	tracker := NewTracker(Options{})
+	// the id each object gets when it becomes a track
+	ids := map[string]int{}
+	object := func(tr Track) string {
+		switch {
+		case tr.Label == "person":
+			return "person"
+		case tr.BoundingBox.Min.Y < 200:
+			return "top part"
+		default:
+			return "bottom part"
+		}
+	}
+	for frame := 0; frame < 20; frame++ {
+		detections := []objectdetection.Detection{
+			objectdetection.NewDetection(movingBox(10, 100, frame), 0.9, "part"),
+			objectdetection.NewDetection(movingBox(200, 300, frame), 0.8, "part"),
+			objectdetection.NewDetection(image.Rect(500, 20, 540, 60), 0.7, "person"),
+		}
+		// a spurious detection that does not last long enough to become a track
+		if frame == 4 {
+			detections = append(detections, objectdetection.NewDetection(image.Rect(300, 50, 320, 70), 0.5, "part"))
+		}
+		tracker.Update(detections, frameTime(frame))
+
+		tracks := tracker.Tracks()
+		if frame < defaultMinHits-1 {
+			test.That(t, tracks, test.ShouldBeEmpty)
+			continue
+		}
+		test.That(t, tracks, test.ShouldHaveLength, 3)
+		for _, tr := range tracks {
+			if _, ok := ids[object(tr)]; !ok {
+				ids[object(tr)] = tr.ID
+			}
+			test.That(t, tr.ID, test.ShouldEqual, ids[object(tr)])
+			test.That(t, tr.FirstSeen, test.ShouldEqual, frameTime(0))
+			test.That(t, tr.LastSeen, test.ShouldEqual, frameTime(frame))
+		}
+		test.That(t, ids, test.ShouldHaveLength, 3)
+		if frame < 10 {
+			continue
+		}
+		// the moving ones are found to move at 50 pixels a second
+		for _, tr := range tracks {
+			switch object(tr) {
+			case "person":
+				test.That(t, tr.Velocity.Norm(), test.ShouldBeLessThan, 1)
+			case "top part":
+				test.That(t, tr.Velocity.X, test.ShouldAlmostEqual, 50, 2)
+				test.That(t, tr.Velocity.Y, test.ShouldAlmostEqual, 0, 2)
+				test.That(t, IoU(tr.BoundingBox, movingBox(10, 100, frame)), test.ShouldBeGreaterThan, 0.9)
+			default:
+				test.That(t, tr.Velocity.X, test.ShouldAlmostEqual, 50, 2)
+			}
+		}
+	}
+	for _, id := range ids {
+		test.That(t, id, test.ShouldBeBetweenOrEqual, 1, 3)
+	}
+}
+
+func TestTrackerCoastsThroughMissedFrames(t *testing.T) {
+	tracker := NewTracker(Options{MaxMissed: 3})
+	var id int
+	for frame := 0; frame < 20; frame++ {
+		var detections []objectdetection.Detection
+		// the detector misses the object for a few frames
+		if frame < 8 || frame > 10 {
+			detections = append(detections, objectdetection.NewDetection(movingBox(10, 100, frame), 0.9, "part"))
+		}
+		tracker.Update(detections, frameTime(frame))
+		tracks := tracker.Tracks()
+		if frame < defaultMinHits-1 {
+			continue
+		}
+		test.That(t, tracks, test.ShouldHaveLength, 1)
+		if id == 0 {
+			id = tracks[0].ID
+		}
+		test.That(t, tracks[0].ID, test.ShouldEqual, id)
+		if frame == 10 {
+			test.That(t, tracks[0].Missed, test.ShouldEqual, 3)
+			// it is predicted to keep moving
+			test.That(t, IoU(tracks[0].BoundingBox, movingBox(10, 100, frame)), test.ShouldBeGreaterThan, 0.7)
+		}
+	}
+
+	// once it is gone for too long it is dropped, and coming back makes a new track
+	for frame := 20; frame < 24; frame++ {
+		tracker.Update(nil, frameTime(frame))
+	}
+	test.That(t, tracker.Tracks(), test.ShouldBeEmpty)
+	for frame := 24; frame < 27; frame++ {
+		tracker.Update([]objectdetection.Detection{objectdetection.NewDetection(movingBox(10, 100, frame), 0.9, "part")}, frameTime(frame))
+	}
+	tracks := tracker.Tracks()
+	test.That(t, tracks, test.ShouldHaveLength, 1)
+	test.That(t, tracks[0].ID, test.ShouldEqual, id+1)
+}
+
+func TestTrackerRegionEvents(t *testing.T) {
+	tracker := NewTracker(Options{
+		MaxMissed: 2,
+		Regions:   []Region{{Name: "exit_gate", Bounds: image.Rect(100, 0, 150, 480)}},
+	})
+	var events []Event
+	for frame := 0; frame < 40; frame++ {
+		var detections []objectdetection.Detection
+		if frame < 35 {
+			detections = append(detections, objectdetection.NewDetection(movingBox(0, 100, frame), 0.9, "part"))
+		}
+		// this one stops inside the gate and then goes out of view
+		if frame < 30 {
+			x := 5 * frame
+			if x > 100 {
+				x = 100
+			}
+			detections = append(detections, objectdetection.NewDetection(image.Rect(x, 300, x+40, 330), 0.9, "part"))
+		}
+		events = append(events, tracker.Update(detections, frameTime(frame))...)
+	}
+
+	test.That(t, events, test.ShouldHaveLength, 4)
+	// the centers of both enter the gate at x=100 on frame 16
+	test.That(t, events[0].Type, test.ShouldEqual, EventEnter)
+	test.That(t, events[1].Type, test.ShouldEqual, EventEnter)
+	test.That(t, events[0].Region, test.ShouldEqual, "exit_gate")
+	test.That(t, events[0].Time, test.ShouldEqual, frameTime(16))
+	test.That(t, events[0].TrackID, test.ShouldNotEqual, events[1].TrackID)
+	// the moving one leaves the gate at x=150 on frame 26
+	test.That(t, events[2].Type, test.ShouldEqual, EventExit)
+	test.That(t, events[2].Time, test.ShouldEqual, frameTime(26))
+	// the stopped one is dropped 2 frames after it is last seen, which makes it exit
+	test.That(t, events[3].Type, test.ShouldEqual, EventExit)
+	test.That(t, events[3].Time, test.ShouldEqual, frameTime(32))
+	test.That(t, events[3].TrackID, test.ShouldNotEqual, events[2].TrackID)
+	test.That(t, events[3].Label, test.ShouldEqual, "part")
+}